@@ -21,6 +21,7 @@ import (
 	"github.com/Azure/agentbaker/aks-node-controller/helpers"
 	"github.com/Azure/agentbaker/aks-node-controller/parser"
 	"github.com/Azure/agentbaker/aks-node-controller/pkg/gpu"
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/msiauth"
 	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
 	"github.com/fsnotify/fsnotify"
 	"github.com/urfave/cli/v3"
@@ -74,6 +75,10 @@ type App struct {
 	// grpcDialContext overrides how the gRPC LPS client dials, letting tests point the client at
 	// an in-process (bufconn) server. When nil, the real TLS dial to the apiserver front is used.
 	grpcDialContext func(ctx context.Context, target string) (net.Conn, error)
+	// msiTokenClient overrides the managed identity token client used by get-credential for testing.
+	msiTokenClient *msiauth.Client
+	// enabledFeaturesPath overrides the default enabled-features file read by the features command for testing.
+	enabledFeaturesPath string
 }
 
 // provision.json values are emitted as strings by the shell jq invocation.
@@ -153,6 +158,28 @@ func (a *App) Run(ctx context.Context, args []string) int {
 					return nil
 				},
 			},
+			{
+				Name:  "features",
+				Usage: "Print the effective feature toggles on the node",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "json", Usage: "print the features as JSON"},
+				},
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					return a.runFeaturesCommand(cmd.Root().Writer, cmd.Bool("json"))
+				},
+			},
+			{
+				Name:  "get-credential",
+				Usage: "Print a client-go ExecCredential holding the managed identity token used by the kubelet",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "auth-method", Usage: "managed identity to use: azure-msi or arc-msi", Required: true},
+					&cli.StringFlag{Name: "resource", Usage: "resource the token is requested for", Value: msiauth.AKSAADServerAppID},
+					&cli.StringFlag{Name: "client-id", Usage: "client ID of the user-assigned identity, azure-msi only"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return a.runGetCredentialCommand(ctx, cmd.Root().Writer, cmd.String("auth-method"), cmd.String("resource"), cmd.String("client-id"))
+				},
+			},
 			{
 				Name:  "download-hotfix",
 				Usage: "Download the requested hotfix binary",
@@ -221,6 +248,25 @@ func (a *App) runProvisionWaitCommand(ctx context.Context, provisionStatusFiles
 	return provisionOutput, err
 }
 
+// runGetCredentialCommand is invoked by client-go as the exec credential plugin of the MSI bootstrapping kubeconfig.
+// Only the ExecCredential is written to out, logs go to stderr.
+func (a *App) runGetCredentialCommand(ctx context.Context, out io.Writer, authMethod, resource, clientID string) error {
+	method, err := msiauth.ParseMethod(authMethod)
+	if err != nil {
+		return err
+	}
+	client := a.msiTokenClient
+	if client == nil {
+		client = &msiauth.Client{}
+	}
+	token, err := client.GetToken(ctx, method, resource, clientID)
+	if err != nil {
+		slog.Error("failed to get managed identity token", "authMethod", method, "error", err)
+		return fmt.Errorf("get %s token: %w", method, err)
+	}
+	return json.NewEncoder(out).Encode(msiauth.NewExecCredential(token))
+}
+
 func (a *App) runDownloadHotfixCommand(ctx context.Context) error {
 	slog.Info("aks-node-controller hotfix download started")
 	err := a.downloadHotfix(ctx)
@@ -259,28 +305,18 @@ func buildCmdFromProvisionConfig(ctx context.Context, path string, gpuComponents
 		return nil, fmt.Errorf("open provision file %s: %w", path, err)
 	}
 
-	config, err := nodeconfigutils.UnmarshalConfigurationV1(inputJSON)
+	// Unknown fields are tolerated so a newer generator can talk to an older aks-node-controller, they are reported
+	// so settings this version ignores are visible. Older schema versions are migrated to the current one.
+	config, report, err := nodeconfigutils.LoadConfiguration(inputJSON)
+	if len(report.UnknownFields) > 0 {
+		slog.Warn("AKSNodeConfig has fields unknown to this aks-node-controller version, they are ignored",
+			"version", Version, "fields", strings.Join(report.UnknownFields, ","))
+	}
 	if err != nil {
-		// We try our best to continue unmarshal even if there are unexpected situations such as unknown fields.
-		// It usually happens when a newer version of aksNodeConfig is being parsed by an older version of aks-node-controller.
-		// This allows older versions of aks-node-controller to read configurations that may have fields added in newer versions.
-		// Log the error and continue processing.
-		// Feature owner should be aware that any unrecognized fields will be ignored in older versions of VHD image.
-
-		slog.Info("Unmarshalling aksNodeConfigv1 encounters error but the process will continue."+
-			"This may be due to version mismatch. "+
-			"Usually it is newer aksNodeConfig being parsed by older aks-node-controller. "+
-			"Continuing with partial configuration, but unrecognized fields will be ignored.",
-			"error", err)
-	}
-	// TODO: "v0" were a mistake. We are not going to have different logic maintaining both v0 and v1
-	// Disallow "v0" after some time (allow some time to update consumers)
-	if config.Version != "v0" && config.Version != "v1" {
-		return nil, fmt.Errorf("unsupported version: %s", config.Version)
-	}
-
-	if config.Version == "v0" {
-		slog.Error("v0 version is deprecated, please use v1 instead")
+		return nil, err
+	}
+	if len(report.Migrations) > 0 {
+		slog.Info("migrated AKSNodeConfig to the current schema", "from", report.Version, "migrations", strings.Join(report.Migrations, ","))
 	}
 
 	gpuConfig, err := loadGPUConfig(gpuComponentsFilePath)
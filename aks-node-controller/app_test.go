@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,6 +18,7 @@ import (
 	"time"
 
 	"github.com/Azure/agentbaker/aks-node-controller/helpers"
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/msiauth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -230,6 +234,26 @@ func TestApp_Run(t *testing.T) {
 	})
 }
 
+func TestApp_GetCredential(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token": "token", "expires_on": "1700000000"}`))
+	}))
+	defer server.Close()
+
+	tt := NewTestApp(t, TestAppConfig{})
+	tt.App.msiTokenClient = &msiauth.Client{HTTPClient: server.Client(), AzureTokenURL: server.URL}
+	var out bytes.Buffer
+	require.NoError(t, tt.App.runGetCredentialCommand(context.Background(), &out, "azure-msi", msiauth.AKSAADServerAppID, ""))
+	assert.JSONEq(t, `{
+		"kind": "ExecCredential",
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"status": {"token": "token", "expirationTimestamp": "2023-11-14T22:13:20Z"}
+	}`, out.String())
+
+	exitCode := tt.App.Run(context.Background(), []string{"aks-node-controller", "get-credential", "--auth-method", "unknown"})
+	assert.Equal(t, 1, exitCode)
+}
+
 func TestApp_Provision(t *testing.T) {
 	t.Run("valid provision config", func(t *testing.T) {
 		tt := NewTestApp(t, TestAppConfig{})
@@ -346,6 +370,27 @@ func TestApp_Provision(t *testing.T) {
 	})
 }
 
+func TestBuildCmdFromProvisionConfig_Versions(t *testing.T) {
+	original, err := os.ReadFile("parser/testdata/test_aksnodeconfig.json")
+	require.NoError(t, err)
+	writeConfig := func(t *testing.T, version string) string {
+		path := filepath.Join(t.TempDir(), "config.json")
+		contents := strings.Replace(string(original), `"version": "v1"`, `"version": "`+version+`", "future_field": true`, 1)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	t.Run("v0 is migrated", func(t *testing.T) {
+		_, err := buildCmdFromProvisionConfig(context.Background(), writeConfig(t, "v0"), writeTestGPUComponentsFile(t))
+		require.NoError(t, err)
+	})
+
+	t.Run("unsupported versions are rejected", func(t *testing.T) {
+		_, err := buildCmdFromProvisionConfig(context.Background(), writeConfig(t, "v2"), writeTestGPUComponentsFile(t))
+		require.EqualError(t, err, "unsupported version: v2")
+	})
+}
+
 func TestApp_Provision_DryRun(t *testing.T) {
 	tt := NewTestApp(t, TestAppConfig{})
 	tt.App.cmdRun = cmdRunner // Use real cmdRunner to test dry-run override
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
+	"github.com/Azure/agentbaker/pkg/agent/enabledfeatures"
+)
+
+// runFeaturesCommand prints the effective feature toggles on the node: the toggles delivered in the enabled-features
+// file, which the launcher exports before provisioning, merged with the registry defaults. Delivered toggles which
+// are unknown, invalid or not honored by this binary are reported with the problem.
+func (a *App) runFeaturesCommand(out io.Writer, asJSON bool) error {
+	path := a.enabledFeaturesPath
+	if path == "" {
+		path = nodeconfigutils.EnabledFeaturesFilePath
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	// a missing file is the default-off case, every feature has its default value.
+	states := enabledfeatures.Embedded().Effective(enabledfeatures.ParseFile(contents), Version)
+
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(states)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tVALUE\tSOURCE\tPROBLEM")
+	for _, s := range states {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Value, s.Source, s.Problem)
+	}
+	return w.Flush()
+}
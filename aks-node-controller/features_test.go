@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Features(t *testing.T) {
+	origVersion := Version
+	Version = "202605.30.0"
+	defer func() { Version = origVersion }()
+
+	t.Run("reports the defaults when the features file is absent", func(t *testing.T) {
+		tt := NewTestApp(t, TestAppConfig{})
+		tt.App.enabledFeaturesPath = filepath.Join(t.TempDir(), "enabled_features.sh")
+
+		var out bytes.Buffer
+		require.NoError(t, tt.App.runFeaturesCommand(&out, false))
+		assert.Equal(t, "NAME                        VALUE  SOURCE   PROBLEM\n"+
+			"ENABLE_PROVISIONING_HOTFIX  false  default  \n", out.String())
+	})
+
+	t.Run("reports delivered and unknown toggles as JSON", func(t *testing.T) {
+		tt := NewTestApp(t, TestAppConfig{})
+		tt.App.enabledFeaturesPath = filepath.Join(t.TempDir(), "enabled_features.sh")
+		require.NoError(t, os.WriteFile(tt.App.enabledFeaturesPath, []byte("ENABLE_PROVISIONING_HOTFIX=true\nZED_FEATURE=1\n"), 0o600))
+
+		var out bytes.Buffer
+		require.NoError(t, tt.App.runFeaturesCommand(&out, true))
+		assert.JSONEq(t, `[
+			{
+				"name": "ENABLE_PROVISIONING_HOTFIX",
+				"value": "true",
+				"source": "delivered",
+				"description": "Run check-hotfix before provisioning to refresh the aks-node-controller hotfix pointer from the live-patching-service."
+			},
+			{"name": "ZED_FEATURE", "value": "1", "source": "delivered", "problem": "unknown feature"}
+		]`, out.String())
+	})
+
+	t.Run("features command returns success exit code", func(t *testing.T) {
+		tt := NewTestApp(t, TestAppConfig{})
+		tt.App.enabledFeaturesPath = filepath.Join(t.TempDir(), "enabled_features.sh")
+		exitCode := tt.App.Run(context.Background(), []string{"aks-node-controller", "features", "--json"})
+		assert.Equal(t, 0, exitCode)
+	})
+}
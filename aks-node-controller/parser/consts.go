@@ -27,22 +27,15 @@ const (
 	dhcpV6ServiceCSEScriptFilepath       = "/etc/systemd/system/dhcpv6.service"
 	dhcpV6ConfigCSEScriptFilepath        = "/opt/azure/containers/enable-dhcpv6.sh"
 	initAKSCloudFilepath                 = "/opt/azure/containers/init-aks-cloud.sh"
+	// vhdInstallManifestFilepath lists the components installed in the VHD at build time.
+	vhdInstallManifestFilepath = "/opt/azure/vhd-install.complete"
 )
 
 const (
-	maxLBRuleCountDefault               = 148
-	ipLocalReservedPorts                = 65330
-	MinArgs                             = 2
-	defaultNetIpv4TcpRetries2           = 8
-	defaultNetCoreMessageBurst          = 80
-	defaultNetCoreMessageCost           = 40
-	defaultNetCoreSomaxconn             = 16384
-	defaultNetIpv4TcpMaxSynBacklog      = 16384
-	defaultNetIpv4NeighDefaultGcThresh1 = 4096
-	defaultNetIpv4NeighDefaultGcThresh2 = 8192
-	defaultNetIpv4NeighDefaultGcThresh3 = 16384
-	maxCSETimeout                       = 21600
-	defaultCSETimeout                   = 900
+	maxLBRuleCountDefault = 148
+	MinArgs               = 2
+	maxCSETimeout         = 21600
+	defaultCSETimeout     = 900
 )
 
 const (
@@ -59,3 +52,10 @@ const (
 	// defaultLocalDnsMemoryLimitInMb specifies the default Memory limit used in akslocaldns.
 	defaultLocalDnsMemoryLimitInMb string = "128M"
 )
+
+// kubeconfig references of the MSI bootstrapping methods.
+const (
+	kubeCACertFilepath        = "/etc/kubernetes/certs/ca.crt"
+	aksNodeControllerFilepath = "/opt/azure/containers/aks-node-controller"
+	msiKubeconfigUser         = "kubelet-msi"
+)
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/pkg/agent/containerdconfig"
+)
+
+const (
+	runcRuntimeType                     = "io.containerd.runc.v2"
+	kataRuntimeType                     = "io.containerd.kata.v2"
+	runcBinaryPath                      = "/usr/bin/runc"
+	nvidiaContainerRuntimeName          = "nvidia-container-runtime"
+	nvidiaContainerRuntimeBinary        = "/usr/bin/nvidia-container-runtime"
+	kataPreviewConfigPath               = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
+	kataCCConfigPath                    = "/opt/confidential-containers/share/defaults/kata-containers/configuration-clh-snp.toml"
+	containerdMetricsAddress            = "0.0.0.0:10257"
+	containerdOOMScore                  = -999
+	kubenetContainerdConfTemplate       = "/etc/containerd/kubenet_template.conf"
+	artifactStreamingSnapshotter        = "overlaybd"
+	artifactStreamingSnapshotterAddress = "/run/overlaybd-snapshotter/overlaybd.sock"
+	tardevSnapshotterAddress            = "/run/containerd/tardev-snapshotter.sock"
+)
+
+// buildContainerdConfig returns the containerd config generated by AKS, before the AKSNodeConfig overlay is applied.
+// Containerd 2.x splits the CRI plugin (io.containerd.grpc.v1.cri) into io.containerd.cri.v1.images and io.containerd.cri.v1.runtime.
+func buildContainerdConfig(aksnodeconfig *aksnodeconfigv1.Configuration, noGPU bool, containerdVersion string) *containerdconfig.Config {
+	v2 := isContainerdV2(containerdVersion)
+	config := &containerdconfig.Config{
+		Version:  2,
+		OOMScore: containerdOOMScore,
+		Root:     aksnodeconfig.GetKubeletConfig().GetContainerDataDir(),
+		Plugins:  map[string]any{},
+		Metrics:  &containerdconfig.Metrics{Address: containerdMetricsAddress},
+	}
+
+	registry := &containerdconfig.RegistryConfig{Headers: map[string][]string{"X-Meta-Source-Client": {"azure/aks"}}}
+	if IsKubernetesVersionGe(aksnodeconfig.GetKubernetesVersion(), "1.22.0") {
+		registry.ConfigPath = containerdconfig.RegistryConfigPath
+	}
+	var cni *containerdconfig.CNIConfig
+	if getEnsureNoDupePromiscuousBridge(aksnodeconfig.GetNetworkConfig()) {
+		cni = &containerdconfig.CNIConfig{BinDir: "/opt/cni/bin", ConfDir: "/etc/cni/net.d", ConfTemplate: kubenetContainerdConfTemplate}
+	}
+	criContainerd := &containerdconfig.CRIContainerdConfig{}
+	criContainerd.DefaultRuntimeName, criContainerd.Runtimes = defaultContainerdRuntimes(getEnableNvidia(aksnodeconfig) && !noGPU,
+		v2 || aksnodeconfig.GetNeedsCgroupv2())
+
+	// snapshotter and disable_snapshot_annotations are CRI images plugin settings with containerd 2.x.
+	var snapshotter string
+	var disableSnapshotAnnotations *bool
+	disabled := false
+	if aksnodeconfig.GetIsKata() && !v2 {
+		snapshotter, disableSnapshotAnnotations = "overlayfs", &disabled
+	}
+	if aksnodeconfig.GetEnableArtifactStreaming() {
+		snapshotter, disableSnapshotAnnotations = artifactStreamingSnapshotter, &disabled
+		config.ProxyPlugins = map[string]*containerdconfig.ProxyPlugin{
+			artifactStreamingSnapshotter: {Type: "snapshot", Address: artifactStreamingSnapshotterAddress},
+		}
+	}
+
+	sandboxImage := aksnodeconfig.GetKubeBinaryConfig().GetPodInfraContainerImageUrl()
+	if v2 {
+		images := &containerdconfig.CRIImagesConfig{
+			Snapshotter:                snapshotter,
+			DisableSnapshotAnnotations: disableSnapshotAnnotations,
+			Registry:                   registry,
+		}
+		if sandboxImage != "" {
+			images.PinnedImages = &containerdconfig.PinnedImages{Sandbox: sandboxImage}
+		}
+		config.Plugins[containerdconfig.CRIImagesPluginID] = images
+		config.Plugins[containerdconfig.CRIRuntimePluginID] = &containerdconfig.CRIRuntimeConfig{Containerd: criContainerd, CNI: cni}
+	} else {
+		criContainerd.Snapshotter, criContainerd.DisableSnapshotAnnotations = snapshotter, disableSnapshotAnnotations
+		config.Plugins[containerdconfig.CRIPluginID] = &containerdconfig.CRIConfig{
+			SandboxImage: sandboxImage,
+			EnableCDI:    !noGPU,
+			Containerd:   criContainerd,
+			CNI:          cni,
+			Registry:     registry,
+		}
+	}
+
+	if aksnodeconfig.GetIsKata() {
+		addKataContainerdConfig(config, criContainerd, noGPU, v2)
+	}
+	return config
+}
+
+// defaultContainerdRuntimes returns the default runtime handler and the runtime handlers of every node.
+func defaultContainerdRuntimes(enableNvidia, systemdCgroup bool) (string, map[string]*containerdconfig.Runtime) {
+	name, binary := "runc", runcBinaryPath
+	if enableNvidia {
+		name, binary = nvidiaContainerRuntimeName, nvidiaContainerRuntimeBinary
+	}
+	defaultOptions := map[string]any{"BinaryName": binary}
+	if systemdCgroup {
+		defaultOptions["SystemdCgroup"] = true
+	}
+	return name, map[string]*containerdconfig.Runtime{
+		name:        {RuntimeType: runcRuntimeType, Options: defaultOptions},
+		"untrusted": {RuntimeType: runcRuntimeType, Options: map[string]any{"BinaryName": binary}},
+	}
+}
+
+// addKataContainerdConfig adds the erofs snapshotter and the kata runtime handlers of Kata Containers nodes.
+// The kata runtime handlers are still configured in the containerd 1.x CRI plugin with containerd 2.x.
+func addKataContainerdConfig(config *containerdconfig.Config, criContainerd *containerdconfig.CRIContainerdConfig, noGPU, v2 bool) {
+	config.Plugins[containerdconfig.SnapshotterPluginIDPrefix+"erofs"] = map[string]any{
+		"default_size":      "10G",
+		"enable_fsverity":   false,
+		"ovl_mount_options": []string{},
+	}
+	config.Plugins["io.containerd.service.v1.diff-service"] = map[string]any{"default": []string{"erofs", "walking"}}
+	config.Plugins["io.containerd.differ.v1.erofs"] = map[string]any{
+		"mkfs_options":     []string{"-T0", "--mkfs-time", "--sort=none"},
+		"enable_tar_index": false,
+	}
+	if config.ProxyPlugins == nil {
+		config.ProxyPlugins = map[string]*containerdconfig.ProxyPlugin{}
+	}
+	config.ProxyPlugins["tardev"] = &containerdconfig.ProxyPlugin{Type: "snapshot", Address: tardevSnapshotterAddress}
+
+	runtimes := criContainerd.Runtimes
+	if v2 {
+		runtimes = map[string]*containerdconfig.Runtime{}
+		config.Plugins[containerdconfig.CRIPluginID] = &containerdconfig.CRIConfig{
+			Containerd: &containerdconfig.CRIContainerdConfig{Runtimes: runtimes},
+		}
+		runtimes["kata"] = &containerdconfig.Runtime{
+			RuntimeType:                  kataRuntimeType,
+			Snapshotter:                  "overlayfs",
+			PrivilegedWithoutHostDevices: true,
+			Options:                      map[string]any{"ConfigPath": "/usr/share/defaults/kata-containers/configuration.toml"},
+		}
+	} else {
+		runtimes["kata"] = &containerdconfig.Runtime{RuntimeType: kataRuntimeType, Snapshotter: "overlayfs"}
+		runtimes["katacli"] = &containerdconfig.Runtime{
+			RuntimeType: "io.containerd.runc.v1",
+			Options: map[string]any{
+				"NoPivotRoot":   false,
+				"NoNewKeyring":  false,
+				"ShimCgroup":    "",
+				"IoUid":         0,
+				"IoGid":         0,
+				"BinaryName":    "/usr/bin/kata-runtime",
+				"Root":          "",
+				"CriuPath":      "",
+				"SystemdCgroup": false,
+			},
+		}
+	}
+	runtimes["kata-preview"] = &containerdconfig.Runtime{
+		RuntimeType:                  kataRuntimeType,
+		Snapshotter:                  "erofs",
+		PrivilegedWithoutHostDevices: true,
+		Options:                      map[string]any{"ConfigPath": kataPreviewConfigPath},
+	}
+	// Confidential containers are not configured on containerd 2.x nodes without GPU.
+	if !v2 || !noGPU {
+		runtimes["kata-cc"] = &containerdconfig.Runtime{
+			RuntimeType:                  "io.containerd.kata-cc.v2",
+			Snapshotter:                  "tardev",
+			PrivilegedWithoutHostDevices: true,
+			PodAnnotations:               []string{"io.katacontainers.*"},
+			Options:                      map[string]any{"ConfigPath": kataCCConfigPath},
+		}
+	}
+}
+
+// getContainerdConfigOverlay converts the AKSNodeConfig containerd overlay, option values are converted to TOML booleans and integers.
+func getContainerdConfigOverlay(containerdConfig *aksnodeconfigv1.ContainerdConfig) *containerdconfig.Overlay {
+	overlay := containerdConfig.GetOverlay()
+	if overlay == nil {
+		return nil
+	}
+	result := &containerdconfig.Overlay{}
+	for host, mirror := range overlay.GetRegistryMirrors() {
+		if result.RegistryMirrors == nil {
+			result.RegistryMirrors = map[string]*containerdconfig.RegistryMirror{}
+		}
+		result.RegistryMirrors[host] = &containerdconfig.RegistryMirror{
+			Endpoints:    mirror.GetEndpoints(),
+			Capabilities: mirror.GetCapabilities(),
+			SkipVerify:   mirror.GetSkipVerify(),
+			OverridePath: mirror.GetOverridePath(),
+		}
+	}
+	for name, handler := range overlay.GetRuntimeHandlers() {
+		if result.RuntimeHandlers == nil {
+			result.RuntimeHandlers = map[string]*containerdconfig.Runtime{}
+		}
+		result.RuntimeHandlers[name] = &containerdconfig.Runtime{
+			RuntimeType:                  handler.GetRuntimeType(),
+			Snapshotter:                  handler.GetSnapshotter(),
+			PrivilegedWithoutHostDevices: handler.GetPrivilegedWithoutHostDevices(),
+			PodAnnotations:               handler.GetPodAnnotations(),
+			Options:                      parseContainerdOptions(handler.GetOptions()),
+		}
+	}
+	for name, snapshotter := range overlay.GetSnapshotterOptions() {
+		if result.SnapshotterOptions == nil {
+			result.SnapshotterOptions = map[string]map[string]any{}
+		}
+		result.SnapshotterOptions[name] = parseContainerdOptions(snapshotter.GetOptions())
+	}
+	return result
+}
+
+func parseContainerdOptions(options map[string]string) map[string]any {
+	if len(options) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(options))
+	for key, value := range options {
+		result[key] = containerdconfig.ParseOptionValue(value)
+	}
+	return result
+}
+
+// getContainerdRegistryHostsContent returns the hosts.toml files of the AKSNodeConfig registry mirrors, base64 encoded.
+// Each line holds a registry host and the base64 encoded content of its hosts.toml file, separated by a space.
+func getContainerdRegistryHostsContent(config *aksnodeconfigv1.Configuration) (string, error) {
+	files, err := getContainerdConfigOverlay(config.GetContainerdConfig()).HostsFiles()
+	if err != nil || len(files) == 0 {
+		return "", err
+	}
+	lines := make([]string, 0, len(files))
+	for host, content := range files {
+		lines = append(lines, fmt.Sprintf("%s %s\n", host, base64.StdEncoding.EncodeToString(content)))
+	}
+	sort.Strings(lines)
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(lines, ""))), nil
+}
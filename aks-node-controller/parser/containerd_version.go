@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+)
+
+// ContainerdVersionSource names where the containerd version selecting the containerd config comes from.
+type ContainerdVersionSource string
+
+const (
+	// ContainerdVersionSourceAKSNodeConfig is AKSNodeConfig.containerd_config.containerd_version.
+	ContainerdVersionSourceAKSNodeConfig ContainerdVersionSource = "aksnodeconfig"
+	// ContainerdVersionSourceVHDManifest is the list of components installed in the VHD, see vhdInstallManifestFilepath.
+	ContainerdVersionSourceVHDManifest ContainerdVersionSource = "vhd_manifest"
+	// ContainerdVersionSourceBinary is the Go build info embedded in the containerd binary.
+	ContainerdVersionSourceBinary ContainerdVersionSource = "binary"
+	// ContainerdVersionSourceNone means the version is unknown, a containerd 1.x config is generated.
+	ContainerdVersionSourceNone ContainerdVersionSource = "none"
+)
+
+// ldflagsVersionPattern matches the version set at link time by the containerd build, e.g.
+// -X github.com/containerd/containerd/v2/version.Version=2.0.0.
+var ldflagsVersionPattern = regexp.MustCompile(`/version\.Version=([^\s'"]+)`)
+
+// containerdVersions resolves the version of the containerd installed on the node without running it.
+//
+//nolint:gochecknoglobals // replaced in tests, which must not depend on the containerd of the host.
+var containerdVersions = &containerdVersionResolver{
+	vhdManifestPath: vhdInstallManifestFilepath,
+	binaryName:      "containerd",
+	binaryPaths:     []string{"/usr/bin/containerd", "/usr/local/bin/containerd"},
+}
+
+// ContainerdVersion is a containerd major.minor.patch version and where it comes from.
+type ContainerdVersion struct {
+	Version string
+	Source  ContainerdVersionSource
+}
+
+// containerdVersionResolver looks the containerd version up in the AKSNodeConfig, the VHD manifest, then the containerd binary.
+type containerdVersionResolver struct {
+	// vhdManifestPath lists the components installed in the VHD, e.g. "  - containerd version 1.7.27-1".
+	vhdManifestPath string
+	// binaryName is looked up on PATH, then binaryPaths are searched for the containerd binary,
+	// as it might not be on PATH yet when the CSE runs.
+	binaryName  string
+	binaryPaths []string
+}
+
+// resolve returns the containerd version from, in order, the AKSNodeConfig, the VHD manifest and the containerd binary.
+// The sources which don't know the version are logged, if none does, the version is empty and a containerd 1.x config is generated.
+// An error is only returned for an invalid AKSNodeConfig version, so it is not silently ignored.
+func (r *containerdVersionResolver) resolve(config *aksnodeconfigv1.Configuration) (ContainerdVersion, error) {
+	if explicit := config.GetContainerdConfig().GetContainerdVersion(); explicit != "" {
+		version := normalizeContainerdVersion(explicit)
+		if version == "" {
+			return ContainerdVersion{}, fmt.Errorf("containerd_version %q is not a valid containerd version", explicit)
+		}
+		return r.resolved(version, ContainerdVersionSourceAKSNodeConfig), nil
+	}
+
+	version, err := r.versionFromVHDManifest()
+	if err == nil {
+		return r.resolved(version, ContainerdVersionSourceVHDManifest), nil
+	}
+	slog.Info("containerd version not found in the VHD manifest", "path", r.vhdManifestPath, "error", err)
+
+	version, err = r.versionFromBinary()
+	if err == nil {
+		return r.resolved(version, ContainerdVersionSourceBinary), nil
+	}
+	slog.Info("containerd version not found in the containerd binary", "error", err)
+
+	slog.Warn("containerd version is unknown, generating a containerd 1.x config")
+	return ContainerdVersion{Source: ContainerdVersionSourceNone}, nil
+}
+
+func (r *containerdVersionResolver) resolved(version string, source ContainerdVersionSource) ContainerdVersion {
+	slog.Info("resolved containerd version", "version", version, "source", source)
+	return ContainerdVersion{Version: version, Source: source}
+}
+
+// versionFromVHDManifest returns the last containerd version installed in the VHD, it replaced the previous ones.
+func (r *containerdVersionResolver) versionFromVHDManifest() (string, error) {
+	content, err := os.ReadFile(r.vhdManifestPath)
+	if err != nil {
+		return "", err
+	}
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 4 && fields[0] == "-" && fields[1] == "containerd" && fields[2] == "version" {
+			last = fields[3]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if last == "" {
+		return "", errors.New("no containerd version listed")
+	}
+	version := normalizeContainerdVersion(last)
+	if version == "" {
+		return "", fmt.Errorf("%q is not a valid containerd version", last)
+	}
+	return version, nil
+}
+
+// versionFromBinary reads the version from the Go build info of the containerd binary rather than running it.
+func (r *containerdVersionResolver) versionFromBinary() (string, error) {
+	paths := r.binaryPaths
+	if r.binaryName != "" {
+		if path, err := exec.LookPath(r.binaryName); err == nil {
+			paths = append([]string{path}, paths...)
+		}
+	}
+	var errs []error
+	for _, path := range paths {
+		info, err := buildinfo.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		version, err := containerdVersionFromBuildInfo(info)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		return version, nil
+	}
+	if len(errs) == 0 {
+		return "", errors.New("containerd binary not found")
+	}
+	return "", errors.Join(errs...)
+}
+
+// containerdVersionFromBuildInfo returns the version set at link time, or the version of the main module.
+// Distro packages are built from source, so the main module version is often "(devel)", the module path
+// still tells containerd 2.x (github.com/containerd/containerd/v2) apart.
+func containerdVersionFromBuildInfo(info *debug.BuildInfo) (string, error) {
+	if !strings.HasPrefix(info.Main.Path, "github.com/containerd/containerd") {
+		return "", fmt.Errorf("main module %q is not containerd", info.Main.Path)
+	}
+	for _, setting := range info.Settings {
+		if setting.Key != "-ldflags" {
+			continue
+		}
+		if match := ldflagsVersionPattern.FindStringSubmatch(setting.Value); match != nil {
+			if version := normalizeContainerdVersion(match[1]); version != "" {
+				return version, nil
+			}
+		}
+	}
+	if version := normalizeContainerdVersion(info.Main.Version); version != "" {
+		return version, nil
+	}
+	if strings.HasSuffix(info.Main.Path, "/v2") {
+		return "2.0.0", nil
+	}
+	return "", errors.New("the build info has no containerd version")
+}
+
+// normalizeContainerdVersion returns the major.minor.patch part of a containerd version, or "" if it is not a version.
+// e.g. "1.6.24-11-ubuntu1~24.04.1" -> "1.6.24", "v2.0.0-6.azl3" -> "2.0.0", "2.0.0-beta.1" -> "2.0.0".
+func normalizeContainerdVersion(version string) string {
+	// Strip any leading "v" prefix.
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	// Strip everything after the first "-" (package revision or pre-release suffix).
+	if idx := strings.Index(version, "-"); idx > 0 {
+		version = version[:idx]
+	}
+	// Validate the result is a valid major.minor.patch version.
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	for _, p := range parts {
+		if len(p) == 0 {
+			return ""
+		}
+		for _, c := range p {
+			if c < '0' || c > '9' {
+				return ""
+			}
+		}
+	}
+	return version
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The generated containerd config must not depend on the containerd installed on the host running the tests.
+	containerdVersions = &containerdVersionResolver{}
+	// nor on the distro of the host, the sysctl policy target is left unknown.
+	osReleasePath = ""
+	os.Exit(m.Run())
+}
+
+// useContainerdVersionResolver replaces the containerd version resolver for the duration of the test.
+func useContainerdVersionResolver(t *testing.T, r *containerdVersionResolver) {
+	previous := containerdVersions
+	containerdVersions = r
+	t.Cleanup(func() { containerdVersions = previous })
+}
+
+func TestContainerdVersionResolver(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "vhd-install.complete")
+	require.NoError(t, os.WriteFile(manifest, []byte(`Components downloaded in this VHD build (some of the below components might get deleted during cluster provisioning if they are not needed):
+  - containernetworking-plugins version 1.6.2
+  - containerd version 1.7.27-1
+  - containerd version 2.0.0-6.azl3
+`), 0o644))
+	noContainerd := filepath.Join(dir, "no-containerd.complete")
+	require.NoError(t, os.WriteFile(noContainerd, []byte("  - runc version 1.2.4\n"), 0o644))
+	// the test binary embeds Go build info, but it is not containerd.
+	testBinary, err := os.Executable()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resolver *containerdVersionResolver
+		explicit string
+		want     ContainerdVersion
+		wantErr  string
+	}{
+		{
+			name:     "AKSNodeConfig wins",
+			resolver: &containerdVersionResolver{vhdManifestPath: manifest},
+			explicit: "v1.7.22",
+			want:     ContainerdVersion{Version: "1.7.22", Source: ContainerdVersionSourceAKSNodeConfig},
+		},
+		{
+			name:     "invalid AKSNodeConfig version",
+			resolver: &containerdVersionResolver{vhdManifestPath: manifest},
+			explicit: "1.7",
+			wantErr:  `containerd_version "1.7" is not a valid containerd version`,
+		},
+		{
+			name:     "last version of the VHD manifest",
+			resolver: &containerdVersionResolver{vhdManifestPath: manifest},
+			want:     ContainerdVersion{Version: "2.0.0", Source: ContainerdVersionSourceVHDManifest},
+		},
+		{
+			name:     "VHD manifest without containerd",
+			resolver: &containerdVersionResolver{vhdManifestPath: noContainerd, binaryPaths: []string{testBinary}},
+			want:     ContainerdVersion{Source: ContainerdVersionSourceNone},
+		},
+		{
+			name:     "no source",
+			resolver: &containerdVersionResolver{vhdManifestPath: filepath.Join(dir, "missing"), binaryPaths: []string{filepath.Join(dir, "containerd")}},
+			want:     ContainerdVersion{Source: ContainerdVersionSourceNone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &aksnodeconfigv1.Configuration{ContainerdConfig: &aksnodeconfigv1.ContainerdConfig{ContainerdVersion: tt.explicit}}
+			got, err := tt.resolver.resolve(config)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContainerdVersionFromBuildInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    *debug.BuildInfo
+		want    string
+		wantErr string
+	}{
+		{
+			name: "version set at link time",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "github.com/containerd/containerd/v2", Version: "(devel)"},
+				Settings: []debug.BuildSetting{
+					{Key: "-ldflags", Value: "-X github.com/containerd/containerd/v2/version.Version=2.1.3-1 -X github.com/containerd/containerd/v2/version.Revision=abc"},
+				},
+			},
+			want: "2.1.3",
+		},
+		{
+			name: "main module version",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "github.com/containerd/containerd", Version: "v1.7.27"}},
+			want: "1.7.27",
+		},
+		{
+			name: "containerd 2.x module without version",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "github.com/containerd/containerd/v2", Version: "(devel)"}},
+			want: "2.0.0",
+		},
+		{
+			name:    "containerd 1.x module without version",
+			info:    &debug.BuildInfo{Main: debug.Module{Path: "github.com/containerd/containerd", Version: "(devel)"}},
+			wantErr: "the build info has no containerd version",
+		},
+		{
+			name:    "not containerd",
+			info:    &debug.BuildInfo{Main: debug.Module{Path: "github.com/opencontainers/runc", Version: "v1.2.4"}},
+			wantErr: `main module "github.com/opencontainers/runc" is not containerd`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := containerdVersionFromBuildInfo(tt.info)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeContainerdVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{
+			name:    "containerd v2 with package revision",
+			version: "2.3.2-1",
+			want:    "2.3.2",
+		},
+		{
+			name:    "containerd v2 without package revision",
+			version: "v2.0.0",
+			want:    "2.0.0",
+		},
+		{
+			name:    "containerd v1 with package revision",
+			version: "1.7.22-1",
+			want:    "1.7.22",
+		},
+		{
+			name:    "containerd v1 without package revision",
+			version: "1.7.22",
+			want:    "1.7.22",
+		},
+		{
+			name:    "containerd v2 pre-release suffix",
+			version: "2.0.0-beta.1",
+			want:    "2.0.0",
+		},
+		{
+			name:    "containerd v2 rc suffix",
+			version: "v2.1.0-rc.2",
+			want:    "2.1.0",
+		},
+		{
+			name:    "empty version",
+			version: "",
+			want:    "",
+		},
+		{
+			name:    "not a version",
+			version: "not a version",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeContainerdVersion(tt.version)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvVarError is returned when the value of a CSE environment variable cannot be generated from the AKSNodeConfig.
+type EnvVarError struct {
+	// Name is the CSE environment variable, e.g. CONTAINERD_CONFIG_CONTENT.
+	Name string
+	Err  error
+}
+
+func (e *EnvVarError) Error() string {
+	return fmt.Sprintf("failed to generate %s: %v", e.Name, e.Err)
+}
+
+func (e *EnvVarError) Unwrap() error {
+	return e.Err
+}
+
+// CSEEnvError lists every CSE environment variable which could not be generated from the AKSNodeConfig.
+// It is returned by BuildCSECmd instead of running the CSE with a broken configuration, so provisioning
+// fails early and provision.json names the faulty AKSNodeConfig fields.
+type CSEEnvError struct {
+	Errors []*EnvVarError
+}
+
+func (e *CSEEnvError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("invalid AKSNodeConfig: %s", strings.Join(msgs, "; "))
+}
+
+func (e *CSEEnvError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// envErrors collects the errors of the helpers generating CSE environment variable values.
+type envErrors []*EnvVarError
+
+func (e *envErrors) add(name string, err error) {
+	if err != nil {
+		*e = append(*e, &EnvVarError{Name: name, Err: err})
+	}
+}
+
+// err returns a *CSEEnvError holding the collected errors, or nil if there is none.
+func (e envErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return &CSEEnvError{Errors: e}
+}
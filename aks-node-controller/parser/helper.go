@@ -19,13 +19,14 @@ package parser
 
 import (
 	"bytes"
-	"context"
 	_ "embed"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
-	"os/exec"
+	"maps"
+	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,6 +35,11 @@ import (
 	"github.com/Azure/agentbaker/aks-node-controller/helpers"
 	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
 	"github.com/Azure/agentbaker/aks-node-controller/pkg/gpu"
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/msiauth"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletconfig"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
+	"github.com/Azure/agentbaker/pkg/agent/localdns"
+	"github.com/Azure/agentbaker/pkg/agent/sysctlpolicy"
 	"github.com/Masterminds/semver/v3"
 	"google.golang.org/protobuf/encoding/protojson"
 )
@@ -41,37 +47,6 @@ import (
 var (
 	//go:embed templates/kubenet-cni.json.gtpl
 	kubenetTemplateContent []byte
-	//go:embed  templates/containerd.toml.gtpl
-	containerdConfigTemplateText string
-	//nolint:gochecknoglobals
-	containerdConfigTemplate = template.Must(
-		template.New("containerdconfig").Funcs(getFuncMapForContainerdConfigTemplate()).Parse(containerdConfigTemplateText),
-	)
-	//go:embed  templates/containerd_no_GPU.toml.gtpl
-	containerdConfigNoGPUTemplateText string
-	//nolint:gochecknoglobals
-	containerdConfigNoGPUTemplate = template.Must(
-		template.New("nogpucontainerdconfig").Funcs(getFuncMapForContainerdConfigTemplate()).Parse(containerdConfigNoGPUTemplateText),
-	)
-	//go:embed  templates/containerd_v2.toml.gtpl
-	containerdV2ConfigTemplateText string
-	//nolint:gochecknoglobals
-	containerdV2ConfigTemplate = template.Must(
-		template.New("containerdv2config").Funcs(getFuncMapForContainerdConfigTemplate()).Parse(containerdV2ConfigTemplateText),
-	)
-	//go:embed  templates/containerd_v2_no_GPU.toml.gtpl
-	containerdV2ConfigNoGPUTemplateText string
-	//nolint:gochecknoglobals
-	containerdV2ConfigNoGPUTemplate = template.Must(
-		template.New("nogpucontainerdv2config").Funcs(getFuncMapForContainerdConfigTemplate()).Parse(containerdV2ConfigNoGPUTemplateText),
-	)
-
-	//go:embed templates/localdns.toml.gtpl
-	localDnsCorefileTemplateText string
-	//nolint:gochecknoglobals
-	localDnsCorefileTemplate = template.Must(
-		template.New("localdnscorefile").Funcs(getFuncMapForLocalDnsCorefileTemplate()).Parse(localDnsCorefileTemplateText),
-	)
 )
 
 func getFuncMap() template.FuncMap {
@@ -82,16 +57,6 @@ func getFuncMap() template.FuncMap {
 	}
 }
 
-func getFuncMapForContainerdConfigTemplate() template.FuncMap {
-	return template.FuncMap{
-		"derefBool":                        deref[bool],
-		"getEnsureNoDupePromiscuousBridge": getEnsureNoDupePromiscuousBridge,
-		"isKubernetesVersionGe":            IsKubernetesVersionGe,
-		"getHasDataDir":                    getHasDataDir,
-		"getEnableNvidia":                  getEnableNvidia,
-	}
-}
-
 func getStringFromVMType(enum aksnodeconfigv1.VmType) string {
 	switch enum {
 	case aksnodeconfigv1.VmType_VM_TYPE_STANDARD:
@@ -172,72 +137,48 @@ func getKubenetTemplate() string {
 }
 
 // getContainerdConfigBase64 returns the base64 encoded containerd config depending on whether the node is with GPU or not.
-func getContainerdConfigBase64(aksnodeconfig *aksnodeconfigv1.Configuration, containerdVersion string) string {
+func getContainerdConfigBase64(aksnodeconfig *aksnodeconfigv1.Configuration, containerdVersion string) (string, error) {
 	if aksnodeconfig == nil {
-		return ""
+		return "", nil
 	}
 
 	containerdConfig, err := containerdConfigFromAKSNodeConfig(aksnodeconfig, false, containerdVersion)
 	if err != nil {
-		return fmt.Sprintf("error getting containerd config from node bootstrap variables: %v", err)
+		return "", err
 	}
 
-	return base64.StdEncoding.EncodeToString([]byte(containerdConfig))
+	return base64.StdEncoding.EncodeToString([]byte(containerdConfig)), nil
 }
 
 // getNoGPUContainerdConfigBase64 returns the base64 encoded containerd config depending on whether the node is with GPU or not.
-func getNoGPUContainerdConfigBase64(aksnodeconfig *aksnodeconfigv1.Configuration, containerdVersion string) string {
+func getNoGPUContainerdConfigBase64(aksnodeconfig *aksnodeconfigv1.Configuration, containerdVersion string) (string, error) {
 	if aksnodeconfig == nil {
-		return ""
+		return "", nil
 	}
 
 	containerdConfig, err := containerdConfigFromAKSNodeConfig(aksnodeconfig, true, containerdVersion)
 	if err != nil {
-		return fmt.Sprintf("error getting No GPU containerd config from node bootstrap variables: %v", err)
+		return "", err
 	}
 
-	return base64.StdEncoding.EncodeToString([]byte(containerdConfig))
+	return base64.StdEncoding.EncodeToString([]byte(containerdConfig)), nil
 }
 
+// containerdConfigFromAKSNodeConfig renders the containerd config generated by AKS, merged with the AKSNodeConfig overlay.
 func containerdConfigFromAKSNodeConfig(aksnodeconfig *aksnodeconfigv1.Configuration, noGPU bool, containerdVersion string) (string, error) {
 	if aksnodeconfig == nil {
 		return "", fmt.Errorf("AKSNodeConfig is nil")
 	}
 
-	// Select the appropriate containerd config template based on version and GPU presence.
-	// Containerd 2.x uses different CRI plugin paths (io.containerd.cri.v1.images/runtime)
-	// compared to containerd 1.x (io.containerd.grpc.v1.cri).
-	var _template *template.Template
-	if isContainerdV2(containerdVersion) {
-		_template = containerdV2ConfigTemplate
-		if noGPU {
-			_template = containerdV2ConfigNoGPUTemplate
-		}
-	} else {
-		_template = containerdConfigTemplate
-		if noGPU {
-			_template = containerdConfigNoGPUTemplate
-		}
-	}
-
-	var buffer bytes.Buffer
-	if err := _template.Execute(&buffer, aksnodeconfig); err != nil {
-		return "", fmt.Errorf("error executing containerd config template for AKSNodeConfig: %w", err)
+	config := buildContainerdConfig(aksnodeconfig, noGPU, containerdVersion)
+	if err := config.ApplyOverlay(getContainerdConfigOverlay(aksnodeconfig.GetContainerdConfig())); err != nil {
+		return "", fmt.Errorf("invalid containerd config overlay: %w", err)
 	}
-
-	return buffer.String(), nil
-}
-
-// detectContainerdVersion runs "containerd --version" and parses the version string.
-// The expected output format is: "containerd <source> <version> <commit>"
-// e.g. "containerd containerd.io 1.7.22 c814c75..." or "containerd github.com/containerd/containerd/v2 v2.0.0 ..."
-// Returns the semver version without the leading "v" prefix, or empty string if detection fails.
-func detectContainerdVersion(ctx context.Context) (string, error) {
-	out, err := exec.CommandContext(ctx, "containerd", "--version").Output()
+	content, err := config.Marshal()
 	if err != nil {
-		return "", fmt.Errorf("running containerd --version: %w", err)
+		return "", fmt.Errorf("error rendering containerd config for AKSNodeConfig: %w", err)
 	}
-	return parseContainerdVersionOutput(string(out)), nil
+	return string(content), nil
 }
 
 // isContainerdV2 returns true if the containerd version string indicates a 2.x release.
@@ -307,157 +248,65 @@ func getDHCPV6ConfigFilepath() string {
 }
 
 // getSysctlContent converts aksnodeconfigv1.SysctlConfig to a string with key=value pairs, with default values.
-//
-//gocyclo:ignore
-//nolint:funlen,gocognit,cyclop // This function is long because it has to handle all the sysctl values.
-func getSysctlContent(s *aksnodeconfigv1.SysctlConfig) string {
-	// This is a partial workaround to this upstream Kubernetes issue:
-	// https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
-
-	if s == nil {
-		// If the sysctl config is nil, setting it to non-nil so that it can go through the defaulting logic below to get the default values.
-		s = &aksnodeconfigv1.SysctlConfig{}
-	}
-
-	m := make(map[string]interface{})
-	m["net.ipv4.tcp_retries2"] = defaultNetIpv4TcpRetries2
-	m["net.core.message_burst"] = defaultNetCoreMessageBurst
-	m["net.core.message_cost"] = defaultNetCoreMessageCost
-
-	// Access the variable directly, instead of using the getter, so that it knows whether it's nil or not.
-	// This is based on protobuf3 explicit presence feature.
-	// Other directly access variables in this function implies the same idea.
-	if s.NetCoreSomaxconn == nil {
-		m["net.core.somaxconn"] = defaultNetCoreSomaxconn
-	} else {
-		// either using getter for NetCoreSomaxconn or direct access is fine because we ensure it's not nil.
-		m["net.core.somaxconn"] = s.GetNetCoreSomaxconn()
-	}
-
-	if s.NetIpv4TcpMaxSynBacklog == nil {
-		m["net.ipv4.tcp_max_syn_backlog"] = defaultNetIpv4TcpMaxSynBacklog
-	} else {
-		m["net.ipv4.tcp_max_syn_backlog"] = s.GetNetIpv4TcpMaxSynBacklog()
-	}
-
-	if s.NetIpv4NeighDefaultGcThresh1 == nil {
-		m["net.ipv4.neigh.default.gc_thresh1"] = defaultNetIpv4NeighDefaultGcThresh1
-	} else {
-		m["net.ipv4.neigh.default.gc_thresh1"] = s.GetNetIpv4NeighDefaultGcThresh1()
-	}
-
-	if s.NetIpv4NeighDefaultGcThresh2 == nil {
-		m["net.ipv4.neigh.default.gc_thresh2"] = defaultNetIpv4NeighDefaultGcThresh2
-	} else {
-		m["net.ipv4.neigh.default.gc_thresh2"] = s.GetNetIpv4NeighDefaultGcThresh2()
-	}
-
-	if s.NetIpv4NeighDefaultGcThresh3 == nil {
-		m["net.ipv4.neigh.default.gc_thresh3"] = defaultNetIpv4NeighDefaultGcThresh3
-	} else {
-		m["net.ipv4.neigh.default.gc_thresh3"] = s.GetNetIpv4NeighDefaultGcThresh3()
-	}
-
-	if s.NetCoreNetdevMaxBacklog != nil {
-		m["net.core.netdev_max_backlog"] = s.GetNetCoreNetdevMaxBacklog()
-	}
-
-	if s.NetCoreRmemDefault != nil {
-		m["net.core.rmem_default"] = s.GetNetCoreRmemDefault()
-	}
-
-	if s.NetCoreRmemMax != nil {
-		m["net.core.rmem_max"] = s.GetNetCoreRmemMax()
-	}
-
-	if s.NetCoreWmemDefault != nil {
-		m["net.core.wmem_default"] = s.GetNetCoreWmemDefault()
-	}
-
-	if s.NetCoreWmemMax != nil {
-		m["net.core.wmem_max"] = s.GetNetCoreWmemMax()
-	}
-
-	if s.NetCoreOptmemMax != nil {
-		m["net.core.optmem_max"] = s.GetNetCoreOptmemMax()
-	}
-
-	if s.NetIpv4TcpMaxTwBuckets != nil {
-		m["net.ipv4.tcp_max_tw_buckets"] = s.GetNetIpv4TcpMaxTwBuckets()
+// Values rejected by the sysctl policy are reported as an error.
+func getSysctlContent(s *aksnodeconfigv1.SysctlConfig, target sysctlpolicy.Target) (string, error) {
+	sysctls, err := SysctlValues(s)
+	if err != nil {
+		return "", err
 	}
-
-	if s.NetIpv4TcpFinTimeout != nil {
-		m["net.ipv4.tcp_fin_timeout"] = s.GetNetIpv4TcpFinTimeout()
+	result, err := sysctlpolicy.Embedded().Evaluate(target, sysctls, nil)
+	if err != nil {
+		return "", err
 	}
+	return base64.StdEncoding.EncodeToString([]byte(result.SysctlContent())), nil
+}
 
-	if s.NetIpv4TcpKeepaliveTime != nil {
-		m["net.ipv4.tcp_keepalive_time"] = s.GetNetIpv4TcpKeepaliveTime()
+// SysctlValues returns the sysctls set in the SysctlConfig keyed by sysctl name, without defaults.
+func SysctlValues(s *aksnodeconfigv1.SysctlConfig) (map[string]string, error) {
+	// protojson only writes the fields which are set, based on protobuf3 explicit presence feature, so that only
+	// the values set are checked against the policy.
+	sysctlConfig, err := protojson.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sysctl config: %w", err)
 	}
+	return sysctlpolicy.Embedded().SysctlValues(sysctlConfig)
+}
 
-	if s.NetIpv4TcpKeepaliveProbes != nil {
-		m["net.ipv4.tcp_keepalive_probes"] = s.GetNetIpv4TcpKeepaliveProbes()
-	}
+// osReleasePath identifies the distro of the node the sysctl policy is evaluated for.
+//
+//nolint:gochecknoglobals // replaced in tests, which must not depend on the distro of the host.
+var osReleasePath = "/etc/os-release"
 
-	if s.NetIpv4TcpkeepaliveIntvl != nil {
-		m["net.ipv4.tcp_keepalive_intvl"] = s.GetNetIpv4TcpkeepaliveIntvl()
+// getSysctlTarget returns the sysctl policy target of the node, its fields are left empty when unknown.
+func getSysctlTarget(containerdVersion string) sysctlpolicy.Target {
+	osRelease, err := os.ReadFile(osReleasePath)
+	if err != nil {
+		log.Printf("OS family is unknown to the sysctl policy: %v", err)
 	}
+	return SysctlTarget(string(osRelease), containerdVersion)
+}
 
-	if s.NetIpv4TcpTwReuse != nil {
-		if s.GetNetIpv4TcpTwReuse() {
-			m["net.ipv4.tcp_tw_reuse"] = 1
-		} else {
-			m["net.ipv4.tcp_tw_reuse"] = 0
+// SysctlTarget returns the sysctl policy target of a node from its /etc/os-release and containerd version.
+// The OS family and the containerd major version are left empty when unknown.
+func SysctlTarget(osRelease, containerdVersion string) sysctlpolicy.Target {
+	var target sysctlpolicy.Target
+	for _, line := range strings.Split(osRelease, "\n") {
+		id, found := strings.CutPrefix(strings.TrimSpace(line), "ID=")
+		if !found {
+			continue
 		}
-	}
-
-	if s.GetNetIpv4IpLocalPortRange() != "" {
-		m["net.ipv4.ip_local_port_range"] = s.GetNetIpv4IpLocalPortRange()
-		if getPortRangeEndValue(s.GetNetIpv4IpLocalPortRange()) > ipLocalReservedPorts {
-			m["net.ipv4.ip_local_reserved_ports"] = ipLocalReservedPorts
+		// the OS families of datamodel.DistroCapabilities, CBL-Mariner is the former name of Azure Linux.
+		switch strings.Trim(id, `"`) {
+		case "ubuntu":
+			target.OSFamily = "Ubuntu"
+		case "azurelinux", "mariner":
+			target.OSFamily = "AzureLinux"
 		}
 	}
-
-	if s.NetNetfilterNfConntrackMax != nil {
-		m["net.netfilter.nf_conntrack_max"] = s.GetNetNetfilterNfConntrackMax()
-	}
-
-	if s.NetNetfilterNfConntrackBuckets != nil {
-		m["net.netfilter.nf_conntrack_buckets"] = s.GetNetNetfilterNfConntrackBuckets()
-	}
-
-	if s.FsInotifyMaxUserWatches != nil {
-		m["fs.inotify.max_user_watches"] = s.GetFsInotifyMaxUserWatches()
-	}
-
-	if s.FsFileMax != nil {
-		m["fs.file-max"] = s.GetFsFileMax()
-	}
-
-	if s.FsAioMaxNr != nil {
-		m["fs.aio-max-nr"] = s.GetFsAioMaxNr()
-	}
-
-	if s.FsNrOpen != nil {
-		m["fs.nr_open"] = s.GetFsNrOpen()
-	}
-
-	if s.KernelThreadsMax != nil {
-		m["kernel.threads-max"] = s.GetKernelThreadsMax()
-	}
-
-	if s.VmMaxMapCount != nil {
-		m["vm.max_map_count"] = s.GetVmMaxMapCount()
-	}
-
-	if s.VmSwappiness != nil {
-		m["vm.swappiness"] = s.GetVmSwappiness()
-	}
-
-	if s.VmVfsCachePressure != nil {
-		m["vm.vfs_cache_pressure"] = s.GetVmVfsCachePressure()
+	if v, err := semver.NewVersion(containerdVersion); err == nil {
+		target.ContainerdMajorVersion = int(v.Major())
 	}
-
-	return base64.StdEncoding.EncodeToString([]byte(createSortedKeyValuePairs(m, "\n") + "\n"))
+	return target
 }
 
 func getShouldConfigContainerdUlimits(u *aksnodeconfigv1.UlimitConfig) bool {
@@ -465,27 +314,43 @@ func getShouldConfigContainerdUlimits(u *aksnodeconfigv1.UlimitConfig) bool {
 }
 
 // getUlimitContent converts aksnodeconfigv1.UlimitConfig to a string with key=value pairs.
-func getUlimitContent(u *aksnodeconfigv1.UlimitConfig) string {
+// Values rejected by the sysctl policy are reported as an error, values which do not apply to the containerd
+// version are logged and left out.
+func getUlimitContent(u *aksnodeconfigv1.UlimitConfig, target sysctlpolicy.Target) (string, error) {
 	if u == nil {
-		return ""
+		return "", nil
+	}
+
+	result, err := sysctlpolicy.Embedded().Evaluate(target, nil, UlimitValues(u))
+	if err != nil {
+		return "", err
+	}
+	for _, skipped := range result.Skipped {
+		log.Printf("containerd ulimit %s=%s is not set: %s", skipped.Key, skipped.Value, skipped.Reason)
 	}
 
 	// spaces are used here because they are converted to newlines in scripts
-	header := "[Service] "
+	var sb strings.Builder
+	sb.WriteString("[Service] ")
+	for _, line := range result.UlimitLines() {
+		sb.WriteString(line + " ")
+	}
+	return sb.String(), nil
+}
+
+// UlimitValues returns the containerd service limits set in the UlimitConfig keyed by systemd directive.
+func UlimitValues(u *aksnodeconfigv1.UlimitConfig) map[string]string {
 	m := make(map[string]string)
+	if u == nil {
+		return m
+	}
 	if u.NoFile != nil {
 		m["LimitNOFILE"] = u.GetNoFile()
 	}
-
 	if u.MaxLockedMemory != nil {
 		m["LimitMEMLOCK"] = u.GetMaxLockedMemory()
 	}
-
-	if len(m) == 0 {
-		return header
-	}
-
-	return header + createSortedKeyValuePairs(m, " ") + " "
+	return m
 }
 
 // getPortRangeEndValue returns the end value of the port range where the input is in the format of "start end".
@@ -494,39 +359,43 @@ func getPortRangeEndValue(portRange string) int {
 		return -1
 	}
 
+	_, end, err := parsePortRange(portRange)
+	if err != nil {
+		log.Printf("error parsing port range: %v", err)
+		return -1
+	}
+
+	return end
+}
+
+// parsePortRange parses a port range in the format of "start end".
+func parsePortRange(portRange string) (start, end int, err error) {
 	arr := strings.Split(portRange, " ")
 
 	// we are expecting only two values, start and end.
 	if len(arr) != MinArgs {
-		return -1
+		return 0, 0, fmt.Errorf("port range %q should be in the format of \"start end\"", portRange)
 	}
 
-	var start, end int
-	var err error
-
 	// the start value should be a valid port number.
 	if start, err = strconv.Atoi(arr[0]); err != nil {
-		log.Printf("error converting port range start value to int: %v", err)
-		return -1
+		return 0, 0, fmt.Errorf("error converting port range start value to int: %w", err)
 	}
 
 	// the end value should be a valid port number.
 	if end, err = strconv.Atoi(arr[1]); err != nil {
-		log.Printf("error converting port range end value to int: %v", err)
-		return -1
+		return 0, 0, fmt.Errorf("error converting port range end value to int: %w", err)
 	}
 
 	if start <= 0 || end <= 0 {
-		log.Printf("port range values should be greater than 0: %d", start)
-		return -1
+		return 0, 0, fmt.Errorf("port range values should be greater than 0: %d %d", start, end)
 	}
 
 	if start >= end {
-		log.Printf("port range end value should be greater than the start value: %d >= %d", start, end)
-		return -1
+		return 0, 0, fmt.Errorf("port range end value should be greater than the start value: %d >= %d", start, end)
 	}
 
-	return end
+	return start, end, nil
 }
 
 // createSortedKeyValuePairs creates a string with key=value pairs, sorted by key, with custom delimiter.
@@ -693,54 +562,100 @@ func getKubeletFlags(kubeletConfig *aksnodeconfigv1.KubeletConfig) string {
 	return createSortedKeyValuePairs(kubeletConfig.GetKubeletFlags(), " ")
 }
 
-func marshalToJSON(v any) ([]byte, error) {
-	// Originally we can set the Multiline here and it will marshal to a JSON we can use.
-	// However the protojson team intentionally randomly add extra whitespace after the key in the key-value.
-	// E.g., Sometimes it is "key": "value" and sometimes it is "key":  "value".
-	// They did it intentionally to make the output format not reliable,
-	// because they think it is not a good idea to rely on the JSON output format.
-	// ref: https://github.com/protocolbuffers/protobuf-go/commit/582ab3de426ef0758666e018b422dd20390f7f26
-	marshaler := &protojson.MarshalOptions{
-		Indent: "",
-	}
-
-	// Therefore, we have to implement our own re-formatting to make the output reliable as we expected.
-	switch v := v.(type) {
-	case *aksnodeconfigv1.KubeletConfigFileConfig:
-		data, err := marshaler.Marshal(v)
-		if err != nil {
-			log.Printf("error marshalling: %v", err)
-			return nil, err
-		}
+// kubeletConfigFileFieldNames maps the JSON names of the KubeletConfigFileConfig fields which differ from
+// the KubeletConfiguration ones.
+//
+//nolint:gochecknoglobals
+var kubeletConfigFileFieldNames = map[string]string{
+	"cacheTtl":             "cacheTTL",
+	"cacheAuthorizedTtl":   "cacheAuthorizedTTL",
+	"cacheUnauthorizedTtl": "cacheUnauthorizedTTL",
+}
 
-		var rawMessage json.RawMessage = data
-		jsonByte, err := json.MarshalIndent(rawMessage, "", "    ")
-		if err != nil {
-			log.Printf("error marshalling kubelet config file content: %v", err)
-			return nil, err
+// getKubeletConfiguration converts the KubeletConfigFileConfig to the KubeletConfiguration through their
+// JSON form, so a KubeletConfigFileConfig field without KubeletConfiguration counterpart is an error.
+func getKubeletConfiguration(kubeletConfigFileConfig *aksnodeconfigv1.KubeletConfigFileConfig) (*kubeletconfig.KubeletConfiguration, error) {
+	data, err := protojson.Marshal(kubeletConfigFileConfig)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(renameKubeletConfigFileFields(fields))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	kubeletConfiguration := &kubeletconfig.KubeletConfiguration{}
+	if err := decoder.Decode(kubeletConfiguration); err != nil {
+		return nil, err
+	}
+	return kubeletConfiguration, nil
+}
+
+func renameKubeletConfigFileFields(v any) any {
+	fields, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	renamed := make(map[string]any, len(fields))
+	for name, value := range fields {
+		if upstream, ok := kubeletConfigFileFieldNames[name]; ok {
+			name = upstream
 		}
-		return jsonByte, nil
-	default:
-		return nil, fmt.Errorf("unsupported type: %T", v)
+		renamed[name] = renameKubeletConfigFileFields(value)
 	}
+	return renamed
+}
+
+// applyKubeletFlagPolicy removes the kubelet flags dropped by the target Kubernetes version, and rejects the flags
+// the kubelet would not start with. Flags which are only accepted in the kubelet config file are removed from the
+// command line, the config file content is carried by KubeletConfigFileConfig. The config file fields of the dropped
+// flags are cleared by kubeletconfig.SetDefaults.
+func applyKubeletFlagPolicy(config *aksnodeconfigv1.Configuration) error {
+	kc := config.GetKubeletConfig()
+	if kc == nil {
+		return nil
+	}
+	result, err := kubeletpolicy.Embedded().Apply(config.GetKubernetesVersion(), kc.GetKubeletFlags(), kc.GetEnableKubeletConfigFile())
+	if err != nil {
+		return err
+	}
+	for _, flag := range result.ConfigFileOnly {
+		delete(kc.KubeletFlags, flag)
+	}
+	return nil
 }
 
 // getKubeletConfigFileContent converts kubelet flags we set to a file, and return the json content.
-func getKubeletConfigFileContent(kubeletConfig *aksnodeconfigv1.KubeletConfig) string {
+func getKubeletConfigFileContent(kubeletConfig *aksnodeconfigv1.KubeletConfig, k8sVersion string) (string, error) {
 	if kubeletConfig == nil {
-		return ""
+		return "", nil
 	}
-	kubeletConfigFileConfig := kubeletConfig.GetKubeletConfigFileConfig()
-	kubeletConfigFileConfigByte, err := marshalToJSON(kubeletConfigFileConfig)
+	kubeletConfiguration, err := getKubeletConfiguration(kubeletConfig.GetKubeletConfigFileConfig())
 	if err != nil {
-		log.Printf("error marshalling kubelet config file content: %v", err)
-		return ""
+		return "", fmt.Errorf("error converting kubelet config file content: %w", err)
+	}
+	kubeletconfig.SetDefaults(kubeletConfiguration, k8sVersion)
+	if err := kubeletconfig.Validate(kubeletConfiguration, k8sVersion); err != nil {
+		return "", err
+	}
+	kubeletConfigFileConfigByte, err := kubeletconfig.Marshal(kubeletConfiguration)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling kubelet config file content: %w", err)
 	}
-	return string(kubeletConfigFileConfigByte)
+	return string(kubeletConfigFileConfigByte), nil
 }
 
-func getKubeletConfigFileContentBase64(kubeletConfig *aksnodeconfigv1.KubeletConfig) string {
-	return base64.StdEncoding.EncodeToString([]byte(getKubeletConfigFileContent(kubeletConfig)))
+func getKubeletConfigFileContentBase64(kubeletConfig *aksnodeconfigv1.KubeletConfig, k8sVersion string) (string, error) {
+	content, err := getKubeletConfigFileContent(kubeletConfig, k8sVersion)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(content)), nil
 }
 
 func getEnableSwapConfig(v *aksnodeconfigv1.CustomLinuxOsConfig) bool {
@@ -796,56 +711,8 @@ func removeNewlines(str string) string {
 	return sanitizedStr
 }
 
-// parseContainerdVersionOutput extracts the semver version from containerd --version output.
-// The output format is: "containerd <source> <version> <commit>"
-// e.g. "containerd containerd.io 1.7.22 c814c75..." or "containerd github.com/containerd/containerd/v2 2.0.0 ..."
-// The version (3rd field) could be in the format "1.6.24-11-ubuntu1~24.04.1" or "2.0.0-6.azl3" or just "2.0.0",
-// we extract the major.minor.patch version only.
-func parseContainerdVersionOutput(output string) string {
-	fields := strings.Fields(strings.TrimSpace(output))
-	if len(fields) < 3 {
-		return ""
-	}
-	// Take the 3rd field and strip any leading "v" prefix.
-	version := strings.TrimPrefix(fields[2], "v")
-	// Strip everything after the first "-" (package revision or pre-release suffix).
-	// e.g. "2.3.2-1" -> "2.3.2", "2.0.0-beta.1" -> "2.0.0"
-	if idx := strings.Index(version, "-"); idx > 0 {
-		version = version[:idx]
-	}
-	// Validate the result is a valid major.minor.patch version.
-	parts := strings.Split(version, ".")
-	if len(parts) != 3 {
-		return ""
-	}
-	for _, p := range parts {
-		if len(p) == 0 {
-			return ""
-		}
-		for _, c := range p {
-			if c < '0' || c > '9' {
-				return ""
-			}
-		}
-	}
-	return version
-}
-
 // ---------------------- Start of localdns related helper code ----------------------//
 
-// FuncMap used for generating localdns corefile.
-// getLocalDnsNodeListenerIp, getLocalDnsClusterListenerIp, getAzureDnsIp and getCoreDnsServiceIp have their place holder in
-// localdns.toml.gtpl template and will be replaced by the values returned from these functions.
-func getFuncMapForLocalDnsCorefileTemplate() template.FuncMap {
-	return template.FuncMap{
-		"hasSuffix":                    strings.HasSuffix,
-		"getLocalDnsNodeListenerIp":    getLocalDnsNodeListenerIp,
-		"getLocalDnsClusterListenerIp": getLocalDnsClusterListenerIp,
-		"getAzureDnsIp":                getAzureDnsIp,
-		"getCoreDnsServiceIp":          getCoreDnsServiceIp,
-	}
-}
-
 // getLocalDnsCorefileBase64WithHostsPlugin generates a LocalDns corefile from the AKS node config
 // and returns it as a base64-encoded string. The includeHostsPlugin parameter controls whether
 // the hosts plugin block (hosts /etc/localdns/hosts { reload 5s; fallthrough }) is included in root-domain
@@ -859,18 +726,18 @@ func getFuncMapForLocalDnsCorefileTemplate() template.FuncMap {
 // Runtime selection between BASE and WITH_HOSTS happens in localdns.sh
 // (via select_localdns_corefile(), invoked on localdns service start/restart) based on
 // SHOULD_ENABLE_HOSTS_PLUGIN and the availability of the corresponding corefile environment variables.
-func getLocalDnsCorefileBase64WithHostsPlugin(aksnodeconfig *aksnodeconfigv1.Configuration, includeHostsPlugin bool) string {
+func getLocalDnsCorefileBase64WithHostsPlugin(aksnodeconfig *aksnodeconfigv1.Configuration, includeHostsPlugin bool) (string, error) {
 	if aksnodeconfig == nil {
-		return ""
+		return "", nil
 	}
 	// If LocalDnsProfile is nil or EnableLocalDns is false, return empty string.
 	// This means localdns is not enabled for the agent pool.
 	// In this case we don't need to generate localdns corefile.
 	if aksnodeconfig.GetLocalDnsProfile() == nil {
-		return ""
+		return "", nil
 	}
 	if !aksnodeconfig.GetLocalDnsProfile().GetEnableLocalDns() {
-		return ""
+		return "", nil
 	}
 
 	variant := "with hosts plugin"
@@ -880,30 +747,85 @@ func getLocalDnsCorefileBase64WithHostsPlugin(aksnodeconfig *aksnodeconfigv1.Con
 
 	localDnsConfig, err := generateLocalDnsCorefileFromAKSNodeConfig(aksnodeconfig, includeHostsPlugin)
 	if err != nil {
-		log.Printf("error getting localdns corefile (%s) from aks node config: %v", variant, err)
-		return ""
+		return "", fmt.Errorf("error getting localdns corefile (%s) from aks node config: %w", variant, err)
 	}
-	return base64.StdEncoding.EncodeToString([]byte(localDnsConfig))
+	return base64.StdEncoding.EncodeToString([]byte(localDnsConfig)), nil
 }
 
-// localDnsCorefileTemplateData wraps the AKS node config with additional template control flags.
-type localDnsCorefileTemplateData struct {
-	Config             *aksnodeconfigv1.Configuration
-	IncludeHostsPlugin bool
-}
-
-// Corefile is created using localdns.toml.gtpl template and aksnodeconfig values.
+// generateLocalDnsCorefileFromAKSNodeConfig renders the Corefile from the aksnodeconfig values.
 // includeHostsPlugin controls whether the hosts plugin block is included in the generated Corefile.
 func generateLocalDnsCorefileFromAKSNodeConfig(aksnodeconfig *aksnodeconfigv1.Configuration, includeHostsPlugin bool) (string, error) {
-	var corefileBuffer bytes.Buffer
-	templateData := localDnsCorefileTemplateData{
-		Config:             aksnodeconfig,
+	corefile, err := getLocalDnsCorefile(aksnodeconfig, includeHostsPlugin)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := corefile.Render()
+	if err != nil {
+		return "", fmt.Errorf("failed to render localdns corefile: %w", err)
+	}
+	return rendered, nil
+}
+
+// getLocalDnsCorefile converts the LocalDns profile to the shared localdns model.
+func getLocalDnsCorefile(aksnodeconfig *aksnodeconfigv1.Configuration, includeHostsPlugin bool) (*localdns.Corefile, error) {
+	vnetDnsOverrides, err := getLocalDnsOverrides(aksnodeconfig.GetLocalDnsProfile().GetVnetDnsOverrides())
+	if err != nil {
+		return nil, fmt.Errorf("invalid VnetDns overrides: %w", err)
+	}
+	kubeDnsOverrides, err := getLocalDnsOverrides(aksnodeconfig.GetLocalDnsProfile().GetKubeDnsOverrides())
+	if err != nil {
+		return nil, fmt.Errorf("invalid KubeDns overrides: %w", err)
+	}
+	return &localdns.Corefile{
+		NodeListenerIP:     getLocalDnsNodeListenerIp(),
+		ClusterListenerIP:  getLocalDnsClusterListenerIp(),
+		CoreDNSServiceIP:   getCoreDnsServiceIp(aksnodeconfig),
+		AzureDNSIP:         getAzureDnsIp(),
 		IncludeHostsPlugin: includeHostsPlugin,
+		VnetDNSOverrides:   vnetDnsOverrides,
+		KubeDNSOverrides:   kubeDnsOverrides,
+	}, nil
+}
+
+// validateLocalDnsProfile rejects LocalDns overrides and critical FQDNs the localdns Corefile cannot express.
+func validateLocalDnsProfile(aksnodeconfig *aksnodeconfigv1.Configuration) error {
+	if shouldEnableLocalDns(aksnodeconfig) != "true" {
+		return nil
 	}
-	if err := localDnsCorefileTemplate.Execute(&corefileBuffer, templateData); err != nil {
-		return "", fmt.Errorf("failed to execute localdns corefile template: %w", err)
+	corefile, err := getLocalDnsCorefile(aksnodeconfig, shouldEnableHostsPlugin(aksnodeconfig) == "true")
+	if err != nil {
+		return err
 	}
-	return corefileBuffer.String(), nil
+	return localdns.Validate(corefile, aksnodeconfig.GetLocalDnsProfile().GetCriticalFqdns())
+}
+
+// getLocalDnsOverrides converts the LocalDns overrides to the shared localdns model, ordered by domain.
+func getLocalDnsOverrides(overrides map[string]*aksnodeconfigv1.LocalDnsOverrides) ([]localdns.Override, error) {
+	result := make([]localdns.Override, 0, len(overrides))
+	for _, domain := range slices.Sorted(maps.Keys(overrides)) {
+		o := overrides[domain]
+		if o == nil {
+			return nil, fmt.Errorf("override for domain %q is nil", domain)
+		}
+		result = append(result, localdns.Override{
+			Domain:                      domain,
+			QueryLogging:                localdns.QueryLogging(o.GetQueryLogging()),
+			Protocol:                    localdns.Protocol(o.GetProtocol()),
+			ForwardDestination:          localdns.ForwardDestination(o.GetForwardDestination()),
+			ForwardPolicy:               localdns.ForwardPolicy(o.GetForwardPolicy()),
+			MaxConcurrent:               o.MaxConcurrent,
+			CacheDurationInSeconds:      o.CacheDurationInSeconds,
+			ServeStale:                  localdns.ServeStale(o.GetServeStale()),
+			ServeStaleDurationInSeconds: o.ServeStaleDurationInSeconds,
+			HealthCheck: localdns.HealthCheck{
+				Duration: o.GetHealthCheck().GetDuration(),
+				NoRec:    o.GetHealthCheck().GetNoRec(),
+				Domain:   o.GetHealthCheck().GetDomain(),
+			},
+			FailfastAllUnhealthyUpstreams: o.GetFailfastAllUnhealthyUpstreams(),
+		})
+	}
+	return result, nil
 }
 
 // getLocalDnsClusterListenerIp returns APIPA-IP address that will be used in localdns systemd unit.
@@ -1035,3 +957,95 @@ func GetCSETimeout(cseTimeout int) string {
 	}
 	return fmt.Sprintf("%d", cseTimeout)
 }
+
+// getBootstrappingAuthMethod returns the BOOTSTRAPPING_AUTH_METHOD value consumed by ensureKubelet.
+func getBootstrappingAuthMethod(bootstrapConfig *aksnodeconfigv1.BootstrappingConfig) string {
+	switch bootstrapConfig.GetBootstrappingAuthMethod() {
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_BOOTSTRAP_TOKEN:
+		return "bootstrap_token"
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_SECURE_TLS_BOOTSTRAPPING:
+		return "secure_tls_bootstrapping"
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_ARC_MSI:
+		return "arc_msi"
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_AZURE_MSI:
+		return "azure_msi"
+	default:
+		return ""
+	}
+}
+
+// getClusterJoinMethod returns the CLUSTER_JOIN_METHOD value consumed by ensureKubelet.
+func getClusterJoinMethod(bootstrapConfig *aksnodeconfigv1.BootstrappingConfig) string {
+	switch bootstrapConfig.GetClusterJoinMethod() {
+	case aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_GENERATE_CSR:
+		return "generate_csr"
+	case aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_USE_BOOTSTRAPPING_AUTH:
+		return "use_bootstrapping_auth"
+	default:
+		return ""
+	}
+}
+
+// getMSIMethod returns the managed identity used to authenticate the node, ok is false for non-MSI bootstrapping methods.
+func getMSIMethod(bootstrapConfig *aksnodeconfigv1.BootstrappingConfig) (method msiauth.Method, ok bool) {
+	switch bootstrapConfig.GetBootstrappingAuthMethod() {
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_ARC_MSI:
+		return msiauth.MethodArcMSI, true
+	case aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_AZURE_MSI:
+		return msiauth.MethodAzureMSI, true
+	default:
+		return "", false
+	}
+}
+
+// validateBootstrappingConfig rejects bootstrapping configurations the node cannot join the cluster with.
+func validateBootstrappingConfig(config *aksnodeconfigv1.Configuration) error {
+	bootstrapConfig := config.GetBootstrappingConfig()
+	method, isMSI := getMSIMethod(bootstrapConfig)
+	if bootstrapConfig.GetClusterJoinMethod() == aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_USE_BOOTSTRAPPING_AUTH && !isMSI {
+		return fmt.Errorf("cluster join method %s requires the %s or %s bootstrapping auth method, got %s",
+			bootstrapConfig.GetClusterJoinMethod(),
+			aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_ARC_MSI,
+			aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_AZURE_MSI,
+			bootstrapConfig.GetBootstrappingAuthMethod())
+	}
+	if !isMSI {
+		return nil
+	}
+	if config.GetApiServerConfig().GetApiServerName() == "" {
+		return fmt.Errorf("bootstrapping auth method %s requires the API server name", bootstrapConfig.GetBootstrappingAuthMethod())
+	}
+	if method == msiauth.MethodArcMSI && bootstrapConfig.GetSecureTlsBootstrappingUserAssignedIdentityId() != "" {
+		return fmt.Errorf("bootstrapping auth method %s only supports the system-assigned identity, user-assigned identity ID must be empty",
+			bootstrapConfig.GetBootstrappingAuthMethod())
+	}
+	return nil
+}
+
+// getBootstrappingKubeconfigContent returns the base64 kubeconfig of the MSI bootstrapping methods, which gets the
+// managed identity token from aks-node-controller acting as exec credential plugin. It is empty for other methods.
+// Depending on the cluster join method, ensureKubelet installs it as the bootstrap kubeconfig or as the kubeconfig.
+func getBootstrappingKubeconfigContent(config *aksnodeconfigv1.Configuration) (string, error) {
+	bootstrapConfig := config.GetBootstrappingConfig()
+	method, ok := getMSIMethod(bootstrapConfig)
+	if !ok {
+		return "", nil
+	}
+	clientID := ""
+	if method == msiauth.MethodAzureMSI {
+		clientID = bootstrapConfig.GetSecureTlsBootstrappingUserAssignedIdentityId()
+	}
+	kubeconfig, err := msiauth.Kubeconfig(msiauth.KubeconfigOptions{
+		Server:               fmt.Sprintf("https://%s:443", config.GetApiServerConfig().GetApiServerName()),
+		CertificateAuthority: kubeCACertFilepath,
+		User:                 msiKubeconfigUser,
+		Command:              aksNodeControllerFilepath,
+		Method:               method,
+		Resource:             bootstrapConfig.GetSecureTlsBootstrappingAadResource(),
+		ClientID:             clientID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error generating the %s bootstrapping kubeconfig: %w", method, err)
+	}
+	return base64.StdEncoding.EncodeToString(kubeconfig), nil
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/Azure/agentbaker/aks-node-controller/helpers"
 	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/pkg/agent/sysctlpolicy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/google/go-cmp/cmp"
 )
@@ -147,9 +148,10 @@ func Test_getSysctlContent(t *testing.T) {
 		s *aksnodeconfigv1.SysctlConfig
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    string
+		wantErr string
 	}{
 		{
 			name: "Default SysctlConfig",
@@ -164,6 +166,8 @@ net.ipv4.neigh.default.gc_thresh1=4096
 net.ipv4.neigh.default.gc_thresh2=8192
 net.ipv4.neigh.default.gc_thresh3=16384
 net.ipv4.tcp_max_syn_backlog=16384
+# This is a partial workaround to this upstream Kubernetes issue:
+# https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
 net.ipv4.tcp_retries2=8
 `)),
 		},
@@ -172,27 +176,54 @@ net.ipv4.tcp_retries2=8
 			args: args{
 				s: &aksnodeconfigv1.SysctlConfig{
 					NetIpv4TcpMaxSynBacklog: to.Ptr(int32(9999)),
-					NetCoreRmemDefault:      to.Ptr(int32(9999)),
-					NetIpv4IpLocalPortRange: to.Ptr("32768 62535"),
+					NetCoreRmemDefault:      to.Ptr(int32(262144)),
+					NetIpv4TcpTwReuse:       to.Ptr(true),
+					NetIpv4IpLocalPortRange: to.Ptr("32768 65535"),
 				},
 			},
 			want: base64.StdEncoding.EncodeToString(
 				[]byte(`net.core.message_burst=80
 net.core.message_cost=40
-net.core.rmem_default=9999
 net.core.somaxconn=16384
-net.ipv4.ip_local_port_range=32768 62535
 net.ipv4.neigh.default.gc_thresh1=4096
 net.ipv4.neigh.default.gc_thresh2=8192
 net.ipv4.neigh.default.gc_thresh3=16384
 net.ipv4.tcp_max_syn_backlog=9999
+# This is a partial workaround to this upstream Kubernetes issue:
+# https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
 net.ipv4.tcp_retries2=8
+# The following are sysctl configs passed from API
+net.core.rmem_default=262144
+net.ipv4.ip_local_port_range=32768 65535
+net.ipv4.ip_local_reserved_ports=65330
+net.ipv4.tcp_tw_reuse=1
 `)),
 		},
+		{
+			name: "SysctlConfig with values rejected by the policy",
+			args: args{
+				s: &aksnodeconfigv1.SysctlConfig{
+					NetCoreRmemDefault: to.Ptr(int32(9999)),
+					VmSwappiness:       to.Ptr(int32(101)),
+				},
+			},
+			wantErr: "invalid net.core.rmem_default: 9999 must be between 212992 and 134217728; " +
+				"invalid vm.swappiness: 101 must be between 0 and 100",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getSysctlContent(tt.args.s); got != tt.want {
+			got, err := getSysctlContent(tt.args.s, sysctlpolicy.Target{})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("getSysctlContent() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
 				t.Errorf("getSysctlContent() = %v, want %v", got, tt.want)
 			}
 		})
@@ -201,13 +232,16 @@ net.ipv4.tcp_retries2=8
 
 func Test_getUlimitContent(t *testing.T) {
 	type args struct {
-		u *aksnodeconfigv1.UlimitConfig
+		u                 *aksnodeconfigv1.UlimitConfig
+		osRelease         string
+		containerdVersion string
 	}
 	str9999 := "9999"
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    string
+		wantErr string
 	}{
 		{
 			name: "Default UlimitConfig",
@@ -226,10 +260,67 @@ func Test_getUlimitContent(t *testing.T) {
 			},
 			want: "[Service] LimitMEMLOCK=9999 LimitNOFILE=9999 ",
 		},
+		{
+			name: "UlimitConfig on Ubuntu with containerd 1.x",
+			args: args{
+				u: &aksnodeconfigv1.UlimitConfig{
+					NoFile:          &str9999,
+					MaxLockedMemory: &str9999,
+				},
+				osRelease:         "ID=ubuntu\nVERSION_ID=\"22.04\"\n",
+				containerdVersion: "1.7.27",
+			},
+			want: "[Service] LimitMEMLOCK=9999 LimitNOFILE=9999 ",
+		},
+		{
+			name: "UlimitConfig on Ubuntu with containerd 2.x, which removed LimitNOFILE",
+			args: args{
+				u: &aksnodeconfigv1.UlimitConfig{
+					NoFile:          &str9999,
+					MaxLockedMemory: &str9999,
+				},
+				osRelease:         "ID=ubuntu\nVERSION_ID=\"24.04\"\n",
+				containerdVersion: "2.0.4",
+			},
+			want: "[Service] LimitMEMLOCK=9999 ",
+		},
+		{
+			name: "UlimitConfig on Azure Linux with containerd 2.x",
+			args: args{
+				u: &aksnodeconfigv1.UlimitConfig{
+					NoFile:          &str9999,
+					MaxLockedMemory: &str9999,
+				},
+				osRelease:         "ID=azurelinux\nVERSION_ID=\"3.0\"\n",
+				containerdVersion: "2.0.4",
+			},
+			want: "[Service] LimitMEMLOCK=9999 LimitNOFILE=9999 ",
+		},
+		{
+			name: "UlimitConfig with values rejected by the policy",
+			args: args{
+				u: &aksnodeconfigv1.UlimitConfig{
+					NoFile:          to.Ptr("16"),
+					MaxLockedMemory: to.Ptr("9999 LimitCORE=infinity"),
+				},
+			},
+			wantErr: `invalid LimitMEMLOCK: "9999 LimitCORE=infinity" is not a size in bytes with an optional K, M, G or T suffix; ` +
+				"invalid LimitNOFILE: 16 must be between 1024 and 1048576",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getUlimitContent(tt.args.u); got != tt.want {
+			got, err := getUlimitContent(tt.args.u, SysctlTarget(tt.args.osRelease, tt.args.containerdVersion))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("getUlimitContent() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
 				t.Errorf("getUlimitContent() = %v, want %v", got, tt.want)
 			}
 		})
@@ -384,19 +475,18 @@ func Test_getContainerdConfig(t *testing.T) {
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
 [plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
   enable_cdi = true
   [plugins."io.containerd.grpc.v1.cri".containerd]
     default_runtime_name = "runc"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      SystemdCgroup = true
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        BinaryName = "/usr/bin/runc"
+        SystemdCgroup = true
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/runc"
   [plugins."io.containerd.grpc.v1.cri".registry.headers]
     X-Meta-Source-Client = ["azure/aks"]
 [metrics]
@@ -413,18 +503,17 @@ oom_score = -999
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
 [plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
   enable_cdi = true
   [plugins."io.containerd.grpc.v1.cri".containerd]
     default_runtime_name = "runc"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        BinaryName = "/usr/bin/runc"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/runc"
   [plugins."io.containerd.grpc.v1.cri".registry.headers]
     X-Meta-Source-Client = ["azure/aks"]
 [metrics]
@@ -445,19 +534,18 @@ oom_score = -999
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
 [plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
   enable_cdi = true
   [plugins."io.containerd.grpc.v1.cri".containerd]
     default_runtime_name = "nvidia-container-runtime"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
-      SystemdCgroup = true
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime.options]
+        BinaryName = "/usr/bin/nvidia-container-runtime"
+        SystemdCgroup = true
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/nvidia-container-runtime"
   [plugins."io.containerd.grpc.v1.cri".registry.headers]
     X-Meta-Source-Client = ["azure/aks"]
 [metrics]
@@ -475,19 +563,18 @@ oom_score = -999
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
 [plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
   enable_cdi = true
   [plugins."io.containerd.grpc.v1.cri".containerd]
     default_runtime_name = "runc"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      SystemdCgroup = true
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        BinaryName = "/usr/bin/runc"
+        SystemdCgroup = true
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/runc"
   [plugins."io.containerd.grpc.v1.cri".registry.headers]
     X-Meta-Source-Client = ["azure/aks"]
 [metrics]
@@ -497,7 +584,11 @@ oom_score = -999
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getContainerdConfigBase64(tt.args.aksnodeconfig, ""); got != tt.want {
+			got, err := getContainerdConfigBase64(tt.args.aksnodeconfig, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
 				t.Errorf("getContainerdConfig() = %v, want %v", got, tt.want)
 			}
 		})
@@ -525,20 +616,17 @@ func Test_getContainerdConfigV2(t *testing.T) {
 			},
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
-[plugins."io.containerd.cri.v1.images"]
-  [plugins."io.containerd.cri.v1.images".pinned_images]
-    sandbox = ""
-  [plugins."io.containerd.cri.v1.images".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
+[plugins."io.containerd.cri.v1.images".registry.headers]
+  X-Meta-Source-Client = ["azure/aks"]
 [plugins."io.containerd.cri.v1.runtime".containerd]
-    default_runtime_name = "runc"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
+  default_runtime_name = "runc"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc.options]
       BinaryName = "/usr/bin/runc"
       SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
       BinaryName = "/usr/bin/runc"
 [metrics]
@@ -561,20 +649,17 @@ oom_score = -999
 			},
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
-[plugins."io.containerd.cri.v1.images"]
-  [plugins."io.containerd.cri.v1.images".pinned_images]
-    sandbox = ""
-  [plugins."io.containerd.cri.v1.images".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
+[plugins."io.containerd.cri.v1.images".registry.headers]
+  X-Meta-Source-Client = ["azure/aks"]
 [plugins."io.containerd.cri.v1.runtime".containerd]
-    default_runtime_name = "nvidia-container-runtime"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia-container-runtime]
-      runtime_type = "io.containerd.runc.v2"
+  default_runtime_name = "nvidia-container-runtime"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia-container-runtime]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia-container-runtime.options]
       BinaryName = "/usr/bin/nvidia-container-runtime"
       SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
       BinaryName = "/usr/bin/nvidia-container-runtime"
 [metrics]
@@ -596,20 +681,17 @@ oom_score = -999
 			},
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
-[plugins."io.containerd.cri.v1.images"]
-  [plugins."io.containerd.cri.v1.images".pinned_images]
-    sandbox = ""
-  [plugins."io.containerd.cri.v1.images".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
+[plugins."io.containerd.cri.v1.images".registry.headers]
+  X-Meta-Source-Client = ["azure/aks"]
 [plugins."io.containerd.cri.v1.runtime".containerd]
-    default_runtime_name = "runc"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
+  default_runtime_name = "runc"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc.options]
       BinaryName = "/usr/bin/runc"
       SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
+  [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
       BinaryName = "/usr/bin/runc"
 [metrics]
@@ -617,7 +699,7 @@ oom_score = -999
 `)),
 		},
 		{
-			name: "Containerd v1 still uses old templates",
+			name: "Containerd v1 uses the containerd 1.x CRI plugin",
 			args: args{
 				aksnodeconfig: &aksnodeconfigv1.Configuration{
 					NeedsCgroupv2: to.Ptr(true),
@@ -629,19 +711,18 @@ oom_score = -999
 			want: base64.StdEncoding.EncodeToString([]byte(`version = 2
 oom_score = -999
 [plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
   enable_cdi = true
   [plugins."io.containerd.grpc.v1.cri".containerd]
     default_runtime_name = "runc"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      SystemdCgroup = true
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        BinaryName = "/usr/bin/runc"
+        SystemdCgroup = true
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
       runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/runc"
   [plugins."io.containerd.grpc.v1.cri".registry.headers]
     X-Meta-Source-Client = ["azure/aks"]
 [metrics]
@@ -653,10 +734,14 @@ oom_score = -999
 		t.Run(tt.name, func(t *testing.T) {
 			containerdVersion := tt.args.aksnodeconfig.GetContainerdConfig().GetContainerdVersion()
 			var got string
+			var err error
 			if tt.args.noGpu {
-				got = getNoGPUContainerdConfigBase64(tt.args.aksnodeconfig, containerdVersion)
+				got, err = getNoGPUContainerdConfigBase64(tt.args.aksnodeconfig, containerdVersion)
 			} else {
-				got = getContainerdConfigBase64(tt.args.aksnodeconfig, containerdVersion)
+				got, err = getContainerdConfigBase64(tt.args.aksnodeconfig, containerdVersion)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
 			if got != tt.want {
 				t.Errorf("getContainerdConfig() = %v, want %v", got, tt.want)
@@ -1588,7 +1673,11 @@ func Test_getKubeletConfigFileContent(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getKubeletConfigFileContent(tt.args.kubeletConfig); got != tt.want {
+			got, err := getKubeletConfigFileContent(tt.args.kubeletConfig, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
 				// Normalize JSON strings to avoid any formatting differences such as space, indent, line breaking
 				var gotJSON, wantJSON map[string]any
 				if err := json.Unmarshal([]byte(got), &gotJSON); err != nil {
@@ -1607,6 +1696,74 @@ func Test_getKubeletConfigFileContent(t *testing.T) {
 	}
 }
 
+func Test_getKubeletConfigFileContent_UpstreamFieldNames(t *testing.T) {
+	kubeletConfig := &aksnodeconfigv1.KubeletConfig{
+		KubeletConfigFileConfig: &aksnodeconfigv1.KubeletConfigFileConfig{
+			Authentication: &aksnodeconfigv1.KubeletAuthentication{
+				Webhook: &aksnodeconfigv1.KubeletWebhookAuthentication{Enabled: true, CacheTtl: "2m0s"},
+			},
+			Authorization: &aksnodeconfigv1.KubeletAuthorization{
+				Mode:    "Webhook",
+				Webhook: &aksnodeconfigv1.KubeletWebhookAuthorization{CacheAuthorizedTtl: "5m0s", CacheUnauthorizedTtl: "30s"},
+			},
+		},
+	}
+	got, err := getKubeletConfigFileContent(kubeletConfig, "1.33.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{
+    "kind": "KubeletConfiguration",
+    "apiVersion": "kubelet.config.k8s.io/v1beta1",
+    "authentication": {
+        "webhook": {
+            "enabled": true,
+            "cacheTTL": "2m0s"
+        }
+    },
+    "authorization": {
+        "mode": "Webhook",
+        "webhook": {
+            "cacheAuthorizedTTL": "5m0s",
+            "cacheUnauthorizedTTL": "30s"
+        }
+    }
+}`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generated config file is different than expected (-want +got):\n%s", diff)
+	}
+}
+
+func Test_getKubeletConfigFileContent_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *aksnodeconfigv1.KubeletConfigFileConfig
+		wantErr string
+	}{
+		{
+			name:    "invalid duration",
+			config:  &aksnodeconfigv1.KubeletConfigFileConfig{NodeStatusUpdateFrequency: "10 seconds"},
+			wantErr: "error converting kubelet config file content",
+		},
+		{
+			name: "invalid image GC thresholds",
+			config: &aksnodeconfigv1.KubeletConfigFileConfig{
+				ImageGcHighThresholdPercent: to.Ptr(int32(80)),
+				ImageGcLowThresholdPercent:  to.Ptr(int32(85)),
+			},
+			wantErr: "imageGCLowThresholdPercent 85 must be less than imageGCHighThresholdPercent 80",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := getKubeletConfigFileContent(&aksnodeconfigv1.KubeletConfig{KubeletConfigFileConfig: tt.config}, "1.33.0")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("getKubeletConfigFileContent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func Test_getKubeletFlags(t *testing.T) {
 	type args struct {
 		kubeletConfig *aksnodeconfigv1.KubeletConfig
@@ -1955,12 +2112,28 @@ func Test_getLocalDNSCorefileBase64(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getLocalDnsCorefileBase64WithHostsPlugin(tt.args.aksnodeconfig, tt.args.includeHostsPlugin)
+			got, err := getLocalDnsCorefileBase64WithHostsPlugin(tt.args.aksnodeconfig, tt.args.includeHostsPlugin)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			assertCorefileBase64Contains(t, got, tt.wantContains, tt.wantNotContains)
 		})
 	}
 }
 
+func Test_getLocalDNSCorefileBase64NilOverride(t *testing.T) {
+	aksnodeconfig := &aksnodeconfigv1.Configuration{
+		LocalDnsProfile: &aksnodeconfigv1.LocalDnsProfile{
+			EnableLocalDns:   true,
+			KubeDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{".": nil},
+		},
+	}
+	_, err := getLocalDnsCorefileBase64WithHostsPlugin(aksnodeconfig, true)
+	if err == nil || !strings.Contains(err.Error(), `invalid KubeDns overrides: override for domain "." is nil`) {
+		t.Fatalf("expected nil override error, got %v", err)
+	}
+}
+
 func Test_getLocalDNSCorefileBase64ForwardHealthCheckAndFailfast(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -2100,7 +2273,10 @@ func Test_getLocalDNSCorefileBase64ForwardHealthCheckAndFailfast(t *testing.T) {
 			} else {
 				profile.VnetDnsOverrides = map[string]*aksnodeconfigv1.LocalDnsOverrides{".": override}
 			}
-			got := getLocalDnsCorefileBase64WithHostsPlugin(&aksnodeconfigv1.Configuration{LocalDnsProfile: profile}, false)
+			got, err := getLocalDnsCorefileBase64WithHostsPlugin(&aksnodeconfigv1.Configuration{LocalDnsProfile: profile}, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
 			decoded, err := base64.StdEncoding.DecodeString(got)
 			if err != nil {
@@ -2119,6 +2295,70 @@ func Test_getLocalDNSCorefileBase64ForwardHealthCheckAndFailfast(t *testing.T) {
 	}
 }
 
+func Test_validateLocalDnsProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *aksnodeconfigv1.LocalDnsProfile
+		wantErr string
+	}{
+		{
+			name: "LocalDnsProfile disabled is not validated",
+			profile: &aksnodeconfigv1.LocalDnsProfile{
+				KubeDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{".": {Protocol: "QUIC"}},
+			},
+		},
+		{
+			name: "supported overrides are accepted",
+			profile: &aksnodeconfigv1.LocalDnsProfile{
+				EnableLocalDns:    true,
+				EnableHostsPlugin: true,
+				VnetDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{
+					".": {Protocol: "PreferUDP", ForwardDestination: "VnetDNS", CacheDurationInSeconds: to.Ptr(int32(3600))},
+				},
+				CriticalFqdns: []string{"mcr.microsoft.com", "packages.aks.azure.com"},
+			},
+		},
+		{
+			name: "unknown protocol is rejected",
+			profile: &aksnodeconfigv1.LocalDnsProfile{
+				EnableLocalDns:   true,
+				KubeDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{".": {Protocol: "QUIC"}},
+			},
+			wantErr: `KubeDNS override ".": protocol "QUIC" is invalid`,
+		},
+		{
+			name: "shadowed forward destination is rejected",
+			profile: &aksnodeconfigv1.LocalDnsProfile{
+				EnableLocalDns:   true,
+				VnetDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{".": {ForwardDestination: "ClusterCoreDNS"}},
+			},
+			wantErr: `VnetDNS override ".": forwardDestination ClusterCoreDNS is shadowed`,
+		},
+		{
+			name: "out of range cache duration is rejected",
+			profile: &aksnodeconfigv1.LocalDnsProfile{
+				EnableLocalDns:   true,
+				VnetDnsOverrides: map[string]*aksnodeconfigv1.LocalDnsOverrides{"example.com": {CacheDurationInSeconds: to.Ptr(int32(-5))}},
+			},
+			wantErr: `VnetDNS override "example.com": cacheDurationInSeconds -5 must be between 1 and 86400`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLocalDnsProfile(&aksnodeconfigv1.Configuration{LocalDnsProfile: tt.profile})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func Test_shouldEnableLocalDns(t *testing.T) {
 	type args struct {
 		aksnodeconfig *aksnodeconfigv1.Configuration
@@ -30,22 +30,40 @@ func executeBootstrapTemplate(inputContract *aksnodeconfigv1.Configuration) (str
 	return buffer.String(), nil
 }
 
+// getCSEEnv returns the environment of the CSE. A *CSEEnvError is returned when a value cannot be generated
+// from the AKSNodeConfig, rather than passing a broken value to the CSE.
+//
 //nolint:funlen
-func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuConfig *gpu.GPUConfiguration) map[string]string {
-	// streamingConnectionIdleTimeout was removed from KubeletConfiguration in k8s 1.34+.
-	// Clear it from both KubeletFlags and KubeletConfigFileConfig so it doesn't appear
-	// on the command line or in the marshaled config file JSON.
-	if IsKubernetesVersionGe(config.GetKubernetesVersion(), "1.34.0") {
-		if kc := config.GetKubeletConfig(); kc != nil {
-			delete(kc.KubeletFlags, "--streaming-connection-idle-timeout")
-			if kcfg := kc.GetKubeletConfigFileConfig(); kcfg != nil {
-				kcfg.StreamingConnectionIdleTimeout = ""
-			}
-		}
+func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuConfig *gpu.GPUConfiguration) (map[string]string, error) {
+	cloudProviderSettings := getCloudProviderSettings(config)
+
+	var errs envErrors
+	resolvedContainerdVersion, err := containerdVersions.resolve(config)
+	errs.add("CONTAINERD_CONFIG_VERSION", err)
+	containerdVersion := resolvedContainerdVersion.Version
+	containerdConfig, err := getContainerdConfigBase64(config, containerdVersion)
+	errs.add("CONTAINERD_CONFIG_CONTENT", err)
+	noGPUContainerdConfig, err := getNoGPUContainerdConfigBase64(config, containerdVersion)
+	errs.add("CONTAINERD_CONFIG_NO_GPU_CONTENT", err)
+	kubeletConfigFileContent, err := getKubeletConfigFileContentBase64(config.GetKubeletConfig(), config.GetKubernetesVersion())
+	errs.add("KUBELET_CONFIG_FILE_CONTENT", err)
+	sysctlTarget := getSysctlTarget(containerdVersion)
+	sysctlContent, err := getSysctlContent(config.GetCustomLinuxOsConfig().GetSysctlConfig(), sysctlTarget)
+	errs.add("SYSCTL_CONTENT", err)
+	ulimitContent, err := getUlimitContent(config.GetCustomLinuxOsConfig().GetUlimitConfig(), sysctlTarget)
+	errs.add("CONTAINERD_ULIMITS", err)
+	localDnsCorefileBase, err := getLocalDnsCorefileBase64WithHostsPlugin(config, false)
+	errs.add("LOCALDNS_COREFILE_BASE", err)
+	localDnsCorefileWithHosts, err := getLocalDnsCorefileBase64WithHostsPlugin(config, true)
+	errs.add("LOCALDNS_COREFILE_WITH_HOSTS", err)
+	bootstrappingKubeconfig, err := getBootstrappingKubeconfigContent(config)
+	errs.add("BOOTSTRAPPING_KUBECONFIG_CONTENT", err)
+	containerdRegistryHosts, err := getContainerdRegistryHostsContent(config)
+	errs.add("CONTAINERD_REGISTRY_HOSTS_CONTENT", err)
+	if err := errs.err(); err != nil {
+		return nil, err
 	}
 
-	containerdVersion, _ := detectContainerdVersion(ctx)
-	cloudProviderSettings := getCloudProviderSettings(config)
 	env := map[string]string{
 		"PROVISION_OUTPUT":                                     "/var/log/azure/cluster-provision-cse-output.log",
 		"MOBY_VERSION":                                         "",
@@ -123,7 +141,7 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 		"SHOULD_CONFIG_SWAP_FILE":                              fmt.Sprintf("%v", getEnableSwapConfig(config.GetCustomLinuxOsConfig())),
 		"SHOULD_CONFIG_TRANSPARENT_HUGE_PAGE":                  fmt.Sprintf("%v", getShouldConfigTransparentHugePage(config.GetCustomLinuxOsConfig())),
 		"SHOULD_CONFIG_CONTAINERD_ULIMITS":                     fmt.Sprintf("%v", getShouldConfigContainerdUlimits(config.GetCustomLinuxOsConfig().GetUlimitConfig())),
-		"CONTAINERD_ULIMITS":                                   getUlimitContent(config.GetCustomLinuxOsConfig().GetUlimitConfig()),
+		"CONTAINERD_ULIMITS":                                   ulimitContent,
 		"TARGET_CLOUD":                                         getTargetCloud(config),
 		"TARGET_ENVIRONMENT":                                   getTargetEnvironment(config),
 		"ARM_RESOURCE_ENDPOINT":                                getArmResourceEndpoint(config),
@@ -144,6 +162,9 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 		"PROXY_VARS":                                           getProxyVariables(config.GetHttpProxyConfig()),
 		"TLS_BOOTSTRAP_TOKEN":                                  config.GetBootstrappingConfig().GetTlsBootstrappingToken(),
 		"ENABLE_SECURE_TLS_BOOTSTRAPPING":                      fmt.Sprintf("%v", getEnableSecureTLSBootstrapping(config.GetBootstrappingConfig())),
+		"BOOTSTRAPPING_AUTH_METHOD":                            getBootstrappingAuthMethod(config.GetBootstrappingConfig()),
+		"CLUSTER_JOIN_METHOD":                                  getClusterJoinMethod(config.GetBootstrappingConfig()),
+		"BOOTSTRAPPING_KUBECONFIG_CONTENT":                     bootstrappingKubeconfig,
 		"SECURE_TLS_BOOTSTRAPPING_AAD_RESOURCE":                config.GetBootstrappingConfig().GetSecureTlsBootstrappingAadResource(),
 		"SECURE_TLS_BOOTSTRAPPING_USER_ASSIGNED_IDENTITY_ID":   config.GetBootstrappingConfig().GetSecureTlsBootstrappingUserAssignedIdentityId(),
 		"SECURE_TLS_BOOTSTRAPPING_VALIDATE_KUBECONFIG_TIMEOUT": config.GetBootstrappingConfig().GetSecureTlsBootstrappingValidateKubeconfigTimeout(),
@@ -162,7 +183,7 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 		"KUBELET_CLIENT_CONTENT":                               config.GetKubeletConfig().GetKubeletClientKey(),
 		"KUBELET_CLIENT_CERT_CONTENT":                          config.GetKubeletConfig().GetKubeletClientCertContent(),
 		"KUBELET_CONFIG_FILE_ENABLED":                          fmt.Sprintf("%v", config.GetKubeletConfig().GetEnableKubeletConfigFile()),
-		"KUBELET_CONFIG_FILE_CONTENT":                          getKubeletConfigFileContentBase64(config.GetKubeletConfig()),
+		"KUBELET_CONFIG_FILE_CONTENT":                          kubeletConfigFileContent,
 		"SWAP_FILE_SIZE_MB":                                    fmt.Sprintf("%v", config.GetCustomLinuxOsConfig().GetSwapFileSize()),
 		"GPU_DRIVER_VERSION":                                   getGpuDriverVersion(config.GetVmSize(), gpuConfig),
 		"GPU_IMAGE_SHA":                                        getGpuImageSha(config.GetVmSize(), gpuConfig),
@@ -180,11 +201,14 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 		"AZURE_ENVIRONMENT_FILEPATH":                           getAzureEnvironmentFilepath(config),
 		"KUBE_CA_CRT":                                          config.GetKubernetesCaCert(),
 		"KUBENET_TEMPLATE":                                     getKubenetTemplate(),
-		"CONTAINERD_CONFIG_CONTENT":                            getContainerdConfigBase64(config, containerdVersion),
-		"CONTAINERD_CONFIG_NO_GPU_CONTENT":                     getNoGPUContainerdConfigBase64(config, containerdVersion),
+		"CONTAINERD_CONFIG_CONTENT":                            containerdConfig,
+		"CONTAINERD_CONFIG_NO_GPU_CONTENT":                     noGPUContainerdConfig,
+		"CONTAINERD_REGISTRY_HOSTS_CONTENT":                    containerdRegistryHosts,
+		"CONTAINERD_CONFIG_VERSION":                            containerdVersion,
+		"CONTAINERD_CONFIG_VERSION_SOURCE":                     string(resolvedContainerdVersion.Source),
 		"IS_KATA":                                              fmt.Sprintf("%v", config.GetIsKata()),
 		"ARTIFACT_STREAMING_ENABLED":                           fmt.Sprintf("%v", config.GetEnableArtifactStreaming()),
-		"SYSCTL_CONTENT":                                       getSysctlContent(config.GetCustomLinuxOsConfig().GetSysctlConfig()),
+		"SYSCTL_CONTENT":                                       sysctlContent,
 		"PRIVATE_EGRESS_PROXY_ADDRESS":                         config.GetPrivateEgressProxyAddress(),
 		"BOOTSTRAP_PROFILE_CONTAINER_REGISTRY_SERVER":          config.GetBootstrapProfileContainerRegistryServer(),
 		"ENABLE_IMDS_RESTRICTION":                              fmt.Sprintf("%v", config.GetImdsRestrictionConfig().GetEnableImdsRestriction()),
@@ -199,9 +223,9 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 		// LOCALDNS_GENERATED_COREFILE is the legacy key read by older VHDs that predate the hosts plugin.
 		// It must remain the base (no hosts plugin) corefile for backward compatibility.
 		// LOCALDNS_COREFILE_BASE is the new explicit name used by the dynamic corefile selection logic.
-		"LOCALDNS_GENERATED_COREFILE":                  getLocalDnsCorefileBase64WithHostsPlugin(config, false),
-		"LOCALDNS_COREFILE_BASE":                       getLocalDnsCorefileBase64WithHostsPlugin(config, false),
-		"LOCALDNS_COREFILE_WITH_HOSTS":                 getLocalDnsCorefileBase64WithHostsPlugin(config, true),
+		"LOCALDNS_GENERATED_COREFILE":                  localDnsCorefileBase,
+		"LOCALDNS_COREFILE_BASE":                       localDnsCorefileBase,
+		"LOCALDNS_COREFILE_WITH_HOSTS":                 localDnsCorefileWithHosts,
 		"DISABLE_PUBKEY_AUTH":                          fmt.Sprintf("%v", config.GetDisablePubkeyAuth()),
 		"SERVICE_ACCOUNT_IMAGE_PULL_ENABLED":           fmt.Sprintf("%v", config.GetServiceAccountImagePullProfile().GetEnabled()),
 		"SERVICE_ACCOUNT_IMAGE_PULL_DEFAULT_CLIENT_ID": config.GetServiceAccountImagePullProfile().GetDefaultClientId(),
@@ -219,7 +243,7 @@ func getCSEEnv(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuCo
 	for i, cert := range config.CustomCaCerts {
 		env[fmt.Sprintf("CUSTOM_CA_CERT_%d", i)] = removeNewlines(cert)
 	}
-	return env
+	return env, nil
 }
 
 type cloudProviderSettings struct {
@@ -307,6 +331,15 @@ func mapToEnviron(input map[string]string) []string {
 }
 
 func BuildCSECmd(ctx context.Context, config *aksnodeconfigv1.Configuration, gpuConfig *gpu.GPUConfiguration) (*exec.Cmd, error) {
+	if err := applyKubeletFlagPolicy(config); err != nil {
+		return nil, err
+	}
+	if err := validateBootstrappingConfig(config); err != nil {
+		return nil, err
+	}
+	if err := validateLocalDnsProfile(config); err != nil {
+		return nil, err
+	}
 	triggerBootstrapScript, err := executeBootstrapTemplate(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute the template: %w", err)
@@ -314,7 +347,11 @@ func BuildCSECmd(ctx context.Context, config *aksnodeconfigv1.Configuration, gpu
 	// Convert to one-liner
 	triggerBootstrapScript = strings.ReplaceAll(triggerBootstrapScript, "\n", " ")
 	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", triggerBootstrapScript)
-	env := mapToEnviron(getCSEEnv(ctx, config, gpuConfig))
+	cseEnv, err := getCSEEnv(ctx, config, gpuConfig)
+	if err != nil {
+		return nil, err
+	}
+	env := mapToEnviron(cseEnv)
 	cmd.Env = append(os.Environ(), env...) // append existing environment variables
 	sort.Strings(cmd.Env)
 	return cmd, nil
@@ -7,12 +7,14 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/Azure/agentbaker/aks-node-controller/helpers"
 	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
 	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,20 +51,18 @@ func TestBuildCSECmd(t *testing.T) {
 				require.NoError(t, err)
 				expectedShimConfig := `version = 2
 oom_score = -999
-[plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = ""
-  [plugins."io.containerd.grpc.v1.cri".containerd]
-    default_runtime_name = "runc"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  default_runtime_name = "runc"
+  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
       BinaryName = "/usr/bin/runc"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
+  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
+    runtime_type = "io.containerd.runc.v2"
     [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
       BinaryName = "/usr/bin/runc"
-  [plugins."io.containerd.grpc.v1.cri".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
+[plugins."io.containerd.grpc.v1.cri".registry.headers]
+  X-Meta-Source-Client = ["azure/aks"]
 [metrics]
   address = "0.0.0.0:10257"
 `
@@ -205,10 +205,11 @@ oom_score = -999
 					SwapFileSize:               int32(1500),
 					TransparentHugepageSupport: "never",
 					TransparentDefrag:          "defer+madvise",
+					// net.core.wmem_default is the kernel default, the lowest value accepted by the sysctl policy.
 					SysctlConfig: &aksnodeconfigv1.SysctlConfig{
 						NetCoreSomaxconn:             to.Ptr[int32](1638499),
 						NetCoreRmemDefault:           to.Ptr[int32](456000),
-						NetCoreWmemDefault:           to.Ptr[int32](89000),
+						NetCoreWmemDefault:           to.Ptr[int32](212992),
 						NetIpv4TcpTwReuse:            to.Ptr(true),
 						NetIpv4IpLocalPortRange:      to.Ptr("32768 65400"),
 						NetIpv4TcpMaxSynBacklog:      to.Ptr[int32](1638498),
@@ -222,6 +223,7 @@ oom_score = -999
 				require.NoError(t, err)
 				assert.Contains(t, sysctlContent, "net.core.somaxconn=1638499")
 				assert.Contains(t, sysctlContent, "net.ipv4.tcp_max_syn_backlog=1638498")
+				assert.Contains(t, sysctlContent, "net.core.wmem_default=212992")
 				assert.Contains(t, sysctlContent, "net.ipv4.neigh.default.gc_thresh1=10001")
 				assert.Contains(t, sysctlContent, "net.ipv4.neigh.default.gc_thresh2=8192")
 				assert.Contains(t, sysctlContent, "net.ipv4.neigh.default.gc_thresh3=16384")
@@ -282,12 +284,6 @@ oom_score = -999
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Set up a fake containerd binary returning v1 so detectContainerdVersion
-			// consistently returns 1.7.22 regardless of the host system.
-			fakeBinDir := t.TempDir()
-			require.NoError(t, os.WriteFile(fakeBinDir+"/containerd", []byte("#!/bin/sh\necho 'containerd containerd.io 1.7.22 c814c75'\n"), 0755))
-			t.Setenv("PATH", fakeBinDir+":"+os.Getenv("PATH"))
-
 			aksNodeConfig := &aksnodeconfigv1.Configuration{
 				LinuxAdminUsername: "azureuser",
 				VmSize:             "Standard_DS1_v2",
@@ -490,6 +486,137 @@ func TestBuildCSECmd_StreamingConnectionIdleTimeout_VersionGated(t *testing.T) {
 	}
 }
 
+func TestBuildCSECmd_KubeletFlagPolicy(t *testing.T) {
+	t.Run("rejects flags removed by the kubernetes version", func(t *testing.T) {
+		config := &aksnodeconfigv1.Configuration{
+			KubernetesVersion: "1.27.1",
+			KubeletConfig: &aksnodeconfigv1.KubeletConfig{
+				KubeletFlags: map[string]string{"--container-runtime": "remote", "--max-pods": "30"},
+			},
+		}
+		_, err := BuildCSECmd(context.TODO(), config, nil)
+		var invalid *kubeletpolicy.InvalidFlagsError
+		require.ErrorAs(t, err, &invalid)
+		require.Len(t, invalid.Violations, 1)
+		assert.Equal(t, "--container-runtime", invalid.Violations[0].Flag)
+	})
+
+	t.Run("drops removed flags which are still sent by the RP", func(t *testing.T) {
+		config := &aksnodeconfigv1.Configuration{
+			KubernetesVersion: "1.30.0",
+			KubeletConfig: &aksnodeconfigv1.KubeletConfig{
+				KubeletFlags: map[string]string{"--azure-container-registry-config": "/etc/kubernetes/azure.json", "--max-pods": "30"},
+			},
+		}
+		cmd, err := BuildCSECmd(context.TODO(), config, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "--max-pods=30", environToMap(cmd.Env)["KUBELET_FLAGS"])
+	})
+}
+
+func TestBuildCSECmd_MSIBootstrapping(t *testing.T) {
+	newConfig := func(method aksnodeconfigv1.BootstrappingAuthMethod, join aksnodeconfigv1.ClusterJoinMethod) *aksnodeconfigv1.Configuration {
+		return &aksnodeconfigv1.Configuration{
+			ApiServerConfig: &aksnodeconfigv1.ApiServerConfig{ApiServerName: "example.hcp.eastus.azmk8s.io"},
+			BootstrappingConfig: &aksnodeconfigv1.BootstrappingConfig{
+				BootstrappingAuthMethod: method,
+				ClusterJoinMethod:       join,
+			},
+		}
+	}
+
+	t.Run("azure MSI with user-assigned identity", func(t *testing.T) {
+		config := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_AZURE_MSI,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_USE_BOOTSTRAPPING_AUTH)
+		config.BootstrappingConfig.SecureTlsBootstrappingUserAssignedIdentityId = to.Ptr("11111111-1111-1111-1111-111111111111")
+		cmd, err := BuildCSECmd(context.TODO(), config, nil)
+		require.NoError(t, err)
+		vars := environToMap(cmd.Env)
+		assertHasKeyWithValue(t, vars, "BOOTSTRAPPING_AUTH_METHOD", "azure_msi")
+		assertHasKeyWithValue(t, vars, "CLUSTER_JOIN_METHOD", "use_bootstrapping_auth")
+		kubeconfig, err := getBase64DecodedValue([]byte(vars["BOOTSTRAPPING_KUBECONFIG_CONTENT"]))
+		require.NoError(t, err)
+		assert.Contains(t, kubeconfig, "server: https://example.hcp.eastus.azmk8s.io:443")
+		assert.Contains(t, kubeconfig, "command: /opt/azure/containers/aks-node-controller")
+		assert.Contains(t, kubeconfig, "- azure-msi")
+		assert.Contains(t, kubeconfig, "- 6dae42f8-4368-4678-94ff-3960e28e3630")
+		assert.Contains(t, kubeconfig, "- 11111111-1111-1111-1111-111111111111")
+	})
+
+	t.Run("arc MSI with a custom resource", func(t *testing.T) {
+		config := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_ARC_MSI,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_GENERATE_CSR)
+		config.BootstrappingConfig.SecureTlsBootstrappingAadResource = to.Ptr("custom-resource")
+		cmd, err := BuildCSECmd(context.TODO(), config, nil)
+		require.NoError(t, err)
+		vars := environToMap(cmd.Env)
+		assertHasKeyWithValue(t, vars, "BOOTSTRAPPING_AUTH_METHOD", "arc_msi")
+		assertHasKeyWithValue(t, vars, "CLUSTER_JOIN_METHOD", "generate_csr")
+		kubeconfig, err := getBase64DecodedValue([]byte(vars["BOOTSTRAPPING_KUBECONFIG_CONTENT"]))
+		require.NoError(t, err)
+		assert.Contains(t, kubeconfig, "- arc-msi")
+		assert.Contains(t, kubeconfig, "- custom-resource")
+		assert.NotContains(t, kubeconfig, "--client-id")
+	})
+
+	t.Run("bootstrap token has no MSI kubeconfig", func(t *testing.T) {
+		config := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_BOOTSTRAP_TOKEN,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_UNSPECIFIED)
+		cmd, err := BuildCSECmd(context.TODO(), config, nil)
+		require.NoError(t, err)
+		vars := environToMap(cmd.Env)
+		assertHasKeyWithValue(t, vars, "BOOTSTRAPPING_AUTH_METHOD", "bootstrap_token")
+		assertHasKeyWithValue(t, vars, "BOOTSTRAPPING_KUBECONFIG_CONTENT", "")
+	})
+
+	t.Run("rejects invalid configurations", func(t *testing.T) {
+		noAPIServer := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_AZURE_MSI,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_GENERATE_CSR)
+		noAPIServer.ApiServerConfig = nil
+		_, err := BuildCSECmd(context.TODO(), noAPIServer, nil)
+		assert.ErrorContains(t, err, "requires the API server name")
+
+		arcUserAssigned := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_ARC_MSI,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_GENERATE_CSR)
+		arcUserAssigned.BootstrappingConfig.SecureTlsBootstrappingUserAssignedIdentityId = to.Ptr("id")
+		_, err = BuildCSECmd(context.TODO(), arcUserAssigned, nil)
+		assert.ErrorContains(t, err, "only supports the system-assigned identity")
+
+		tokenJoin := newConfig(aksnodeconfigv1.BootstrappingAuthMethod_BOOTSTRAPPING_AUTH_METHOD_BOOTSTRAP_TOKEN,
+			aksnodeconfigv1.ClusterJoinMethod_CLUSTER_JOIN_METHOD_USE_BOOTSTRAPPING_AUTH)
+		_, err = BuildCSECmd(context.TODO(), tokenJoin, nil)
+		assert.ErrorContains(t, err, "CLUSTER_JOIN_METHOD_USE_BOOTSTRAPPING_AUTH requires")
+	})
+}
+
+func TestBuildCSECmd_ReturnsEnvErrors(t *testing.T) {
+	newConfig := func(portRange string) *aksnodeconfigv1.Configuration {
+		return &aksnodeconfigv1.Configuration{
+			CustomLinuxOsConfig: &aksnodeconfigv1.CustomLinuxOsConfig{
+				SysctlConfig: &aksnodeconfigv1.SysctlConfig{NetIpv4IpLocalPortRange: to.Ptr(portRange)},
+			},
+		}
+	}
+
+	cmd, err := BuildCSECmd(context.TODO(), newConfig("32768 65400"), nil)
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+
+	_, err = BuildCSECmd(context.TODO(), newConfig("32768 1024"), nil)
+	var envErr *CSEEnvError
+	require.ErrorAs(t, err, &envErr)
+	require.Len(t, envErr.Errors, 1)
+	assert.Equal(t, "SYSCTL_CONTENT", envErr.Errors[0].Name)
+	assert.EqualError(t, err, "invalid AKSNodeConfig: failed to generate SYSCTL_CONTENT: invalid net.ipv4.ip_local_port_range: "+
+		"port range end value should be greater than the start value: 32768 >= 1024")
+
+	// the range is written as is to the sysctl file, it must not be able to inject other settings.
+	_, err = BuildCSECmd(context.TODO(), newConfig("32768 65400\nkernel.panic=1"), nil)
+	var varErr *EnvVarError
+	require.ErrorAs(t, err, &varErr)
+	assert.Equal(t, "SYSCTL_CONTENT", varErr.Name)
+}
+
 func TestAKSNodeConfigCompatibilityFromJsonToCSECommand(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -653,74 +780,14 @@ func assertHasKeyWithValue[K comparable, V any](t *testing.T, m map[K]V, key K,
 	assert.Equal(t, value, m[key], "expected map to have key-value pair %s=%v", key, value)
 }
 
-func TestParseContainerdVersionOutput(t *testing.T) {
-	tests := []struct {
-		name   string
-		output string
-		want   string
-	}{
-		{
-			name:   "containerd v2 with package revision",
-			output: "containerd github.com/containerd/containerd/v2 2.3.2-1 fff62f14765df376e5fc36f5a8f8e795b5670f61",
-			want:   "2.3.2",
-		},
-		{
-			name:   "containerd v2 without package revision",
-			output: "containerd github.com/containerd/containerd/v2 v2.0.0 abc123",
-			want:   "2.0.0",
-		},
-		{
-			name:   "containerd v1 with package revision",
-			output: "containerd containerd.io 1.7.22-1 c814c75abc123",
-			want:   "1.7.22",
-		},
-		{
-			name:   "containerd v1 without package revision",
-			output: "containerd containerd.io 1.7.22 c814c75abc123",
-			want:   "1.7.22",
-		},
-		{
-			name:   "containerd v2 pre-release suffix",
-			output: "containerd github.com/containerd/containerd/v2 2.0.0-beta.1 abc123",
-			want:   "2.0.0",
-		},
-		{
-			name:   "containerd v2 rc suffix",
-			output: "containerd github.com/containerd/containerd/v2 v2.1.0-rc.2 abc123",
-			want:   "2.1.0",
-		},
-		{
-			name:   "empty output",
-			output: "",
-			want:   "",
-		},
-		{
-			name:   "unexpected format",
-			output: "not a valid output",
-			want:   "",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parseContainerdVersionOutput(tt.output)
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
-
-func TestBuildCSECmd_DetectsContainerdV2FromSystem(t *testing.T) {
-	// Create a fake containerd binary that outputs a v2 version string.
-	tmpDir := t.TempDir()
-	fakeBin := tmpDir + "/containerd"
-	err := os.WriteFile(fakeBin, []byte("#!/bin/sh\necho 'containerd github.com/containerd/containerd/v2 2.3.2-1 fff62f14765df376e5fc36f5a8f8e795b5670f61'\n"), 0755)
-	require.NoError(t, err)
-
-	// Prepend tmpDir to PATH so our fake binary is found first.
-	t.Setenv("PATH", tmpDir+":"+os.Getenv("PATH"))
+func TestBuildCSECmd_ContainerdVersionFromVHDManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "vhd-install.complete")
+	require.NoError(t, os.WriteFile(manifest, []byte("  - runc version 1.2.4\n  - containerd version 2.0.0-6.azl3\n"), 0o644))
+	useContainerdVersionResolver(t, &containerdVersionResolver{vhdManifestPath: manifest})
 
 	config := &aksnodeconfigv1.Configuration{
 		NeedsCgroupv2: to.Ptr(true),
-		// ContainerdVersion is intentionally NOT set — should be auto-detected.
+		// ContainerdVersion is intentionally NOT set — should be read from the VHD manifest.
 		ContainerdConfig: &aksnodeconfigv1.ContainerdConfig{},
 	}
 
@@ -728,36 +795,115 @@ func TestBuildCSECmd_DetectsContainerdV2FromSystem(t *testing.T) {
 	require.NoError(t, err)
 
 	vars := environToMap(cmd.Env)
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION", "2.0.0")
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION_SOURCE", "vhd_manifest")
 
-	// Verify the v2 containerd config template was used (uses "io.containerd.cri.v1.images" path).
+	// Verify the v2 containerd config was generated (uses "io.containerd.cri.v1.images" path).
 	containerdConfig, err := getBase64DecodedValue([]byte(vars["CONTAINERD_CONFIG_NO_GPU_CONTENT"]))
 	require.NoError(t, err)
 	assert.Contains(t, containerdConfig, `plugins."io.containerd.cri.v1.images"`)
 	assert.NotContains(t, containerdConfig, `plugins."io.containerd.grpc.v1.cri"`)
+
+	// An explicit AKSNodeConfig version wins over the VHD manifest.
+	config.ContainerdConfig.ContainerdVersion = "1.7.22-1"
+	cmd, err = BuildCSECmd(context.TODO(), config, nil)
+	require.NoError(t, err)
+	vars = environToMap(cmd.Env)
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION", "1.7.22")
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION_SOURCE", "aksnodeconfig")
+
+	config.ContainerdConfig.ContainerdVersion = "latest"
+	_, err = BuildCSECmd(context.TODO(), config, nil)
+	assert.ErrorContains(t, err, `failed to generate CONTAINERD_CONFIG_VERSION: containerd_version "latest" is not a valid containerd version`)
 }
 
-func TestBuildCSECmd_FallsBackToV1WhenContainerdDetectionFails(t *testing.T) {
-	// Ensure no containerd binary is found by setting PATH to an empty temp dir.
-	tmpDir := t.TempDir()
-	t.Setenv("PATH", tmpDir)
+func TestBuildCSECmd_FallsBackToV1WhenContainerdVersionIsUnknown(t *testing.T) {
+	useContainerdVersionResolver(t, &containerdVersionResolver{vhdManifestPath: filepath.Join(t.TempDir(), "missing")})
 
 	config := &aksnodeconfigv1.Configuration{
-		// ContainerdVersion is intentionally NOT set and detection will fail.
+		// ContainerdVersion is intentionally NOT set and no other source knows the version.
 		ContainerdConfig: &aksnodeconfigv1.ContainerdConfig{},
 	}
 
-	// BuildCSECmd should NOT return an error even when containerd detection fails.
+	// BuildCSECmd should NOT return an error when the containerd version is unknown.
 	cmd, err := BuildCSECmd(context.TODO(), config, nil)
 	require.NoError(t, err)
 
 	vars := environToMap(cmd.Env)
-
-	// Version should remain empty (detection failed gracefully).
 	assert.Equal(t, "", vars["CONTAINERD_VERSION"])
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION", "")
+	assertHasKeyWithValue(t, vars, "CONTAINERD_CONFIG_VERSION_SOURCE", "none")
 
-	// Verify the v1 containerd config template was used (uses "io.containerd.grpc.v1.cri" path).
+	// Verify the v1 containerd config was generated (uses "io.containerd.grpc.v1.cri" path).
 	containerdConfig, err := getBase64DecodedValue([]byte(vars["CONTAINERD_CONFIG_NO_GPU_CONTENT"]))
 	require.NoError(t, err)
 	assert.Contains(t, containerdConfig, `plugins."io.containerd.grpc.v1.cri"`)
 	assert.NotContains(t, containerdConfig, `plugins."io.containerd.cri.v1.images"`)
 }
+
+func TestBuildCSECmd_ContainerdOverlay(t *testing.T) {
+	newConfig := func(overlay *aksnodeconfigv1.ContainerdConfigOverlay) *aksnodeconfigv1.Configuration {
+		return &aksnodeconfigv1.Configuration{
+			KubernetesVersion: "1.30.0",
+			KubeBinaryConfig:  &aksnodeconfigv1.KubeBinaryConfig{PodInfraContainerImageUrl: "mcr.microsoft.com/oss/kubernetes/pause:3.6"},
+			ContainerdConfig:  &aksnodeconfigv1.ContainerdConfig{Overlay: overlay},
+		}
+	}
+
+	cmd, err := BuildCSECmd(context.TODO(), newConfig(&aksnodeconfigv1.ContainerdConfigOverlay{
+		RegistryMirrors: map[string]*aksnodeconfigv1.ContainerdRegistryMirror{
+			"docker.io": {Endpoints: []string{"https://mirror.example.com"}},
+		},
+		RuntimeHandlers: map[string]*aksnodeconfigv1.ContainerdRuntimeHandler{
+			"gvisor": {RuntimeType: "io.containerd.runsc.v1", Options: map[string]string{"TypeUrl": "io.containerd.runsc.v1.options", "Debug": "true"}},
+		},
+		SnapshotterOptions: map[string]*aksnodeconfigv1.ContainerdSnapshotterOptions{
+			"overlayfs": {Options: map[string]string{"upperdir_label": "true"}},
+		},
+	}), nil)
+	require.NoError(t, err)
+	vars := environToMap(cmd.Env)
+
+	for _, name := range []string{"CONTAINERD_CONFIG_CONTENT", "CONTAINERD_CONFIG_NO_GPU_CONTENT"} {
+		containerdConfig, err := getBase64DecodedValue([]byte(vars[name]))
+		require.NoError(t, err)
+		assert.Contains(t, containerdConfig, `[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.gvisor]
+      runtime_type = "io.containerd.runsc.v1"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.gvisor.options]
+        Debug = true
+        TypeUrl = "io.containerd.runsc.v1.options"
+`)
+		assert.Contains(t, containerdConfig, `[plugins."io.containerd.snapshotter.v1.overlayfs"]
+  upperdir_label = true
+`)
+	}
+
+	registryHosts, err := getBase64DecodedValue([]byte(vars["CONTAINERD_REGISTRY_HOSTS_CONTENT"]))
+	require.NoError(t, err)
+	host, hostsToml, found := strings.Cut(strings.TrimSpace(registryHosts), " ")
+	require.True(t, found)
+	assert.Equal(t, "docker.io", host)
+	hostsTomlContent, err := getBase64DecodedValue([]byte(hostsToml))
+	require.NoError(t, err)
+	assert.Equal(t, `[host."https://mirror.example.com"]
+  capabilities = ["pull", "resolve"]
+`, hostsTomlContent)
+
+	_, err = BuildCSECmd(context.TODO(), newConfig(&aksnodeconfigv1.ContainerdConfigOverlay{
+		RuntimeHandlers: map[string]*aksnodeconfigv1.ContainerdRuntimeHandler{
+			"runc": {RuntimeType: "io.containerd.runc.v2"},
+		},
+	}), nil)
+	var envErr *CSEEnvError
+	require.ErrorAs(t, err, &envErr)
+	assert.ErrorContains(t, err, `failed to generate CONTAINERD_CONFIG_CONTENT: invalid containerd config overlay: runtime handler "runc" is configured by AKS`)
+
+	config := newConfig(&aksnodeconfigv1.ContainerdConfigOverlay{
+		RegistryMirrors: map[string]*aksnodeconfigv1.ContainerdRegistryMirror{
+			"docker.io": {Endpoints: []string{"https://mirror.example.com"}},
+		},
+	})
+	config.KubernetesVersion = "1.21.0"
+	_, err = BuildCSECmd(context.TODO(), config, nil)
+	assert.ErrorContains(t, err, "registry mirrors require the CRI plugin registry config_path")
+}
@@ -169,8 +169,9 @@ type Configuration struct {
 	CseTimeout *int32 `protobuf:"varint,44,opt,name=cse_timeout,json=cseTimeout,proto3,oneof" json:"cse_timeout,omitempty"`
 	// enabled_features is a generic set of feature toggles delivered to the node as KEY=VALUE
 	// lines in enabled_features.sh, read by the aks-node-controller wrapper. Each entry becomes
-	// an exported environment variable (e.g. "ENABLE_PROVISIONING_HOTFIX" -> "true"). Using a map
-	// lets new toggles be added without producer-side code changes. Empty/absent => no file, no-op.
+	// an exported environment variable (e.g. "ENABLE_PROVISIONING_HOTFIX" -> "true"). Entries must be
+	// registered in pkg/agent/enabledfeatures/registry.json, CustomData rejects unknown names and values
+	// of the wrong type. Empty/absent => no file, no-op.
 	EnabledFeatures map[string]string `protobuf:"bytes,45,rep,name=enabled_features,json=enabledFeatures,proto3" json:"enabled_features,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
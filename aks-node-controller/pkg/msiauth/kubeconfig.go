@@ -0,0 +1,115 @@
+package msiauth
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// execCredentialAPIVersion is the client.authentication.k8s.io version of the exec credentials exchanged with client-go.
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+// ExecCredential is the client.authentication.k8s.io/v1 ExecCredential printed by an exec credential plugin.
+type ExecCredential struct {
+	Kind       string               `json:"kind"`
+	APIVersion string               `json:"apiVersion"`
+	Status     ExecCredentialStatus `json:"status"`
+}
+
+// ExecCredentialStatus holds the token handed to client-go.
+type ExecCredentialStatus struct {
+	Token               string `json:"token"`
+	ExpirationTimestamp string `json:"expirationTimestamp,omitempty"`
+}
+
+// NewExecCredential wraps a token into an ExecCredential. client-go caches the token until it expires.
+func NewExecCredential(token *Token) *ExecCredential {
+	cred := &ExecCredential{
+		Kind:       "ExecCredential",
+		APIVersion: execCredentialAPIVersion,
+		Status:     ExecCredentialStatus{Token: token.AccessToken},
+	}
+	if !token.ExpiresOn.IsZero() {
+		cred.Status.ExpirationTimestamp = token.ExpiresOn.UTC().Format(time.RFC3339)
+	}
+	return cred
+}
+
+// KubeconfigOptions describes a kubeconfig authenticating with a managed identity token.
+type KubeconfigOptions struct {
+	// Server is the API server URL, e.g. https://example.hcp.eastus.azmk8s.io:443.
+	Server string
+	// CertificateAuthority is the path of the cluster CA certificate.
+	CertificateAuthority string
+	// User names the kubeconfig user, e.g. kubelet-bootstrap.
+	User string
+	// Command is the path of the exec credential plugin, i.e. the aks-node-controller binary.
+	Command  string
+	Method   Method
+	Resource string
+	// ClientID selects a user-assigned identity, it is only supported by MethodAzureMSI.
+	ClientID string
+}
+
+type kubeconfig struct {
+	APIVersion     string            `yaml:"apiVersion"`
+	Kind           string            `yaml:"kind"`
+	Clusters       []kubeconfigEntry `yaml:"clusters"`
+	Users          []kubeconfigEntry `yaml:"users"`
+	Contexts       []kubeconfigEntry `yaml:"contexts"`
+	CurrentContext string            `yaml:"current-context"`
+}
+
+type kubeconfigEntry struct {
+	Name    string         `yaml:"name"`
+	Cluster map[string]any `yaml:"cluster,omitempty"`
+	User    map[string]any `yaml:"user,omitempty"`
+	Context map[string]any `yaml:"context,omitempty"`
+}
+
+// Kubeconfig renders a kubeconfig which gets its credential from the exec credential plugin.
+func Kubeconfig(opts KubeconfigOptions) ([]byte, error) {
+	if opts.Server == "" || opts.CertificateAuthority == "" || opts.User == "" || opts.Command == "" {
+		return nil, errors.New("server, certificate authority, user and command are required to render a managed identity kubeconfig")
+	}
+	if _, err := ParseMethod(string(opts.Method)); err != nil {
+		return nil, err
+	}
+	if opts.Method == MethodArcMSI && opts.ClientID != "" {
+		return nil, errors.New("azure arc only supports the system-assigned identity, client ID must be empty")
+	}
+	resource := opts.Resource
+	if resource == "" {
+		resource = AKSAADServerAppID
+	}
+	args := []string{"get-credential", "--auth-method", string(opts.Method), "--resource", resource}
+	if opts.ClientID != "" {
+		args = append(args, "--client-id", opts.ClientID)
+	}
+
+	const clusterName, contextName = "localcluster", "localclustercontext"
+	return yaml.Marshal(kubeconfig{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []kubeconfigEntry{{
+			Name:    clusterName,
+			Cluster: map[string]any{"certificate-authority": opts.CertificateAuthority, "server": opts.Server},
+		}},
+		Users: []kubeconfigEntry{{
+			Name: opts.User,
+			User: map[string]any{"exec": map[string]any{
+				"apiVersion":         execCredentialAPIVersion,
+				"command":            opts.Command,
+				"args":               args,
+				"interactiveMode":    "Never",
+				"provideClusterInfo": false,
+			}},
+		}},
+		Contexts: []kubeconfigEntry{{
+			Name:    contextName,
+			Context: map[string]any{"cluster": clusterName, "user": opts.User},
+		}},
+		CurrentContext: contextName,
+	})
+}
@@ -0,0 +1,243 @@
+// Package msiauth authenticates a node to an AAD-enabled API server with the Entra ID token of a managed identity.
+// Azure VMs use their managed identity through IMDS, machines outside Azure use the Azure Arc managed identity through
+// the local Arc hybrid instance metadata service (HIMDS). The token is handed to the kubelet by a client-go exec
+// credential plugin, which is aks-node-controller itself.
+package msiauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/agentbaker/aks-node-controller/common"
+)
+
+// Method is a managed identity flavour used to get a token.
+type Method string
+
+const (
+	// MethodAzureMSI gets tokens of the VM managed identity from the Azure IMDS.
+	MethodAzureMSI Method = "azure-msi"
+	// MethodArcMSI gets tokens of the Azure Arc machine identity from the Arc HIMDS.
+	MethodArcMSI Method = "arc-msi"
+)
+
+const (
+	// AKSAADServerAppID uniquely identifies AKS's Entra ID application, it is the default audience of the tokens.
+	// more details: https://learn.microsoft.com/en-us/azure/aks/kubelogin-authentication#how-to-use-kubelogin-with-aks
+	AKSAADServerAppID = "6dae42f8-4368-4678-94ff-3960e28e3630"
+
+	azureIMDSTokenURL   = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureIMDSAPIVersion = "2018-02-01"
+	arcHIMDSTokenURL    = "http://127.0.0.1:40342/metadata/identity/oauth2/token"
+	arcHIMDSAPIVersion  = "2020-06-01"
+	// arcTokenKeyDir is the only directory the Arc agent writes its challenge keys to.
+	arcTokenKeyDir = "/var/opt/azcmagent/tokens"
+	// arcMaxKeySize is the maximum size of an Arc challenge key, as documented by Azure Arc.
+	arcMaxKeySize = 4096
+
+	tokenFetchTimeout = 10 * time.Second
+	maxResponseSize   = 1 << 20
+)
+
+// ParseMethod parses the name of a managed identity method.
+func ParseMethod(name string) (Method, error) {
+	switch m := Method(name); m {
+	case MethodAzureMSI, MethodArcMSI:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown managed identity method %q, expected %s or %s", name, MethodAzureMSI, MethodArcMSI)
+	}
+}
+
+// Token is an Entra ID access token.
+type Token struct {
+	AccessToken string
+	ExpiresOn   time.Time
+}
+
+// Client gets managed identity tokens. The zero value talks to the real Azure IMDS and Arc HIMDS endpoints.
+type Client struct {
+	// HTTPClient overrides the HTTP client for testing.
+	HTTPClient *http.Client
+	// AzureTokenURL overrides the Azure IMDS token endpoint for testing.
+	AzureTokenURL string
+	// ArcTokenURL overrides the Arc HIMDS token endpoint for testing. The IDENTITY_ENDPOINT environment variable set
+	// by the Arc agent takes precedence over the default endpoint.
+	ArcTokenURL string
+	// ArcKeyDir overrides the directory Arc challenge keys must be read from, for testing.
+	ArcKeyDir string
+}
+
+// GetToken returns a token for the resource. clientID selects a user-assigned identity, it is only supported by MethodAzureMSI.
+func (c *Client) GetToken(ctx context.Context, method Method, resource, clientID string) (*Token, error) {
+	if resource == "" {
+		return nil, errors.New("resource is required to get a managed identity token")
+	}
+	ctx, cancel := context.WithTimeout(ctx, tokenFetchTimeout)
+	defer cancel()
+
+	switch method {
+	case MethodAzureMSI:
+		return c.getAzureToken(ctx, resource, clientID)
+	case MethodArcMSI:
+		if clientID != "" {
+			return nil, errors.New("azure arc only supports the system-assigned identity, client ID must be empty")
+		}
+		return c.getArcToken(ctx, resource)
+	default:
+		return nil, fmt.Errorf("unknown managed identity method %q", method)
+	}
+}
+
+func (c *Client) getAzureToken(ctx context.Context, resource, clientID string) (*Token, error) {
+	query := url.Values{"api-version": {azureIMDSAPIVersion}, "resource": {resource}}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := c.newTokenRequest(ctx, firstNonEmpty(c.AzureTokenURL, azureIMDSTokenURL), query)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token from IMDS: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("IMDS returned status %d: %s", resp.StatusCode, body)
+	}
+	return parseTokenResponse(body)
+}
+
+// getArcToken implements the Arc HIMDS challenge flow: the first request is answered with the path of a key file only
+// readable by privileged users, and its content authenticates the second request.
+func (c *Client) getArcToken(ctx context.Context, resource string) (*Token, error) {
+	endpoint := firstNonEmpty(c.ArcTokenURL, os.Getenv("IDENTITY_ENDPOINT"), arcHIMDSTokenURL)
+	query := url.Values{"api-version": {arcHIMDSAPIVersion}, "resource": {resource}}
+
+	req, err := c.newTokenRequest(ctx, endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token challenge from Arc HIMDS: %w", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, fmt.Errorf("arc HIMDS returned status %d instead of a challenge: %s", resp.StatusCode, body)
+	}
+	key, err := c.readArcKey(resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = c.newTokenRequest(ctx, endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Basic "+key)
+	resp, body, err = c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token from Arc HIMDS: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arc HIMDS returned status %d: %s", resp.StatusCode, body)
+	}
+	return parseTokenResponse(body)
+}
+
+// readArcKey reads the key file referenced by a "Basic realm=<path>" challenge, after checking it is an Arc key file.
+func (c *Client) readArcKey(challenge string) (string, error) {
+	_, path, found := strings.Cut(challenge, "Basic realm=")
+	if !found || path == "" {
+		return "", fmt.Errorf("unexpected Arc HIMDS challenge %q", challenge)
+	}
+	path = filepath.Clean(path)
+	keyDir := firstNonEmpty(c.ArcKeyDir, arcTokenKeyDir)
+	if filepath.Dir(path) != filepath.Clean(keyDir) || filepath.Ext(path) != ".key" {
+		return "", fmt.Errorf("arc HIMDS challenge key %s is not a .key file in %s", path, keyDir)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat Arc HIMDS challenge key: %w", err)
+	}
+	if info.Size() > arcMaxKeySize {
+		return "", fmt.Errorf("arc HIMDS challenge key %s is larger than %d bytes", path, arcMaxKeySize)
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Arc HIMDS challenge key: %w", err)
+	}
+	return string(key), nil
+}
+
+func (c *Client) newTokenRequest(ctx context.Context, endpoint string, query url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		// both metadata endpoints are local and must never be routed through an HTTP(S) proxy.
+		httpClient = &http.Client{
+			Timeout:   tokenFetchTimeout,
+			Transport: common.NewBaseTransport(common.HTTPTransportOptions{}),
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// parseTokenResponse parses the token response shared by IMDS and HIMDS, where expires_on is a unix timestamp string.
+func parseTokenResponse(body []byte) (*Token, error) {
+	var resp struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresOn   json.Number `json:"expires_on"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("token response has an empty access token")
+	}
+	token := &Token{AccessToken: resp.AccessToken}
+	if resp.ExpiresOn != "" {
+		seconds, err := strconv.ParseInt(resp.ExpiresOn.String(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse token expiry %q: %w", resp.ExpiresOn, err)
+		}
+		token.ExpiresOn = time.Unix(seconds, 0).UTC()
+	}
+	return token, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package msiauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseMethod(t *testing.T) {
+	m, err := ParseMethod("arc-msi")
+	require.NoError(t, err)
+	assert.Equal(t, MethodArcMSI, m)
+
+	_, err = ParseMethod("msi")
+	assert.ErrorContains(t, err, `unknown managed identity method "msi"`)
+}
+
+func TestGetToken_AzureMSI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, "2018-02-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "resource-id", r.URL.Query().Get("resource"))
+		assert.Equal(t, "client-id", r.URL.Query().Get("client_id"))
+		_, _ = w.Write([]byte(`{"access_token": "azure-token", "expires_on": "1700000000"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), AzureTokenURL: server.URL}
+	token, err := client.GetToken(context.Background(), MethodAzureMSI, "resource-id", "client-id")
+	require.NoError(t, err)
+	assert.Equal(t, "azure-token", token.AccessToken)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), token.ExpiresOn)
+}
+
+func TestGetToken_AzureMSIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "invalid_request"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), AzureTokenURL: server.URL}
+	_, err := client.GetToken(context.Background(), MethodAzureMSI, "resource-id", "")
+	assert.ErrorContains(t, err, "IMDS returned status 400")
+}
+
+func TestGetToken_ArcMSI(t *testing.T) {
+	keyDir := t.TempDir()
+	keyPath := filepath.Join(keyDir, "challenge.key")
+	require.NoError(t, os.WriteFile(keyPath, []byte("secret"), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2020-06-01", r.URL.Query().Get("api-version"))
+		if r.Header.Get("Authorization") == "" {
+			w.Header().Set("WWW-Authenticate", "Basic realm="+keyPath)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "Basic secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"access_token": "arc-token", "expires_on": "1700000000"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), ArcTokenURL: server.URL, ArcKeyDir: keyDir}
+	token, err := client.GetToken(context.Background(), MethodArcMSI, "resource-id", "")
+	require.NoError(t, err)
+	assert.Equal(t, "arc-token", token.AccessToken)
+
+	_, err = client.GetToken(context.Background(), MethodArcMSI, "resource-id", "client-id")
+	assert.ErrorContains(t, err, "only supports the system-assigned identity")
+}
+
+func TestReadArcKey(t *testing.T) {
+	keyDir := t.TempDir()
+	client := &Client{ArcKeyDir: keyDir}
+
+	tests := []struct {
+		name      string
+		challenge string
+		wantErr   string
+	}{
+		{name: "missing realm", challenge: "Bearer", wantErr: "unexpected Arc HIMDS challenge"},
+		{name: "outside the key directory", challenge: "Basic realm=/etc/shadow.key", wantErr: "is not a .key file in"},
+		{name: "path traversal", challenge: "Basic realm=" + keyDir + "/../x.key", wantErr: "is not a .key file in"},
+		{name: "not a key file", challenge: "Basic realm=" + filepath.Join(keyDir, "x.txt"), wantErr: "is not a .key file in"},
+		{name: "too large", challenge: "Basic realm=" + filepath.Join(keyDir, "large.key"), wantErr: "is larger than 4096 bytes"},
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(keyDir, "large.key"), make([]byte, arcMaxKeySize+1), 0o600))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.readArcKey(tt.challenge)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewExecCredential(t *testing.T) {
+	cred := NewExecCredential(&Token{AccessToken: "token", ExpiresOn: time.Unix(1700000000, 0)})
+	out, err := json.Marshal(cred)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"kind": "ExecCredential",
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"status": {"token": "token", "expirationTimestamp": "2023-11-14T22:13:20Z"}
+	}`, string(out))
+}
+
+func TestKubeconfig(t *testing.T) {
+	out, err := Kubeconfig(KubeconfigOptions{
+		Server:               "https://example:443",
+		CertificateAuthority: "/etc/kubernetes/certs/ca.crt",
+		User:                 "kubelet-msi",
+		Command:              "/opt/azure/containers/aks-node-controller",
+		Method:               MethodAzureMSI,
+		ClientID:             "client-id",
+	})
+	require.NoError(t, err)
+
+	var parsed struct {
+		Users []struct {
+			User struct {
+				Exec struct {
+					Command         string   `yaml:"command"`
+					Args            []string `yaml:"args"`
+					InteractiveMode string   `yaml:"interactiveMode"`
+				} `yaml:"exec"`
+			} `yaml:"user"`
+		} `yaml:"users"`
+		CurrentContext string `yaml:"current-context"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &parsed))
+	require.Len(t, parsed.Users, 1)
+	exec := parsed.Users[0].User.Exec
+	assert.Equal(t, "/opt/azure/containers/aks-node-controller", exec.Command)
+	assert.Equal(t, []string{"get-credential", "--auth-method", "azure-msi", "--resource", AKSAADServerAppID, "--client-id", "client-id"}, exec.Args)
+	assert.Equal(t, "Never", exec.InteractiveMode)
+	assert.Equal(t, "localclustercontext", parsed.CurrentContext)
+
+	_, err = Kubeconfig(KubeconfigOptions{
+		Server: "https://example:443", CertificateAuthority: "ca.crt", User: "u", Command: "c",
+		Method: MethodArcMSI, ClientID: "client-id",
+	})
+	assert.ErrorContains(t, err, "only supports the system-assigned identity")
+}
@@ -0,0 +1,244 @@
+package nodeconfigutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/pkg/agent/enabledfeatures"
+	"github.com/Masterminds/semver/v3"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CurrentVersion is the version of the Configuration schema generated and read by this code.
+const CurrentVersion = "v1"
+
+// migration upgrades a Configuration from one schema version to the next.
+type migration struct {
+	from, to string
+	migrate  func(cfg *aksnodeconfigv1.Configuration)
+}
+
+// migrations are applied in order until the Configuration reaches CurrentVersion.
+//
+//nolint:gochecknoglobals
+var migrations = []migration{
+	{
+		// "v0" was a mistake, it was released with the same schema as v1.
+		from:    "v0",
+		to:      "v1",
+		migrate: func(*aksnodeconfigv1.Configuration) {},
+	},
+}
+
+// fieldsSince lists the Configuration fields added after the first released schema, with the first
+// aks-node-controller version reading them. Older versions drop these fields when unmarshalling, so a generator
+// targeting them must not set the fields. Fields which are not listed are read by every aks-node-controller.
+// Add an entry with every new field, with the unreleased version until the VHD release shipping it is published.
+//
+//nolint:gochecknoglobals
+var fieldsSince = []struct {
+	path    string
+	version string
+}{
+	{path: "containerd_config.overlay", version: unreleased},
+	{path: "enabled_features", version: "202604.01.0"},
+}
+
+// unreleased is the version of the fields no published aks-node-controller reads yet, every released version
+// drops them.
+const unreleased = "unreleased"
+
+// LoadReport describes how a Configuration payload was read.
+type LoadReport struct {
+	// Version is the schema version of the payload, before migration.
+	Version string
+	// Migrations are the applied migrations, e.g. "v0 -> v1".
+	Migrations []string
+	// UnknownFields are the fields of the payload which are not part of the schema and were dropped, usually
+	// because the payload was generated for a newer aks-node-controller.
+	UnknownFields []string
+}
+
+// LoadConfiguration unmarshals a Configuration payload, reports the fields it drops, and migrates the Configuration
+// to CurrentVersion. Unknown fields are not an error so a newer generator can talk to an older aks-node-controller,
+// an invalid payload and an unsupported version are. The report is returned with the error.
+func LoadConfiguration(data []byte) (*aksnodeconfigv1.Configuration, *LoadReport, error) {
+	cfg, err := UnmarshalConfigurationV1(data)
+	report := &LoadReport{Version: cfg.GetVersion(), UnknownFields: UnknownFields(data)}
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to unmarshal the AKSNodeConfig: %w", err)
+	}
+	report.Migrations, err = Migrate(cfg)
+	if err != nil {
+		return nil, report, err
+	}
+	return cfg, report, nil
+}
+
+// Migrate upgrades the Configuration in place to CurrentVersion and returns the applied migrations.
+func Migrate(cfg *aksnodeconfigv1.Configuration) ([]string, error) {
+	var applied []string
+	for cfg.GetVersion() != CurrentVersion {
+		i := migrationFrom(cfg.GetVersion())
+		if i < 0 {
+			return applied, fmt.Errorf("unsupported version: %s", cfg.GetVersion())
+		}
+		migrations[i].migrate(cfg)
+		cfg.Version = migrations[i].to
+		applied = append(applied, fmt.Sprintf("%s -> %s", migrations[i].from, migrations[i].to))
+	}
+	return applied, nil
+}
+
+func migrationFrom(version string) int {
+	for i, m := range migrations {
+		if m.from == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// UnknownFields returns the dotted paths of the fields of a Configuration JSON payload which are not part of the
+// schema, sorted. Map entries are reported as path[key] and list items as path[index].
+func UnknownFields(data []byte) []string {
+	var payload any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	var unknown []string
+	collectUnknownFields((&aksnodeconfigv1.Configuration{}).ProtoReflect().Descriptor(), payload, "", &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+func collectUnknownFields(desc protoreflect.MessageDescriptor, value any, path string, unknown *[]string) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	fields := desc.Fields()
+	for key, v := range obj {
+		// protojson accepts both the proto and the JSON names of the fields.
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(key))
+		}
+		if fd == nil {
+			*unknown = append(*unknown, joinPath(path, key))
+			continue
+		}
+		name := joinPath(path, string(fd.Name()))
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Kind() != protoreflect.MessageKind {
+				continue
+			}
+			entries, _ := v.(map[string]any)
+			for k, entry := range entries {
+				collectUnknownFields(fd.MapValue().Message(), entry, fmt.Sprintf("%s[%s]", name, k), unknown)
+			}
+		case fd.IsList():
+			if fd.Kind() != protoreflect.MessageKind {
+				continue
+			}
+			items, _ := v.([]any)
+			for i, item := range items {
+				collectUnknownFields(fd.Message(), item, fmt.Sprintf("%s[%d]", name, i), unknown)
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			collectUnknownFields(fd.Message(), v, name, unknown)
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// FieldsDroppedBy returns the fields set in the Configuration which the given aks-node-controller version does not
+// read, sorted.
+func FieldsDroppedBy(cfg *aksnodeconfigv1.Configuration, ancVersion string) ([]string, error) {
+	target, err := semver.NewVersion(ancVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aks-node-controller version %q: %w", ancVersion, err)
+	}
+	var dropped []string
+	for _, f := range fieldsSince {
+		if !hasField(cfg.ProtoReflect(), f.path) {
+			continue
+		}
+		if f.version == unreleased {
+			dropped = append(dropped, f.path)
+			continue
+		}
+		since, err := semver.NewVersion(f.version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q of field %s: %w", f.version, f.path, err)
+		}
+		if target.LessThan(since) {
+			dropped = append(dropped, f.path)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped, nil
+}
+
+// ValidateMinANCVersion rejects Configurations using fields or enabled features which the given, oldest,
+// aks-node-controller version receiving them would silently ignore.
+func ValidateMinANCVersion(cfg *aksnodeconfigv1.Configuration, minANCVersion string) error {
+	dropped, err := FieldsDroppedBy(cfg, minANCVersion)
+	if err != nil {
+		return err
+	}
+	target, _ := semver.NewVersion(minANCVersion)
+	var errs []error
+	for _, path := range dropped {
+		errs = append(errs, fmt.Errorf("field %s is not supported by aks-node-controller %s", path, minANCVersion))
+	}
+	for _, name := range sortedFeatureNames(cfg.GetEnabledFeatures()) {
+		f, ok := enabledfeatures.Embedded().Lookup(name)
+		if !ok {
+			continue
+		}
+		if since, err := semver.NewVersion(f.MinANCVersion); err == nil && target.LessThan(since) {
+			errs = append(errs, fmt.Errorf("feature %s requires aks-node-controller %s or later", name, f.MinANCVersion))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sortedFeatureNames(features map[string]string) []string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasField reports whether the field at the dotted path is set.
+func hasField(msg protoreflect.Message, path string) bool {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(segment))
+		if fd == nil || !msg.Has(fd) {
+			return false
+		}
+		if i == len(segments)-1 {
+			return true
+		}
+		if fd.IsList() || fd.IsMap() || fd.Kind() != protoreflect.MessageKind {
+			return false
+		}
+		msg = msg.Get(fd).Message()
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package nodeconfigutils
+
+import (
+	"strings"
+	"testing"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("current version is not migrated", func(t *testing.T) {
+		cfg := &aksnodeconfigv1.Configuration{Version: CurrentVersion}
+		applied, err := Migrate(cfg)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("v0 is migrated to v1", func(t *testing.T) {
+		cfg := &aksnodeconfigv1.Configuration{Version: "v0", VmSize: "Standard_D2s_v3"}
+		applied, err := Migrate(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0 -> v1"}, applied)
+		assert.Equal(t, "v1", cfg.GetVersion())
+		assert.Equal(t, "Standard_D2s_v3", cfg.GetVmSize())
+	})
+
+	for _, version := range []string{"", "v2", "V1"} {
+		t.Run("unsupported version "+version, func(t *testing.T) {
+			_, err := Migrate(&aksnodeconfigv1.Configuration{Version: version})
+			require.EqualError(t, err, "unsupported version: "+version)
+		})
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	unknown := UnknownFields([]byte(`{
+		"version": "v1",
+		"vmSize": "Standard_D2s_v3",
+		"future_field": true,
+		"kubelet_config": {"enable_kubelet_config_file": true, "future_kubelet_field": 1},
+		"containerd_config": {
+			"overlay": {"registry_mirrors": {"docker.io": {"endpoints": ["https://mirror"], "mirror_auth": "x"}}}
+		},
+		"enabled_features": {"ENABLE_PROVISIONING_HOTFIX": "true"},
+		"custom_ca_certs": ["cert"]
+	}`))
+	assert.Equal(t, []string{
+		"containerd_config.overlay.registry_mirrors[docker.io].mirror_auth",
+		"future_field",
+		"kubelet_config.future_kubelet_field",
+	}, unknown)
+
+	assert.Empty(t, UnknownFields([]byte(`not json`)))
+}
+
+func TestLoadConfiguration(t *testing.T) {
+	t.Run("reports migrations and unknown fields", func(t *testing.T) {
+		cfg, report, err := LoadConfiguration([]byte(`{"version": "v0", "vm_size": "Standard_D2s_v3", "future_field": 1}`))
+		require.NoError(t, err)
+		assert.Equal(t, "v1", cfg.GetVersion())
+		assert.Equal(t, "Standard_D2s_v3", cfg.GetVmSize())
+		assert.Equal(t, &LoadReport{Version: "v0", Migrations: []string{"v0 -> v1"}, UnknownFields: []string{"future_field"}}, report)
+	})
+
+	t.Run("rejects invalid payloads", func(t *testing.T) {
+		_, report, err := LoadConfiguration([]byte(`{"version": "v1", "vm_size": 1}`))
+		require.ErrorContains(t, err, "failed to unmarshal the AKSNodeConfig")
+		assert.NotContains(t, err.Error(), "unsupported version")
+		assert.Empty(t, report.UnknownFields)
+
+		_, _, err = LoadConfiguration([]byte(`{"version": "v1",`))
+		require.ErrorContains(t, err, "failed to unmarshal the AKSNodeConfig")
+	})
+
+	t.Run("rejects unsupported versions", func(t *testing.T) {
+		_, report, err := LoadConfiguration([]byte(`{"version": "v2", "future_field": 1}`))
+		require.EqualError(t, err, "unsupported version: v2")
+		assert.Equal(t, []string{"future_field"}, report.UnknownFields)
+	})
+}
+
+func TestFieldsSinceResolve(t *testing.T) {
+	// Every listed path must name a field of the schema, and every version must be comparable.
+	for _, f := range fieldsSince {
+		if f.version != unreleased {
+			_, err := semver.NewVersion(f.version)
+			require.NoError(t, err, "field %s", f.path)
+		}
+		desc := (&aksnodeconfigv1.Configuration{}).ProtoReflect().Descriptor()
+		segments := strings.Split(f.path, ".")
+		for i, segment := range segments {
+			fd := desc.Fields().ByName(protoreflect.Name(segment))
+			require.NotNil(t, fd, "field %s does not exist", f.path)
+			if i < len(segments)-1 {
+				require.Equal(t, protoreflect.MessageKind, fd.Kind(), "field %s", f.path)
+				desc = fd.Message()
+			}
+		}
+	}
+}
+
+func TestValidateMinANCVersion(t *testing.T) {
+	cfg := &aksnodeconfigv1.Configuration{
+		Version:         "v1",
+		EnabledFeatures: map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"},
+	}
+
+	dropped, err := FieldsDroppedBy(cfg, "202603.15.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"enabled_features"}, dropped)
+
+	require.EqualError(t, ValidateMinANCVersion(cfg, "202603.15.0"),
+		"field enabled_features is not supported by aks-node-controller 202603.15.0\n"+
+			"feature ENABLE_PROVISIONING_HOTFIX requires aks-node-controller 202604.01.0 or later")
+	require.NoError(t, ValidateMinANCVersion(cfg, "202605.30.0"))
+
+	// unset fields do not need a newer aks-node-controller.
+	require.NoError(t, ValidateMinANCVersion(&aksnodeconfigv1.Configuration{Version: "v1"}, "202501.01.0"))
+
+	_, err = FieldsDroppedBy(cfg, "latest")
+	require.Error(t, err)
+}
+
+func TestValidateMinANCVersionUnreleasedField(t *testing.T) {
+	cfg := &aksnodeconfigv1.Configuration{
+		Version: "v1",
+		ContainerdConfig: &aksnodeconfigv1.ContainerdConfig{
+			Overlay: &aksnodeconfigv1.ContainerdConfigOverlay{},
+		},
+	}
+
+	// no released aks-node-controller reads the overlay yet, however recent.
+	for _, version := range []string{"202501.01.0", "202611.01.0", "999912.31.0"} {
+		dropped, err := FieldsDroppedBy(cfg, version)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"containerd_config.overlay"}, dropped, version)
+		require.EqualError(t, ValidateMinANCVersion(cfg, version),
+			"field containerd_config.overlay is not supported by aks-node-controller "+version)
+	}
+
+	// the rest of the containerd config is read by every version.
+	cfg.ContainerdConfig.Overlay = nil
+	cfg.ContainerdConfig.ContainerdVersion = "1.7.22"
+	require.NoError(t, ValidateMinANCVersion(cfg, "202501.01.0"))
+}
@@ -6,11 +6,9 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/textproto"
-	"regexp"
-	"sort"
-	"strings"
 
 	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/pkg/agent/enabledfeatures"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
@@ -67,6 +65,11 @@ runcmd:
 // to disk and starts the aks-node-controller service, then pairs it with a cloud-config part. Cloud-init
 // processes each MIME part according to its Content-Type during the VM's first boot.
 func CustomData(cfg *aksnodeconfigv1.Configuration) (string, error) {
+	// Feature toggles are checked against the registry so typos are caught before they reach a node.
+	if err := enabledfeatures.Embedded().Validate(cfg.GetEnabledFeatures()); err != nil {
+		return "", err
+	}
+
 	aksNodeConfigJSON, err := MarshalConfigurationV1(cfg)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal nbc, error: %w", err)
@@ -132,38 +135,17 @@ func writeMIMEPart(writer *multipart.Writer, contentType, content string) error
 
 // enabledFeaturesBlock returns the boothook snippet writing the enabled-features file, or ""
 // when no valid feature is set (keeping custom data byte-identical to the default for VHD
-// compat). Keys are sorted for deterministic output and filtered to valid shell identifiers -
-// the same set the wrapper parses. Entries whose value contains a newline or carriage return
-// are dropped so a single entry can never expand into multiple lines in the heredoc.
+// compat). The file is rendered by enabledfeatures.Render: keys are sorted, filtered to the valid
+// shell identifiers the wrapper parses, and entries whose value spans several lines are dropped.
 func enabledFeaturesBlock(cfg *aksnodeconfigv1.Configuration) string {
-	features := cfg.GetEnabledFeatures()
-	keys := make([]string, 0, len(features))
-	for k, v := range features {
-		if isValidFeatureKey(k) && !strings.ContainsAny(v, "\n\r") {
-			keys = append(keys, k)
-		}
-	}
-	if len(keys) == 0 {
+	lines := enabledfeatures.Render(cfg.GetEnabledFeatures())
+	if lines == "" {
 		return ""
 	}
-	sort.Strings(keys)
-	var lines strings.Builder
-	for _, k := range keys {
-		fmt.Fprintf(&lines, "%s=%s\n", k, features[k])
-	}
 	return fmt.Sprintf(`cat <<'EOF' >%[1]s
 %[2]sEOF
 chmod 0600 %[1]s
-`, EnabledFeaturesFilePath, lines.String())
-}
-
-// featureKeyRe matches a valid shell identifier ([a-zA-Z_][a-zA-Z0-9_]*) - the same set the
-// aks-node-controller wrapper parses out of enabled_features.sh.
-var featureKeyRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
-
-// isValidFeatureKey reports whether k is a valid shell identifier the wrapper would accept.
-func isValidFeatureKey(k string) bool {
-	return featureKeyRe.MatchString(k)
+`, EnabledFeaturesFilePath, lines)
 }
 
 func MarshalConfigurationV1(cfg *aksnodeconfigv1.Configuration) ([]byte, error) {
@@ -378,6 +378,15 @@ func TestEnabledFeaturesBlockSkipsValuesWithNewlines(t *testing.T) {
 	require.NotContains(t, block, "EVIL")
 }
 
+func TestCustomDataRejectsUnregisteredEnabledFeatures(t *testing.T) {
+	// Typos must fail at generation time instead of silently never enabling the feature on the node.
+	_, err := CustomData(&aksnodeconfigv1.Configuration{EnabledFeatures: map[string]string{"ENABLE_PROVISIONING_HOTFX": "true"}})
+	require.EqualError(t, err, `invalid enabled features: unknown feature "ENABLE_PROVISIONING_HOTFX", did you mean ENABLE_PROVISIONING_HOTFIX?`)
+
+	_, err = CustomData(&aksnodeconfigv1.Configuration{EnabledFeatures: map[string]string{"ENABLE_PROVISIONING_HOTFIX": "yes"}})
+	require.EqualError(t, err, `invalid enabled features: feature ENABLE_PROVISIONING_HOTFIX: value "yes" must be true or false`)
+}
+
 func TestEnabledFeaturesFilePathMatchesWrapperContract(t *testing.T) {
 	// Shared contract with the wrapper's FEATURES_PATH default; if it changes here it must
 	// change there too, or the wrapper will never read the file.
@@ -21,6 +21,15 @@ type OptionConfigurator func(opts *Options)
 type Options struct {
 	Addr    string
 	Toggles toggles.Toggles
+	// SIGImageCatalogSource is an optional file path or http(s) URL of a SIG image catalog
+	// overriding the compiled-in SIG image versions.
+	SIGImageCatalogSource string
+	// SIGImageReplicationManifestSource is an optional file path or http(s) URL of a SIG image replication manifest,
+	// used to only return image versions which are replicated to the requested region.
+	SIGImageReplicationManifestSource string
+	// SIGImageCatalogReloadInterval is how often the SIG image catalog and replication manifest are reloaded,
+	// zero disables reloading.
+	SIGImageCatalogReloadInterval time.Duration
 }
 
 func (o *Options) validate() error {
@@ -31,6 +40,10 @@ func (o *Options) validate() error {
 	if o.Addr == "" {
 		return errors.New("addr must not be empty")
 	}
+
+	if o.SIGImageCatalogReloadInterval < 0 {
+		return errors.New("sig image catalog reload interval must not be negative")
+	}
 	return nil
 }
 
@@ -54,6 +67,11 @@ func NewAPIServer(o *Options) (*APIServer, error) {
 
 // ListenAndServe wraps http.Server and provides context-based cancelation.
 func (api *APIServer) ListenAndServe(ctx context.Context) error {
+	if err := api.loadSIGImageCatalog(ctx); err != nil {
+		return err
+	}
+	go api.reloadSIGImageCatalog(ctx)
+
 	svr := http.Server{
 		Addr:              api.Options.Addr,
 		Handler:           api.NewRouter(),
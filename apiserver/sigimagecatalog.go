@@ -0,0 +1,63 @@
+package apiserver
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+)
+
+// loadSIGImageCatalog loads the SIG image catalog and replication manifest configured in the options and makes them active.
+// Each of them is skipped when no source is configured, in which case the compiled-in image versions are used as is.
+// Neither is made active unless both load, so a failed reload never leaves a catalog paired with a stale manifest.
+func (api *APIServer) loadSIGImageCatalog(ctx context.Context) error {
+	var (
+		catalog  *datamodel.SIGImageCatalog
+		manifest *datamodel.SIGImageReplicationManifest
+		err      error
+	)
+	if source := api.Options.SIGImageCatalogSource; source != "" {
+		if catalog, err = datamodel.LoadSIGImageCatalog(ctx, source); err != nil {
+			return err
+		}
+	}
+	if source := api.Options.SIGImageReplicationManifestSource; source != "" {
+		if manifest, err = datamodel.LoadSIGImageReplicationManifest(ctx, source); err != nil {
+			return err
+		}
+	}
+
+	if catalog != nil {
+		datamodel.SetSIGImageCatalog(catalog)
+		log.Printf("loaded SIG image catalog from %s with %d image overrides\n", api.Options.SIGImageCatalogSource, len(catalog.Images))
+	}
+	if manifest != nil {
+		datamodel.SetSIGImageReplicationManifest(manifest)
+		log.Printf("loaded SIG image replication manifest from %s for %d distros\n", api.Options.SIGImageReplicationManifestSource, len(manifest.Images))
+	}
+	return nil
+}
+
+// reloadSIGImageCatalog periodically reloads the SIG image catalog and replication manifest until the context is canceled.
+// A file which fails to load or validate is logged and ignored, the previously loaded one stays active.
+func (api *APIServer) reloadSIGImageCatalog(ctx context.Context) {
+	if api.Options.SIGImageCatalogSource == "" && api.Options.SIGImageReplicationManifestSource == "" {
+		return
+	}
+	if api.Options.SIGImageCatalogReloadInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(api.Options.SIGImageCatalogReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := api.loadSIGImageCatalog(ctx); err != nil {
+				log.Printf("failed to reload SIG image catalog, keeping the previous one: %s\n", err)
+			}
+		}
+	}
+}
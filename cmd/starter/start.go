@@ -16,6 +16,12 @@ import (
 func Execute(configurators ...apiserver.OptionConfigurator) {
 	rootCmd.AddCommand(startCmd)
 	startCmd.Flags().StringVar(&options.Addr, "addr", ":8080", "the addr to serve the api on")
+	startCmd.Flags().StringVar(&options.SIGImageCatalogSource, "sig-image-catalog", "",
+		"optional file path or http(s) URL of a SIG image catalog overriding the compiled-in image versions")
+	startCmd.Flags().StringVar(&options.SIGImageReplicationManifestSource, "sig-image-replication-manifest", "",
+		"optional file path or http(s) URL of a manifest listing the regions and clouds each SIG image version is replicated to")
+	startCmd.Flags().DurationVar(&options.SIGImageCatalogReloadInterval, "sig-image-catalog-reload-interval", 0,
+		"how often to reload the SIG image catalog and replication manifest, 0 disables reloading")
 
 	for _, configurator := range configurators {
 		configurator(options)
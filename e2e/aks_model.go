@@ -728,6 +728,7 @@ func createPrivateAzureContainerRegistry(ctx context.Context, cluster *armcontai
 	toolkit.Logf(ctx, "ACR does not exist, creating...")
 	createParams := armcontainerregistry.Registry{
 		Location: to.Ptr(*cluster.Location),
+		Tags:     sharedTags(),
 		SKU: &armcontainerregistry.SKU{
 			Name: to.Ptr(armcontainerregistry.SKUNamePremium),
 		},
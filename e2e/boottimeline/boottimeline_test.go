@@ -0,0 +1,231 @@
+package boottimeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/csetiming"
+	"github.com/stretchr/testify/require"
+)
+
+var boot = time.Date(2026, 7, 17, 2, 20, 0, 0, time.UTC)
+
+func at(seconds float64) time.Time {
+	return boot.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func task(name string, start, end float64) csetiming.TaskTiming {
+	return csetiming.TaskTiming{TaskName: name, StartTime: at(start), EndTime: at(end), Duration: at(end).Sub(at(start))}
+}
+
+func testInput() Input {
+	return Input{
+		Provision: &csetiming.ProvisionTiming{
+			ExecDuration:        "40",
+			KernelStartTime:     "Fri 2026-07-17 02:20:00 UTC",
+			CloudInitLocalStart: "Fri 2026-07-17 02:20:08 UTC",
+			CloudInitStart:      "Fri 2026-07-17 02:20:12 UTC",
+			CloudFinalStart:     "Fri 2026-07-17 02:20:15 UTC",
+			GuestAgentStartTime: "Fri 2026-07-17 02:20:14 UTC",
+			CSEStartTime:        "Fri Jul 17 02:20:20 UTC 2026",
+			SystemdSummary:      "Startup finished in 1.500s (firmware) + 2.500s (kernel) + 3s (initrd) + 1min 4.250s (userspace) = 1min 11.250s\ngraphical.target reached after 1min 4.100s in userspace",
+			BootDatapoints:      json.RawMessage(`{"KubeletStartTime": "Fri 2026-07-17 02:20:55 UTC"}`),
+		},
+		Tasks: []csetiming.TaskTiming{
+			task("AKS.CSE.installContainerRuntime", 21, 30),
+			task("AKS.CSE.installContainerRuntime.download", 21, 27),
+			task("AKS.CSE.installContainerRuntime.install", 27, 30),
+			// overlaps installContainerRuntime without nesting in it.
+			task("AKS.CSE.pullImages", 25, 33),
+			task("AKS.CSE.ensureKubelet", 35, 58),
+		},
+	}
+}
+
+func spanByName(t *testing.T, timeline *Timeline, name string) Span {
+	t.Helper()
+	for _, s := range timeline.Spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	require.Failf(t, "span not found", "%s", name)
+	return Span{}
+}
+
+func TestBuild(t *testing.T) {
+	timeline := Build(testInput())
+	require.Empty(t, timeline.Warnings)
+
+	require.Equal(t, boot.Add(-1500*time.Millisecond), timeline.Start())
+	require.Equal(t, at(69.75), timeline.End())
+
+	firmware := spanByName(t, timeline, "firmware")
+	require.Equal(t, TrackSystemd, firmware.Track)
+	require.Equal(t, boot.Add(-1500*time.Millisecond), firmware.Start)
+	userspace := spanByName(t, timeline, "userspace")
+	require.Equal(t, at(5.5), userspace.Start)
+	require.Equal(t, 64250*time.Millisecond, userspace.Duration())
+
+	stages := map[string][2]time.Time{}
+	for _, s := range timeline.Spans {
+		if s.Track == TrackBoot {
+			stages[s.Name] = [2]time.Time{s.Start, s.End}
+		}
+	}
+	require.Equal(t, map[string][2]time.Time{
+		"kernel":           {at(0), at(8)},
+		"cloud-init-local": {at(8), at(12)},
+		"cloud-init":       {at(12), at(15)},
+		"cloud-final":      {at(15), at(20)},
+	}, stages)
+
+	total := spanByName(t, timeline, csetiming.TotalTaskName)
+	require.Equal(t, at(20), total.Start)
+	require.Equal(t, 40*time.Second, total.Duration())
+	require.Equal(t, 0, total.Depth)
+	runtime := spanByName(t, timeline, "AKS.CSE.installContainerRuntime")
+	require.Equal(t, 0, runtime.Lane)
+	require.Equal(t, 1, runtime.Depth)
+	require.Equal(t, 2, spanByName(t, timeline, "AKS.CSE.installContainerRuntime.install").Depth)
+	pull := spanByName(t, timeline, "AKS.CSE.pullImages")
+	require.Equal(t, 1, pull.Lane)
+	require.Equal(t, 0, pull.Depth)
+	require.Equal(t, 0, spanByName(t, timeline, "AKS.CSE.ensureKubelet").Lane)
+
+	var marks []string
+	for _, m := range timeline.Marks {
+		marks = append(marks, m.Name)
+	}
+	require.Equal(t, []string{
+		"kernel start", "cloud-init-local start", "cloud-init start", "guest-agent start", "cloud-final start",
+		"cse start", "kubelet start",
+	}, marks)
+}
+
+func TestCriticalPath(t *testing.T) {
+	var names []string
+	for _, s := range Build(testInput()).CriticalPath() {
+		names = append(names, s.Name)
+	}
+	// userspace ends last, after CSE: it is the critical span in the systemd track, and the boot before it started.
+	require.Equal(t, []string{"firmware", "kernel", "initrd", "userspace"}, names)
+
+	in := testInput()
+	in.Provision.SystemdSummary = ""
+	names = nil
+	for _, s := range Build(in).CriticalPath() {
+		names = append(names, s.Name)
+	}
+	require.Equal(t, []string{
+		"kernel", "cloud-init-local", "cloud-init", "cloud-final", "AKS.CSE.pullImages", "AKS.CSE.ensureKubelet",
+	}, names)
+}
+
+func TestBuildWarnings(t *testing.T) {
+	timeline := Build(Input{
+		Provision: &csetiming.ProvisionTiming{KernelStartTime: "yesterday", SystemdSummary: "Startup finished in 2s (kernel)"},
+		Tasks:     []csetiming.TaskTiming{task("AKS.CSE.backwards", 5, 3)},
+	})
+	require.Len(t, timeline.Warnings, 2)
+	require.Contains(t, timeline.Warnings[0], "kernel start")
+	require.Contains(t, timeline.Warnings[1], "AKS.CSE.backwards")
+	require.Empty(t, timeline.Spans)
+}
+
+func TestBuildKubeletReady(t *testing.T) {
+	cse := task(csetiming.TotalTaskName, 20, 60)
+	cse.Message = `{"ExitCode":"0","KubeletReadyTime":"2026-07-17 02:21:05.500"}`
+	timeline := Build(Input{Tasks: []csetiming.TaskTiming{cse}})
+	require.Equal(t, []Mark{{Name: "kubelet ready", Time: at(65.5)}}, timeline.Marks)
+}
+
+func TestParseSystemdDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"845ms":        845 * time.Millisecond,
+		"2.745s":       2745 * time.Millisecond,
+		"1min 2.745s":  62745 * time.Millisecond,
+		"1h 2min 3s":   time.Hour + 2*time.Minute + 3*time.Second,
+		"12.500us":     12500 * time.Nanosecond,
+		"2d 1h 1min":   49*time.Hour + time.Minute,
+		"1min 500ms  ": time.Minute + 500*time.Millisecond,
+	} {
+		got, err := parseSystemdDuration(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, got, s)
+	}
+	_, err := parseSystemdDuration("soon")
+	require.Error(t, err)
+}
+
+func TestWriteTrace(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Build(testInput()).WriteTrace(&buf))
+
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+	threads := map[int]string{}
+	var spans, instants int
+	for _, e := range trace.TraceEvents {
+		switch e.Phase {
+		case "M":
+			if e.Name == "thread_name" {
+				threads[e.TID] = e.Args["name"].(string)
+			}
+		case "X":
+			spans++
+			require.GreaterOrEqual(t, e.TS, int64(0))
+			if e.Name == "AKS.CSE.pullImages" {
+				require.Equal(t, "cse (2)", threads[e.TID])
+				require.Equal(t, int64(26500000), e.TS)
+				require.Equal(t, int64(8000000), e.Dur)
+			}
+		case "i":
+			instants++
+			require.Equal(t, "g", e.Scope)
+		}
+	}
+	require.Equal(t, 14, spans)
+	require.Equal(t, 7, instants)
+	require.ElementsMatch(t, []string{"systemd", "boot", "cse", "cse (2)"}, mapValues(threads))
+}
+
+func mapValues(m map[int]string) []string {
+	var values []string
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestWriteWaterfall(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Build(testInput()).WriteWaterfall(&buf, 40))
+	out := buf.String()
+	lines := strings.Split(out, "\n")
+	require.Equal(t, "Boot timeline from 2026-07-17 02:19:58.500 UTC, 71.250s", lines[0])
+
+	var ensureKubelet, pull, kernelMark string
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "AKS.CSE.ensureKubelet"):
+			ensureKubelet = line
+		case strings.Contains(line, "AKS.CSE.pullImages"):
+			pull = line
+		case strings.Contains(line, "@ kernel start"):
+			kernelMark = line
+		}
+	}
+	require.Contains(t, ensureKubelet, "+36.500s")
+	require.Contains(t, ensureKubelet, "23.000s")
+	require.Contains(t, ensureKubelet, "    AKS.CSE.ensureKubelet")
+	require.Contains(t, pull, "=")
+	require.NotContains(t, pull, "*")
+	require.Contains(t, kernelMark, "|")
+	require.Contains(t, out, "Critical path: 4 spans, 71.250s of 71.250s covered, 0.000s between spans")
+}
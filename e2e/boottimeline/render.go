@@ -0,0 +1,141 @@
+package boottimeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// traceEvent is an event of the Chrome trace-event format, loaded by chrome://tracing and https://ui.perfetto.dev.
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"`
+	Dur   int64          `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteTrace writes the timeline as Chrome trace-event JSON. Each lane of a track is a thread, timestamps are relative
+// to the start of the timeline.
+func (t *Timeline) WriteTrace(w io.Writer) error {
+	origin := t.Start()
+	micros := func(at time.Time) int64 { return at.Sub(origin).Microseconds() }
+	events := []traceEvent{{Name: "process_name", Phase: "M", PID: 1, Args: map[string]any{"name": "node boot"}}}
+
+	threads := map[string]int{}
+	thread := func(s Span) int {
+		name := s.Track
+		if s.Lane > 0 {
+			name = fmt.Sprintf("%s (%d)", s.Track, s.Lane+1)
+		}
+		tid, ok := threads[name]
+		if !ok {
+			tid = len(threads) + 1
+			threads[name] = tid
+			events = append(events,
+				traceEvent{Name: "thread_name", Phase: "M", PID: 1, TID: tid, Args: map[string]any{"name": name}},
+				traceEvent{Name: "thread_sort_index", Phase: "M", PID: 1, TID: tid, Args: map[string]any{"sort_index": tid}},
+			)
+		}
+		return tid
+	}
+
+	for _, s := range t.Spans {
+		args := map[string]any{"duration": s.Duration().String()}
+		if s.Critical {
+			args["critical"] = true
+		}
+		events = append(events, traceEvent{
+			Name: s.Name, Cat: s.Track, Phase: "X", TS: micros(s.Start), Dur: s.Duration().Microseconds(),
+			PID: 1, TID: thread(s), Args: args,
+		})
+	}
+	for _, m := range t.Marks {
+		events = append(events, traceEvent{
+			Name: m.Name, Cat: "milestone", Phase: "i", TS: micros(m.Time), PID: 1, Scope: "g",
+			Args: map[string]any{"time": m.Time.Format(time.RFC3339Nano)},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// WriteWaterfall writes the timeline as a text waterfall with bars width characters wide. Spans on the critical path
+// are marked with a star and drawn with '#', the others with '='. Marks are drawn with '|'.
+func (t *Timeline) WriteWaterfall(w io.Writer, width int) error {
+	origin, end := t.Start(), t.End()
+	total := end.Sub(origin)
+	column := func(at time.Time) int {
+		if total <= 0 {
+			return 0
+		}
+		return int(math.Round(float64(at.Sub(origin)) / float64(total) * float64(width-1)))
+	}
+	offset := func(at time.Time) string { return fmt.Sprintf("+%.3fs", at.Sub(origin).Seconds()) }
+
+	type row struct {
+		at   time.Time
+		line string
+	}
+	var rows []row
+	for _, s := range t.Spans {
+		bar := []byte(strings.Repeat(" ", width))
+		fill := byte('=')
+		critical := " "
+		if s.Critical {
+			fill, critical = '#', "*"
+		}
+		for i := column(s.Start); i <= column(s.End); i++ {
+			bar[i] = fill
+		}
+		name := strings.Repeat("  ", s.Depth) + s.Name
+		rows = append(rows, row{s.Start, fmt.Sprintf("%s\t%.3fs\t%s\t%s %s\t|%s|",
+			offset(s.Start), s.Duration().Seconds(), s.Track, critical, name, bar)})
+	}
+	for _, m := range t.Marks {
+		bar := []byte(strings.Repeat(" ", width))
+		bar[column(m.Time)] = '|'
+		rows = append(rows, row{m.Time, fmt.Sprintf("%s\t\t\t@ %s\t|%s|", offset(m.Time), m.Name, bar)})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].at.Before(rows[j].at) })
+
+	if _, err := fmt.Fprintf(w, "Boot timeline from %s, %.3fs\n\n", origin.Format("2006-01-02 15:04:05.000 MST"), total.Seconds()); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tDURATION\tTRACK\t  NAME\t")
+	for _, r := range rows {
+		fmt.Fprintln(tw, r.line)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if path := t.CriticalPath(); len(path) > 0 {
+		var covered time.Duration
+		for _, s := range path {
+			covered += s.Duration()
+		}
+		pathTotal := path[len(path)-1].End.Sub(path[0].Start)
+		fmt.Fprintf(w, "\nCritical path: %d spans, %.3fs of %.3fs covered, %.3fs between spans\n",
+			len(path), covered.Seconds(), pathTotal.Seconds(), (pathTotal - covered).Seconds())
+	}
+	for _, warning := range t.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+	return nil
+}
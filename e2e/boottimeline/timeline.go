@@ -0,0 +1,368 @@
+// Package boottimeline merges the boot milestones of provision.json, the systemd boot phases and the CSE task timings
+// of a node into a single timeline, and renders it as Chrome trace-event JSON or as a text waterfall to find the
+// critical path and the overlap of a slow node boot.
+package boottimeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/csetiming"
+)
+
+// Tracks group the spans of the timeline, in display order.
+const (
+	TrackSystemd = "systemd"
+	TrackBoot    = "boot"
+	TrackCSE     = "cse"
+)
+
+var trackOrder = map[string]int{TrackSystemd: 0, TrackBoot: 1, TrackCSE: 2}
+
+// Span is a timed interval of the boot.
+type Span struct {
+	Name  string
+	Track string
+	Start time.Time
+	End   time.Time
+	// Lane and Depth place the span in its track: spans of a lane are nested, overlapping spans which aren't nested
+	// are in different lanes.
+	Lane  int
+	Depth int
+	// Critical spans are on the critical path of the boot.
+	Critical bool
+
+	children int
+}
+
+// Duration returns the duration of the span.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+func (s Span) contains(o Span) bool {
+	return !s.Start.After(o.Start) && !s.End.Before(o.End) && s.Duration() > o.Duration()
+}
+
+// Mark is an instant of the boot, such as the start of a service.
+type Mark struct {
+	Name string
+	Time time.Time
+}
+
+// Timeline is the boot timeline of a node. Spans are sorted by track, start time and decreasing duration, marks by
+// time.
+type Timeline struct {
+	Spans []Span
+	Marks []Mark
+	// Warnings are the inputs which could not be used, such as unparsable timestamps.
+	Warnings []string
+}
+
+// Start returns the earliest instant of the timeline.
+func (t *Timeline) Start() time.Time {
+	var start time.Time
+	for _, s := range t.Spans {
+		if start.IsZero() || s.Start.Before(start) {
+			start = s.Start
+		}
+	}
+	for _, m := range t.Marks {
+		if start.IsZero() || m.Time.Before(start) {
+			start = m.Time
+		}
+	}
+	return start
+}
+
+// End returns the latest instant of the timeline.
+func (t *Timeline) End() time.Time {
+	var end time.Time
+	for _, s := range t.Spans {
+		if s.End.After(end) {
+			end = s.End
+		}
+	}
+	for _, m := range t.Marks {
+		if m.Time.After(end) {
+			end = m.Time
+		}
+	}
+	return end
+}
+
+// CriticalPath returns the spans on the critical path, in time order.
+func (t *Timeline) CriticalPath() []Span {
+	var path []Span
+	for _, s := range t.Spans {
+		if s.Critical {
+			path = append(path, s)
+		}
+	}
+	sort.SliceStable(path, func(i, j int) bool { return path[i].Start.Before(path[j].Start) })
+	return path
+}
+
+// Input are the timing sources of a node, any of them may be missing.
+type Input struct {
+	Provision *csetiming.ProvisionTiming
+	Tasks     []csetiming.TaskTiming
+}
+
+// Build merges the inputs into a timeline.
+func Build(in Input) *Timeline {
+	t := &Timeline{}
+	milestones := map[string]time.Time{}
+	if p := in.Provision; p != nil {
+		for _, m := range []struct{ name, value string }{
+			{"kernel", p.KernelStartTime},
+			{"systemd-networkd", p.NetworkdStartTime},
+			{"cloud-init-local", p.CloudInitLocalStart},
+			{"cloud-init", p.CloudInitStart},
+			{"cloud-final", p.CloudFinalStart},
+			{"guest-agent", p.GuestAgentStartTime},
+			{"cse", p.CSEStartTime},
+			{"kubelet", bootDatapoint(p.BootDatapoints, "KubeletStartTime")},
+		} {
+			t.addMark(milestones, m.name, m.value)
+		}
+	}
+	for _, task := range in.Tasks {
+		if task.TaskName == csetiming.TotalTaskName {
+			t.addKubeletReady(task.Message)
+		}
+	}
+
+	t.addBootStages(milestones)
+	if p := in.Provision; p != nil && p.SystemdSummary != "" {
+		if kernel, ok := milestones["kernel"]; ok {
+			t.addSystemdPhases(kernel, p.SystemdSummary)
+		}
+	}
+	t.addTasks(in)
+
+	sort.SliceStable(t.Marks, func(i, j int) bool { return t.Marks[i].Time.Before(t.Marks[j].Time) })
+	sort.SliceStable(t.Spans, func(i, j int) bool {
+		a, b := t.Spans[i], t.Spans[j]
+		if a.Track != b.Track {
+			return trackOrder[a.Track] < trackOrder[b.Track]
+		}
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.Duration() > b.Duration()
+	})
+	assignLanes(t.Spans)
+	markCriticalPath(t.Spans)
+	return t
+}
+
+func (t *Timeline) addMark(milestones map[string]time.Time, name, value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	at, err := csetiming.ParseTimestamp(value)
+	if err != nil {
+		t.Warnings = append(t.Warnings, fmt.Sprintf("%s start: %s", name, err))
+		return
+	}
+	milestones[name] = at
+	t.Marks = append(t.Marks, Mark{Name: name + " start", Time: at})
+}
+
+// addKubeletReady adds the kubelet ready time reported in the message of the cse_start event.
+func (t *Timeline) addKubeletReady(message string) {
+	var m struct {
+		KubeletReadyTime string `json:"KubeletReadyTime"`
+	}
+	if json.Unmarshal([]byte(message), &m) != nil || m.KubeletReadyTime == "" {
+		return
+	}
+	at, err := csetiming.ParseTimestamp(m.KubeletReadyTime)
+	if err != nil {
+		t.Warnings = append(t.Warnings, fmt.Sprintf("kubelet ready: %s", err))
+		return
+	}
+	t.Marks = append(t.Marks, Mark{Name: "kubelet ready", Time: at})
+}
+
+// bootStages are the sequential boot stages, each lasting until the start of the next one found. The last one lasts
+// until CSE starts.
+var bootStages = []string{"kernel", "cloud-init-local", "cloud-init", "cloud-final", "cse"}
+
+func (t *Timeline) addBootStages(milestones map[string]time.Time) {
+	var previous string
+	for _, stage := range bootStages {
+		at, ok := milestones[stage]
+		if !ok {
+			continue
+		}
+		if previous != "" && at.After(milestones[previous]) {
+			t.Spans = append(t.Spans, Span{Name: previous, Track: TrackBoot, Start: milestones[previous], End: at})
+		}
+		previous = stage
+	}
+}
+
+var (
+	systemdPhaseRegexp    = regexp.MustCompile(`([0-9][0-9a-z. ]*?) \((firmware|loader|kernel|initrd|userspace)\)`)
+	systemdDurationRegexp = regexp.MustCompile(`([0-9.]+)(d|h|min|s|ms|us)\b`)
+)
+
+// addSystemdPhases adds the boot phases of systemd-analyze, "Startup finished in 2.1s (kernel) + 5.3s (initrd) +
+// 1min 2.745s (userspace) = 1min 10.145s". The firmware and loader phases run before the kernel starts.
+func (t *Timeline) addSystemdPhases(kernelStart time.Time, summary string) {
+	type phase struct {
+		name     string
+		duration time.Duration
+	}
+	var phases []phase
+	kernelIndex := -1
+	for _, match := range systemdPhaseRegexp.FindAllStringSubmatch(summary, -1) {
+		d, err := parseSystemdDuration(match[1])
+		if err != nil {
+			t.Warnings = append(t.Warnings, fmt.Sprintf("systemd %s phase: %s", match[2], err))
+			return
+		}
+		if match[2] == "kernel" {
+			kernelIndex = len(phases)
+		}
+		phases = append(phases, phase{match[2], d})
+	}
+	if kernelIndex < 0 {
+		if len(phases) > 0 {
+			t.Warnings = append(t.Warnings, "systemd summary has no kernel phase")
+		}
+		return
+	}
+	start := kernelStart
+	for i := kernelIndex - 1; i >= 0; i-- {
+		start = start.Add(-phases[i].duration)
+	}
+	for _, p := range phases {
+		t.Spans = append(t.Spans, Span{Name: p.name, Track: TrackSystemd, Start: start, End: start.Add(p.duration)})
+		start = start.Add(p.duration)
+	}
+}
+
+func parseSystemdDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	matches := systemdDurationRegexp.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("cannot parse systemd duration %q", s)
+	}
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour, "h": time.Hour, "min": time.Minute, "s": time.Second, "ms": time.Millisecond, "us": time.Microsecond,
+	}
+	for _, m := range matches {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse systemd duration %q: %w", s, err)
+		}
+		total += time.Duration(v * float64(units[m[2]]))
+	}
+	return total, nil
+}
+
+// addTasks adds the CSE task spans. The whole CSE execution is taken from provision.json when no cse_start task was
+// found.
+func (t *Timeline) addTasks(in Input) {
+	hasTotal := false
+	for _, task := range in.Tasks {
+		if task.TaskName == csetiming.TotalTaskName {
+			hasTotal = true
+		}
+		if task.EndTime.Before(task.StartTime) {
+			t.Warnings = append(t.Warnings, fmt.Sprintf("task %s ends before it starts", task.TaskName))
+			continue
+		}
+		t.Spans = append(t.Spans, Span{Name: task.TaskName, Track: TrackCSE, Start: task.StartTime, End: task.EndTime})
+	}
+	if hasTotal || in.Provision == nil || in.Provision.CSEStartTime == "" || in.Provision.ExecDuration == "" {
+		return
+	}
+	start, err := csetiming.ParseTimestamp(in.Provision.CSEStartTime)
+	if err != nil {
+		return
+	}
+	d, err := time.ParseDuration(in.Provision.ExecDuration + "s")
+	if err != nil {
+		t.Warnings = append(t.Warnings, fmt.Sprintf("CSE duration: %s", err))
+		return
+	}
+	t.Spans = append(t.Spans, Span{Name: csetiming.TotalTaskName, Track: TrackCSE, Start: start, End: start.Add(d)})
+}
+
+func bootDatapoint(raw json.RawMessage, name string) string {
+	var datapoints map[string]string
+	if json.Unmarshal(raw, &datapoints) != nil {
+		return ""
+	}
+	return datapoints[name]
+}
+
+// assignLanes places the spans, sorted by track, start time and decreasing duration, in the first lane of their track
+// where they nest in the open spans.
+func assignLanes(spans []Span) {
+	var lanes [][]*Span
+	track := ""
+	for i := range spans {
+		s := &spans[i]
+		if s.Track != track {
+			track = s.Track
+			lanes = nil
+		}
+		placed := false
+		for lane, open := range lanes {
+			for len(open) > 0 && !open[len(open)-1].End.After(s.Start) {
+				open = open[:len(open)-1]
+			}
+			lanes[lane] = open
+			if len(open) == 0 || open[len(open)-1].contains(*s) {
+				s.Lane, s.Depth = lane, len(open)
+				if len(open) > 0 {
+					open[len(open)-1].children++
+				}
+				lanes[lane] = append(open, s)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			s.Lane = len(lanes)
+			lanes = append(lanes, []*Span{s})
+		}
+	}
+}
+
+// markCriticalPath marks the critical path: from the innermost span ending last, the innermost span ending last before
+// the current one starts, repeatedly. Spans with nested spans aren't marked since they don't tell which step was slow.
+func markCriticalPath(spans []Span) {
+	var leaves []int
+	for i := range spans {
+		if spans[i].children == 0 {
+			leaves = append(leaves, i)
+		}
+	}
+	latestBefore := func(limit time.Time, bounded bool) int {
+		best := -1
+		for _, i := range leaves {
+			s := spans[i]
+			if s.Duration() == 0 || bounded && s.End.After(limit) {
+				continue
+			}
+			if best < 0 || s.End.After(spans[best].End) || s.End.Equal(spans[best].End) && s.Duration() > spans[best].Duration() {
+				best = i
+			}
+		}
+		return best
+	}
+	for i := latestBefore(time.Time{}, false); i >= 0; i = latestBefore(spans[i].Start, true) {
+		spans[i].Critical = true
+	}
+}
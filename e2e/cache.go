@@ -61,6 +61,7 @@ func createGallery(ctx context.Context, request CreateGalleryRequest) (armcomput
 	// If the gallery does not exist, create it.
 	poller, err := config.Azure.Galleries.BeginCreateOrUpdate(ctx, request.ResourceGroup, galleryName, armcompute.Gallery{
 		Location: to.Ptr(request.Location),
+		Tags:     sharedTags(),
 		Properties: &armcompute.GalleryProperties{
 			Description: to.Ptr("E2E test gallery for two-stage kubelet configuration"),
 		},
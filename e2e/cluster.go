@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/errorclass"
+	"github.com/Azure/agentbaker/e2e/sweeper"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
@@ -626,80 +628,66 @@ func createNewAKSCluster(ctx context.Context, cluster *armcontainerservice.Manag
 // clusters are reused, and sometimes a cluster can be in UPDATING or DELETING state
 // simple retry should be sufficient to avoid such conflicts
 func createNewAKSClusterWithRetry(ctx context.Context, cluster *armcontainerservice.ManagedCluster) (*armcontainerservice.ManagedCluster, error) {
-	maxRetries := 10
-	retryInterval := 30 * time.Second
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			toolkit.Logf(ctx, "Attempt %d: creating or updating cluster %s in region %s and rg %s", attempt+1, *cluster.Name, *cluster.Location, config.ResourceGroupName(*cluster.Location))
-		}
-
-		createdCluster, err := createNewAKSCluster(ctx, cluster)
+	var createdCluster *armcontainerservice.ManagedCluster
+	retrier := errorclass.Retrier{
+		Phase: errorclass.PhaseCluster,
+		// the cluster is created once for the scenarios sharing it, its retries are recorded by the scenario creating it.
+		BeforeRetry: func(ctx context.Context, a errorclass.Attempt) {
+			scenarioRecorderFromContext(ctx).retried(a)
+		},
+		Logf: func(format string, args ...any) {
+			toolkit.Logf(ctx, format, args...)
+		},
+	}
+	result, err := retrier.Do(ctx, func(ctx context.Context) error {
+		var err error
+		createdCluster, err = createNewAKSCluster(ctx, cluster)
 		if err == nil {
-			return createdCluster, nil
+			return nil
 		}
-
-		if isRetryableClusterError(err) {
-			lastErr = err
-			if isClusterCreateOperationInProgressError(err) {
-				toolkit.Logf(ctx, "cluster %s has an in-progress create operation; waiting for it to finish", *cluster.Name)
-				createdCluster, waitErr := waitUntilClusterReady(ctx, *cluster.Name, *cluster.Location)
-				if waitErr != nil {
-					return nil, fmt.Errorf("waiting for in-progress cluster creation: %w", waitErr)
-				}
-				if createdCluster != nil {
-					return createdCluster, nil
-				}
-				continue
+		if isClusterCreateOperationInProgressError(err) {
+			toolkit.Logf(ctx, "cluster %s has an in-progress create operation; waiting for it to finish", *cluster.Name)
+			var waitErr error
+			createdCluster, waitErr = waitUntilClusterReady(ctx, *cluster.Name, *cluster.Location)
+			if waitErr != nil {
+				return errorclass.Permanent(fmt.Errorf("waiting for in-progress cluster creation: %w", waitErr))
 			}
-			if isResourceGroupBeingDeletedError(err) {
-				nodeResourceGroup := expectedNodeResourceGroupName(*cluster.Location, *cluster.Name)
-				if cluster.Properties != nil && cluster.Properties.NodeResourceGroup != nil {
-					nodeResourceGroup = *cluster.Properties.NodeResourceGroup
-				}
-				if cleanupErr := detachNodeResourceGroupReferencesFromClusterSubnet(ctx, *cluster.Location, *cluster.Name, nodeResourceGroup); cleanupErr != nil {
-					toolkit.Logf(ctx, "warning: failed to detach subnet references for deleting node resource group %q: %v", nodeResourceGroup, cleanupErr)
-				}
-				if deleteErr := deleteCluster(ctx, *cluster.Name, config.ResourceGroupName(*cluster.Location)); deleteErr != nil {
-					return nil, fmt.Errorf("deleting cluster with deleting node resource group %q: %w", nodeResourceGroup, deleteErr)
-				}
-				if deleteErr := waitForClusterDeletion(ctx, *cluster.Name, config.ResourceGroupName(*cluster.Location)); deleteErr != nil {
-					return nil, fmt.Errorf("failed waiting for cluster deletion: %w", deleteErr)
-				}
+			if createdCluster != nil {
+				return nil
 			}
-			toolkit.Logf(ctx, "Attempt %d failed with retryable error: %v. Retrying in %v...", attempt+1, err, retryInterval)
-
-			select {
-			case <-time.After(retryInterval):
-			case <-ctx.Done():
-				return nil, fmt.Errorf("context canceled while retrying cluster creation: %w", ctx.Err())
+			return err
+		}
+		if isResourceGroupBeingDeletedError(err) {
+			nodeResourceGroup := expectedNodeResourceGroupName(*cluster.Location, *cluster.Name)
+			if cluster.Properties != nil && cluster.Properties.NodeResourceGroup != nil {
+				nodeResourceGroup = *cluster.Properties.NodeResourceGroup
+			}
+			if cleanupErr := detachNodeResourceGroupReferencesFromClusterSubnet(ctx, *cluster.Location, *cluster.Name, nodeResourceGroup); cleanupErr != nil {
+				toolkit.Logf(ctx, "warning: failed to detach subnet references for deleting node resource group %q: %v", nodeResourceGroup, cleanupErr)
+			}
+			if deleteErr := deleteCluster(ctx, *cluster.Name, config.ResourceGroupName(*cluster.Location)); deleteErr != nil {
+				return errorclass.Permanent(fmt.Errorf("deleting cluster with deleting node resource group %q: %w", nodeResourceGroup, deleteErr))
+			}
+			if deleteErr := waitForClusterDeletion(ctx, *cluster.Name, config.ResourceGroupName(*cluster.Location)); deleteErr != nil {
+				return errorclass.Permanent(fmt.Errorf("failed waiting for cluster deletion: %w", deleteErr))
 			}
-		} else {
-			return nil, fmt.Errorf("failed to create cluster: %w", err)
 		}
+		return err
+	})
+	switch {
+	case err == nil:
+		return createdCluster, nil
+	case result.Exhausted():
+		return nil, fmt.Errorf("failed to create cluster after %d attempts: %w", result.Attempts, err)
+	default:
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
 	}
-
-	return nil, fmt.Errorf("failed to create cluster after %d attempts: %w", maxRetries, lastErr)
 }
 
 func expectedNodeResourceGroupName(location, clusterName string) string {
 	return fmt.Sprintf("MC_%s_%s_%s", config.ResourceGroupName(location), clusterName, location)
 }
 
-// isRetryableClusterError returns true for transient cluster creation errors
-// that can be resolved by retrying, such as 409 Conflict (concurrent operations)
-// and NotFound during managed identity reconciliation (stale references after cluster deletion).
-func isRetryableClusterError(err error) bool {
-	var respErr *azcore.ResponseError
-	if !errors.As(err, &respErr) {
-		return false
-	}
-	if respErr.StatusCode == 409 {
-		return true
-	}
-	return respErr.ErrorCode == "NotFound" && strings.Contains(err.Error(), "Reconcile managed identity credential failed")
-}
-
 func isResourceGroupBeingDeletedError(err error) bool {
 	var respErr *azcore.ResponseError
 	if !errors.As(err, &respErr) {
@@ -829,12 +817,12 @@ func collectGarbageVMSS(ctx context.Context, cluster *armcontainerservice.Manage
 			return fmt.Errorf("failed to get next page of VMSS: %w", err)
 		}
 		for _, vmss := range page.Value {
-			if _, ok := vmss.Tags["KEEP_VMSS"]; ok {
+			if _, ok := vmss.Tags[sweeper.TagKeep]; ok {
 				keptVMSS[*vmss.Name] = struct{}{}
 				continue
 			}
 			// don't delete managed pools (tag-based check)
-			if _, ok := vmss.Tags["aks-managed-poolName"]; ok {
+			if _, ok := vmss.Tags[sweeper.TagAKSManagedPool]; ok {
 				keptVMSS[*vmss.Name] = struct{}{}
 				continue
 			}
@@ -0,0 +1,134 @@
+// Command boot-timeline merges provision.json, the CSE task traces of cluster-provision.log and the guest agent events
+// of a node into a single boot timeline, and writes it as a text waterfall or as Chrome trace-event JSON to load in
+// chrome://tracing or https://ui.perfetto.dev.
+//
+//	boot-timeline -node -format trace -output boot.json
+//	boot-timeline -report scenario-logs/Test_Ubuntu2204_CSEPerf/cse-timing.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/Azure/agentbaker/e2e/boottimeline"
+	"github.com/Azure/agentbaker/e2e/csetiming"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("boot-timeline", flag.ContinueOnError)
+	node := flags.Bool("node", false, "read the timing files of the node the command runs on")
+	reportPath := flags.String("report", "", "CSE timing report saved by the e2e scenarios")
+	provisionPath := flags.String("provision", "", "provision.json")
+	logPath := flags.String("log", "", "cluster-provision.log")
+	eventsDir := flags.String("events", "", "directory of guest agent event files")
+	format := flags.String("format", "text", "output format, text or trace")
+	width := flags.Int("width", 80, "width of the text waterfall bars")
+	output := flags.String("output", "", "output file, stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "trace" {
+		return fmt.Errorf("unknown format %q, expected text or trace", *format)
+	}
+
+	// files found on the node may be missing, such as events already sent by the guest agent.
+	optional := false
+	if *node {
+		optional = true
+		*provisionPath = csetiming.ProvisionJSONPath
+		*logPath = csetiming.ProvisionLogPath
+		*eventsDir = csetiming.EventsDir
+	}
+	if *reportPath == "" && *provisionPath == "" && *logPath == "" && *eventsDir == "" {
+		return errors.New("one of -node, -report, -provision, -log or -events is required")
+	}
+
+	var in boottimeline.Input
+	var sources [][]csetiming.TaskTiming
+	if *reportPath != "" {
+		report, err := csetiming.LoadReport(*reportPath)
+		if err != nil {
+			return err
+		}
+		in.Provision = report.Provision
+		sources = append(sources, report.Tasks)
+	}
+	if *provisionPath != "" {
+		provision, err := loadProvision(*provisionPath)
+		if err := skipMissing(err, optional); err != nil {
+			return err
+		}
+		if provision != nil {
+			in.Provision = provision
+		}
+	}
+	if *logPath != "" {
+		data, err := os.ReadFile(*logPath)
+		if err := skipMissing(err, optional); err != nil {
+			return err
+		}
+		tasks, parseErrors := csetiming.ParseProvisionLog(string(data))
+		for _, err := range parseErrors {
+			log.Printf("%s: %s", *logPath, err)
+		}
+		sources = append(sources, tasks)
+	}
+	if *eventsDir != "" {
+		tasks, err := csetiming.LoadEvents(*eventsDir)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, tasks)
+	}
+	in.Tasks = csetiming.MergeTasks(sources...)
+
+	timeline := boottimeline.Build(in)
+	if len(timeline.Spans) == 0 && len(timeline.Marks) == 0 {
+		return errors.New("no boot timing found")
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "trace" {
+		return timeline.WriteTrace(w)
+	}
+	return timeline.WriteWaterfall(w, *width)
+}
+
+func loadProvision(path string) (*csetiming.ProvisionTiming, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var provision csetiming.ProvisionTiming
+	if err := json.Unmarshal(data, &provision); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &provision, nil
+}
+
+func skipMissing(err error, optional bool) error {
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+// Command cse-timing aggregates the CSE timing reports saved by the e2e scenarios into per distro and VM size
+// baselines, and compares new reports against them. compare exits with a non-zero code when a task regressed.
+//
+//	cse-timing aggregate -o baselines.json scenario-logs/
+//	cse-timing compare -baseline baselines.json scenario-logs/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/Azure/agentbaker/e2e/csetiming"
+)
+
+const usage = `usage: cse-timing <command> [flags] <report files or directories>
+
+commands:
+  aggregate  aggregate reports into baselines
+  compare    compare reports against their baselines`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "aggregate":
+		return aggregate(args[1:], stdout)
+	case "compare":
+		return compare(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func aggregate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	output := flags.String("o", "", "baselines file, stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	reports, err := loadReports(flags.Args())
+	if err != nil {
+		return err
+	}
+	baselines := csetiming.Aggregate(reports)
+	if *output != "" {
+		return baselines.WriteFile(*output)
+	}
+	return baselines.Write(stdout)
+}
+
+func compare(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	baselinePath := flags.String("baseline", "", "baselines file written by aggregate")
+	var opts csetiming.CompareOptions
+	flags.Float64Var(&opts.Alpha, "alpha", 0, "significance level, 0.01 when zero")
+	flags.DurationVar(&opts.MinIncrease, "min-increase", 0, "smallest mean increase reported, 1s when zero")
+	flags.Float64Var(&opts.MinRelativeIncrease, "min-relative-increase", 0, "smallest mean increase reported relative to the baseline, 0.1 when zero")
+	flags.IntVar(&opts.MinBaselineSamples, "min-baseline-samples", 0, "baseline samples needed to test a task, 5 when zero")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *baselinePath == "" {
+		return errors.New("-baseline is required")
+	}
+	baselines, err := csetiming.LoadBaselines(*baselinePath)
+	if err != nil {
+		return err
+	}
+	reports, err := loadReports(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	byKey := map[csetiming.Key][]*csetiming.Report{}
+	for _, r := range reports {
+		byKey[r.Key()] = append(byKey[r.Key()], r)
+	}
+	keys := make([]csetiming.Key, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	var regressions int
+	for _, key := range keys {
+		fmt.Fprintf(stdout, "=== %s (%d reports)\n", key, len(byKey[key]))
+		baseline := baselines.Find(key)
+		if baseline == nil {
+			fmt.Fprintln(stdout, "no baseline")
+			continue
+		}
+		comparisons := csetiming.Compare(baseline, byKey[key], opts)
+		for _, c := range comparisons {
+			fmt.Fprintln(stdout, c)
+		}
+		regressions += len(csetiming.Regressions(comparisons))
+	}
+	if regressions > 0 {
+		return fmt.Errorf("%d CSE tasks regressed", regressions)
+	}
+	return nil
+}
+
+func loadReports(paths []string) ([]*csetiming.Report, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no report file or directory given")
+	}
+	reports, err := csetiming.LoadReports(paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no %s report found", csetiming.ReportFileName)
+	}
+	return reports, nil
+}
@@ -0,0 +1,147 @@
+// Command list-scenarios prints the e2e scenarios matching a scenario query, with the attributes the query can select
+// them by. It runs the e2e tests in list mode (LIST_SCENARIOS=true), which renders the bootstrap config of each
+// scenario against a synthetic cluster instead of creating VMs, so it doesn't need Azure. TAGS_TO_RUN and TAGS_TO_SKIP
+// are applied as in a test run.
+//
+//	go run ./cmd/list-scenarios 'os == ubuntu and osversion == 24.04 and gpu and name !~ MIG'
+//	go run ./cmd/list-scenarios -json 'vmfamily in (NC, ND)'
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/agentbaker/e2e/scenarioquery"
+)
+
+// scenarioListFileName mirrors e2e.ScenarioListFileName, the e2e package is a test package which can't be imported.
+const scenarioListFileName = "scenario.json"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("list-scenarios", flag.ContinueOnError)
+	dir := flags.String("dir", ".", "the e2e module directory")
+	testRun := flags.String("run", "^Test_", "go test -run pattern of the scenario tests")
+	timeout := flags.String("timeout", "10m", "go test timeout")
+	asJSON := flags.Bool("json", false, "print the scenario attributes as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("usage: list-scenarios [-json] [-run pattern] [query]")
+	}
+	query := flags.Arg(0)
+	if query != "" {
+		if _, err := scenarioquery.Parse(query); err != nil {
+			return err
+		}
+	}
+
+	logDir, err := os.MkdirTemp("", "list-scenarios")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(logDir)
+
+	var output bytes.Buffer
+	cmd := exec.Command("go", "test", "-run", *testRun, "-count", "1", "-timeout", *timeout, ".")
+	cmd.Dir = *dir
+	cmd.Env = append(os.Environ(), "LIST_SCENARIOS=true", "SCENARIO_QUERY="+query, "LOGGING_DIR="+logDir)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	testErr := cmd.Run()
+
+	scenarios, err := loadScenarios(logDir)
+	if err != nil {
+		return err
+	}
+	if testErr != nil {
+		// tests which aren't scenarios may fail without Azure, the scenarios which were listed are still printed.
+		log.Printf("go test failed, the list may be incomplete: %v\n%s", testErr, failures(output.String()))
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(scenarios)
+	}
+	return printScenarios(stdout, scenarios)
+}
+
+// loadScenarios reads the scenario attributes written under dir, sorted by scenario name.
+func loadScenarios(dir string) ([]map[string]string, error) {
+	var scenarios []map[string]string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != scenarioListFileName {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var attributes map[string]string
+		if err := json.Unmarshal(data, &attributes); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		scenarios = append(scenarios, attributes)
+		return nil
+	})
+	sort.SliceStable(scenarios, func(i, j int) bool { return scenarios[i]["name"] < scenarios[j]["name"] })
+	return scenarios, err
+}
+
+// columns are the attributes printed as columns, the boolean tags which are true are printed in the last column.
+var columns = []string{"name", "os", "osversion", "arch", "vmsize", "k8sversion"}
+
+func printScenarios(w io.Writer, scenarios []map[string]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t"))+"\tTAGS")
+	for _, attributes := range scenarios {
+		for _, c := range columns {
+			value := attributes[c]
+			if value == "" {
+				value = "-"
+			}
+			fmt.Fprintf(tw, "%s\t", value)
+		}
+		var tags []string
+		for name, value := range attributes {
+			if b, err := strconv.ParseBool(value); err == nil && b {
+				tags = append(tags, name)
+			}
+		}
+		sort.Strings(tags)
+		fmt.Fprintln(tw, strings.Join(tags, ","))
+	}
+	fmt.Fprintf(tw, "\n%d scenarios\n", len(scenarios))
+	return tw.Flush()
+}
+
+// failures returns the lines of the go test output naming the failed tests.
+func failures(output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--- FAIL") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
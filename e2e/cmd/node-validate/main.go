@@ -0,0 +1,105 @@
+// Command node-validate runs the nodevalidate conformance checks on the node it runs on, against the AKSNodeConfig
+// the node was provisioned with, and writes a JSON or JUnit report. It exits with a non-zero code when a check fails.
+//
+//	node-validate -config /opt/azure/containers/aks-node-controller-config.json -format junit -output report.xml
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/e2e/nodevalidate"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("node-validate", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to the AKSNodeConfig JSON the node was provisioned with")
+	checkNames := flags.String("checks", "", "comma-separated checks to run, the default checks when empty")
+	list := flags.Bool("list", false, "list the checks and exit")
+	format := flags.String("format", "json", "report format, json or junit")
+	output := flags.String("output", "", "report file, stdout when empty")
+	verbose := flags.Bool("v", false, "log the progress of the checks to stderr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		return listChecks(stdout)
+	}
+	if *configPath == "" {
+		return fmt.Errorf("-config is required")
+	}
+	if *format != "json" && *format != "junit" {
+		return fmt.Errorf("unknown report format %q, expected json or junit", *format)
+	}
+	var names []string
+	if *checkNames != "" {
+		names = strings.Split(*checkNames, ",")
+	}
+	checks, err := nodevalidate.Select(names)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*configPath)
+	if err != nil {
+		return fmt.Errorf("read AKSNodeConfig: %w", err)
+	}
+	cfg, loadReport, err := nodeconfigutils.LoadConfiguration(data)
+	if err != nil {
+		return fmt.Errorf("load AKSNodeConfig %s: %w", *configPath, err)
+	}
+	if len(loadReport.UnknownFields) > 0 {
+		log.Printf("AKSNodeConfig fields unknown to node-validate are not checked: %s", strings.Join(loadReport.UnknownFields, ", "))
+	}
+
+	if *verbose {
+		ctx = nodevalidate.WithLogger(ctx, log.Printf)
+	}
+	report := nodevalidate.Run(ctx, &nodeexec.Local{}, cfg, checks)
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "junit" {
+		err = report.WriteJUnit(w, "node-validate")
+	} else {
+		err = report.WriteJSON(w)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", report.Failed, len(report.Checks))
+	}
+	return nil
+}
+
+func listChecks(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDEFAULT\tDESCRIPTION")
+	for _, c := range nodevalidate.Checks() {
+		fmt.Fprintf(tw, "%s\t%v\t%s\n", c.Name, c.Default, c.Description)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,61 @@
+// Command scenario-report aggregates the scenario records saved by the e2e scenarios into a JUnit report, with a test
+// case per phase and per validator of each scenario. The e2e tests write the same report to scenarios-junit.xml in the
+// logging directory; the command rebuilds it from downloaded scenario logs, possibly of several runs.
+//
+//	scenario-report -o scenarios-junit.xml scenario-logs/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Azure/agentbaker/e2e/scenarioreport"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("scenario-report", flag.ContinueOnError)
+	output := flags.String("o", "", "JUnit report file, stdout when empty")
+	name := flags.String("name", "e2e", "name of the test suites")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: scenario-report [-o report.xml] [-name e2e] <scenario log directories>")
+	}
+
+	var records []*scenarioreport.Record
+	for _, dir := range flags.Args() {
+		dirRecords, err := scenarioreport.LoadRecords(dir)
+		if err != nil {
+			return err
+		}
+		records = append(records, dirRecords...)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no %s found in %v", scenarioreport.RecordFileName, flags.Args())
+	}
+	scenarioreport.SortRecords(records)
+
+	if *output == "" {
+		return scenarioreport.WriteJUnit(stdout, *name, records)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := scenarioreport.WriteJUnit(f, *name, records); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,105 @@
+// Command sweep-resources finds the resources the e2e tests leaked in the e2e resource groups and the node resource groups
+// of their clusters, such as the VMSS and gallery image versions of aborted runs, by the tags the tests set on the
+// resources they create, and deletes them.
+// It only reports what it would delete unless -dry-run=false is set. The galleries, bastions and private ACRs shared by
+// the runs of a location are only swept when -shared-max-age is set.
+//
+//	go run ./cmd/sweep-resources -location westus3
+//	go run ./cmd/sweep-resources -aborted-build 20261019.3 -dry-run=false
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/sweeper"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sweep-resources", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", true, "report the resources which would be deleted without deleting them")
+	owner := flags.String("owner", "", "only sweep the resources tagged with this owner")
+	locations := flags.String("location", "", "comma-separated locations to sweep, every location when empty")
+	abortedBuilds := flags.String("aborted-build", "", "comma-separated build IDs whose resources are swept whatever their age")
+	activeBuilds := flags.String("active-build", "", "comma-separated build IDs whose resources are kept whatever their age")
+	maxAge := flags.Duration("max-age", config.Config.TestTimeout+10*time.Minute, "age after which VMSS and gallery image versions are swept")
+	sharedMaxAge := flags.Duration("shared-max-age", 0, "age after which galleries, bastions and container registries are swept, never when 0")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	policy := sweeper.Policy{
+		MaxAge: map[sweeper.Kind]time.Duration{
+			sweeper.KindVMSS:                *maxAge,
+			sweeper.KindGalleryImageVersion: *maxAge,
+		},
+		Owner:           *owner,
+		AbortedBuildIDs: split(*abortedBuilds),
+		ActiveBuildIDs:  split(*activeBuilds),
+	}
+	if *sharedMaxAge > 0 {
+		for _, kind := range []sweeper.Kind{sweeper.KindGallery, sweeper.KindBastion, sweeper.KindContainerRegistry} {
+			policy.MaxAge[kind] = *sharedMaxAge
+		}
+	}
+	azure := &sweeper.Azure{
+		ResourceGroups:       config.Azure.ResourceGroup,
+		VMSS:                 config.Azure.VMSS,
+		Galleries:            config.Azure.Galleries,
+		GalleryImages:        config.Azure.GalleryImages,
+		GalleryImageVersions: config.Azure.GalleryImageVersions,
+		BastionHosts:         config.Azure.BastionHosts,
+		Registries:           config.Azure.RegistriesClient,
+		ResourceGroupName:    config.ResourceGroupName,
+		GalleryPrefix:        config.Config.TestGalleryNamePrefix,
+		Locations:            split(*locations),
+		PollOptions:          config.DefaultPollUntilDoneOptions,
+	}
+	s := sweeper.Sweeper{Inventory: azure, Deleter: azure, Policy: policy, DryRun: *dryRun, Logf: log.Printf}
+
+	report, sweepErr := s.Sweep(ctx)
+	if report == nil {
+		return sweepErr
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else if err := report.WriteText(stdout); err != nil {
+		return err
+	}
+	return sweepErr
+}
+
+func split(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,119 @@
+// Command vhd-bom prints the bill of materials components.json lists for a distro, release and architecture: the
+// packages, container images, downloaded files and OCI artifacts cached on the VHD. With -diff it runs on a Linux VHD,
+// compares the bill of materials of the VHD with what is cached on it, and exits with a non-zero code on differences.
+//
+//	go run ./cmd/vhd-bom -distro azurelinux -release v3.0 -arch arm64
+//	vhd-bom -diff -components /opt/azure/components.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/agentbaker/e2e/components"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("vhd-bom", flag.ContinueOnError)
+	componentsPath := flags.String("components", components.DefaultPath(), "path to components.json")
+	distro := flags.String("distro", "", "distro of the downloadURIs, such as ubuntu, azurelinux, flatcar or windows; with -diff, overrides the distro of the node, as kata VHDs need")
+	release := flags.String("release", "", "release of the distro, such as r2404, v3.0 or current, or the Windows SKU, such as 2022-containerd-gen2")
+	variant := flags.String("variant", "", "OS variant, such as OSGUARD")
+	arch := flags.String("arch", "amd64", "amd64 or arm64")
+	diff := flags.Bool("diff", false, "compare the bill of materials of the node this runs on with what is cached on it")
+	asJSON := flags.Bool("json", false, "print the bill of materials or the differences as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	c, err := components.Load(*componentsPath)
+	if err != nil {
+		return fmt.Errorf("load components.json: %w", err)
+	}
+
+	if *diff {
+		report, err := c.DiffNode(ctx, &nodeexec.Local{}, *distro)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			err = writeJSON(stdout, report)
+		} else {
+			err = report.WriteText(stdout)
+		}
+		if err != nil {
+			return err
+		}
+		if !report.OK() {
+			return fmt.Errorf("%d differences between components.json and the node", len(report.Findings))
+		}
+		return nil
+	}
+
+	if *distro == "" || *release == "" {
+		return fmt.Errorf("-distro and -release are required without -diff")
+	}
+	bom, err := c.Expected(components.Target{Distro: *distro, Release: *release, Variant: strings.ToUpper(*variant), Arch: *arch})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(stdout, bom)
+	}
+	return writeText(stdout, bom)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeText(w io.Writer, bom *components.BOM) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAME\tLOCATION\tVERSIONS")
+	for _, p := range bom.Packages {
+		location := p.DownloadLocation
+		if p.OSPackage != "" {
+			location = strings.TrimSpace(p.OSPackage + " " + location)
+		}
+		fmt.Fprintf(tw, "package\t%s\t%s\t%s\n", p.Name, location, strings.Join(p.Versions, ","))
+	}
+	for _, f := range bom.Files {
+		fmt.Fprintf(tw, "file\t%s\t%s\t%s\n", f.Package, f.Path, f.Version)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// Images are listed apart, their references would widen the table.
+	fmt.Fprintln(w, "\nIMAGES")
+	for _, image := range bom.ContainerImages {
+		fmt.Fprintln(w, image)
+	}
+	if len(bom.OCIArtifacts) > 0 {
+		fmt.Fprintln(w, "\nOCI ARTIFACTS")
+		for _, artifact := range bom.OCIArtifacts {
+			fmt.Fprintln(w, artifact)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s: %d packages, %d files, %d images, %d OCI artifacts\n",
+		bom.Target, len(bom.Packages), len(bom.Files), len(bom.ContainerImages), len(bom.OCIArtifacts))
+	return err
+}
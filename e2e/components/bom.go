@@ -0,0 +1,348 @@
+package components
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// skipVersion is the version of the packages which aren't cached on a release.
+const skipVersion = "<SKIP>"
+
+// Target is the OS a bill of materials is computed for.
+type Target struct {
+	// Distro is a distro of the package downloadURIs: ubuntu, mariner, marinerkata, azurelinux, azurelinuxkata,
+	// flatcar or windows. Azure Container Linux uses the flatcar entries.
+	Distro string `json:"distro"`
+	// Release is a release of the distro, such as r2404, v3.0 or current. For windows it is the Windows SKU, such as
+	// 2022-containerd-gen2, which selects both the release and the versions whose windowsSkuMatch matches it.
+	Release string `json:"release"`
+	// Variant is the OS variant, such as OSGUARD, DEFAULT when empty.
+	Variant string `json:"variant,omitempty"`
+	// Arch is amd64 or arm64.
+	Arch string `json:"arch"`
+}
+
+func (t Target) String() string {
+	s := t.Distro + "/" + t.Release
+	if t.Variant != "" {
+		s += "/" + t.Variant
+	}
+	return s + " " + t.Arch
+}
+
+// IsWindows returns whether the target is a Windows SKU.
+func (t Target) IsWindows() bool {
+	return t.Distro == "windows"
+}
+
+// TargetFromOSRelease returns the target of a Linux node from its /etc/os-release and `uname -m`, resolving the distro,
+// release and variant the way the CSE and VHD build scripts do. Kata VHDs can't be told apart from their os-release,
+// their Distro has to be set by the caller.
+func TargetFromOSRelease(osRelease, machine string) (Target, error) {
+	fields := map[string]string{}
+	for _, line := range strings.Split(osRelease, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+	t := Target{Variant: strings.ToUpper(fields["VARIANT_ID"])}
+	switch strings.TrimSpace(machine) {
+	case "x86_64", "amd64":
+		t.Arch = "amd64"
+	case "aarch64", "arm64":
+		t.Arch = "arm64"
+	default:
+		return Target{}, fmt.Errorf("unknown machine %q", machine)
+	}
+	id, version := fields["ID"], fields["VERSION_ID"]
+	switch {
+	case id == "ubuntu":
+		t.Distro, t.Release = "ubuntu", "r"+strings.ReplaceAll(version, ".", "")
+	case id == "mariner" || (id == "azurelinux" && strings.HasPrefix(version, "2.")):
+		t.Distro, t.Release = "mariner", "current"
+	case id == "azurecontainerlinux" || t.Variant == "AZURECONTAINERLINUX" || id == "flatcar":
+		t.Distro, t.Release, t.Variant = "flatcar", "current", ""
+	case id == "azurelinux":
+		t.Distro, t.Release = "azurelinux", "v"+version
+	default:
+		return Target{}, fmt.Errorf("unknown distro %q", id)
+	}
+	if version == "" {
+		return Target{}, fmt.Errorf("os-release of %s has no VERSION_ID", id)
+	}
+	return t, nil
+}
+
+// BOM is the bill of materials of a VHD: everything components.json says is cached on it.
+type BOM struct {
+	Target   Target            `json:"target"`
+	Packages []ExpectedPackage `json:"packages"`
+	// ContainerImages are the references of the cached images.
+	ContainerImages []string `json:"containerImages"`
+	// Files are the files the packages downloaded from a URL are cached as.
+	Files []File `json:"files,omitempty"`
+	// OCIArtifacts are the references of the cached OCI artifacts, only cached on Windows.
+	OCIArtifacts []string `json:"ociArtifacts,omitempty"`
+}
+
+// ExpectedPackage is a package cached on the VHD.
+type ExpectedPackage struct {
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"`
+	// DownloadLocation is the directory the package is cached in, empty when it is only installed.
+	DownloadLocation string `json:"downloadLocation,omitempty"`
+	// DownloadURLs are the URLs of the versions, empty when the package is installed with the package manager.
+	DownloadURLs []string `json:"downloadURLs,omitempty"`
+	// OSPackage is the deb or rpm name of a package installed with the package manager, from its renovate tag.
+	OSPackage string `json:"osPackage,omitempty"`
+}
+
+// File is a file a package version is cached as. Archives may be extracted in a directory named after the archive
+// without its extension instead.
+type File struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	URL     string `json:"url"`
+}
+
+// customInstalledPackages aren't cached as the file their URL is named after, testPackagesInstalled in
+// vhdbuilder/packer/test/linux-vhd-content-test.sh checks them with dedicated tests.
+var customInstalledPackages = []string{
+	"kubernetes-binaries",
+	"azure-acr-credential-provider",
+	"aks-secure-tls-bootstrap-client",
+	"cni-plugins",
+	"containernetworking-plugins",
+}
+
+// Expected returns the bill of materials of the target.
+func (c *Components) Expected(t Target) (*BOM, error) {
+	if t.Arch != "amd64" && t.Arch != "arm64" {
+		return nil, fmt.Errorf("unknown arch %q", t.Arch)
+	}
+	bom := &BOM{Target: t}
+
+	for _, p := range c.Packages {
+		// Windows only caches the packages with a Windows download location.
+		if t.IsWindows() && p.WindowsDownloadLocation == "" {
+			continue
+		}
+		release, ok := p.release(t)
+		if !ok {
+			continue
+		}
+		expected := ExpectedPackage{Name: p.Name, DownloadLocation: p.DownloadLocation}
+		downloadURL := release.DownloadURL
+		if t.IsWindows() {
+			expected.DownloadLocation = p.WindowsDownloadLocation
+			if release.WindowsDownloadURL != "" {
+				downloadURL = release.WindowsDownloadURL
+			}
+		}
+		for _, v := range release.VersionsV2 {
+			for _, version := range v.Versions() {
+				if version == skipVersion || slices.Contains(expected.Versions, version) {
+					continue
+				}
+				expected.Versions = append(expected.Versions, version)
+			}
+			if downloadURL == "" && expected.OSPackage == "" {
+				expected.OSPackage = renovateTagName(v.RenovateTag)
+			}
+		}
+		if len(expected.Versions) == 0 {
+			continue
+		}
+		if downloadURL == "" {
+			bom.Packages = append(bom.Packages, expected)
+			continue
+		}
+		expected.OSPackage = ""
+		for _, version := range expected.Versions {
+			url, err := expandVariables(downloadURL, version, t)
+			if err != nil {
+				return nil, fmt.Errorf("package %s: %w", p.Name, err)
+			}
+			expected.DownloadURLs = append(expected.DownloadURLs, url)
+			if file, ok := cachedFile(p.Name, expected.DownloadLocation, version, url, t); ok {
+				bom.Files = append(bom.Files, file)
+			}
+		}
+		bom.Packages = append(bom.Packages, expected)
+	}
+
+	for _, image := range c.ContainerImages {
+		references, err := image.references(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, reference := range references {
+			if !slices.Contains(bom.ContainerImages, reference) {
+				bom.ContainerImages = append(bom.ContainerImages, reference)
+			}
+		}
+	}
+
+	if t.IsWindows() {
+		for _, artifact := range c.OCIArtifacts {
+			if artifact.WindowsDownloadLocation == "" {
+				continue
+			}
+			for _, v := range artifact.WindowsVersions {
+				if !v.matchesSku(t.Release) {
+					continue
+				}
+				for _, version := range v.Versions() {
+					reference, err := expandVariables(strings.Replace(artifact.Registry, "*", version, 1), version, t)
+					if err != nil {
+						return nil, fmt.Errorf("OCI artifact %s: %w", artifact.Name, err)
+					}
+					bom.OCIArtifacts = append(bom.OCIArtifacts, reference)
+				}
+			}
+		}
+	}
+	return bom, nil
+}
+
+// release returns the entry of the package for the target, in the order getPackageJSON in cse_helpers.sh and
+// GetWindowsDownloadPartForPackage in components_json_helpers.ps1 look them up.
+func (p Package) release(t Target) (ReleaseDownloadURI, bool) {
+	lookup := func(distro string, releases ...string) (ReleaseDownloadURI, bool) {
+		for _, release := range releases {
+			if r, ok := p.DownloadURIs[distro][release]; ok {
+				return r, true
+			}
+		}
+		return ReleaseDownloadURI{}, false
+	}
+
+	if t.IsWindows() {
+		if _, ok := p.DownloadURIs["windows"]; !ok {
+			return lookup("default", "current")
+		}
+		return lookup("windows", windowsRelease(t.Release), "default")
+	}
+	variant := t.Variant
+	if variant == "" {
+		variant = "DEFAULT"
+	}
+	if r, ok := lookup(t.Distro, variant+"/"+t.Release, t.Release, variant+"/current", "current"); ok {
+		return r, true
+	}
+	return lookup("default", "current")
+}
+
+// windowsRelease returns the release of the windows downloadURIs of a Windows SKU.
+func windowsRelease(sku string) string {
+	switch {
+	case strings.HasPrefix(sku, "2019-containerd"):
+		return "ws2019"
+	case strings.HasPrefix(sku, "2022-containerd"):
+		return "ws2022"
+	case strings.HasPrefix(sku, "23H2"):
+		return "ws23h2"
+	case strings.HasPrefix(sku, "2025"):
+		return "ws2025"
+	default:
+		return "default"
+	}
+}
+
+// references returns the references of the image versions cached on the target. Windows uses the windowsVersions
+// matching the SKU when the image has some, the multi-arch versions otherwise. The amd64-only versions are only
+// cached on amd64 Linux.
+func (i ContainerImage) references(t Target) ([]string, error) {
+	downloadURL := i.DownloadURL
+	var versions []string
+	if t.IsWindows() {
+		if i.WindowsDownloadURL != "" {
+			downloadURL = i.WindowsDownloadURL
+		}
+		if i.WindowsVersions != nil {
+			for _, v := range i.WindowsVersions {
+				if v.matchesSku(t.Release) {
+					versions = append(versions, v.Versions()...)
+				}
+			}
+		} else {
+			for _, v := range i.MultiArchVersionsV2 {
+				versions = append(versions, v.Versions()...)
+			}
+		}
+	} else {
+		if t.Arch == "amd64" {
+			versions = append(versions, i.Amd64OnlyVersions...)
+		}
+		for _, v := range i.MultiArchVersionsV2 {
+			versions = append(versions, v.Versions()...)
+		}
+	}
+
+	var references []string
+	for _, version := range versions {
+		reference, err := expandVariables(strings.Replace(downloadURL, "*", version, 1), version, t)
+		if err != nil {
+			return nil, fmt.Errorf("container image %s: %w", i.DownloadURL, err)
+		}
+		references = append(references, reference)
+	}
+	return references, nil
+}
+
+// cachedFile returns the file a package version downloaded from url is cached as. Packages without a download
+// location, installed with a custom step, or pulled from a registry rather than downloaded aren't cached as a file.
+func cachedFile(pkg, downloadLocation, version, url string, t Target) (File, bool) {
+	if downloadLocation == "" || slices.Contains(customInstalledPackages, pkg) || !strings.HasPrefix(url, "https://") {
+		return File{}, false
+	}
+	// /opt/bin packages are installed in the PATH rather than cached.
+	if downloadLocation == "/opt/bin" {
+		return File{}, false
+	}
+	name := path.Base(url)
+	var filePath string
+	if t.IsWindows() {
+		filePath = strings.TrimSuffix(downloadLocation, `\`) + `\` + name
+	} else {
+		filePath = path.Join(downloadLocation, name)
+	}
+	return File{Package: pkg, Version: version, Path: filePath, URL: url}, true
+}
+
+// expandVariables substitutes the shell and PowerShell variables components.json URLs and references use, the way
+// evalPackageDownloadURL and SafeReplaceString do on the node. It fails on variables it doesn't know.
+func expandVariables(s, version string, t Target) (string, error) {
+	systemdArch, machine := "x86-64", "x86_64"
+	if t.Arch == "arm64" {
+		systemdArch, machine = "arm64", "aarch64"
+	}
+	majorMinorPatch, _, _ := strings.Cut(version, "-")
+	expanded := strings.NewReplacer(
+		"${version}", version,
+		"$($version.Split('-')[0])", majorMinorPatch,
+		"${CPU_ARCH}", t.Arch,
+		"${SYSTEMD_ARCH}", systemdArch,
+		"$(uname -m)", machine,
+	).Replace(s)
+	if strings.Contains(expanded, "$") {
+		return "", fmt.Errorf("unknown variable in %q", s)
+	}
+	return expanded, nil
+}
+
+// renovateTagName returns the name field of a renovate tag, such as moby-containerd in
+// "name=moby-containerd, repository=production, os=ubuntu, release=24.04".
+func renovateTagName(tag string) string {
+	for _, field := range strings.Split(tag, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if ok && key == "name" {
+			return value
+		}
+	}
+	return ""
+}
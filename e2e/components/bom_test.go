@@ -0,0 +1,323 @@
+package components
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/stretchr/testify/require"
+)
+
+const fixture = `{
+  "ContainerImages": [
+    {
+      "downloadURL": "mcr.microsoft.com/oss/kubernetes/pause:*",
+      "amd64OnlyVersions": ["3.6"],
+      "multiArchVersionsV2": [{"latestVersion": "3.10", "previousLatestVersion": "3.9"}],
+      "windowsVersions": [{"latestVersion": "3.10-win", "windowsSkuMatch": "2022*"}]
+    },
+    {
+      "downloadURL": "mcr.microsoft.com/windows/servercore:*",
+      "amd64OnlyVersions": [],
+      "multiArchVersionsV2": [],
+      "windowsVersions": [{"latestVersion": "ltsc2022", "windowsSkuMatch": "2022*"}, {"latestVersion": "ltsc2025", "windowsSkuMatch": "2025*"}]
+    }
+  ],
+  "Packages": [
+    {
+      "name": "containerd",
+      "downloadLocation": "/opt/containerd/downloads",
+      "downloadURIs": {
+        "ubuntu": {"r2404": {"versionsV2": [{"renovateTag": "name=moby-containerd, os=ubuntu", "latestVersion": "2.0.0-ubuntu24.04u1"}]}},
+        "azurelinux": {
+          "v3.0": {"versionsV2": [{"renovateTag": "RPM_registry=https://example, name=containerd2, os=azurelinux", "latestVersion": "2.0.0-1.azl3"}]},
+          "OSGUARD/v3.0": {"versionsV2": [{"latestVersion": "<SKIP>"}]}
+        }
+      }
+    },
+    {
+      "name": "kubelet",
+      "downloadLocation": "/opt/kubelet/downloads",
+      "downloadURIs": {
+        "ubuntu": {"r2404": {"versionsV2": [{"renovateTag": "name=kubelet", "latestVersion": "1.34.10-ubuntu24.04u1", "previousLatestVersion": "1.34.1-ubuntu24.04u1"}]}}
+      }
+    },
+    {
+      "name": "azure-cni",
+      "downloadLocation": "/opt/cni/downloads",
+      "windowsDownloadLocation": "c:\\akse-cache\\win-vnet-cni\\",
+      "downloadURIs": {
+        "default": {"current": {"versionsV2": [{"latestVersion": "1.6.0", "previousLatestVersion": "1.5.0"}], "downloadURL": "https://packages.aks.azure.com/azure-cni/v${version}/binaries/azure-vnet-cni-linux-${CPU_ARCH}-v${version}.tgz"}},
+        "windows": {"ws2022": {"versionsV2": [{"latestVersion": "1.6.0"}], "downloadURL": "https://packages.aks.azure.com/azure-cni/v${version}/binaries/azure-vnet-cni-windows-amd64-v${version}.zip"}}
+      }
+    }
+  ],
+  "OCIArtifacts": [
+    {"name": "wcn", "registry": "mcr.microsoft.com/wcn/package:${version}-${CPU_ARCH}", "windowsDownloadLocation": "c:\\akse-cache\\wcn\\", "windowsVersions": [{"latestVersion": "1.7.1", "windowsSkuMatch": "2025*"}]}
+  ]
+}`
+
+func loadFixture(t *testing.T) *Components {
+	t.Helper()
+	c, err := Parse([]byte(fixture))
+	require.NoError(t, err)
+	return c
+}
+
+func TestParseValidatesSchema(t *testing.T) {
+	_, err := Parse([]byte(`{"ContainerImages": [], "Packages": [{"name": "kubelet", "downloadURIs": {"ubuntu": {"r1804": {"versionsV2": []}}}}]}`))
+	require.ErrorContains(t, err, "r1804")
+	_, err = Parse([]byte(`{"ContainerImages": [{"downloadURL": "mcr.microsoft.com/pause:*", "amd64OnlyVersions": [], "multiArchVersionsV2": [{}]}], "Packages": []}`))
+	require.ErrorContains(t, err, "latestVersion")
+	_, err = Parse([]byte(`{"ContainerImages": [], "Packages": [], "Extra": []}`))
+	require.ErrorContains(t, err, "Extra")
+}
+
+func TestTargetFromOSRelease(t *testing.T) {
+	for _, c := range []struct {
+		osRelease, machine string
+		expected           Target
+	}{
+		{"ID=ubuntu\nVERSION_ID=\"24.04\"\n", "x86_64", Target{Distro: "ubuntu", Release: "r2404", Arch: "amd64"}},
+		{"ID=azurelinux\nVERSION_ID=\"3.0\"\n", "aarch64", Target{Distro: "azurelinux", Release: "v3.0", Arch: "arm64"}},
+		{"ID=azurelinux\nVERSION_ID=\"3.0\"\nVARIANT_ID=osguard\n", "x86_64", Target{Distro: "azurelinux", Release: "v3.0", Variant: "OSGUARD", Arch: "amd64"}},
+		{"ID=azurelinux\nVERSION_ID=\"3.0\"\nVARIANT_ID=azurecontainerlinux\n", "x86_64", Target{Distro: "flatcar", Release: "current", Arch: "amd64"}},
+		{"ID=mariner\nVERSION_ID=\"2.0\"\n", "x86_64", Target{Distro: "mariner", Release: "current", Arch: "amd64"}},
+	} {
+		target, err := TargetFromOSRelease(c.osRelease, c.machine)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, target)
+	}
+	_, err := TargetFromOSRelease("ID=debian\nVERSION_ID=12\n", "x86_64")
+	require.ErrorContains(t, err, `unknown distro "debian"`)
+}
+
+func TestExpected(t *testing.T) {
+	c := loadFixture(t)
+
+	bom, err := c.Expected(Target{Distro: "ubuntu", Release: "r2404", Arch: "amd64"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"mcr.microsoft.com/oss/kubernetes/pause:3.6",
+		"mcr.microsoft.com/oss/kubernetes/pause:3.10",
+		"mcr.microsoft.com/oss/kubernetes/pause:3.9",
+	}, bom.ContainerImages)
+	require.Equal(t, []ExpectedPackage{
+		{Name: "containerd", Versions: []string{"2.0.0-ubuntu24.04u1"}, DownloadLocation: "/opt/containerd/downloads", OSPackage: "moby-containerd"},
+		{Name: "kubelet", Versions: []string{"1.34.10-ubuntu24.04u1", "1.34.1-ubuntu24.04u1"}, DownloadLocation: "/opt/kubelet/downloads", OSPackage: "kubelet"},
+		{Name: "azure-cni", Versions: []string{"1.6.0", "1.5.0"}, DownloadLocation: "/opt/cni/downloads", DownloadURLs: []string{
+			"https://packages.aks.azure.com/azure-cni/v1.6.0/binaries/azure-vnet-cni-linux-amd64-v1.6.0.tgz",
+			"https://packages.aks.azure.com/azure-cni/v1.5.0/binaries/azure-vnet-cni-linux-amd64-v1.5.0.tgz",
+		}},
+	}, bom.Packages)
+	require.Equal(t, "/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.6.0.tgz", bom.Files[0].Path)
+	require.Empty(t, bom.OCIArtifacts)
+
+	// arm64 doesn't cache the amd64-only versions.
+	bom, err = c.Expected(Target{Distro: "ubuntu", Release: "r2404", Arch: "arm64"})
+	require.NoError(t, err)
+	require.NotContains(t, bom.ContainerImages, "mcr.microsoft.com/oss/kubernetes/pause:3.6")
+	require.Equal(t, "/opt/cni/downloads/azure-vnet-cni-linux-arm64-v1.6.0.tgz", bom.Files[0].Path)
+
+	// OSGuard skips containerd, and falls back to the default entries.
+	bom, err = c.Expected(Target{Distro: "azurelinux", Release: "v3.0", Variant: "OSGUARD", Arch: "amd64"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"azure-cni"}, packageNames(bom))
+
+	bom, err = c.Expected(Target{Distro: "azurelinux", Release: "v3.0", Arch: "amd64"})
+	require.NoError(t, err)
+	require.Equal(t, "containerd2", bom.Packages[0].OSPackage)
+
+	bom, err = c.Expected(Target{Distro: "windows", Release: "2025-gen2", Arch: "amd64"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"mcr.microsoft.com/windows/servercore:ltsc2025"}, bom.ContainerImages)
+	require.Equal(t, []string{"mcr.microsoft.com/wcn/package:1.7.1-amd64"}, bom.OCIArtifacts)
+	// azure-cni has no Windows 2025 nor default Windows entry.
+	require.Empty(t, bom.Packages)
+
+	bom, err = c.Expected(Target{Distro: "windows", Release: "2022-containerd-gen2", Arch: "amd64"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"mcr.microsoft.com/oss/kubernetes/pause:3.10-win", "mcr.microsoft.com/windows/servercore:ltsc2022"}, bom.ContainerImages)
+	require.Equal(t, []string{"https://packages.aks.azure.com/azure-cni/v1.6.0/binaries/azure-vnet-cni-windows-amd64-v1.6.0.zip"}, bom.Packages[0].DownloadURLs)
+	require.Equal(t, `c:\akse-cache\win-vnet-cni\azure-vnet-cni-windows-amd64-v1.6.0.zip`, bom.Files[0].Path)
+
+	c.Packages[2].DownloadURIs["default"]["current"] = ReleaseDownloadURI{
+		VersionsV2:  []VersionV2{{LatestVersion: "1.0.0"}},
+		DownloadURL: "https://example.com/${OS_VERSION}/azure-cni.tgz",
+	}
+	_, err = c.Expected(Target{Distro: "ubuntu", Release: "r2404", Arch: "amd64"})
+	require.ErrorContains(t, err, "package azure-cni: unknown variable")
+}
+
+func packageNames(bom *BOM) []string {
+	var names []string
+	for _, p := range bom.Packages {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// TestExpectedComponentsJSON computes the bill of materials of every VHD from the components.json of the repository,
+// which fails on URLs with variables the node scripts would expand but Expected doesn't know.
+func TestExpectedComponentsJSON(t *testing.T) {
+	c, err := Load(DefaultPath())
+	require.NoError(t, err)
+	for _, target := range []Target{
+		{Distro: "ubuntu", Release: "r2004", Arch: "amd64"},
+		{Distro: "ubuntu", Release: "r2204", Arch: "amd64"},
+		{Distro: "ubuntu", Release: "r2404", Arch: "arm64"},
+		{Distro: "ubuntu", Release: "r2604", Arch: "amd64"},
+		{Distro: "mariner", Release: "current", Arch: "amd64"},
+		{Distro: "marinerkata", Release: "current", Arch: "amd64"},
+		{Distro: "azurelinux", Release: "v3.0", Arch: "amd64"},
+		{Distro: "azurelinux", Release: "v3.0", Arch: "arm64"},
+		{Distro: "azurelinux", Release: "v3.0", Variant: "OSGUARD", Arch: "amd64"},
+		{Distro: "azurelinuxkata", Release: "v3.0", Arch: "amd64"},
+		{Distro: "flatcar", Release: "current", Arch: "amd64"},
+		{Distro: "windows", Release: "2019-containerd", Arch: "amd64"},
+		{Distro: "windows", Release: "2022-containerd-gen2", Arch: "amd64"},
+		{Distro: "windows", Release: "23H2-gen2", Arch: "amd64"},
+		{Distro: "windows", Release: "2025-gen2", Arch: "amd64"},
+	} {
+		t.Run(target.String(), func(t *testing.T) {
+			bom, err := c.Expected(target)
+			require.NoError(t, err)
+			require.NotEmpty(t, bom.Packages)
+			require.NotEmpty(t, bom.ContainerImages)
+			for _, image := range bom.ContainerImages {
+				require.NotContains(t, image, "*")
+			}
+		})
+	}
+
+	bom, err := c.Expected(Target{Distro: "ubuntu", Release: "r2204", Arch: "amd64"})
+	require.NoError(t, err)
+	for _, p := range bom.Packages {
+		if p.Name == "containerd" {
+			require.Equal(t, GetExpectedPackageVersions("containerd", "ubuntu", "r2204"), p.Versions)
+			require.Equal(t, "moby-containerd", p.OSPackage)
+		}
+	}
+	seen := map[string]bool{}
+	for _, image := range bom.ContainerImages {
+		require.False(t, seen[image], "%s is listed twice", image)
+		seen[image] = true
+	}
+}
+
+func TestDiff(t *testing.T) {
+	bom, err := loadFixture(t).Expected(Target{Distro: "ubuntu", Release: "r2404", Arch: "amd64"})
+	require.NoError(t, err)
+
+	complete := &Inventory{
+		Images: []string{
+			"mcr.microsoft.com/oss/kubernetes/pause:3.6",
+			"mcr.microsoft.com/oss/kubernetes/pause:3.10",
+			"mcr.microsoft.com/oss/kubernetes/pause:3.9",
+			"mcr.microsoft.com/oss/kubernetes/pause@sha256:0123",
+			"mcr.microsoft.com/oss/kubernetes/coredns:v1.12.0",
+		},
+		Files: []string{
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.6.0.tgz",
+			// Extracted archives are cached as a directory.
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.5.0",
+			"/opt/cni/downloads/cni-plugins-linux-amd64-v1.4.0.tgz",
+			"/opt/kubelet/downloads/kubelet_1.34.10-ubuntu24.04u1_amd64.deb",
+		},
+		Packages: map[string]string{"moby-containerd": "2.0.0-ubuntu24.04u1", "kubelet": "1:1.34.1-ubuntu24.04u1"},
+	}
+	require.True(t, Diff(bom, complete).OK(), Diff(bom, complete).Error())
+
+	drifted := &Inventory{
+		Images: []string{
+			"mcr.microsoft.com/oss/kubernetes/pause:3.6",
+			"mcr.microsoft.com/oss/kubernetes/pause:3.10",
+			"mcr.microsoft.com/oss/kubernetes/pause:3.8",
+		},
+		Files: []string{
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.6.0.tgz",
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.5.0.tgz",
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.4.0.tgz",
+			"/opt/kubelet/downloads/kubelet_1.34.10-ubuntu24.04u1_amd64.deb",
+			"/opt/kubelet/downloads/kubelet_1.34.1-ubuntu24.04u1_amd64.deb",
+		},
+		Packages: map[string]string{"moby-containerd": "1.7.0-ubuntu24.04u1"},
+	}
+	report := Diff(bom, drifted)
+	require.Equal(t, []Finding{
+		{Kind: FindingVersionMismatch, Type: ComponentImage, Component: "mcr.microsoft.com/oss/kubernetes/pause", Expected: []string{"3.9"}, Actual: []string{"3.8"}},
+		{Kind: FindingExtra, Type: ComponentFile, Component: "azure-cni", Actual: []string{"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.4.0.tgz"}},
+		{Kind: FindingVersionMismatch, Type: ComponentPackage, Component: "containerd", Expected: []string{"2.0.0-ubuntu24.04u1"}, Actual: []string{"1.7.0-ubuntu24.04u1"}},
+	}, report.Findings)
+	require.ErrorContains(t, report.Error(), "3 differences between components.json and the ubuntu/r2404 amd64 node")
+	require.ErrorContains(t, report.Error(), "image mcr.microsoft.com/oss/kubernetes/pause: expected 3.9, found 3.8")
+
+	report = Diff(bom, &Inventory{})
+	require.Equal(t, []Finding{
+		{Kind: FindingMissing, Type: ComponentImage, Component: "mcr.microsoft.com/oss/kubernetes/pause", Expected: []string{"3.10", "3.6", "3.9"}},
+		{Kind: FindingMissing, Type: ComponentFile, Component: "azure-cni", Expected: []string{
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.5.0.tgz",
+			"/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.6.0.tgz",
+		}},
+		{Kind: FindingMissing, Type: ComponentPackage, Component: "containerd", Expected: []string{"2.0.0-ubuntu24.04u1"}},
+		{Kind: FindingMissing, Type: ComponentPackage, Component: "kubelet", Expected: []string{"1.34.1-ubuntu24.04u1", "1.34.10-ubuntu24.04u1"}},
+	}, report.Findings)
+
+	filtered := report.Filter(func(f Finding) bool { return f.Type == ComponentPackage })
+	require.Len(t, filtered.Findings, 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, filtered.WriteText(&buf))
+	require.Contains(t, buf.String(), "missing  image  mcr.microsoft.com/oss/kubernetes/pause  3.10,3.6,3.9")
+}
+
+func TestDiffPackageEpoch(t *testing.T) {
+	bom := &BOM{Packages: []ExpectedPackage{{
+		Name:             "datacenter-gpu-manager-4-core",
+		Versions:         []string{"1:4.5.3-1", "1:4.4.0-1"},
+		DownloadLocation: "/opt/datacenter-gpu-manager-4-core/downloads",
+		OSPackage:        "datacenter-gpu-manager-4-core",
+	}}}
+	report := Diff(bom, &Inventory{
+		Files: []string{
+			// deb file names don't have the epoch.
+			"/opt/datacenter-gpu-manager-4-core/downloads/datacenter-gpu-manager-4-core_4.4.0-1_amd64.deb",
+			"/opt/datacenter-gpu-manager-4-core/downloads/datacenter-gpu-manager-4-core_4.3.0-1_amd64.deb",
+		},
+		Packages: map[string]string{"datacenter-gpu-manager-4-core": "1:4.5.3-1"},
+	})
+	require.Equal(t, []Finding{
+		{Kind: FindingExtra, Type: ComponentPackage, Component: "datacenter-gpu-manager-4-core", Actual: []string{
+			"/opt/datacenter-gpu-manager-4-core/downloads/datacenter-gpu-manager-4-core_4.3.0-1_amd64.deb",
+		}},
+	}, report.Findings)
+}
+
+func TestDiffNode(t *testing.T) {
+	node := nodeexec.Func(func(_ context.Context, command string) (*nodeexec.Result, error) {
+		var stdout string
+		switch {
+		case command == "cat /etc/os-release":
+			stdout = "ID=ubuntu\nVERSION_ID=\"24.04\"\n"
+		case command == "uname -m":
+			stdout = "x86_64\n"
+		case strings.Contains(command, "ctr --namespace k8s.io images list"):
+			stdout = "mcr.microsoft.com/oss/kubernetes/pause:3.6\nmcr.microsoft.com/oss/kubernetes/pause:3.10\nmcr.microsoft.com/oss/kubernetes/pause:3.9\n"
+		case strings.Contains(command, "find /opt/cni/downloads /opt/containerd/downloads /opt/kubelet/downloads"):
+			stdout = "/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.6.0.tgz\n/opt/cni/downloads/azure-vnet-cni-linux-amd64-v1.5.0.tgz\n"
+		case strings.Contains(command, "dpkg-query"):
+			stdout = "moby-containerd 2.0.0-ubuntu24.04u1\nkubelet 1.34.10-ubuntu24.04u1\n"
+		default:
+			return &nodeexec.Result{Command: command, ExitCode: 127, Stderr: "unexpected command"}, nil
+		}
+		return &nodeexec.Result{Command: command, Stdout: stdout}, nil
+	})
+
+	report, err := loadFixture(t).DiffNode(context.Background(), node, "")
+	require.NoError(t, err)
+	require.Equal(t, []Finding{
+		{Kind: FindingMissing, Type: ComponentPackage, Component: "kubelet", Expected: []string{"1.34.1-ubuntu24.04u1"}},
+	}, report.Findings)
+}
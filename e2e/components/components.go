@@ -1,15 +1,10 @@
 package components
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/Azure/agentbaker/e2e/config"
 	"github.com/Azure/agentbaker/e2e/toolkit"
-	"github.com/tidwall/gjson"
 )
 
 func GetKubeletVersionByMinorVersion(minorVersion string) string {
@@ -29,33 +24,17 @@ func GetKubeletVersionByMinorVersion(minorVersion string) string {
 
 func GetExpectedPackageVersions(packageName, distro, release string) []string {
 	var expectedVersions []string
-	// since we control this json, we assume its going to be properly formatted here
-
-	// Get the project root dynamically
-	_, filename, _, _ := runtime.Caller(0)
-	projectRoot := filepath.Dir(filepath.Dir(filepath.Dir(filename))) // Go up 3 levels from e2e/components/
-	componentsPath := filepath.Join(projectRoot, "parts", "common", "components.json")
-
-	jsonBytes, _ := os.ReadFile(componentsPath)
-	packages := gjson.GetBytes(jsonBytes, fmt.Sprintf("Packages.#(name=%s).downloadURIs", packageName))
-
-	// If there is a dot in the "release" then we need to escape it for the json path
-	release = strings.ReplaceAll(release, ".", "\\.")
-
-	for _, packageItem := range packages.Array() {
-		// Check if versionsV2 exists. Assume the DEFAULT OS variant.
-		versions := packageItem.Get(fmt.Sprintf("%s.DEFAULT/%s.versionsV2", distro, release)).Array()
-		if len(versions) == 0 {
-			versions = packageItem.Get(fmt.Sprintf("%s.%s.versionsV2", distro, release)).Array()
+	for _, p := range mustLoadDefault().Packages {
+		if p.Name != packageName {
+			continue
 		}
-
-		for _, version := range versions {
-			// get versions.latestVersion and append to expectedVersions
-			expectedVersions = append(expectedVersions, version.Get("latestVersion").String())
-			// get versions.previousLatestVersion (if exists) and append to expectedVersions
-			if version.Get("previousLatestVersion").Exists() {
-				expectedVersions = append(expectedVersions, version.Get("previousLatestVersion").String())
-			}
+		// Assume the DEFAULT OS variant.
+		releaseURI, ok := p.DownloadURIs[distro]["DEFAULT/"+release]
+		if !ok {
+			releaseURI = p.DownloadURIs[distro][release]
+		}
+		for _, version := range releaseURI.VersionsV2 {
+			expectedVersions = append(expectedVersions, version.Versions()...)
 		}
 	}
 	return expectedVersions
@@ -67,52 +46,18 @@ func GetWindowsContainerImages(containerName string, windowsVersion string) []st
 	})
 }
 
-// TODO: expand this logic to support linux container images as well
 func getWindowsContainerImageTags(containerName string, windowsVersion string) []string {
 	var expectedVersions []string
-	// since we control this json, we assume its going to be properly formatted here
-
-	// Get the project root dynamically
-	_, filename, _, _ := runtime.Caller(0)
-	projectRoot := filepath.Dir(filepath.Dir(filepath.Dir(filename))) // Go up 3 levels from e2e/components/
-	componentsPath := filepath.Join(projectRoot, "parts", "common", "components.json")
-
-	jsonBytes, _ := os.ReadFile(componentsPath)
-
-	containerImages := gjson.GetBytes(jsonBytes, "ContainerImages") //fmt.Sprintf("ContainerImages", containerName))
-
-	for _, containerImage := range containerImages.Array() {
-		imageDownloadUrl := containerImage.Get("downloadURL").String()
-		if strings.EqualFold(imageDownloadUrl, containerName) {
-			packages := containerImage.Get("windowsVersions")
-			//t.Logf("got packages: %s", packages.String())
-
-			for _, packageItem := range packages.Array() {
-				// check if versionsV2 exists
-				if packageItem.Get("windowsSkuMatch").Exists() {
-					windowsSkuMatch := packageItem.Get("windowsSkuMatch").String()
-					matched, err := filepath.Match(windowsSkuMatch, windowsVersion)
-					if matched && err == nil {
-
-						// get versions.latestVersion and append to expectedVersions
-						expectedVersions = append(expectedVersions, packageItem.Get("latestVersion").String())
-						// get versions.previousLatestVersion (if exists) and append to expectedVersions
-						if packageItem.Get("previousLatestVersion").Exists() {
-							expectedVersions = append(expectedVersions, packageItem.Get("previousLatestVersion").String())
-						}
-					}
-				} else {
-					// get versions.latestVersion and append to expectedVersions
-					expectedVersions = append(expectedVersions, packageItem.Get("latestVersion").String())
-					// get versions.previousLatestVersion (if exists) and append to expectedVersions
-					if packageItem.Get("previousLatestVersion").Exists() {
-						expectedVersions = append(expectedVersions, packageItem.Get("previousLatestVersion").String())
-					}
-				}
+	for _, containerImage := range mustLoadDefault().ContainerImages {
+		if !strings.EqualFold(containerImage.DownloadURL, containerName) {
+			continue
+		}
+		for _, version := range containerImage.WindowsVersions {
+			if version.matchesSku(windowsVersion) {
+				expectedVersions = append(expectedVersions, version.Versions()...)
 			}
 		}
 	}
-
 	return expectedVersions
 }
 
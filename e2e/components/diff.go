@@ -0,0 +1,311 @@
+package components
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Inventory is what is cached on a node.
+type Inventory struct {
+	// Images are the references of the images in the k8s.io containerd namespace.
+	Images []string `json:"images"`
+	// Files are the entries of the download locations of the bill of materials.
+	Files []string `json:"files"`
+	// Packages are the versions of the installed debs or rpms, by name.
+	Packages map[string]string `json:"packages"`
+}
+
+// FindingKind is how the node differs from the bill of materials.
+type FindingKind string
+
+const (
+	// FindingMissing is a version which isn't on the node.
+	FindingMissing FindingKind = "missing"
+	// FindingExtra is a version on the node which isn't in the bill of materials.
+	FindingExtra FindingKind = "extra"
+	// FindingVersionMismatch is a component whose expected versions are missing while other versions are on the node.
+	FindingVersionMismatch FindingKind = "version-mismatch"
+)
+
+// ComponentType is the type of component a finding is about.
+type ComponentType string
+
+const (
+	ComponentImage   ComponentType = "image"
+	ComponentFile    ComponentType = "file"
+	ComponentPackage ComponentType = "package"
+)
+
+// Finding is a difference between a component of the bill of materials and the node.
+type Finding struct {
+	Kind FindingKind   `json:"kind"`
+	Type ComponentType `json:"type"`
+	// Component is the image repository, the package name, or the package name of the file.
+	Component string   `json:"component"`
+	Expected  []string `json:"expected,omitempty"`
+	Actual    []string `json:"actual,omitempty"`
+}
+
+func (f Finding) String() string {
+	switch f.Kind {
+	case FindingMissing:
+		return fmt.Sprintf("%s %s: missing %s", f.Type, f.Component, strings.Join(f.Expected, ", "))
+	case FindingExtra:
+		return fmt.Sprintf("%s %s: unexpected %s", f.Type, f.Component, strings.Join(f.Actual, ", "))
+	default:
+		return fmt.Sprintf("%s %s: expected %s, found %s", f.Type, f.Component, strings.Join(f.Expected, ", "), strings.Join(f.Actual, ", "))
+	}
+}
+
+// Report is the difference between a bill of materials and a node.
+type Report struct {
+	Target   Target    `json:"target"`
+	Findings []Finding `json:"findings"`
+}
+
+// OK returns whether the node has exactly the bill of materials.
+func (r *Report) OK() bool {
+	return len(r.Findings) == 0
+}
+
+// Filter returns the report without the findings for which ignore returns true.
+func (r *Report) Filter(ignore func(Finding) bool) *Report {
+	filtered := &Report{Target: r.Target}
+	for _, f := range r.Findings {
+		if !ignore(f) {
+			filtered.Findings = append(filtered.Findings, f)
+		}
+	}
+	return filtered
+}
+
+// Error returns the findings as an error, nil when there are none.
+func (r *Report) Error() error {
+	if r.OK() {
+		return nil
+	}
+	lines := make([]string, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		lines = append(lines, f.String())
+	}
+	return fmt.Errorf("%d differences between components.json and the %s node:\n%s", len(r.Findings), r.Target, strings.Join(lines, "\n"))
+}
+
+// WriteText writes the findings as a table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tTYPE\tCOMPONENT\tEXPECTED\tACTUAL")
+	for _, f := range r.Findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Kind, f.Type, f.Component, strings.Join(f.Expected, ","), strings.Join(f.Actual, ","))
+	}
+	fmt.Fprintf(tw, "\n%d differences between components.json and the %s node\n", len(r.Findings), r.Target)
+	return tw.Flush()
+}
+
+// Diff compares the node inventory with the bill of materials, component by component: the versions of a component
+// missing from the node are reported as missing, the versions on the node which aren't expected as extra, and both as
+// a version mismatch when a component has both. Only the components of the bill of materials are compared, images of
+// other repositories and files other packages cache in the download locations aren't reported.
+func Diff(bom *BOM, inventory *Inventory) *Report {
+	report := &Report{Target: bom.Target}
+	report.add(ComponentImage, diffImages(bom.ContainerImages, inventory.Images))
+	report.add(ComponentFile, diffFiles(bom.Files, inventory.Files))
+	report.add(ComponentPackage, diffPackages(bom.Packages, inventory))
+	return report
+}
+
+// difference is the missing and extra versions of a component.
+type difference struct {
+	missing, extra []string
+}
+
+func (r *Report) add(typ ComponentType, differences map[string]*difference) {
+	components := make([]string, 0, len(differences))
+	for component := range differences {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		d := differences[component]
+		sort.Strings(d.missing)
+		sort.Strings(d.extra)
+		switch {
+		case len(d.missing) > 0 && len(d.extra) > 0:
+			r.Findings = append(r.Findings, Finding{Kind: FindingVersionMismatch, Type: typ, Component: component, Expected: d.missing, Actual: d.extra})
+		case len(d.missing) > 0:
+			r.Findings = append(r.Findings, Finding{Kind: FindingMissing, Type: typ, Component: component, Expected: d.missing})
+		case len(d.extra) > 0:
+			r.Findings = append(r.Findings, Finding{Kind: FindingExtra, Type: typ, Component: component, Actual: d.extra})
+		}
+	}
+}
+
+func differenceOf(differences map[string]*difference, component string) *difference {
+	d, ok := differences[component]
+	if !ok {
+		d = &difference{}
+		differences[component] = d
+	}
+	return d
+}
+
+// splitImage splits an image reference into its repository and tag.
+func splitImage(reference string) (string, string) {
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:]
+	}
+	return reference, ""
+}
+
+func diffImages(expected, actual []string) map[string]*difference {
+	differences := map[string]*difference{}
+	repositories := map[string]bool{}
+	for _, reference := range expected {
+		repository, tag := splitImage(reference)
+		repositories[repository] = true
+		if !slices.Contains(actual, reference) {
+			d := differenceOf(differences, repository)
+			d.missing = append(d.missing, tag)
+		}
+	}
+	for _, reference := range actual {
+		repository, tag := splitImage(reference)
+		// Images are also listed by digest, only tags are compared.
+		if !repositories[repository] || strings.Contains(reference, "@") || slices.Contains(expected, reference) {
+			continue
+		}
+		d := differenceOf(differences, repository)
+		if !slices.Contains(d.extra, tag) {
+			d.extra = append(d.extra, tag)
+		}
+	}
+	return differences
+}
+
+// filePattern returns the glob matching the files of every version of a package, from the file of one version.
+func filePattern(f File) string {
+	return strings.ReplaceAll(path.Base(f.Path), f.Version, "*")
+}
+
+// extractedDir returns the directory an archive is extracted in, the archive path without its last extension as
+// testPackagesInstalled expects it.
+func extractedDir(filePath string) string {
+	return strings.TrimSuffix(filePath, path.Ext(filePath))
+}
+
+func diffFiles(expected []File, actual []string) map[string]*difference {
+	differences := map[string]*difference{}
+	for _, f := range expected {
+		if !slices.Contains(actual, f.Path) && !slices.Contains(actual, extractedDir(f.Path)) {
+			d := differenceOf(differences, f.Package)
+			d.missing = append(d.missing, f.Path)
+		}
+	}
+	for _, entry := range actual {
+		for _, f := range expected {
+			if path.Dir(entry) != path.Dir(f.Path) || !matchesFile(f, entry) {
+				continue
+			}
+			if !slices.ContainsFunc(expected, func(e File) bool { return entry == e.Path || entry == extractedDir(e.Path) }) {
+				d := differenceOf(differences, f.Package)
+				if !slices.Contains(d.extra, entry) {
+					d.extra = append(d.extra, entry)
+				}
+			}
+			break
+		}
+	}
+	return differences
+}
+
+// matchesFile returns whether the entry is the file, or its extracted directory, of any version of the package.
+func matchesFile(f File, entry string) bool {
+	pattern := filePattern(f)
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	for _, candidate := range []string{pattern, extractedDir(pattern)} {
+		if ok, _ := path.Match(candidate, path.Base(entry)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// diffPackages compares the packages installed with the package manager. A version is on the node when it is the
+// installed version, or when its deb or rpm is cached in the download location, as kubelet and kubectl are for every
+// Kubernetes version the VHD supports.
+func diffPackages(expected []ExpectedPackage, inventory *Inventory) map[string]*difference {
+	differences := map[string]*difference{}
+	for _, p := range expected {
+		if p.OSPackage == "" || len(p.DownloadURLs) > 0 {
+			continue
+		}
+		installed, isInstalled := inventory.Packages[p.OSPackage]
+		installed = stripEpoch(installed)
+		var cached []string
+		for _, entry := range inventory.Files {
+			if p.DownloadLocation != "" && path.Dir(entry) == p.DownloadLocation && isOSPackageFile(p.OSPackage, path.Base(entry)) {
+				cached = append(cached, path.Base(entry))
+			}
+		}
+		for _, version := range p.Versions {
+			bare := stripEpoch(version)
+			if installed == bare || slices.ContainsFunc(cached, func(name string) bool { return osPackageFileVersion(p.OSPackage, name, bare) }) {
+				continue
+			}
+			d := differenceOf(differences, p.Name)
+			d.missing = append(d.missing, version)
+		}
+		if isInstalled && !slices.ContainsFunc(p.Versions, func(version string) bool { return stripEpoch(version) == installed }) {
+			d := differenceOf(differences, p.Name)
+			d.extra = append(d.extra, installed)
+		}
+		for _, name := range cached {
+			if !slices.ContainsFunc(p.Versions, func(version string) bool { return osPackageFileVersion(p.OSPackage, name, stripEpoch(version)) }) {
+				d := differenceOf(differences, p.Name)
+				d.extra = append(d.extra, path.Join(p.DownloadLocation, name))
+			}
+		}
+	}
+	return differences
+}
+
+// stripEpoch strips the epoch of a deb or rpm version, which package file names and most components.json versions
+// don't have.
+func stripEpoch(version string) string {
+	if epoch, rest, ok := strings.Cut(version, ":"); ok && strings.Trim(epoch, "0123456789") == "" {
+		return rest
+	}
+	return version
+}
+
+// isOSPackageFile returns whether name is a deb, named <package>_<version>_<arch>.deb, or an rpm, named
+// <package>-<version>.<arch>.rpm, of the package.
+func isOSPackageFile(osPackage, name string) bool {
+	if !strings.HasSuffix(name, ".deb") && !strings.HasSuffix(name, ".rpm") {
+		return false
+	}
+	for _, prefix := range []string{osPackage + "_", osPackage + "-"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// osPackageFileVersion returns whether name is the deb or rpm of the version of the package.
+func osPackageFileVersion(osPackage, name, version string) bool {
+	for _, prefix := range []string{osPackage + "_" + version, osPackage + "-" + version} {
+		// The version is followed by the architecture, after a _ in debs and a . in rpms.
+		if rest, ok := strings.CutPrefix(name, prefix); ok && (strings.HasPrefix(rest, "_") || strings.HasPrefix(rest, ".")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+)
+
+// Components is components.json, as described by schemas/components.cue, which Parse validates it against.
+type Components struct {
+	ContainerImages    []ContainerImage    `json:"ContainerImages"`
+	Packages           []Package           `json:"Packages"`
+	GPUContainerImages []GPUContainerImage `json:"GPUContainerImages,omitempty"`
+	OCIArtifacts       []OCIArtifact       `json:"OCIArtifacts,omitempty"`
+}
+
+// ContainerImage is a container image cached on the VHDs. DownloadURL is the image reference with a * for the tag.
+type ContainerImage struct {
+	DownloadURL         string           `json:"downloadURL"`
+	WindowsDownloadURL  string           `json:"windowsDownloadURL,omitempty"`
+	Amd64OnlyVersions   []string         `json:"amd64OnlyVersions,omitempty"`
+	MultiArchVersionsV2 []VersionV2      `json:"multiArchVersionsV2,omitempty"`
+	WindowsVersions     []WindowsVersion `json:"windowsVersions,omitempty"`
+}
+
+// GPUContainerImage is a GPU driver image, only cached on the GPU VHDs.
+type GPUContainerImage struct {
+	DownloadURL string    `json:"downloadURL"`
+	GPUVersion  VersionV2 `json:"gpuVersion"`
+}
+
+// VersionV2 is a version of a package or image, with the previous version kept for the nodes which haven't upgraded.
+type VersionV2 struct {
+	K8sVersion            string `json:"k8sVersion,omitempty"`
+	RenovateTag           string `json:"renovateTag,omitempty"`
+	LatestVersion         string `json:"latestVersion"`
+	PreviousLatestVersion string `json:"previousLatestVersion,omitempty"`
+}
+
+// Versions returns the latest and, when set, the previous latest version.
+func (v VersionV2) Versions() []string {
+	if v.PreviousLatestVersion == "" {
+		return []string{v.LatestVersion}
+	}
+	return []string{v.LatestVersion, v.PreviousLatestVersion}
+}
+
+// WindowsVersion is a version of a Windows image or OCI artifact, cached on the Windows SKUs matching WindowsSkuMatch,
+// a filepath.Match pattern, or on every SKU when it is empty.
+type WindowsVersion struct {
+	Comment               string `json:"comment,omitempty"`
+	K8sVersion            string `json:"k8sVersion,omitempty"`
+	RenovateTag           string `json:"renovateTag,omitempty"`
+	LatestVersion         string `json:"latestVersion"`
+	PreviousLatestVersion string `json:"previousLatestVersion,omitempty"`
+	WindowsSkuMatch       string `json:"windowsSkuMatch,omitempty"`
+}
+
+// Versions returns the latest and, when set, the previous latest version.
+func (v WindowsVersion) Versions() []string {
+	return VersionV2{LatestVersion: v.LatestVersion, PreviousLatestVersion: v.PreviousLatestVersion}.Versions()
+}
+
+// matchesSku returns whether the version is cached on the Windows SKU.
+func (v WindowsVersion) matchesSku(sku string) bool {
+	if v.WindowsSkuMatch == "" {
+		return true
+	}
+	matched, err := filepath.Match(v.WindowsSkuMatch, sku)
+	return matched && err == nil
+}
+
+// Package is a package or binary cached on the VHDs. DownloadURIs maps a distro, such as ubuntu, to its releases,
+// such as r2404, "v3.0" or "OSGUARD/v3.0".
+type Package struct {
+	Name                    string                                   `json:"name"`
+	DownloadLocation        string                                   `json:"downloadLocation,omitempty"`
+	WindowsDownloadLocation string                                   `json:"windowsDownloadLocation,omitempty"`
+	DownloadURIs            map[string]map[string]ReleaseDownloadURI `json:"downloadURIs"`
+}
+
+// ReleaseDownloadURI are the versions of a package for a release. Packages without a DownloadURL are installed with
+// the package manager of the distro.
+type ReleaseDownloadURI struct {
+	VersionsV2         []VersionV2 `json:"versionsV2"`
+	DownloadURL        string      `json:"downloadURL,omitempty"`
+	WindowsDownloadURL string      `json:"windowsDownloadURL,omitempty"`
+}
+
+// OCIArtifact is an OCI artifact cached on the Windows VHDs. Registry is the artifact reference with a * for the tag.
+type OCIArtifact struct {
+	Name                    string           `json:"name"`
+	Registry                string           `json:"registry"`
+	WindowsDownloadLocation string           `json:"windowsDownloadLocation,omitempty"`
+	WindowsVersions         []WindowsVersion `json:"windowsVersions"`
+}
+
+// DefaultPath returns the path of parts/common/components.json in the repository.
+func DefaultPath() string {
+	return filepath.Join(projectRoot(), "parts", "common", "components.json")
+}
+
+// SchemaPath returns the path of schemas/components.cue in the repository.
+func SchemaPath() string {
+	return filepath.Join(projectRoot(), "schemas", "components.cue")
+}
+
+func projectRoot() string {
+	_, filename, _, _ := runtime.Caller(0)
+	return filepath.Dir(filepath.Dir(filepath.Dir(filename))) // Go up 3 levels from e2e/components/
+}
+
+var readSchema = sync.OnceValues(func() ([]byte, error) {
+	return os.ReadFile(SchemaPath())
+})
+
+// Load reads the components.json at path.
+func Load(path string) (*Components, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse validates components.json against schemas/components.cue, as `cue vet -c` does in CI, and parses it.
+func Parse(data []byte) (*Components, error) {
+	if err := validate(data); err != nil {
+		return nil, err
+	}
+	var c Components
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse components.json: %w", err)
+	}
+	return &c, nil
+}
+
+// validate checks that components.json is a concrete #Components of schemas/components.cue. Definitions are closed,
+// so fields the schema doesn't know are rejected.
+func validate(data []byte) error {
+	schemaData, err := readSchema()
+	if err != nil {
+		return fmt.Errorf("read components.json schema: %w", err)
+	}
+	ctx := cuecontext.New()
+	schema := ctx.CompileBytes(schemaData, cue.Filename(SchemaPath())).LookupPath(cue.ParsePath("#Components"))
+	if err := schema.Err(); err != nil {
+		return fmt.Errorf("compile components.json schema: %w", err)
+	}
+	value := ctx.CompileBytes(data, cue.Filename("components.json"))
+	if err := value.Err(); err != nil {
+		return fmt.Errorf("parse components.json: %w", err)
+	}
+	if err := schema.Unify(value).Validate(cue.Concrete(true)); err != nil {
+		return fmt.Errorf("components.json doesn't conform to %s: %s", filepath.Base(SchemaPath()), strings.TrimSpace(cueerrors.Details(err, nil)))
+	}
+	return nil
+}
+
+// mustLoadDefault returns the components.json of the repository, loaded once, which the helpers below assume is well
+// formed since we control it.
+var mustLoadDefault = sync.OnceValue(func() *Components {
+	c, err := Load(DefaultPath())
+	if err != nil {
+		panic(err)
+	}
+	return c
+})
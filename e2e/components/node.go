@@ -0,0 +1,109 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+)
+
+// NodeTarget returns the target of a Linux node from its os-release and architecture.
+func NodeTarget(ctx context.Context, node nodeexec.NodeExecutor) (Target, error) {
+	osRelease, err := run(ctx, node, "cat /etc/os-release")
+	if err != nil {
+		return Target{}, err
+	}
+	machine, err := run(ctx, node, "uname -m")
+	if err != nil {
+		return Target{}, err
+	}
+	return TargetFromOSRelease(osRelease, machine)
+}
+
+// CollectInventory lists the images, the entries of the download locations and the installed packages of a Linux node,
+// for Diff to compare with the bill of materials.
+func CollectInventory(ctx context.Context, node nodeexec.NodeExecutor, bom *BOM) (*Inventory, error) {
+	if bom.Target.IsWindows() {
+		return nil, fmt.Errorf("collecting the inventory of %s nodes isn't implemented", bom.Target.Distro)
+	}
+	inventory := &Inventory{Packages: map[string]string{}}
+
+	images, err := run(ctx, node, "sudo ctr --namespace k8s.io images list --quiet")
+	if err != nil {
+		return nil, err
+	}
+	inventory.Images = lines(images)
+
+	var locations []string
+	for _, p := range bom.Packages {
+		if p.DownloadLocation != "" && !slices.Contains(locations, p.DownloadLocation) {
+			locations = append(locations, p.DownloadLocation)
+		}
+	}
+	sort.Strings(locations)
+	if len(locations) > 0 {
+		// Download locations of packages which aren't cached on this VHD may not exist.
+		files, err := run(ctx, node, fmt.Sprintf("sudo find %s -mindepth 1 -maxdepth 1 2>/dev/null || true", strings.Join(locations, " ")))
+		if err != nil {
+			return nil, err
+		}
+		inventory.Files = lines(files)
+	}
+
+	packages, err := run(ctx, node, `if command -v dpkg-query >/dev/null; then dpkg-query -W -f='${Package} ${Version}\n'; `+
+		`elif command -v rpm >/dev/null; then rpm -qa --queryformat '%{NAME} %{VERSION}-%{RELEASE}\n'; fi`)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines(packages) {
+		if name, version, ok := strings.Cut(line, " "); ok {
+			inventory.Packages[name] = version
+		}
+	}
+	return inventory, nil
+}
+
+// DiffNode computes the bill of materials of a Linux node from its os-release and compares it with the node.
+// distro, when set, overrides the distro of the os-release, as kata VHDs need.
+func (c *Components) DiffNode(ctx context.Context, node nodeexec.NodeExecutor, distro string) (*Report, error) {
+	target, err := NodeTarget(ctx, node)
+	if err != nil {
+		return nil, fmt.Errorf("get node target: %w", err)
+	}
+	if distro != "" {
+		target.Distro = distro
+	}
+	bom, err := c.Expected(target)
+	if err != nil {
+		return nil, fmt.Errorf("compute bill of materials of %s: %w", target, err)
+	}
+	inventory, err := CollectInventory(ctx, node, bom)
+	if err != nil {
+		return nil, fmt.Errorf("collect node inventory: %w", err)
+	}
+	return Diff(bom, inventory), nil
+}
+
+func run(ctx context.Context, node nodeexec.NodeExecutor, command string) (string, error) {
+	result, err := node.Exec(ctx, command)
+	if err != nil {
+		return "", fmt.Errorf("execute command %q: %w", command, err)
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("command %q exited with %d: %s", command, result.ExitCode, result.Stderr)
+	}
+	return result.Stdout, nil
+}
+
+func lines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
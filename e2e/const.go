@@ -1,7 +1,12 @@
 package e2e
 
+import (
+	"github.com/Azure/agentbaker/e2e/errorclass"
+	"github.com/Azure/agentbaker/e2e/sweeper"
+)
+
 const (
-	buildIDTagKey    = "buildID"
+	buildIDTagKey    = sweeper.TagBuildID
 	defaultNamespace = "default"
 )
 
@@ -17,15 +22,9 @@ const (
 // extensions.
 const cseExtensionName = "vmssCSE"
 
-// cseExitCodeOutboundConnFail is the CSE exit code for ERR_OUTBOUND_CONN_FAIL
-// (see parts/linux/cloud-init/artifacts/cse_helpers.sh). It indicates the node's
-// outbound connectivity preflight check (curl to mcr.microsoft.com, optionally routed
-// through the e2e HTTP proxy) failed all retries and the script exited before kubelet
-// started. In the e2e environment this is a known low-rate transient infrastructure
-// flake rather than a product regression, so the harness retries node provisioning a
-// bounded number of times to reduce PR-gate noise. A genuine regression fails on every
-// attempt and still surfaces after the retry budget is exhausted.
-const cseExitCodeOutboundConnFail = "50"
+// cseExitCodeOutboundConnFail is the CSE exit code for ERR_OUTBOUND_CONN_FAIL, which the
+// cse-outbound-conn-fail rule of the error classifier retries by recreating the node.
+const cseExitCodeOutboundConnFail = errorclass.CSEExitCodeOutboundConnFail
 
 // test data used across multiple test cases
 const (
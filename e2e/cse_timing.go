@@ -1,105 +1,32 @@
 package e2e
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Azure/agentbaker/e2e/boottimeline"
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/csetiming"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 )
 
-const (
-	// provisionJSONPath is the path to the provision.json file with overall boot timing.
-	provisionJSONPath = "/var/log/azure/aks/provision.json"
-)
-
 // CSETaskTiming represents the timing of a single CSE task.
-type CSETaskTiming struct {
-	TaskName  string
-	StartTime time.Time
-	EndTime   time.Time
-	Duration  time.Duration
-	Message   string
-}
+type CSETaskTiming = csetiming.TaskTiming
 
 // CSEProvisionTiming represents the overall provisioning timing from provision.json.
-type CSEProvisionTiming struct {
-	ExitCode            string          `json:"ExitCode"`
-	ExecDuration        string          `json:"ExecDuration"`
-	KernelStartTime     string          `json:"KernelStartTime"`
-	CloudInitLocalStart string          `json:"CloudInitLocalStartTime"`
-	CloudInitStart      string          `json:"CloudInitStartTime"`
-	CloudFinalStart     string          `json:"CloudFinalStartTime"`
-	CSEStartTime        string          `json:"CSEStartTime"`
-	GuestAgentStartTime string          `json:"GuestAgentStartTime"`
-	SystemdSummary      string          `json:"SystemdSummary"`
-	BootDatapoints      json.RawMessage `json:"BootDatapoints"`
-}
+type CSEProvisionTiming = csetiming.ProvisionTiming
 
 // CSETimingReport holds all parsed timing data from a VM.
-type CSETimingReport struct {
-	Tasks     []CSETaskTiming
-	Provision *CSEProvisionTiming
-	taskIndex map[string]*CSETaskTiming
-}
-
-// GetTask returns the timing for a specific task, or nil if not found.
-func (r *CSETimingReport) GetTask(name string) *CSETaskTiming {
-	if r.taskIndex == nil {
-		r.taskIndex = make(map[string]*CSETaskTiming, len(r.Tasks))
-		for i := range r.Tasks {
-			r.taskIndex[r.Tasks[i].TaskName] = &r.Tasks[i]
-		}
-	}
-	return r.taskIndex[name]
-}
-
-// TotalCSEDuration returns the duration of the cse_start task if present.
-func (r *CSETimingReport) TotalCSEDuration() time.Duration {
-	if t := r.GetTask("AKS.CSE.cse_start"); t != nil {
-		return t.Duration
-	}
-	return 0
-}
-
-// LogReport logs all task timings to the test logger.
-func (r *CSETimingReport) LogReport(_ context.Context, t interface{ Logf(string, ...any) }) {
-	t.Logf("=== CSE Task Timing Report ===")
-	t.Logf("%-60s %12s %12s", "Task", "Duration", "Start→End")
-	t.Logf("%s", strings.Repeat("-", 90))
-
-	sorted := make([]CSETaskTiming, len(r.Tasks))
-	copy(sorted, r.Tasks)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].StartTime.Before(sorted[j].StartTime)
-	})
-
-	for _, task := range sorted {
-		t.Logf("%-60s %10.2fs   %s → %s",
-			task.TaskName,
-			task.Duration.Seconds(),
-			task.StartTime.Format("15:04:05.000"),
-			task.EndTime.Format("15:04:05.000"),
-		)
-	}
-
-	if total := r.TotalCSEDuration(); total > 0 {
-		t.Logf("%s", strings.Repeat("-", 90))
-		t.Logf("%-60s %10.2fs", "TOTAL (cse_start)", total.Seconds())
-	}
-
-	if r.Provision != nil {
-		t.Logf("\n=== Provision Summary ===")
-		t.Logf("ExitCode: %s, ExecDuration: %ss", r.Provision.ExitCode, r.Provision.ExecDuration)
-		t.Logf("KernelStart: %s, CSEStart: %s, GuestAgent: %s",
-			r.Provision.KernelStartTime, r.Provision.CSEStartTime, r.Provision.GuestAgentStartTime)
-	}
-}
+type CSETimingReport = csetiming.Report
 
 // ExtractCSETimings SSHes into the scenario VM and extracts all CSE task timings.
 // Returns an error if no tasks could be parsed, since an empty report would make
@@ -107,80 +34,44 @@ func (r *CSETimingReport) LogReport(_ context.Context, t interface{ Logf(string,
 func ExtractCSETimings(ctx context.Context, s *Scenario) (*CSETimingReport, error) {
 	report := &CSETimingReport{}
 
-	result, err := execScriptOnVm(ctx, s, s.Runtime.VM, "sudo cat /var/log/azure/cluster-provision.log")
+	result, err := execScriptOnVm(ctx, s, s.Runtime.VM, "sudo cat "+csetiming.ProvisionLogPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read cluster-provision.log: %w", err)
 	}
 
-	var parseErrors int
-	for _, line := range strings.Split(result.stdout, "\n") {
-		if !strings.Contains(line, " echo ") ||
-			!strings.Contains(line, `"TaskName"`) ||
-			!strings.Contains(line, "AKS.CSE.") {
-			continue
-		}
-
-		// Bash xtrace prints each word as a separately quoted shell argument:
-		// + echo '{' '"Timestamp":' '"2026-07-17' '02:22:57.206",' ...
-		// Removing those trace-only single quotes reconstructs the JSON fields.
-		normalized := strings.ReplaceAll(line, "'", "")
-		startTimestamp := extractXtraceJSONField(normalized, "Timestamp")
-		endTimestamp := extractXtraceJSONField(normalized, "OperationId")
-		taskName := extractXtraceJSONField(normalized, "TaskName")
-		if startTimestamp == "" || endTimestamp == "" || !strings.HasPrefix(taskName, "AKS.CSE.") {
-			parseErrors++
-			continue
-		}
-
-		startTime, err := parseCSETimestamp(startTimestamp)
-		if err != nil {
-			parseErrors++
-			s.T.Logf("WARNING: failed to parse CSE start timestamp for task %s: %v", taskName, err)
-			continue
-		}
-		endTime, err := parseCSETimestamp(endTimestamp)
-		if err != nil {
-			parseErrors++
-			s.T.Logf("WARNING: failed to parse CSE end timestamp for task %s: %v", taskName, err)
-			continue
-		}
-
-		report.Tasks = append(report.Tasks, CSETaskTiming{
-			TaskName:  taskName,
-			StartTime: startTime,
-			EndTime:   endTime,
-			Duration:  endTime.Sub(startTime),
-		})
+	tasks, parseErrors := csetiming.ParseProvisionLog(result.stdout)
+	for _, err := range parseErrors {
+		s.T.Logf("WARNING: %v", err)
 	}
-
-	if parseErrors > 0 {
-		s.T.Logf("WARNING: %d CSE timing lines in cluster-provision.log could not be parsed", parseErrors)
+	if len(parseErrors) > 0 {
+		s.T.Logf("WARNING: %d CSE timing lines in cluster-provision.log could not be parsed", len(parseErrors))
 	}
-	if len(report.Tasks) == 0 {
-		return report, fmt.Errorf("no CSE task timings were parsed from cluster-provision.log (%d parse errors)", parseErrors)
+	if len(tasks) == 0 {
+		return report, fmt.Errorf("no CSE task timings were parsed from cluster-provision.log (%d parse errors)", len(parseErrors))
 	}
+	report.Tasks = tasks
 
-	provResult, err := execScriptOnVm(ctx, s, s.Runtime.VM, fmt.Sprintf("sudo cat %s", provisionJSONPath))
+	provResult, err := execScriptOnVm(ctx, s, s.Runtime.VM, fmt.Sprintf("sudo cat %s", csetiming.ProvisionJSONPath))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", provisionJSONPath, err)
+		return nil, fmt.Errorf("failed to read %s: %w", csetiming.ProvisionJSONPath, err)
 	}
 
 	var prov CSEProvisionTiming
 	if err := json.Unmarshal([]byte(strings.TrimSpace(provResult.stdout)), &prov); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", provisionJSONPath, err)
+		return nil, fmt.Errorf("failed to parse %s: %w", csetiming.ProvisionJSONPath, err)
 	}
 	report.Provision = &prov
 
-	cseStart, err := parseProvisionTimestamp(prov.CSEStartTime)
+	cseStart, err := csetiming.ParseTimestamp(prov.CSEStartTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSEStartTime from %s: %w", provisionJSONPath, err)
+		return nil, fmt.Errorf("failed to parse CSEStartTime from %s: %w", csetiming.ProvisionJSONPath, err)
 	}
 	execDuration, err := time.ParseDuration(prov.ExecDuration + "s")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse ExecDuration %q from %s: %w", prov.ExecDuration, provisionJSONPath, err)
+		return nil, fmt.Errorf("failed to parse ExecDuration %q from %s: %w", prov.ExecDuration, csetiming.ProvisionJSONPath, err)
 	}
 	report.Tasks = append(report.Tasks, CSETaskTiming{
-		TaskName:  "AKS.CSE.cse_start",
+		TaskName:  csetiming.TotalTaskName,
 		StartTime: cseStart,
 		EndTime:   cseStart.Add(execDuration),
 		Duration:  execDuration,
@@ -189,50 +80,6 @@ func ExtractCSETimings(ctx context.Context, s *Scenario) (*CSETimingReport, erro
 	return report, nil
 }
 
-func extractXtraceJSONField(line, field string) string {
-	fieldStart := strings.Index(line, `"`+field+`":`)
-	if fieldStart == -1 {
-		return ""
-	}
-	valueStart := strings.Index(line[fieldStart+len(field)+3:], `"`)
-	if valueStart == -1 {
-		return ""
-	}
-	valueStart += fieldStart + len(field) + 4
-	valueEnd := strings.Index(line[valueStart:], `"`)
-	if valueEnd == -1 {
-		return ""
-	}
-	return line[valueStart : valueStart+valueEnd]
-}
-
-// parseCSETimestamp parses the timestamp format used by logs_to_events: "YYYY-MM-DD HH:MM:SS.mmm"
-func parseCSETimestamp(s string) (time.Time, error) {
-	layouts := []string{
-		"2006-01-02 15:04:05.000",
-		"2006-01-02 15:04:05",
-	}
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, s); err == nil {
-			return t, nil
-		}
-	}
-	return time.Time{}, fmt.Errorf("cannot parse CSE timestamp %q", s)
-}
-
-func parseProvisionTimestamp(s string) (time.Time, error) {
-	layouts := []string{
-		time.RFC3339Nano,
-		"Mon Jan _2 15:04:05 MST 2006",
-	}
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, s); err == nil {
-			return t, nil
-		}
-	}
-	return time.Time{}, fmt.Errorf("cannot parse provision timestamp %q", s)
-}
-
 // CSETimingThresholds defines maximum acceptable durations for CSE tasks.
 type CSETimingThresholds struct {
 	// TaskThresholds maps task name suffixes to maximum duration.
@@ -271,12 +118,19 @@ func ValidateCSETimings(ctx context.Context, s *Scenario, thresholds CSETimingTh
 		}
 	}
 
+	setCSETimingKey(s, report)
 	report.LogReport(ctx, s.T)
+	if err := writeCSETimingReport(s, report); err != nil {
+		s.T.Logf("WARNING: failed to save CSE timing report: %v", err)
+	}
+	if err := writeBootTimeline(s, report); err != nil {
+		s.T.Logf("WARNING: failed to save boot timeline: %v", err)
+	}
 
 	if len(report.Tasks) == 0 {
 		return report, errors.New("no CSE task timings were parsed; cannot validate performance thresholds")
 	}
-	if report.GetTask("AKS.CSE.cse_start") == nil {
+	if report.GetTask(csetiming.TotalTaskName) == nil {
 		return report, errors.New("AKS.CSE.cse_start task not found in timing report; cannot validate total CSE duration")
 	}
 
@@ -354,7 +208,7 @@ func ValidateCSETimings(ctx context.Context, s *Scenario, thresholds CSETimingTh
 			if matchedTasks[task.TaskName] {
 				continue
 			}
-			if task.TaskName == "AKS.CSE.cse_start" {
+			if task.TaskName == csetiming.TotalTaskName {
 				continue
 			}
 			if !strings.HasPrefix(task.TaskName, "AKS.CSE.") {
@@ -385,5 +239,82 @@ func ValidateCSETimings(ctx context.Context, s *Scenario, thresholds CSETimingTh
 		}
 	}
 
+	if config.Config.CSETimingBaselineFile != "" {
+		if err := compareCSETimingBaseline(s, tRunner, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	return report, errors.Join(errs...)
 }
+
+// setCSETimingKey records the distro, VM size and install path of the scenario on the report, they identify the
+// baseline the report is aggregated into and compared against.
+func setCSETimingKey(s *Scenario, report *CSETimingReport) {
+	if s.VHD != nil {
+		report.Distro = string(s.VHD.Distro)
+	}
+	if s.Runtime.VM == nil || s.Runtime.VM.VMSS == nil {
+		return
+	}
+	vmss := s.Runtime.VM.VMSS
+	if vmss.SKU != nil && vmss.SKU.Name != nil {
+		report.VMSize = *vmss.SKU.Name
+	}
+	if tag := vmss.Tags["SkipBinaryCleanup"]; tag != nil && *tag == "true" {
+		report.Variant = "full-install"
+	}
+}
+
+// writeCSETimingReport saves the report in the scenario logs, where the cse-timing tool collects reports from.
+func writeCSETimingReport(s *Scenario, report *CSETimingReport) error {
+	if err := os.MkdirAll(testDir(s.T), 0755); err != nil {
+		return err
+	}
+	return report.WriteFile(filepath.Join(testDir(s.T), csetiming.ReportFileName))
+}
+
+// writeBootTimeline saves the boot timeline of the report in the scenario logs, as a text waterfall and as Chrome
+// trace-event JSON.
+func writeBootTimeline(s *Scenario, report *CSETimingReport) error {
+	timeline := boottimeline.Build(boottimeline.Input{Provision: report.Provision, Tasks: report.Tasks})
+	var waterfall, trace bytes.Buffer
+	if err := timeline.WriteWaterfall(&waterfall, 80); err != nil {
+		return err
+	}
+	if err := timeline.WriteTrace(&trace); err != nil {
+		return err
+	}
+	if err := writeToFile(s.T, "boot-timeline.txt", waterfall.String()); err != nil {
+		return err
+	}
+	return writeToFile(s.T, "boot-timeline.json", trace.String())
+}
+
+// compareCSETimingBaseline compares the report against its baseline from CSE_TIMING_BASELINE_FILE, emitting one
+// subtest per regressed task.
+func compareCSETimingBaseline(s *Scenario, tRunner *testing.T, report *CSETimingReport) error {
+	baselines, err := csetiming.LoadBaselines(config.Config.CSETimingBaselineFile)
+	if err != nil {
+		return err
+	}
+	baseline := baselines.Find(report.Key())
+	if baseline == nil {
+		s.T.Logf("no CSE timing baseline for %s, skipping the regression check", report.Key())
+		return nil
+	}
+	comparisons := csetiming.Compare(baseline, []*CSETimingReport{report}, csetiming.CompareOptions{})
+	var errs []error
+	for _, c := range comparisons {
+		s.T.Logf("%s", c)
+	}
+	for _, c := range csetiming.Regressions(comparisons) {
+		c := c
+		checkErr := fmt.Errorf("CSE task %s regressed against the %s baseline: %s", c.TaskName, report.Key(), c)
+		errs = append(errs, checkErr)
+		tRunner.Run(fmt.Sprintf("Regression_%s", strings.TrimPrefix(c.TaskName, "AKS.CSE.")), func(t *testing.T) {
+			t.Error(checkErr)
+		})
+	}
+	return errors.Join(errs...)
+}
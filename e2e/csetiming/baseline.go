@@ -0,0 +1,205 @@
+package csetiming
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// BaselineVersion is the version of the baseline file format.
+const BaselineVersion = 1
+
+// Key identifies a baseline.
+type Key struct {
+	Distro  string `json:"distro"`
+	VMSize  string `json:"vmSize"`
+	Variant string `json:"variant,omitempty"`
+}
+
+func (k Key) String() string {
+	s := k.Distro + "/" + k.VMSize
+	if k.Variant != "" {
+		s += "/" + k.Variant
+	}
+	return s
+}
+
+// Stats summarizes the durations of a task, in seconds.
+type Stats struct {
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stdDev"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+// Baseline is the timing profile of the CSE tasks on a distro and VM size.
+type Baseline struct {
+	Key
+	// Reports is the number of reports aggregated, a task may have fewer samples when it doesn't run on every node.
+	Reports int              `json:"reports"`
+	Tasks   map[string]Stats `json:"tasks"`
+}
+
+// Baselines is the persisted set of baselines, sorted by key.
+type Baselines struct {
+	Version   int        `json:"version"`
+	Baselines []Baseline `json:"baselines"`
+}
+
+// Find returns the baseline with the key, or nil.
+func (b *Baselines) Find(key Key) *Baseline {
+	for i := range b.Baselines {
+		if b.Baselines[i].Key == key {
+			return &b.Baselines[i]
+		}
+	}
+	return nil
+}
+
+// Write writes the baselines as indented JSON.
+func (b *Baselines) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		return fmt.Errorf("write CSE timing baselines: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the baselines as indented JSON to a file.
+func (b *Baselines) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create CSE timing baselines: %w", err)
+	}
+	defer f.Close()
+	if err := b.Write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// LoadBaselines reads baselines written by Baselines.WriteFile.
+func LoadBaselines(path string) (*Baselines, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CSE timing baselines: %w", err)
+	}
+	var b Baselines
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse CSE timing baselines %s: %w", path, err)
+	}
+	if b.Version != BaselineVersion {
+		return nil, fmt.Errorf("CSE timing baselines %s have version %d, expected %d", path, b.Version, BaselineVersion)
+	}
+	return &b, nil
+}
+
+// Aggregate groups the reports by key and summarizes the durations of each task. A task running more than once in a
+// report, such as a retried download, contributes the sum of its durations.
+func Aggregate(reports []*Report) *Baselines {
+	type group struct {
+		reports int
+		samples map[string][]time.Duration
+	}
+	groups := map[Key]*group{}
+	for _, r := range reports {
+		g := groups[r.Key()]
+		if g == nil {
+			g = &group{samples: map[string][]time.Duration{}}
+			groups[r.Key()] = g
+		}
+		g.reports++
+		for name, d := range taskDurations(r) {
+			g.samples[name] = append(g.samples[name], d)
+		}
+	}
+
+	result := &Baselines{Version: BaselineVersion}
+	for key, g := range groups {
+		b := Baseline{Key: key, Reports: g.reports, Tasks: make(map[string]Stats, len(g.samples))}
+		for name, samples := range g.samples {
+			b.Tasks[name] = Summarize(samples)
+		}
+		result.Baselines = append(result.Baselines, b)
+	}
+	sort.Slice(result.Baselines, func(i, j int) bool {
+		return result.Baselines[i].Key.String() < result.Baselines[j].Key.String()
+	})
+	return result
+}
+
+// taskDurations returns the duration of each task of the report, summing repeated tasks.
+func taskDurations(r *Report) map[string]time.Duration {
+	durations := make(map[string]time.Duration, len(r.Tasks))
+	for _, t := range r.Tasks {
+		durations[t.TaskName] += t.Duration
+	}
+	return durations
+}
+
+// Summarize returns the statistics of the durations.
+func Summarize(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+	seconds := make([]float64, len(durations))
+	for i, d := range durations {
+		seconds[i] = d.Seconds()
+	}
+	sort.Float64s(seconds)
+	mean, stdDev := meanStdDev(seconds)
+	return Stats{
+		Samples: len(seconds),
+		Mean:    round(mean),
+		StdDev:  round(stdDev),
+		P50:     round(percentile(seconds, 50)),
+		P90:     round(percentile(seconds, 90)),
+		P95:     round(percentile(seconds, 95)),
+		P99:     round(percentile(seconds, 99)),
+		Max:     round(seconds[len(seconds)-1]),
+	}
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating linearly between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// meanStdDev returns the mean and the sample standard deviation of the values.
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+// round rounds to the millisecond, the precision of the CSE event timestamps, to keep baseline diffs readable.
+func round(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,230 @@
+package csetiming
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// CompareOptions tune when a slower task is a regression. A task is a regression when its slowdown is statistically
+// significant and large enough to matter.
+type CompareOptions struct {
+	// Alpha is the significance level of the one-sided test, 0.01 when zero.
+	Alpha float64
+	// MinIncrease is the smallest increase of the mean duration reported, 1s when zero.
+	MinIncrease time.Duration
+	// MinRelativeIncrease is the smallest increase of the mean duration reported relative to the baseline mean, 0.1
+	// when zero.
+	MinRelativeIncrease float64
+	// MinBaselineSamples is the number of baseline samples needed to test a task, 5 when zero.
+	MinBaselineSamples int
+}
+
+func (o CompareOptions) withDefaults() CompareOptions {
+	if o.Alpha == 0 {
+		o.Alpha = 0.01
+	}
+	if o.MinIncrease == 0 {
+		o.MinIncrease = time.Second
+	}
+	if o.MinRelativeIncrease == 0 {
+		o.MinRelativeIncrease = 0.1
+	}
+	if o.MinBaselineSamples == 0 {
+		o.MinBaselineSamples = 5
+	}
+	return o
+}
+
+// Verdict is the outcome of the comparison of a task.
+type Verdict string
+
+const (
+	VerdictOK Verdict = "ok"
+	// VerdictRegression is a significant and large enough slowdown.
+	VerdictRegression Verdict = "regression"
+	// VerdictNoBaseline is a task missing from the baseline, or with too few baseline samples to be tested.
+	VerdictNoBaseline Verdict = "no-baseline"
+)
+
+// TaskComparison is the comparison of the durations of a task against its baseline.
+type TaskComparison struct {
+	TaskName  string
+	Baseline  Stats
+	Candidate Stats
+	// PValue is the probability of a slowdown at least as large if the task wasn't slower, 1 when not tested.
+	PValue  float64
+	Verdict Verdict
+}
+
+// Increase returns the increase of the mean duration.
+func (c TaskComparison) Increase() time.Duration {
+	return time.Duration((c.Candidate.Mean - c.Baseline.Mean) * float64(time.Second))
+}
+
+func (c TaskComparison) String() string {
+	switch c.Verdict {
+	case VerdictNoBaseline:
+		return fmt.Sprintf("%s: mean %.3fs over %d samples, no baseline (%d baseline samples)",
+			c.TaskName, c.Candidate.Mean, c.Candidate.Samples, c.Baseline.Samples)
+	default:
+		return fmt.Sprintf("%s: mean %.3fs over %d samples, baseline mean %.3fs p95 %.3fs over %d samples, %+.3fs, p=%.4f: %s",
+			c.TaskName, c.Candidate.Mean, c.Candidate.Samples, c.Baseline.Mean, c.Baseline.P95, c.Baseline.Samples,
+			c.Increase().Seconds(), c.PValue, c.Verdict)
+	}
+}
+
+// Compare compares the task durations of the reports, which are expected to share the baseline key, against the
+// baseline. Comparisons are sorted by task name.
+//
+// With several reports, the task means are compared with a one-sided Welch t-test. With a single report, the duration
+// is tested against the prediction interval of the baseline, which tells whether a single run is an outlier of the
+// baseline distribution.
+func Compare(baseline *Baseline, reports []*Report, opts CompareOptions) []TaskComparison {
+	opts = opts.withDefaults()
+	samples := map[string][]time.Duration{}
+	for _, r := range reports {
+		for name, d := range taskDurations(r) {
+			samples[name] = append(samples[name], d)
+		}
+	}
+
+	comparisons := make([]TaskComparison, 0, len(samples))
+	for name, durations := range samples {
+		c := TaskComparison{TaskName: name, Candidate: Summarize(durations), PValue: 1, Verdict: VerdictNoBaseline}
+		if baseline != nil {
+			c.Baseline = baseline.Tasks[name]
+		}
+		if c.Baseline.Samples >= opts.MinBaselineSamples {
+			c.Verdict = VerdictOK
+			c.PValue = slowdownPValue(c.Baseline, c.Candidate)
+			increase := c.Candidate.Mean - c.Baseline.Mean
+			if c.PValue < opts.Alpha &&
+				increase >= opts.MinIncrease.Seconds() &&
+				increase >= opts.MinRelativeIncrease*c.Baseline.Mean {
+				c.Verdict = VerdictRegression
+			}
+		}
+		comparisons = append(comparisons, c)
+	}
+	sort.Slice(comparisons, func(i, j int) bool { return comparisons[i].TaskName < comparisons[j].TaskName })
+	return comparisons
+}
+
+// Regressions returns the comparisons with a regression verdict.
+func Regressions(comparisons []TaskComparison) []TaskComparison {
+	var regressions []TaskComparison
+	for _, c := range comparisons {
+		if c.Verdict == VerdictRegression {
+			regressions = append(regressions, c)
+		}
+	}
+	return regressions
+}
+
+// slowdownPValue returns the p-value of the one-sided test that the candidate durations are longer than the baseline.
+func slowdownPValue(baseline, candidate Stats) float64 {
+	diff := candidate.Mean - baseline.Mean
+	var standardError, degreesOfFreedom float64
+	if candidate.Samples < 2 {
+		// prediction interval of a single new observation.
+		standardError = baseline.StdDev * math.Sqrt(1+1/float64(baseline.Samples))
+		degreesOfFreedom = float64(baseline.Samples - 1)
+	} else {
+		// Welch's t-test, with the Welch–Satterthwaite degrees of freedom.
+		vb := baseline.StdDev * baseline.StdDev / float64(baseline.Samples)
+		vc := candidate.StdDev * candidate.StdDev / float64(candidate.Samples)
+		standardError = math.Sqrt(vb + vc)
+		if vb+vc > 0 {
+			degreesOfFreedom = (vb + vc) * (vb + vc) /
+				(vb*vb/float64(baseline.Samples-1) + vc*vc/float64(candidate.Samples-1))
+		}
+	}
+	if standardError == 0 || degreesOfFreedom == 0 {
+		// no variance, any slowdown is significant.
+		if diff > 0 {
+			return 0
+		}
+		return 1
+	}
+	return 1 - studentTCDF(diff/standardError, degreesOfFreedom)
+}
+
+// studentTCDF returns the cumulative distribution function of the Student t distribution.
+func studentTCDF(t, degreesOfFreedom float64) float64 {
+	x := degreesOfFreedom / (degreesOfFreedom + t*t)
+	tail := 0.5 * regularizedIncompleteBeta(x, degreesOfFreedom/2, 0.5)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), evaluated with its continued fraction.
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges quickly for x < (a+1)/(a+b+2), use the symmetry relation otherwise.
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function with the modified Lentz
+// method.
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		// even step
+		numerator := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// odd step
+		numerator = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
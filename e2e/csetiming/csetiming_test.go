@@ -0,0 +1,240 @@
+package csetiming
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func report(key Key, durations map[string]time.Duration) *Report {
+	r := &Report{Distro: key.Distro, VMSize: key.VMSize, Variant: key.Variant}
+	for name, d := range durations {
+		r.Tasks = append(r.Tasks, TaskTiming{TaskName: name, Duration: d})
+	}
+	return r
+}
+
+func seconds(values ...float64) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v * float64(time.Second))
+	}
+	return durations
+}
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize(seconds(4, 1, 3, 2, 5))
+	require.Equal(t, Stats{Samples: 5, Mean: 3, StdDev: 1.581, P50: 3, P90: 4.6, P95: 4.8, P99: 4.96, Max: 5}, stats)
+
+	require.Equal(t, Stats{Samples: 1, Mean: 2, P50: 2, P90: 2, P95: 2, P99: 2, Max: 2}, Summarize(seconds(2)))
+	require.Equal(t, Stats{}, Summarize(nil))
+}
+
+func TestAggregate(t *testing.T) {
+	ubuntu := Key{Distro: "ubuntu2204", VMSize: "Standard_D2ds_v5"}
+	full := Key{Distro: "ubuntu2204", VMSize: "Standard_D2ds_v5", Variant: "full-install"}
+	retried := report(ubuntu, map[string]time.Duration{TotalTaskName: 30 * time.Second})
+	retried.Tasks = append(retried.Tasks,
+		TaskTiming{TaskName: "AKS.CSE.download", Duration: 2 * time.Second},
+		TaskTiming{TaskName: "AKS.CSE.download", Duration: 3 * time.Second},
+	)
+	reports := []*Report{
+		report(full, map[string]time.Duration{TotalTaskName: 90 * time.Second}),
+		retried,
+		report(ubuntu, map[string]time.Duration{TotalTaskName: 40 * time.Second, "AKS.CSE.download": 3 * time.Second}),
+		report(ubuntu, map[string]time.Duration{TotalTaskName: 50 * time.Second}),
+	}
+
+	baselines := Aggregate(reports)
+	require.Equal(t, BaselineVersion, baselines.Version)
+	require.Len(t, baselines.Baselines, 2)
+	require.Equal(t, ubuntu, baselines.Baselines[0].Key)
+	require.Equal(t, full, baselines.Baselines[1].Key)
+
+	b := baselines.Find(ubuntu)
+	require.NotNil(t, b)
+	require.Equal(t, 3, b.Reports)
+	require.Equal(t, 3, b.Tasks[TotalTaskName].Samples)
+	require.Equal(t, 40.0, b.Tasks[TotalTaskName].Mean)
+	require.Equal(t, 2, b.Tasks["AKS.CSE.download"].Samples)
+	require.Equal(t, 4.0, b.Tasks["AKS.CSE.download"].Mean)
+	require.Nil(t, baselines.Find(Key{Distro: "azurelinuxv3"}))
+}
+
+func TestBaselinesFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	key := Key{Distro: "ubuntu2404", VMSize: "Standard_D2ds_v5"}
+	r := report(key, map[string]time.Duration{TotalTaskName: 42 * time.Second})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Test_Scenario"), 0755))
+	require.NoError(t, r.WriteFile(filepath.Join(dir, "Test_Scenario", ReportFileName)))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Test_Scenario", "other.json"), []byte("{"), 0600))
+
+	reports, err := LoadReports([]string{dir})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, key, reports[0].Key())
+	require.Equal(t, 42*time.Second, reports[0].TotalCSEDuration())
+
+	path := filepath.Join(dir, "baselines.json")
+	require.NoError(t, Aggregate(reports).WriteFile(path))
+	loaded, err := LoadBaselines(path)
+	require.NoError(t, err)
+	require.Equal(t, Aggregate(reports), loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2}`), 0600))
+	_, err = LoadBaselines(path)
+	require.ErrorContains(t, err, "version 2")
+}
+
+func TestCompare(t *testing.T) {
+	key := Key{Distro: "ubuntu2204", VMSize: "Standard_D2ds_v5"}
+	var history []*Report
+	for _, total := range []float64{40, 42, 38, 41, 39, 40, 43, 37} {
+		history = append(history, report(key, map[string]time.Duration{
+			TotalTaskName:       seconds(total)[0],
+			"AKS.CSE.download":  seconds(total / 10)[0],
+			"AKS.CSE.configure": 2 * time.Second,
+		}))
+	}
+	baseline := Aggregate(history).Find(key)
+
+	t.Run("noise is not a regression", func(t *testing.T) {
+		comparisons := Compare(baseline, []*Report{report(key, map[string]time.Duration{
+			TotalTaskName:       44 * time.Second,
+			"AKS.CSE.download":  4 * time.Second,
+			"AKS.CSE.configure": 2 * time.Second,
+		})}, CompareOptions{})
+		require.Len(t, comparisons, 3)
+		require.Empty(t, Regressions(comparisons))
+	})
+
+	t.Run("a single slow run is a regression", func(t *testing.T) {
+		comparisons := Compare(baseline, []*Report{report(key, map[string]time.Duration{
+			TotalTaskName:      70 * time.Second,
+			"AKS.CSE.download": 4 * time.Second,
+			"AKS.CSE.new":      time.Second,
+		})}, CompareOptions{})
+		regressions := Regressions(comparisons)
+		require.Len(t, regressions, 1)
+		require.Equal(t, TotalTaskName, regressions[0].TaskName)
+		require.Equal(t, 30*time.Second, regressions[0].Increase())
+		require.Less(t, regressions[0].PValue, 0.001)
+		require.Equal(t, "AKS.CSE.new", comparisons[2].TaskName)
+		require.Equal(t, VerdictNoBaseline, comparisons[2].Verdict)
+	})
+
+	t.Run("a significant increase below the floors is not a regression", func(t *testing.T) {
+		comparisons := Compare(baseline, []*Report{report(key, map[string]time.Duration{
+			"AKS.CSE.configure": 2500 * time.Millisecond,
+		})}, CompareOptions{})
+		require.Len(t, comparisons, 1)
+		require.Zero(t, comparisons[0].PValue)
+		require.Equal(t, VerdictOK, comparisons[0].Verdict)
+
+		comparisons = Compare(baseline, []*Report{report(key, map[string]time.Duration{
+			"AKS.CSE.configure": 2500 * time.Millisecond,
+		})}, CompareOptions{MinIncrease: 100 * time.Millisecond})
+		require.Equal(t, VerdictRegression, comparisons[0].Verdict)
+	})
+
+	t.Run("several runs are compared with a t-test", func(t *testing.T) {
+		var candidates []*Report
+		for _, total := range []float64{44, 46, 45, 47} {
+			candidates = append(candidates, report(key, map[string]time.Duration{TotalTaskName: seconds(total)[0]}))
+		}
+		comparisons := Compare(baseline, candidates, CompareOptions{})
+		require.Len(t, comparisons, 1)
+		require.Equal(t, 4, comparisons[0].Candidate.Samples)
+		require.Equal(t, VerdictRegression, comparisons[0].Verdict)
+	})
+
+	t.Run("too few baseline samples", func(t *testing.T) {
+		comparisons := Compare(baseline, []*Report{report(key, map[string]time.Duration{
+			TotalTaskName: 70 * time.Second,
+		})}, CompareOptions{MinBaselineSamples: 10})
+		require.Equal(t, VerdictNoBaseline, comparisons[0].Verdict)
+		require.Empty(t, Regressions(comparisons))
+	})
+}
+
+func TestStudentTCDF(t *testing.T) {
+	for _, tc := range []struct {
+		t, df, want float64
+	}{
+		{t: 0, df: 5, want: 0.5},
+		{t: 2, df: 10, want: 0.963306},
+		{t: -2, df: 10, want: 0.036694},
+		{t: 1, df: 1, want: 0.75},
+		{t: 2.998, df: 7, want: 0.99},
+		{t: 1.96, df: 10000, want: 0.975},
+	} {
+		require.InDelta(t, tc.want, studentTCDF(tc.t, tc.df), 1e-4, "t=%v df=%v", tc.t, tc.df)
+	}
+	require.False(t, math.IsNaN(studentTCDF(100, 3)))
+}
+
+func TestParseProvisionLog(t *testing.T) {
+	log := `+ echo '{' '"Timestamp":' '"2026-07-17' '02:22:57.206",' '"OperationId":' '"2026-07-17' '02:23:01.706",' '"Version":' '"1.23",' '"TaskName":' '"AKS.CSE.installContainerRuntime",' '"EventLevel":' '"Informational"' '}'
++ echo '{' '"Timestamp":' '"2026-07-17' '02:22:50.000",' '"OperationId":' '"2026-07-17' '02:22:51.000",' '"TaskName":' '"AKS.CSE.configureAdminUser"' '}'
++ echo '{' '"Timestamp":' '"yesterday",' '"OperationId":' '"2026-07-17' '02:22:51.000",' '"TaskName":' '"AKS.CSE.broken"' '}'
++ echo '{' '"TaskName":' '"AKS.CSE.incomplete"' '}'
++ echo hello`
+	tasks, errs := ParseProvisionLog(log)
+	require.Len(t, errs, 2)
+	require.ErrorContains(t, errs[0], "AKS.CSE.broken")
+	require.Len(t, tasks, 2)
+	require.Equal(t, "AKS.CSE.configureAdminUser", tasks[0].TaskName)
+	require.Equal(t, "AKS.CSE.installContainerRuntime", tasks[1].TaskName)
+	require.Equal(t, 4500*time.Millisecond, tasks[1].Duration)
+	require.Equal(t, time.Date(2026, 7, 17, 2, 22, 57, 206000000, time.UTC), tasks[1].StartTime)
+}
+
+func TestLoadEvents(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1784254977206.json": `{"Timestamp":"2026-07-17 02:22:57.206","OperationId":"2026-07-17 02:23:01.706","Version":"1.23","TaskName":"AKS.CSE.installContainerRuntime","EventLevel":"Informational","Message":"Completed: installContainerRuntime","EventPid":"0","EventTid":"0"}`,
+		"1784254970000.json": `{"Timestamp":"2026-07-17 02:22:50.000","OperationId":"2026-07-17 02:23:30.000","TaskName":"AKS.CSE.cse_start","Message":"{\"ExitCode\":\"0\"}"}` + "\n",
+		"1784254990000.json": `{"Timestamp":"2026-07-17 02:23:10.000","TaskName":"AKS.Runtime.memory_telemetry","Message":"{}"}`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	tasks, err := LoadEvents(dir)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, TotalTaskName, tasks[0].TaskName)
+	require.Equal(t, 40*time.Second, tasks[0].Duration)
+	require.Equal(t, `{"ExitCode":"0"}`, tasks[0].Message)
+
+	logTasks, errs := ParseProvisionLog(`+ echo '{' '"Timestamp":' '"2026-07-17' '02:22:57.206",' '"OperationId":' '"2026-07-17' '02:23:01.706",' '"TaskName":' '"AKS.CSE.installContainerRuntime"' '}'`)
+	require.Empty(t, errs)
+	merged := MergeTasks(logTasks, tasks)
+	require.Len(t, merged, 2)
+	require.Equal(t, TotalTaskName, merged[0].TaskName)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"TaskName":"AKS.CSE.x","Timestamp":"now","OperationId":"later"}`), 0600))
+	_, err = LoadEvents(dir)
+	require.ErrorContains(t, err, "broken.json")
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2026, 7, 17, 2, 22, 57, 0, time.UTC)
+	for _, s := range []string{
+		"2026-07-17 02:22:57",
+		"2026-07-17 02:22:57.000",
+		"Fri Jul 17 02:22:57 UTC 2026",
+		"Fri 2026-07-17 02:22:57 UTC",
+		"2026-07-17T02:22:57Z",
+		" 2026-07-17 02:22:57\n",
+	} {
+		got, err := ParseTimestamp(s)
+		require.NoError(t, err, s)
+		require.True(t, want.Equal(got), "%s: %s", s, got)
+	}
+	_, err := ParseTimestamp("")
+	require.Error(t, err)
+}
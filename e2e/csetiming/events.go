@@ -0,0 +1,194 @@
+package csetiming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ProvisionLogPath is the log of the CSE execution, traced with bash xtrace.
+	ProvisionLogPath = "/var/log/azure/cluster-provision.log"
+	// ProvisionJSONPath is the summary of the node boot written at the end of the CSE.
+	ProvisionJSONPath = "/var/log/azure/aks/provision.json"
+	// EventsDir is where CSE tasks write their guest agent events. The guest agent removes the events it has sent.
+	EventsDir = "/var/log/azure/Microsoft.Azure.Extensions.CustomScript/events"
+
+	taskPrefix = "AKS.CSE."
+)
+
+// Event is a guest agent event written by logs_to_events. Timestamp and OperationID are the start and end of the task.
+type Event struct {
+	Timestamp   string `json:"Timestamp"`
+	OperationID string `json:"OperationId"`
+	TaskName    string `json:"TaskName"`
+	EventLevel  string `json:"EventLevel"`
+	Message     string `json:"Message"`
+}
+
+// Timing returns the timing of the task of the event.
+func (e Event) Timing() (TaskTiming, error) {
+	start, err := ParseTimestamp(e.Timestamp)
+	if err != nil {
+		return TaskTiming{}, fmt.Errorf("start of task %s: %w", e.TaskName, err)
+	}
+	end, err := ParseTimestamp(e.OperationID)
+	if err != nil {
+		return TaskTiming{}, fmt.Errorf("end of task %s: %w", e.TaskName, err)
+	}
+	return TaskTiming{TaskName: e.TaskName, StartTime: start, EndTime: end, Duration: end.Sub(start), Message: e.Message}, nil
+}
+
+// ParseEvents reads a stream of guest agent events and returns the timings of the CSE tasks. Events of other tasks
+// are ignored.
+func ParseEvents(r io.Reader) ([]TaskTiming, error) {
+	var tasks []TaskTiming
+	dec := json.NewDecoder(r)
+	for {
+		var e Event
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			return tasks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("parse guest agent events: %w", err)
+		}
+		if !strings.HasPrefix(e.TaskName, taskPrefix) {
+			continue
+		}
+		t, err := e.Timing()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+}
+
+// LoadEvents reads the CSE task timings of the guest agent event files of a directory, sorted by start time.
+func LoadEvents(dir string) ([]TaskTiming, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var tasks []TaskTiming
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fileTasks, err := ParseEvents(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		tasks = append(tasks, fileTasks...)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// ParseProvisionLog returns the CSE task timings traced in cluster-provision.log, sorted by start time, with an error
+// for each trace line of a task which could not be parsed.
+func ParseProvisionLog(log string) ([]TaskTiming, []error) {
+	var tasks []TaskTiming
+	var errs []error
+	for _, line := range strings.Split(log, "\n") {
+		if !strings.Contains(line, " echo ") ||
+			!strings.Contains(line, `"TaskName"`) ||
+			!strings.Contains(line, taskPrefix) {
+			continue
+		}
+
+		// Bash xtrace prints each word as a separately quoted shell argument:
+		// + echo '{' '"Timestamp":' '"2026-07-17' '02:22:57.206",' ...
+		// Removing those trace-only single quotes reconstructs the JSON fields.
+		normalized := strings.ReplaceAll(line, "'", "")
+		e := Event{
+			Timestamp:   extractXtraceJSONField(normalized, "Timestamp"),
+			OperationID: extractXtraceJSONField(normalized, "OperationId"),
+			TaskName:    extractXtraceJSONField(normalized, "TaskName"),
+		}
+		if e.Timestamp == "" || e.OperationID == "" || !strings.HasPrefix(e.TaskName, taskPrefix) {
+			errs = append(errs, fmt.Errorf("incomplete CSE task trace %q", line))
+			continue
+		}
+		t, err := e.Timing()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	sortTasks(tasks)
+	return tasks, errs
+}
+
+func extractXtraceJSONField(line, field string) string {
+	fieldStart := strings.Index(line, `"`+field+`":`)
+	if fieldStart == -1 {
+		return ""
+	}
+	valueStart := strings.Index(line[fieldStart+len(field)+3:], `"`)
+	if valueStart == -1 {
+		return ""
+	}
+	valueStart += fieldStart + len(field) + 4
+	valueEnd := strings.Index(line[valueStart:], `"`)
+	if valueEnd == -1 {
+		return ""
+	}
+	return line[valueStart : valueStart+valueEnd]
+}
+
+// MergeTasks merges task timings from several sources, such as the provision log and the guest agent events, dropping
+// the tasks found in more than one source. The result is sorted by start time.
+func MergeTasks(sources ...[]TaskTiming) []TaskTiming {
+	type key struct {
+		name       string
+		start, end time.Time
+	}
+	seen := map[key]bool{}
+	var tasks []TaskTiming
+	for _, source := range sources {
+		for _, t := range source {
+			k := key{t.TaskName, t.StartTime, t.EndTime}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			tasks = append(tasks, t)
+		}
+	}
+	sortTasks(tasks)
+	return tasks
+}
+
+func sortTasks(tasks []TaskTiming) {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].StartTime.Before(tasks[j].StartTime) })
+}
+
+// timestampLayouts are the layouts of the timestamps found in CSE events and provision.json: the logs_to_events
+// format, the output of date, systemd timestamps and RFC 3339.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"Mon Jan _2 15:04:05 MST 2006",
+	"Mon 2006-01-02 15:04:05 MST",
+	time.RFC3339Nano,
+}
+
+// ParseTimestamp parses a timestamp written by CSE. Timestamps without a time zone are in UTC, the time zone of AKS
+// nodes.
+func ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse CSE timestamp %q", s)
+}
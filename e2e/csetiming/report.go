@@ -0,0 +1,172 @@
+// Package csetiming holds the CSE task timings extracted from a node, aggregates many of them into per distro and VM
+// size baselines, and compares new timings against a baseline to find statistically significant regressions.
+package csetiming
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TotalTaskName is the task covering the whole CSE execution.
+	TotalTaskName = "AKS.CSE.cse_start"
+	// ReportFileName is the name of the reports saved in the e2e scenario logs.
+	ReportFileName = "cse-timing.json"
+)
+
+// TaskTiming represents the timing of a single CSE task.
+type TaskTiming struct {
+	TaskName  string
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	Message   string
+}
+
+// ProvisionTiming represents the overall provisioning timing from provision.json.
+type ProvisionTiming struct {
+	ExitCode            string          `json:"ExitCode"`
+	ExecDuration        string          `json:"ExecDuration"`
+	KernelStartTime     string          `json:"KernelStartTime"`
+	CloudInitLocalStart string          `json:"CloudInitLocalStartTime"`
+	CloudInitStart      string          `json:"CloudInitStartTime"`
+	CloudFinalStart     string          `json:"CloudFinalStartTime"`
+	NetworkdStartTime   string          `json:"NetworkdStartTime"`
+	CSEStartTime        string          `json:"CSEStartTime"`
+	GuestAgentStartTime string          `json:"GuestAgentStartTime"`
+	SystemdSummary      string          `json:"SystemdSummary"`
+	BootDatapoints      json.RawMessage `json:"BootDatapoints"`
+}
+
+// Report holds all parsed timing data from a VM.
+type Report struct {
+	// Distro, VMSize and Variant identify the baseline the report belongs to. Variant separates install paths with
+	// different timing profiles on the same distro and VM size, such as "full-install".
+	Distro    string `json:",omitempty"`
+	VMSize    string `json:",omitempty"`
+	Variant   string `json:",omitempty"`
+	Tasks     []TaskTiming
+	Provision *ProvisionTiming
+	taskIndex map[string]*TaskTiming
+}
+
+// GetTask returns the timing for a specific task, or nil if not found.
+func (r *Report) GetTask(name string) *TaskTiming {
+	if r.taskIndex == nil {
+		r.taskIndex = make(map[string]*TaskTiming, len(r.Tasks))
+		for i := range r.Tasks {
+			r.taskIndex[r.Tasks[i].TaskName] = &r.Tasks[i]
+		}
+	}
+	return r.taskIndex[name]
+}
+
+// TotalCSEDuration returns the duration of the cse_start task if present.
+func (r *Report) TotalCSEDuration() time.Duration {
+	if t := r.GetTask(TotalTaskName); t != nil {
+		return t.Duration
+	}
+	return 0
+}
+
+// Key returns the key of the baseline the report belongs to.
+func (r *Report) Key() Key {
+	return Key{Distro: r.Distro, VMSize: r.VMSize, Variant: r.Variant}
+}
+
+// LogReport logs all task timings to the test logger.
+func (r *Report) LogReport(_ context.Context, t interface{ Logf(string, ...any) }) {
+	t.Logf("=== CSE Task Timing Report ===")
+	t.Logf("%-60s %12s %12s", "Task", "Duration", "Start→End")
+	t.Logf("%s", strings.Repeat("-", 90))
+
+	sorted := make([]TaskTiming, len(r.Tasks))
+	copy(sorted, r.Tasks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	for _, task := range sorted {
+		t.Logf("%-60s %10.2fs   %s → %s",
+			task.TaskName,
+			task.Duration.Seconds(),
+			task.StartTime.Format("15:04:05.000"),
+			task.EndTime.Format("15:04:05.000"),
+		)
+	}
+
+	if total := r.TotalCSEDuration(); total > 0 {
+		t.Logf("%s", strings.Repeat("-", 90))
+		t.Logf("%-60s %10.2fs", "TOTAL (cse_start)", total.Seconds())
+	}
+
+	if r.Provision != nil {
+		t.Logf("\n=== Provision Summary ===")
+		t.Logf("ExitCode: %s, ExecDuration: %ss", r.Provision.ExitCode, r.Provision.ExecDuration)
+		t.Logf("KernelStart: %s, CSEStart: %s, GuestAgent: %s",
+			r.Provision.KernelStartTime, r.Provision.CSEStartTime, r.Provision.GuestAgentStartTime)
+	}
+}
+
+// WriteFile writes the report as indented JSON.
+func (r *Report) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal CSE timing report: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadReport reads a report written by Report.WriteFile.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CSE timing report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse CSE timing report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// LoadReports reads the reports at the paths. Directories are walked for report files named ReportFileName.
+func LoadReports(paths []string) ([]*Report, error) {
+	var reports []*Report
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			r, err := LoadReport(path)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, r)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || d.Name() != ReportFileName {
+				return err
+			}
+			r, err := LoadReport(p)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, r)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
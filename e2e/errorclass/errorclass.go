@@ -0,0 +1,220 @@
+// Package errorclass classifies the errors of e2e scenario phases with a declarative rule table, telling
+// infrastructure flakes and capacity issues from product bugs, and runs the retry and skip policy of the matching rule,
+// so flakes are retried or skipped the same way in every phase without masking genuine regressions.
+package errorclass
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// Class is the kind of a failure.
+type Class string
+
+const (
+	// InfraFlake is a transient failure of the e2e infrastructure or of Azure.
+	InfraFlake Class = "infra-flake"
+	// Capacity is a failure to get VM capacity or quota, which retrying later may solve.
+	Capacity Class = "capacity"
+	// ProductBug is a failure of the bootstrapped node.
+	ProductBug Class = "product-bug"
+	// Unclassified is a failure no rule matched, reported as a failure like product bugs.
+	Unclassified Class = "unclassified"
+)
+
+// Infrastructure reports whether failures of the class aren't caused by the product.
+func (c Class) Infrastructure() bool {
+	return c == InfraFlake || c == Capacity
+}
+
+// Action is what the policy does with a failure.
+type Action string
+
+const (
+	Fail  Action = "fail"
+	Retry Action = "retry"
+	// Skip skips the scenario, when SKIP_TESTS_WITH_SKU_CAPACITY_ISSUE is set.
+	Skip Action = "skip"
+)
+
+// Phases of a scenario, named as in the scenario record.
+const (
+	PhaseCluster    = "cluster"
+	PhaseVMSS       = "vmss"
+	PhaseCSE        = "cse"
+	PhaseNodeReady  = "node-ready"
+	PhaseSSH        = "ssh"
+	PhaseValidation = "validation"
+)
+
+// AnyCSEExitCode matches any non-zero CSE exit code.
+const AnyCSEExitCode = "*"
+
+// Failure is a failed phase to classify.
+type Failure struct {
+	Phase string
+	Err   error
+	// CSEExitCode is the exit code of the CSE, when known.
+	CSEExitCode string
+}
+
+// Rule classifies the failures it matches. Every matcher which is set must match: a rule with only Phases matches
+// every failure of these phases.
+type Rule struct {
+	Name   string
+	Phases []string
+	// StatusCode and ErrorCode match the Azure response error wrapped by the failure.
+	StatusCode int
+	ErrorCode  string
+	// CSEExitCode matches the CSE exit code of the failure, AnyCSEExitCode matches a non-zero one.
+	CSEExitCode string
+	// Message is a regular expression matched against the failure error message.
+	Message string
+
+	Class  Class
+	Action Action
+	// MaxAttempts bounds the attempts of the phase when Action is Retry, 0 retries until the context is done. Delay is
+	// the wait between attempts.
+	MaxAttempts int
+	Delay       time.Duration
+	// ExhaustedClass is the class of the failures still matching the rule once the attempts are exhausted, Class when
+	// empty.
+	ExhaustedClass Class
+
+	message *regexp.Regexp
+}
+
+func (r *Rule) matches(f Failure) bool {
+	if len(r.Phases) > 0 && !slices.Contains(r.Phases, f.Phase) {
+		return false
+	}
+	if r.StatusCode != 0 || r.ErrorCode != "" {
+		var respErr *azcore.ResponseError
+		if !errors.As(f.Err, &respErr) {
+			return false
+		}
+		if r.StatusCode != 0 && respErr.StatusCode != r.StatusCode {
+			return false
+		}
+		if r.ErrorCode != "" && respErr.ErrorCode != r.ErrorCode {
+			return false
+		}
+	}
+	switch r.CSEExitCode {
+	case "":
+	case AnyCSEExitCode:
+		if f.CSEExitCode == "" || f.CSEExitCode == "0" {
+			return false
+		}
+	default:
+		if f.CSEExitCode != r.CSEExitCode {
+			return false
+		}
+	}
+	return r.message == nil || r.message.MatchString(f.Err.Error())
+}
+
+// Classification is the outcome of classifying a failure.
+type Classification struct {
+	// Rule is the name of the matching rule, empty for unclassified failures.
+	Rule        string
+	Class       Class
+	Action      Action
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+// Table is an ordered list of rules, the first matching rule classifies a failure.
+type Table struct {
+	rules []Rule
+}
+
+// NewTable compiles rules into a table.
+func NewTable(rules ...Rule) (*Table, error) {
+	t := &Table{rules: make([]Rule, len(rules))}
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if r.Class == "" || r.Action == "" {
+			return nil, fmt.Errorf("rule %s has no class or action", r.Name)
+		}
+		if r.Action == Retry && (r.MaxAttempts < 0 || r.MaxAttempts == 1) {
+			return nil, fmt.Errorf("rule %s retries without allowing a second attempt", r.Name)
+		}
+		if r.ExhaustedClass != "" && (r.Action != Retry || r.MaxAttempts == 0) {
+			return nil, fmt.Errorf("rule %s has an exhausted class but its attempts are not bounded", r.Name)
+		}
+		if r.Message != "" {
+			message, err := regexp.Compile(r.Message)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+			r.message = message
+		}
+		t.rules[i] = r
+	}
+	return t, nil
+}
+
+// MustNewTable is NewTable panicking on invalid rules.
+func MustNewTable(rules ...Rule) *Table {
+	t, err := NewTable(rules...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Rules returns the rules of the table, in order.
+func (t *Table) Rules() []Rule {
+	return slices.Clone(t.rules)
+}
+
+// Classify returns the classification of the first rule matching f. Failures no rule matches are unclassified and
+// fail, permanent errors fail whatever their rule. Errors of exhausted attempts fail too, with the exhausted class of
+// their rule.
+func (t *Table) Classify(f Failure) Classification {
+	if f.Err == nil {
+		return Classification{}
+	}
+	c := Classification{Class: Unclassified, Action: Fail}
+	var rule *Rule
+	for i := range t.rules {
+		if r := &t.rules[i]; r.matches(f) {
+			rule = r
+			c = Classification{Rule: r.Name, Class: r.Class, Action: r.Action, MaxAttempts: r.MaxAttempts, Delay: r.Delay}
+			break
+		}
+	}
+	var permanent *permanentError
+	if errors.As(f.Err, &permanent) {
+		c.Action = Fail
+	}
+	var exhausted *exhaustedError
+	if errors.As(f.Err, &exhausted) {
+		c.Action = Fail
+		if rule != nil && rule.ExhaustedClass != "" {
+			c.Class = rule.ExhaustedClass
+		}
+	}
+	return c
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable, whatever the rule it matches.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
@@ -0,0 +1,208 @@
+package errorclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/require"
+)
+
+func responseError(statusCode int, errorCode, message string) error {
+	return fmt.Errorf("%s: %w", message, &azcore.ResponseError{StatusCode: statusCode, ErrorCode: errorCode})
+}
+
+func TestDefaultRules(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		failure Failure
+		rule    string
+		class   Class
+		action  Action
+	}{
+		{
+			name:    "cluster conflict",
+			failure: Failure{Phase: PhaseCluster, Err: responseError(http.StatusConflict, "Conflict", "")},
+			rule:    "cluster-conflict", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "cluster create in progress",
+			failure: Failure{Phase: PhaseCluster, Err: responseError(http.StatusConflict, "OperationNotAllowed", "in progress create managed cluster operation")},
+			rule:    "cluster-create-in-progress", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "cluster identity reconcile",
+			failure: Failure{Phase: PhaseCluster, Err: responseError(http.StatusBadRequest, "NotFound", "Reconcile managed identity credential failed")},
+			rule:    "cluster-identity-reconcile", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "cluster bad request",
+			failure: Failure{Phase: PhaseCluster, Err: responseError(http.StatusBadRequest, "InvalidParameter", "")},
+			class:   Unclassified, action: Fail,
+		},
+		{
+			name:    "sku not available",
+			failure: Failure{Phase: PhaseVMSS, Err: responseError(http.StatusConflict, "SkuNotAvailable", "")},
+			rule:    "sku-not-available", class: Capacity, action: Skip,
+		},
+		{
+			name:    "quota exceeded",
+			failure: Failure{Phase: PhaseCluster, Err: responseError(http.StatusConflict, "OperationNotAllowed", "quota exceeded: exceeding approved Total Regional Cores quota")},
+			rule:    "quota-exceeded", class: Capacity, action: Skip,
+		},
+		{
+			name:    "allocation failed",
+			failure: Failure{Phase: PhaseVMSS, Err: responseError(http.StatusOK, "AllocationFailed", "")},
+			rule:    "allocation-failed", class: Capacity, action: Retry,
+		},
+		{
+			name:    "allocation failed outside of the vmss phase",
+			failure: Failure{Phase: PhaseCSE, Err: responseError(http.StatusOK, "AllocationFailed", "")},
+			class:   Unclassified, action: Fail,
+		},
+		{
+			name:    "gallery image not found",
+			failure: Failure{Phase: PhaseVMSS, Err: responseError(http.StatusNotFound, "GalleryImageNotFound", "")},
+			rule:    "gallery-image-not-found", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "outbound connectivity",
+			failure: Failure{Phase: PhaseCSE, Err: errors.New("VMExtensionProvisioningError"), CSEExitCode: "50"},
+			rule:    "cse-outbound-conn-fail", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "cse failure",
+			failure: Failure{Phase: PhaseCSE, Err: errors.New("VMExtensionProvisioningError"), CSEExitCode: "124"},
+			rule:    "cse-failure", class: ProductBug, action: Fail,
+		},
+		{
+			name:    "cse failure without exit code",
+			failure: Failure{Phase: PhaseCSE, Err: errors.New("VMExtensionProvisioningError")},
+			class:   Unclassified, action: Fail,
+		},
+		{
+			name:    "rebooting node",
+			failure: Failure{Phase: PhaseSSH, Err: errors.New("SSH connection to 10.0.0.4 failed: Stderr: System is going down")},
+			rule:    "ssh-node-rebooting", class: InfraFlake, action: Retry,
+		},
+		{
+			name:    "ssh authentication",
+			failure: Failure{Phase: PhaseSSH, Err: errors.New("Permission denied (publickey)")},
+			class:   Unclassified, action: Fail,
+		},
+		{
+			name:    "validation",
+			failure: Failure{Phase: PhaseValidation, Err: errors.New("kubelet isn't running")},
+			rule:    "node-failure", class: ProductBug, action: Fail,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := Default.Classify(c.failure)
+			require.Equal(t, c.rule, got.Rule)
+			require.Equal(t, c.class, got.Class)
+			require.Equal(t, c.action, got.Action)
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	require.Equal(t, Classification{}, Default.Classify(Failure{Phase: PhaseVMSS}))
+
+	err := Permanent(responseError(http.StatusConflict, "Conflict", ""))
+	c := Default.Classify(Failure{Phase: PhaseCluster, Err: err})
+	require.Equal(t, "cluster-conflict", c.Rule)
+	require.Equal(t, Fail, c.Action)
+	require.NoError(t, Permanent(nil))
+
+	require.True(t, Capacity.Infrastructure())
+	require.False(t, ProductBug.Infrastructure())
+	require.False(t, Unclassified.Infrastructure())
+}
+
+func TestNewTable(t *testing.T) {
+	for _, c := range []struct {
+		rule    Rule
+		message string
+	}{
+		{Rule{Class: InfraFlake, Action: Fail}, "has no name"},
+		{Rule{Name: "r", Action: Fail}, "has no class or action"},
+		{Rule{Name: "r", Class: InfraFlake, Action: Retry, MaxAttempts: 1}, "without allowing a second attempt"},
+		{Rule{Name: "r", Class: InfraFlake, Action: Fail, Message: "["}, "rule r"},
+	} {
+		_, err := NewTable(c.rule)
+		require.ErrorContains(t, err, c.message)
+	}
+	require.NotEmpty(t, Default.Rules())
+}
+
+var flaky = MustNewTable(
+	Rule{Name: "flaky", Message: "flaky", Class: InfraFlake, Action: Retry, MaxAttempts: 3},
+	Rule{Name: "unbounded", Message: "unbounded", Class: InfraFlake, Action: Retry, Delay: time.Millisecond},
+)
+
+func TestRetrier(t *testing.T) {
+	var attempts, cleanups int
+	retrier := Retrier{Table: flaky, Phase: PhaseVMSS, BeforeRetry: func(context.Context, Attempt) { cleanups++ }}
+	result, err := retrier.Do(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Attempts)
+	require.Len(t, result.Retried, 2)
+	require.Equal(t, "flaky", result.Retried[0].Rule)
+	require.Equal(t, 2, cleanups)
+	require.False(t, result.Exhausted())
+
+	result, err = retrier.Do(context.Background(), func(context.Context) error { return errors.New("flaky") })
+	require.EqualError(t, err, "flaky")
+	require.Equal(t, 3, result.Attempts)
+	require.True(t, result.Exhausted())
+
+	result, err = retrier.Do(context.Background(), func(context.Context) error { return errors.New("regression") })
+	require.EqualError(t, err, "regression")
+	require.Equal(t, 1, result.Attempts)
+	require.Equal(t, Unclassified, result.Last.Class)
+	require.False(t, result.Exhausted())
+}
+
+func TestRetrierInspect(t *testing.T) {
+	table := MustNewTable(Rule{Name: "exit-50", CSEExitCode: "50", Class: InfraFlake, Action: Retry, MaxAttempts: 2})
+	retrier := Retrier{Table: table, Phase: PhaseCSE, Inspect: func(_ context.Context, f *Failure) { f.CSEExitCode = "50" }}
+	result, err := retrier.Do(context.Background(), func(context.Context) error { return errors.New("cse failed") })
+	require.Error(t, err)
+	require.Equal(t, 2, result.Attempts)
+	require.True(t, result.Exhausted())
+}
+
+func TestRetrierExhaustedClass(t *testing.T) {
+	retrier := Retrier{Phase: PhaseCSE, Inspect: func(_ context.Context, f *Failure) { f.CSEExitCode = CSEExitCodeOutboundConnFail }}
+	result, err := retrier.Do(context.Background(), func(context.Context) error { return errors.New("cse failed") })
+	require.EqualError(t, err, "cse failed")
+	require.True(t, result.Exhausted())
+
+	// the error of the exhausted attempts is reported as a product failure, and not retried by an enclosing phase.
+	c := Default.Classify(Failure{Phase: PhaseVMSS, Err: fmt.Errorf("create vmss: %w", err), CSEExitCode: CSEExitCodeOutboundConnFail})
+	require.Equal(t, Classification{Rule: "cse-outbound-conn-fail", Class: ProductBug, Action: Fail, MaxAttempts: 3}, c)
+	c = Default.Classify(Failure{Phase: PhaseVMSS, Err: errors.New("cse failed"), CSEExitCode: CSEExitCodeOutboundConnFail})
+	require.Equal(t, InfraFlake, c.Class)
+
+	_, err = NewTable(Rule{Name: "unbounded", Class: InfraFlake, Action: Retry, ExhaustedClass: ProductBug})
+	require.EqualError(t, err, "rule unbounded has an exhausted class but its attempts are not bounded")
+}
+
+func TestRetrierContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err := Retrier{Table: flaky}.Do(ctx, func(context.Context) error { return errors.New("unbounded") })
+	require.EqualError(t, err, "unbounded")
+	require.Greater(t, result.Attempts, 1)
+	require.False(t, result.Exhausted())
+}
@@ -0,0 +1,91 @@
+package errorclass
+
+import (
+	"context"
+	"time"
+)
+
+// Attempt is a failed attempt of a phase.
+type Attempt struct {
+	Classification
+	Err error
+}
+
+// Result is the outcome of the attempts of a phase.
+type Result struct {
+	Attempts int
+	// Retried are the failed attempts which were retried.
+	Retried []Attempt
+	// Last is the classification of the error of the last attempt, the zero value when it succeeded.
+	Last Classification
+}
+
+// Exhausted reports whether the last attempt failed with a retryable error, but no attempt was left.
+func (r Result) Exhausted() bool {
+	return r.Last.Action == Retry && r.Last.MaxAttempts > 0 && r.Attempts >= r.Last.MaxAttempts
+}
+
+// Retrier runs the attempts of a phase, retrying the failures its table classifies as retryable.
+type Retrier struct {
+	// Table classifies the failures, Default when nil.
+	Table *Table
+	Phase string
+	// Inspect adds details, such as the CSE exit code, to a failure before it is classified.
+	Inspect func(ctx context.Context, f *Failure)
+	// BeforeRetry cleans up after a failed attempt before it is retried.
+	BeforeRetry func(ctx context.Context, a Attempt)
+	// Logf logs the retries.
+	Logf func(format string, args ...any)
+}
+
+// Do runs attempt until it succeeds, fails with an error which isn't retryable, runs out of attempts or ctx is done.
+// It returns the error of the last attempt, which is classified with the exhausted class of its rule when no attempt
+// was left.
+func (r Retrier) Do(ctx context.Context, attempt func(ctx context.Context) error) (Result, error) {
+	table := r.Table
+	if table == nil {
+		table = Default
+	}
+	var result Result
+	for {
+		result.Attempts++
+		err := attempt(ctx)
+		if err == nil {
+			result.Last = Classification{}
+			return result, nil
+		}
+
+		failure := Failure{Phase: r.Phase, Err: err}
+		if r.Inspect != nil {
+			r.Inspect(ctx, &failure)
+		}
+		c := table.Classify(failure)
+		result.Last = c
+		if result.Exhausted() {
+			return result, &exhaustedError{err: err}
+		}
+		if c.Action != Retry {
+			return result, err
+		}
+
+		a := Attempt{Classification: c, Err: err}
+		result.Retried = append(result.Retried, a)
+		if r.Logf != nil {
+			r.Logf("%s attempt %d failed with %s error (rule %s), retrying in %s: %v", r.Phase, result.Attempts, c.Class, c.Rule, c.Delay, err)
+		}
+		if r.BeforeRetry != nil {
+			r.BeforeRetry(ctx, a)
+		}
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(c.Delay):
+		}
+	}
+}
+
+// exhaustedError is the error of the last attempt of a phase which ran out of attempts.
+type exhaustedError struct{ err error }
+
+func (e *exhaustedError) Error() string { return e.err.Error() }
+func (e *exhaustedError) Unwrap() error { return e.err }
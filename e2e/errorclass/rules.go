@@ -0,0 +1,127 @@
+package errorclass
+
+import (
+	"net/http"
+	"time"
+)
+
+// CSEExitCodeOutboundConnFail is the CSE exit code for ERR_OUTBOUND_CONN_FAIL (see
+// parts/linux/cloud-init/artifacts/cse_helpers.sh). The outbound connectivity preflight check of the node failed all
+// its retries and the script exited before kubelet started, a known low-rate flake of the e2e network.
+const CSEExitCodeOutboundConnFail = "50"
+
+// Default is the rule table of the e2e scenarios. Rules are matched in order, so the specific rules of a phase come
+// before its catch-all rule.
+var Default = MustNewTable(
+	// capacity, before the cluster conflict rule as capacity errors are conflicts too
+	Rule{
+		Name:       "sku-not-available",
+		Phases:     []string{PhaseCluster, PhaseVMSS},
+		StatusCode: http.StatusConflict,
+		ErrorCode:  "SkuNotAvailable",
+		Class:      Capacity,
+		Action:     Skip,
+	},
+	Rule{
+		Name:       "quota-exceeded",
+		Phases:     []string{PhaseCluster, PhaseVMSS},
+		StatusCode: http.StatusConflict,
+		ErrorCode:  "OperationNotAllowed",
+		Message:    `(?s)exceeding approved.*quota|quota.*exceeding approved`,
+		Class:      Capacity,
+		Action:     Skip,
+	},
+
+	// cluster
+	Rule{
+		Name:   "cluster-create-in-progress",
+		Phases: []string{PhaseCluster},
+		// the remediation waits for the operation in progress, so the next attempt doesn't have to be delayed.
+		StatusCode:  http.StatusConflict,
+		ErrorCode:   "OperationNotAllowed",
+		Message:     "in progress create managed cluster operation",
+		Class:       InfraFlake,
+		Action:      Retry,
+		MaxAttempts: 10,
+	},
+	Rule{
+		Name:        "cluster-conflict",
+		Phases:      []string{PhaseCluster},
+		StatusCode:  http.StatusConflict,
+		Class:       InfraFlake,
+		Action:      Retry,
+		MaxAttempts: 10,
+		Delay:       30 * time.Second,
+	},
+	Rule{
+		Name:        "cluster-identity-reconcile",
+		Phases:      []string{PhaseCluster},
+		ErrorCode:   "NotFound",
+		Message:     "Reconcile managed identity credential failed",
+		Class:       InfraFlake,
+		Action:      Retry,
+		MaxAttempts: 10,
+		Delay:       30 * time.Second,
+	},
+
+	// vmss
+	Rule{
+		Name:   "allocation-failed",
+		Phases: []string{PhaseVMSS},
+		// allocation failures are reported by the long-running operation, after the initial 200 response.
+		StatusCode:  http.StatusOK,
+		ErrorCode:   "AllocationFailed",
+		Class:       Capacity,
+		Action:      Retry,
+		MaxAttempts: 10,
+		Delay:       5 * time.Second,
+	},
+	Rule{
+		Name:        "gallery-image-not-found",
+		Phases:      []string{PhaseVMSS},
+		StatusCode:  http.StatusNotFound,
+		ErrorCode:   "GalleryImageNotFound",
+		Class:       InfraFlake,
+		Action:      Retry,
+		MaxAttempts: 10,
+		Delay:       5 * time.Second,
+	},
+
+	// cse
+	Rule{
+		Name:   "cse-outbound-conn-fail",
+		Phases: []string{PhaseVMSS, PhaseCSE},
+		// the VMSS is recreated twice at most, a genuine regression fails every attempt and is reported as a failure.
+		CSEExitCode:    CSEExitCodeOutboundConnFail,
+		Class:          InfraFlake,
+		Action:         Retry,
+		MaxAttempts:    3,
+		ExhaustedClass: ProductBug,
+	},
+	Rule{
+		Name:        "cse-failure",
+		Phases:      []string{PhaseVMSS, PhaseCSE},
+		CSEExitCode: AnyCSEExitCode,
+		Class:       ProductBug,
+		Action:      Fail,
+	},
+
+	// ssh
+	Rule{
+		Name:   "ssh-node-rebooting",
+		Phases: []string{PhaseSSH},
+		// the retries are bounded by the WaitForSSHAfterReboot timeout of the scenario rather than by attempts.
+		Message: "System is going down|pam_nologin|Connection closed by|Connection refused|Connection timed out",
+		Class:   InfraFlake,
+		Action:  Retry,
+		Delay:   5 * time.Second,
+	},
+
+	// node
+	Rule{
+		Name:   "node-failure",
+		Phases: []string{PhaseNodeReady, PhaseValidation},
+		Class:  ProductBug,
+		Action: Fail,
+	},
+)
@@ -1,20 +1,16 @@
 package e2e
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
+	"testing"
 
-	scp "github.com/bramvdbogaerde/go-scp"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/e2e/toolkit"
 	"golang.org/x/crypto/ssh"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/tools/remotecommand"
 )
 
 type podExecResult struct {
@@ -33,6 +29,14 @@ func (r podExecResult) String() string {
 `, r.exitCode, r.stderr, r.stdout)
 }
 
+func newPodExecResult(r *nodeexec.Result) *podExecResult {
+	return &podExecResult{
+		exitCode: strconv.Itoa(r.ExitCode),
+		stdout:   r.Stdout,
+		stderr:   r.Stderr,
+	}
+}
+
 func cleanupBastionTunnel(sshClient *ssh.Client) {
 	// We have to do this because az network tunnel creates a new detached process for tunnel
 	if sshClient != nil {
@@ -46,103 +50,79 @@ func runSSHCommand(
 	command string,
 	isWindows bool,
 ) (*podExecResult, error) {
-	return runSSHCommandWithPrivateKeyFile(ctx, client, command, isWindows)
-}
-
-func copyScriptToRemoteIfRequired(ctx context.Context, client *ssh.Client, command string, isWindows bool) (string, error) {
-	if !strings.Contains(command, "\n") && !isWindows {
-		return command, nil
-	}
-
-	randBytes := make([]byte, 16)
-	if _, err := rand.Read(randBytes); err != nil {
-		return "", fmt.Errorf("generate remote script path: %w", err)
-	}
-
-	var remotePath, remoteCommand string
-	if isWindows {
-		remotePath = fmt.Sprintf("c:/script_file_%x.ps1", randBytes)
-		remoteCommand = fmt.Sprintf("powershell %s", remotePath)
-	} else {
-		remotePath = filepath.Join("/home/azureuser", fmt.Sprintf("remote_script_%x.sh", randBytes))
-		remoteCommand = remotePath
-	}
-
-	scpClient, err := scp.NewClientBySSH(client)
+	result, err := (&nodeexec.SSH{Client: client, Windows: isWindows}).Exec(ctx, command)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer scpClient.Close()
-
-	copyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return newPodExecResult(result), nil
+}
 
-	return remoteCommand, scpClient.Copy(copyCtx,
-		strings.NewReader(command),
-		remotePath,
-		"0755",
-		int64(len(command)))
+// vmSSHExecutor runs commands over the SSH connection of the scenario VM, which is replaced when the VM reboots.
+type vmSSHExecutor struct {
+	s *Scenario
 }
 
-func runSSHCommandWithPrivateKeyFile(
-	ctx context.Context,
-	client *ssh.Client,
-	command string,
-	isWindows bool,
-) (*podExecResult, error) {
-	if client == nil {
-		return nil, fmt.Errorf("Permission denied: ssh client is nil")
-	}
-	var err error
-	command, err = copyScriptToRemoteIfRequired(ctx, client, command, isWindows)
-	if err != nil {
-		return nil, err
+func (e vmSSHExecutor) Exec(ctx context.Context, command string) (*nodeexec.Result, error) {
+	if e.s.Runtime == nil || e.s.Runtime.VM == nil {
+		return nil, fmt.Errorf("cannot execute script on a nil VM")
 	}
+	return (&nodeexec.SSH{Client: e.s.Runtime.VM.SSHClient, Windows: e.s.IsWindows()}).Exec(ctx, command)
+}
 
-	session, err := client.NewSession()
-	if err != nil {
-		return nil, err
+// NodeExecutor returns the executor running validator commands on the scenario node: Runtime.Executor when set,
+// SSH to the scenario VM otherwise.
+func (s *Scenario) NodeExecutor() nodeexec.NodeExecutor {
+	if s.Runtime != nil && s.Runtime.Executor != nil {
+		return s.Runtime.Executor
 	}
-	defer session.Close()
-
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	session.Stdout = &stdout
-	session.Stderr = &stderr
+	return vmSSHExecutor{s: s}
+}
 
-	runErr := make(chan error, 1)
-	go func() {
-		runErr <- session.Run(command)
-	}()
-	select {
-	case err = <-runErr:
-	case <-ctx.Done():
-		_ = session.Close()
-		_ = client.Close()
-		select {
-		case <-runErr:
-		case <-time.After(5 * time.Second):
-		}
-		return nil, fmt.Errorf("SSH command canceled or timed out: %w", ctx.Err())
+// targetExecutor returns the executor running commands with live on another target of the scenario node, e.g. a pod
+// scheduled on it, or the node itself over a new connection when name is empty. The commands are recorded and
+// replayed along the node commands, live is not called by a replay.
+func (s *Scenario) targetExecutor(name string, live nodeexec.NodeExecutor) nodeexec.NodeExecutor {
+	if targeter, ok := s.NodeExecutor().(nodeexec.Targeter); ok {
+		return targeter.Target(name, live)
 	}
+	return live
+}
 
-	exitCode := 0
-	if err != nil {
-		if exitErr, ok := err.(*ssh.ExitError); ok {
-			exitCode = exitErr.ExitStatus()
-		} else if _, ok := err.(*ssh.ExitMissingError); ok {
-			// Bastion closed channel early – ignore
-			err = nil
-		} else {
-			return nil, err // real SSH failure
+// debugNonHostPodExecutor runs commands in the debugnonhost pod scheduled on the scenario node, which does not share
+// the host namespaces. The pod is looked up by the first command, so a replay does not need the cluster.
+func debugNonHostPodExecutor(s *Scenario) nodeexec.NodeExecutor {
+	var pod *nodeexec.Pod
+	return s.targetExecutor("debugnonhost pod", nodeexec.Func(func(ctx context.Context, command string) (*nodeexec.Result, error) {
+		if pod == nil {
+			nonHostPod, err := s.Runtime.Kube.GetPodNetworkDebugPodForNode(ctx, s.Runtime.VM.KubeName)
+			if err != nil {
+				return nil, fmt.Errorf("get non-host debug pod: %w", err)
+			}
+			pod = podExecutor(s.Runtime.Kube, nonHostPod.Namespace, nonHostPod.Name)
 		}
-	}
+		return pod.Exec(ctx, command)
+	}))
+}
 
-	return &podExecResult{
-		exitCode: strconv.Itoa(exitCode),
-		stdout:   stdout.String(),
-		stderr:   stderr.String(),
-	}, nil
+// recordNodeExecutor records the validator commands run on the scenario node and writes them to exec-recording.json
+// in the scenario log directory when the test ends, so validators can be replayed against the run with
+// nodeexec.Replay.
+func recordNodeExecutor(t testing.TB, s *Scenario) {
+	recorder := nodeexec.NewRecorder(s.NodeExecutor())
+	s.Runtime.Executor = recorder
+	t.Cleanup(func() {
+		recording := recorder.Recording()
+		if len(recording.Entries) == 0 {
+			return
+		}
+		if err := os.MkdirAll(testDir(t), 0755); err != nil {
+			t.Logf("failed to write the node command recording: %v", err)
+			return
+		}
+		if err := recording.WriteFile(filepath.Join(testDir(t), "exec-recording.json")); err != nil {
+			t.Logf("failed to write the node command recording: %v", err)
+		}
+	})
 }
 
 func execScriptOnVm(ctx context.Context, s *Scenario, vm *ScenarioVM, script string) (*podExecResult, error) {
@@ -153,136 +133,63 @@ func execScriptOnVm(ctx context.Context, s *Scenario, vm *ScenarioVM, script str
 	return runSSHCommand(ctx, vm.SSHClient, script, s.IsWindows())
 }
 
-func execOnUnprivilegedPod(ctx context.Context, kube *Kubeclient, namespace string, podName string, bashCommand string) (*podExecResult, error) {
-	nonPrivilegedCommand := append(unprivilegedCommandArray(), bashCommand)
-	return execOnPod(ctx, kube, namespace, podName, nonPrivilegedCommand)
-}
-
 func execOnVMForScenarioOnUnprivilegedPod(ctx context.Context, s *Scenario, cmd string) (*podExecResult, error) {
 	s.T.Helper()
-	nonHostPod, err := s.Runtime.Kube.GetPodNetworkDebugPodForNode(ctx, s.Runtime.VM.KubeName)
-	if err != nil {
-		return nil, fmt.Errorf("get non-host debug pod: %w", err)
-	}
-	execResult, err := execOnUnprivilegedPod(ctx, s.Runtime.Kube, nonHostPod.Namespace, nonHostPod.Name, cmd)
+	result, err := debugNonHostPodExecutor(s).Exec(ctx, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("execute command %q on unprivileged pod: %w", cmd, err)
 	}
-	return execResult, nil
+	return newPodExecResult(result), nil
 }
 
 func execScriptOnVMForScenario(ctx context.Context, s *Scenario, cmd string) (*podExecResult, error) {
 	s.T.Helper()
-	result, err := execScriptOnVm(ctx, s, s.Runtime.VM, cmd)
-	if err != nil {
-		return nil, fmt.Errorf("execute command %q on VM: %w", cmd, err)
-	}
-	return result, nil
+	return execOnNode(ctx, s.NodeExecutor(), cmd)
 }
 
 func execScriptOnVMForScenarioValidateExitCode(ctx context.Context, s *Scenario, cmd string, expectedExitCode int, additionalErrorMessage string) (*podExecResult, error) {
 	s.T.Helper()
-	execResult, err := execScriptOnVMForScenario(ctx, s, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	expectedExitCodeStr := fmt.Sprint(expectedExitCode)
-	if expectedExitCodeStr != execResult.exitCode {
-		s.T.Logf("Command: %s\nStdout: %s\nStderr: %s", cmd, execResult.stdout, execResult.stderr)
-		return execResult, fmt.Errorf("expected exit code %s, got %s for command %q: %s", expectedExitCodeStr, execResult.exitCode, cmd, additionalErrorMessage)
-	}
-	return execResult, nil
+	return execOnNodeValidateExitCode(ctx, s.NodeExecutor(), cmd, expectedExitCode, additionalErrorMessage)
 }
 
-// isRetryableConnectionError checks if the error is a transient connection issue that should be retried
-func isRetryableConnectionError(err error) bool {
-	errorMsg := err.Error()
-	return strings.Contains(errorMsg, "error dialing backend") ||
-		strings.Contains(errorMsg, "connection refused") ||
-		strings.Contains(errorMsg, "dial tcp") ||
-		strings.Contains(errorMsg, "i/o timeout") ||
-		strings.Contains(errorMsg, "connection reset by peer")
+// execOnNode runs the command on the node, a non-zero exit code is not an error.
+func execOnNode(ctx context.Context, node nodeexec.NodeExecutor, cmd string) (*podExecResult, error) {
+	result, err := node.Exec(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("execute command %q on VM: %w", cmd, err)
+	}
+	return newPodExecResult(result), nil
 }
 
-func execOnPod(ctx context.Context, kube *Kubeclient, namespace, podName string, command []string) (*podExecResult, error) {
-	maxRetries := 3
-	retryDelay := 1 * time.Second
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := attemptExecOnPod(ctx, kube, namespace, podName, command)
-		if err == nil {
-			return result, nil
-		}
-
-		// If it's a retryable connection error and we have retries left, retry
-		if isRetryableConnectionError(err) && attempt < maxRetries-1 {
-			select {
-			case <-time.After(retryDelay):
-				// Continue to next attempt
-			case <-ctx.Done():
-				return nil, fmt.Errorf("context cancelled during retry attempt %d: %w", attempt+1, ctx.Err())
-			}
-			continue
-		}
-
-		// For non-retryable errors or final attempt, return the error
+// execOnNodeValidateExitCode is execValidateExitCode returning the result as a podExecResult.
+func execOnNodeValidateExitCode(ctx context.Context, node nodeexec.NodeExecutor, cmd string, expectedExitCode int, additionalErrorMessage string) (*podExecResult, error) {
+	result, err := execValidateExitCode(ctx, node, cmd, expectedExitCode, additionalErrorMessage)
+	if result == nil {
 		return nil, err
 	}
-
-	return nil, fmt.Errorf("failed after %d attempts", maxRetries)
+	return newPodExecResult(result), err
 }
 
-func attemptExecOnPod(ctx context.Context, kube *Kubeclient, namespace, podName string, command []string) (*podExecResult, error) {
-	req := kube.Typed.CoreV1().RESTClient().Get().Resource("pods").Name(podName).Namespace(namespace).SubResource("exec")
-
-	option := &corev1.PodExecOptions{
-		Command: command,
-		Stdout:  true,
-		Stderr:  true,
-	}
-
-	req.VersionedParams(
-		option,
-		scheme.ParameterCodec,
-	)
-
-	exec, err := remotecommand.NewWebSocketExecutor(kube.RESTConfig, "GET", req.URL().String())
+// execValidateExitCode runs the command on the node and fails when it exits with another code than expectedExitCode,
+// the result is returned in that case too.
+func execValidateExitCode(ctx context.Context, node nodeexec.NodeExecutor, cmd string, expectedExitCode int, additionalErrorMessage string) (*nodeexec.Result, error) {
+	result, err := node.Exec(ctx, cmd)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create new WebSocket executor for pod exec: %w", err)
+		return nil, fmt.Errorf("execute command %q on VM: %w", cmd, err)
 	}
-
-	var (
-		stdout, stderr bytes.Buffer
-		exitCode       string = "0"
-	)
-
-	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-		Stdout: &stdout,
-		Stderr: &stderr,
-	})
-	if err != nil {
-		if strings.Contains(err.Error(), "command terminated with exit code") {
-			code, err := extractExitCode(err.Error())
-			if err != nil {
-				return nil, fmt.Errorf("error extracing exit code from remote command execution error msg: %w", err)
-			}
-			exitCode = code
-		} else {
-			return nil, fmt.Errorf("encountered unexpected error when executing command on pod: %w", err)
-		}
+	if result.ExitCode != expectedExitCode {
+		toolkit.Logf(ctx, "Command: %s\nStdout: %s\nStderr: %s", cmd, result.Stdout, result.Stderr)
+		return result, fmt.Errorf("expected exit code %d, got %d for command %q: %s", expectedExitCode, result.ExitCode, cmd, additionalErrorMessage)
 	}
-
-	return &podExecResult{
-		exitCode: exitCode,
-		stdout:   stdout.String(),
-		stderr:   stderr.String(),
-	}, nil
+	return result, nil
 }
 
-func unprivilegedCommandArray() []string {
-	return []string{
-		"bash",
-		"-c",
+func podExecutor(kube *Kubeclient, namespace, podName string) *nodeexec.Pod {
+	return &nodeexec.Pod{
+		Config:    kube.RESTConfig,
+		Client:    kube.Typed,
+		Namespace: namespace,
+		Name:      podName,
+		Shell:     nodeexec.UnprivilegedShell,
 	}
 }
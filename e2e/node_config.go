@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/agentbaker/pkg/agent"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 )
 
@@ -1096,25 +1097,16 @@ DXRqvV7TWO2hndliQq3BW385ZkiephlrmpUVM= r2k1@arturs-mbp.lan`,
 	return config, nil
 }
 
-// k8s version > 1.30.0 contains deprecated kubelet flags
+// pruneKubeletConfig removes the kubelet flags which are not accepted anymore by the given Kubernetes version.
 func pruneKubeletConfig(kubernetesVersion string, datamodel *datamodel.NodeBootstrappingConfiguration) (*datamodel.NodeBootstrappingConfiguration, error) {
-	version, err := semver.NewVersion(kubernetesVersion)
-	if err != nil {
+	if _, err := semver.NewVersion(kubernetesVersion); err != nil {
 		return nil, err
 	}
-	constraint130, err := semver.NewConstraint(">= 1.30.0")
-	if err != nil {
-		return nil, err
-	}
-	if constraint130.Check(version) {
-		delete(datamodel.KubeletConfig, "--azure-container-registry-config")
-	}
-	constraint134, err := semver.NewConstraint(">= 1.34.0")
-	if err != nil {
-		return nil, err
-	}
-	if constraint134.Check(version) {
-		delete(datamodel.KubeletConfig, "--streaming-connection-idle-timeout")
+	policy := kubeletpolicy.Embedded()
+	for flag := range datamodel.KubeletConfig {
+		if policy.StateOf(flag, kubernetesVersion) == kubeletpolicy.StateRemoved {
+			delete(datamodel.KubeletConfig, flag)
+		}
 	}
 	return datamodel, nil
 }
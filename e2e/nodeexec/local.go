@@ -0,0 +1,43 @@
+package nodeexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// Local runs commands in a local shell, for checks running on the node itself.
+type Local struct {
+	// Shell is the command prefix running a shell command, UnprivilegedShell when empty.
+	Shell []string
+}
+
+// Exec runs the command with the local shell.
+func (e *Local) Exec(ctx context.Context, command string) (*Result, error) {
+	shell := e.Shell
+	if len(shell) == 0 {
+		shell = UnprivilegedShell
+	}
+	argv := append(append([]string{}, shell...), command)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // running arbitrary commands is the purpose
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return nil, err
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return &Result{
+		Command:  command,
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
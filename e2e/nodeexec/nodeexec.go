@@ -0,0 +1,42 @@
+// Package nodeexec runs commands on a node. Commands run over SSH, in a pod scheduled on the node, in a local shell
+// when running on the node itself, or are answered from a recording of an earlier run. Validators written against
+// NodeExecutor run the same way in the e2e harness, on a production node and in unit tests.
+package nodeexec
+
+import (
+	"context"
+	"fmt"
+)
+
+// Result is the outcome of a command which ran to completion.
+type Result struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf(`exit code: %d
+----------------------------------- begin stderr -----------------------------------
+%s
+------------------------------------ end stderr ------------------------------------
+----------------------------------- begin stdout -----------------------------------,
+%s
+----------------------------------- end stdout ------------------------------------
+`, r.ExitCode, r.Stderr, r.Stdout)
+}
+
+// NodeExecutor runs a shell command on a node. A command exiting with a non-zero code is not an error, the code is
+// reported in the Result. An error means the command could not be run or its outcome is unknown.
+type NodeExecutor interface {
+	Exec(ctx context.Context, command string) (*Result, error)
+}
+
+// Func adapts a function to a NodeExecutor.
+type Func func(ctx context.Context, command string) (*Result, error)
+
+// Exec calls f.
+func (f Func) Exec(ctx context.Context, command string) (*Result, error) {
+	return f(ctx, command)
+}
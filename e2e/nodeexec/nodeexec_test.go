@@ -0,0 +1,81 @@
+package nodeexec
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalReportsExitCodeAndOutput(t *testing.T) {
+	result, err := (&Local{}).Exec(context.Background(), "echo out; echo err >&2; exit 3")
+
+	require.NoError(t, err)
+	require.Equal(t, Result{Command: "echo out; echo err >&2; exit 3", ExitCode: 3, Stdout: "out\n", Stderr: "err\n"}, *result)
+}
+
+func TestReplayAnswersFromRecording(t *testing.T) {
+	exitCodes := []int{1, 0}
+	recorder := NewRecorder(Func(func(_ context.Context, command string) (*Result, error) {
+		if command == "unreachable" {
+			return nil, errors.New("connection lost")
+		}
+		code := exitCodes[0]
+		exitCodes = exitCodes[1:]
+		return &Result{Command: command, ExitCode: code, Stdout: command}, nil
+	}))
+	ctx := context.Background()
+	for _, command := range []string{"systemctl is-active kubelet", "systemctl is-active kubelet", "unreachable"} {
+		_, _ = recorder.Exec(ctx, command)
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, recorder.Recording().WriteFile(path))
+	recording, err := LoadRecording(path)
+	require.NoError(t, err)
+	require.Len(t, recording.Entries, 3)
+
+	replay := NewReplay(recording)
+	for _, want := range []int{1, 0, 0} {
+		result, err := replay.Exec(ctx, "systemctl is-active kubelet")
+		require.NoError(t, err)
+		require.Equal(t, want, result.ExitCode)
+	}
+	_, err = replay.Exec(ctx, "unreachable")
+	require.ErrorContains(t, err, "connection lost")
+	_, err = replay.Exec(ctx, "systemctl is-active containerd")
+	require.ErrorContains(t, err, `no recorded result for command "systemctl is-active containerd"`)
+}
+
+func TestReplayAnswersTargetsSeparately(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRecorder(Func(func(_ context.Context, command string) (*Result, error) {
+		return &Result{Command: command, Stdout: "node"}, nil
+	}))
+	pod := recorder.Target("debug pod", Func(func(_ context.Context, command string) (*Result, error) {
+		return &Result{Command: command, Stdout: "pod"}, nil
+	}))
+	_, _ = recorder.Exec(ctx, "hostname")
+	_, _ = pod.Exec(ctx, "hostname")
+
+	replay := NewReplay(recorder.Recording())
+	result, err := replay.Exec(ctx, "hostname")
+	require.NoError(t, err)
+	require.Equal(t, "node", result.Stdout)
+	result, err = replay.Target("debug pod", nil).Exec(ctx, "hostname")
+	require.NoError(t, err)
+	require.Equal(t, "pod", result.Stdout)
+	_, err = replay.Target("kata pod", nil).Exec(ctx, "hostname")
+	require.EqualError(t, err, `no recorded result for command "hostname" on kata pod`)
+}
+
+func TestExtractExitCode(t *testing.T) {
+	code, err := extractExitCode("command terminated with exit code 137")
+	require.NoError(t, err)
+	require.Equal(t, 137, code)
+
+	_, err = extractExitCode("connection refused")
+	require.Error(t, err)
+}
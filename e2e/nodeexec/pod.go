@@ -0,0 +1,144 @@
+package nodeexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+//nolint:gochecknoglobals
+var (
+	// UnprivilegedShell runs commands in the namespaces of the pod container.
+	UnprivilegedShell = []string{"bash", "-c"}
+	// HostShell runs commands in the namespaces of the node, it requires a privileged pod sharing the host PID
+	// namespace.
+	HostShell = []string{"nsenter", "--target", "1", "--mount", "--uts", "--ipc", "--net", "--pid", "--", "bash", "-c"}
+)
+
+// errMsgExitCodeRegex matches "command terminated with exit code CODE", returning CODE as a submatch.
+var errMsgExitCodeRegex = regexp.MustCompile("command terminated with exit code (25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)")
+
+// Pod runs commands in the first container of a running pod, through the Kubernetes exec API.
+type Pod struct {
+	Config    *rest.Config
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	// Shell is the command prefix running a shell command, UnprivilegedShell when empty.
+	Shell []string
+}
+
+// Exec runs the command with the pod shell.
+func (e *Pod) Exec(ctx context.Context, command string) (*Result, error) {
+	shell := e.Shell
+	if len(shell) == 0 {
+		shell = UnprivilegedShell
+	}
+	result, err := e.Run(ctx, append(append([]string{}, shell...), command))
+	if err != nil {
+		return nil, err
+	}
+	result.Command = command
+	return result, nil
+}
+
+// Run runs a command given as an argument vector, retrying transient connection errors.
+func (e *Pod) Run(ctx context.Context, argv []string) (*Result, error) {
+	maxRetries := 3
+	retryDelay := 1 * time.Second
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		result, err := e.attempt(ctx, argv)
+		if err == nil {
+			return result, nil
+		}
+
+		// If it's a retryable connection error and we have retries left, retry
+		if isRetryableConnectionError(err) && attempt < maxRetries-1 {
+			select {
+			case <-time.After(retryDelay):
+				// Continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry attempt %d: %w", attempt+1, ctx.Err())
+			}
+			continue
+		}
+
+		// For non-retryable errors or final attempt, return the error
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("failed after %d attempts", maxRetries)
+}
+
+func (e *Pod) attempt(ctx context.Context, argv []string) (*Result, error) {
+	req := e.Client.CoreV1().RESTClient().Get().Resource("pods").Name(e.Name).Namespace(e.Namespace).SubResource("exec")
+
+	option := &corev1.PodExecOptions{
+		Command: argv,
+		Stdout:  true,
+		Stderr:  true,
+	}
+
+	req.VersionedParams(
+		option,
+		scheme.ParameterCodec,
+	)
+
+	exec, err := remotecommand.NewWebSocketExecutor(e.Config, "GET", req.URL().String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new WebSocket executor for pod exec: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := 0
+
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "command terminated with exit code") {
+			return nil, fmt.Errorf("encountered unexpected error when executing command on pod: %w", err)
+		}
+		exitCode, err = extractExitCode(err.Error())
+		if err != nil {
+			return nil, fmt.Errorf("error extracing exit code from remote command execution error msg: %w", err)
+		}
+	}
+
+	return &Result{
+		Command:  strings.Join(argv, " "),
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+func extractExitCode(errMsg string) (int, error) {
+	matches := errMsgExitCodeRegex.FindStringSubmatch(errMsg)
+	if len(matches) < 2 {
+		return 0, fmt.Errorf("expected 1 match with 1 submatch from regex, result %q", matches)
+	}
+	return strconv.Atoi(matches[1])
+}
+
+// isRetryableConnectionError checks if the error is a transient connection issue that should be retried
+func isRetryableConnectionError(err error) bool {
+	errorMsg := err.Error()
+	return strings.Contains(errorMsg, "error dialing backend") ||
+		strings.Contains(errorMsg, "connection refused") ||
+		strings.Contains(errorMsg, "dial tcp") ||
+		strings.Contains(errorMsg, "i/o timeout") ||
+		strings.Contains(errorMsg, "connection reset by peer")
+}
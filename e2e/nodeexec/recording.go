@@ -0,0 +1,159 @@
+package nodeexec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RecordingVersion is the version of the recording file format.
+const RecordingVersion = 1
+
+// Entry is a recorded command with its result, or with the error which prevented running it.
+type Entry struct {
+	// Target names where the command ran when it is not the node itself, e.g. a pod scheduled on the node.
+	Target string `json:"target,omitempty"`
+	Result
+	Error string `json:"error,omitempty"`
+}
+
+// Recording is a transcript of the commands run on a node, in order.
+type Recording struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// LoadRecording reads a recording written by Recording.WriteFile.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	var r Recording
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse recording %s: %w", path, err)
+	}
+	if r.Version != RecordingVersion {
+		return nil, fmt.Errorf("recording %s has version %d, expected %d", path, r.Version, RecordingVersion)
+	}
+	return &r, nil
+}
+
+// WriteFile writes the recording as indented JSON.
+func (r *Recording) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal recording: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Targeter is implemented by the executors which also record or replay the commands run on other targets of the
+// node, e.g. a pod scheduled on it.
+type Targeter interface {
+	// Target returns the executor of the named target. live runs the commands which are not replayed.
+	Target(name string, live NodeExecutor) NodeExecutor
+}
+
+// Recorder is a NodeExecutor recording the commands run by another NodeExecutor. It is safe for concurrent use.
+type Recorder struct {
+	executor NodeExecutor
+	mu       sync.Mutex
+	entries  []Entry
+}
+
+// NewRecorder returns a Recorder running commands with executor.
+func NewRecorder(executor NodeExecutor) *Recorder {
+	return &Recorder{executor: executor}
+}
+
+// Exec runs the command and records its result or error.
+func (r *Recorder) Exec(ctx context.Context, command string) (*Result, error) {
+	return r.record(ctx, "", r.executor, command)
+}
+
+// Target returns a NodeExecutor running the commands with live and recording them with the target name.
+func (r *Recorder) Target(name string, live NodeExecutor) NodeExecutor {
+	return Func(func(ctx context.Context, command string) (*Result, error) {
+		return r.record(ctx, name, live, command)
+	})
+}
+
+func (r *Recorder) record(ctx context.Context, target string, executor NodeExecutor, command string) (*Result, error) {
+	result, err := executor.Exec(ctx, command)
+	entry := Entry{Target: target, Result: Result{Command: command}}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Result = *result
+		entry.Command = command
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+	return result, err
+}
+
+// Recording returns the commands recorded so far.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Recording{Version: RecordingVersion, Entries: append([]Entry{}, r.entries...)}
+}
+
+// Replay is a NodeExecutor answering commands from a recording. A command repeated in the recording gets its
+// recorded results in order, the last one is returned again once they are exhausted, so a validator polling a
+// condition observes the same progression as in the recorded run. It is safe for concurrent use.
+type Replay struct {
+	mu      sync.Mutex
+	results map[replayKey][]Entry
+}
+
+type replayKey struct {
+	target, command string
+}
+
+// NewReplay returns a Replay of the recording.
+func NewReplay(r *Recording) *Replay {
+	results := map[replayKey][]Entry{}
+	for _, e := range r.Entries {
+		key := replayKey{target: e.Target, command: e.Command}
+		results[key] = append(results[key], e)
+	}
+	return &Replay{results: results}
+}
+
+// Exec returns the next recorded result of the command. Commands which were not recorded are an error.
+func (r *Replay) Exec(_ context.Context, command string) (*Result, error) {
+	return r.next(replayKey{command: command})
+}
+
+// Target returns a NodeExecutor answering the commands recorded with the target name, live is never called.
+func (r *Replay) Target(name string, _ NodeExecutor) NodeExecutor {
+	return Func(func(_ context.Context, command string) (*Result, error) {
+		return r.next(replayKey{target: name, command: command})
+	})
+}
+
+func (r *Replay) next(key replayKey) (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.results[key]
+	if len(entries) == 0 {
+		if key.target != "" {
+			return nil, fmt.Errorf("no recorded result for command %q on %s", key.command, key.target)
+		}
+		return nil, fmt.Errorf("no recorded result for command %q", key.command)
+	}
+	entry := entries[0]
+	if len(entries) > 1 {
+		r.results[key] = entries[1:]
+	}
+	if entry.Error != "" {
+		return nil, fmt.Errorf("recorded error: %s", entry.Error)
+	}
+	result := entry.Result
+	return &result, nil
+}
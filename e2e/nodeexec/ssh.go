@@ -0,0 +1,116 @@
+package nodeexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	scp "github.com/bramvdbogaerde/go-scp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH runs commands over an established SSH connection. Multi-line scripts, and every Windows command, are copied to
+// the node and run from a file.
+type SSH struct {
+	Client  *ssh.Client
+	Windows bool
+}
+
+// Exec runs the command in an SSH session. When ctx is done the session and the connection are closed.
+func (e *SSH) Exec(ctx context.Context, command string) (*Result, error) {
+	if e.Client == nil {
+		return nil, fmt.Errorf("Permission denied: ssh client is nil")
+	}
+	remoteCommand, err := e.copyScriptToRemoteIfRequired(command)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := e.Client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- session.Run(remoteCommand)
+	}()
+	select {
+	case err = <-runErr:
+	case <-ctx.Done():
+		_ = session.Close()
+		_ = e.Client.Close()
+		select {
+		case <-runErr:
+		case <-time.After(5 * time.Second):
+		}
+		return nil, fmt.Errorf("SSH command canceled or timed out: %w", ctx.Err())
+	}
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *ssh.ExitError
+		var exitMissingErr *ssh.ExitMissingError
+		switch {
+		case errors.As(err, &exitErr):
+			exitCode = exitErr.ExitStatus()
+		case errors.As(err, &exitMissingErr):
+			// Bastion closed channel early – ignore
+		default:
+			return nil, err // real SSH failure
+		}
+	}
+
+	return &Result{
+		Command:  command,
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+func (e *SSH) copyScriptToRemoteIfRequired(command string) (string, error) {
+	if !strings.Contains(command, "\n") && !e.Windows {
+		return command, nil
+	}
+
+	randBytes := make([]byte, 16)
+	if _, err := rand.Read(randBytes); err != nil {
+		return "", fmt.Errorf("generate remote script path: %w", err)
+	}
+
+	var remotePath, remoteCommand string
+	if e.Windows {
+		remotePath = fmt.Sprintf("c:/script_file_%x.ps1", randBytes)
+		remoteCommand = fmt.Sprintf("powershell %s", remotePath)
+	} else {
+		remotePath = filepath.Join("/home/azureuser", fmt.Sprintf("remote_script_%x.sh", randBytes))
+		remoteCommand = remotePath
+	}
+
+	scpClient, err := scp.NewClientBySSH(e.Client)
+	if err != nil {
+		return "", err
+	}
+	defer scpClient.Close()
+
+	copyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return remoteCommand, scpClient.Copy(copyCtx,
+		strings.NewReader(command),
+		remotePath,
+		"0755",
+		int64(len(command)))
+}
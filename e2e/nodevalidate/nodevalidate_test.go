@@ -0,0 +1,143 @@
+package nodevalidate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/pkg/agent/sysctlpolicy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeNode is a node with the AgentBaker default sysctls and sysctls overrides, running kubelet and with the
+// failed units.
+func fakeNode(sysctls map[string]string, failedUnits ...string) nodeexec.NodeExecutor {
+	defaults, err := sysctlpolicy.Embedded().Evaluate(sysctlpolicy.Target{}, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	running := map[string]string{}
+	for k, v := range defaults.Sysctls {
+		running[k] = v
+	}
+	for k, v := range sysctls {
+		running[k] = v
+	}
+	return nodeexec.Func(func(_ context.Context, command string) (*nodeexec.Result, error) {
+		result := &nodeexec.Result{Command: command}
+		switch {
+		case command == "uname -r":
+			result.Stdout = "5.15.0-1064-azure\n"
+		case strings.Contains(command, "sudo sysctl "):
+			keys := strings.SplitN(strings.SplitN(command, "sudo sysctl ", 2)[1], " |", 2)[0]
+			for _, key := range strings.Fields(keys) {
+				result.Stdout += fmt.Sprintf("%s = %s\n", key, running[key])
+			}
+		case strings.HasSuffix(command, "systemctl is-active kubelet"):
+			result.Stdout = "active\n"
+		case command == "systemctl list-units --failed --output json":
+			units := []map[string]string{}
+			for _, unit := range failedUnits {
+				units = append(units, map[string]string{"unit": unit})
+			}
+			out, _ := json.Marshal(units)
+			result.Stdout = string(out)
+		case strings.HasPrefix(command, "journalctl -u "):
+			result.Stdout = "logs of " + strings.TrimPrefix(command, "journalctl -u ")
+		default:
+			result.ExitCode = 1
+		}
+		return result, nil
+	})
+}
+
+func TestSelect(t *testing.T) {
+	defaults, err := Select(nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, defaults)
+	for _, c := range defaults {
+		require.True(t, c.Default, c.Name)
+		require.NotEqual(t, "fips", c.Name)
+	}
+
+	selected, err := Select([]string{"fips", "sysctls"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"fips", "sysctls"}, checkNames(selected))
+
+	_, err = Select([]string{"sysctls", "selinux"})
+	require.ErrorContains(t, err, "unknown checks selinux")
+}
+
+func TestRunReportsCheckOutcomes(t *testing.T) {
+	cfg := &aksnodeconfigv1.Configuration{
+		CustomLinuxOsConfig: &aksnodeconfigv1.CustomLinuxOsConfig{
+			SysctlConfig: &aksnodeconfigv1.SysctlConfig{NetCoreSomaxconn: proto.Int32(32768)},
+		},
+	}
+	checks, err := Select([]string{"sysctls", "kubelet-running", "imds-restriction"})
+	require.NoError(t, err)
+
+	report := Run(context.Background(), fakeNode(map[string]string{"net.core.somaxconn": "32768"}), cfg, checks)
+	require.Equal(t, 2, report.Passed)
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, StatusSkipped, report.Checks[2].Status)
+	require.Equal(t, "IMDS restriction is not enabled", report.Checks[2].Message)
+
+	report = Run(context.Background(), fakeNode(nil), cfg, checks)
+	require.Equal(t, 1, report.Failed)
+	require.Equal(t, StatusFailed, report.Checks[0].Status)
+	require.Contains(t, report.Checks[0].Message, "expected to find net.core.somaxconn set to 32768")
+
+	var junit bytes.Buffer
+	require.NoError(t, report.WriteJUnit(&junit, "node-validate"))
+	require.Contains(t, junit.String(), `<testsuite name="node-validate" tests="3" failures="1" skipped="1"`)
+	require.Contains(t, junit.String(), `<skipped message="IMDS restriction is not enabled"></skipped>`)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, *report, decoded)
+}
+
+func TestNoFailedSystemdUnits(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, NoFailedSystemdUnits(ctx, fakeNode(nil, "fwupd-refresh.service", `run-cloud\x2dinit-tmp-tmpab12.mount`), DefaultUnitAllowList()))
+
+	err := NoFailedSystemdUnits(ctx, fakeNode(nil, "fwupd-refresh.service", "kubelet.service"), DefaultUnitAllowList())
+	var failed *FailedUnitsError
+	require.ErrorAs(t, err, &failed)
+	require.Equal(t, []string{"kubelet.service"}, failed.Units)
+	require.Contains(t, failed.Logs["kubelet.service.log"], "logs of kubelet.service")
+}
+
+func TestOpensslProviderActive(t *testing.T) {
+	output := `Providers:
+  default
+    name: OpenSSL Default Provider
+    status: active
+  symcryptprovider
+    name: SCOSSL
+    status: inactive
+`
+	require.False(t, opensslProviderActive(output, "fips", "symcrypt"))
+	require.True(t, opensslProviderActive(strings.Replace(output, "inactive", "active", 1), "fips", "symcrypt"))
+}
+
+func TestContainerdConfigHasNoWarnings(t *testing.T) {
+	dump := func(stderr string) nodeexec.NodeExecutor {
+		return nodeexec.Func(func(_ context.Context, command string) (*nodeexec.Result, error) {
+			return &nodeexec.Result{Command: command, Stdout: "version = 2\n", Stderr: stderr}, nil
+		})
+	}
+	ctx := context.Background()
+	require.NoError(t, ContainerdConfigHasNoWarnings(ctx, dump("")))
+	// containerd logs the deprecated config properties on stderr.
+	require.ErrorContains(t, ContainerdConfigHasNoWarnings(ctx, dump(`level=warning msg="Ignoring unknown key in TOML"`)), "do not expect warning message")
+}
@@ -0,0 +1,282 @@
+package nodevalidate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/agentbaker/aks-node-controller/parser"
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/pkg/agent/sysctlpolicy"
+)
+
+// KubeletConfigFilePath is where the kubelet config file is written when EnableKubeletConfigFile is set.
+const KubeletConfigFilePath = "/etc/default/kubeletconfig.json"
+
+// Check is a check of a node against the AKSNodeConfig it was provisioned with.
+type Check struct {
+	Name        string
+	Description string
+	// Default checks run when no check is selected by name.
+	Default bool
+	Run     func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error
+}
+
+// SkipError is returned by a check which doesn't apply to the node.
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return "skipped: " + e.Reason
+}
+
+func skip(format string, args ...any) error {
+	return &SkipError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Checks returns the registered checks sorted by name.
+func Checks() []Check {
+	checks := []Check{
+		{
+			Name:        "containerd-config",
+			Description: "containerd converts its config file without warnings",
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return ContainerdConfigHasNoWarnings(ctx, node)
+			},
+		},
+		{
+			Name:        "containerd-running",
+			Description: "the containerd service is running",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return SystemdUnitIsRunning(ctx, node, "containerd")
+			},
+		},
+		{
+			Name:        "fips",
+			Description: "kernel FIPS mode is enabled and OpenSSL has an active FIPS provider",
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return FIPSProvider(ctx, node)
+			},
+		},
+		{
+			Name:        "imds-restriction",
+			Description: "the IMDS restriction iptables rule is installed when IMDS restriction is enabled",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+				imds := cfg.GetImdsRestrictionConfig()
+				if !imds.GetEnableImdsRestriction() {
+					return skip("IMDS restriction is not enabled")
+				}
+				table := "filter"
+				if imds.GetInsertImdsRestrictionRuleToMangleTable() {
+					table = "mangle"
+				}
+				return IMDSRestrictionRule(ctx, node, table)
+			},
+		},
+		{
+			Name:        "kubelet-config-file",
+			Description: "kubelet is started with the kubelet config file when it is enabled",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+				if !cfg.GetKubeletConfig().GetEnableKubeletConfigFile() {
+					return skip("the kubelet config file is not enabled")
+				}
+				return KubeletHasFlags(ctx, node, KubeletConfigFilePath)
+			},
+		},
+		{
+			Name:        "kubelet-node-ip",
+			Description: "kubelet is given one IP address per IP family with --node-ip",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return KubeletNodeIP(ctx, node)
+			},
+		},
+		{
+			Name:        "kubelet-not-stopped",
+			Description: "kubelet has not been stopped since boot",
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return KubeletHasNotStopped(ctx, node)
+			},
+		},
+		{
+			Name:        "kubelet-running",
+			Description: "the kubelet service is running",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return SystemdUnitIsRunning(ctx, node, "kubelet")
+			},
+		},
+		{
+			Name:        "no-failed-units",
+			Description: "no systemd unit is in a failed state",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return NoFailedSystemdUnits(ctx, node, DefaultUnitAllowList())
+			},
+		},
+		{
+			Name:        "services-do-not-restart-kubelet",
+			Description: "no systemd unit restarts kubelet",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, _ *aksnodeconfigv1.Configuration) error {
+				return ServicesDoNotRestartKubelet(ctx, node)
+			},
+		},
+		{
+			Name:        "swap-file",
+			Description: "the swap file is active and as large as configured",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+				osConfig := cfg.GetCustomLinuxOsConfig()
+				if !osConfig.GetEnableSwapConfig() || osConfig.GetSwapFileSize() <= 0 {
+					return skip("no swap file is configured")
+				}
+				return SwapFile(ctx, node, osConfig.GetSwapFileSize())
+			},
+		},
+		{
+			Name:        "sysctls",
+			Description: "the running sysctls have the configured values and the AgentBaker defaults",
+			Default:     true,
+			Run:         checkSysctls,
+		},
+		{
+			Name:        "transparent-hugepages",
+			Description: "the transparent huge page modes are the configured ones",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+				osConfig := cfg.GetCustomLinuxOsConfig()
+				if osConfig.GetTransparentHugepageSupport() == "" && osConfig.GetTransparentDefrag() == "" {
+					return skip("transparent huge pages are not configured")
+				}
+				return TransparentHugePages(ctx, node, osConfig.GetTransparentHugepageSupport(), osConfig.GetTransparentDefrag())
+			},
+		},
+		{
+			Name:        "ulimits",
+			Description: "the containerd service has the configured limits",
+			Default:     true,
+			Run: func(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+				ulimitConfig := cfg.GetCustomLinuxOsConfig().GetUlimitConfig()
+				if ulimitConfig == nil {
+					return skip("containerd ulimits are not configured")
+				}
+				target, err := containerdSysctlTarget(ctx, node)
+				if err != nil {
+					return err
+				}
+				result, err := sysctlpolicy.Embedded().Evaluate(target, nil, parser.UlimitValues(ulimitConfig))
+				if err != nil {
+					logf(ctx, "ulimits rejected by the sysctl policy are not rendered and not checked: %s", err)
+				}
+				if len(result.Ulimits) == 0 {
+					return skip("no containerd ulimit is rendered")
+				}
+				return Ulimits(ctx, node, result.Ulimits)
+			},
+		},
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+	return checks
+}
+
+// containerdSysctlTarget returns the sysctl policy target of the node from its os-release and containerd version.
+func containerdSysctlTarget(ctx context.Context, node nodeexec.NodeExecutor) (sysctlpolicy.Target, error) {
+	osRelease, err := execExitCode(ctx, node, "cat /etc/os-release", 0, "could not read /etc/os-release")
+	if err != nil {
+		return sysctlpolicy.Target{}, fmt.Errorf("read os-release: %w", err)
+	}
+	result, err := execExitCode(ctx, node, "containerd --version", 0, "could not read the containerd version")
+	if err != nil {
+		return sysctlpolicy.Target{}, fmt.Errorf("read containerd version: %w", err)
+	}
+	// containerd github.com/containerd/containerd/v2 v2.0.4 1a43cb6a1035441f9aca8f5666a9b3ef9e70ab20
+	fields := strings.Fields(result.Stdout)
+	if len(fields) < 3 {
+		return sysctlpolicy.Target{}, fmt.Errorf("unexpected containerd version %q", result.Stdout)
+	}
+	return parser.SysctlTarget(osRelease.Stdout, strings.TrimPrefix(fields[2], "v")), nil
+}
+
+// checkSysctls checks the sysctls rendered by aks-node-controller, with the defaults set on every node.
+func checkSysctls(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration) error {
+	sysctls, err := parser.SysctlValues(cfg.GetCustomLinuxOsConfig().GetSysctlConfig())
+	if err != nil {
+		return err
+	}
+	result, err := sysctlpolicy.Embedded().Evaluate(sysctlpolicy.Target{}, sysctls, nil)
+	if err != nil {
+		logf(ctx, "sysctls rejected by the sysctl policy are not rendered and not checked: %s", err)
+	}
+	return Sysctls(ctx, node, result.Sysctls)
+}
+
+// Select returns the checks with the given names, or the default checks when no name is given.
+func Select(names []string) ([]Check, error) {
+	all := Checks()
+	if len(names) == 0 {
+		var defaults []Check
+		for _, c := range all {
+			if c.Default {
+				defaults = append(defaults, c)
+			}
+		}
+		return defaults, nil
+	}
+	byName := make(map[string]Check, len(all))
+	for _, c := range all {
+		byName[c.Name] = c
+	}
+	var selected []Check
+	var unknown []string
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		selected = append(selected, c)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown checks %s, known checks are %s", strings.Join(unknown, ", "), strings.Join(checkNames(all), ", "))
+	}
+	return selected, nil
+}
+
+func checkNames(checks []Check) []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Run runs the checks in order against the node and reports their outcome. A failing check doesn't stop the
+// following ones.
+func Run(ctx context.Context, node nodeexec.NodeExecutor, cfg *aksnodeconfigv1.Configuration, checks []Check) *Report {
+	report := &Report{}
+	for _, c := range checks {
+		start := time.Now()
+		err := c.Run(ctx, node, cfg)
+		result := CheckResult{Name: c.Name, Description: c.Description, Status: StatusPassed, Duration: time.Since(start)}
+		var skipErr *SkipError
+		switch {
+		case errors.As(err, &skipErr):
+			result.Status = StatusSkipped
+			result.Message = skipErr.Reason
+		case err != nil:
+			result.Status = StatusFailed
+			result.Message = err.Error()
+		}
+		report.add(result)
+	}
+	return report
+}
@@ -0,0 +1,119 @@
+package nodevalidate
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Status      Status        `json:"status"`
+	Duration    time.Duration `json:"duration"`
+	// Message is the failure, or the reason the check was skipped.
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the outcome of the checks run against a node.
+type Report struct {
+	Passed  int           `json:"passed"`
+	Failed  int           `json:"failed"`
+	Skipped int           `json:"skipped"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+func (r *Report) add(result CheckResult) {
+	switch result.Status {
+	case StatusPassed:
+		r.Passed++
+	case StatusFailed:
+		r.Failed++
+	case StatusSkipped:
+		r.Skipped++
+	}
+	r.Checks = append(r.Checks, result)
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit test suite named suiteName, with a test case per check.
+func (r *Report) WriteJUnit(w io.Writer, suiteName string) error {
+	suite := junitTestSuite{
+		Name:     suiteName,
+		Tests:    len(r.Checks),
+		Failures: r.Failed,
+		Skipped:  r.Skipped,
+	}
+	var total time.Duration
+	for _, c := range r.Checks {
+		total += c.Duration
+		tc := junitTestCase{Name: c.Name, ClassName: suiteName, Time: junitSeconds(c.Duration)}
+		switch c.Status {
+		case StatusFailed:
+			tc.Failure = &junitMessage{Message: c.Description, Text: c.Message}
+		case StatusSkipped:
+			tc.Skipped = &junitMessage{Message: c.Message}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
+	"github.com/Azure/agentbaker/e2e/assert"
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/pkg/agent"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v8"
+)
+
+const planClusterName = "abe2e-plan"
+
+// ScenarioPlan is the outcome of rendering the bootstrapping payloads of a scenario in plan mode (PLAN_ONLY=true).
+// Sizes are in bytes, custom data sizes are the base64 payloads sent to Azure.
+type ScenarioPlan struct {
+	Name      string `json:"name"`
+	ImageName string `json:"imageName"`
+	CSESize   int    `json:"cseSize"`
+	// CustomDataSize doesn't include the aks-node-controller binary injected when scriptless compilation is enabled.
+	CustomDataSize int `json:"customDataSize"`
+	// AKSNodeConfigSize, the size of the AKSNodeConfig JSON, and AKSNodeConfigCustomDataSize are set for scenarios with
+	// an AKSNodeConfigMutator.
+	AKSNodeConfigSize           int    `json:"aksNodeConfigSize,omitempty"`
+	AKSNodeConfigCustomDataSize int    `json:"aksNodeConfigCustomDataSize,omitempty"`
+	Error                       string `json:"error,omitempty"`
+}
+
+// planScenario renders the bootstrapping payloads of a scenario against a synthetic cluster instead of running it, so
+// broken mutators are caught without creating Azure resources. The plan is logged and written to plan.json in the
+// scenario log directory. Validators are not run, and an ExpectedError is only checked when rendering fails.
+func planScenario(ctx context.Context, t testing.TB, s *Scenario) error {
+	if err := skipScenarioByTags(t, s); err != nil {
+		return err
+	}
+	if s.Runtime == nil {
+		s.Runtime = &ScenarioRuntime{}
+	}
+	s.Runtime.Cluster = syntheticCluster(s.Location)
+	s.Runtime.VMSSName = generateVMSSName(s)
+
+	plan, err := renderScenarioPlan(ctx, s)
+	if err != nil {
+		plan.Error = err.Error()
+	}
+	planJSON, marshalErr := json.MarshalIndent(plan, "", "  ")
+	if marshalErr != nil {
+		return fmt.Errorf("marshal scenario plan: %w", marshalErr)
+	}
+	if writeErr := writeToFile(t, "plan.json", string(planJSON)); writeErr != nil {
+		return fmt.Errorf("write scenario plan: %w", writeErr)
+	}
+	t.Logf("PLAN %s", planJSON)
+
+	if s.ExpectedError != "" && err != nil {
+		return assert.ErrorContains(err, s.ExpectedError)
+	}
+	return err
+}
+
+// renderScenarioPlan builds the scenario NBC and AKSNodeConfig the way prepareAKSNode does and renders them.
+// The returned plan is never nil, it holds the sizes rendered before an error.
+func renderScenarioPlan(ctx context.Context, s *Scenario) (*ScenarioPlan, error) {
+	plan := &ScenarioPlan{Name: s.T.Name(), ImageName: s.VHD.Name}
+	if err := prepareBootstrapConfig(ctx, s); err != nil {
+		return plan, err
+	}
+	if err := skipScenarioByQuery(s.T, s); err != nil {
+		return plan, err
+	}
+
+	nodeBootstrapping, err := getNodeBootstrapping(ctx, s)
+	if err != nil {
+		return plan, err
+	}
+	plan.CSESize = len(nodeBootstrapping.CSE)
+	customData := nodeBootstrapping.CustomData
+	if len(s.Config.CustomDataWriteFiles) > 0 {
+		customData, err = injectWriteFilesEntriesToCustomData(customData, s.Config.CustomDataWriteFiles)
+		if err != nil {
+			return plan, fmt.Errorf("inject customData write_files entries: %w", err)
+		}
+	}
+	plan.CustomDataSize = len(customData)
+	if plan.CustomDataSize > agent.MaxCustomDataLength {
+		return plan, fmt.Errorf("custom data is %d bytes, more than the %d bytes limit", plan.CustomDataSize, agent.MaxCustomDataLength)
+	}
+	if !config.Config.DisableScriptless && !usesScriptlessNBCCSECmd(s) && s.VHD.SupportsScriptless() {
+		if err := validateScriptlessCustomData(customData); err != nil {
+			return plan, err
+		}
+	}
+
+	if s.Runtime.AKSNodeConfig == nil {
+		return plan, nil
+	}
+	plan.AKSNodeConfigSize = len(s.Runtime.NBC.AKSNodeConfigJSON)
+	var aksNodeConfigCustomData string
+	if s.VHD.Flatcar {
+		aksNodeConfigCustomData, err = nodeconfigutils.CustomDataFlatcar(s.Runtime.AKSNodeConfig)
+	} else {
+		aksNodeConfigCustomData, err = nodeconfigutils.CustomData(s.Runtime.AKSNodeConfig)
+	}
+	if err != nil {
+		return plan, fmt.Errorf("render AKS node config custom data: %w", err)
+	}
+	plan.AKSNodeConfigCustomDataSize = len(aksNodeConfigCustomData)
+	if plan.AKSNodeConfigCustomDataSize > agent.MaxCustomDataLength {
+		return plan, fmt.Errorf("AKS node config custom data is %d bytes, more than the %d bytes limit", plan.AKSNodeConfigCustomDataSize, agent.MaxCustomDataLength)
+	}
+	return plan, nil
+}
+
+// syntheticCluster returns a kubenet Cluster with placeholder identities and credentials. It holds everything
+// getBaseNBC and the scenario mutators read, but nothing on it exists in Azure.
+func syntheticCluster(location string) *Cluster {
+	nodeResourceGroup := fmt.Sprintf("MC_%s_%s_%s", config.ResourceGroupName(location), planClusterName, location)
+	model := getKubenetClusterModel(planClusterName, location, config.Config.DefaultVMSKU)
+	model.ID = to.Ptr(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s",
+		config.Config.SubscriptionID, config.ResourceGroupName(location), planClusterName))
+	model.Properties.KubernetesVersion = to.Ptr(config.Config.PlanKubernetesVersion)
+	model.Properties.CurrentKubernetesVersion = to.Ptr(config.Config.PlanKubernetesVersion)
+	model.Properties.NodeResourceGroup = to.Ptr(nodeResourceGroup)
+	fqdn := fmt.Sprintf("%s.hcp.%s.azmk8s.io", planClusterName, location)
+	model.Properties.Fqdn = to.Ptr(fqdn)
+	return &Cluster{
+		Model: model,
+		KubeletIdentity: &armcontainerservice.UserAssignedIdentity{
+			ClientID: to.Ptr("00000000-0000-0000-0000-000000000001"),
+			ObjectID: to.Ptr("00000000-0000-0000-0000-000000000002"),
+			ResourceID: to.Ptr(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ManagedIdentity/userAssignedIdentities/%s-agentpool",
+				config.Config.SubscriptionID, nodeResourceGroup, planClusterName)),
+		},
+		SubnetID: fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s-vnet/subnets/%s",
+			config.Config.SubscriptionID, nodeResourceGroup, planClusterName, config.Config.DefaultSubnetName),
+		ClusterParams: &ClusterParams{
+			CACert:         []byte("-----BEGIN CERTIFICATE-----\nplan\n-----END CERTIFICATE-----\n"),
+			BootstrapToken: "abcdef.0123456789abcdef",
+			FQDN:           fqdn,
+		},
+		TenantID: "00000000-0000-0000-0000-000000000000",
+	}
+}
@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+)
+
+func TestRenderScenarioPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name: "bootstrap config mutator",
+			config: Config{
+				VHD: config.VHDUbuntu2204Gen2Containerd,
+				BootstrapConfigMutator: func(_ *Cluster, nbc *datamodel.NodeBootstrappingConfiguration) {
+					nbc.AgentPoolProfile.KubernetesConfig.ContainerRuntime = "containerd"
+				},
+			},
+		},
+		{
+			name: "AKS node config mutator",
+			config: Config{
+				VHD:                    config.VHDUbuntu2204Gen2Containerd,
+				BootstrapConfigMutator: EmptyBootstrapConfigMutator,
+				AKSNodeConfigMutator: func(_ *Cluster, cfg *aksnodeconfigv1.Configuration) {
+					cfg.EnabledFeatures = map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"}
+				},
+			},
+		},
+		{
+			name: "failing bootstrap config mutator",
+			config: Config{
+				VHD: config.VHDUbuntu2204Gen2Containerd,
+				BootstrapConfigMutatorWithError: func(context.Context, *Cluster, *datamodel.NodeBootstrappingConfiguration) error {
+					return errors.New("broken mutator")
+				},
+			},
+			wantErr: "broken mutator",
+		},
+		{
+			name: "invalid enabled feature",
+			config: Config{
+				VHD: config.VHDUbuntu2204Gen2Containerd,
+				BootstrapConfigMutator: func(_ *Cluster, nbc *datamodel.NodeBootstrappingConfiguration) {
+					nbc.EnabledFeatures = map[string]string{"ENABLE_PROVISIONING_HOTFX": "true"}
+				},
+				AKSNodeConfigMutator: func(*Cluster, *aksnodeconfigv1.Configuration) {},
+			},
+			wantErr: "did you mean ENABLE_PROVISIONING_HOTFIX?",
+		},
+		{
+			name: "enabled feature unknown to the aks-node-controller of a pinned VHD",
+			config: Config{
+				VHD: pinnedVHD(config.VHDUbuntu2204Gen2Containerd, "202512.06.0"),
+				AKSNodeConfigMutator: func(_ *Cluster, cfg *aksnodeconfigv1.Configuration) {
+					cfg.EnabledFeatures = map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"}
+				},
+			},
+			wantErr: "feature ENABLE_PROVISIONING_HOTFIX requires aks-node-controller 202604.01.0 or later",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scenario{
+				T:       t,
+				Config:  tt.config,
+				Runtime: &ScenarioRuntime{Cluster: syntheticCluster("westus3")},
+			}
+			plan, err := renderScenarioPlan(context.Background(), s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderScenarioPlan() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderScenarioPlan() error = %v", err)
+			}
+			if plan.CSESize == 0 || plan.CustomDataSize == 0 {
+				t.Fatalf("renderScenarioPlan() = %+v, want CSE and custom data", plan)
+			}
+			if (tt.config.AKSNodeConfigMutator != nil) != (plan.AKSNodeConfigSize > 0 && plan.AKSNodeConfigCustomDataSize > 0) {
+				t.Fatalf("renderScenarioPlan() = %+v, want AKS node config sizes only with an AKSNodeConfigMutator", plan)
+			}
+		})
+	}
+}
+
+// pinnedVHD returns a copy of the VHD pinned to the given release version.
+func pinnedVHD(vhd *config.Image, version string) *config.Image {
+	pinned := *vhd
+	pinned.Version = version
+	return &pinned
+}
@@ -56,7 +56,7 @@ func Test_Ubuntu2204_NvidiaDevicePlugin_Daemonset(t *testing.T) {
 				// systemd-based plugin is confirmed inactive, so gate the deployment on both.
 				if err := errors.Join(
 					// First, validate that GPU drivers are installed
-					ValidateNvidiaModProbeInstalled(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
 					// Verify that the systemd-based device plugin is NOT running
 					// (managed GPU experience is not enabled, so the service should not be active)
 					validateNvidiaDevicePluginServiceNotRunning(ctx, s),
@@ -50,7 +50,7 @@ func validateDCGMPackageVersions(ctx context.Context, s *Scenario, os, osVersion
 			errs = append(errs, err)
 			continue
 		}
-		errs = append(errs, ValidateInstalledPackageVersion(ctx, s, packageName, version))
+		errs = append(errs, ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, packageName, version))
 	}
 	return errors.Join(errs...)
 }
@@ -60,7 +60,7 @@ func validateDCGMPackageVersions(ctx context.Context, s *Scenario, os, osVersion
 // checks deliberately break a service and then repair it - so the sequence stops at
 // the first failure instead of injecting more faults onto an already broken node.
 func validateNPDNvidiaConditions(ctx context.Context, s *Scenario) error {
-	if err := ValidateNPDUnhealthyNvidiaDevicePlugin(ctx, s); err != nil {
+	if err := ValidateNPDUnhealthyNvidiaDevicePlugin(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 	if err := ValidateNPDUnhealthyNvidiaDevicePluginCondition(ctx, s); err != nil {
@@ -69,7 +69,7 @@ func validateNPDNvidiaConditions(ctx context.Context, s *Scenario) error {
 	if err := ValidateNPDUnhealthyNvidiaDevicePluginAfterFailure(ctx, s); err != nil {
 		return err
 	}
-	if err := ValidateNPDUnhealthyNvidiaDCGMServices(ctx, s); err != nil {
+	if err := ValidateNPDUnhealthyNvidiaDCGMServices(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 	if err := ValidateNPDUnhealthyNvidiaDCGMServicesCondition(ctx, s); err != nil {
@@ -90,15 +90,15 @@ func validateNPDNvidiaGridLicense(ctx context.Context, s *Scenario) error {
 // validateDCGMExporterRunning checks the DCGM exporter service is up, scrapable and
 // advertised through the node label.
 func validateDCGMExporterRunning(ctx context.Context, s *Scenario, metric string) error {
-	if err := ValidateNvidiaDCGMExporterSystemDServiceRunning(ctx, s); err != nil {
+	if err := ValidateNvidiaDCGMExporterSystemDServiceRunning(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 	// Scraping only makes sense once the exporter endpoint answers.
-	if err := ValidateNvidiaDCGMExporterIsScrapable(ctx, s); err != nil {
+	if err := ValidateNvidiaDCGMExporterIsScrapable(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 	return errors.Join(
-		ValidateNvidiaDCGMExporterScrapeCommonMetric(ctx, s, metric),
+		ValidateNvidiaDCGMExporterScrapeCommonMetric(ctx, s.NodeExecutor(), metric),
 		ValidateNodeHasLabel(ctx, s, "kubernetes.azure.com/dcgm-exporter", "enabled"),
 	)
 }
@@ -437,9 +437,9 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
@@ -464,12 +464,12 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning(t *testing.T) {
 
 				// Let's run the NPD validation tests to verify that the nvidia
 				// device plugin & DCGM services are reporting status correctly
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				// Restart NPD to ensure it picks up the managed GPU experience marker file,
 				// which may have been created after NPD's initial startup during provisioning.
-				if err := RestartNodeProblemDetector(ctx, s); err != nil {
+				if err := RestartNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				if err := validateNPDNvidiaConditions(ctx, s); err != nil {
@@ -523,9 +523,9 @@ func Test_Ubuntu2204_NvidiaDevicePluginRunning(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
@@ -550,12 +550,12 @@ func Test_Ubuntu2204_NvidiaDevicePluginRunning(t *testing.T) {
 
 				// Let's run the NPD validation tests to verify that the nvidia
 				// device plugin & DCGM services are reporting status correctly
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				// Restart NPD to ensure it picks up the managed GPU experience marker file,
 				// which may have been created after NPD's initial startup during provisioning.
-				if err := RestartNodeProblemDetector(ctx, s); err != nil {
+				if err := RestartNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				if err := validateNPDNvidiaConditions(ctx, s); err != nil {
@@ -610,9 +610,9 @@ func Test_AzureLinux3_NvidiaDevicePluginRunning(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
@@ -637,12 +637,12 @@ func Test_AzureLinux3_NvidiaDevicePluginRunning(t *testing.T) {
 
 				// Let's run the NPD validation tests to verify that the nvidia
 				// device plugin & DCGM services are reporting status correctly
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				// Restart NPD to ensure it picks up the managed GPU experience marker file,
 				// which may have been created after NPD's initial startup during provisioning.
-				if err := RestartNodeProblemDetector(ctx, s); err != nil {
+				if err := RestartNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return validateNPDNvidiaConditions(ctx, s)
@@ -692,16 +692,16 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning_MIG(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
-				if err := ValidateMIGModeEnabled(ctx, s, 1); err != nil {
+				if err := ValidateMIGModeEnabled(ctx, s.NodeExecutor(), 1); err != nil {
 					return err
 				}
-				if err := ValidateMIGInstancesCreated(ctx, s, "MIG 2g.20gb", 3); err != nil {
+				if err := ValidateMIGInstancesCreated(ctx, s.NodeExecutor(), "MIG 2g.20gb", 3); err != nil {
 					return err
 				}
 				if err := ValidateNodeAdvertisesGPUResources(ctx, s, 3, "nvidia.com/gpu"); err != nil {
@@ -724,7 +724,7 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning_MIG(t *testing.T) {
 
 				// Let's run the NPD validation tests to verify that the nvidia
 				// device plugin & DCGM services are reporting status correctly
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return validateNPDNvidiaConditions(ctx, s)
@@ -776,15 +776,15 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning_MIG_MultiGPU(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
-				if err := ValidateMIGModeEnabled(ctx, s, gpuCount); err != nil {
+				if err := ValidateMIGModeEnabled(ctx, s.NodeExecutor(), gpuCount); err != nil {
 					return err
 				}
-				if err := ValidateMIGInstancesCreated(ctx, s, "MIG 2g.20gb", totalMIGInstances); err != nil {
+				if err := ValidateMIGInstancesCreated(ctx, s.NodeExecutor(), "MIG 2g.20gb", totalMIGInstances); err != nil {
 					return err
 				}
 				if err := ValidateNodeAdvertisesGPUResources(ctx, s, totalMIGInstances, "nvidia.com/gpu"); err != nil {
@@ -837,9 +837,9 @@ func Test_Ubuntu2204_NvidiaDevicePluginRunning_WithoutVMSSTag(t *testing.T) {
 					return err
 				}
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
@@ -864,12 +864,12 @@ func Test_Ubuntu2204_NvidiaDevicePluginRunning_WithoutVMSSTag(t *testing.T) {
 
 				// Let's run the NPD validation tests to verify that the nvidia
 				// device plugin & DCGM services are reporting status correctly
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				// Restart NPD to ensure it picks up the managed GPU experience marker file,
 				// which may have been created after NPD's initial startup during provisioning.
-				if err := RestartNodeProblemDetector(ctx, s); err != nil {
+				if err := RestartNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				if err := validateNPDNvidiaConditions(ctx, s); err != nil {
@@ -985,16 +985,16 @@ func Test_Ubuntu2404_NvidiaDevicePluginRunning_MIG_Mixed(t *testing.T) {
 				}
 				migResourceName := "nvidia.com/mig-1g.10gb"
 				if err := errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "nvidia-device-plugin", devicePluginVersion),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "nvidia-device-plugin", devicePluginVersion),
 					// Validate that the NVIDIA device plugin systemd service is running
-					ValidateNvidiaDevicePluginServiceRunning(ctx, s),
+					ValidateNvidiaDevicePluginServiceRunning(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
-				if err := ValidateMIGModeEnabled(ctx, s, 1); err != nil {
+				if err := ValidateMIGModeEnabled(ctx, s.NodeExecutor(), 1); err != nil {
 					return err
 				}
-				if err := ValidateMIGInstancesCreated(ctx, s, "MIG 1g.10gb", 7); err != nil {
+				if err := ValidateMIGInstancesCreated(ctx, s.NodeExecutor(), "MIG 1g.10gb", 7); err != nil {
 					return err
 				}
 				if err := ValidateNodeAdvertisesGPUResources(ctx, s, 7, migResourceName); err != nil {
@@ -1040,13 +1040,13 @@ func Test_Ubuntu2404_DraDriverNvidiaGpuRunning(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				if err := errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
-				if err := ValidateDraDriverNvidiaGpuServiceRunning(ctx, s); err != nil {
+				if err := ValidateDraDriverNvidiaGpuServiceRunning(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return ValidateDRAWorkloadSchedulable(ctx, s)
@@ -1090,13 +1090,13 @@ func Test_Ubuntu2404_DraDriverNvidiaGpuRunning_AKSNodeController(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				if err := errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
-				if err := ValidateDraDriverNvidiaGpuServiceRunning(ctx, s); err != nil {
+				if err := ValidateDraDriverNvidiaGpuServiceRunning(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return ValidateDRAWorkloadSchedulable(ctx, s)
@@ -14,5 +14,9 @@ func TestMain(m *testing.M) {
 	if _, err := os.Stat("scenario-logs"); err == nil {
 		_ = os.RemoveAll("scenario-logs")
 	}
-	m.Run()
+	code := m.Run()
+	if err := writeScenarioReport(config.Config.E2ELoggingDir); err != nil {
+		stdlog.Printf("failed to write the scenario report: %v", err)
+	}
+	os.Exit(code)
 }
@@ -0,0 +1,169 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/scenarioquery"
+)
+
+// ScenarioListFileName is the file the attributes of a scenario are written to in list mode (LIST_SCENARIOS=true).
+const ScenarioListFileName = "scenario.json"
+
+// Scenario attributes SCENARIO_QUERY can use besides the tags, which are named after the Tags fields.
+const (
+	attributeDistro     = "distro"
+	attributeOSVersion  = "osversion"
+	attributeLocation   = "location"
+	attributeK8sVersion = "k8sversion"
+	attributeVMSize     = "vmsize"
+	attributeVMFamily   = "vmfamily"
+)
+
+// scenarioQueryAttributes returns the names of the attributes SCENARIO_QUERY can use, sorted.
+func scenarioQueryAttributes() []string {
+	attributes := []string{attributeDistro, attributeOSVersion, attributeLocation, attributeK8sVersion, attributeVMSize, attributeVMFamily}
+	for name := range (Tags{}).Map() {
+		attributes = append(attributes, strings.ToLower(name))
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+// skipScenarioByQuery skips the scenario when it doesn't match SCENARIO_QUERY. It runs once the tags are set, when the
+// Kubernetes version and the VM size of some scenarios aren't known, and again once the bootstrap config is prepared.
+// Scenarios for which the query is still unknown then are skipped.
+func skipScenarioByQuery(t testing.TB, s *Scenario) error {
+	if config.Config.ScenarioQuery == "" {
+		return nil
+	}
+	query, err := scenarioquery.Parse(config.Config.ScenarioQuery)
+	if err != nil {
+		return err
+	}
+	if err := query.Validate(scenarioQueryAttributes()); err != nil {
+		return err
+	}
+	attributes := scenarioAttributes(s, query.Attributes())
+	switch query.Eval(attributes) {
+	case scenarioquery.False:
+		t.Skipf("skipping scenario %q: scenario attributes %v do not match query %q", t.Name(), attributes, query)
+	case scenarioquery.Unknown:
+		if s.Runtime != nil && s.Runtime.NBC != nil {
+			t.Skipf("skipping scenario %q: query %q cannot be evaluated with scenario attributes %v", t.Name(), query, attributes)
+		}
+	}
+	return nil
+}
+
+// scenarioAttributes returns the scenario attributes a query is evaluated against, keyed by lower-cased name.
+// Attributes which aren't known yet are missing. The VM size is only resolved when needed is nil or has it, since it
+// runs the scenario VM mutator.
+func scenarioAttributes(s *Scenario, needed []string) map[string]string {
+	attributes := map[string]string{}
+	for name, value := range s.Tags.Map() {
+		attributes[strings.ToLower(name)] = value
+	}
+	attributes[attributeDistro] = string(s.VHD.Distro)
+	attributes[attributeOSVersion] = osVersion(string(s.VHD.Distro))
+	attributes[attributeLocation] = s.Location
+	if version := s.GetK8sVersion(); version != "" {
+		attributes[attributeK8sVersion] = version
+	}
+	if needed == nil || slices.Contains(needed, attributeVMSize) || slices.Contains(needed, attributeVMFamily) {
+		if size, ok := scenarioVMSize(s); ok {
+			attributes[attributeVMSize] = size
+			attributes[attributeVMFamily] = vmFamily(size)
+		}
+	}
+	return attributes
+}
+
+var (
+	osVersionRegex = regexp.MustCompile(`-v?(\d+(?:\.\d+)?)(?:-|$)`)
+	vmFamilyRegex  = regexp.MustCompile(`^(?i:standard_)?([A-Za-z]+)`)
+)
+
+// osVersion returns the OS version in a distro name, "24.04" for aks-ubuntu-containerd-24.04-gen2, "3" for
+// aks-azurelinux-v3-gen2 and "2022" for aks-windows-2022-containerd, or "" when it has none.
+func osVersion(distro string) string {
+	if m := osVersionRegex.FindStringSubmatch(distro); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// vmFamily returns the upper-cased letters a VM size starts with, "NC" for Standard_NC24ads_A100_v4.
+func vmFamily(size string) string {
+	if m := vmFamilyRegex.FindStringSubmatch(size); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// scenarioVMSize returns the VM size the scenario creates its VMSS with. The VM mutator is run on the base VMSS model of
+// a synthetic cluster, a mutator failing on it leaves the size unknown. VMConfigMutatorWithError needs Azure, so for the
+// scenarios using it the size is the agent pool VM size of the bootstrap config once it is prepared.
+func scenarioVMSize(s *Scenario) (size string, ok bool) {
+	if s.Config.VMConfigMutatorWithError != nil {
+		if s.Runtime == nil || s.Runtime.NBC == nil || s.Runtime.NBC.AgentPoolProfile == nil || s.Runtime.NBC.AgentPoolProfile.VMSize == "" {
+			return "", false
+		}
+		return s.Runtime.NBC.AgentPoolProfile.VMSize, true
+	}
+	if s.Config.VMConfigMutator == nil {
+		return config.Config.DefaultVMSKU, true
+	}
+
+	defer func() {
+		if recover() != nil {
+			size, ok = "", false
+		}
+	}()
+	synthetic := copyScenario(s)
+	synthetic.Runtime = &ScenarioRuntime{Cluster: syntheticCluster(s.Location), VMSSName: "query"}
+	// the CSE command only has to be set for the model to have its CSE extension, as the Windows model expects.
+	model := getBaseVMSSModel(synthetic, "", "true")
+	s.Config.VMConfigMutator(&model)
+	if model.SKU == nil || model.SKU.Name == nil {
+		return "", false
+	}
+	return *model.SKU.Name, true
+}
+
+// listScenario writes the attributes of a scenario matching TAGS_TO_RUN, TAGS_TO_SKIP and SCENARIO_QUERY instead of
+// running it. The bootstrap config is prepared against the synthetic cluster of plan mode, so the Kubernetes version is
+// PLAN_KUBERNETES_VERSION unless the scenario sets its own.
+func listScenario(ctx context.Context, t testing.TB, s *Scenario) error {
+	if err := skipScenarioByTags(t, s); err != nil {
+		return err
+	}
+	if s.Runtime == nil {
+		s.Runtime = &ScenarioRuntime{}
+	}
+	s.Runtime.Cluster = syntheticCluster(s.Location)
+	s.Runtime.VMSSName = generateVMSSName(s)
+	if err := prepareBootstrapConfig(ctx, s); err != nil {
+		return err
+	}
+	if err := skipScenarioByQuery(t, s); err != nil {
+		return err
+	}
+
+	attributes, err := json.Marshal(scenarioAttributes(s, nil))
+	if err != nil {
+		return fmt.Errorf("marshal scenario attributes: %w", err)
+	}
+	if err := writeToFile(t, ScenarioListFileName, string(attributes)); err != nil {
+		return fmt.Errorf("write scenario attributes: %w", err)
+	}
+	t.Logf("SCENARIO %s", attributes)
+	return nil
+}
@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+)
+
+func TestOSVersion(t *testing.T) {
+	for distro, want := range map[datamodel.Distro]string{
+		datamodel.AKSUbuntuContainerd2404Gen2:        "24.04",
+		datamodel.AKSUbuntuFipsContainerd2204TLGen2:  "22.04",
+		datamodel.AKSAzureLinuxV3Gen2:                "3",
+		datamodel.AKSCBLMarinerV2Gen2:                "2",
+		datamodel.AKSWindows2022ContainerdGen2:       "2022",
+		datamodel.AKSACLGen2TL:                       "",
+		datamodel.AKSUbuntuMinimalContainerd2604Gen2: "26.04",
+	} {
+		if got := osVersion(string(distro)); got != want {
+			t.Errorf("osVersion(%q) = %q, want %q", distro, got, want)
+		}
+	}
+}
+
+func TestVMFamily(t *testing.T) {
+	for size, want := range map[string]string{
+		"Standard_NC24ads_A100_v4": "NC",
+		"Standard_D2ds_v5":         "D",
+		"standard_nd96asr_v4":      "ND",
+		"":                         "",
+	} {
+		if got := vmFamily(size); got != want {
+			t.Errorf("vmFamily(%q) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestScenarioAttributes(t *testing.T) {
+	s := &Scenario{
+		Location: "westus3",
+		Tags:     Tags{Name: "Test_Ubuntu2404_GPU", OS: "ubuntu", GPU: true},
+		Config: Config{
+			VHD: config.VHDUbuntu2404Gen2Containerd,
+			VMConfigMutator: func(vmss *armcompute.VirtualMachineScaleSet) {
+				vmss.SKU.Name = to.Ptr("Standard_NC24ads_A100_v4")
+			},
+		},
+	}
+	attributes := scenarioAttributes(s, nil)
+	for name, want := range map[string]string{
+		"name":      "Test_Ubuntu2404_GPU",
+		"os":        "ubuntu",
+		"gpu":       "true",
+		"wasm":      "false",
+		"osversion": "24.04",
+		"location":  "westus3",
+		"vmsize":    "Standard_NC24ads_A100_v4",
+		"vmfamily":  "NC",
+	} {
+		if attributes[name] != want {
+			t.Errorf("attribute %s = %q, want %q", name, attributes[name], want)
+		}
+	}
+	if _, ok := attributes["k8sversion"]; ok {
+		t.Errorf("k8sversion is known before the bootstrap config is prepared")
+	}
+
+	if _, ok := scenarioAttributes(s, []string{"os"})["vmsize"]; ok {
+		t.Errorf("vmsize is resolved while the query doesn't use it")
+	}
+
+	s.Config.VMConfigMutator = func(vmss *armcompute.VirtualMachineScaleSet) {
+		vmss.Properties.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations[3].Name = to.Ptr("missing")
+	}
+	if _, ok := scenarioVMSize(s); ok {
+		t.Errorf("the VM size of a panicking VM mutator is known")
+	}
+}
+
+func TestScenarioQueryAttributes(t *testing.T) {
+	attributes := map[string]bool{}
+	for _, a := range scenarioQueryAttributes() {
+		attributes[a] = true
+	}
+	for _, a := range []string{"name", "gpu", "imagename", "osversion", "k8sversion", "vmfamily"} {
+		if !attributes[a] {
+			t.Errorf("scenario query attributes miss %s", a)
+		}
+	}
+}
@@ -323,7 +323,7 @@ func Test_RCV1P_Ubuntu2204(t *testing.T) {
 			VHD:             config.VHDUbuntu2204Gen2Containerd,
 			VMConfigMutator: rcv1pVMConfigMutator(),
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PCertMode(ctx, s)
+				return ValidateRCV1PCertMode(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -344,7 +344,7 @@ func Test_RCV1P_Ubuntu2604Minimal(t *testing.T) {
 			VHD:             config.VHDUbuntu2604MinimalGen2Containerd,
 			VMConfigMutator: rcv1pVMConfigMutator(),
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PCertMode(ctx, s)
+				return ValidateRCV1PCertMode(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -365,7 +365,7 @@ func Test_RCV1P_Ubuntu2404(t *testing.T) {
 			VHD:             config.VHDUbuntu2404Gen2Containerd,
 			VMConfigMutator: rcv1pVMConfigMutator(),
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PCertMode(ctx, s)
+				return ValidateRCV1PCertMode(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -386,7 +386,7 @@ func Test_RCV1P_AzureLinuxV3(t *testing.T) {
 			VHD:             config.VHDAzureLinuxV3Gen2,
 			VMConfigMutator: rcv1pVMConfigMutator(),
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PCertMode(ctx, s)
+				return ValidateRCV1PCertMode(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -412,7 +412,7 @@ func Test_RCV1P_ACL(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PCertMode(ctx, s)
+				return ValidateRCV1PCertMode(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -436,7 +436,7 @@ func Test_RCV1P_NotOptedIn(t *testing.T) {
 			Cluster: ClusterKubenet,
 			VHD:     config.VHDUbuntu2204Gen2Containerd,
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateRCV1PNotOptedIn(ctx, s)
+				return ValidateRCV1PNotOptedIn(ctx, s.NodeExecutor(), s.VHD)
 			},
 		},
 	})
@@ -0,0 +1,237 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/errorclass"
+	"github.com/Azure/agentbaker/e2e/scenarioreport"
+)
+
+// scenarioRecorder builds the record of a scenario run. Its methods are no-ops on a nil recorder, so helpers shared
+// with plan mode don't have to check whether the scenario is recorded.
+type scenarioRecorder struct {
+	mu     sync.Mutex
+	record scenarioreport.Record
+	// retries are the retried attempts of the phase in progress.
+	retries []scenarioreport.Retry
+}
+
+func newScenarioRecorder(t testing.TB, s *Scenario) *scenarioRecorder {
+	r := &scenarioRecorder{record: scenarioreport.Record{
+		Name:        t.Name(),
+		Description: s.Description,
+		Location:    s.Location,
+		Start:       time.Now(),
+	}}
+	if s.VHD != nil {
+		r.record.VHD = scenarioreport.VHD{
+			Name:    s.VHD.Name,
+			Distro:  string(s.VHD.Distro),
+			OS:      string(s.VHD.OS),
+			Arch:    s.VHD.Arch,
+			Version: s.VHD.Version,
+		}
+	}
+	return r
+}
+
+// phase records the start of a scenario phase, the returned function records its end, with the classification of its
+// error and the attempts which were retried meanwhile.
+func (r *scenarioRecorder) phase(name string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		if r == nil {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		step := r.classifiedStep(name, name, start, err)
+		step.Retries, r.retries = r.retries, nil
+		r.record.Phases = append(r.record.Phases, step)
+	}
+}
+
+// classifiedStep returns the step of a phase or validator, with the classification of its error in phase. r.mu must be
+// held.
+func (r *scenarioRecorder) classifiedStep(name, phase string, start time.Time, err error) scenarioreport.Step {
+	step := scenarioreport.NewStep(name, start, err)
+	if err != nil {
+		c := errorclass.Default.Classify(errorclass.Failure{Phase: phase, Err: err, CSEExitCode: r.record.CSEExitCode})
+		step.Class, step.Rule = c.Class, c.Rule
+	}
+	return step
+}
+
+type scenarioRecorderKey struct{}
+
+// contextWithScenarioRecorder passes the recorder to the shared helpers of a phase which don't take the scenario, such
+// as the cached cluster creation.
+func contextWithScenarioRecorder(ctx context.Context, r *scenarioRecorder) context.Context {
+	return context.WithValue(ctx, scenarioRecorderKey{}, r)
+}
+
+// scenarioRecorderFromContext returns the recorder passed with contextWithScenarioRecorder, nil when there is none.
+func scenarioRecorderFromContext(ctx context.Context) *scenarioRecorder {
+	r, _ := ctx.Value(scenarioRecorderKey{}).(*scenarioRecorder)
+	return r
+}
+
+// retried records an attempt of the phase in progress which failed and was retried.
+func (r *scenarioRecorder) retried(a errorclass.Attempt) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries = append(r.retries, scenarioreport.Retry{Class: a.Class, Rule: a.Rule, Error: a.Err.Error()})
+}
+
+func (r *scenarioRecorder) setCSEExitCode(code string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.CSEExitCode = code
+}
+
+func (r *scenarioRecorder) setVHDResourceID(id config.VHDResourceID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.VHD.ResourceID = string(id)
+}
+
+func (r *scenarioRecorder) setError(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.Error = err.Error()
+}
+
+// finish completes the record once the scenario and its cleanups are done, and writes it to the scenario log directory.
+func (r *scenarioRecorder) finish(t testing.TB, s *Scenario) {
+	r.mu.Lock()
+	record := r.record
+	r.mu.Unlock()
+
+	record.Duration = time.Since(record.Start)
+	record.Tags = s.Tags.Map()
+	switch {
+	case t.Skipped():
+		record.Status = scenarioreport.StatusSkipped
+	case t.Failed():
+		record.Status = scenarioreport.StatusFailed
+		record.Class = failureClass(record)
+	default:
+		record.Status = scenarioreport.StatusPassed
+	}
+	if s.Runtime != nil && s.Runtime.VM != nil && s.Runtime.VM.VMSS != nil && s.Runtime.VM.VMSS.SKU != nil && s.Runtime.VM.VMSS.SKU.Name != nil {
+		record.VMSize = *s.Runtime.VM.VMSS.SKU.Name
+	}
+	// scenarios skipped by their tags or a missing VHD didn't start, they aren't reported.
+	if record.Status == scenarioreport.StatusSkipped && len(record.Phases) == 0 {
+		return
+	}
+
+	dir := testDir(t)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Logf("failed to write the scenario record: %v", err)
+		return
+	}
+	artifacts, err := scenarioreport.Artifacts(dir)
+	if err != nil {
+		t.Logf("failed to list the scenario artifacts: %v", err)
+	}
+	record.Artifacts = artifacts
+	if err := record.WriteFile(filepath.Join(dir, scenarioreport.RecordFileName)); err != nil {
+		t.Logf("failed to write the scenario record: %v", err)
+	}
+}
+
+// failureClass returns the class of the first failed phase or validator of a failed scenario. Scenarios failing
+// elsewhere, such as in a cleanup, are unclassified.
+func failureClass(record scenarioreport.Record) errorclass.Class {
+	for _, steps := range [][]scenarioreport.Step{record.Phases, record.Validators} {
+		for _, step := range steps {
+			if step.Status == scenarioreport.StatusFailed {
+				return step.Class
+			}
+		}
+	}
+	return errorclass.Unclassified
+}
+
+// validate runs a validator and records its outcome and duration in the scenario record under name.
+func validate(ctx context.Context, s *Scenario, name string, validator func(context.Context, *Scenario) error) error {
+	start := time.Now()
+	err := validator(ctx, s)
+	if r := s.record; r != nil {
+		r.mu.Lock()
+		r.record.Validators = append(r.record.Validators, r.classifiedStep(name, errorclass.PhaseValidation, start, err))
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// scenarioReportFileName is the JUnit report of the scenario records, written in the logging directory.
+const scenarioReportFileName = "scenarios-junit.xml"
+
+// writeScenarioReport writes the records found in the logging directory as a JUnit report. The records are read back
+// from the scenario log directories rather than kept in memory, so the report of a run with reruns of failed tests
+// covers the scenarios of every rerun, with the outcome of their last attempt. Nothing is written when no scenario
+// ran, such as in plan mode, where the logging directory is not created.
+func writeScenarioReport(dir string) error {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	records, err := scenarioreport.LoadRecords(dir)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	f, err := os.Create(filepath.Join(dir, scenarioReportFileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := scenarioreport.WriteJUnit(f, "e2e", records); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Map returns the tags by field name, as they are matched by TAGS_TO_RUN and TAGS_TO_SKIP.
+func (t Tags) Map() map[string]string {
+	v := reflect.ValueOf(t)
+	tags := make(map[string]string, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			tags[v.Type().Field(i).Name] = field.String()
+		case reflect.Bool:
+			tags[v.Type().Field(i).Name] = strconv.FormatBool(field.Bool())
+		default:
+			tags[v.Type().Field(i).Name] = fmt.Sprint(field.Interface())
+		}
+	}
+	return tags
+}
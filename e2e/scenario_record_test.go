@@ -0,0 +1,31 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/agentbaker/e2e/scenarioreport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteScenarioReport(t *testing.T) {
+	t.Run("skips the report when no scenario ran", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "scenario-logs")
+		require.NoError(t, writeScenarioReport(dir))
+		require.NoDirExists(t, dir)
+	})
+
+	t.Run("writes the records of the scenarios", func(t *testing.T) {
+		dir := t.TempDir()
+		scenarioDir := filepath.Join(dir, "Test_Ubuntu2204")
+		require.NoError(t, os.MkdirAll(scenarioDir, 0755))
+		record := &scenarioreport.Record{Name: "Test_Ubuntu2204", Status: scenarioreport.StatusPassed}
+		require.NoError(t, record.WriteFile(filepath.Join(scenarioDir, scenarioreport.RecordFileName)))
+
+		require.NoError(t, writeScenarioReport(dir))
+		report, err := os.ReadFile(filepath.Join(dir, scenarioReportFileName))
+		require.NoError(t, err)
+		require.Contains(t, string(report), "Test_Ubuntu2204")
+	})
+}
@@ -28,7 +28,7 @@ func Test_AzureLinux3OSGuard(t *testing.T) {
 				nbc.AgentPoolProfile.LocalDNSProfile = nil
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateFIPSProvider(ctx, s)
+				return ValidateFIPSProvider(ctx, s.NodeExecutor())
 			},
 			VMConfigMutator: func(vmss *armcompute.VirtualMachineScaleSet) {
 				vmss.Properties = addTrustedLaunchToVMSS(vmss.Properties)
@@ -121,7 +121,7 @@ func Test_ACL_CustomCA(t *testing.T) {
 					ValidateFileHasContent(ctx, s, "/etc/os-release", "VARIANT_ID=azurecontainerlinux"),
 					ValidateFileExists(ctx, s, "/etc/ssl/certs/ca-certificates.crt"),
 					// ACL uses Azure Linux CA trust paths under /etc (read-only /usr via dm-verity)
-					ValidateNonEmptyDirectory(ctx, s, "/etc/pki/ca-trust/source/anchors"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/pki/ca-trust/source/anchors"),
 				)
 			},
 		},
@@ -173,8 +173,8 @@ func Test_ACLGen2FIPSTL(t *testing.T) {
 				return errors.Join(
 					ValidateFileHasContent(ctx, s, "/etc/os-release", "ID=azurelinux"),
 					ValidateFileHasContent(ctx, s, "/etc/os-release", "VARIANT_ID=azurecontainerlinux"),
-					ValidateACLFIPSEnabled(ctx, s),
-					ValidateFIPSProvider(ctx, s),
+					ValidateACLFIPSEnabled(ctx, s.NodeExecutor()),
+					ValidateFIPSProvider(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -197,7 +197,7 @@ func Test_AzureLinuxV3Gen2FIPS(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateFIPSProvider(ctx, s)
+				return ValidateFIPSProvider(ctx, s.NodeExecutor())
 			},
 		},
 	})
@@ -220,7 +220,7 @@ func Test_ACL_AzureCNI(t *testing.T) {
 				config.NetworkConfig.NetworkPlugin = aksnodeconfigv1.NetworkPlugin_NETWORK_PLUGIN_AZURE
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				if err := ServiceCanRestartValidator(ctx, s, "chronyd", 10); err != nil {
+				if err := ServiceCanRestartValidator(ctx, s.NodeExecutor(), "chronyd", 10); err != nil {
 					return err
 				}
 				return errors.Join(
@@ -315,8 +315,8 @@ func runScenarioACLGPU(t *testing.T, vmSize string, location string) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateNvidiaPersistencedRunning(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaPersistencedRunning(ctx, s.NodeExecutor()),
 					ValidateScriptlessCSECmd(ctx, s),
 				)
 			},
@@ -345,9 +345,9 @@ func runScenarioACLGRID(t *testing.T, vmSize string) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateNvidiaGRIDLicenseValid(ctx, s),
-					ValidateNvidiaPersistencedRunning(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaGRIDLicenseValid(ctx, s.NodeExecutor()),
+					ValidateNvidiaPersistencedRunning(ctx, s.NodeExecutor()),
 					ValidateScriptlessCSECmd(ctx, s),
 				)
 			},
@@ -424,14 +424,15 @@ func Test_AzureLinuxV3(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				if err := ServiceCanRestartValidator(ctx, s, "chronyd", 10); err != nil {
+				if err := ServiceCanRestartValidator(ctx, s.NodeExecutor(), "chronyd", 10); err != nil {
 					return err
 				}
 				return errors.Join(
-					ValidateAppArmorBasic(ctx, s),
+					ValidateAppArmorBasic(ctx, s.NodeExecutor()),
 					ValidateFileHasContent(ctx, s, kubeletConfigFilePath, `"seccompDefault": true`),
-					ValidateKubeletHasFlags(ctx, s, kubeletConfigFilePath),
-					ValidateInstalledPackageVersion(ctx, s, "containerd2", components.GetExpectedPackageVersions("containerd", "azurelinux", "v3.0")[0]),
+					ValidateKubeletHasFlags(ctx, s.NodeExecutor(), kubeletConfigFilePath),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "containerd2", components.GetExpectedPackageVersions("containerd", "azurelinux", "v3.0")[0]),
+					ValidateComponentsBillOfMaterials(ctx, s),
 				)
 			},
 		},
@@ -467,10 +468,10 @@ func Test_AzureLinuxV3Gen2Kata(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				if err := errors.Join(
-					ValidateKataContainerdConfig(ctx, s),
-					ValidateKataErofsContainerdConfig(ctx, s),
-					ValidateKataContainerdConfigDump(ctx, s),
-					ValidateKataHostReadiness(ctx, s),
+					ValidateKataContainerdConfig(ctx, s.NodeExecutor(), s.VHD),
+					ValidateKataErofsContainerdConfig(ctx, s.NodeExecutor()),
+					ValidateKataContainerdConfigDump(ctx, s.NodeExecutor()),
+					ValidateKataHostReadiness(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
@@ -502,7 +503,7 @@ func Test_AzureLinuxV3_CustomCA(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateNonEmptyDirectory(ctx, s, "/usr/share/pki/ca-trust-source/anchors")
+				return ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/usr/share/pki/ca-trust-source/anchors")
 			},
 		},
 	})
@@ -572,13 +573,13 @@ func Test_Ubuntu2204(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				if err := ServiceCanRestartValidator(ctx, s, "chronyd", 10); err != nil {
+				if err := ServiceCanRestartValidator(ctx, s.NodeExecutor(), "chronyd", 10); err != nil {
 					return err
 				}
 				return errors.Join(
 					ValidateTaints(ctx, s, s.Runtime.AKSNodeConfig.KubeletConfig.KubeletFlags["--register-with-taints"]),
-					ValidateUlimitSettings(ctx, s, customContainerdUlimits),
-					ValidateSysctlConfig(ctx, s, customSysctls),
+					ValidateUlimitSettings(ctx, s.NodeExecutor(), customContainerdUlimits),
+					ValidateSysctlConfig(ctx, s.NodeExecutor(), customSysctls),
 				)
 			},
 			AKSNodeConfigMutator: func(_ *Cluster, config *aksnodeconfigv1.Configuration) {
@@ -614,7 +615,7 @@ func Test_Ubuntu2204_CustomCA(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateNonEmptyDirectory(ctx, s, "/usr/local/share/ca-certificates/certs")
+				return ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/usr/local/share/ca-certificates/certs")
 			},
 		},
 	})
@@ -760,10 +761,10 @@ func Test_Ubuntu2204FIPS(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
-					ValidateInstalledPackageVersion(ctx, s, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
-					ValidateFIPSProvider(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
+					ValidateFIPSProvider(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -783,10 +784,10 @@ func Test_Ubuntu2004Gen2FIPS(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2004")[0]),
-					ValidateInstalledPackageVersion(ctx, s, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2004")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
-					ValidateFIPSProvider(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2004")[0]),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2004")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
+					ValidateFIPSProvider(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -809,10 +810,10 @@ func Test_Ubuntu2204Gen2FIPS(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
-					ValidateInstalledPackageVersion(ctx, s, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
-					ValidateFIPSProvider(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
+					ValidateFIPSProvider(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -836,10 +837,10 @@ func Test_Ubuntu2204Gen2FIPSTL(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
-					ValidateInstalledPackageVersion(ctx, s, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
-					ValidateFIPSProvider(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
+					ValidateFIPSProvider(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -1114,11 +1115,11 @@ func Test_Ubuntu2204_ArtifactStreaming(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1145,11 +1146,11 @@ func Test_Ubuntu2204_ArtifactStreaming_ARM64(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1170,11 +1171,11 @@ func Test_AzureLinuxV3_ArtifactStreaming(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1204,11 +1205,11 @@ func Test_Ubuntu2404_ArtifactStreaming_ARM64(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1232,11 +1233,11 @@ func Test_Ubuntu2204_ArtifactStreaming_TrustedLaunch(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1262,11 +1263,11 @@ func Test_Ubuntu2204_ArtifactStreaming_FIPS(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1304,11 +1305,11 @@ func Test_Ubuntu2204_ArtifactStreaming_NetworkIsolatedCluster(t *testing.T) {
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
 					ValidateDirectoryContent(ctx, s, "/opt/azure", []string{"outbound-check-skipped"}),
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				)
 			},
 		},
@@ -1336,11 +1337,11 @@ func Test_Ubuntu2204_ArtifactStreaming_ImagePull(t *testing.T) {
 			Validator: func(ctx context.Context, s *Scenario) error {
 				if err := errors.Join(
 					// Node bootstrap sanity (same checks as the other streaming scenarios).
-					ValidateNonEmptyDirectory(ctx, s, "/etc/overlaybd"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-snapshotter.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "overlaybd-tcmu.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "acr-mirror.service"),
-					ValidateSystemdUnitIsRunning(ctx, s, "containerd.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/etc/overlaybd"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-snapshotter.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "overlaybd-tcmu.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "acr-mirror.service"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "containerd.service"),
 				); err != nil {
 					return err
 				}
@@ -1367,7 +1368,7 @@ func Test_Ubuntu2204_ChronyRestarts_Taints_And_Tolerations(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				if err := ServiceCanRestartValidator(ctx, s, "chronyd", 10); err != nil {
+				if err := ServiceCanRestartValidator(ctx, s.NodeExecutor(), "chronyd", 10); err != nil {
 					return err
 				}
 				return ValidateTaints(ctx, s, s.Runtime.NBC.KubeletConfig["--register-with-taints"])
@@ -1410,8 +1411,8 @@ func Test_Ubuntu2204_CustomSysctls(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateUlimitSettings(ctx, s, customContainerdUlimits),
-					ValidateSysctlConfig(ctx, s, customSysctls),
+					ValidateUlimitSettings(ctx, s.NodeExecutor(), customContainerdUlimits),
+					ValidateSysctlConfig(ctx, s.NodeExecutor(), customSysctls),
 				)
 			},
 		},
@@ -1452,11 +1453,11 @@ func Test_Ubuntu2204_GPUA10(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateNvidiaGRIDLicenseValid(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
-					ValidateServicesDoNotRestartKubelet(ctx, s),
-					ValidateNvidiaPersistencedRunning(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaGRIDLicenseValid(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+					ValidateServicesDoNotRestartKubelet(ctx, s.NodeExecutor()),
+					ValidateNvidiaPersistencedRunning(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -1486,9 +1487,9 @@ func runScenarioUbuntu2204GPU(t *testing.T, vmSize string, location string) {
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
 					// Ensure nvidia-modprobe install does not restart kubelet and temporarily cause node to be unschedulable
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
-					ValidateServicesDoNotRestartKubelet(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+					ValidateServicesDoNotRestartKubelet(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -1515,9 +1516,9 @@ func Test_Ubuntu2204_GPUGridDriver(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
-					ValidateNvidiaSMIInstalled(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+					ValidateNvidiaSMIInstalled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -1554,7 +1555,7 @@ func Test_Ubuntu2204_GPUNoDriver(t *testing.T) {
 				vmss.SKU.Name = to.Ptr("Standard_NC4as_T4_v3")
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateNvidiaSMINotInstalled(ctx, s)
+				return ValidateNvidiaSMINotInstalled(ctx, s.NodeExecutor())
 			},
 		},
 	})
@@ -1585,7 +1586,7 @@ func Test_Ubuntu2204_ContainerdURL_IMDSRestrictionFilterTable(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateInstalledPackageVersion(ctx, s, "containerd", "1.6.9")
+				return ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "containerd", "1.6.9")
 			},
 		},
 	})
@@ -1598,7 +1599,7 @@ func Test_Ubuntu2204_ContainerdHasCurrentVersion(t *testing.T) {
 			Cluster: ClusterKubenet,
 			VHD:     config.VHDUbuntu2204Gen2Containerd,
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0])
+				return ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0])
 			},
 		},
 	})
@@ -1617,7 +1618,7 @@ func Test_AzureLinux_Skip_Binary_Cleanup(t *testing.T) {
 				vmss.Tags["SkipBinaryCleanup"] = to.Ptr("true")
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateMultipleKubeProxyVersionsExist(ctx, s)
+				return ValidateMultipleKubeProxyVersionsExist(ctx, s.NodeExecutor())
 			},
 		},
 	})
@@ -1730,9 +1731,9 @@ func Test_AzureLinuxV3_MA35D(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNonEmptyDirectory(ctx, s, "/sys/devices/virtual/misc/ama_transcoder0"),
-					ValidateNonEmptyDirectory(ctx, s, "/opt/amd/ama/ma35/"),
-					ValidateSystemdUnitIsRunning(ctx, s, "amdama-device-plugin.service"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/sys/devices/virtual/misc/ama_transcoder0"),
+					ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/opt/amd/ama/ma35/"),
+					ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "amdama-device-plugin.service"),
 					ValidateNodeAdvertisesGPUResources(ctx, s, 1, "squat.ai/amdama"),
 				)
 			},
@@ -1762,8 +1763,8 @@ func Test_AzureLinuxV3LocalDns_Disabled(t *testing.T) {
 			SkipDefaultValidation: true,
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateLocalDNSService(ctx, s, "disabled"),
-					ValidateLocalDNSResolution(ctx, s, "168.63.129.16"),
+					ValidateLocalDNSService(ctx, s.NodeExecutor(), "disabled"),
+					ValidateLocalDNSResolution(ctx, s.NodeExecutor(), "168.63.129.16"),
 				)
 			},
 		},
@@ -1804,8 +1805,8 @@ func Test_AzureLinuxV3_CustomSysctls(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateUlimitSettings(ctx, s, customContainerdUlimits),
-					ValidateSysctlConfig(ctx, s, customSysctls),
+					ValidateUlimitSettings(ctx, s.NodeExecutor(), customContainerdUlimits),
+					ValidateSysctlConfig(ctx, s.NodeExecutor(), customSysctls),
 				)
 			},
 		},
@@ -1904,7 +1905,7 @@ func Test_Ubuntu2204_KubeletCustomConfig(t *testing.T) {
 				kubeletConfigFilePath := "/etc/default/kubeletconfig.json"
 				return errors.Join(
 					ValidateFileHasContent(ctx, s, kubeletConfigFilePath, `"seccompDefault": true`),
-					ValidateKubeletHasFlags(ctx, s, kubeletConfigFilePath),
+					ValidateKubeletHasFlags(ctx, s.NodeExecutor(), kubeletConfigFilePath),
 				)
 			},
 		},
@@ -1933,8 +1934,8 @@ func Test_AzureLinuxV3_KubeletCustomConfig(t *testing.T) {
 				kubeletConfigFilePath := "/etc/default/kubeletconfig.json"
 				return errors.Join(
 					ValidateFileHasContent(ctx, s, kubeletConfigFilePath, `"seccompDefault": true`),
-					ValidateKubeletHasFlags(ctx, s, kubeletConfigFilePath),
-					ValidateInstalledPackageVersion(ctx, s, "containerd2", components.GetExpectedPackageVersions("containerd", "azurelinux", "v3.0")[0]),
+					ValidateKubeletHasFlags(ctx, s.NodeExecutor(), kubeletConfigFilePath),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "containerd2", components.GetExpectedPackageVersions("containerd", "azurelinux", "v3.0")[0]),
 				)
 			},
 		},
@@ -1989,11 +1990,11 @@ func Test_AzureLinuxV3_GPUA10(t *testing.T) {
 					},
 					Validator: func(ctx context.Context, s *Scenario) error {
 						return errors.Join(
-							ValidateNvidiaModProbeInstalled(ctx, s),
-							ValidateNvidiaGRIDLicenseValid(ctx, s),
-							ValidateKubeletHasNotStopped(ctx, s),
-							ValidateServicesDoNotRestartKubelet(ctx, s),
-							ValidateNvidiaPersistencedRunning(ctx, s),
+							ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+							ValidateNvidiaGRIDLicenseValid(ctx, s.NodeExecutor()),
+							ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+							ValidateServicesDoNotRestartKubelet(ctx, s.NodeExecutor()),
+							ValidateNvidiaPersistencedRunning(ctx, s.NodeExecutor()),
 						)
 					},
 				},
@@ -2066,7 +2067,7 @@ func Test_Ubuntu2204ARM64_KubeletCustomConfig(t *testing.T) {
 				kubeletConfigFilePath := "/etc/default/kubeletconfig.json"
 				return errors.Join(
 					ValidateFileHasContent(ctx, s, kubeletConfigFilePath, `"seccompDefault": true`),
-					ValidateKubeletHasFlags(ctx, s, kubeletConfigFilePath),
+					ValidateKubeletHasFlags(ctx, s.NodeExecutor(), kubeletConfigFilePath),
 				)
 			},
 		},
@@ -2089,11 +2090,12 @@ func Test_Ubuntu2404Gen2(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					ValidateInstalledPackageVersion(ctx, s, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2404")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2404")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
+					ValidateComponentsBillOfMaterials(ctx, s),
 				)
 			},
 		},
@@ -2114,11 +2116,11 @@ func Test_Ubuntu2604Minimal(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2604")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2604")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					// ValidateInstalledPackageVersion(ctx, s, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					// ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -2137,11 +2139,11 @@ func Test_Ubuntu2604Minimal_AzureCNI(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2604")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2604")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					// ValidateInstalledPackageVersion(ctx, s, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					// ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -2163,7 +2165,7 @@ func Test_Ubuntu2604Minimal_NPD_Basic(t *testing.T) {
 				return nil
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return ValidateNPDFilesystemCorruption(ctx, s)
@@ -2196,11 +2198,11 @@ func Test_Ubuntu2604Minimal_SecondaryNIC(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICUp(ctx, s, nicName)
+				return ValidateSecondaryNICUp(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -2240,11 +2242,11 @@ func Test_Ubuntu2604Minimal_SecondaryNIC_DualStack(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICDualStack(ctx, s, nicName)
+				return ValidateSecondaryNICDualStack(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -2272,7 +2274,7 @@ func Test_Ubuntu2604Minimal_KubeletCustomConfig(t *testing.T) {
 				kubeletConfigFilePath := "/etc/default/kubeletconfig.json"
 				return errors.Join(
 					ValidateFileHasContent(ctx, s, kubeletConfigFilePath, `"seccompDefault": true`),
-					ValidateKubeletHasFlags(ctx, s, kubeletConfigFilePath),
+					ValidateKubeletHasFlags(ctx, s.NodeExecutor(), kubeletConfigFilePath),
 				)
 			},
 		},
@@ -2426,7 +2428,7 @@ func Test_Ubuntu2604Minimal_CustomCa(t *testing.T) {
 				}
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateNonEmptyDirectory(ctx, s, "/usr/local/share/ca-certificates/certs")
+				return ValidateNonEmptyDirectory(ctx, s.NodeExecutor(), "/usr/local/share/ca-certificates/certs")
 			},
 		},
 	})
@@ -2466,8 +2468,8 @@ func Test_Ubuntu2604Minimal_CustomSysctls(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateUlimitSettings(ctx, s, customContainerdUlimits),
-					ValidateSysctlConfig(ctx, s, customSysctls),
+					ValidateUlimitSettings(ctx, s.NodeExecutor(), customContainerdUlimits),
+					ValidateSysctlConfig(ctx, s.NodeExecutor(), customSysctls),
 				)
 			},
 		},
@@ -2518,10 +2520,10 @@ func Test_Ubuntu2604Gen2_McrChinaCloud(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2604")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2604")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 					ValidateDirectoryContent(ctx, s, "/etc/containerd/certs.d/mcr.azk8s.cn", []string{"hosts.toml"}),
 				)
 			},
@@ -2545,7 +2547,7 @@ func Test_Ubuntu2604Minimal_ChronyRestarts_Taints_And_Tolerations(t *testing.T)
 				); err != nil {
 					return err
 				}
-				if err := ServiceCanRestartValidator(ctx, s, "chronyd", 10); err != nil {
+				if err := ServiceCanRestartValidator(ctx, s.NodeExecutor(), "chronyd", 10); err != nil {
 					return err
 				}
 				return ValidateTaints(ctx, s, s.Runtime.NBC.KubeletConfig["--register-with-taints"])
@@ -2631,8 +2633,8 @@ func Test_Ubuntu2604Minimal_NodeHardening_KubeReservedSlice_ConfigFile(t *testin
 					ValidateFileHasContent(ctx, s, "/etc/systemd/system/containerd.service.d/10-kubereserved-slice.conf", "Slice=kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubeletconfig.json", `"kubeReservedCgroup": "/kubereserved.slice"`),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubeletconfig.json", `"systemReservedCgroup": "/system.slice"`),
-					ValidateServiceInSlice(ctx, s, "kubelet.service", "kubereserved.slice"),
-					ValidateServiceInSlice(ctx, s, "containerd.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "kubelet.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "containerd.service", "kubereserved.slice"),
 				)
 			},
 		},
@@ -2667,8 +2669,8 @@ func Test_Ubuntu2604Minimal_NodeHardening_KubeReservedSlice_CLIFlags(t *testing.
 					ValidateFileHasContent(ctx, s, "/etc/systemd/system/containerd.service.d/10-kubereserved-slice.conf", "Slice=kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubelet", "--kube-reserved-cgroup=/kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubelet", "--system-reserved-cgroup=/system.slice"),
-					ValidateServiceInSlice(ctx, s, "kubelet.service", "kubereserved.slice"),
-					ValidateServiceInSlice(ctx, s, "containerd.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "kubelet.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "containerd.service", "kubereserved.slice"),
 				)
 			},
 		},
@@ -2871,11 +2873,11 @@ func Test_Ubuntu2604MinimalArm64(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2604")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2604")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					// ValidateInstalledPackageVersion(ctx, s, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					// ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -2898,11 +2900,11 @@ func Test_Ubuntu2604MinimalArm64_AzureCNI(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2604")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2604")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					// ValidateInstalledPackageVersion(ctx, s, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					// ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "blobfuse2", components.GetExpectedPackageVersions("blobfuse2", "ubuntu", "r2604")[0])
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -2925,7 +2927,7 @@ func Test_Ubuntu2604MinimalArm64_NPD_Basic(t *testing.T) {
 				return nil
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return ValidateNPDFilesystemCorruption(ctx, s)
@@ -2979,10 +2981,10 @@ func Test_Ubuntu2404Gen2_McrChinaCloud(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
-					ValidateContainerRuntimePlugins(ctx, s),
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
+					ValidateContainerRuntimePlugins(ctx, s.NodeExecutor()),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 					ValidateDirectoryContent(ctx, s, "/etc/containerd/certs.d/mcr.azk8s.cn", []string{"hosts.toml"}),
 				)
 			},
@@ -3057,9 +3059,9 @@ func Test_Ubuntu2404Gen2_GPUNoDriver(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				return errors.Join(
-					ValidateNvidiaSMINotInstalled(ctx, s),
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
+					ValidateNvidiaSMINotInstalled(ctx, s.NodeExecutor()),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
 				)
 			},
 		},
@@ -3076,8 +3078,8 @@ func Test_Ubuntu2404Gen1(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
 				)
 			},
 		},
@@ -3097,8 +3099,8 @@ func Test_Ubuntu2404ARM(t *testing.T) {
 				containerdVersions := components.GetExpectedPackageVersions("containerd", "ubuntu", "r2404")
 				runcVersions := components.GetExpectedPackageVersions("runc", "ubuntu", "r2404")
 				return errors.Join(
-					ValidateContainerd2Properties(ctx, s, containerdVersions),
-					ValidateRuncVersion(ctx, s, runcVersions),
+					ValidateContainerd2Properties(ctx, s.NodeExecutor(), s.VHD, containerdVersions),
+					ValidateRuncVersion(ctx, s.NodeExecutor(), s.VHD, runcVersions),
 				)
 			},
 		},
@@ -3136,11 +3138,11 @@ func runScenarioUbuntu2404GRID(t *testing.T, vmSize string) {
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
 					// Ensure nvidia-modprobe install does not restart kubelet and temporarily cause node to be unschedulable
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateNvidiaGRIDLicenseValid(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
-					ValidateServicesDoNotRestartKubelet(ctx, s),
-					ValidateNvidiaPersistencedRunning(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaGRIDLicenseValid(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+					ValidateServicesDoNotRestartKubelet(ctx, s.NodeExecutor()),
+					ValidateNvidiaPersistencedRunning(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -3176,10 +3178,10 @@ func Test_Ubuntu2404_GPU_RTXPro6000_GridV20(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateNvidiaSMIInstalled(ctx, s),
-					ValidateNvidiaGridV20DriverInstalled(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaSMIInstalled(ctx, s.NodeExecutor()),
+					ValidateNvidiaGridV20DriverInstalled(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -3201,7 +3203,7 @@ func Test_Ubuntu2404_NPD_Basic(t *testing.T) {
 				return nil
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				if err := ValidateNodeProblemDetector(ctx, s); err != nil {
+				if err := ValidateNodeProblemDetector(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				return ValidateNPDFilesystemCorruption(ctx, s)
@@ -3263,9 +3265,9 @@ func Test_Ubuntu2204_PMC_Install(t *testing.T) {
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
 				return errors.Join(
-					ValidateInstalledPackageVersion(ctx, s, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
-					ValidateInstalledPackageVersion(ctx, s, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
-					ValidateSSHServiceEnabled(ctx, s),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-containerd", components.GetExpectedPackageVersions("containerd", "ubuntu", "r2204")[0]),
+					ValidateInstalledPackageVersion(ctx, s.NodeExecutor(), s.VHD, "moby-runc", components.GetExpectedPackageVersions("runc", "ubuntu", "r2204")[0]),
+					ValidateSSHServiceEnabled(ctx, s.NodeExecutor()),
 				)
 			},
 		},
@@ -3282,7 +3284,7 @@ func Test_AzureLinux3OSGuard_PMC_Install(t *testing.T) {
 				nbc.AgentPoolProfile.LocalDNSProfile = nil
 			},
 			Validator: func(ctx context.Context, s *Scenario) error {
-				return ValidateFIPSProvider(ctx, s)
+				return ValidateFIPSProvider(ctx, s.NodeExecutor())
 			},
 			VMConfigMutator: func(vmss *armcompute.VirtualMachineScaleSet) {
 				vmss.Properties = addTrustedLaunchToVMSS(vmss.Properties)
@@ -3528,11 +3530,11 @@ func Test_Ubuntu2404_SecondaryNIC(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICUp(ctx, s, nicName)
+				return ValidateSecondaryNICUp(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3559,11 +3561,11 @@ func Test_AzureLinuxV3_SecondaryNIC(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICUp(ctx, s, nicName)
+				return ValidateSecondaryNICUp(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3590,11 +3592,11 @@ func Test_Ubuntu2204_SecondaryNIC(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICUp(ctx, s, nicName)
+				return ValidateSecondaryNICUp(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3622,11 +3624,11 @@ func Test_ACL_SecondaryNIC(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICUp(ctx, s, nicName)
+				return ValidateSecondaryNICUp(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3666,11 +3668,11 @@ func Test_Ubuntu2404_SecondaryNIC_DualStack(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICDualStack(ctx, s, nicName)
+				return ValidateSecondaryNICDualStack(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3710,11 +3712,11 @@ func Test_Ubuntu2204_SecondaryNIC_DualStack(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICDualStack(ctx, s, nicName)
+				return ValidateSecondaryNICDualStack(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3753,11 +3755,11 @@ func Test_AzureLinuxV3_SecondaryNIC_DualStack(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICDualStack(ctx, s, nicName)
+				return ValidateSecondaryNICDualStack(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3797,11 +3799,11 @@ func Test_ACL_SecondaryNIC_DualStack(t *testing.T) {
 				); err != nil {
 					return err
 				}
-				nicName, err := resolveSecondaryNICName(ctx, s)
+				nicName, err := resolveSecondaryNICName(ctx, s.NodeExecutor())
 				if err != nil {
 					return err
 				}
-				return ValidateSecondaryNICDualStack(ctx, s, nicName)
+				return ValidateSecondaryNICDualStack(ctx, s.NodeExecutor(), nicName)
 			},
 		},
 	})
@@ -3907,8 +3909,8 @@ func Test_Ubuntu2204_NodeHardening_KubeReservedSlice_ConfigFile(t *testing.T) {
 					ValidateFileHasContent(ctx, s, "/etc/systemd/system/containerd.service.d/10-kubereserved-slice.conf", "Slice=kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubeletconfig.json", `"kubeReservedCgroup": "/kubereserved.slice"`),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubeletconfig.json", `"systemReservedCgroup": "/system.slice"`),
-					ValidateServiceInSlice(ctx, s, "kubelet.service", "kubereserved.slice"),
-					ValidateServiceInSlice(ctx, s, "containerd.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "kubelet.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "containerd.service", "kubereserved.slice"),
 				)
 			},
 		},
@@ -3946,8 +3948,8 @@ func Test_Ubuntu2204_NodeHardening_KubeReservedSlice_CLIFlags(t *testing.T) {
 					ValidateFileHasContent(ctx, s, "/etc/systemd/system/containerd.service.d/10-kubereserved-slice.conf", "Slice=kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubelet", "--kube-reserved-cgroup=/kubereserved.slice"),
 					ValidateFileHasContent(ctx, s, "/etc/default/kubelet", "--system-reserved-cgroup=/system.slice"),
-					ValidateServiceInSlice(ctx, s, "kubelet.service", "kubereserved.slice"),
-					ValidateServiceInSlice(ctx, s, "containerd.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "kubelet.service", "kubereserved.slice"),
+					ValidateServiceInSlice(ctx, s.NodeExecutor(), "containerd.service", "kubereserved.slice"),
 				)
 			},
 		},
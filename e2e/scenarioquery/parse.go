@@ -0,0 +1,280 @@
+package scenarioquery
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// is reports whether the token is the operator or the case-insensitive keyword value.
+func (t token) is(value string) bool {
+	return (t.kind == tokenOp || t.kind == tokenWord) && strings.EqualFold(t.value, value)
+}
+
+// operators are matched longest first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "=", "!"}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.-/*:", c) >= 0
+}
+
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("parse query %q: unterminated string at offset %d", expr, i)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: expr[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case isWordChar(c):
+			start := i
+			for i < len(expr) && isWordChar(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: expr[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("parse query %q: unexpected %q at offset %d", expr, c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, value: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") || p.peek().is("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") || p.peek().is("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().is("not") || p.peek().is("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	if p.peek().kind == tokenLParen {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at offset %d, got %s", t.pos, t)
+		}
+		return n, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	t := p.next()
+	if t.kind != tokenWord || isKeyword(t.value) {
+		return nil, fmt.Errorf("expected an attribute at offset %d, got %s", t.pos, t)
+	}
+	n := comparisonNode{attribute: strings.ToLower(t.value)}
+
+	op := p.peek()
+	switch {
+	case op.is("in"):
+		p.next()
+		n.op = "in"
+	case op.is("not") && p.tokens[p.pos+1].is("in"):
+		p.next()
+		p.next()
+		n.op = "not in"
+	case op.kind == tokenOp && op.value != "&&" && op.value != "||" && op.value != "!":
+		p.next()
+		n.op = op.value
+		if n.op == "=" {
+			n.op = "=="
+		}
+	default:
+		// a boolean attribute.
+		return n, nil
+	}
+
+	if n.op == "in" || n.op == "not in" {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		n.values = values
+		return n, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	n.value = value
+	switch n.op {
+	case "=~", "!~":
+		if n.regexp, err = regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid regular expression for %s: %w", n.attribute, err)
+		}
+	case "<", "<=", ">", ">=":
+		if n.version, err = parseVersion(value); err != nil {
+			return nil, fmt.Errorf("%s %s needs a version: %w", n.attribute, n.op, err)
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind != tokenWord && t.kind != tokenString {
+		return "", fmt.Errorf("expected a value at offset %d, got %s", t.pos, t)
+	}
+	return t.value, nil
+}
+
+func (p *parser) parseList() ([]string, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, fmt.Errorf("expected \"(\" at offset %d, got %s", t.pos, t)
+	}
+	var values []string
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		switch t := p.next(); t.kind {
+		case tokenComma:
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected \",\" or \")\" at offset %d, got %s", t.pos, t)
+		}
+	}
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
+
+// ErrUnknownAttribute is returned by Validate for attributes which scenarios don't have.
+var ErrUnknownAttribute = errors.New("unknown attribute")
+
+// Validate checks that the query only uses the given attributes, compared case-insensitively.
+func (q *Query) Validate(attributes []string) error {
+	known := map[string]bool{}
+	for _, a := range attributes {
+		known[strings.ToLower(a)] = true
+	}
+	var unknown []string
+	for _, a := range q.Attributes() {
+		if !known[a] {
+			unknown = append(unknown, a)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("query %q: %w %s", q.expr, ErrUnknownAttribute, strings.Join(unknown, ", "))
+	}
+	return nil
+}
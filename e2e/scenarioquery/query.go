@@ -0,0 +1,264 @@
+// Package scenarioquery implements the expression language selecting e2e scenarios by their attributes, such as
+//
+//	os == ubuntu and osversion >= 24.04 and gpu and not name =~ "MIG"
+//	vmfamily in (NC, ND) or k8sversion < 1.32
+//
+// Expressions combine comparisons with and, or, not and parentheses (&&, || and ! are accepted too). A comparison is
+// one of:
+//
+//	attr == value, attr != value   case-insensitive equality, = is accepted for ==
+//	attr < value, <=, >, >=        dotted version ordering, "1.33.5" > "1.9", false when the attribute isn't a version
+//	attr =~ regex, attr !~ regex   Go regular expression match anywhere in the value
+//	attr in (a, b), not in (a, b)  case-insensitive set membership
+//	attr                           the boolean attribute is true
+//
+// Values are bare words or quoted with " or '. Attribute names are case-insensitive. Attributes which aren't known
+// yet, such as the Kubernetes version before the scenario cluster is chosen, evaluate to Unknown with three-valued
+// logic, so a query can be evaluated early and again once every attribute it uses is known.
+package scenarioquery
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is the outcome of evaluating a query.
+type Result int
+
+const (
+	False Result = iota
+	True
+	// Unknown means the outcome depends on attributes which aren't known.
+	Unknown
+)
+
+func (r Result) String() string {
+	switch r {
+	case False:
+		return "false"
+	case True:
+		return "true"
+	default:
+		return "unknown"
+	}
+}
+
+func not(r Result) Result {
+	switch r {
+	case False:
+		return True
+	case True:
+		return False
+	default:
+		return Unknown
+	}
+}
+
+func boolResult(b bool) Result {
+	if b {
+		return True
+	}
+	return False
+}
+
+// Query is a parsed expression.
+type Query struct {
+	expr string
+	root node
+}
+
+// Parse parses expr.
+func Parse(expr string) (*Query, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parse query %q: %w", expr, err)
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("parse query %q: unexpected %s at offset %d", expr, t, t.pos)
+	}
+	return &Query{expr: expr, root: root}, nil
+}
+
+// String returns the expression the query was parsed from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Attributes returns the lower-cased names of the attributes the query uses, sorted.
+func (q *Query) Attributes() []string {
+	names := map[string]bool{}
+	q.root.attributes(names)
+	attributes := make([]string, 0, len(names))
+	for name := range names {
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+// Eval evaluates the query against attrs, keyed by lower-cased attribute name. Attributes missing from attrs are
+// unknown.
+func (q *Query) Eval(attrs map[string]string) Result {
+	return q.root.eval(attrs)
+}
+
+type node interface {
+	eval(attrs map[string]string) Result
+	attributes(names map[string]bool)
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(attrs map[string]string) Result {
+	left, right := n.left.eval(attrs), n.right.eval(attrs)
+	switch {
+	case left == False || right == False:
+		return False
+	case left == True && right == True:
+		return True
+	default:
+		return Unknown
+	}
+}
+
+func (n andNode) attributes(names map[string]bool) {
+	n.left.attributes(names)
+	n.right.attributes(names)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(attrs map[string]string) Result {
+	left, right := n.left.eval(attrs), n.right.eval(attrs)
+	switch {
+	case left == True || right == True:
+		return True
+	case left == False && right == False:
+		return False
+	default:
+		return Unknown
+	}
+}
+
+func (n orNode) attributes(names map[string]bool) {
+	n.left.attributes(names)
+	n.right.attributes(names)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(attrs map[string]string) Result {
+	return not(n.operand.eval(attrs))
+}
+
+func (n notNode) attributes(names map[string]bool) {
+	n.operand.attributes(names)
+}
+
+// comparisonNode compares an attribute with a value, or tests a boolean attribute when op is empty.
+type comparisonNode struct {
+	attribute string
+	op        string
+	value     string
+	values    []string
+	version   []int
+	regexp    *regexp.Regexp
+}
+
+func (n comparisonNode) eval(attrs map[string]string) Result {
+	actual, ok := attrs[n.attribute]
+	if !ok {
+		return Unknown
+	}
+	switch n.op {
+	case "":
+		b, err := strconv.ParseBool(actual)
+		return boolResult(err == nil && b)
+	case "==":
+		return boolResult(strings.EqualFold(actual, n.value))
+	case "!=":
+		return boolResult(!strings.EqualFold(actual, n.value))
+	case "=~":
+		return boolResult(n.regexp.MatchString(actual))
+	case "!~":
+		return boolResult(!n.regexp.MatchString(actual))
+	case "in", "not in":
+		found := false
+		for _, v := range n.values {
+			if strings.EqualFold(actual, v) {
+				found = true
+				break
+			}
+		}
+		return boolResult(found == (n.op == "in"))
+	}
+	version, err := parseVersion(actual)
+	if err != nil {
+		return False
+	}
+	c := compareVersions(version, n.version)
+	switch n.op {
+	case "<":
+		return boolResult(c < 0)
+	case "<=":
+		return boolResult(c <= 0)
+	case ">":
+		return boolResult(c > 0)
+	default:
+		return boolResult(c >= 0)
+	}
+}
+
+func (n comparisonNode) attributes(names map[string]bool) {
+	names[n.attribute] = true
+}
+
+// parseVersion parses a dotted version such as "1.33.5", "v3" or "24.04". Pre-release and build suffixes after "-" or
+// "+" are ignored.
+func parseVersion(s string) ([]int, error) {
+	v := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	parts := strings.Split(v, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// compareVersions compares versions component by component, missing components are 0.
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package scenarioquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var ubuntuGPU = map[string]string{
+	"name":       "Test_Ubuntu2404_GPUNC",
+	"os":         "ubuntu",
+	"osversion":  "24.04",
+	"gpu":        "true",
+	"wasm":       "false",
+	"vmsize":     "Standard_NC6s_v3",
+	"vmfamily":   "NC",
+	"k8sversion": "1.33.5",
+}
+
+func TestEval(t *testing.T) {
+	for expr, want := range map[string]Result{
+		"os == ubuntu":               True,
+		"OS = Ubuntu":                True,
+		"os != ubuntu":               False,
+		"gpu":                        True,
+		"wasm":                       False,
+		"not wasm":                   True,
+		"!gpu":                       False,
+		"osversion >= 24.04":         True,
+		"osversion > 24.04":          False,
+		"osversion < 24.10":          True,
+		"k8sversion >= 1.9":          True,
+		"k8sversion <= v1.33":        False,
+		`name =~ "^Test_Ubuntu2404"`: True,
+		`name !~ 'MIG'`:              True,
+		`name =~ "(?i)gpunc$"`:       True,
+		"vmfamily in (NC, ND)":       True,
+		"vmfamily in (nd)":           False,
+		"vmfamily not in (D, E)":     True,
+		"os == ubuntu and osversion >= 24.04 and gpu and not name =~ 'MIG'": True,
+		"os == azurelinux or gpu && wasm":                                   False,
+		"(os == azurelinux or gpu) && !wasm":                                True,
+		"os == ubuntu and (vmfamily == ND || k8sversion < 1.32)":            False,
+	} {
+		q, err := Parse(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, want, q.Eval(ubuntuGPU), expr)
+	}
+}
+
+func TestEvalUnknown(t *testing.T) {
+	attrs := map[string]string{"os": "ubuntu", "gpu": "false", "osversion": "22.04"}
+	for expr, want := range map[string]Result{
+		"k8sversion >= 1.32":                  Unknown,
+		"not k8sversion >= 1.32":              Unknown,
+		"gpu and k8sversion >= 1.32":          False,
+		"os == ubuntu or k8sversion >= 1.32":  True,
+		"os == ubuntu and k8sversion >= 1.32": Unknown,
+	} {
+		q, err := Parse(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, want, q.Eval(attrs), expr)
+	}
+
+	// images without an OS version, such as ACL, don't match version comparisons.
+	q, err := Parse("osversion >= 3")
+	require.NoError(t, err)
+	require.Equal(t, False, q.Eval(map[string]string{"osversion": ""}))
+}
+
+func TestParseErrors(t *testing.T) {
+	for expr, message := range map[string]string{
+		"":                      "expected an attribute",
+		"os ==":                 "expected a value",
+		"os == ubuntu and":      "expected an attribute",
+		"(os == ubuntu":         `expected ")"`,
+		"os == ubuntu)":         `unexpected ")"`,
+		"vmfamily in NC":        `expected "("`,
+		"vmfamily in (NC ND)":   `expected "," or ")"`,
+		`name =~ "["`:           "invalid regular expression",
+		"k8sversion >= latest":  "needs a version",
+		`name == "unterminated`: "unterminated string",
+		"os == ubuntu; gpu":     `unexpected ';'`,
+		"and":                   "expected an attribute",
+	} {
+		_, err := Parse(expr)
+		require.ErrorContains(t, err, message, expr)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	q, err := Parse("OS == ubuntu and (GPU or vmfamily in (NC)) and not os == mariner")
+	require.NoError(t, err)
+	require.Equal(t, []string{"gpu", "os", "vmfamily"}, q.Attributes())
+	require.Equal(t, "OS == ubuntu and (GPU or vmfamily in (NC)) and not os == mariner", q.String())
+
+	require.NoError(t, q.Validate([]string{"OS", "GPU", "VMFamily"}))
+	err = q.Validate([]string{"os"})
+	require.ErrorIs(t, err, ErrUnknownAttribute)
+	require.ErrorContains(t, err, "gpu, vmfamily")
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.33.5", "1.33.5", 0},
+		{"1.33", "1.33.0", 0},
+		{"1.9", "1.33", -1},
+		{"v3", "2", 1},
+		{"1.32.0-alpha.1", "1.32", 0},
+		{"24.04", "22.04", 1},
+	} {
+		a, err := parseVersion(c.a)
+		require.NoError(t, err)
+		b, err := parseVersion(c.b)
+		require.NoError(t, err)
+		require.Equal(t, c.want, compareVersions(a, b), "%s vs %s", c.a, c.b)
+	}
+	_, err := parseVersion("24.04-lts.x")
+	require.NoError(t, err)
+	_, err = parseVersion("ubuntu")
+	require.Error(t, err)
+}
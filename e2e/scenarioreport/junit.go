@@ -0,0 +1,159 @@
+package scenarioreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the records as JUnit test suites named name. Each scenario is a test suite, with the VHD, VM size,
+// location, CSE exit code and tags as properties, and a test case for the scenario, for each phase prefixed with
+// "phase/" and for each validator prefixed with "validator/". Test cases are classed by scenario name, so a validator
+// is tracked across runs of the same scenario. Failures classified as infrastructure flakes or capacity issues are
+// reported as errors rather than failures, so they aren't mistaken for product regressions, and the retries of a step
+// are written to its output.
+func WriteJUnit(w io.Writer, name string, records []*Record) error {
+	suites := junitTestSuites{Name: name}
+	var total time.Duration
+	for _, r := range records {
+		suite := recordSuite(r)
+		suites.Suites = append(suites.Suites, suite)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		total += r.Duration
+	}
+	suites.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write JUnit report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func recordSuite(r *Record) junitTestSuite {
+	suite := junitTestSuite{Name: r.Name, Time: junitSeconds(r.Duration)}
+	if !r.Start.IsZero() {
+		suite.Timestamp = r.Start.UTC().Format("2006-01-02T15:04:05")
+	}
+	for _, p := range []junitProperty{
+		{"vhd", r.VHD.Name},
+		{"vhdDistro", r.VHD.Distro},
+		{"vhdVersion", r.VHD.Version},
+		{"vhdResourceId", r.VHD.ResourceID},
+		{"vmSize", r.VMSize},
+		{"location", r.Location},
+		{"cseExitCode", r.CSEExitCode},
+	} {
+		if p.Value != "" {
+			suite.Properties = append(suite.Properties, p)
+		}
+	}
+	tagNames := make([]string, 0, len(r.Tags))
+	for tag := range r.Tags {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	for _, tag := range tagNames {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "tag." + tag, Value: r.Tags[tag]})
+	}
+
+	suite.add(junitCase(r.Name, "scenario", Step{Duration: r.Duration, Status: r.Status, Error: r.Error, Class: r.Class}))
+	for _, p := range r.Phases {
+		suite.add(junitCase(r.Name, "phase/"+p.Name, p))
+	}
+	for _, v := range r.Validators {
+		suite.add(junitCase(r.Name, "validator/"+v.Name, v))
+	}
+	return suite
+}
+
+func (s *junitTestSuite) add(tc junitTestCase) {
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	if tc.Error != nil {
+		s.Errors++
+	}
+	if tc.Skipped != nil {
+		s.Skipped++
+	}
+	s.Cases = append(s.Cases, tc)
+}
+
+func junitCase(className, name string, step Step) junitTestCase {
+	tc := junitTestCase{Name: name, ClassName: className, Time: junitSeconds(step.Duration)}
+	switch {
+	case step.Status == StatusFailed && step.Class.Infrastructure():
+		tc.Error = &junitMessage{Message: fmt.Sprintf("%s failed with %s error", name, step.Class), Type: string(step.Class), Text: step.Error}
+	case step.Status == StatusFailed:
+		tc.Failure = &junitMessage{Message: name + " failed", Type: string(step.Class), Text: step.Error}
+	case step.Status == StatusSkipped:
+		tc.Skipped = &junitMessage{Message: step.Error}
+	}
+	var out strings.Builder
+	for _, retry := range step.Retries {
+		fmt.Fprintf(&out, "retried after %s error (rule %s): %s\n", retry.Class, retry.Rule, retry.Error)
+	}
+	tc.SystemOut = out.String()
+	return tc
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
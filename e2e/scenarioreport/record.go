@@ -0,0 +1,162 @@
+// Package scenarioreport holds the machine-readable record of an e2e scenario run, and aggregates the records of a
+// test run into a JUnit report with a test case per phase and per validator, so flaky validators and VHDs can be
+// tracked without parsing test logs.
+package scenarioreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/errorclass"
+)
+
+// RecordFileName is the name of the record written in the log directory of each scenario.
+const RecordFileName = "scenario-record.json"
+
+// Status is the outcome of a scenario, phase or validator.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+func statusOf(err error) Status {
+	if err != nil {
+		return StatusFailed
+	}
+	return StatusPassed
+}
+
+// Step is the outcome of a phase of the scenario, such as creating the VMSS, or of a validator.
+type Step struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	// Class and Rule are the classification of the error of a failed step.
+	Class errorclass.Class `json:"class,omitempty"`
+	Rule  string           `json:"rule,omitempty"`
+	// Retries are the failed attempts of the step which were retried.
+	Retries []Retry `json:"retries,omitempty"`
+}
+
+// Retry is a failed attempt of a step, retried as its classification allowed.
+type Retry struct {
+	Class errorclass.Class `json:"class"`
+	Rule  string           `json:"rule"`
+	Error string           `json:"error"`
+}
+
+// NewStep returns the step which started at start and ended now with err.
+func NewStep(name string, start time.Time, err error) Step {
+	step := Step{Name: name, Start: start, Duration: time.Since(start), Status: statusOf(err)}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// VHD identifies the image the scenario node was created from.
+type VHD struct {
+	Name    string `json:"name"`
+	Distro  string `json:"distro,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	Version string `json:"version,omitempty"`
+	// ResourceID is the image version the VHD was resolved to.
+	ResourceID string `json:"resourceId,omitempty"`
+}
+
+// Record is the record of a scenario run.
+type Record struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	VHD         VHD               `json:"vhd"`
+	VMSize      string            `json:"vmSize,omitempty"`
+	Location    string            `json:"location,omitempty"`
+	Start       time.Time         `json:"start"`
+	Duration    time.Duration     `json:"duration"`
+	Status      Status            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	// Class is the classification of the error of the first failed phase or validator of a failed scenario.
+	Class errorclass.Class `json:"class,omitempty"`
+	// CSEExitCode is the exit code reported by the CSE extension, empty when the extension status wasn't read.
+	CSEExitCode string `json:"cseExitCode,omitempty"`
+	Phases      []Step `json:"phases"`
+	Validators  []Step `json:"validators"`
+	// Artifacts are the files of the scenario log directory.
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// WriteFile writes the record as indented JSON.
+func (r *Record) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal scenario record: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// LoadRecord reads a record written by Record.WriteFile.
+func LoadRecord(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario record: %w", err)
+	}
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse scenario record %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// LoadRecords reads the records found under dir, sorted by scenario name.
+func LoadRecords(dir string) ([]*Record, error) {
+	var records []*Record
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != RecordFileName {
+			return err
+		}
+		r, err := LoadRecord(path)
+		if err != nil {
+			return err
+		}
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortRecords(records)
+	return records, nil
+}
+
+// SortRecords sorts records by scenario name.
+func SortRecords(records []*Record) {
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Name < records[j].Name })
+}
+
+// Artifacts returns the files of dir, sorted, skipping the record itself. Subdirectories belong to subtests, which have
+// their own records.
+func Artifacts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []string
+	for _, e := range entries {
+		if !e.IsDir() && e.Name() != RecordFileName {
+			artifacts = append(artifacts, e.Name())
+		}
+	}
+	return artifacts, nil
+}
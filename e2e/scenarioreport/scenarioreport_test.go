@@ -0,0 +1,146 @@
+package scenarioreport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/agentbaker/e2e/errorclass"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 7, 17, 2, 20, 0, 0, time.UTC)
+
+func testRecord() *Record {
+	return &Record{
+		Name:        "Test_Ubuntu2204",
+		Description: "tests that a node using ubuntu 2204 can be bootstrapped",
+		Tags:        map[string]string{"OS": "linux", "GPU": "false"},
+		VHD:         VHD{Name: "2204gen2containerd", Distro: "aks-ubuntu-containerd-22.04-gen2", Version: "202607.10.0", ResourceID: "/subscriptions/x/images/2204gen2containerd/versions/202607.10.0"},
+		VMSize:      "Standard_D2ds_v5",
+		Location:    "westus3",
+		Start:       start,
+		Duration:    5 * time.Minute,
+		Status:      StatusFailed,
+		Error:       "validation failed",
+		CSEExitCode: "0",
+		Phases: []Step{
+			{Name: "vmss", Start: start, Duration: 3 * time.Minute, Status: StatusPassed},
+			{Name: "validation", Start: start.Add(4 * time.Minute), Duration: time.Minute, Status: StatusFailed, Error: "validation failed"},
+		},
+		Validators: []Step{
+			{Name: "TLSBootstrapping", Start: start.Add(4 * time.Minute), Duration: 1500 * time.Millisecond, Status: StatusPassed},
+			{Name: "KernelLogs", Start: start.Add(4 * time.Minute), Duration: 2 * time.Second, Status: StatusFailed, Error: "kernel panic"},
+		},
+		Artifacts: []string{"cluster-provision.log"},
+	}
+}
+
+func TestNewStep(t *testing.T) {
+	step := NewStep("vmss", time.Now().Add(-time.Second), nil)
+	require.Equal(t, StatusPassed, step.Status)
+	require.Empty(t, step.Error)
+	require.GreaterOrEqual(t, step.Duration, time.Second)
+
+	step = NewStep("vmss", time.Now(), errors.New("quota exceeded"))
+	require.Equal(t, StatusFailed, step.Status)
+	require.Equal(t, "quota exceeded", step.Error)
+}
+
+func TestLoadRecords(t *testing.T) {
+	dir := t.TempDir()
+	second := testRecord()
+	first := testRecord()
+	first.Name = "Test_AzureLinuxV3"
+	first.Status, first.Error = StatusPassed, ""
+	for _, r := range []*Record{second, first} {
+		scenarioDir := filepath.Join(dir, r.Name)
+		require.NoError(t, os.MkdirAll(scenarioDir, 0755))
+		require.NoError(t, r.WriteFile(filepath.Join(scenarioDir, RecordFileName)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, second.Name, "cluster-provision.log"), nil, 0600))
+
+	records, err := LoadRecords(dir)
+	require.NoError(t, err)
+	require.Equal(t, []*Record{first, second}, records)
+
+	artifacts, err := Artifacts(filepath.Join(dir, second.Name))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cluster-provision.log"}, artifacts)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, first.Name, RecordFileName), []byte("{"), 0600))
+	_, err = LoadRecords(dir)
+	require.ErrorContains(t, err, "parse scenario record")
+}
+
+func TestWriteJUnit(t *testing.T) {
+	skipped := &Record{Name: "Test_Flatcar", Start: start, Status: StatusSkipped, Error: "no VHD"}
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, "e2e", []*Record{testRecord(), skipped}))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, "e2e", suites.Name)
+	require.Equal(t, 6, suites.Tests)
+	require.Equal(t, 3, suites.Failures)
+	require.Equal(t, 1, suites.Skipped)
+	require.Equal(t, "300.000", suites.Time)
+	require.Len(t, suites.Suites, 2)
+
+	suite := suites.Suites[0]
+	require.Equal(t, "Test_Ubuntu2204", suite.Name)
+	require.Equal(t, "2026-07-17T02:20:00", suite.Timestamp)
+	require.Equal(t, []junitProperty{
+		{"vhd", "2204gen2containerd"},
+		{"vhdDistro", "aks-ubuntu-containerd-22.04-gen2"},
+		{"vhdVersion", "202607.10.0"},
+		{"vhdResourceId", "/subscriptions/x/images/2204gen2containerd/versions/202607.10.0"},
+		{"vmSize", "Standard_D2ds_v5"},
+		{"location", "westus3"},
+		{"cseExitCode", "0"},
+		{"tag.GPU", "false"},
+		{"tag.OS", "linux"},
+	}, suite.Properties)
+
+	var names []string
+	for _, tc := range suite.Cases {
+		names = append(names, tc.Name)
+		require.Equal(t, "Test_Ubuntu2204", tc.ClassName)
+	}
+	require.Equal(t, []string{"scenario", "phase/vmss", "phase/validation", "validator/TLSBootstrapping", "validator/KernelLogs"}, names)
+	require.Nil(t, suite.Cases[3].Failure)
+	require.Equal(t, "1.500", suite.Cases[3].Time)
+	require.Equal(t, &junitMessage{Message: "validator/KernelLogs failed", Text: "kernel panic"}, suite.Cases[4].Failure)
+
+	require.Equal(t, &junitMessage{Message: "no VHD"}, suites.Suites[1].Cases[0].Skipped)
+	require.Empty(t, suites.Suites[1].Properties)
+}
+
+func TestWriteJUnitClassifiedFailures(t *testing.T) {
+	flake := &Record{
+		Name:   "Test_AzureLinuxV3",
+		Start:  start,
+		Status: StatusFailed,
+		Error:  "CSE exited with 50",
+		Class:  errorclass.InfraFlake,
+		Phases: []Step{{
+			Name: "cse", Status: StatusFailed, Error: "CSE exited with 50", Class: errorclass.InfraFlake, Rule: "cse-outbound-conn-fail",
+			Retries: []Retry{{Class: errorclass.InfraFlake, Rule: "cse-outbound-conn-fail", Error: "CSE exited with 50"}},
+		}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, "e2e", []*Record{flake}))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, 0, suites.Failures)
+	require.Equal(t, 2, suites.Errors)
+	cse := suites.Suites[0].Cases[1]
+	require.Nil(t, cse.Failure)
+	require.Equal(t, &junitMessage{Message: "phase/cse failed with infra-flake error", Type: "infra-flake", Text: "CSE exited with 50"}, cse.Error)
+	require.Equal(t, "retried after infra-flake error (rule cse-outbound-conn-fail): CSE exited with 50\n", cse.SystemOut)
+}
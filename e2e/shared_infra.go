@@ -348,6 +348,7 @@ func ensureSharedBastion(ctx context.Context, rg, location string) (string, erro
 	toolkit.Logf(ctx, "creating shared bastion %s (Standard SKU, tunneling enabled)", SharedBastionName)
 	bastionPoller, err := config.Azure.BastionHosts.BeginCreateOrUpdate(ctx, rg, SharedBastionName, armnetwork.BastionHost{
 		Location: to.Ptr(location),
+		Tags:     sharedTags(),
 		SKU: &armnetwork.SKU{
 			Name: to.Ptr(armnetwork.BastionHostSKUNameStandard),
 		},
@@ -0,0 +1,302 @@
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerregistry/armcontainerregistry/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v7"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+)
+
+// Azure lists and deletes the resources of the e2e resource groups of a subscription with the ARM clients.
+type Azure struct {
+	ResourceGroups       *armresources.ResourceGroupsClient
+	VMSS                 *armcompute.VirtualMachineScaleSetsClient
+	Galleries            *armcompute.GalleriesClient
+	GalleryImages        *armcompute.GalleryImagesClient
+	GalleryImageVersions *armcompute.GalleryImageVersionsClient
+	BastionHosts         *armnetwork.BastionHostsClient
+	Registries           *armcontainerregistry.RegistriesClient
+	// ResourceGroupName returns the e2e resource group of a location. Resources are only listed in the e2e resource
+	// groups and the MC_ node resource groups of their clusters, the rest of the subscription isn't e2e-owned.
+	ResourceGroupName func(location string) string
+	// GalleryPrefix is the name prefix of the e2e galleries, the image versions of the other galleries aren't listed
+	// unless the gallery is tagged TagE2E.
+	GalleryPrefix string
+	// Locations, when set, restricts the listing to these locations.
+	Locations []string
+	// PollOptions are the options deletions are polled with.
+	PollOptions *runtime.PollUntilDoneOptions
+}
+
+var (
+	_ Inventory = (*Azure)(nil)
+	_ Deleter   = (*Azure)(nil)
+)
+
+// List lists the VMSS, e2e gallery image versions, galleries, bastions and container registries of the e2e resource
+// groups.
+func (a *Azure) List(ctx context.Context) ([]Resource, error) {
+	resourceGroups, err := a.listResourceGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	for _, rg := range resourceGroups {
+		rgResources, err := a.listResourceGroup(ctx, rg)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, rgResources...)
+	}
+	return resources, nil
+}
+
+// listResourceGroups returns the e2e resource groups, and the MC_ node resource groups of the e2e clusters, of the
+// locations swept.
+func (a *Azure) listResourceGroups(ctx context.Context) ([]string, error) {
+	var names []string
+	pager := a.ResourceGroups.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list resource groups: %w", err)
+		}
+		for _, rg := range page.Value {
+			name, location := deref(rg.Name), deref(rg.Location)
+			if a.inLocation(location) && a.isE2EResourceGroup(name, location) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (a *Azure) isE2EResourceGroup(name, location string) bool {
+	e2e := strings.ToLower(a.ResourceGroupName(location))
+	name = strings.ToLower(name)
+	return name == e2e || strings.HasPrefix(name, "mc_"+e2e+"_")
+}
+
+func (a *Azure) listResourceGroup(ctx context.Context, resourceGroup string) ([]Resource, error) {
+	var resources []Resource
+	add := func(id *string, kind Kind, location *string, tags map[string]*string, createdAt *time.Time) error {
+		r, err := newResource(id, kind, location, tags, createdAt)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, r)
+		return nil
+	}
+
+	vmssPager := a.VMSS.NewListPager(resourceGroup, nil)
+	for vmssPager.More() {
+		page, err := vmssPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list VMSS of %s: %w", resourceGroup, err)
+		}
+		for _, vmss := range page.Value {
+			var createdAt *time.Time
+			if vmss.Properties != nil {
+				createdAt = vmss.Properties.TimeCreated
+			}
+			if err := add(vmss.ID, KindVMSS, vmss.Location, vmss.Tags, createdAt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	galleryPager := a.Galleries.NewListByResourceGroupPager(resourceGroup, nil)
+	for galleryPager.More() {
+		page, err := galleryPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list galleries of %s: %w", resourceGroup, err)
+		}
+		for _, gallery := range page.Value {
+			var createdAt *time.Time
+			if gallery.SystemData != nil {
+				createdAt = gallery.SystemData.CreatedAt
+			}
+			r, err := newResource(gallery.ID, KindGallery, gallery.Location, gallery.Tags, createdAt)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, r)
+			if _, e2e := r.Tags[TagE2E]; !e2e && (a.GalleryPrefix == "" || !strings.HasPrefix(r.Name, a.GalleryPrefix)) {
+				continue
+			}
+			versions, err := a.listImageVersions(ctx, r.ResourceGroup, r.Name)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, versions...)
+		}
+	}
+
+	bastionPager := a.BastionHosts.NewListByResourceGroupPager(resourceGroup, nil)
+	for bastionPager.More() {
+		page, err := bastionPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list bastions of %s: %w", resourceGroup, err)
+		}
+		for _, bastion := range page.Value {
+			if err := add(bastion.ID, KindBastion, bastion.Location, bastion.Tags, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	registryPager := a.Registries.NewListByResourceGroupPager(resourceGroup, nil)
+	for registryPager.More() {
+		page, err := registryPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list container registries of %s: %w", resourceGroup, err)
+		}
+		for _, registry := range page.Value {
+			var createdAt *time.Time
+			if registry.Properties != nil {
+				createdAt = registry.Properties.CreationDate
+			}
+			if err := add(registry.ID, KindContainerRegistry, registry.Location, registry.Tags, createdAt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resources, nil
+}
+
+func (a *Azure) listImageVersions(ctx context.Context, resourceGroup, gallery string) ([]Resource, error) {
+	var versions []Resource
+	imagePager := a.GalleryImages.NewListByGalleryPager(resourceGroup, gallery, nil)
+	for imagePager.More() {
+		page, err := imagePager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list images of gallery %s: %w", gallery, err)
+		}
+		for _, image := range page.Value {
+			if image.Name == nil {
+				continue
+			}
+			versionPager := a.GalleryImageVersions.NewListByGalleryImagePager(resourceGroup, gallery, *image.Name, nil)
+			for versionPager.More() {
+				page, err := versionPager.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("list versions of gallery image %s/%s: %w", gallery, *image.Name, err)
+				}
+				for _, version := range page.Value {
+					var createdAt *time.Time
+					if version.Properties != nil && version.Properties.PublishingProfile != nil {
+						createdAt = version.Properties.PublishingProfile.PublishedDate
+					}
+					r, err := newResource(version.ID, KindGalleryImageVersion, version.Location, version.Tags, createdAt)
+					if err != nil {
+						return nil, err
+					}
+					versions = append(versions, r)
+				}
+			}
+		}
+	}
+	return versions, nil
+}
+
+func (a *Azure) inLocation(location string) bool {
+	if len(a.Locations) == 0 {
+		return true
+	}
+	for _, l := range a.Locations {
+		if strings.EqualFold(l, location) {
+			return true
+		}
+	}
+	return false
+}
+
+func newResource(id *string, kind Kind, location *string, tags map[string]*string, createdAt *time.Time) (Resource, error) {
+	parsed, err := arm.ParseResourceID(deref(id))
+	if err != nil {
+		return Resource{}, fmt.Errorf("parse %s ID %q: %w", kind, deref(id), err)
+	}
+	r := Resource{
+		ID:            *id,
+		Kind:          kind,
+		Name:          parsed.Name,
+		ResourceGroup: parsed.ResourceGroupName,
+		Location:      deref(location),
+		Tags:          map[string]string{},
+	}
+	for k, v := range tags {
+		r.Tags[k] = deref(v)
+	}
+	if createdAt != nil {
+		r.CreatedAt = *createdAt
+	}
+	return r, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Delete deletes r and waits for its deletion. VMSS are force-deleted.
+func (a *Azure) Delete(ctx context.Context, r Resource) error {
+	parsed, err := arm.ParseResourceID(r.ID)
+	if err != nil {
+		return fmt.Errorf("parse resource ID %q: %w", r.ID, err)
+	}
+	switch r.Kind {
+	case KindVMSS:
+		poller, err := a.VMSS.BeginDelete(ctx, r.ResourceGroup, r.Name, &armcompute.VirtualMachineScaleSetsClientBeginDeleteOptions{
+			ForceDeletion: to.Ptr(true),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx, a.PollOptions)
+		return err
+	case KindGalleryImageVersion:
+		image := parsed.Parent
+		if image == nil || image.Parent == nil {
+			return fmt.Errorf("gallery image version ID %q has no gallery image", r.ID)
+		}
+		poller, err := a.GalleryImageVersions.BeginDelete(ctx, r.ResourceGroup, image.Parent.Name, image.Name, r.Name, nil)
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx, a.PollOptions)
+		return err
+	case KindGallery:
+		poller, err := a.Galleries.BeginDelete(ctx, r.ResourceGroup, r.Name, nil)
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx, a.PollOptions)
+		return err
+	case KindBastion:
+		poller, err := a.BastionHosts.BeginDelete(ctx, r.ResourceGroup, r.Name, nil)
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx, a.PollOptions)
+		return err
+	case KindContainerRegistry:
+		poller, err := a.Registries.BeginDelete(ctx, r.ResourceGroup, r.Name, nil)
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx, a.PollOptions)
+		return err
+	default:
+		return fmt.Errorf("can't delete %s resources", r.Kind)
+	}
+}
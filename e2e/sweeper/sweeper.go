@@ -0,0 +1,271 @@
+// Package sweeper deletes the resources the e2e tests leaked, such as the VMSS and gallery image versions of runs
+// which were aborted before their cleanups ran. Resources are discovered in the e2e resource groups, only those with the
+// TagE2E marker the e2e tests set on the resources they create are e2e-owned, and they are swept by an age and
+// ownership policy. The Azure calls are behind the Inventory and Deleter
+// interfaces, so the policy can be tested without Azure.
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Tags the e2e tests set on the resources they create.
+const (
+	// TagE2E marks the resources created by the e2e tests. Resources without it aren't e2e-owned, whatever their other
+	// tags.
+	TagE2E = "agentbaker-e2e"
+	// TagOwner is the Azure user, or the local user, who ran the tests. The resources shared by the runs of a location
+	// don't have it.
+	TagOwner = "owner"
+	// TagBuildID is the BUILD_ID of the run which created the resource.
+	TagBuildID = "buildID"
+	// TagKeep is set with KEEP_VMSS=true, the resource is kept for debugging.
+	TagKeep = "KEEP_VMSS"
+	// TagAKSManagedPool is set by AKS on the VMSS of the cluster agent pools.
+	TagAKSManagedPool = "aks-managed-poolName"
+)
+
+// Kind is the Azure resource type of a resource.
+type Kind string
+
+const (
+	KindVMSS                Kind = "Microsoft.Compute/virtualMachineScaleSets"
+	KindGalleryImageVersion Kind = "Microsoft.Compute/galleries/images/versions"
+	KindGallery             Kind = "Microsoft.Compute/galleries"
+	KindBastion             Kind = "Microsoft.Network/bastionHosts"
+	KindContainerRegistry   Kind = "Microsoft.ContainerRegistry/registries"
+)
+
+// Kinds are the kinds of resources swept, in deletion order: image versions are deleted before their gallery.
+var Kinds = []Kind{KindVMSS, KindGalleryImageVersion, KindGallery, KindBastion, KindContainerRegistry}
+
+// Resource is an Azure resource the e2e tests may have created.
+type Resource struct {
+	ID            string            `json:"id"`
+	Kind          Kind              `json:"kind"`
+	Name          string            `json:"name"`
+	ResourceGroup string            `json:"resourceGroup"`
+	Location      string            `json:"location,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	// CreatedAt is the zero time when Azure doesn't report the creation time of the resource.
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// Inventory lists the resources of the kinds the sweeper knows.
+type Inventory interface {
+	List(ctx context.Context) ([]Resource, error)
+}
+
+// Deleter deletes a resource and waits for its deletion.
+type Deleter interface {
+	Delete(ctx context.Context, r Resource) error
+}
+
+// Policy decides which e2e-owned resources are leaked.
+type Policy struct {
+	// MaxAge is the age after which a resource of a kind is leaked. Kinds without a max age are never swept, which by
+	// default are the galleries, bastions and registries shared by the runs of a location.
+	MaxAge map[Kind]time.Duration
+	// Owner, when set, restricts the sweep to the resources of an owner.
+	Owner string
+	// AbortedBuildIDs are the builds whose resources are swept whatever their age.
+	AbortedBuildIDs []string
+	// ActiveBuildIDs are the builds whose resources are kept whatever their age.
+	ActiveBuildIDs []string
+	// Now is the time ages are computed at, time.Now when zero.
+	Now time.Time
+}
+
+// DefaultPolicy sweeps the VMSS and gallery image versions older than the test timeout, with a buffer for cleanups
+// and clock drift, as collectGarbageVMSS does for the VMSS of a cluster.
+func DefaultPolicy(testTimeout time.Duration) Policy {
+	maxAge := testTimeout + 10*time.Minute
+	return Policy{MaxAge: map[Kind]time.Duration{
+		KindVMSS:                maxAge,
+		KindGalleryImageVersion: maxAge,
+	}}
+}
+
+// Decision is whether a resource is swept, and why.
+type Decision struct {
+	Resource
+	Sweep  bool   `json:"sweep"`
+	Reason string `json:"reason"`
+}
+
+// Decide returns whether the policy sweeps r.
+func (p Policy) Decide(r Resource) Decision {
+	keep := func(format string, args ...any) Decision {
+		return Decision{Resource: r, Reason: fmt.Sprintf(format, args...)}
+	}
+	sweep := func(format string, args ...any) Decision {
+		return Decision{Resource: r, Sweep: true, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if _, ok := r.Tags[TagE2E]; !ok {
+		return keep("not tagged %s, not created by the e2e tests", TagE2E)
+	}
+	if _, ok := r.Tags[TagKeep]; ok {
+		return keep("tagged %s", TagKeep)
+	}
+	if _, ok := r.Tags[TagAKSManagedPool]; ok {
+		return keep("agent pool managed by AKS")
+	}
+	owner, ok := r.Tags[TagOwner]
+	if p.Owner != "" && !ok {
+		return keep("not owned by %s", p.Owner)
+	}
+	if p.Owner != "" && !strings.EqualFold(strings.TrimSpace(owner), strings.TrimSpace(p.Owner)) {
+		return keep("owned by %s", owner)
+	}
+	buildID := r.Tags[TagBuildID]
+	if buildID != "" && slices.Contains(p.ActiveBuildIDs, buildID) {
+		return keep("build %s is active", buildID)
+	}
+	maxAge, ok := p.MaxAge[r.Kind]
+	if !ok {
+		return keep("%s resources aren't swept", r.Kind)
+	}
+	if buildID != "" && slices.Contains(p.AbortedBuildIDs, buildID) {
+		return sweep("build %s was aborted", buildID)
+	}
+	if r.CreatedAt.IsZero() {
+		return keep("creation time unknown")
+	}
+	now := p.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	age := now.Sub(r.CreatedAt).Truncate(time.Minute)
+	if age < maxAge {
+		return keep("%s old, younger than %s", age, maxAge)
+	}
+	return sweep("%s old, older than %s", age, maxAge)
+}
+
+// Sweeper deletes the resources its policy decides are leaked.
+type Sweeper struct {
+	Inventory Inventory
+	Deleter   Deleter
+	Policy    Policy
+	// DryRun reports the resources which would be deleted without deleting them.
+	DryRun bool
+	// Logf logs the deletions.
+	Logf func(format string, args ...any)
+}
+
+// Report is the outcome of a sweep.
+type Report struct {
+	DryRun    bool       `json:"dryRun"`
+	Decisions []Decision `json:"decisions"`
+	// Deleted are the IDs of the deleted resources, Failed the errors of the resources which failed to be deleted.
+	Deleted []string          `json:"deleted,omitempty"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// Swept returns the decisions to sweep.
+func (r *Report) Swept() []Decision {
+	var swept []Decision
+	for _, d := range r.Decisions {
+		if d.Sweep {
+			swept = append(swept, d)
+		}
+	}
+	return swept
+}
+
+// Sweep lists the resources, decides which are leaked, and deletes them in the order of Kinds unless DryRun is set.
+// Deletion failures don't stop the sweep, they are returned joined once every resource was tried.
+func (s Sweeper) Sweep(ctx context.Context) (*Report, error) {
+	resources, err := s.Inventory.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list resources: %w", err)
+	}
+	sortResources(resources)
+
+	report := &Report{DryRun: s.DryRun}
+	for _, r := range resources {
+		report.Decisions = append(report.Decisions, s.Policy.Decide(r))
+	}
+	if s.DryRun {
+		return report, nil
+	}
+
+	var errs []error
+	for _, d := range report.Swept() {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+		if err := s.Deleter.Delete(ctx, d.Resource); err != nil {
+			if report.Failed == nil {
+				report.Failed = map[string]string{}
+			}
+			report.Failed[d.ID] = err.Error()
+			errs = append(errs, fmt.Errorf("delete %s: %w", d.ID, err))
+			continue
+		}
+		report.Deleted = append(report.Deleted, d.ID)
+		if s.Logf != nil {
+			s.Logf("deleted %s (%s)", d.ID, d.Reason)
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// WriteText writes the report as a table of the e2e-owned resources, with the decision about each of them, followed by
+// the deletion failures. Resources which aren't e2e-owned are only counted.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tKIND\tRESOURCE GROUP\tNAME\tOWNER\tBUILD\tREASON")
+	var swept, notOwned int
+	for _, d := range r.Decisions {
+		if _, ok := d.Tags[TagE2E]; !ok {
+			notOwned++
+			continue
+		}
+		action := "keep"
+		if d.Sweep {
+			swept++
+			action = "delete"
+			if r.DryRun {
+				action = "would delete"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", action, path.Base(string(d.Kind)), d.ResourceGroup, d.Name, d.Tags[TagOwner], d.Tags[TagBuildID], d.Reason)
+	}
+	fmt.Fprintf(tw, "\n%d resources, %d swept, %d not created by the e2e tests\n", len(r.Decisions), swept, notOwned)
+	ids := make([]string, 0, len(r.Failed))
+	for id := range r.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(tw, "failed to delete %s: %s\n", id, r.Failed[id])
+	}
+	return tw.Flush()
+}
+
+// sortResources sorts resources in deletion order, then by ID.
+func sortResources(resources []Resource) {
+	order := func(k Kind) int {
+		if i := slices.Index(Kinds, k); i >= 0 {
+			return i
+		}
+		return len(Kinds)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if oi, oj := order(resources[i].Kind), order(resources[j].Kind); oi != oj {
+			return oi < oj
+		}
+		return resources[i].ID < resources[j].ID
+	})
+}
@@ -0,0 +1,147 @@
+package sweeper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+type fakeAzure struct {
+	resources []Resource
+	listErr   error
+	deleteErr map[string]error
+	deleted   []string
+}
+
+func (f *fakeAzure) List(context.Context) ([]Resource, error) {
+	return f.resources, f.listErr
+}
+
+func (f *fakeAzure) Delete(_ context.Context, r Resource) error {
+	if err := f.deleteErr[r.ID]; err != nil {
+		return err
+	}
+	f.deleted = append(f.deleted, r.ID)
+	return nil
+}
+
+func resource(kind Kind, name string, age time.Duration, tags map[string]string) Resource {
+	return Resource{
+		ID:            "/subscriptions/s/resourceGroups/abe2e-westus3/providers/" + string(kind) + "/" + name,
+		Kind:          kind,
+		Name:          name,
+		ResourceGroup: "abe2e-westus3",
+		Tags:          tags,
+		CreatedAt:     now.Add(-age),
+	}
+}
+
+func owned(buildID string) map[string]string {
+	return map[string]string{TagE2E: "true", TagOwner: "alice@example.com", TagBuildID: buildID}
+}
+
+func TestDecide(t *testing.T) {
+	policy := DefaultPolicy(50 * time.Minute)
+	policy.Now = now
+	policy.AbortedBuildIDs = []string{"aborted"}
+	policy.ActiveBuildIDs = []string{"running"}
+
+	for _, c := range []struct {
+		name     string
+		resource Resource
+		sweep    bool
+		reason   string
+	}{
+		{"old VMSS", resource(KindVMSS, "old", 2*time.Hour, owned("1")), true, "2h0m0s old, older than 1h0m0s"},
+		{"young VMSS", resource(KindVMSS, "young", 20*time.Minute, owned("1")), false, "younger than"},
+		{"untagged", resource(KindVMSS, "untagged", 48*time.Hour, map[string]string{"env": "prod"}), false, "not tagged agentbaker-e2e"},
+		{"owner only", resource(KindVMSS, "someone-elses", 48*time.Hour, map[string]string{TagOwner: "bob"}), false, "not created by the e2e tests"},
+		{"kept", resource(KindVMSS, "kept", 48*time.Hour, map[string]string{TagE2E: "true", TagOwner: "bob", TagKeep: "true"}), false, "tagged KEEP_VMSS"},
+		{"agent pool", resource(KindVMSS, "aks-nodepool1-1234-vmss", 48*time.Hour, map[string]string{TagE2E: "true", TagOwner: "bob", TagAKSManagedPool: "nodepool1"}), false, "managed by AKS"},
+		{"active build", resource(KindVMSS, "running", 48*time.Hour, owned("running")), false, "build running is active"},
+		{"aborted build", resource(KindGalleryImageVersion, "1.0.0", time.Minute, owned("aborted")), true, "build aborted was aborted"},
+		{"shared bastion", resource(KindBastion, "abe2e-shared-bastion", 48*time.Hour, owned("aborted")), false, "aren't swept"},
+		{"unknown age", Resource{Kind: KindVMSS, Name: "unknown", Tags: owned("1")}, false, "creation time unknown"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			d := policy.Decide(c.resource)
+			require.Equal(t, c.sweep, d.Sweep, d.Reason)
+			require.Contains(t, d.Reason, c.reason)
+		})
+	}
+}
+
+func TestDecideOwner(t *testing.T) {
+	policy := DefaultPolicy(50 * time.Minute)
+	policy.Now = now
+	policy.Owner = "Alice@example.com"
+	require.True(t, policy.Decide(resource(KindVMSS, "mine", 2*time.Hour, map[string]string{TagE2E: "true", TagOwner: "alice@example.com\n"})).Sweep)
+	d := policy.Decide(resource(KindVMSS, "theirs", 2*time.Hour, map[string]string{TagE2E: "true", TagOwner: "bob"}))
+	require.False(t, d.Sweep)
+	require.Equal(t, "owned by bob", d.Reason)
+	policy.MaxAge[KindBastion] = time.Hour
+	d = policy.Decide(resource(KindBastion, "abe2e-shared-bastion", 48*time.Hour, map[string]string{TagE2E: "true"}))
+	require.False(t, d.Sweep)
+	require.Equal(t, "not owned by Alice@example.com", d.Reason)
+}
+
+func TestSweep(t *testing.T) {
+	gallery := resource(KindGallery, "abe2etestwestus3", 48*time.Hour, owned("1"))
+	version := resource(KindGalleryImageVersion, "1.0.0", 2*time.Hour, owned("1"))
+	vmss := resource(KindVMSS, "old", 2*time.Hour, owned("1"))
+	failing := resource(KindVMSS, "failing", 2*time.Hour, owned("1"))
+	young := resource(KindVMSS, "young", time.Minute, owned("1"))
+	fake := &fakeAzure{
+		resources: []Resource{gallery, version, young, vmss, failing},
+		deleteErr: map[string]error{failing.ID: errors.New("conflict")},
+	}
+	policy := DefaultPolicy(50 * time.Minute)
+	policy.Now = now
+	policy.MaxAge[KindGallery] = 24 * time.Hour
+
+	report, err := Sweeper{Inventory: fake, Deleter: fake, Policy: policy, DryRun: true}.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, fake.deleted)
+	require.Len(t, report.Swept(), 4)
+
+	report, err = Sweeper{Inventory: fake, Deleter: fake, Policy: policy}.Sweep(context.Background())
+	require.ErrorContains(t, err, "conflict")
+	// VMSS and image versions are deleted before the gallery.
+	require.Equal(t, []string{vmss.ID, version.ID, gallery.ID}, fake.deleted)
+	require.Equal(t, fake.deleted, report.Deleted)
+	require.Equal(t, map[string]string{failing.ID: "conflict"}, report.Failed)
+
+	fake.listErr = errors.New("forbidden")
+	_, err = Sweeper{Inventory: fake, Deleter: fake, Policy: policy}.Sweep(context.Background())
+	require.ErrorContains(t, err, "list resources: forbidden")
+}
+
+func TestWriteText(t *testing.T) {
+	report := &Report{
+		DryRun: true,
+		Decisions: []Decision{
+			{Resource: resource(KindVMSS, "old", 2*time.Hour, owned("1")), Sweep: true, Reason: "2h0m0s old, older than 1h0m0s"},
+			{Resource: resource(KindVMSS, "prod", 2*time.Hour, nil), Reason: "not tagged agentbaker-e2e, not created by the e2e tests"},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	require.Contains(t, buf.String(), "would delete  virtualMachineScaleSets  abe2e-westus3   old   alice@example.com  1      2h0m0s old")
+	require.Contains(t, buf.String(), "2 resources, 1 swept, 1 not created by the e2e tests")
+	require.NotContains(t, buf.String(), "prod")
+}
+
+func TestIsE2EResourceGroup(t *testing.T) {
+	a := &Azure{ResourceGroupName: func(location string) string { return "abe2e-" + location }}
+	require.True(t, a.isE2EResourceGroup("abe2e-westus3", "westus3"))
+	require.True(t, a.isE2EResourceGroup("MC_abe2e-westus3_abe2e-kubenet-v4_westus3", "westus3"))
+	require.False(t, a.isE2EResourceGroup("abe2e-westus3", "eastus"))
+	require.False(t, a.isE2EResourceGroup("MC_prod_cluster_westus3", "westus3"))
+	require.False(t, a.isE2EResourceGroup("abe2e-westus3-vhd", "westus3"))
+}
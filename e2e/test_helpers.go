@@ -21,11 +21,11 @@ import (
 	"github.com/Azure/agentbaker/e2e/assert"
 	"github.com/Azure/agentbaker/e2e/components"
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/errorclass"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
-	"k8s.io/apimachinery/pkg/util/wait"
 	ctrruntimelog "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -78,7 +78,8 @@ func newTestCtx(t testing.TB) context.Context {
 func RunScenario(t *testing.T, s *Scenario) {
 	t.Parallel()
 	// Special case for testing VHD caching. Not used by default.
-	if config.Config.TestPreProvision || s.VHDCaching {
+	// plan and list modes render the payloads of the scenario as is, pre-provisioning needs a VM to capture.
+	if !config.Config.PlanOnly && !config.Config.ListScenarios && (config.Config.TestPreProvision || s.VHDCaching) {
 		t.Run("VHDCreation", func(t *testing.T) {
 			t.Parallel()
 			if err := runScenarioWithPreProvision(t, s); err != nil {
@@ -132,8 +133,8 @@ func runScenarioWithPreProvision(t *testing.T, original *Scenario) error {
 				ValidateFileExists(ctx, stage1, "/etc/containerd/config.toml"),
 				ValidateFileExists(ctx, stage1, "/opt/azure/containers/base_prep.complete"),
 				ValidateFileDoesNotExist(ctx, stage1, "/opt/azure/containers/provision.complete"),
-				ValidateSystemdUnitIsRunning(ctx, stage1, "containerd"),
-				ValidateSystemdUnitIsNotRunning(ctx, stage1, "kubelet"),
+				ValidateSystemdUnitIsRunning(ctx, stage1.NodeExecutor(), "containerd"),
+				ValidateSystemdUnitIsNotRunning(ctx, stage1.NodeExecutor(), "kubelet"),
 			)
 		}
 		if validationErr != nil {
@@ -230,10 +231,11 @@ func copyScenario(s *Scenario) *Scenario {
 	copied := *s
 	copied.Config = s.Config
 	copied.cleanup = nil
+	copied.record = nil
 	return &copied
 }
 
-func runScenario(t testing.TB, s *Scenario) error {
+func runScenario(t testing.TB, s *Scenario) (err error) {
 	t = toolkit.WithTestLogger(t)
 	s.T = t
 	if s.cleanup != nil {
@@ -250,6 +252,17 @@ func runScenario(t testing.TB, s *Scenario) error {
 	}
 
 	ctx := newTestCtx(t)
+	if config.Config.ListScenarios {
+		return listScenario(ctx, t, s)
+	}
+	if config.Config.PlanOnly {
+		return planScenario(ctx, t, s)
+	}
+	// registered first so the record is written last, once the cleanups collected the logs.
+	record := newScenarioRecorder(t, s)
+	s.record = record
+	t.Cleanup(func() { record.finish(t, s) })
+	defer func() { record.setError(err) }()
 	cleanup := &scenarioCleanup{}
 	s.cleanup = cleanup
 	t.Cleanup(func() {
@@ -273,10 +286,13 @@ func runScenario(t testing.TB, s *Scenario) error {
 
 	defer toolkit.LogStep(t, "running scenario")()
 
-	cluster, err := s.Config.Cluster(ctx, ClusterRequest{
+	endClusterPhase := record.phase(errorclass.PhaseCluster)
+	cluster, err := s.Config.Cluster(contextWithScenarioRecorder(ctx, record), ClusterRequest{
 		Location:         s.Location,
 		K8sSystemPoolSKU: s.K8sSystemPoolSKU,
 	})
+	endClusterPhase(err)
+	skipScenarioByErrorClass(t, errorclass.PhaseCluster, err)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster: %w", err)
 	}
@@ -320,16 +336,99 @@ func runScenario(t testing.TB, s *Scenario) error {
 	}
 
 	t.Logf("Choosing the private ACR %q for the vm validation", config.GetPrivateACRName(s.Tags.NonAnonymousACR, s.Location))
+	recordNodeExecutor(t, s)
 
-	return validateVM(vmssCtx, s)
+	endValidationPhase := record.phase(errorclass.PhaseValidation)
+	err = validateVM(vmssCtx, s)
+	endValidationPhase(err)
+	return err
 }
 
 func prepareAKSNode(ctx context.Context, s *Scenario) (*ScenarioVM, error) {
 	defer toolkit.LogStep(s.T, "preparing AKS node")()
 
+	endBootstrapPhase := s.record.phase("bootstrap-config")
+	err := prepareBootstrapConfig(ctx, s)
+	endBootstrapPhase(err)
+	if err != nil {
+		return nil, err
+	}
+	if err := skipScenarioByQuery(s.T, s); err != nil {
+		return nil, err
+	}
+
+	gen2Only, err := CachedIsVMSizeGen2Only(ctx, VMSizeSKURequest{
+		Location: s.Location,
+		VMSize:   config.Config.DefaultVMSKU,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking if VM size %q supports only Gen2: %w", config.Config.DefaultVMSKU, err)
+	}
+	if gen2Only && s.Config.VHD.UnsupportedGen2 {
+		s.T.Logf("VM size %q only supports Gen2 hypervisor but image does not, falling back to vm size that supported gen 1 %q", config.Config.DefaultVMSKU, config.DefaultV5VMSKU)
+		config.Config.DefaultVMSKU = config.DefaultV5VMSKU
+	}
+	supportsNVMe, err := CachedVMSizeSupportsNVMe(ctx, VMSizeSKURequest{
+		Location: s.Location,
+		VMSize:   config.Config.DefaultVMSKU,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking if VM size %q supports only NVMe: %w", config.Config.DefaultVMSKU, err)
+	}
+	if supportsNVMe {
+		if s.Config.VHD.UnsupportedNVMe {
+			s.T.Logf("VM size %q supports NVMe disk controller but image does not support NVMe, falling back to vm size that supports SCSI %q", config.Config.DefaultVMSKU, config.DefaultV5VMSKU)
+			config.Config.DefaultVMSKU = config.DefaultV5VMSKU
+		} else {
+			s.Config.UseNVMe = true
+		}
+	}
+
+	start := time.Now() // Record the start time
+	endVMSSPhase := s.record.phase(errorclass.PhaseVMSS)
+	scenarioVM, err := ConfigureAndCreateVMSS(ctx, s)
+	endVMSSPhase(err)
+	// Expected failures are checked by the runner; cleanup still collects debug information.
+	if s.ExpectedError != "" {
+		return scenarioVM, err
+	}
+	if err != nil {
+		return scenarioVM, fmt.Errorf("create vmss %q, check %s for vm logs: %w", s.Runtime.VMSSName, testDir(s.T), err)
+	}
+	if scenarioVM == nil || scenarioVM.VM == nil {
+		return nil, fmt.Errorf("create vmss %q returned an incomplete VM", s.Runtime.VMSSName)
+	}
+
+	endCSEPhase := s.record.phase(errorclass.PhaseCSE)
+	err = getCustomScriptExtensionStatus(s, scenarioVM.VM)
+	endCSEPhase(err)
+	if err != nil {
+		return scenarioVM, err
+	}
+
+	if !s.Config.SkipDefaultValidation {
+		vmssCreatedAt := time.Now()         // Record the start time
+		creationElapse := time.Since(start) // Calculate the elapsed time
+		endNodeReadyPhase := s.record.phase(errorclass.PhaseNodeReady)
+		scenarioVM.KubeName, err = s.Runtime.Kube.WaitUntilNodeReady(ctx, s.T, s.Runtime.VMSSName)
+		endNodeReadyPhase(err)
+		if err != nil {
+			return scenarioVM, err
+		}
+		readyElapse := time.Since(vmssCreatedAt) // Calculate the elapsed time
+		totalElapse := time.Since(start)
+		toolkit.LogDuration(ctx, totalElapse, 3*time.Minute, fmt.Sprintf("Node %s took %s to be created and %s to be ready", s.Runtime.VMSSName, creationElapse, readyElapse))
+	}
+
+	return scenarioVM, nil
+}
+
+// prepareBootstrapConfig builds the scenario's NodeBootstrappingConfiguration, and AKSNodeConfig when the scenario
+// mutates it, from the scenario cluster and stores them in the scenario runtime.
+func prepareBootstrapConfig(ctx context.Context, s *Scenario) error {
 	nbc, err := getBaseNBC(ctx, s.Runtime.Cluster, s.VHD)
 	if err != nil {
-		return nil, fmt.Errorf("get base node bootstrapping configuration: %w", err)
+		return fmt.Errorf("get base node bootstrapping configuration: %w", err)
 	}
 
 	if !config.Config.DisableScriptless {
@@ -350,20 +449,20 @@ func prepareAKSNode(ctx context.Context, s *Scenario) (*ScenarioVM, error) {
 	}
 	if s.BootstrapConfigMutatorWithError != nil {
 		if err := s.BootstrapConfigMutatorWithError(ctx, s.Runtime.Cluster, nbc); err != nil {
-			return nil, fmt.Errorf("mutate bootstrap configuration: %w", err)
+			return fmt.Errorf("mutate bootstrap configuration: %w", err)
 		}
 	}
 	if s.AKSNodeConfigMutator != nil {
 		nodeconfig, err := nbcToAKSNodeConfigV1(nbc)
 		if err != nil {
-			return nil, fmt.Errorf("convert NBC to AKS node config: %w", err)
+			return fmt.Errorf("convert NBC to AKS node config: %w", err)
 		}
 		s.AKSNodeConfigMutator(s.Runtime.Cluster, nodeconfig)
 		s.Runtime.AKSNodeConfig = nodeconfig
 
 		aksNodeConfigJSON, err := nodeconfigutils.MarshalConfigurationV1(nodeconfig)
 		if err != nil {
-			return nil, fmt.Errorf("marshal AKS node config: %w", err)
+			return fmt.Errorf("marshal AKS node config: %w", err)
 		}
 		s.Runtime.NBC.AKSNodeConfigJSON = string(aksNodeConfigJSON)
 
@@ -388,67 +487,32 @@ func prepareAKSNode(ctx context.Context, s *Scenario) (*ScenarioVM, error) {
 		// the Linux SSH keys for Windows SSH to work. Yeah. I find it odd too.
 		s.Runtime.NBC.ContainerService.Properties.LinuxProfile.SSH.PublicKeys = append(s.Runtime.NBC.ContainerService.Properties.LinuxProfile.SSH.PublicKeys, publicKeyData)
 	}
+	return nil
+}
 
-	gen2Only, err := CachedIsVMSizeGen2Only(ctx, VMSizeSKURequest{
-		Location: s.Location,
-		VMSize:   config.Config.DefaultVMSKU,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("checking if VM size %q supports only Gen2: %w", config.Config.DefaultVMSKU, err)
-	}
-	if gen2Only && s.Config.VHD.UnsupportedGen2 {
-		s.T.Logf("VM size %q only supports Gen2 hypervisor but image does not, falling back to vm size that supported gen 1 %q", config.Config.DefaultVMSKU, config.DefaultV5VMSKU)
-		config.Config.DefaultVMSKU = config.DefaultV5VMSKU
+func maybeSkipScenario(ctx context.Context, t testing.TB, s *Scenario) error {
+	if err := skipScenarioByTags(t, s); err != nil {
+		return err
 	}
-	supportsNVMe, err := CachedVMSizeSupportsNVMe(ctx, VMSizeSKURequest{
+
+	resourceID, err := CachedPrepareVHD(ctx, GetVHDRequest{
+		Image:    *s.VHD,
 		Location: s.Location,
-		VMSize:   config.Config.DefaultVMSKU,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("checking if VM size %q supports only NVMe: %w", config.Config.DefaultVMSKU, err)
-	}
-	if supportsNVMe {
-		if s.Config.VHD.UnsupportedNVMe {
-			s.T.Logf("VM size %q supports NVMe disk controller but image does not support NVMe, falling back to vm size that supports SCSI %q", config.Config.DefaultVMSKU, config.DefaultV5VMSKU)
-			config.Config.DefaultVMSKU = config.DefaultV5VMSKU
-		} else {
-			s.Config.UseNVMe = true
-		}
-	}
-
-	start := time.Now() // Record the start time
-	scenarioVM, err := ConfigureAndCreateVMSS(ctx, s)
-	// Expected failures are checked by the runner; cleanup still collects debug information.
-	if s.ExpectedError != "" {
-		return scenarioVM, err
-	}
-	if err != nil {
-		return scenarioVM, fmt.Errorf("create vmss %q, check %s for vm logs: %w", s.Runtime.VMSSName, testDir(s.T), err)
-	}
-	if scenarioVM == nil || scenarioVM.VM == nil {
-		return nil, fmt.Errorf("create vmss %q returned an incomplete VM", s.Runtime.VMSSName)
-	}
-
-	if err := getCustomScriptExtensionStatus(s, scenarioVM.VM); err != nil {
-		return scenarioVM, err
-	}
-
-	if !s.Config.SkipDefaultValidation {
-		vmssCreatedAt := time.Now()         // Record the start time
-		creationElapse := time.Since(start) // Calculate the elapsed time
-		scenarioVM.KubeName, err = s.Runtime.Kube.WaitUntilNodeReady(ctx, s.T, s.Runtime.VMSSName)
-		if err != nil {
-			return scenarioVM, err
+		if config.Config.IgnoreScenariosWithMissingVHD && errors.Is(err, config.ErrNotFound) {
+			t.Skipf("skipping scenario %q: could not find image for VHD %s due to %s", t.Name(), s.VHD.Distro, err)
 		}
-		readyElapse := time.Since(vmssCreatedAt) // Calculate the elapsed time
-		totalElapse := time.Since(start)
-		toolkit.LogDuration(ctx, totalElapse, 3*time.Minute, fmt.Sprintf("Node %s took %s to be created and %s to be ready", s.Runtime.VMSSName, creationElapse, readyElapse))
+		return fmt.Errorf("failing scenario %q: could not find image for VHD %s: %w", t.Name(), s.VHD.Distro, err)
 	}
-
-	return scenarioVM, nil
+	s.record.setVHDResourceID(resourceID)
+	t.Logf("TAGS %+v", s.Tags)
+	return nil
 }
 
-func maybeSkipScenario(ctx context.Context, t testing.TB, s *Scenario) error {
+// skipScenarioByTags sets the scenario tags derived from the test and its VHD, and skips the scenario when the tags do
+// not match TAGS_TO_RUN or match TAGS_TO_SKIP, or when SCENARIO_QUERY is known not to match.
+func skipScenarioByTags(t testing.TB, s *Scenario) error {
 	s.Tags.Name = t.Name()
 	s.Tags.OS = string(s.VHD.OS)
 	s.Tags.Arch = s.VHD.Arch
@@ -474,19 +538,7 @@ func maybeSkipScenario(ctx context.Context, t testing.TB, s *Scenario) error {
 			t.Skipf("skipping scenario %q: scenario tags %+v matches filter %q", t.Name(), s.Tags, config.Config.TagsToSkip)
 		}
 	}
-
-	_, err := CachedPrepareVHD(ctx, GetVHDRequest{
-		Image:    *s.VHD,
-		Location: s.Location,
-	})
-	if err != nil {
-		if config.Config.IgnoreScenariosWithMissingVHD && errors.Is(err, config.ErrNotFound) {
-			t.Skipf("skipping scenario %q: could not find image for VHD %s due to %s", t.Name(), s.VHD.Distro, err)
-		}
-		return fmt.Errorf("failing scenario %q: could not find image for VHD %s: %w", t.Name(), s.VHD.Distro, err)
-	}
-	t.Logf("TAGS %+v", s.Tags)
-	return nil
+	return skipScenarioByQuery(t, s)
 }
 
 func ValidateNodeCanRunAPod(ctx context.Context, s *Scenario) error {
@@ -529,7 +581,7 @@ func validateVM(ctx context.Context, s *Scenario) error {
 
 	var errs []error
 	if !s.Config.SkipDefaultValidation {
-		errs = append(errs, ValidateNodeCanRunAPod(ctx, s))
+		errs = append(errs, validate(ctx, s, "NodeCanRunAPod", ValidateNodeCanRunAPod))
 		switch s.VHD.OS {
 		case config.OSWindows:
 			errs = append(errs, ValidateCommonWindows(ctx, s))
@@ -538,9 +590,10 @@ func validateVM(ctx context.Context, s *Scenario) error {
 		}
 	}
 
-	// test-specific validation
+	// test-specific validation, recorded under the scenario name so the validators of different scenarios are
+	// told apart in the aggregated reports.
 	if s.Config.Validator != nil {
-		errs = append(errs, s.Config.Validator(ctx, s))
+		errs = append(errs, validate(ctx, s, s.T.Name(), s.Config.Validator))
 	}
 	err := errors.Join(errs...)
 	if err != nil {
@@ -628,6 +681,7 @@ func getCustomScriptExtensionStatus(s *Scenario, vmssVM *armcompute.VirtualMachi
 				if err != nil {
 					return fmt.Errorf("parse CSE message with error, error %w", err)
 				}
+				s.record.setCSEExitCode(resp.ExitCode)
 				if resp.ExitCode != "0" {
 					return fmt.Errorf("vmssCSE %s, output=%s, error=%s, cse output: %s", resp.ExitCode, resp.Output, resp.Error, *status.Message)
 				}
@@ -1030,6 +1084,7 @@ func CreateSIGImageVersionFromDisk(ctx context.Context, s *Scenario, version str
 	s.T.Logf("Creating gallery image version: %s in %s", version, *image.ID)
 	createVersionOp, err := config.Azure.GalleryImageVersions.BeginCreateOrUpdate(ctx, rg, *gallery.Name, *image.Name, version, armcompute.GalleryImageVersion{
 		Location: to.Ptr(s.Location),
+		Tags:     ownershipTags(),
 		Properties: &armcompute.GalleryImageVersionProperties{
 			StorageProfile: &armcompute.GalleryImageVersionStorageProfile{
 				OSDiskImage: &armcompute.GalleryOSDiskImage{
@@ -1076,76 +1131,34 @@ func CreateSIGImageVersionFromDisk(ctx context.Context, s *Scenario, version str
 	return &customVHD, nil
 }
 
-// isRebootRelatedSSHError checks if the error is related to a system reboot
-func isRebootRelatedSSHError(err error, stderr string) bool {
-	if err == nil {
-		return false
-	}
-
-	rebootIndicators := []string{
-		"System is going down",
-		"pam_nologin",
-		"Connection closed by",
-		"Connection refused",
-		"Connection timed out",
-	}
-
-	errMsg := err.Error()
-	for _, indicator := range rebootIndicators {
-		if strings.Contains(errMsg, indicator) || strings.Contains(stderr, indicator) {
-			return true
-		}
-	}
-	return false
-}
-
 func validateSSHConnectivity(ctx context.Context, s *Scenario) error {
 	// If WaitForSSHAfterReboot is not set, use the original single-attempt behavior
 	if s.Config.WaitForSSHAfterReboot == 0 {
 		return attemptSSHConnection(ctx, s)
 	}
 
-	// Retry logic with exponential backoff for scenarios that may reboot
+	// Errors of a rebooting node are retried, as classified by the ssh-node-rebooting rule
 	s.T.Logf("SSH connectivity validation will retry for up to %s if reboot-related errors are encountered", s.Config.WaitForSSHAfterReboot)
 	startTime := time.Now()
-	var lastSSHError error
-
-	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, s.Config.WaitForSSHAfterReboot, true, func(ctx context.Context) (bool, error) {
-		err := attemptSSHConnection(ctx, s)
-		if err == nil {
-			elapsed := time.Since(startTime)
-			s.T.Logf("SSH connectivity established after %s", elapsed)
-			return true, nil
-		}
-
-		// Save the last error for better error messages
-		lastSSHError = err
-
-		// Extract stderr from the error
-		stderr := ""
-		if strings.Contains(err.Error(), "Stderr:") {
-			parts := strings.Split(err.Error(), "Stderr:")
-			if len(parts) > 1 {
-				stderr = parts[1]
-			}
-		}
-
-		// Check if this is a reboot-related error
-		if isRebootRelatedSSHError(err, stderr) {
-			s.T.Logf("Detected reboot-related SSH error, will retry: %v", err)
-			return false, nil // Continue polling
-		}
-
-		// Not a reboot error, fail immediately
-		return false, err
+	ctx, cancel := context.WithTimeout(ctx, s.Config.WaitForSSHAfterReboot)
+	defer cancel()
+	retrier := errorclass.Retrier{
+		Phase:       errorclass.PhaseSSH,
+		BeforeRetry: func(ctx context.Context, a errorclass.Attempt) { s.record.retried(a) },
+		Logf:        s.T.Logf,
+	}
+	result, err := retrier.Do(ctx, func(ctx context.Context) error {
+		return attemptSSHConnection(ctx, s)
 	})
+	if err == nil {
+		s.T.Logf("SSH connectivity established after %s", time.Since(startTime))
+		return nil
+	}
 
 	// If we timed out while retrying reboot-related errors, provide a better error message
-	if err != nil && lastSSHError != nil {
-		elapsed := time.Since(startTime)
-		return fmt.Errorf("SSH connection failed after waiting %s for node to reboot and come back up. Last SSH error: %w", elapsed, lastSSHError)
+	if len(result.Retried) > 0 {
+		return fmt.Errorf("SSH connection failed after waiting %s for node to reboot and come back up. Last SSH error: %w", time.Since(startTime), err)
 	}
-
 	return err
 }
 
@@ -1197,15 +1210,15 @@ func runScenarioUbuntu2404GPUNPD(vmSize, location, k8sSystemPoolSKU string) *Sce
 			Validator: func(ctx context.Context, s *Scenario) error {
 				// First, ensure nvidia-modprobe install does not restart kubelet and temporarily cause node to be unschedulable
 				if err := errors.Join(
-					ValidateNvidiaModProbeInstalled(ctx, s),
-					ValidateKubeletHasNotStopped(ctx, s),
-					ValidateServicesDoNotRestartKubelet(ctx, s),
+					ValidateNvidiaModProbeInstalled(ctx, s.NodeExecutor()),
+					ValidateKubeletHasNotStopped(ctx, s.NodeExecutor()),
+					ValidateServicesDoNotRestartKubelet(ctx, s.NodeExecutor()),
 				); err != nil {
 					return err
 				}
 
 				// Then validate NPD configuration and GPU monitoring
-				if err := ValidateNPDGPUCountPlugin(ctx, s); err != nil {
+				if err := ValidateNPDGPUCountPlugin(ctx, s.NodeExecutor()); err != nil {
 					return err
 				}
 				if err := ValidateNPDGPUCountCondition(ctx, s); err != nil {
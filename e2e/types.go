@@ -4,16 +4,20 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"maps"
 	"os/exec"
 	"os/user"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	aksnodeconfigv1 "github.com/Azure/agentbaker/aks-node-controller/pkg/gen/aksnodeconfig/v1"
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/e2e/sweeper"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
@@ -152,6 +156,7 @@ type Scenario struct {
 	Runtime *ScenarioRuntime
 	T       testing.TB
 	cleanup *scenarioCleanup
+	record  *scenarioRecorder
 }
 
 type ScenarioRuntime struct {
@@ -163,6 +168,9 @@ type ScenarioRuntime struct {
 	VMSSName                  string
 	EnableScriptlessNBCCSECmd bool
 	CSETimingReport           *CSETimingReport // eagerly extracted before GA can sweep events
+	// Executor runs the validator commands on the node. Real runs record the commands, a nodeexec.Replay runs the
+	// validators against a recording.
+	Executor nodeexec.NodeExecutor
 }
 
 type ScenarioVM struct {
@@ -409,13 +417,29 @@ func (s *Scenario) updateTags(ctx context.Context, vmss *armcompute.VirtualMachi
 
 	// don't clean up VMSS in other tests
 	if config.Config.KeepVMSS {
-		vmss.Tags["KEEP_VMSS"] = to.Ptr("true")
+		vmss.Tags[sweeper.TagKeep] = to.Ptr("true")
 	}
 
+	maps.Copy(vmss.Tags, ownershipTags())
+}
+
+// ownershipTags returns the tags identifying the resources created by a test run, which the sweeper discovers
+// leaked resources by.
+func ownershipTags() map[string]*string {
+	tags := map[string]*string{sweeper.TagE2E: to.Ptr("true"), sweeper.TagOwner: to.Ptr(testOwner())}
 	if config.Config.BuildID != "" {
-		vmss.Tags[buildIDTagKey] = &config.Config.BuildID
+		tags[buildIDTagKey] = to.Ptr(config.Config.BuildID)
 	}
+	return tags
+}
 
+// sharedTags returns the tags of the resources shared by the runs of a location, which aren't owned by a run.
+func sharedTags() map[string]*string {
+	return map[string]*string{sweeper.TagE2E: to.Ptr("true")}
+}
+
+// testOwner returns the logged in Azure user, or the local user when az isn't logged in.
+var testOwner = sync.OnceValue(func() string {
 	owner, err := getLoggedInAzUser()
 	if err != nil {
 		owner, err = getLocalUsername()
@@ -423,8 +447,8 @@ func (s *Scenario) updateTags(ctx context.Context, vmss *armcompute.VirtualMachi
 			owner = "unknown"
 		}
 	}
-	vmss.Tags["owner"] = to.Ptr(owner)
-}
+	return strings.TrimSpace(owner)
+})
 
 func getLoggedInAzUser() (string, error) {
 	// Define the command and arguments
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Azure/agentbaker/e2e/assert"
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	corev1 "k8s.io/api/core/v1"
@@ -44,60 +45,71 @@ func ValidatePodRunning(ctx context.Context, s *Scenario, pod *corev1.Pod) error
 	return nil
 }
 
+// onNode adapts a validator running its commands on the node to the validators of a scenario.
+func onNode(validator func(context.Context, nodeexec.NodeExecutor) error) func(context.Context, *Scenario) error {
+	return func(ctx context.Context, s *Scenario) error {
+		return validator(ctx, s.NodeExecutor())
+	}
+}
+
 func ValidateCommonLinux(ctx context.Context, s *Scenario) error {
 	// Every validator below is independent, so all of them run and their failures are
 	// reported together instead of stopping at the first one.
 	errs := []error{
-		ValidateTLSBootstrapping(ctx, s),
-		ValidateKubeletServingCertificateRotation(ctx, s),
-		ValidateSystemdWatchdogForKubernetes132Plus(ctx, s),
-		ValidateAKSLogCollector(ctx, s),
-		ValidateDiskQueueService(ctx, s),
-		ValidateLeakedSecrets(ctx, s),
-		ValidateKubeletActiveFlagsEvent(ctx, s),
-		ValidateIPTablesCompatibleWithCiliumEBPF(ctx, s),
-		ValidateRxBufferDefault(ctx, s),
+		validate(ctx, s, "TLSBootstrapping", ValidateTLSBootstrapping),
+		validate(ctx, s, "KubeletServingCertificateRotation", ValidateKubeletServingCertificateRotation),
+		validate(ctx, s, "SystemdWatchdogForKubernetes132Plus", ValidateSystemdWatchdogForKubernetes132Plus),
+		validate(ctx, s, "AKSLogCollector", onNode(ValidateAKSLogCollector)),
+		validate(ctx, s, "DiskQueueService", onNode(ValidateDiskQueueService)),
+		validate(ctx, s, "LeakedSecrets", ValidateLeakedSecrets),
+		validate(ctx, s, "KubeletActiveFlagsEvent", ValidateKubeletActiveFlagsEvent),
+		validate(ctx, s, "IPTablesCompatibleWithCiliumEBPF", onNode(ValidateIPTablesCompatibleWithCiliumEBPF)),
+		validate(ctx, s, "RxBufferDefault", ValidateRxBufferDefault),
 	}
 
 	// Validate MANA (Accelerated Networking) when hardware is present.
 	// MANA is the standard network adapter on V5+ VM series.
-	hasMANA, err := hasMANAHardware(ctx, s)
+	hasMANA, err := hasMANAHardware(ctx, s.NodeExecutor())
 	switch {
 	case err != nil:
 		errs = append(errs, fmt.Errorf("failed to detect MANA hardware: %w", err))
 	case hasMANA:
-		errs = append(errs, ValidateMANA(ctx, s))
+		errs = append(errs, validate(ctx, s, "MANA", ValidateMANA))
 	}
 
 	errs = append(errs,
-		ValidateKernelLogs(ctx, s),
-		ValidateWaagentLog(ctx, s),
-		ValidateScriptlessCSECmd(ctx, s),
-		ValidateScriptlessNBCCSECmd(ctx, s),
-		ValidateScriptlessPhase3(ctx, s),
-		ValidateNodeExporter(ctx, s),
-
-		ValidateSysctlConfig(ctx, s, map[string]string{
-			"net.ipv4.tcp_retries2":             "8",
-			"net.core.message_burst":            "80",
-			"net.core.message_cost":             "40",
-			"net.core.somaxconn":                "16384",
-			"net.ipv4.tcp_max_syn_backlog":      "16384",
-			"net.ipv4.neigh.default.gc_thresh1": "4096",
-			"net.ipv4.neigh.default.gc_thresh2": "8192",
-			"net.ipv4.neigh.default.gc_thresh3": "16384",
+		validate(ctx, s, "KernelLogs", ValidateKernelLogs),
+		validate(ctx, s, "WaagentLog", ValidateWaagentLog),
+		validate(ctx, s, "ScriptlessCSECmd", ValidateScriptlessCSECmd),
+		validate(ctx, s, "ScriptlessNBCCSECmd", ValidateScriptlessNBCCSECmd),
+		validate(ctx, s, "ScriptlessPhase3", ValidateScriptlessPhase3),
+		validate(ctx, s, "NodeExporter", ValidateNodeExporter),
+
+		validate(ctx, s, "SysctlConfig", func(ctx context.Context, s *Scenario) error {
+			return ValidateSysctlConfig(ctx, s.NodeExecutor(), map[string]string{
+				"net.ipv4.tcp_retries2":             "8",
+				"net.core.message_burst":            "80",
+				"net.core.message_cost":             "40",
+				"net.core.somaxconn":                "16384",
+				"net.ipv4.tcp_max_syn_backlog":      "16384",
+				"net.ipv4.neigh.default.gc_thresh1": "4096",
+				"net.ipv4.neigh.default.gc_thresh2": "8192",
+				"net.ipv4.neigh.default.gc_thresh3": "16384",
+			})
 		}),
-		ValidateDirectoryContent(ctx, s, "/var/log/azure/aks", []string{
-			"cluster-provision.log",
-			"cluster-provision-cse-output.log",
-			"cloud-init-files.paved",
-			"vhd-install.complete",
+		validate(ctx, s, "DirectoryContent", func(ctx context.Context, s *Scenario) error {
+			return ValidateDirectoryContent(ctx, s, "/var/log/azure/aks", []string{
+				"cluster-provision.log",
+				"cluster-provision-cse-output.log",
+				"cloud-init-files.paved",
+				"vhd-install.complete",
+			})
 		}),
 	)
 
 	// kubeletNodeIPValidator cannot be run on older VHDs with kubelet < 1.29
 	if !s.VHD.UnsupportedKubeletNodeIP {
-		errs = append(errs, ValidateKubeletNodeIP(ctx, s))
+		errs = append(errs, validate(ctx, s, "KubeletNodeIP", onNode(ValidateKubeletNodeIP)))
 	}
 
 	// localdns validation is skipped for VHDs with UnsupportedLocalDns=true:
@@ -105,9 +117,13 @@ func ValidateCommonLinux(ctx context.Context, s *Scenario) error {
 	// See e2e/config/vhd.go for the full list.
 	if !s.VHD.UnsupportedLocalDns && !config.Config.TestPreProvision && !s.VHDCaching {
 		errs = append(errs,
-			ValidateLocalDNSService(ctx, s, "enabled"),
-			ValidateLocalDNSResolution(ctx, s, "169.254.10.10"),
-			ValidateLocalDNSExporterMetrics(ctx, s),
+			validate(ctx, s, "LocalDNSService", func(ctx context.Context, s *Scenario) error {
+				return ValidateLocalDNSService(ctx, s.NodeExecutor(), "enabled")
+			}),
+			validate(ctx, s, "LocalDNSResolution", func(ctx context.Context, s *Scenario) error {
+				return ValidateLocalDNSResolution(ctx, s.NodeExecutor(), "169.254.10.10")
+			}),
+			validate(ctx, s, "LocalDNSExporterMetrics", ValidateLocalDNSExporterMetrics),
 		)
 
 		// Validate hosts plugin validators only if hosts plugin is explicitly enabled
@@ -116,7 +132,7 @@ func ValidateCommonLinux(ctx context.Context, s *Scenario) error {
 			// The Agentbaker E2E pipeline uses VHDs from main, which may not yet include
 			// aks-localdns-hosts-setup artifacts until the PR merges. This mirrors the pattern
 			// used by PR #7917 for the localdns-exporter feature.
-			hasHostsPluginArtifacts, err := vhdHasHostsPluginArtifacts(ctx, s)
+			hasHostsPluginArtifacts, err := vhdHasHostsPluginArtifacts(ctx, s.NodeExecutor())
 			switch {
 			case err != nil:
 				errs = append(errs, fmt.Errorf("failed to detect hosts plugin artifacts on the VHD: %w", err))
@@ -127,58 +143,63 @@ func ValidateCommonLinux(ctx context.Context, s *Scenario) error {
 					// Validate hosts file contains resolved IPs for critical FQDNs (IPs resolved dynamically).
 					// CSE sets up the hosts file and enables the aks-localdns-hosts-setup timer, but population
 					// is performed asynchronously by the timer/service rather than synchronously during provisioning.
-					ValidateLocalDNSHostsFile(ctx, s, s.GetDefaultFQDNsForValidation()),
+					validate(ctx, s, "LocalDNSHostsFile", func(ctx context.Context, s *Scenario) error {
+						return ValidateLocalDNSHostsFile(ctx, s.NodeExecutor(), s.GetDefaultFQDNsForValidation())
+					}),
 					// Validate aks-localdns-hosts-setup service ran successfully and timer is active
-					ValidateAKSLocalDNSHostsSetupService(ctx, s),
+					validate(ctx, s, "AKSLocalDNSHostsSetupService", onNode(ValidateAKSLocalDNSHostsSetupService)),
 					// No restart needed: select_localdns_corefile() uses feature flag to select WITH_HOSTS corefile,
 					// and CoreDNS's reload 5s hot-reloads the hosts file when it gets populated.
 					// Validate hosts plugin serves responses with IPs matching /etc/localdns/hosts
-					ValidateLocalDNSHostsPluginBypass(ctx, s),
+					validate(ctx, s, "LocalDNSHostsPluginBypass", ValidateLocalDNSHostsPluginBypass),
 					// Validate IPv6 entries in hosts file are served correctly by CoreDNS (skips if no IPv6 present)
-					ValidateLocalDNSHostsPluginIPv6(ctx, s),
+					validate(ctx, s, "LocalDNSHostsPluginIPv6", onNode(ValidateLocalDNSHostsPluginIPv6)),
 					// Validate localdns cold start with empty hosts file: restart → fallthrough → populate → reload
-					ValidateLocalDNSHostsPluginColdStart(ctx, s),
+					validate(ctx, s, "LocalDNSHostsPluginColdStart", onNode(ValidateLocalDNSHostsPluginColdStart)),
 				)
 			}
 		}
 	}
 
-	errs = append(errs, ValidateInspektorGadget(ctx, s))
-
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo cat /etc/default/kubelet", 0, "could not read kubelet config")
-	if err != nil {
-		errs = append(errs, err)
-	} else {
-		errs = append(errs, assert.NotContains(execResult.stdout, "--dynamic-config-dir",
-			"kubelet flag '--dynamic-config-dir' should not be present in /etc/default/kubelet\nContents:\n%s", execResult.stdout))
-	}
-
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo curl http://168.63.129.16:32526/vmSettings", 0, "curl to wireserver failed"); err != nil {
-		errs = append(errs, err)
-	}
+	errs = append(errs, validate(ctx, s, "InspektorGadget", ValidateInspektorGadget))
 
 	errs = append(errs,
-		validateWireServerBlocked(ctx, s),
-		ValidateVulnerableKernelModulesDisabled(ctx, s),
+		validate(ctx, s, "KubeletDynamicConfigDir", func(ctx context.Context, s *Scenario) error {
+			execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo cat /etc/default/kubelet", 0, "could not read kubelet config")
+			if err != nil {
+				return err
+			}
+			return assert.NotContains(execResult.stdout, "--dynamic-config-dir",
+				"kubelet flag '--dynamic-config-dir' should not be present in /etc/default/kubelet\nContents:\n%s", execResult.stdout)
+		}),
+		validate(ctx, s, "WireServerReachable", func(ctx context.Context, s *Scenario) error {
+			_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo curl http://168.63.129.16:32526/vmSettings", 0, "curl to wireserver failed")
+			return err
+		}),
+		validate(ctx, s, "WireServerBlocked", validateWireServerBlocked),
+		validate(ctx, s, "VulnerableKernelModulesDisabled", func(ctx context.Context, s *Scenario) error {
+			return ValidateVulnerableKernelModulesDisabled(ctx, s.NodeExecutor(), s.VHD)
+		}),
 	)
 
 	// base NBC templates define a mock service principal profile that we can still use to test
 	// the correct bootstrapping logic: https://github.com/Azure/AgentBaker/blob/master/e2e/node_config.go#L438-L441
 	if s.HasServicePrincipalData() {
-		if _, err := execScriptOnVMForScenarioValidateExitCode(
-			ctx,
-			s,
-			`sudo test -n "$(sudo cat /etc/kubernetes/azure.json | jq -r '.aadClientId')" && sudo test -n "$(sudo cat /etc/kubernetes/azure.json | jq -r '.aadClientSecret')"`,
-			0,
-			"AAD client ID and secret should be present in /etc/kubernetes/azure.json"); err != nil {
-			errs = append(errs, err)
-		}
+		errs = append(errs, validate(ctx, s, "AzureJSONServicePrincipal", func(ctx context.Context, s *Scenario) error {
+			_, err := execScriptOnVMForScenarioValidateExitCode(
+				ctx,
+				s,
+				`sudo test -n "$(sudo cat /etc/kubernetes/azure.json | jq -r '.aadClientId')" && sudo test -n "$(sudo cat /etc/kubernetes/azure.json | jq -r '.aadClientSecret')"`,
+				0,
+				"AAD client ID and secret should be present in /etc/kubernetes/azure.json")
+			return err
+		}))
 	}
 
 	errs = append(errs,
 		// ensure that no unexpected systemd units are in a failed state
-		ValidateNoFailedSystemdUnits(ctx, s),
-		ValidateStaleCachedKubeBinariesRemoved(ctx, s),
+		validate(ctx, s, "NoFailedSystemdUnits", ValidateNoFailedSystemdUnits),
+		validate(ctx, s, "StaleCachedKubeBinariesRemoved", onNode(ValidateStaleCachedKubeBinariesRemoved)),
 	)
 
 	return errors.Join(errs...)
@@ -186,8 +207,8 @@ func ValidateCommonLinux(ctx context.Context, s *Scenario) error {
 
 func ValidateCommonWindows(ctx context.Context, s *Scenario) error {
 	return errors.Join(
-		ValidateTLSBootstrapping(ctx, s),
-		ValidateKubeletServingCertificateRotation(ctx, s),
+		validate(ctx, s, "TLSBootstrapping", ValidateTLSBootstrapping),
+		validate(ctx, s, "KubeletServingCertificateRotation", ValidateKubeletServingCertificateRotation),
 	)
 }
 
@@ -350,10 +371,7 @@ func getIPTablesRulesCompatibleWithEBPFHostRouting() (map[string][]string, []str
 func validateWireServerBlocked(ctx context.Context, s *Scenario) error {
 	defer toolkit.LogStep(s.T, "validating wireserver is blocked from unprivileged pods")()
 
-	nonHostPod, err := s.Runtime.Kube.GetPodNetworkDebugPodForNode(ctx, s.Runtime.VM.KubeName)
-	if err != nil {
-		return fmt.Errorf("failed to get non host debug pod for wireserver validation: %w", err)
-	}
+	nonHostPod := debugNonHostPodExecutor(s)
 
 	type wireServerCheck struct {
 		cmd  string
@@ -382,7 +400,7 @@ func validateWireServerBlocked(ctx context.Context, s *Scenario) error {
 		pollErr := wait.PollUntilContextTimeout(ctx, 5*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
 			attemptCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 			defer cancel()
-			r, execErr := execOnUnprivilegedPod(attemptCtx, s.Runtime.Kube, nonHostPod.Namespace, nonHostPod.Name, check.cmd)
+			r, execErr := nonHostPod.Exec(attemptCtx, check.cmd)
 			if execErr != nil {
 				if errors.Is(execErr, context.DeadlineExceeded) {
 					s.T.Logf("wireserver check %q: exec attempt timed out after 15s (retrying): %v", check.desc, execErr)
@@ -391,7 +409,7 @@ func validateWireServerBlocked(ctx context.Context, s *Scenario) error {
 				}
 				return false, nil
 			}
-			execResult = r
+			execResult = newPodExecResult(r)
 			return true, nil
 		})
 		if pollErr != nil {
@@ -413,10 +431,10 @@ func validateWireServerBlocked(ctx context.Context, s *Scenario) error {
 			"iptables-save filter:\n%s\n"+
 			"conntrack:\n%s",
 			check.desc, execResult.exitCode, execResult.stdout, execResult.stderr,
-			collectVMDiagnostic(ctx, s, "sudo iptables -t filter -L FORWARD -v -n --line-numbers"),
-			collectVMDiagnostic(ctx, s, "sudo iptables -t filter -L KUBE-FORWARD -v -n --line-numbers 2>/dev/null || echo 'chain not found'"),
-			collectVMDiagnostic(ctx, s, "sudo iptables-save -t filter 2>/dev/null | head -80"),
-			collectVMDiagnostic(ctx, s, "sudo conntrack -L -d 168.63.129.16 2>/dev/null || echo 'conntrack not available'")))
+			collectVMDiagnostic(ctx, s.NodeExecutor(), "sudo iptables -t filter -L FORWARD -v -n --line-numbers"),
+			collectVMDiagnostic(ctx, s.NodeExecutor(), "sudo iptables -t filter -L KUBE-FORWARD -v -n --line-numbers 2>/dev/null || echo 'chain not found'"),
+			collectVMDiagnostic(ctx, s.NodeExecutor(), "sudo iptables-save -t filter 2>/dev/null | head -80"),
+			collectVMDiagnostic(ctx, s.NodeExecutor(), "sudo conntrack -L -d 168.63.129.16 2>/dev/null || echo 'conntrack not available'")))
 	}
 
 	return errors.Join(errs...)
@@ -425,8 +443,8 @@ func validateWireServerBlocked(ctx context.Context, s *Scenario) error {
 // collectVMDiagnostic runs a diagnostic command on the VM and returns its combined output.
 // It is only used to enrich failure messages, so a collection failure is rendered inline
 // rather than returned - it must never mask the failure being diagnosed.
-func collectVMDiagnostic(ctx context.Context, s *Scenario, cmd string) string {
-	result, err := execScriptOnVMForScenario(ctx, s, cmd)
+func collectVMDiagnostic(ctx context.Context, node nodeexec.NodeExecutor, cmd string) string {
+	result, err := execOnNode(ctx, node, cmd)
 	if err != nil {
 		return fmt.Sprintf("<failed to collect %q: %v>", cmd, err)
 	}
@@ -436,8 +454,8 @@ func collectVMDiagnostic(ctx context.Context, s *Scenario, cmd string) string {
 // vhdHasHostsPluginArtifacts checks if the VHD has aks-localdns-hosts-setup.service installed
 // by running a file existence check on the VM. Returns false if the service file is absent,
 // meaning the VHD predates the hosts plugin feature and validators should be skipped.
-func vhdHasHostsPluginArtifacts(ctx context.Context, s *Scenario) (bool, error) {
-	result, err := execScriptOnVMForScenario(ctx, s, "test -f /etc/systemd/system/aks-localdns-hosts-setup.service")
+func vhdHasHostsPluginArtifacts(ctx context.Context, node nodeexec.NodeExecutor) (bool, error) {
+	result, err := execOnNode(ctx, node, "test -f /etc/systemd/system/aks-localdns-hosts-setup.service")
 	if err != nil {
 		return false, fmt.Errorf("failed to check for aks-localdns-hosts-setup.service on the VM: %w", err)
 	}
@@ -5,11 +5,9 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"net"
 	"os"
 	"regexp"
 	"slices"
@@ -25,7 +23,9 @@ import (
 	"github.com/Azure/agentbaker/e2e/assert"
 	"github.com/Azure/agentbaker/e2e/components"
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
 	"github.com/Azure/agentbaker/e2e/nodeexporter"
+	"github.com/Azure/agentbaker/e2e/nodevalidate"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/agentbaker/pkg/agent"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
@@ -38,6 +38,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// nodeValidateCtx returns a context in which nodevalidate checks log to the test of the context.
+func nodeValidateCtx(ctx context.Context) context.Context {
+	return nodevalidate.WithLogger(ctx, func(format string, args ...any) {
+		toolkit.Logf(ctx, format, args...)
+	})
+}
+
 func ValidateTLSBootstrapping(ctx context.Context, s *Scenario) error {
 	switch s.VHD.OS {
 	case config.OSWindows:
@@ -66,7 +73,7 @@ func validateTLSBootstrappingLinux(ctx context.Context, s *Scenario) error {
 	case s.SecureTLSBootstrappingEnabled():
 		s.T.Logf("will validate bootstrapping mode: secure TLS bootstrapping")
 		errs = append(errs,
-			ValidateSystemdUnitIsRunning(ctx, s, "secure-tls-bootstrap"),
+			ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "secure-tls-bootstrap"),
 			validateKubeletClientCSRCreatedBySecureTLSBootstrapping(ctx, s),
 			assert.Equal(
 				!strings.Contains(kubeletLogs, "unable to validate bootstrap credentials") && strings.Contains(kubeletLogs, "client credential already exists within kubeconfig"),
@@ -77,8 +84,8 @@ func validateTLSBootstrappingLinux(ctx context.Context, s *Scenario) error {
 	default:
 		s.T.Logf("will validate bootstrapping mode: bootstrap token")
 		errs = append(errs,
-			ValidateSystemdUnitIsNotRunning(ctx, s, "secure-tls-bootstrap"),
-			ValidateSystemdUnitIsNotFailed(ctx, s, "secure-tls-bootstrap"),
+			ValidateSystemdUnitIsNotRunning(ctx, s.NodeExecutor(), "secure-tls-bootstrap"),
+			ValidateSystemdUnitIsNotFailed(ctx, s.NodeExecutor(), "secure-tls-bootstrap"),
 			assert.Equal(
 				!strings.Contains(kubeletLogs, "unable to validate bootstrap credentials") && strings.Contains(kubeletLogs, "kubelet bootstrap token credential is valid"),
 				true,
@@ -230,20 +237,20 @@ func ValidateSystemdWatchdogForKubernetes132Plus(ctx context.Context, s *Scenari
 	if k8sVersion := s.GetK8sVersion(); k8sVersion != "" && agent.IsKubernetesVersionGe(k8sVersion, "1.32.0") {
 		// Validate systemd watchdog is enabled and configured for kubelet
 		return errors.Join(
-			ValidateSystemdUnitIsRunning(ctx, s, "kubelet.service"),
+			ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), "kubelet.service"),
 			ValidateFileHasContent(ctx, s, "/etc/systemd/system/kubelet.service.d/10-watchdog.conf", "WatchdogSec=60s"),
-			ValidateJournalctlOutput(ctx, s, "kubelet.service", "Starting systemd watchdog with interval"),
+			ValidateJournalctlOutput(ctx, s.NodeExecutor(), "kubelet.service", "Starting systemd watchdog with interval"),
 		)
 	}
 	return nil
 }
 
-func ValidateAKSLogCollector(ctx context.Context, s *Scenario) error {
-	return ValidateSystemdUnitIsNotFailed(ctx, s, "aks-log-collector")
+func ValidateAKSLogCollector(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return ValidateSystemdUnitIsNotFailed(ctx, node, "aks-log-collector")
 }
 
-func ValidateDiskQueueService(ctx context.Context, s *Scenario) error {
-	return ValidateSystemdUnitIsRunning(ctx, s, "disk_queue.service")
+func ValidateDiskQueueService(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return ValidateSystemdUnitIsRunning(ctx, node, "disk_queue.service")
 }
 
 func ValidateLeakedSecrets(ctx context.Context, s *Scenario) error {
@@ -263,19 +270,19 @@ func ValidateLeakedSecrets(ctx context.Context, s *Scenario) error {
 	return errors.Join(errs...)
 }
 
-func ValidateSSHServiceEnabled(ctx context.Context, s *Scenario) error {
+func ValidateSSHServiceEnabled(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// Verify SSH service is active and running
-	errs := []error{ValidateSystemdUnitIsRunning(ctx, s, "ssh")}
+	errs := []error{ValidateSystemdUnitIsRunning(ctx, node, "ssh")}
 
 	// Verify socket-based activation is disabled
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "systemctl is-active ssh.socket", 3, "could not check ssh.socket status")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, "systemctl is-active ssh.socket", 3, "could not check ssh.socket status")
 	if err != nil {
 		return errors.Join(append(errs, fmt.Errorf("check ssh.socket status: %w", err))...)
 	}
 	errs = append(errs, assert.Contains(execResult.stdout, "inactive", "ssh.socket should be inactive"))
 
 	// Check that systemd recognizes SSH service should be active at boot
-	execResult, err = execScriptOnVMForScenarioValidateExitCode(ctx, s, "systemctl is-enabled ssh.service", 0, "could not check ssh.service status")
+	execResult, err = execOnNodeValidateExitCode(ctx, node, "systemctl is-enabled ssh.service", 0, "could not check ssh.service status")
 	if err != nil {
 		return errors.Join(append(errs, fmt.Errorf("check ssh.service status: %w", err))...)
 	}
@@ -285,8 +292,12 @@ func ValidateSSHServiceEnabled(ctx context.Context, s *Scenario) error {
 
 func ValidateDirectoryContent(ctx context.Context, s *Scenario, path string, files []string) error {
 	s.T.Helper()
+	return validateDirectoryContent(ctx, s.NodeExecutor(), s.IsWindows(), path, files)
+}
+
+func validateDirectoryContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, path string, files []string) error {
 	var steps []string
-	if s.IsWindows() {
+	if windows {
 		steps = []string{
 			"$ErrorActionPreference = \"Stop\"",
 			fmt.Sprintf("Get-ChildItem -Path %s", path),
@@ -297,7 +308,7 @@ func ValidateDirectoryContent(ctx context.Context, s *Scenario, path string, fil
 			fmt.Sprintf("sudo ls -la %s", path),
 		}
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(steps, "\n"), 0, "could not get directory contents")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(steps, "\n"), 0, "could not get directory contents")
 	if err != nil {
 		return fmt.Errorf("get contents of directory %s: %w", path, err)
 	}
@@ -308,86 +319,36 @@ func ValidateDirectoryContent(ctx context.Context, s *Scenario, path string, fil
 	return errors.Join(errs...)
 }
 
-func ValidateSysctlConfig(ctx context.Context, s *Scenario, customSysctls map[string]string) error {
-	s.T.Helper()
-	keysToCheck := make([]string, 0, len(customSysctls))
-	for k := range customSysctls {
-		keysToCheck = append(keysToCheck, k)
-	}
-	command := []string{
-		"set -ex",
-		fmt.Sprintf("sudo sysctl %s | sed -E 's/([0-9])\\s+([0-9])/\\1 \\2/g'", strings.Join(keysToCheck, " ")),
-	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "sysctl command failed")
-	if err != nil {
-		return fmt.Errorf("read sysctl config: %w", err)
-	}
-	var errs []error
-	for name, value := range customSysctls {
-		errs = append(errs, assert.Contains(execResult.stdout, fmt.Sprintf("%s = %v", name, value), "expected to find %s set to %v, but was not.\nStdout:\n%s", name, value, execResult.stdout))
-	}
-	return errors.Join(errs...)
+func ValidateSysctlConfig(ctx context.Context, node nodeexec.NodeExecutor, customSysctls map[string]string) error {
+	return nodevalidate.Sysctls(nodeValidateCtx(ctx), node, customSysctls)
 }
 
 func ValidateCustomLinuxOSConfigPersistsAfterReboot(ctx context.Context, s *Scenario, customSysctls map[string]string, customContainerdUlimits map[string]string, swapFileSizeMB int32, thpEnabled, thpDefrag string) error {
 	s.T.Helper()
-	if err := validateCustomLinuxOSConfig(ctx, s, customSysctls, customContainerdUlimits, swapFileSizeMB, thpEnabled, thpDefrag); err != nil {
+	if err := validateCustomLinuxOSConfig(ctx, s.NodeExecutor(), customSysctls, customContainerdUlimits, swapFileSizeMB, thpEnabled, thpDefrag); err != nil {
 		return err
 	}
 	if err := RebootVMAndWaitForSSH(ctx, s); err != nil {
 		return err
 	}
-	return validateCustomLinuxOSConfig(ctx, s, customSysctls, customContainerdUlimits, swapFileSizeMB, thpEnabled, thpDefrag)
+	return validateCustomLinuxOSConfig(ctx, s.NodeExecutor(), customSysctls, customContainerdUlimits, swapFileSizeMB, thpEnabled, thpDefrag)
 }
 
-func validateCustomLinuxOSConfig(ctx context.Context, s *Scenario, customSysctls map[string]string, customContainerdUlimits map[string]string, swapFileSizeMB int32, thpEnabled, thpDefrag string) error {
-	s.T.Helper()
+func validateCustomLinuxOSConfig(ctx context.Context, node nodeexec.NodeExecutor, customSysctls map[string]string, customContainerdUlimits map[string]string, swapFileSizeMB int32, thpEnabled, thpDefrag string) error {
 	return errors.Join(
-		ValidateSysctlConfig(ctx, s, customSysctls),
-		ValidateUlimitSettings(ctx, s, customContainerdUlimits),
-		ValidateSwapFileConfig(ctx, s, swapFileSizeMB),
-		ValidateTransparentHugePageConfig(ctx, s, thpEnabled, thpDefrag),
+		ValidateSysctlConfig(ctx, node, customSysctls),
+		ValidateUlimitSettings(ctx, node, customContainerdUlimits),
+		ValidateSwapFileConfig(ctx, node, swapFileSizeMB),
+		ValidateTransparentHugePageConfig(ctx, node, thpEnabled, thpDefrag),
 	)
 }
 
-func ValidateTransparentHugePageConfig(ctx context.Context, s *Scenario, thpEnabled, thpDefrag string) error {
-	s.T.Helper()
-	command := []string{"set -ex"}
-	if thpEnabled != "" {
-		command = append(command,
-			"cat /sys/kernel/mm/transparent_hugepage/enabled",
-			fmt.Sprintf("grep -Fq '[%s]' /sys/kernel/mm/transparent_hugepage/enabled", thpEnabled),
-		)
-	}
-	if thpDefrag != "" {
-		command = append(command,
-			"cat /sys/kernel/mm/transparent_hugepage/defrag",
-			fmt.Sprintf("grep -Fq '[%s]' /sys/kernel/mm/transparent_hugepage/defrag", thpDefrag),
-		)
-	}
-
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "transparent huge page configuration did not match expected values")
-	return err
+func ValidateTransparentHugePageConfig(ctx context.Context, node nodeexec.NodeExecutor, thpEnabled, thpDefrag string) error {
+	return nodevalidate.TransparentHugePages(nodeValidateCtx(ctx), node, thpEnabled, thpDefrag)
 }
 
-func ValidateSwapFileConfig(ctx context.Context, s *Scenario, swapFileSizeMB int32) error {
-	s.T.Helper()
-	if swapFileSizeMB <= 0 {
-		return nil
-	}
-
-	command := []string{
-		"set -ex",
-		"swapon --show --bytes",
-		"swap_file=$(awk '$3 == \"swap\" {print $1; exit}' /etc/fstab)",
-		"test -n \"${swap_file}\"",
-		"swapon --show --bytes | grep -F \"${swap_file}\"",
-		fmt.Sprintf("expected_bytes=$((%d * 1000 * 1000))", swapFileSizeMB),
-		"actual_bytes=$(stat -c %s \"${swap_file}\")",
-		"test \"${actual_bytes}\" -ge \"${expected_bytes}\"",
-	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "swap file configuration did not match expected values")
-	return err
+func ValidateSwapFileConfig(ctx context.Context, node nodeexec.NodeExecutor, swapFileSizeMB int32) error {
+	return nodevalidate.SwapFile(nodeValidateCtx(ctx), node, swapFileSizeMB)
 }
 
 func RebootVMAndWaitForSSH(ctx context.Context, s *Scenario) error {
@@ -416,7 +377,7 @@ func RebootVMAndWaitForSSH(ctx context.Context, s *Scenario) error {
 		}
 
 		bootIDCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		execResult, err := runSSHCommand(bootIDCtx, sshClient, "cat /proc/sys/kernel/random/boot_id", s.IsWindows())
+		execResult, err := s.targetExecutor("", &nodeexec.SSH{Client: sshClient, Windows: s.IsWindows()}).Exec(bootIDCtx, "cat /proc/sys/kernel/random/boot_id")
 		cancel()
 		if err != nil {
 			cleanupBastionTunnel(sshClient)
@@ -424,7 +385,7 @@ func RebootVMAndWaitForSSH(ctx context.Context, s *Scenario) error {
 			return false, nil
 		}
 
-		afterRebootBootID := strings.TrimSpace(execResult.stdout)
+		afterRebootBootID := strings.TrimSpace(execResult.Stdout)
 		if afterRebootBootID == "" || afterRebootBootID == beforeRebootBootID {
 			cleanupBastionTunnel(sshClient)
 			s.T.Logf("waiting for VM reboot to complete: boot ID is still %q", afterRebootBootID)
@@ -444,8 +405,7 @@ func RebootVMAndWaitForSSH(ctx context.Context, s *Scenario) error {
 // It identifies network interfaces with slot names matching the enP* pattern (same logic as the udev rule),
 // then verifies that each interface has the expected configuration settings (e.g., rx buffer size).
 // The nicConfig map specifies the ethtool settings to validate (key: setting name, value: expected value).
-func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig map[string]string) error {
-	s.T.Helper()
+func ValidateNetworkInterfaceConfig(ctx context.Context, node nodeexec.NodeExecutor, nicConfig map[string]string) error {
 	// Get list of NICs using udevadm (same logic as udev rule)
 	getNicsCommand := []string{
 		"#!/usr/bin/env bash",
@@ -459,11 +419,11 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 		"done",
 		"IFS=,; echo \"${enp_ifaces[*]}\"",
 	}
-	nicsResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(getNicsCommand, "\n"), 0, "could not get nics to configure")
+	nicsResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(getNicsCommand, "\n"), 0, "could not get nics to configure")
 	if err != nil {
 		return fmt.Errorf("get NICs to configure: %w", err)
 	}
-	s.T.Logf("NICs to configure:\n%s", nicsResult.stdout)
+	toolkit.Logf(ctx, "NICs to configure:\n%s", nicsResult.stdout)
 
 	// Parse NIC output - it may be multi-line with header
 	lines := strings.Split(strings.TrimSpace(nicsResult.stdout), "\n")
@@ -480,10 +440,10 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 
 	nics := strings.Split(nicsOutput, ",")
 
-	s.T.Logf("Parsed NICs list: %v (count: %d)", nics, len(nics))
+	toolkit.Logf(ctx, "Parsed NICs list: %v (count: %d)", nics, len(nics))
 
 	if len(nics) == 0 || (len(nics) == 1 && strings.TrimSpace(nics[0]) == "") {
-		s.T.Logf("No PCI devices (NICs) with enP* slot pattern found - skipping network interface config validation")
+		toolkit.Logf(ctx, "No PCI devices (NICs) with enP* slot pattern found - skipping network interface config validation")
 		return nil
 	}
 
@@ -495,7 +455,7 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 			continue
 		}
 
-		s.T.Logf("Validating network interface config for NIC: %s", nic)
+		toolkit.Logf(ctx, "Validating network interface config for NIC: %s", nic)
 
 		// Get full ethtool output for debugging
 		debugCommand := []string{
@@ -503,11 +463,11 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 			fmt.Sprintf("echo '=== Full ethtool output for %s ==='", nic),
 			fmt.Sprintf("sudo ethtool -g %s", nic),
 		}
-		debugResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(debugCommand, "\n"))
+		debugResult, err := execOnNode(ctx, node, strings.Join(debugCommand, "\n"))
 		if err != nil {
 			return errors.Join(append(errs, fmt.Errorf("get ethtool output for nic %s: %w", nic, err))...)
 		}
-		s.T.Logf("Full ethtool output for %s:\n%s", nic, debugResult.stdout)
+		toolkit.Logf(ctx, "Full ethtool output for %s:\n%s", nic, debugResult.stdout)
 		oldEthtool := strings.Contains(debugResult.stdout, "Current hardware settings")
 
 		for setting, expectedValue := range nicConfig {
@@ -521,12 +481,12 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 				"set -ex",
 				cmd,
 			}
-			execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "could not get ethtool config")
+			execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "could not get ethtool config")
 			if err != nil {
 				return errors.Join(append(errs, fmt.Errorf("get ethtool setting %s for nic %s: %w", setting, nic, err))...)
 			}
 			actualValue := strings.TrimSpace(execResult.stdout)
-			s.T.Logf("Ethtool setting %s for NIC %s: expected=%s, actual=%s", setting, nic, expectedValue, actualValue)
+			toolkit.Logf(ctx, "Ethtool setting %s for NIC %s: expected=%s, actual=%s", setting, nic, expectedValue, actualValue)
 			errs = append(errs, assert.Equal(actualValue, expectedValue, "expected %s to be %s on nic %s, but got %s.\nFull ethtool output:\n%s", setting, expectedValue, nic, actualValue, debugResult.stdout))
 		}
 	}
@@ -534,46 +494,41 @@ func ValidateNetworkInterfaceConfig(ctx context.Context, s *Scenario, nicConfig
 }
 
 // ValidateAzureNetworkFiles checks that udev rules files exist.
-func ValidateAzureNetworkFiles(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateAzureNetworkFiles(ctx context.Context, node nodeexec.NodeExecutor) error {
 	return errors.Join(
-		ValidateFileExists(ctx, s, "/opt/azure-network/configure-azure-network.sh"),
-		ValidateFileExists(ctx, s, "/etc/udev/rules.d/99-azure-network.rules"),
+		validateFileExists(ctx, node, false, "/opt/azure-network/configure-azure-network.sh"),
+		validateFileExists(ctx, node, false, "/etc/udev/rules.d/99-azure-network.rules"),
 	)
 }
 
-func ValidateNvidiaSMINotInstalled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaSMINotInstalled(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		"sudo nvidia-smi",
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 1, "")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 1, "")
 	if err != nil {
 		return fmt.Errorf("run nvidia-smi: %w", err)
 	}
 	return assert.Contains(execResult.stderr, "nvidia-smi: command not found", "expected stderr to contain 'nvidia-smi: command not found', but got %q", execResult.stderr)
 }
 
-func ValidateNvidiaSMIInstalled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaSMIInstalled(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{"set -ex", "sudo nvidia-smi"}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "could not execute nvidia-smi command")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "could not execute nvidia-smi command")
 	return err
 }
 
-func ValidateNvidiaModProbeInstalled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaModProbeInstalled(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		"sudo nvidia-modprobe",
 	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "could not execute nvidia-modprobe command")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "could not execute nvidia-modprobe command")
 	return err
 }
 
-func ValidateNvidiaGRIDLicenseValid(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaGRIDLicenseValid(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Capture the license status output, or continue silently if not found
@@ -584,19 +539,18 @@ func ValidateNvidiaGRIDLicenseValid(ctx context.Context, s *Scenario) error {
 		"active_status=$(sudo systemctl is-active nvidia-gridd)",
 		"if [ \"$active_status\" != \"active\" ]; then echo \"nvidia-gridd is not active: $active_status\"; exit 1; fi",
 	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "failed to validate nvidia-smi license state or nvidia-gridd service status")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "failed to validate nvidia-smi license state or nvidia-gridd service status")
 	return err
 }
 
-func ValidateNvidiaPersistencedRunning(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaPersistencedRunning(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Check that nvidia-persistenced.service is active by capturing its is-active output
 		"active_status=$(sudo systemctl is-active nvidia-persistenced.service)",
 		"if [ \"$active_status\" != \"active\" ]; then echo \"nvidia-gridd is not active: $active_status\"; exit 1; fi",
 	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "failed to validate nvidia-persistenced.service status")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "failed to validate nvidia-persistenced.service status")
 	return err
 }
 
@@ -604,32 +558,29 @@ func ValidateNvidiaPersistencedRunning(ctx context.Context, s *Scenario) error {
 // (595.x) driver from the aks-gpu-grid-v20 image rather than falling back to a
 // cuda/grid driver. This is the grid-v20-specific check: if SKU->driver-type
 // selection regressed, nvidia-smi would report a different driver major.
-func ValidateNvidiaGridV20DriverInstalled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaGridV20DriverInstalled(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		"driver_version=$(sudo nvidia-smi --query-gpu=driver_version --format=csv,noheader | head -n1 | tr -d '[:space:]')",
 		"echo \"nvidia driver_version=$driver_version\"",
 		"case \"$driver_version\" in 595.*) ;; *) echo \"expected grid-v20 595.x driver, got '$driver_version'\"; exit 1 ;; esac",
 	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "expected grid-v20 (595.x) NVIDIA driver version")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "expected grid-v20 (595.x) NVIDIA driver version")
 	return err
 }
 
-func ValidateNonEmptyDirectory(ctx context.Context, s *Scenario, dirName string) error {
-	s.T.Helper()
+func ValidateNonEmptyDirectory(ctx context.Context, node nodeexec.NodeExecutor, dirName string) error {
 	command := []string{
 		"set -ex",
 		fmt.Sprintf("sudo ls -1q %s | grep -q '^.*$' && true || false", dirName),
 	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "either could not find expected file, or something went wrong")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "either could not find expected file, or something went wrong")
 	return err
 }
 
-func ValidateEmptyDirectory(ctx context.Context, s *Scenario, dirName string) error {
-	s.T.Helper()
+func ValidateEmptyDirectory(ctx context.Context, node nodeexec.NodeExecutor, dirName string) error {
 	command := fmt.Sprintf("! [ -d '%s' ] || [ -z \"$(ls -A '%s')\" ]", dirName, dirName)
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 0,
+	_, err := execOnNodeValidateExitCode(ctx, node, command, 0,
 		fmt.Sprintf("expected directory %s to be empty or not exist", dirName))
 	return err
 }
@@ -643,7 +594,7 @@ func ValidateInspektorGadget(ctx context.Context, s *Scenario) error {
 	// Check if IG is installed on this VHD by looking for the skip sentinel file.
 	// The skip file is only present on VHDs that have IG installed (Ubuntu and Azure Linux non-OSGuard).
 	// Flatcar, OSGuard, and older VHDs do not have IG installed and will not have the skip file.
-	skipFileExists, err := fileExist(ctx, s, skipFile)
+	skipFileExists, err := fileExist(ctx, s.NodeExecutor(), s.IsWindows(), skipFile)
 	if err != nil {
 		return fmt.Errorf("check for Inspektor Gadget sentinel file %s: %w", skipFile, err)
 	}
@@ -654,7 +605,7 @@ func ValidateInspektorGadget(ctx context.Context, s *Scenario) error {
 
 	s.T.Logf("skip_vhd_ig sentinel file found, validating Inspektor Gadget installation")
 
-	errs := []error{ValidateSystemdUnitIsNotFailed(ctx, s, serviceName)}
+	errs := []error{ValidateSystemdUnitIsNotFailed(ctx, s.NodeExecutor(), serviceName)}
 	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, fmt.Sprintf("systemctl is-enabled %s | grep -qx disabled", serviceName), 0, fmt.Sprintf("%s should be disabled", serviceName)); err != nil {
 		errs = append(errs, err)
 	}
@@ -721,7 +672,11 @@ echo "trace_exec gadget ran successfully"
 
 func ValidateFileExists(ctx context.Context, s *Scenario, fileName string) error {
 	s.T.Helper()
-	exists, err := fileExist(ctx, s, fileName)
+	return validateFileExists(ctx, s.NodeExecutor(), s.IsWindows(), fileName)
+}
+
+func validateFileExists(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string) error {
+	exists, err := fileExist(ctx, node, windows, fileName)
 	if err != nil {
 		return fmt.Errorf("check existence of file %s: %w", fileName, err)
 	}
@@ -732,64 +687,64 @@ func ValidateFileExists(ctx context.Context, s *Scenario, fileName string) error
 // the /etc/system-fips marker file written by vhdbuilder/scripts/linux/acl/tool_installs_acl.sh.
 // Kernel FIPS mode (/proc/sys/crypto/fips_enabled == 1) is universal and is asserted by
 // ValidateFIPSProvider; callers should compose the two validators when both are needed.
-func ValidateACLFIPSEnabled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	return ValidateFileExists(ctx, s, "/etc/system-fips")
+func ValidateACLFIPSEnabled(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return validateFileExists(ctx, node, false, "/etc/system-fips")
 }
 
 func ValidateFileDoesNotExist(ctx context.Context, s *Scenario, fileName string) error {
 	s.T.Helper()
-	exists, err := fileExist(ctx, s, fileName)
+	return validateFileDoesNotExist(ctx, s.NodeExecutor(), s.IsWindows(), fileName)
+}
+
+func validateFileDoesNotExist(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string) error {
+	exists, err := fileExist(ctx, node, windows, fileName)
 	if err != nil {
 		return fmt.Errorf("check existence of file %s: %w", fileName, err)
 	}
 	return assert.Equal(exists, false, "expected file %s to not exist, but it does", fileName)
 }
 
-func ValidateFileIsRegularFile(ctx context.Context, s *Scenario, fileName string) error {
-	s.T.Helper()
+func ValidateFileIsRegularFile(ctx context.Context, node nodeexec.NodeExecutor, fileName string) error {
 	steps := []string{
 		"set -ex",
 		fmt.Sprintf("stat --printf=%%F %s | grep 'regular file'", fileName),
 	}
 
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+	execResult, err := execOnNode(ctx, node, strings.Join(steps, "\n"))
 	if err != nil {
 		return fmt.Errorf("stat file %s: %w", fileName, err)
 	}
 	return assert.Equal(execResult.exitCode, "0", "expected %s to be a regular file, but it is not", fileName)
 }
 
-func fileExist(ctx context.Context, s *Scenario, fileName string) (bool, error) {
-	s.T.Helper()
-	if s.IsWindows() {
+func fileExist(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string) (bool, error) {
+	if windows {
 		steps := []string{
 			"$ErrorActionPreference = \"Stop\"",
 			fmt.Sprintf("if (Test-Path -Path '%s') { exit 0 } else { exit 1 }", fileName),
 		}
-		execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+		execResult, err := node.Exec(ctx, strings.Join(steps, "\n"))
 		if err != nil {
 			return false, err
 		}
-		s.T.Logf("stdout: %s\nstderr: %s", execResult.stdout, execResult.stderr)
-		return execResult.exitCode == "0", nil
+		toolkit.Logf(ctx, "stdout: %s\nstderr: %s", execResult.Stdout, execResult.Stderr)
+		return execResult.ExitCode == 0, nil
 	}
 	steps := []string{
 		"set -ex",
 		fmt.Sprintf("test -f %s", fileName),
 	}
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+	execResult, err := node.Exec(ctx, strings.Join(steps, "\n"))
 	if err != nil {
 		return false, err
 	}
-	return execResult.exitCode == "0", nil
+	return execResult.ExitCode == 0, nil
 }
 
-func getFileContent(ctx context.Context, s *Scenario, fileName string) (string, error) {
-	s.T.Helper()
+func getFileContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string) (string, error) {
 	var steps []string
 
-	if s.IsWindows() {
+	if windows {
 		steps = []string{
 			"$ErrorActionPreference = \"Stop\"",
 			fmt.Sprintf("Get-Content %s", fileName),
@@ -801,24 +756,23 @@ func getFileContent(ctx context.Context, s *Scenario, fileName string) (string,
 		}
 	}
 
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+	execResult, err := node.Exec(ctx, strings.Join(steps, "\n"))
 	if err != nil {
 		return "", fmt.Errorf("failed to get file content for %s: %w", fileName, err)
 	}
-	if execResult.exitCode != "0" {
-		return "", fmt.Errorf("failed to get file content for %s: exit code %s\nStdout: %s\nStderr: %s", fileName, execResult.exitCode, execResult.stdout, execResult.stderr)
+	if execResult.ExitCode != 0 {
+		return "", fmt.Errorf("failed to get file content for %s: exit code %d\nStdout: %s\nStderr: %s", fileName, execResult.ExitCode, execResult.Stdout, execResult.Stderr)
 	}
 
-	return execResult.stdout, nil
+	return execResult.Stdout, nil
 }
 
-func fileHasContent(ctx context.Context, s *Scenario, fileName string, contents string) (bool, error) {
-	s.T.Helper()
+func fileHasContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string, contents string) (bool, error) {
 	if contents == "" {
 		return false, fmt.Errorf("test setup failure: can't validate that a file has contents with an empty string. Filename: %s", fileName)
 	}
 	var steps []string
-	if s.IsWindows() {
+	if windows {
 		steps = []string{
 			"$ErrorActionPreference = \"Stop\"",
 			fmt.Sprintf("if ( -not ( Test-Path -Path %s ) ) { exit 2 }", fileName),
@@ -831,21 +785,20 @@ func fileHasContent(ctx context.Context, s *Scenario, fileName string, contents
 		}
 	}
 
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+	execResult, err := node.Exec(ctx, strings.Join(steps, "\n"))
 	if err != nil {
 		return false, err
 	}
-	return execResult.exitCode == "0", nil
+	return execResult.ExitCode == 0, nil
 }
 
-func fileHasExactContent(ctx context.Context, s *Scenario, fileName string, contents string) (bool, error) {
-	s.T.Helper()
+func fileHasExactContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string, contents string) (bool, error) {
 	if contents == "" {
 		return false, fmt.Errorf("test setup failure: can't validate that a file has contents with an empty string. Filename: %s", fileName)
 	}
 	encodedPattern := base64.StdEncoding.EncodeToString([]byte(contents))
 	var steps []string
-	if s.IsWindows() {
+	if windows {
 		steps = []string{
 			"$ErrorActionPreference = \"Stop\"",
 			fmt.Sprintf("if ( -not ( Test-Path -Path %s ) ) { exit 2 }", fileName),
@@ -864,11 +817,11 @@ func fileHasExactContent(ctx context.Context, s *Scenario, fileName string, cont
 			fmt.Sprintf("if sudo grep -Eq \"$regex\" %s; then exit 0; else exit 1; fi", fileName),
 		}
 	}
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(steps, "\n"))
+	execResult, err := node.Exec(ctx, strings.Join(steps, "\n"))
 	if err != nil {
 		return false, err
 	}
-	return execResult.exitCode == "0", nil
+	return execResult.ExitCode == 0, nil
 }
 
 // ValidateFileHasContent passes the test if the specified file contains the specified contents.
@@ -876,14 +829,18 @@ func fileHasExactContent(ctx context.Context, s *Scenario, fileName string, cont
 // E.g.: searching "bcd" in "abcdef" is a match, thus the validation passes.
 func ValidateFileHasContent(ctx context.Context, s *Scenario, fileName string, contents string) error {
 	s.T.Helper()
-	hasContent, err := fileHasContent(ctx, s, fileName, contents)
+	return validateFileHasContent(ctx, s.NodeExecutor(), s.IsWindows(), fileName, contents)
+}
+
+func validateFileHasContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string, contents string) error {
+	hasContent, err := fileHasContent(ctx, node, windows, fileName, contents)
 	if err != nil {
 		return fmt.Errorf("check whether file %s has contents %q: %w", fileName, contents, err)
 	}
 	if hasContent {
 		return nil
 	}
-	actualContents, err := getFileContent(ctx, s, fileName)
+	actualContents, err := getFileContent(ctx, node, windows, fileName)
 	if err != nil {
 		return fmt.Errorf("expected file %s to have contents %q. Could not determine actual contents due to %w", fileName, contents, err)
 	}
@@ -895,14 +852,18 @@ func ValidateFileHasContent(ctx context.Context, s *Scenario, fileName string, c
 // E.g.: searching "bcd" in "abcdef" is a match, thus the validation fails.
 func ValidateFileExcludesContent(ctx context.Context, s *Scenario, fileName string, contents string) error {
 	s.T.Helper()
-	hasContent, err := fileHasContent(ctx, s, fileName, contents)
+	return validateFileExcludesContent(ctx, s.NodeExecutor(), s.IsWindows(), fileName, contents)
+}
+
+func validateFileExcludesContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string, contents string) error {
+	hasContent, err := fileHasContent(ctx, node, windows, fileName, contents)
 	if err != nil {
 		return fmt.Errorf("check whether file %s has contents %q: %w", fileName, contents, err)
 	}
 	if !hasContent {
 		return nil
 	}
-	actualContents, err := getFileContent(ctx, s, fileName)
+	actualContents, err := getFileContent(ctx, node, windows, fileName)
 	if err != nil {
 		return fmt.Errorf("expected file %s to not have contents %q. Could not determine actual contents due to %w", fileName, contents, err)
 	}
@@ -914,147 +875,23 @@ func ValidateFileExcludesContent(ctx context.Context, s *Scenario, fileName stri
 // E.g.: searching "bcd" in "abcdef" is not a match, thus the validation passes.
 func ValidateFileExcludesExactContent(ctx context.Context, s *Scenario, fileName string, contents string) error {
 	s.T.Helper()
-	hasContent, err := fileHasExactContent(ctx, s, fileName, contents)
-	if err != nil {
-		return fmt.Errorf("check whether file %s has exact contents %q: %w", fileName, contents, err)
-	}
-	return assert.Equal(hasContent, false, "expected file %s to not have exact contents %q, but it does", fileName, contents)
+	return validateFileExcludesExactContent(ctx, s.NodeExecutor(), s.IsWindows(), fileName, contents)
 }
 
-// ValidateFIPSProvider verifies that FIPS is properly configured on the node:
-//  1. Kernel FIPS mode is enabled (/proc/sys/crypto/fips_enabled == 1).
-//  2. OpenSSL (3.x) has an active FIPS or SymCrypt provider loaded. The check is
-//     skipped on hosts shipping OpenSSL 1.1.x (e.g. Ubuntu 20.04 FIPS), which use
-//     the legacy FIPS module rather than the providers interface.
-//  3. /opt/cni/bin/portmap runs without panicking (regression guard for ICM 51000001009688
-//     where the OpenSSL FIPS provider was not loaded on AzureLinux V3 FIPS nodes).
-func ValidateFIPSProvider(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	var errs []error
-
-	// 1. Kernel FIPS mode.
-	fipsEnabled, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "cat /proc/sys/crypto/fips_enabled", 0, "could not read /proc/sys/crypto/fips_enabled")
+func validateFileExcludesExactContent(ctx context.Context, node nodeexec.NodeExecutor, windows bool, fileName string, contents string) error {
+	hasContent, err := fileHasExactContent(ctx, node, windows, fileName, contents)
 	if err != nil {
-		return fmt.Errorf("read /proc/sys/crypto/fips_enabled: %w", err)
-	}
-	errs = append(errs, assert.Equal(strings.TrimSpace(fipsEnabled.stdout), "1", "expected /proc/sys/crypto/fips_enabled to be 1, got %q", fipsEnabled.stdout))
-
-	// 2. OpenSSL provider must include an active fips or symcrypt provider on OpenSSL 3.x.
-	// 1.1.x (Ubuntu 20.04 FIPS) uses the legacy FIPS module and is skipped. Merge stderr
-	// (`2>&1`) so a version banner written to stderr still parses.
-	opensslVersion, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "openssl version 2>&1", 0, "could not run openssl version")
-	if err != nil {
-		return errors.Join(append(errs, fmt.Errorf("run openssl version: %w", err))...)
-	}
-	versionFields := strings.Fields(opensslVersion.stdout)
-	if len(versionFields) < 2 {
-		return errors.Join(append(errs, fmt.Errorf("could not parse openssl version output: %q", opensslVersion.stdout))...)
-	}
-	version := versionFields[1]
-	switch {
-	case strings.HasPrefix(version, "3."):
-		providers, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "openssl list -providers", 0, "could not list openssl providers")
-		if err != nil {
-			return errors.Join(append(errs, fmt.Errorf("list openssl providers: %w", err))...)
-		}
-		// Prefix match so "symcrypt" covers AzureLinux V3 / ACL's "symcryptprovider". See ICM 51000001009688.
-		errs = append(errs, assert.Equal(opensslProviderActive(providers.stdout, "fips", "symcrypt"), true,
-			"expected openssl to have an active fips or symcrypt provider, got:\n%s", providers.stdout))
-	case strings.HasPrefix(version, "1.1."):
-		s.T.Logf("openssl providers check skipped: detected version %q (legacy FIPS module)", strings.TrimSpace(opensslVersion.stdout))
-	default:
-		return errors.Join(append(errs, fmt.Errorf("unexpected openssl version %q: FIPS VHDs are expected to ship OpenSSL 3.x or 1.1.x", strings.TrimSpace(opensslVersion.stdout)))...)
-	}
-
-	// 3. portmap panic check (best-effort). The original FIPS regression manifested as
-	// /opt/cni/bin/portmap panicking when the OpenSSL FIPS provider was missing. Skip if
-	// the binary isn't at the standard CNI path (e.g. ACL stages it under /opt/cni/downloads/);
-	// checks 1 and 2 are already authoritative. Match specific Go runtime panic markers
-	// rather than the bare substring `runtime error:` which appears in CNI usage text.
-	portmapBin := "/opt/cni/bin/portmap"
-	portmapPresent, err := execScriptOnVMForScenario(ctx, s, fmt.Sprintf("test -x %s", portmapBin))
-	if err != nil {
-		return errors.Join(append(errs, fmt.Errorf("check whether %s is executable: %w", portmapBin, err))...)
-	}
-	if portmapPresent.exitCode != "0" {
-		s.T.Logf("portmap panic check skipped: %s not present or not executable on this VHD", portmapBin)
-		if joined := errors.Join(errs...); joined != nil {
-			return joined
-		}
-		s.T.Logf("FIPS provider validation passed")
-		return nil
-	}
-	portmap, err := execScriptOnVMForScenario(ctx, s, fmt.Sprintf("%s < /dev/null", portmapBin))
-	if err != nil {
-		return errors.Join(append(errs, fmt.Errorf("run %s: %w", portmapBin, err))...)
-	}
-	panicMarkers := []*regexp.Regexp{
-		regexp.MustCompile(`(?m)^panic:`),
-		regexp.MustCompile(`(?m)^fatal error:`),
-		regexp.MustCompile(`runtime\.gopanic`),
-		regexp.MustCompile(`goroutine \d+ \[running\]`),
-	}
-	for _, re := range panicMarkers {
-		errs = append(errs,
-			assert.Equal(re.MatchString(portmap.stderr), false,
-				"portmap runtime failure matched %q, indicating FIPS provider misconfiguration:\nstdout:\n%s\nstderr:\n%s", re, portmap.stdout, portmap.stderr),
-			assert.Equal(re.MatchString(portmap.stdout), false,
-				"portmap runtime failure matched %q, indicating FIPS provider misconfiguration:\nstdout:\n%s\nstderr:\n%s", re, portmap.stdout, portmap.stderr),
-		)
-	}
-
-	if joined := errors.Join(errs...); joined != nil {
-		return joined
+		return fmt.Errorf("check whether file %s has exact contents %q: %w", fileName, contents, err)
 	}
-	s.T.Logf("FIPS provider validation passed")
-	return nil
+	return assert.Equal(hasContent, false, "expected file %s to not have exact contents %q, but it does", fileName, contents)
 }
 
-// Package-level regex compiled once at init.
-var (
-	// Provider header: indented (spaces or tabs) name with no key/value separator.
-	// Group 1 = indent (used to scope status lines to their block), group 2 = name.
-	opensslProviderHeaderRE = regexp.MustCompile(`^([ \t]+)(\S+)\s*$`)
-	opensslStatusLineRE     = regexp.MustCompile(`^[ \t]+status:\s*(\S+)`)
-)
-
-// opensslProviderActive parses `openssl list -providers` output and returns true if any
-// provider whose name has one of the given prefixes is reported as `status: active`.
-// The status line is scoped to its enclosing provider block (strictly more indented than
-// the header) so an active default provider cannot mask an inactive fips/symcrypt one.
-func opensslProviderActive(output string, providerPrefixes ...string) bool {
-	matches := func(name string) bool {
-		for _, p := range providerPrefixes {
-			if strings.HasPrefix(name, p) {
-				return true
-			}
-		}
-		return false
-	}
-	var current string
-	var headerIndent int
-	for _, line := range strings.Split(output, "\n") {
-		line = strings.TrimRight(line, "\r")
-		if m := opensslProviderHeaderRE.FindStringSubmatch(line); m != nil {
-			headerIndent = len(m[1])
-			current = m[2]
-			continue
-		}
-		if current == "" || !matches(current) {
-			continue
-		}
-		if m := opensslStatusLineRE.FindStringSubmatch(line); m != nil && m[1] == "active" {
-			leading := len(line) - len(strings.TrimLeft(line, " \t"))
-			if leading > headerIndent {
-				return true
-			}
-		}
-	}
-	return false
+// ValidateFIPSProvider verifies that FIPS is properly configured on the node, see nodevalidate.FIPSProvider.
+func ValidateFIPSProvider(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return nodevalidate.FIPSProvider(nodeValidateCtx(ctx), node)
 }
 
-func ServiceCanRestartValidator(ctx context.Context, s *Scenario, serviceName string, restartTimeoutInSeconds int) error {
-	s.T.Helper()
+func ServiceCanRestartValidator(ctx context.Context, node nodeexec.NodeExecutor, serviceName string, restartTimeoutInSeconds int) error {
 	steps := []string{
 		"set -ex",
 		// Verify the service is active - print the state then verify so we have logs
@@ -1083,36 +920,16 @@ func ServiceCanRestartValidator(ctx context.Context, s *Scenario, serviceName st
 		"if [[ \"$INITIAL_PID\" == \"$POST_PID\" ]]; then echo PID did not change after restart, failing validator. ; exit 1; fi",
 	}
 
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(steps, "\n"), 0, "command to restart service failed")
+	_, err := execOnNodeValidateExitCode(ctx, node, strings.Join(steps, "\n"), 0, "command to restart service failed")
 	return err
 }
 
-func ValidateSystemdUnitIsRunning(ctx context.Context, s *Scenario, serviceName string) error {
-	s.T.Helper()
-	command := []string{
-		"set -ex",
-		// Print the service status for logging purposes
-		fmt.Sprintf("systemctl -n 5 status %s || true", serviceName),
-		// Verify the service is active
-		fmt.Sprintf("systemctl is-active %s", serviceName),
-	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0,
-		fmt.Sprintf("service %s is not running", serviceName))
-	return err
+func ValidateSystemdUnitIsRunning(ctx context.Context, node nodeexec.NodeExecutor, serviceName string) error {
+	return nodevalidate.SystemdUnitIsRunning(nodeValidateCtx(ctx), node, serviceName)
 }
 
-func ValidateSystemdUnitIsNotRunning(ctx context.Context, s *Scenario, serviceName string) error {
-	s.T.Helper()
-	command := []string{
-		"set -ex",
-		// Print the service status for logging purposes (allow failure)
-		fmt.Sprintf("systemctl -n 5 status %s || true", serviceName),
-		// Check if service is active - we expect this to fail
-		fmt.Sprintf("! systemctl is-active %s", serviceName),
-	}
-	_, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0,
-		fmt.Sprintf("service %s is unexpectedly running", serviceName))
-	return err
+func ValidateSystemdUnitIsNotRunning(ctx context.Context, node nodeexec.NodeExecutor, serviceName string) error {
+	return nodevalidate.SystemdUnitIsNotRunning(nodeValidateCtx(ctx), node, serviceName)
 }
 
 func ValidateWindowsServiceIsRunning(ctx context.Context, s *Scenario, serviceName string) error {
@@ -1213,23 +1030,8 @@ func ValidateWindowsSystemServicesRestartConfiguration(ctx context.Context, s *S
 	)
 }
 
-func ValidateSystemdUnitIsNotFailed(ctx context.Context, s *Scenario, serviceName string) error {
-	s.T.Helper()
-	command := []string{
-		"set -ex",
-		fmt.Sprintf("systemctl --no-pager -n 5 status %s || true", serviceName),
-		fmt.Sprintf("systemctl is-failed %s", serviceName),
-	}
-	execResult, err := execScriptOnVMForScenario(ctx, s, strings.Join(command, "\n"))
-	if err != nil {
-		return fmt.Errorf("check failed state of unit %q: %w", serviceName, err)
-	}
-	return assert.NotEqual(
-		execResult.exitCode,
-		"0",
-		`expected "systemctl is-failed" to exit with a non-zero exit code for unit %q, unit is in a failed state`,
-		serviceName,
-	)
+func ValidateSystemdUnitIsNotFailed(ctx context.Context, node nodeexec.NodeExecutor, serviceName string) error {
+	return nodevalidate.SystemdUnitIsNotFailed(nodeValidateCtx(ctx), node, serviceName)
 }
 
 // ValidateKubeletActiveFlagsEvent checks that the emit-kubelet-active-flags oneshot service
@@ -1262,179 +1064,128 @@ func ValidateNoFailedSystemdUnits(ctx context.Context, s *Scenario) error {
 	if s.VHD != nil && s.VHD.SkipOldVHDValidations {
 		return nil
 	}
-	unitFailureAllowList := map[string]bool{
-		// this service depends on non-network-isolated environment - E2Es are run in an environment
-		// which simulates network-isolation by only allowing egress to recommended domains outlined
-		// on public AKS documentation via a firewall. This service depends on some other domain which is
-		// not currently allowed by the firewall. It also seems that this service is only installed on
-		// Ubuntu - do we even need it? it seems that it's coming from the base image
-		"fwupd-refresh.service": true,
-	}
-	// cloud-init creates temporary directories under /run/cloud-init/tmp/ during provisioning.
-	// systemd auto-generates transient .mount units for these (e.g., run-cloud\x2dinit-tmp-tmpXXXXX.mount).
-	// When cloud-init cleans up the temp directory, the mount unit may enter a "failed" state due to
-	// a race in timing between cleanup and systemd state tracking. For this validator, that failure is
-	// treated as benign, and the unit name contains a random suffix, so we use prefix matching instead
-	// of exact string matching.
-	mountFailureAllowPrefixes := []string{
-		`run-cloud\x2dinit-tmp-`,
-	}
+	allow := nodevalidate.DefaultUnitAllowList()
 	if s.Tags.BootstrapTokenFallback {
 		// secure-tls-bootstrap.service is expected to fail within scenarios that test bootstrap token fall-back behavior
-		unitFailureAllowList["secure-tls-bootstrap.service"] = true
+		allow.Units["secure-tls-bootstrap.service"] = true
 	}
 	if s.VHD.IgnoreFailedCgroupTelemetryServices {
-		unitFailureAllowList["cgroup-memory-telemetry.service"] = true
-		unitFailureAllowList["cgroup-pressure-telemetry.service"] = true
+		allow.Units["cgroup-memory-telemetry.service"] = true
+		allow.Units["cgroup-pressure-telemetry.service"] = true
 	}
 	if s.VHD.OS == config.OSACL {
 		// systemd-sysupdate.service: known upstream Flatcar issue (flatcar/Flatcar#1979). The timer
 		// (OnBootSec=15min) fires the service which exits with "No transfer definitions found" because
 		// ACL VHDs don't ship sysupdate transfer configs. Whether it fails depends on whether the timer
 		// fires before the validator checks.
-		unitFailureAllowList["systemd-sysupdate.service"] = true
+		allow.Units["systemd-sysupdate.service"] = true
 	}
 
-	type systemdUnit struct {
-		Name string `json:"unit,omitempty"`
-	}
-	var failedUnits []systemdUnit
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "systemctl list-units --failed --output json", 0, "unable to list failed systemd units")
-	if err != nil {
-		return fmt.Errorf("list failed systemd units: %w", err)
-	}
-	if err := json.Unmarshal([]byte(result.stdout), &failedUnits); err != nil {
-		return fmt.Errorf(`parse and unmarshal "systemctl list-units" command output: %w`, err)
-	}
-	failedUnits = lo.Filter(failedUnits, func(unit systemdUnit, _ int) bool {
-		if unitFailureAllowList[unit.Name] {
-			return false
-		}
-		if strings.HasSuffix(unit.Name, ".mount") {
-			for _, prefix := range mountFailureAllowPrefixes {
-				if strings.HasPrefix(unit.Name, prefix) {
-					return false
-				}
-			}
-		}
-		return true
-	})
-
-	if len(failedUnits) < 1 {
-		// no unexpectedly failed units
-		return nil
-	}
-
-	// extract failed unit logs
-	var errs []error
-	failedUnitLogs := make(map[string]string, len(failedUnits))
-	for _, unit := range failedUnits {
-		unitLogs, err := execScriptOnVMForScenario(ctx, s, fmt.Sprintf("journalctl -u %s", unit.Name))
-		if err != nil {
-			errs = append(errs, fmt.Errorf("retrieve logs of failed unit %s: %w", unit.Name, err))
-			continue
-		}
-		failedUnitLogs[unit.Name+".log"] = unitLogs.String()
+	err := nodevalidate.NoFailedSystemdUnits(nodeValidateCtx(ctx), s.NodeExecutor(), allow)
+	var failed *nodevalidate.FailedUnitsError
+	if !errors.As(err, &failed) {
+		return err
 	}
-	if err := dumpFileMapToDir(s.T, failedUnitLogs); err != nil {
-		errs = append(errs, fmt.Errorf("dump failed systemd unit logs: %w", err))
+	errs := []error{err}
+	if dumpErr := dumpFileMapToDir(s.T, failed.Logs); dumpErr != nil {
+		errs = append(errs, fmt.Errorf("dump failed systemd unit logs: %w", dumpErr))
 	}
-
-	errs = append(errs, fmt.Errorf(
-		"the following systemd units have unexpectedly entered a failed state: %s - failed unit logs will be included in scenario log bundle within <service-name>.service.log",
-		lo.Map(failedUnits, func(unit systemdUnit, _ int) string {
-			return unit.Name
-		}),
-	))
+	errs = append(errs, errors.New("failed unit logs will be included in scenario log bundle within <service-name>.service.log"))
 	return errors.Join(errs...)
 }
 
-func ValidateUlimitSettings(ctx context.Context, s *Scenario, ulimits map[string]string) error {
-	s.T.Helper()
-	ulimitKeys := make([]string, 0, len(ulimits))
-	for k := range ulimits {
-		ulimitKeys = append(ulimitKeys, k)
-	}
-
-	command := fmt.Sprintf("sudo systemctl cat containerd.service | grep -E -i '%s'", strings.Join(ulimitKeys, "|"))
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 0, "could not read containerd.service file")
-	if err != nil {
-		return fmt.Errorf("read containerd.service file: %w", err)
-	}
-
-	var errs []error
-	for name, value := range ulimits {
-		errs = append(errs, assert.Contains(execResult.stdout, fmt.Sprintf("%s=%v", name, value), "expected to find %s set to %v, but was not", name, value))
-	}
-	return errors.Join(errs...)
+func ValidateUlimitSettings(ctx context.Context, node nodeexec.NodeExecutor, ulimits map[string]string) error {
+	return nodevalidate.Ulimits(nodeValidateCtx(ctx), node, ulimits)
 }
 
-func ValidateInstalledPackageVersion(ctx context.Context, s *Scenario, component, version string) error {
-	s.T.Helper()
-	var installedCommand string
-	switch s.VHD.OS {
+func ValidateInstalledPackageVersion(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image, component, version string) error {
+	var packageManager nodevalidate.PackageManager
+	switch vhd.OS {
 	case config.OSUbuntu:
-		installedCommand = "sudo apt list --installed"
+		packageManager = nodevalidate.PackageManagerAPT
 	case config.OSMariner, config.OSAzureLinux:
-		installedCommand = "sudo dnf list installed"
+		packageManager = nodevalidate.PackageManagerDNF
 	default:
-		return fmt.Errorf("command to get package list isn't implemented for OS %s", s.VHD.OS)
+		return fmt.Errorf("command to get package list isn't implemented for OS %s", vhd.OS)
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, installedCommand, 0, "could not get package list")
-	if err != nil {
-		return fmt.Errorf("get package list: %w", err)
-	}
-	for _, line := range strings.Split(execResult.stdout, "\n") {
-		if strings.Contains(line, component) && strings.Contains(line, version) {
-			s.T.Logf("found %s %s in the installed packages", component, version)
-			return nil
-		}
-	}
-	return fmt.Errorf("expected to find %s %s in the installed packages, but did not", component, version)
+	return nodevalidate.InstalledPackageVersion(nodeValidateCtx(ctx), node, packageManager, component, version)
 }
 
-func ValidateKubeletNodeIP(ctx context.Context, s *Scenario) error {
+// ValidateComponentsBillOfMaterials checks that the node caches everything the components.json of its VHD lists, and
+// nothing else, for the images, downloaded files and packages of its distro, release and architecture. The VHD copy of
+// components.json is used rather than the one of the tree, since the scenarios run on VHDs built from main.
+func ValidateComponentsBillOfMaterials(ctx context.Context, s *Scenario) error {
 	s.T.Helper()
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo cat /etc/default/kubelet", 0, "could not read kubelet config")
+	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo cat /opt/azure/components.json", 0, "could not read the components.json of the VHD")
 	if err != nil {
-		return fmt.Errorf("read kubelet config: %w", err)
-	}
-	stdout := execResult.stdout
-
-	// Search for "--node-ip" flag and its value.
-	matches := regexp.MustCompile(`--node-ip=([a-zA-Z0-9.:,]*)`).FindStringSubmatch(stdout)
-	if err := assert.Equal(len(matches) >= 2, true, "could not find kubelet flag --node-ip\nStdout: \n%s", stdout); err != nil {
 		return err
 	}
-
-	ipAddresses := strings.Split(matches[1], ",") // Could be multiple for dual-stack.
-	if err := assert.Equal(len(ipAddresses) >= 1, true, "expected at least one --node-ip address, but got none\nStdout: \n%s", stdout); err != nil {
+	vhdComponents, err := components.Parse([]byte(execResult.stdout))
+	if err != nil {
 		return err
 	}
-	if err := assert.Equal(len(ipAddresses) <= 2, true, "expected at most two --node-ip addresses, but got %d\nStdout: \n%s", len(ipAddresses), stdout); err != nil {
+	var distro string
+	if s.VHD.Distro.IsKataDistro() {
+		distro = string(s.VHD.OS) + "kata"
+	}
+	report, err := vhdComponents.DiffNode(ctx, s.NodeExecutor(), distro)
+	if err != nil {
 		return err
 	}
+	gpuNode := isGPUNode(s)
+	provisioned := func(f components.Finding) bool {
+		return provisionedNodeFinding(f, gpuNode)
+	}
+	for _, f := range report.Findings {
+		if provisioned(f) {
+			s.T.Logf("ignoring components.json difference left by provisioning: %s", f)
+		}
+	}
+	return report.Filter(provisioned).Error()
+}
 
-	// Check that each IP is a valid address.
-	var errs []error
-	for _, ipAddress := range ipAddresses {
-		errs = append(errs, assert.NotNil(net.ParseIP(ipAddress), "--node-ip value %q is not a valid IP address\nStdout: \n%s", ipAddress, stdout))
+// managedGPUPackages are the packages of managedGPUPackageList in the CSE which every VHD caches, cleanUpGPUDrivers
+// removes them from the nodes which don't install the GPU drivers.
+var managedGPUPackages = []string{"datacenter-gpu-manager-4-core", "datacenter-gpu-manager-4-proprietary", "dcgm-exporter"}
+
+// provisionedNodeFinding returns whether a difference between components.json and the node is a change provisioning
+// makes to the VHD rather than a VHD regression.
+func provisionedNodeFinding(f components.Finding, gpuNode bool) bool {
+	switch {
+	case f.Type == components.ComponentImage && f.Kind == components.FindingExtra:
+		// pods scheduled on the node pull their images once it joined
+		return true
+	case f.Type == components.ComponentImage && strings.HasSuffix(f.Component, "/kube-proxy"):
+		// cleanUpKubeProxyImages removes the kube-proxy images of the other Kubernetes versions
+		return true
+	case f.Type == components.ComponentPackage && (f.Component == "kubelet" || f.Component == "kubectl"):
+		// the CSE installs the kubelet and kubectl of the cluster version, which may not be cached
+		return true
+	case !gpuNode && f.Kind == components.FindingMissing && slices.Contains(managedGPUPackages, f.Component):
+		// cleanUpGPUDrivers removes the cached debs and rpms of the managed GPU packages, GPU_NODE is false
+		return true
 	}
-	return errors.Join(errs...)
+	return false
 }
 
-func ValidateIMDSRestrictionRule(ctx context.Context, s *Scenario, table string) error {
-	s.T.Helper()
-	cmd := fmt.Sprintf("sudo iptables -t %s -S | grep -q 'AKS managed: added by AgentBaker ensureIMDSRestriction for IMDS restriction feature'", table)
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0, "expected to find IMDS restriction rule, but did not"); err != nil {
-		return fmt.Errorf("check IMDS restriction rule in table %s: %w", table, err)
+// isGPUNode returns the GPU_NODE of the CSE, whether the node installs the GPU drivers.
+func isGPUNode(s *Scenario) bool {
+	if config := s.Runtime.AKSNodeConfig; config != nil {
+		return config.GetGpuConfig().GetEnableNvidia()
 	}
-	return nil
+	return s.Runtime.NBC != nil && s.Runtime.NBC.EnableNvidia
 }
 
-func ValidateMultipleKubeProxyVersionsExist(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	execResult, err := execScriptOnVMForScenario(ctx, s, "sudo ctr --namespace k8s.io images list | grep kube-proxy | awk '{print $1}' | grep -oE '[0-9]+\\.[0-9]+\\.[0-9]+'")
+func ValidateKubeletNodeIP(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return nodevalidate.KubeletNodeIP(nodeValidateCtx(ctx), node)
+}
+
+func ValidateIMDSRestrictionRule(ctx context.Context, node nodeexec.NodeExecutor, table string) error {
+	return nodevalidate.IMDSRestrictionRule(nodeValidateCtx(ctx), node, table)
+}
+
+func ValidateMultipleKubeProxyVersionsExist(ctx context.Context, node nodeexec.NodeExecutor) error {
+	execResult, err := execOnNode(ctx, node, "sudo ctr --namespace k8s.io images list | grep kube-proxy | awk '{print $1}' | grep -oE '[0-9]+\\.[0-9]+\\.[0-9]+'")
 	if err != nil {
 		return fmt.Errorf("list kube-proxy images: %w", err)
 	}
@@ -1456,48 +1207,25 @@ func ValidateMultipleKubeProxyVersionsExist(ctx context.Context, s *Scenario) er
 	case 1:
 		return fmt.Errorf("only one kube-proxy version exists: %v", versionMap)
 	default:
-		s.T.Logf("Multiple kube-proxy versions exist: %v", versionMap)
+		toolkit.Logf(ctx, "Multiple kube-proxy versions exist: %v", versionMap)
 		return nil
 	}
 }
 
-func ValidateKubeletHasNotStopped(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	command := "sudo journalctl -u kubelet"
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 0, "could not retrieve kubelet logs with journalctl")
-	if err != nil {
-		return fmt.Errorf("retrieve kubelet logs with journalctl: %w", err)
-	}
-	stdout := strings.ToLower(execResult.stdout)
-	return errors.Join(
-		assert.NotContains(stdout, "stopped kubelet"),
-		assert.Contains(stdout, "started kubelet"),
-	)
+func ValidateKubeletHasNotStopped(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return nodevalidate.KubeletHasNotStopped(nodeValidateCtx(ctx), node)
 }
 
-func ValidateServicesDoNotRestartKubelet(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	// grep all filesin /etc/systemd/system/ for /restart\s+kubelet/ and count results
-	command := "sudo grep -rl 'restart[[:space:]]\\+kubelet' /etc/systemd/system/"
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 1, "expected to find no services containing 'restart kubelet' in /etc/systemd/system/"); err != nil {
-		return fmt.Errorf("check for services restarting kubelet: %w", err)
-	}
-	return nil
+func ValidateServicesDoNotRestartKubelet(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return nodevalidate.ServicesDoNotRestartKubelet(nodeValidateCtx(ctx), node)
 }
 
 // ValidateKubeletHasFlags checks kubelet is started with the right flags and configs.
-func ValidateKubeletHasFlags(ctx context.Context, s *Scenario, filePath string) error {
-	s.T.Helper()
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo journalctl -u kubelet", 0, "could not retrieve kubelet logs with journalctl")
-	if err != nil {
-		return fmt.Errorf("retrieve kubelet logs with journalctl: %w", err)
-	}
-	configFileFlags := fmt.Sprintf("FLAG: --config=\"%s\"", filePath)
-	return assert.Contains(execResult.stdout, configFileFlags, "expected to find flag %s, but not found", "config")
+func ValidateKubeletHasFlags(ctx context.Context, node nodeexec.NodeExecutor, filePath string) error {
+	return nodevalidate.KubeletHasFlags(nodeValidateCtx(ctx), node, filePath)
 }
 
-func ValidateContainerd2Properties(ctx context.Context, s *Scenario, versions []string) error {
-	s.T.Helper()
+func ValidateContainerd2Properties(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image, versions []string) error {
 	if err := assert.Equal(len(versions), 1, "expected exactly one version for moby-containerd but got %d", len(versions)); err != nil {
 		return err
 	}
@@ -1507,45 +1235,23 @@ func ValidateContainerd2Properties(ctx context.Context, s *Scenario, versions []
 	}
 
 	var errs []error
-	errs = append(errs, ValidateInstalledPackageVersion(ctx, s, "moby-containerd", versions[0]))
-
-	// TODO: this assertion never actually runs and always passes vacuously.
-	//
-	// execOnVMForScenarioOnUnprivilegedPod executes inside the "debugnonhost" daemonset pod,
-	// which runs a bare mcr.microsoft.com/cbl-mariner/base/core:2.0 image with no volume mounts
-	// (see daemonsetDebug in e2e/kube.go). The host filesystem is not mounted into it, so the
-	// containerd binary is unreachable and the command exits 127 with an empty stdout and
-	// "command not found" on stderr. attemptExecOnPod treats a non-zero exit as a successful
-	// exec, so no error surfaces, and the NotContains check below then trivially passes against
-	// an empty string.
-	//
-	// Two changes are needed: run this on the node via execScriptOnVMForScenarioValidateExitCode
-	// (as ValidateKataContainerdConfigDump in validators_kata.go now does), and check stderr as
-	// well as stdout, since containerd logs its warnings to stderr. Fixing it is likely to
-	// surface real warnings at the 11 call sites that use this validator, so it is left as a
-	// follow-up rather than folded into an unrelated change.
-	execResult, err := execOnVMForScenarioOnUnprivilegedPod(ctx, s, "containerd config dump ")
-	if err != nil {
-		return errors.Join(append(errs, fmt.Errorf("dump containerd config: %w", err))...)
-	}
-	// validate containerd config dump has no warnings
-	errs = append(errs, assert.NotContains(execResult.stdout, "level=warning", "do not expect warning message when converting config file: %s", execResult.stdout))
+	errs = append(errs, ValidateInstalledPackageVersion(ctx, node, vhd, "moby-containerd", versions[0]))
+	errs = append(errs, nodevalidate.ContainerdConfigHasNoWarnings(nodeValidateCtx(ctx), node))
 	return errors.Join(errs...)
 }
 
-func ValidateContainerRuntimePlugins(ctx context.Context, s *Scenario) error {
+func ValidateContainerRuntimePlugins(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// nri plugin is enabled by default
-	return ValidateDirectoryContent(ctx, s, "/var/run/nri", []string{"nri.sock"})
+	return validateDirectoryContent(ctx, node, false, "/var/run/nri", []string{"nri.sock"})
 }
 
-func ValidateNPDGPUCountPlugin(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNPDGPUCountPlugin(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Check NPD GPU count plugin config exists
 		"test -f /etc/node-problem-detector.d/custom-plugin-monitor/gpu_checks/custom-plugin-gpu-count.json",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "NPD GPU count plugin configuration does not exist"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "NPD GPU count plugin configuration does not exist"); err != nil {
 		return fmt.Errorf("check NPD GPU count plugin configuration: %w", err)
 	}
 	return nil
@@ -1661,14 +1367,13 @@ func ValidateNPDIBLinkFlappingAfterFailure(ctx context.Context, s *Scenario) err
 		expectedMessage, "expected IBLinkFlapping message to indicate flapping")
 }
 
-func ValidateNPDUnhealthyNvidiaDevicePlugin(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNPDUnhealthyNvidiaDevicePlugin(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Check NPD unhealthy Nvidia device plugin config exists
 		"test -f /etc/node-problem-detector.d/custom-plugin-monitor/gpu_checks/custom-plugin-nvidia-device-plugin.json",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "NPD Nvidia device plugin configuration does not exist"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "NPD Nvidia device plugin configuration does not exist"); err != nil {
 		return fmt.Errorf("check NPD Nvidia device plugin configuration: %w", err)
 	}
 	return nil
@@ -1708,14 +1413,13 @@ func ValidateNPDUnhealthyNvidiaDevicePluginAfterFailure(ctx context.Context, s *
 	return errors.Join(errs...)
 }
 
-func ValidateNPDUnhealthyNvidiaDCGMServices(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNPDUnhealthyNvidiaDCGMServices(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Check NPD unhealthy Nvidia DCGM services config exists
 		"test -f /etc/node-problem-detector.d/custom-plugin-monitor/gpu_checks/custom-plugin-nvidia-dcgm-services.json",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "NPD Nvidia DCGM services configuration does not exist"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "NPD Nvidia DCGM services configuration does not exist"); err != nil {
 		return fmt.Errorf("check NPD Nvidia DCGM services configuration: %w", err)
 	}
 	return nil
@@ -1811,8 +1515,7 @@ func ValidateNPDUnhealthyNvidiaGridLicenseStatusAfterFailure(ctx context.Context
 	return errors.Join(errs...)
 }
 
-func ValidateRuncVersion(ctx context.Context, s *Scenario, versions []string) error {
-	s.T.Helper()
+func ValidateRuncVersion(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image, versions []string) error {
 	if err := assert.Equal(len(versions), 1, "expected exactly one version for moby-runc but got %d", len(versions)); err != nil {
 		return err
 	}
@@ -1828,7 +1531,7 @@ func ValidateRuncVersion(ctx context.Context, s *Scenario, versions []string) er
 	); err != nil {
 		return err
 	}
-	return ValidateInstalledPackageVersion(ctx, s, "moby-runc", versions[0])
+	return ValidateInstalledPackageVersion(ctx, node, vhd, "moby-runc", versions[0])
 }
 
 func ValidateKubeletArgs(ctx context.Context, s *Scenario) error {
@@ -2141,8 +1844,7 @@ func ValidateTaints(ctx context.Context, s *Scenario, expectedTaints string) err
 }
 
 // ValidateLocalDNSService checks if the localdns service is in the expected state (enabled or disabled).
-func ValidateLocalDNSService(ctx context.Context, s *Scenario, state string) error {
-	s.T.Helper()
+func ValidateLocalDNSService(ctx context.Context, node nodeexec.NodeExecutor, state string) error {
 	serviceName := "localdns"
 
 	var script string
@@ -2158,7 +1860,7 @@ test "$active" = "active"   || { echo "expected active, got $active"; exit 1; }
 test "$enabled" = "enabled" || { echo "expected enabled, got $enabled"; exit 1; }
 `, serviceName)
 
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0, "localdns should be running and enabled"); err != nil {
+		if _, err := execOnNodeValidateExitCode(ctx, node, script, 0, "localdns should be running and enabled"); err != nil {
 			return fmt.Errorf("check that localdns is running and enabled: %w", err)
 		}
 		return nil
@@ -2174,7 +1876,7 @@ test "$active" = "inactive" || { echo "expected inactive, got $active"; exit 1;
 test "$enabled" = "disabled" || { echo "expected disabled, got $enabled"; exit 1; }
 `, serviceName)
 
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0, "localdns should be stopped and disabled"); err != nil {
+		if _, err := execOnNodeValidateExitCode(ctx, node, script, 0, "localdns should be stopped and disabled"); err != nil {
 			return fmt.Errorf("check that localdns is stopped and disabled: %w", err)
 		}
 		return nil
@@ -2186,11 +1888,10 @@ test "$enabled" = "disabled" || { echo "expected disabled, got $enabled"; exit 1
 
 // ValidateLocalDNSResolution checks if the DNS resolution for an external domain is successful from localdns clusterlistenerIP.
 // It uses the 'dig' command to check the DNS resolution and expects a successful response.
-func ValidateLocalDNSResolution(ctx context.Context, s *Scenario, server string) error {
-	s.T.Helper()
+func ValidateLocalDNSResolution(ctx context.Context, node nodeexec.NodeExecutor, server string) error {
 	testdomain := "bing.com"
 	command := fmt.Sprintf("dig %s +timeout=1 +tries=1", testdomain)
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 0, "dns resolution failed")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, command, 0, "dns resolution failed")
 	if err != nil {
 		return fmt.Errorf("resolve %s: %w", testdomain, err)
 	}
@@ -2204,8 +1905,7 @@ func ValidateLocalDNSResolution(ctx context.Context, s *Scenario, server string)
 // This validation approach avoids flakiness with CDN/frontdoor-backed FQDNs (like mcr.microsoft.com) whose A records
 // can rotate between queries. We verify presence, not exact IP matching.
 // The hosts file is populated asynchronously by the aks-localdns-hosts-setup timer/service, so we poll with a timeout.
-func ValidateLocalDNSHostsFile(ctx context.Context, s *Scenario, fqdns []string) error {
-	s.T.Helper()
+func ValidateLocalDNSHostsFile(ctx context.Context, node nodeexec.NodeExecutor, fqdns []string) error {
 	// Build script that polls until all FQDNs have at least one IPv4 entry in hosts file
 	script := fmt.Sprintf(`set -euo pipefail
 hosts_file="/etc/localdns/hosts"
@@ -2266,7 +1966,7 @@ while true; do
 done
 `, quoteFQDNsForBash(fqdns))
 
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 		"hosts file should contain resolved IPs for critical FQDNs"); err != nil {
 		return fmt.Errorf("check localdns hosts file entries: %w", err)
 	}
@@ -2282,15 +1982,14 @@ func quoteFQDNsForBash(fqdns []string) string {
 
 // ValidateAKSLocalDNSHostsSetupService checks that aks-localdns-hosts-setup.service ran successfully
 // and the aks-localdns-hosts-setup.timer is active to ensure periodic refresh of /etc/localdns/hosts.
-func ValidateAKSLocalDNSHostsSetupService(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateAKSLocalDNSHostsSetupService(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// Check that aks-localdns-hosts-setup.service (oneshot) completed without failure
-	if err := ValidateSystemdUnitIsNotFailed(ctx, s, "aks-localdns-hosts-setup.service"); err != nil {
+	if err := ValidateSystemdUnitIsNotFailed(ctx, node, "aks-localdns-hosts-setup.service"); err != nil {
 		return err
 	}
 
 	// Check that aks-localdns-hosts-setup.timer is active for periodic refresh
-	return ValidateSystemdUnitIsRunning(ctx, s, "aks-localdns-hosts-setup.timer")
+	return ValidateSystemdUnitIsRunning(ctx, node, "aks-localdns-hosts-setup.timer")
 }
 
 // ValidateLocalDNSHostsPluginBypass verifies that localdns serves FQDNs from /etc/localdns/hosts
@@ -2482,9 +2181,8 @@ echo "  Resolved IPs match /etc/localdns/hosts entries"
 //  1. Find the first FQDN with an IPv6 entry in the hosts file
 //  2. Query localdns for AAAA records for that FQDN
 //  3. Verify the returned IPv6 addresses match the hosts file entries
-func ValidateLocalDNSHostsPluginIPv6(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	s.T.Log("Testing hosts plugin serves IPv6 entries from hosts file")
+func ValidateLocalDNSHostsPluginIPv6(ctx context.Context, node nodeexec.NodeExecutor) error {
+	toolkit.Log(ctx, "Testing hosts plugin serves IPv6 entries from hosts file")
 
 	script := `set -euo pipefail
 hosts_file="/etc/localdns/hosts"
@@ -2559,7 +2257,7 @@ echo "=== SUCCESS ==="
 echo "IPv6 entries in hosts file are correctly served by CoreDNS hosts plugin"
 `
 
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 		"CoreDNS hosts plugin should serve IPv6 entries from hosts file"); err != nil {
 		return fmt.Errorf("check that the CoreDNS hosts plugin serves IPv6 entries: %w", err)
 	}
@@ -2576,9 +2274,8 @@ echo "IPv6 entries in hosts file are correctly served by CoreDNS hosts plugin"
 //  3. Populate hosts file with a canary entry (simulates aks-localdns-hosts-setup completing)
 //  4. Wait for CoreDNS reload (5s), verify canary resolves (hosts plugin picks up new file)
 //  5. Restore original hosts file and stop/start localdns to leave node in clean state
-func ValidateLocalDNSHostsPluginColdStart(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	s.T.Log("Testing localdns cold start with empty hosts file then population")
+func ValidateLocalDNSHostsPluginColdStart(ctx context.Context, node nodeexec.NodeExecutor) error {
+	toolkit.Log(ctx, "Testing localdns cold start with empty hosts file then population")
 
 	script := `#!/bin/bash
 set -euo pipefail
@@ -2807,7 +2504,7 @@ echo "  1. Start with empty hosts file: DNS resolves via fallthrough"
 echo "  2. Hosts file populated later: CoreDNS picks it up via reload"
 `
 
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 		"localdns should work after cold start with empty hosts file and pick up populated file"); err != nil {
 		return fmt.Errorf("check localdns cold start behaviour: %w", err)
 	}
@@ -2821,49 +2518,46 @@ echo "  2. Hosts file populated later: CoreDNS picks it up via reload"
 //   - All other VHDs launch the launcher as a direct fork from the cloud-boothook (not a systemd
 //     unit, for faster dispatch - see baker.go boothookTemplate), with stdout/stderr redirected to
 //     /var/log/azure/aks-node-controller.output.
-func ValidateANCLauncherOutput(ctx context.Context, s *Scenario, expectedContent string) error {
-	s.T.Helper()
-	if s.VHD.Flatcar {
-		return ValidateJournalctlOutput(ctx, s, "aks-node-controller.service", expectedContent)
+func ValidateANCLauncherOutput(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image, expectedContent string) error {
+	if vhd.Flatcar {
+		return ValidateJournalctlOutput(ctx, node, "aks-node-controller.service", expectedContent)
 	}
-	return ValidateFileHasContent(ctx, s, "/var/log/azure/aks-node-controller.output", expectedContent)
+	return validateFileHasContent(ctx, node, false, "/var/log/azure/aks-node-controller.output", expectedContent)
 }
 
 // ValidateJournalctlOutput checks if specific content exists in the systemd service logs
-func ValidateJournalctlOutput(ctx context.Context, s *Scenario, serviceName string, expectedContent string) error {
-	s.T.Helper()
+func ValidateJournalctlOutput(ctx context.Context, node nodeexec.NodeExecutor, serviceName string, expectedContent string) error {
 	command := []string{
 		"set -ex",
 		// Get the service logs and check for the expected content
 		fmt.Sprintf("sudo journalctl -u %s | grep -q '%s'", serviceName, expectedContent),
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0,
 		fmt.Sprintf("expected content '%s' not found in %s service logs", expectedContent, serviceName)); err != nil {
 		return fmt.Errorf("search %s service logs for %q: %w", serviceName, expectedContent, err)
 	}
 	return nil
 }
 
-func ValidateNodeProblemDetector(ctx context.Context, s *Scenario) error {
+func ValidateNodeProblemDetector(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Verify node-problem-detector service is running
 		"systemctl is-active node-problem-detector",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "Node Problem Detector (NPD) service validation failed"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "Node Problem Detector (NPD) service validation failed"); err != nil {
 		return fmt.Errorf("validate Node Problem Detector (NPD) service: %w", err)
 	}
 	return nil
 }
 
-func RestartNodeProblemDetector(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	s.T.Log("restarting node-problem-detector to pick up managed GPU health checks")
+func RestartNodeProblemDetector(ctx context.Context, node nodeexec.NodeExecutor) error {
+	toolkit.Log(ctx, "restarting node-problem-detector to pick up managed GPU health checks")
 	command := []string{
 		"set -ex",
 		"sudo systemctl restart node-problem-detector",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0,
 		"failed to restart Node Problem Detector (NPD) service"); err != nil {
 		return fmt.Errorf("restart Node Problem Detector (NPD) service: %w", err)
 	}
@@ -2878,7 +2572,7 @@ func ValidateNodeExporter(ctx context.Context, s *Scenario) error {
 	// Check if node-exporter is installed on this VHD by looking for the skip sentinel file.
 	// The skip file is only present on supported Ubuntu and Azure Linux 3 VHDs with node-exporter installed.
 	// Mariner, Flatcar, ACL, OSGuard, Kata, and older VHDs do not have the skip file.
-	exists, err := fileExist(ctx, s, skipFile)
+	exists, err := fileExist(ctx, s.NodeExecutor(), s.IsWindows(), skipFile)
 	if err != nil {
 		return fmt.Errorf("check existence of file %s: %w", skipFile, err)
 	}
@@ -2892,8 +2586,8 @@ func ValidateNodeExporter(ctx context.Context, s *Scenario) error {
 	// Validate service is running
 	var errs []error
 	errs = append(errs,
-		ValidateSystemdUnitIsRunning(ctx, s, serviceName),
-		ValidateSystemdUnitIsNotFailed(ctx, s, serviceName),
+		ValidateSystemdUnitIsRunning(ctx, s.NodeExecutor(), serviceName),
+		ValidateSystemdUnitIsNotFailed(ctx, s.NodeExecutor(), serviceName),
 	)
 
 	// Validate service is enabled
@@ -2916,7 +2610,7 @@ func ValidateNodeExporter(ctx context.Context, s *Scenario) error {
 	// so this also verifies that the endpoint is reachable on the address used by monitoring infrastructure.
 	s.T.Logf("Validating node-exporter metrics on port 19100")
 	metricsURL := fmt.Sprintf("http://%s:19100/metrics", s.Runtime.VM.PrivateIP)
-	errs = append(errs, scrapeAndValidateNodeExporter(ctx, s, metricsURL))
+	errs = append(errs, scrapeAndValidateNodeExporter(ctx, s.NodeExecutor(), metricsURL))
 
 	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, fmt.Sprintf("systemctl is-active %s", serviceName), 0,
 		"node-exporter should remain active after scraping"); err != nil {
@@ -2930,9 +2624,8 @@ func ValidateNodeExporter(ctx context.Context, s *Scenario) error {
 	return nil
 }
 
-func scrapeAndValidateNodeExporter(ctx context.Context, s *Scenario, metricsURL string) error {
-	s.T.Helper()
-	result, err := execScriptOnVMForScenario(ctx, s, fmt.Sprintf("curl --noproxy '*' -sS --max-time 10 %q", metricsURL))
+func scrapeAndValidateNodeExporter(ctx context.Context, node nodeexec.NodeExecutor, metricsURL string) error {
+	result, err := execOnNode(ctx, node, fmt.Sprintf("curl --noproxy '*' -sS --max-time 10 %q", metricsURL))
 	if err != nil {
 		return fmt.Errorf("scrape node-exporter metrics from %s: %w", metricsURL, err)
 	}
@@ -3054,16 +2747,15 @@ func ValidateEnableNvidiaResource(ctx context.Context, s *Scenario) error {
 	return waitUntilResourceAvailable(ctx, s, "nvidia.com/gpu")
 }
 
-func ValidateNvidiaDevicePluginServiceRunning(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	s.T.Logf("validating that NVIDIA device plugin systemd service is running")
+func ValidateNvidiaDevicePluginServiceRunning(ctx context.Context, node nodeexec.NodeExecutor) error {
+	toolkit.Logf(ctx, "validating that NVIDIA device plugin systemd service is running")
 
 	command := []string{
 		"set -ex",
 		"systemctl is-active nvidia-device-plugin.service",
 		"systemctl is-enabled nvidia-device-plugin.service",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "NVIDIA device plugin systemd service should be active and enabled"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "NVIDIA device plugin systemd service should be active and enabled"); err != nil {
 		return fmt.Errorf("check that the NVIDIA device plugin systemd service is active and enabled: %w", err)
 	}
 	return nil
@@ -3235,8 +2927,7 @@ fi`)
 	return nil
 }
 
-func ValidateNvidiaDCGMExporterSystemDServiceRunning(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaDCGMExporterSystemDServiceRunning(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Verify nvidia-dcgm service is running
@@ -3244,53 +2935,50 @@ func ValidateNvidiaDCGMExporterSystemDServiceRunning(ctx context.Context, s *Sce
 		// Verify nvidia-dcgm-exporter service is running
 		"systemctl is-active nvidia-dcgm-exporter",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter service validation failed"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter service validation failed"); err != nil {
 		return fmt.Errorf("validate Nvidia DCGM Exporter services: %w", err)
 	}
 	return nil
 }
 
-func ValidateNvidiaDCGMExporterIsScrapable(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateNvidiaDCGMExporterIsScrapable(ctx context.Context, node nodeexec.NodeExecutor) error {
 	command := []string{
 		"set -ex",
 		// Check if nvidia-dcgm-exporter is scrapable on port 19400
 		"curl -f http://localhost:19400/metrics",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter is not scrapable on port 19400"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter is not scrapable on port 19400"); err != nil {
 		return fmt.Errorf("scrape Nvidia DCGM Exporter on port 19400: %w", err)
 	}
 	return nil
 }
 
-func ValidateNvidiaDCGMExporterScrapeCommonMetric(ctx context.Context, s *Scenario, metric string) error {
-	s.T.Helper()
+func ValidateNvidiaDCGMExporterScrapeCommonMetric(ctx context.Context, node nodeexec.NodeExecutor, metric string) error {
 	command := []string{
 		"set -ex",
 		// Verify the most universal GPU metric is present
 		"curl -s http://localhost:19400/metrics | grep -q '" + metric + "'",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter is not returning "+metric); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "Nvidia DCGM Exporter is not returning "+metric); err != nil {
 		return fmt.Errorf("scrape metric %s from Nvidia DCGM Exporter: %w", metric, err)
 	}
 	return nil
 }
 
-func ValidateMIGModeEnabled(ctx context.Context, s *Scenario, gpuCountExpected int) error {
-	s.T.Helper()
-	s.T.Logf("validating that MIG mode is enabled on %d GPUs", gpuCountExpected)
+func ValidateMIGModeEnabled(ctx context.Context, node nodeexec.NodeExecutor, gpuCountExpected int) error {
+	toolkit.Logf(ctx, "validating that MIG mode is enabled on %d GPUs", gpuCountExpected)
 
 	command := []string{
 		"set -ex",
 		"sudo nvidia-smi --query-gpu=mig.mode.current --format=csv,noheader",
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "MIG mode is not enabled")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "MIG mode is not enabled")
 	if err != nil {
 		return fmt.Errorf("query MIG mode: %w", err)
 	}
 
 	stdout := strings.TrimSpace(execResult.stdout)
-	s.T.Logf("MIG mode status: %s", stdout)
+	toolkit.Logf(ctx, "MIG mode status: %s", stdout)
 	gpuStatuses := strings.Split(stdout, "\n")
 	if err := assert.Equal(len(gpuStatuses), gpuCountExpected, "expected MIG status for %d GPUs, but got: %s", gpuCountExpected, stdout); err != nil {
 		return err
@@ -3302,20 +2990,19 @@ func ValidateMIGModeEnabled(ctx context.Context, s *Scenario, gpuCountExpected i
 	if err := errors.Join(errs...); err != nil {
 		return err
 	}
-	s.T.Logf("MIG mode is enabled on %d GPUs", gpuCountExpected)
+	toolkit.Logf(ctx, "MIG mode is enabled on %d GPUs", gpuCountExpected)
 	return nil
 }
 
-func ValidateMIGInstancesCreated(ctx context.Context, s *Scenario, migProfile string, instanceCountExpected int) error {
-	s.T.Helper()
-	s.T.Logf("validating that %d MIG instances are created with profile %s", instanceCountExpected, migProfile)
+func ValidateMIGInstancesCreated(ctx context.Context, node nodeexec.NodeExecutor, migProfile string, instanceCountExpected int) error {
+	toolkit.Logf(ctx, "validating that %d MIG instances are created with profile %s", instanceCountExpected, migProfile)
 
 	command := []string{
 		"set -ex",
 		// List MIG devices using nvidia-smi
 		"sudo nvidia-smi mig -lgi",
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "failed to list MIG instances")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "failed to list MIG instances")
 	if err != nil {
 		return fmt.Errorf("list MIG instances: %w", err)
 	}
@@ -3333,14 +3020,13 @@ func ValidateMIGInstancesCreated(ctx context.Context, s *Scenario, migProfile st
 	if err := assert.Equal(instanceCount, instanceCountExpected, "expected %d MIG instances with profile %s, but found %d.\nOutput:\n%s", instanceCountExpected, migProfile, instanceCount, stdout); err != nil {
 		return err
 	}
-	s.T.Logf("%d MIG instances with profile %s are created", instanceCountExpected, migProfile)
+	toolkit.Logf(ctx, "%d MIG instances with profile %s are created", instanceCountExpected, migProfile)
 	return nil
 }
 
 // ValidateIPTablesCompatibleWithCiliumEBPF validates that all iptables rules in each table match the provided patterns which are accounted for
 // when eBPF host routing is enabled.
-func ValidateIPTablesCompatibleWithCiliumEBPF(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateIPTablesCompatibleWithCiliumEBPF(ctx context.Context, node nodeexec.NodeExecutor) error {
 	tablePatterns, globalPatterns := getIPTablesRulesCompatibleWithEBPFHostRouting()
 	tables := []string{"filter", "mangle", "nat", "raw", "security"}
 	success := true
@@ -3348,7 +3034,7 @@ func ValidateIPTablesCompatibleWithCiliumEBPF(ctx context.Context, s *Scenario)
 	for _, table := range tables {
 		// Get the rules for this table
 		command := fmt.Sprintf("sudo iptables -t %s -S", table)
-		execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, command, 0, fmt.Sprintf("failed to get iptables rules for table %s", table))
+		execResult, err := execOnNodeValidateExitCode(ctx, node, command, 0, fmt.Sprintf("failed to get iptables rules for table %s", table))
 		if err != nil {
 			return fmt.Errorf("get iptables rules for table %s: %w", table, err)
 		}
@@ -3394,7 +3080,7 @@ func ValidateIPTablesCompatibleWithCiliumEBPF(ctx context.Context, s *Scenario)
 			}
 
 			if !matched {
-				s.T.Logf("Rule in table %s did not match any pattern: %s", table, rule)
+				toolkit.Logf(ctx, "Rule in table %s did not match any pattern: %s", table, rule)
 				success = false
 			}
 		}
@@ -3410,14 +3096,13 @@ func ValidateIPTablesCompatibleWithCiliumEBPF(ctx context.Context, s *Scenario)
 }
 
 // ValidateAppArmorBasic validates that AppArmor is running without requiring aa-status
-func ValidateAppArmorBasic(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateAppArmorBasic(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// Check if AppArmor module is enabled in the kernel
 	command := []string{
 		"set -ex",
 		"cat /sys/module/apparmor/parameters/enabled",
 	}
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "failed to check AppArmor kernel parameter")
+	execResult, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "failed to check AppArmor kernel parameter")
 	if err != nil {
 		return fmt.Errorf("check AppArmor kernel parameter: %w", err)
 	}
@@ -3429,7 +3114,7 @@ func ValidateAppArmorBasic(ctx context.Context, s *Scenario) error {
 		"set -ex",
 		"systemctl is-active apparmor.service",
 	}
-	execResult, err = execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "apparmor.service is not active")
+	execResult, err = execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "apparmor.service is not active")
 	if err != nil {
 		return errors.Join(append(errs, fmt.Errorf("check that apparmor.service is active: %w", err))...)
 	}
@@ -3442,7 +3127,7 @@ func ValidateAppArmorBasic(ctx context.Context, s *Scenario) error {
 		"cat /proc/self/attr/apparmor/current",
 	}
 	// Any output indicates AppArmor is active (profile will be shown)
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "failed to check AppArmor current profile"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "failed to check AppArmor current profile"); err != nil {
 		errs = append(errs, fmt.Errorf("check AppArmor current profile: %w", err))
 	}
 	return errors.Join(errs...)
@@ -3515,7 +3200,7 @@ func ValidateScriptlessPhase3(ctx context.Context, s *Scenario) error {
 		return nil
 	}
 	logFile := "/var/log/azure/aks-node-controller.output"
-	hasContent, err := fileHasContent(ctx, s, logFile, "env compare: no differences found between provision-config and nbc-cmd env vars")
+	hasContent, err := fileHasContent(ctx, s.NodeExecutor(), s.IsWindows(), logFile, "env compare: no differences found between provision-config and nbc-cmd env vars")
 	if err != nil {
 		return fmt.Errorf("check whether %s reports no env var differences: %w", logFile, err)
 	}
@@ -3533,11 +3218,10 @@ func ValidateScriptlessPhase3(ctx context.Context, s *Scenario) error {
 
 // ValidateStaleCachedKubeBinariesRemoved validates that stale versioned kube binaries (e.g. kubelet-1.29.0, kubectl-1.29.0)
 // have been removed from /opt/bin/ after the correct version is installed.
-func ValidateStaleCachedKubeBinariesRemoved(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateStaleCachedKubeBinariesRemoved(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// List any remaining versioned kubelet/kubectl binaries in /opt/bin/
 	cmd := `find /opt/bin -maxdepth 1 \( -name "kubelet-*" -o -name "kubectl-*" \) -type f 2>/dev/null`
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0, "could not list stale cached binaries")
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0, "could not list stale cached binaries")
 	if err != nil {
 		return fmt.Errorf("list stale cached binaries: %w", err)
 	}
@@ -3594,21 +3278,20 @@ func ValidateRxBufferDefault(ctx context.Context, s *Scenario) error {
 	}
 
 	// Validate files exist
-	if err := ValidateAzureNetworkFiles(ctx, s); err != nil {
+	if err := ValidateAzureNetworkFiles(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 
 	// Validate network interface settings match expected default
-	return ValidateNetworkInterfaceConfig(ctx, s, customNicConfig)
+	return ValidateNetworkInterfaceConfig(ctx, s.NodeExecutor(), customNicConfig)
 }
 
 // ValidateMANAPCIDevice checks that the MANA PCI device is exposed to the VM.
 // MANA hardware is identified by PCI device ID 0x00ba (Microsoft Corporation).
-func ValidateMANAPCIDevice(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	defer toolkit.LogStep(s.T, "validating MANA PCI device is present")()
+func ValidateMANAPCIDevice(ctx context.Context, node nodeexec.NodeExecutor) error {
+	defer toolkit.LogStepCtx(ctx, "validating MANA PCI device is present")()
 	cmd := "grep -Rqi '^0x00ba$' /sys/bus/pci/devices/*/device 2>/dev/null"
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		"MANA PCI device (0x00ba) not found in /sys/bus/pci/devices"); err != nil {
 		return fmt.Errorf("check MANA PCI device: %w", err)
 	}
@@ -3618,11 +3301,10 @@ func ValidateMANAPCIDevice(ctx context.Context, s *Scenario) error {
 // ValidateMANADriverLoaded checks that the MANA Ethernet driver (mana) is loaded
 // in the running kernel. For built-in drivers they appear in modules.builtin;
 // for loadable modules they must be present in lsmod.
-func ValidateMANADriverLoaded(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	defer toolkit.LogStep(s.T, "validating MANA kernel driver is loaded")()
+func ValidateMANADriverLoaded(ctx context.Context, node nodeexec.NodeExecutor) error {
+	defer toolkit.LogStepCtx(ctx, "validating MANA kernel driver is loaded")()
 	cmd := `lsmod | grep -q '^mana ' || grep -q '/mana\.ko' /lib/modules/$(uname -r)/modules.builtin`
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		"MANA kernel driver (mana) not found in lsmod or modules.builtin"); err != nil {
 		return fmt.Errorf("check MANA kernel driver: %w", err)
 	}
@@ -3631,26 +3313,24 @@ func ValidateMANADriverLoaded(ctx context.Context, s *Scenario) error {
 
 // ValidateAcceleratedNetworkingVFBonded checks that the accelerated networking
 // VF interface exists and is properly bonded to the primary eth0 interface.
-func ValidateAcceleratedNetworkingVFBonded(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	defer toolkit.LogStep(s.T, "validating accelerated networking VF is bonded to eth0")()
+func ValidateAcceleratedNetworkingVFBonded(ctx context.Context, node nodeexec.NodeExecutor) error {
+	defer toolkit.LogStepCtx(ctx, "validating accelerated networking VF is bonded to eth0")()
 	// Look for any interface that has "master eth0" in ip link output,
 	// indicating it is bonded as a VF to the primary synthetic NIC.
 	cmd := `ip link show | grep 'master eth0'`
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		"no VF interface found bonded to eth0 — accelerated networking may not be working")
 	if err != nil {
 		return fmt.Errorf("check accelerated networking VF bonding: %w", err)
 	}
-	s.T.Logf("Accelerated networking VF bonding: %s", strings.TrimSpace(result.stdout))
+	toolkit.Logf(ctx, "Accelerated networking VF bonding: %s", strings.TrimSpace(result.stdout))
 	return nil
 }
 
 // ValidateAcceleratedNetworkingVFHardware verifies the accelerated networking VF
 // is backed by a PCI function and bound to a kernel network driver.
-func ValidateAcceleratedNetworkingVFHardware(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	defer toolkit.LogStep(s.T, "validating accelerated networking VF PCI hardware")()
+func ValidateAcceleratedNetworkingVFHardware(ctx context.Context, node nodeexec.NodeExecutor) error {
+	defer toolkit.LogStepCtx(ctx, "validating accelerated networking VF PCI hardware")()
 
 	cmd := strings.Join([]string{
 		"set -e",
@@ -3676,12 +3356,12 @@ func ValidateAcceleratedNetworkingVFHardware(ctx context.Context, s *Scenario) e
 		`printf 'vf=%s pci_slot=%s driver=%s ethtool_driver=%s vendor=%s device=%s subsystem_vendor=%s subsystem_device=%s\n' "$vf" "$pci_slot" "$driver" "$ethtool_driver" "$vendor" "$device" "$subsystem_vendor" "$subsystem_device"`,
 	}, "\n")
 
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		"accelerated networking VF should be PCI-backed and driver-bound")
 	if err != nil {
 		return fmt.Errorf("check accelerated networking VF PCI hardware: %w", err)
 	}
-	s.T.Logf("Accelerated networking VF hardware: %s", strings.TrimSpace(result.stdout))
+	toolkit.Logf(ctx, "Accelerated networking VF hardware: %s", strings.TrimSpace(result.stdout))
 	return nil
 }
 
@@ -3691,9 +3371,8 @@ func ValidateAcceleratedNetworkingVFHardware(ctx context.Context, s *Scenario) e
 // as a subordinate (SLAVE) of eth0. The VF name varies by VM generation:
 // - V5: enP* (e.g., enP30832p0s0)
 // - V6+: ens1 or enp0s0
-func ValidateMANAVFBonded(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	return ValidateAcceleratedNetworkingVFBonded(ctx, s)
+func ValidateMANAVFBonded(ctx context.Context, node nodeexec.NodeExecutor) error {
+	return ValidateAcceleratedNetworkingVFBonded(ctx, node)
 }
 
 // ValidateAcceleratedNetworkingTrafficFlowing checks that network traffic is
@@ -3775,15 +3454,15 @@ func ValidateMANATrafficFlowing(ctx context.Context, s *Scenario) error {
 // is flowing through the VF.
 func ValidateMANA(ctx context.Context, s *Scenario) error {
 	s.T.Helper()
-	if err := ValidateMANAPCIDevice(ctx, s); err != nil {
+	if err := ValidateMANAPCIDevice(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
-	if err := ValidateMANADriverLoaded(ctx, s); err != nil {
+	if err := ValidateMANADriverLoaded(ctx, s.NodeExecutor()); err != nil {
 		return err
 	}
 	if err := errors.Join(
-		ValidateMANAVFBonded(ctx, s),
-		ValidateAcceleratedNetworkingVFHardware(ctx, s),
+		ValidateMANAVFBonded(ctx, s.NodeExecutor()),
+		ValidateAcceleratedNetworkingVFHardware(ctx, s.NodeExecutor()),
 	); err != nil {
 		return err
 	}
@@ -3793,8 +3472,8 @@ func ValidateMANA(ctx context.Context, s *Scenario) error {
 // hasMANAHardware checks if the VM has MANA PCI hardware available.
 // Returns true if the MANA device (0x00ba) is found in sysfs.
 // This is used to conditionally run MANA validations on VMs that support it.
-func hasMANAHardware(ctx context.Context, s *Scenario) (bool, error) {
-	result, err := execScriptOnVMForScenario(ctx, s, "grep -Rqi '^0x00ba$' /sys/bus/pci/devices/*/device 2>/dev/null")
+func hasMANAHardware(ctx context.Context, node nodeexec.NodeExecutor) (bool, error) {
+	result, err := execOnNode(ctx, node, "grep -Rqi '^0x00ba$' /sys/bus/pci/devices/*/device 2>/dev/null")
 	if err != nil {
 		return false, fmt.Errorf("check for MANA PCI hardware: %w", err)
 	}
@@ -4019,10 +3698,9 @@ func ValidateCollectWindowsLogsScript(ctx context.Context, s *Scenario) error {
 //
 // To add a new CVE mitigation, append the module name to BOTH lists below —
 // the absence-check list AND the default presence + load-refusal list.
-func ValidateVulnerableKernelModulesDisabled(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	if s.VHD.Flatcar && s.VHD.OS != config.OSACL {
-		s.T.Log("Skipping vulnerable kernel module validation: not applicable for Flatcar")
+func ValidateVulnerableKernelModulesDisabled(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image) error {
+	if vhd.Flatcar && vhd.OS != config.OSACL {
+		toolkit.Log(ctx, "Skipping vulnerable kernel module validation: not applicable for Flatcar")
 		return nil
 	}
 
@@ -4030,7 +3708,7 @@ func ValidateVulnerableKernelModulesDisabled(ctx context.Context, s *Scenario) e
 	// blacklist and the bake-in has been removed because customers need those modules. Assert
 	// the blacklist entries are NOT present on freshly-built AzL3 VHDs. AzureLinux OSGuard is
 	// intentionally kept in-scope (falls through to the full presence + load-refusal check below).
-	if s.VHD.OS == config.OSAzureLinux && !s.VHD.Distro.IsAzureLinuxOSGuardDistro() && s.VHD.Distro != datamodel.AKSAzureLinuxV2Gen2 {
+	if vhd.OS == config.OSAzureLinux && !vhd.Distro.IsAzureLinuxOSGuardDistro() && vhd.Distro != datamodel.AKSAzureLinuxV2Gen2 {
 		script := strings.Join([]string{
 			`failed=0`,
 			`for mod in algif_aead esp4 esp6 rxrpc; do`,
@@ -4043,14 +3721,14 @@ func ValidateVulnerableKernelModulesDisabled(ctx context.Context, s *Scenario) e
 			`done`,
 			`exit $failed`,
 		}, "\n")
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+		if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 			"AzureLinux 3.0 modprobe blacklist should be absent (kernel fix 6.6.139.1-1.azl3+ supersedes; bake-in removed; no `install` or `blacklist` directive should remain)"); err != nil {
 			return fmt.Errorf("check that the AzureLinux 3.0 modprobe blacklist is absent: %w", err)
 		}
 		return nil
 	}
 
-	if s.VHD.OS == config.OSUbuntu {
+	if vhd.OS == config.OSUbuntu {
 		script := strings.Join([]string{
 			`failed=0`,
 			`. /etc/os-release`,
@@ -4096,7 +3774,7 @@ func ValidateVulnerableKernelModulesDisabled(ctx context.Context, s *Scenario) e
 			`fi`,
 		}, "\n")
 		script += "\n" + kernelModuleFullBlockValidationScript()
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+		if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 			"Ubuntu vulnerable kernel module validation failed (fixed/future Ubuntu should have no blacklist; Ubuntu 20.04 and older/unknown 22.04/24.04 kernels should keep algif_aead/esp4/esp6/rxrpc blocked)"); err != nil {
 			return fmt.Errorf("validate vulnerable kernel modules on Ubuntu: %w", err)
 		}
@@ -4104,7 +3782,7 @@ func ValidateVulnerableKernelModulesDisabled(ctx context.Context, s *Scenario) e
 	}
 
 	script := kernelModuleFullBlockValidationScript()
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, script, 0,
+	if _, err := execOnNodeValidateExitCode(ctx, node, script, 0,
 		"Vulnerable kernel module mitigation validation failed (algif_aead/esp4/esp6/rxrpc)"); err != nil {
 		return fmt.Errorf("validate vulnerable kernel module mitigation: %w", err)
 	}
@@ -4144,8 +3822,7 @@ func kernelModuleFullBlockValidationScript() string {
 // (IMDS interface index 1) by matching its MAC address against /sys/class/net/*/address.
 // This avoids hardcoding "eth1" which can be wrong when SR-IOV VFs or predictable
 // naming (ens*/enP*) are in use.
-func resolveSecondaryNICName(ctx context.Context, s *Scenario) (string, error) {
-	s.T.Helper()
+func resolveSecondaryNICName(ctx context.Context, node nodeexec.NodeExecutor) (string, error) {
 	// Get the secondary NIC's MAC from IMDS, then look it up in sysfs.
 	// -sf makes curl fail with non-zero exit on HTTP errors (403/404) instead
 	// of silently returning the error body as the "MAC".
@@ -4154,7 +3831,7 @@ func resolveSecondaryNICName(ctx context.Context, s *Scenario) (string, error) {
 	// Exit 1 if no matching interface is found rather than falling back to a
 	// hardcoded name that could target a VF or wrong interface.
 	cmd := `mac=$(curl -sf -H "Metadata:true" "http://169.254.169.254/metadata/instance/network/interface/1/macAddress?api-version=2021-02-01&format=text") || { echo "IMDS MAC lookup failed" >&2; exit 1; }; mac_lower=$(echo "$mac" | sed 's/\(..\)/\1:/g; s/:$//' | tr '[:upper:]' '[:lower:]'); for f in /sys/class/net/*/address; do d=$(dirname "$f"); [ -e "$d/master" ] && continue; if [ "$(cat "$f" 2>/dev/null)" = "$mac_lower" ]; then basename "$d"; exit 0; fi; done; echo "no interface found for MAC $mac_lower" >&2; exit 1`
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		"failed to resolve secondary NIC interface name")
 	if err != nil {
 		return "", fmt.Errorf("resolve secondary NIC interface name: %w", err)
@@ -4167,10 +3844,9 @@ func resolveSecondaryNICName(ctx context.Context, s *Scenario) (string, error) {
 }
 
 // ValidateSecondaryNICUp checks that the given network interface is UP and has an IPv4 address.
-func ValidateSecondaryNICUp(ctx context.Context, s *Scenario, ifaceName string) error {
-	s.T.Helper()
+func ValidateSecondaryNICUp(ctx context.Context, node nodeexec.NodeExecutor, ifaceName string) error {
 	cmd := fmt.Sprintf("ip addr show %s", ifaceName)
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		fmt.Sprintf("failed to get interface info for %s", ifaceName))
 	if err != nil {
 		return fmt.Errorf("get interface info for %s: %w", ifaceName, err)
@@ -4184,10 +3860,9 @@ func ValidateSecondaryNICUp(ctx context.Context, s *Scenario, ifaceName string)
 }
 
 // ValidateSecondaryNICDualStack checks that the given network interface is UP and has both IPv4 and IPv6 addresses.
-func ValidateSecondaryNICDualStack(ctx context.Context, s *Scenario, ifaceName string) error {
-	s.T.Helper()
+func ValidateSecondaryNICDualStack(ctx context.Context, node nodeexec.NodeExecutor, ifaceName string) error {
 	cmd := fmt.Sprintf("ip addr show %s", ifaceName)
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, cmd, 0,
+	result, err := execOnNodeValidateExitCode(ctx, node, cmd, 0,
 		fmt.Sprintf("failed to get interface info for %s", ifaceName))
 	if err != nil {
 		return fmt.Errorf("get interface info for %s: %w", ifaceName, err)
@@ -4204,16 +3879,15 @@ func ValidateSecondaryNICDualStack(ctx context.Context, s *Scenario, ifaceName s
 	)
 }
 
-func ValidateDraDriverNvidiaGpuServiceRunning(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-	s.T.Logf("validating DRA driver NVIDIA GPU systemd service is running")
+func ValidateDraDriverNvidiaGpuServiceRunning(ctx context.Context, node nodeexec.NodeExecutor) error {
+	toolkit.Logf(ctx, "validating DRA driver NVIDIA GPU systemd service is running")
 
 	command := []string{
 		"set -ex",
 		"systemctl is-active dra-driver-nvidia-gpu.service",
 		"systemctl is-enabled dra-driver-nvidia-gpu.service",
 	}
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, strings.Join(command, "\n"), 0, "DRA driver NVIDIA GPU systemd service should be active and enabled"); err != nil {
+	if _, err := execOnNodeValidateExitCode(ctx, node, strings.Join(command, "\n"), 0, "DRA driver NVIDIA GPU systemd service should be active and enabled"); err != nil {
 		return fmt.Errorf("check that the DRA driver NVIDIA GPU systemd service is active and enabled: %w", err)
 	}
 	return nil
@@ -4326,39 +4000,38 @@ func ValidateDRAWorkloadSchedulable(ctx context.Context, s *Scenario) (err error
 
 // ValidateRCV1PCertMode validates that the rcv1p certificate endpoint mode was used during
 // Linux node provisioning, certificates were downloaded and installed, and a refresh task was scheduled.
-func ValidateRCV1PCertMode(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateRCV1PCertMode(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image) error {
 	var errs []error
 	errs = append(errs,
 		// Validate the provisioning log shows rcv1p mode was selected
-		ValidateFileHasContent(ctx, s, "/var/log/azure/cluster-provision.log",
+		validateFileHasContent(ctx, node, false, "/var/log/azure/cluster-provision.log",
 			"Using custom cloud certificate endpoint mode: rcv1p"),
 		// Validate the subscription is opted in for root certs
-		ValidateFileHasContent(ctx, s, "/var/log/azure/cluster-provision.log",
+		validateFileHasContent(ctx, node, false, "/var/log/azure/cluster-provision.log",
 			"IsOptedInForRootCerts=true"),
 		// Validate certificates were downloaded
-		ValidateNonEmptyDirectory(ctx, s, "/root/AzureCACertificates"),
+		ValidateNonEmptyDirectory(ctx, node, "/root/AzureCACertificates"),
 	)
 
 	// Validate trust store was updated (distro-specific path)
-	trustStoreDir := rcv1pTrustStoreDir(s)
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+	trustStoreDir := rcv1pTrustStoreDir(vhd)
+	if _, err := execOnNodeValidateExitCode(ctx, node,
 		fmt.Sprintf("sudo bash -c 'ls -1 %s/*.{crt,pem} 2>/dev/null' | grep -q .", trustStoreDir),
 		0, fmt.Sprintf("expected certificates in trust store directory %s", trustStoreDir)); err != nil {
 		errs = append(errs, fmt.Errorf("check certificates in trust store directory %s: %w", trustStoreDir, err))
 	}
 
 	// Validate refresh schedule was created (cron or systemd timer depending on distro)
-	if s.VHD.Flatcar || s.VHD.OS == config.OSACL {
+	if vhd.Flatcar || vhd.OS == config.OSACL {
 		// Flatcar and ACL use systemd timer
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+		if _, err := execOnNodeValidateExitCode(ctx, node,
 			"systemctl is-enabled azure-ca-refresh.timer",
 			0, "expected azure-ca-refresh.timer to be enabled"); err != nil {
 			errs = append(errs, fmt.Errorf("check that azure-ca-refresh.timer is enabled: %w", err))
 		}
 	} else {
 		// Ubuntu, Mariner, AzureLinux use cron
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+		if _, err := execOnNodeValidateExitCode(ctx, node,
 			"sudo crontab -l 2>/dev/null | grep -q ca-refresh",
 			0, "expected ca-refresh cron entry"); err != nil {
 			errs = append(errs, fmt.Errorf("check ca-refresh cron entry: %w", err))
@@ -4367,9 +4040,9 @@ func ValidateRCV1PCertMode(ctx context.Context, s *Scenario) error {
 	return errors.Join(errs...)
 }
 
-// rcv1pTrustStoreDir returns the OS trust store directory for the given scenario's distro.
-func rcv1pTrustStoreDir(s *Scenario) string {
-	switch s.VHD.OS {
+// rcv1pTrustStoreDir returns the OS trust store directory of the VHD distro.
+func rcv1pTrustStoreDir(vhd *config.Image) string {
+	switch vhd.OS {
 	case config.OSMariner, config.OSAzureLinux, config.OSACL:
 		return "/etc/pki/ca-trust/source/anchors"
 	case config.OSFlatcar:
@@ -4418,31 +4091,30 @@ func ValidateRCV1PCertModeWindows(ctx context.Context, s *Scenario) error {
 // ValidateRCV1PNotOptedIn validates that when the VM does NOT have the opt-in tag,
 // wireserver returns IsOptedInForRootCerts=false and no certificates are installed,
 // even in the RCV1P subscription with PlatformSettingsOverride registered.
-func ValidateRCV1PNotOptedIn(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
+func ValidateRCV1PNotOptedIn(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image) error {
 	var errs []error
 	errs = append(errs,
 		// Validate the provisioning log shows rcv1p mode was selected
-		ValidateFileHasContent(ctx, s, "/var/log/azure/cluster-provision.log",
+		validateFileHasContent(ctx, node, false, "/var/log/azure/cluster-provision.log",
 			"Using custom cloud certificate endpoint mode: rcv1p"),
 		// Validate wireserver reported not opted in
-		ValidateFileHasContent(ctx, s, "/var/log/azure/cluster-provision.log",
+		validateFileHasContent(ctx, node, false, "/var/log/azure/cluster-provision.log",
 			"Skipping custom cloud root cert installation because IsOptedInForRootCerts is not true"),
 		// Validate no certificates were downloaded
-		ValidateEmptyDirectory(ctx, s, "/root/AzureCACertificates"),
+		ValidateEmptyDirectory(ctx, node, "/root/AzureCACertificates"),
 	)
 
 	// Validate no refresh schedule was created
-	if s.VHD.Flatcar || s.VHD.OS == config.OSACL {
+	if vhd.Flatcar || vhd.OS == config.OSACL {
 		// Flatcar and ACL use systemd timer for cert refresh (see ValidateRCV1PCertMode).
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+		if _, err := execOnNodeValidateExitCode(ctx, node,
 			"systemctl is-enabled azure-ca-refresh.timer 2>/dev/null",
 			1, "expected azure-ca-refresh.timer to be absent/disabled when not opted in"); err != nil {
 			errs = append(errs, fmt.Errorf("check that azure-ca-refresh.timer is absent/disabled: %w", err))
 		}
 	} else {
 		// Ubuntu, Mariner, AzureLinux use cron.
-		if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+		if _, err := execOnNodeValidateExitCode(ctx, node,
 			"sudo crontab -l 2>/dev/null | grep -q ca-refresh",
 			1, "expected no ca-refresh cron entry when not opted in"); err != nil {
 			errs = append(errs, fmt.Errorf("check that no ca-refresh cron entry exists: %w", err))
@@ -4492,13 +4164,12 @@ func ValidateRCV1PNotOptedInWindows(ctx context.Context, s *Scenario) error {
 }
 
 // ValidateServiceInSlice asserts that the given systemd service is running in the expected slice.
-func ValidateServiceInSlice(ctx context.Context, s *Scenario, service, expectedSlice string) error {
-	s.T.Helper()
+func ValidateServiceInSlice(ctx context.Context, node nodeexec.NodeExecutor, service, expectedSlice string) error {
 	// Avoid accidental shell injection / option smuggling.
 	if !regexp.MustCompile(`^[A-Za-z0-9_.@:-]+$`).MatchString(service) {
 		return fmt.Errorf("invalid systemd unit name: %q", service)
 	}
-	result, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+	result, err := execOnNodeValidateExitCode(ctx, node,
 		fmt.Sprintf("systemctl show --property=Slice --value -- %s", service), 0,
 		fmt.Sprintf("could not query Slice property of %s", service))
 	if err != nil {
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/Azure/agentbaker/e2e/assert"
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	corev1 "k8s.io/api/core/v1"
 	nodev1 "k8s.io/api/node/v1"
@@ -16,7 +18,7 @@ import (
 
 const (
 	// kataRuntimeHandler is the containerd runtime handler name for standard Kata Containers,
-	// emitted by the IsKata block of the containerd config templates in pkg/agent/baker.go.
+	// added to the containerd config of Kata distros by pkg/agent/containerd.go.
 	kataRuntimeHandler        = "kata"
 	kataPreviewRuntimeHandler = "kata-preview"
 
@@ -41,43 +43,38 @@ var kataRuntimeHandlers = []string{kataRuntimeHandler, kataPreviewRuntimeHandler
 // ValidateKataContainerdConfig asserts that AgentBaker rendered a containerd configuration
 // containing the Kata runtime handlers on a Kata-enabled VHD.
 //
-// This is the core regression check for the IsKata blocks of the containerd config templates in
-// pkg/agent/baker.go. Note that AgentPoolProfile.IsContainerdV2Distro() returns false for every
-// Kata distro (pkg/agent/datamodel/types.go), so Kata nodes are always rendered from
-// containerdV1ConfigTemplate / containerdV1NoGPUConfigTemplate regardless of the underlying OS.
-// The assertions below therefore target the containerd 1.x plugin paths that those templates
-// emit. If Kata is ever promoted to the V2 templates, this validator should fail loudly rather
-// than silently pass, which is why the plugin paths are asserted explicitly.
-func ValidateKataContainerdConfig(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-
-	if err := assert.Equal(s.VHD.Distro.IsKataDistro(), true,
-		"ValidateKataContainerdConfig requires a Kata distro, got %q", s.VHD.Distro); err != nil {
+// This is the core regression check for the Kata runtime handlers added by buildContainerdConfig in
+// pkg/agent/containerd.go. Note that AgentPoolProfile.IsContainerdV2Distro() returns false for every
+// Kata distro (pkg/agent/datamodel/types.go), so Kata nodes always get the containerd 1.x config
+// regardless of the underlying OS. The assertions below therefore target the containerd 1.x plugin
+// paths of that config. If Kata is ever promoted to the containerd 2.x config, this validator should
+// fail loudly rather than silently pass, which is why the plugin paths are asserted explicitly.
+func ValidateKataContainerdConfig(ctx context.Context, node nodeexec.NodeExecutor, vhd *config.Image) error {
+	if err := assert.Equal(vhd.Distro.IsKataDistro(), true,
+		"ValidateKataContainerdConfig requires a Kata distro, got %q", vhd.Distro); err != nil {
 		return err
 	}
 
 	return errors.Join(
 		// The standard "kata" runtime handler, backed by the kata v2 shim.
-		ValidateFileHasContent(ctx, s, containerdConfigPath, `[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]`),
-		ValidateFileHasContent(ctx, s, containerdConfigPath, `runtime_type = "io.containerd.kata.v2"`),
-		ValidateFileHasContent(ctx, s, containerdConfigPath, kataConfigPath),
+		validateFileHasContent(ctx, node, false, containerdConfigPath, `[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]`),
+		validateFileHasContent(ctx, node, false, containerdConfigPath, `runtime_type = "io.containerd.kata.v2"`),
+		validateFileHasContent(ctx, node, false, containerdConfigPath, kataConfigPath),
 
-		// Kata relies on snapshot annotations being forwarded to the snapshotter; the template sets
+		// Kata relies on snapshot annotations being forwarded to the snapshotter; the config sets
 		// this explicitly under IsKata and disabling it breaks image pulling for Kata pods.
-		ValidateFileHasContent(ctx, s, containerdConfigPath, "disable_snapshot_annotations = false"),
+		validateFileHasContent(ctx, node, false, containerdConfigPath, "disable_snapshot_annotations = false"),
 	)
 }
 
 // ValidateKataErofsContainerdConfig checks that the EROFS snapshotter is configured and that
 // containerd loaded all of its EROFS plugins successfully.
-func ValidateKataErofsContainerdConfig(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-
+func ValidateKataErofsContainerdConfig(ctx context.Context, node nodeexec.NodeExecutor) error {
 	errs := []error{
-		ValidateFileHasContent(ctx, s, containerdConfigPath, `[plugins."io.containerd.snapshotter.v1.erofs"]`),
+		validateFileHasContent(ctx, node, false, containerdConfigPath, `[plugins."io.containerd.snapshotter.v1.erofs"]`),
 	}
 
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+	execResult, err := execOnNodeValidateExitCode(ctx, node,
 		"sudo ctr plugins list | grep erofs", 0, "unable to list EROFS containerd plugins")
 	if err != nil {
 		// Without the plugin list there is nothing left to assert on.
@@ -103,8 +100,8 @@ func ValidateKataErofsContainerdConfig(ctx context.Context, s *Scenario) error {
 // Checking the file alone is not enough. Kata VHDs ship their own containerd build - CSE skips
 // installing one (see the "azurelinuxkata" entries in parts/common/components.json) - so the
 // containerd major version on the node is decided by the image, not by AgentBaker, while the
-// template AgentBaker renders is decided by the distro (IsContainerdV2Distro short-circuits to
-// the v1 template for every Kata distro). The two can therefore disagree: AzureLinux V3 Kata
+// config AgentBaker generates is decided by the distro (IsContainerdV2Distro short-circuits to
+// the containerd 1.x config for every Kata distro). The two can therefore disagree: AzureLinux V3 Kata
 // currently boots containerd 2.x while being handed a containerd 1.x style config.
 //
 // That combination happens to work today because containerd 2.x migrates the legacy
@@ -113,14 +110,12 @@ func ValidateKataErofsContainerdConfig(ctx context.Context, s *Scenario) error {
 // validator pins the property we actually care about: after containerd has parsed the config,
 // the Kata handlers are present in the effective configuration and containerd raised no
 // warnings while getting there.
-func ValidateKataContainerdConfigDump(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-
+func ValidateKataContainerdConfigDump(ctx context.Context, node nodeexec.NodeExecutor) error {
 	// This must run on the node itself, not in a debug pod. The "debugnonhost" daemonset pods
 	// used by execOnVMForScenarioOnUnprivilegedPod run a bare CBL-Mariner base image with no
 	// volume mounts, so the host's containerd binary is not reachable from them and the command
 	// would simply exit 127.
-	execResult, err := execScriptOnVMForScenarioValidateExitCode(ctx, s, "sudo containerd config dump", 0,
+	execResult, err := execOnNodeValidateExitCode(ctx, node, "sudo containerd config dump", 0,
 		"unable to dump the effective containerd config on the node")
 	if err != nil {
 		return err
@@ -150,7 +145,7 @@ func ValidateKataContainerdConfigDump(ctx context.Context, s *Scenario) error {
 		"expected the kata v2 shim runtime_type in the effective containerd config.\nDump:\n%s", dump))
 
 	// A warning here means containerd did not fully understand the config we generated, e.g. it
-	// had to fall back on deprecated handling for the legacy plugin paths the Kata templates use.
+	// had to fall back on deprecated handling for the legacy plugin paths the Kata config uses.
 	errs = append(errs, assert.NotContains(diagnostics, "level=warning",
 		"containerd reported warnings while parsing the AgentBaker-generated config.\nstdout:\n%s\nstderr:\n%s",
 		execResult.stdout, execResult.stderr))
@@ -161,25 +156,23 @@ func ValidateKataContainerdConfigDump(ctx context.Context, s *Scenario) error {
 // ValidateKataHostReadiness asserts the host-side prerequisites that the Kata VHD is expected to
 // ship and that the containerd config references. Without these, the containerd config would be
 // syntactically valid but the kata shim would fail at pod sandbox creation time.
-func ValidateKataHostReadiness(ctx context.Context, s *Scenario) error {
-	s.T.Helper()
-
+func ValidateKataHostReadiness(ctx context.Context, node nodeexec.NodeExecutor) error {
 	var errs []error
 
 	// The kata shim binary that runtime_type = "io.containerd.kata.v2" resolves to.
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+	if _, err := execOnNodeValidateExitCode(ctx, node,
 		"command -v containerd-shim-kata-v2", 0, "containerd-shim-kata-v2 is not present on the Kata VHD"); err != nil {
 		errs = append(errs, err)
 	}
 
 	// The Kata configuration file referenced by options.ConfigPath in the containerd config.
-	errs = append(errs, ValidateFileExists(ctx, s, kataConfigPath))
+	errs = append(errs, validateFileExists(ctx, node, false, kataConfigPath))
 
 	// Kata VHDs deliberately opt out of automatic package updates even when unattended upgrades
 	// are enabled, because kata packages must be updated as a unit (including the kernel, which
 	// requires a reboot). See the IS_KATA branch in parts/linux/cloud-init/artifacts/cse_main.sh.
 	// The scenario leaves unattended upgrades enabled so this branch is genuinely exercised.
-	if _, err := execScriptOnVMForScenarioValidateExitCode(ctx, s,
+	if _, err := execOnNodeValidateExitCode(ctx, node,
 		"systemctl is-enabled dnf-automatic-install.timer", 1,
 		"dnf-automatic-install.timer must not be enabled on Kata VHDs: kata packages have to be updated as a unit via image updates"); err != nil {
 		errs = append(errs, err)
@@ -225,11 +218,14 @@ func ValidateKataPodIsIsolated(ctx context.Context, s *Scenario, handler string)
 		return err
 	}
 
-	execResult, err := execOnPod(ctx, s.Runtime.Kube, pod.Namespace, pod.Name, []string{"uname", "-r"})
+	kataPod := podExecutor(s.Runtime.Kube, pod.Namespace, pod.Name)
+	// the busybox image of the kata pod has no bash.
+	kataPod.Shell = []string{"sh", "-c"}
+	execResult, err := s.targetExecutor("kata pod", kataPod).Exec(ctx, "uname -r")
 	if err != nil {
 		return fmt.Errorf("failed to exec in kata pod %q: %w", pod.Name, err)
 	}
-	guestKernel := strings.TrimSpace(execResult.stdout)
+	guestKernel := strings.TrimSpace(execResult.Stdout)
 	if err := assert.NotEqual(guestKernel, "", "kata guest kernel release was empty"); err != nil {
 		return err
 	}
@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/agentbaker/e2e/components"
+	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/nodeexec"
+	"github.com/Azure/agentbaker/e2e/toolkit"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNode answers commands whose last line is a key of results with its result, other commands exit with 1.
+func fakeNode(results map[string]nodeexec.Result) nodeexec.NodeExecutor {
+	return nodeexec.Func(func(_ context.Context, command string) (*nodeexec.Result, error) {
+		lines := strings.Split(command, "\n")
+		for last, result := range results {
+			if lines[len(lines)-1] == last {
+				result.Command = command
+				return &result, nil
+			}
+		}
+		return &nodeexec.Result{Command: command, ExitCode: 1}, nil
+	})
+}
+
+func TestValidateSystemdUnitIsRunning(t *testing.T) {
+	node := fakeNode(map[string]nodeexec.Result{
+		"systemctl is-active kubelet": {Stdout: "active\n"},
+	})
+
+	require.NoError(t, ValidateSystemdUnitIsRunning(toolkit.ContextWithT(context.Background(), t), node, "kubelet"))
+	require.ErrorContains(t, ValidateSystemdUnitIsRunning(toolkit.ContextWithT(context.Background(), t), node, "containerd"), "service containerd is not running")
+}
+
+func TestValidateFileHasContent(t *testing.T) {
+	node := fakeNode(map[string]nodeexec.Result{
+		`(sudo cat /etc/default/kubelet | grep -q -F -e "--rotate-certificates=true")`: {},
+		"sudo cat /etc/default/kubelet": {Stdout: "KUBELET_FLAGS=--rotate-certificates=true\n"},
+	})
+
+	require.NoError(t, validateFileHasContent(toolkit.ContextWithT(context.Background(), t), node, false, "/etc/default/kubelet", "--rotate-certificates=true"))
+	require.ErrorContains(t,
+		validateFileHasContent(toolkit.ContextWithT(context.Background(), t), node, false, "/etc/default/kubelet", "--tls-cert-file"),
+		`expected file /etc/default/kubelet to have contents "--tls-cert-file", but it does not. It had contents KUBELET_FLAGS=--rotate-certificates=true`)
+}
+
+func TestScenarioValidatorsReplayRecording(t *testing.T) {
+	recorder := nodeexec.NewRecorder(fakeNode(map[string]nodeexec.Result{
+		"test -f /opt/azure/containers/provision.complete": {},
+		"systemctl is-active kubelet":                      {Stdout: "active\n"},
+	}))
+	recorded := &Scenario{T: t, Config: Config{VHD: config.VHDUbuntu2204Gen2Containerd}, Runtime: &ScenarioRuntime{Executor: recorder}}
+	require.NoError(t, ValidateFileExists(toolkit.ContextWithT(context.Background(), t), recorded, "/opt/azure/containers/provision.complete"))
+	require.NoError(t, ValidateFileDoesNotExist(toolkit.ContextWithT(context.Background(), t), recorded, "/opt/azure/containers/provision.failed"))
+	require.NoError(t, ValidateSystemdUnitIsRunning(toolkit.ContextWithT(context.Background(), t), recorder, "kubelet"))
+
+	replay := nodeexec.NewReplay(recorder.Recording())
+	replayed := &Scenario{T: t, Config: Config{VHD: config.VHDUbuntu2204Gen2Containerd}, Runtime: &ScenarioRuntime{Executor: replay}}
+	require.NoError(t, ValidateFileExists(toolkit.ContextWithT(context.Background(), t), replayed, "/opt/azure/containers/provision.complete"))
+	require.NoError(t, ValidateFileDoesNotExist(toolkit.ContextWithT(context.Background(), t), replayed, "/opt/azure/containers/provision.failed"))
+	require.ErrorContains(t, ValidateFileExists(toolkit.ContextWithT(context.Background(), t), replayed, "/etc/kubernetes/azure.json"), "no recorded result")
+	// the node validators take the replay itself, without a scenario.
+	require.NoError(t, ValidateSystemdUnitIsRunning(toolkit.ContextWithT(context.Background(), t), replay, "kubelet"))
+	require.ErrorContains(t, ValidateSystemdUnitIsRunning(toolkit.ContextWithT(context.Background(), t), replay, "containerd"), "no recorded result")
+}
+
+func TestScenarioValidatorsReplayPodCommands(t *testing.T) {
+	replay := nodeexec.NewReplay(&nodeexec.Recording{Version: nodeexec.RecordingVersion, Entries: []nodeexec.Entry{
+		{Target: "debugnonhost pod", Result: nodeexec.Result{Command: "systemctl cat emit-kubelet-active-flags.service 2>/dev/null", ExitCode: 1}},
+	}})
+	// the replay answers the pod commands without the cluster the recording was made on.
+	s := &Scenario{T: t, Config: Config{VHD: config.VHDUbuntu2204Gen2Containerd}, Runtime: &ScenarioRuntime{Executor: replay}}
+
+	require.NoError(t, ValidateKubeletActiveFlagsEvent(toolkit.ContextWithT(context.Background(), t), s))
+}
+
+func TestValidateInstalledPackageVersion(t *testing.T) {
+	node := fakeNode(map[string]nodeexec.Result{
+		"sudo dnf list installed": {Stdout: "containerd2.x86_64    2.0.0-1.azl3    @azurelinux-official-base\n"},
+	})
+	ctx := toolkit.ContextWithT(context.Background(), t)
+
+	require.NoError(t, ValidateInstalledPackageVersion(ctx, node, config.VHDAzureLinuxV3Gen2, "containerd2", "2.0.0"))
+	require.Error(t, ValidateInstalledPackageVersion(ctx, node, config.VHDAzureLinuxV3Gen2, "containerd2", "2.1.0"))
+	require.ErrorContains(t, ValidateInstalledPackageVersion(ctx, node, config.VHDWindows2022Containerd, "containerd2", "2.0.0"), "isn't implemented for OS windows")
+}
+
+func TestProvisionedNodeFinding(t *testing.T) {
+	dcgm := components.Finding{Kind: components.FindingMissing, Type: components.ComponentPackage, Component: "dcgm-exporter", Expected: []string{"4.8.2-10.azl3"}}
+	require.True(t, provisionedNodeFinding(dcgm, false), "cleanUpGPUDrivers removes the managed GPU packages of non-GPU nodes")
+	require.False(t, provisionedNodeFinding(dcgm, true))
+	containerd := components.Finding{Kind: components.FindingMissing, Type: components.ComponentPackage, Component: "containerd", Expected: []string{"2.0.0"}}
+	require.False(t, provisionedNodeFinding(containerd, false))
+}
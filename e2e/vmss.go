@@ -14,12 +14,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/Azure/agentbaker/aks-node-controller/pkg/nodeconfigutils"
 	"github.com/Azure/agentbaker/e2e/config"
+	"github.com/Azure/agentbaker/e2e/errorclass"
 	"github.com/Azure/agentbaker/e2e/toolkit"
 	"github.com/Azure/agentbaker/pkg/agent"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
@@ -73,11 +75,6 @@ func compileAKSNodeController(ctx context.Context, arch string) (*os.File, error
 	return f, nil
 }
 
-// maxOutboundCSERetries bounds how many times node provisioning is retried when the
-// CSE outbound connectivity preflight check fails (ERR_OUTBOUND_CONN_FAIL / exit 50).
-// This is a known transient e2e-infrastructure flake; a genuine product regression
-// fails on every attempt and still surfaces once the budget is exhausted.
-const maxOutboundCSERetries = 2
 const vmssLogCollectionTimeout = 4 * time.Minute
 
 func ConfigureAndCreateVMSS(ctx context.Context, s *Scenario) (*ScenarioVM, error) {
@@ -99,50 +96,59 @@ func ConfigureAndCreateVMSS(ctx context.Context, s *Scenario) (*ScenarioVM, erro
 		return nil
 	})
 
-	skipTestIfSKUNotAvailableErr(s.T, err)
+	skipScenarioByErrorClass(s.T, errorclass.PhaseVMSS, err)
 
 	return vm, err
 }
 
 // createVMSSRecreatingOnOutboundCSEFlake creates the VMSS and, on the known transient e2e-infra
-// outbound flake, recreates the node a bounded number of times.
+// outbound flake, recreates the node as many times as the cse-outbound-conn-fail rule allows.
 //
 // The CSE outbound connectivity preflight check (curl mcr.microsoft.com, optionally via the e2e
 // proxy) intermittently fails all of its own retries and exits ERR_OUTBOUND_CONN_FAIL (50) before
-// kubelet starts. Recreating the node up to maxOutboundCSERetries times reduces PR-gate noise
-// without masking real regressions: a genuine product regression fails on every attempt and still
-// surfaces once the retry budget is exhausted.
+// kubelet starts. Recreating the node reduces PR-gate noise without masking real regressions: a
+// genuine product regression fails on every attempt and still surfaces once the retry budget is
+// exhausted.
 //
 // The returned VMSS is the terminal one (successful attempt, or an exhausted / non-retryable
 // failure); the caller is responsible for registering its teardown.
 func createVMSSRecreatingOnOutboundCSEFlake(ctx context.Context, s *Scenario) (*ScenarioVM, error) {
+	if s.IsWindows() || config.Config.KeepVMSS {
+		return CreateVMSSWithRetry(ctx, s)
+	}
 	var vm *ScenarioVM
-	var err error
-	for attempt := 0; ; attempt++ {
-		vm, err = CreateVMSSWithRetry(ctx, s)
-		if err == nil {
-			return vm, nil
-		}
-		if attempt >= maxOutboundCSERetries || s.IsWindows() || config.Config.KeepVMSS {
-			return vm, err
-		}
+	retrier := errorclass.Retrier{
+		Phase: errorclass.PhaseCSE,
 		// The VMExtensionProvisioningError returned by the create operation does not reliably
 		// embed the CSE status JSON, so classify the failure from the extension instance view
 		// (the same source getCustomScriptExtensionStatus parses) rather than string-matching
-		// the ARM error. Only the outbound preflight exit code is treated as retryable.
-		exitCode, ok := getLinuxCSEExitCode(ctx, s)
-		if !ok || exitCode != cseExitCodeOutboundConnFail {
-			return vm, err
-		}
-		toolkit.Logf(ctx, "CSE failed with ERR_OUTBOUND_CONN_FAIL (exit %s) on VMSS %q: known transient e2e outbound flake, recreating node (attempt %d/%d)", exitCode, s.Runtime.VMSSName, attempt+1, maxOutboundCSERetries)
+		// the ARM error.
+		Inspect: func(ctx context.Context, f *errorclass.Failure) {
+			if exitCode, ok := getLinuxCSEExitCode(ctx, s); ok {
+				f.CSEExitCode = exitCode
+				s.record.setCSEExitCode(exitCode)
+			}
+		},
 		// Close this attempt's bastion tunnel before recreating: the SSH client is established
 		// even on an exit-50 failure (the node booted, only the CSE preflight failed). The single
 		// cleanup registered by the caller covers only the terminal VM, so without this the
 		// detached "az network bastion tunnel" process and SSH client would leak until test exit
 		// and could interfere with subsequent retries.
-		cleanupBastionTunnel(vm.SSHClient)
-		deleteVMSSAndWait(ctx, s)
+		BeforeRetry: func(ctx context.Context, a errorclass.Attempt) {
+			s.record.retried(a)
+			cleanupBastionTunnel(vm.SSHClient)
+			deleteVMSSAndWait(ctx, s)
+		},
+		Logf: func(format string, args ...any) {
+			toolkit.Logf(ctx, "VMSS %s: "+format, append([]any{s.Runtime.VMSSName}, args...)...)
+		},
 	}
+	_, err := retrier.Do(ctx, func(ctx context.Context) error {
+		var err error
+		vm, err = CreateVMSSWithRetry(ctx, s)
+		return err
+	})
+	return vm, err
 }
 
 // getLinuxCSEExitCode queries the VMSS instance view and returns the Linux CSE exit code
@@ -220,47 +226,96 @@ func CustomDataWithNBCCmdHack(customData, binaryURL string) (string, error) {
 	return base64.StdEncoding.EncodeToString([]byte(customData)), nil
 }
 
-func createVMSSModel(ctx context.Context, s *Scenario) (armcompute.VirtualMachineScaleSet, error) {
-	if s == nil || s.Runtime == nil || s.Runtime.Cluster == nil || s.Runtime.Cluster.Model == nil ||
-		s.Runtime.Cluster.Model.Name == nil || s.Runtime.Cluster.Model.Properties == nil ||
-		s.Runtime.Cluster.Model.Properties.NodeResourceGroup == nil || s.Runtime.Cluster.KubeletIdentity == nil ||
-		s.Runtime.Cluster.KubeletIdentity.ResourceID == nil || s.VHD == nil {
-		return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("scenario runtime is incomplete for VMSS model creation")
-	}
-	cluster := s.Runtime.Cluster
-	var nodeBootstrapping *datamodel.NodeBootstrapping
+// getNodeBootstrapping renders the CSE and custom data of the scenario runtime NBC, embedding the AKSNodeConfig when
+// the scenario has one.
+func getNodeBootstrapping(ctx context.Context, s *Scenario) (*datamodel.NodeBootstrapping, error) {
 	ab, err := agent.NewAgentBaker()
 	if err != nil {
-		return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("create AgentBaker: %w", err)
+		return nil, fmt.Errorf("create AgentBaker: %w", err)
+	}
+	if s.Runtime.NBC == nil {
+		return nil, fmt.Errorf("scenario runtime has no NBC")
 	}
-	var cse, customData, aksNodeConfig string
-
 	if s.Runtime.AKSNodeConfig != nil {
-		aksNodeConfigBytes, err := nodeconfigutils.MarshalConfigurationV1(s.Runtime.AKSNodeConfig)
-		if err != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("marshal AKS node config: %w", err)
-		}
-		aksNodeConfig = string(aksNodeConfigBytes)
-		if s.Runtime.NBC == nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("AKS node config is set without an NBC")
+		if version := vhdAKSNodeControllerVersion(s); version != "" {
+			if err := nodeconfigutils.ValidateMinANCVersion(s.Runtime.AKSNodeConfig, version); err != nil {
+				return nil, fmt.Errorf("AKS node config is not supported by the aks-node-controller of %s: %w", s.VHD, err)
+			}
 		}
-		s.Runtime.NBC.AKSNodeConfigJSON = aksNodeConfig
-	}
-
-	if s.Runtime.NBC != nil {
-		nodeBootstrapping, err = ab.GetNodeBootstrapping(ctx, s.Runtime.NBC)
+		aksNodeConfig, err := nodeconfigutils.MarshalConfigurationV1(s.Runtime.AKSNodeConfig)
 		if err != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("get node bootstrapping artifacts: %w", err)
+			return nil, fmt.Errorf("marshal AKS node config: %w", err)
 		}
+		s.Runtime.NBC.AKSNodeConfigJSON = string(aksNodeConfig)
+	}
+	nodeBootstrapping, err := ab.GetNodeBootstrapping(ctx, s.Runtime.NBC)
+	if err != nil {
+		return nil, fmt.Errorf("get node bootstrapping artifacts: %w", err)
 	}
 	if nodeBootstrapping == nil {
-		return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("node bootstrapping artifacts are nil")
+		return nil, fmt.Errorf("node bootstrapping artifacts are nil")
+	}
+	return nodeBootstrapping, nil
+}
+
+// vhdAKSNodeControllerVersion returns the version of the aks-node-controller reading the scenario AKSNodeConfig, the
+// release version of a pinned VHD. It is empty when the aks-node-controller is compiled from this tree or the VHD
+// version is not a release, e.g. a test build resolved by tag.
+func vhdAKSNodeControllerVersion(s *Scenario) string {
+	if enableScriptlessCompilation(s) {
+		return ""
+	}
+	if !vhdReleaseVersion.MatchString(s.VHD.Version) {
+		return ""
+	}
+	return s.VHD.Version
+}
+
+// vhdReleaseVersion matches the VHD release versions, e.g. 202608.20.0, which are the aks-node-controller versions.
+var vhdReleaseVersion = regexp.MustCompile(`^\d{6}\.\d{2}\.\d+$`)
+
+// validateScriptlessCustomData checks that the custom data doesn't contain any script content,
+// which indicates that the scriptless CSE is working as intended.
+func validateScriptlessCustomData(customData string) error {
+	decodedCustomData, err := base64.StdEncoding.DecodeString(customData)
+	if err != nil {
+		return fmt.Errorf("decode custom data: %w", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(decodedCustomData))
+	if err != nil {
+		return fmt.Errorf("create custom data gzip reader: %w", err)
+	}
+	result, err := io.ReadAll(reader)
+	closeErr := reader.Close()
+	if err != nil {
+		return fmt.Errorf("read gzip custom data: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("close custom data gzip reader: %w", closeErr)
+	}
+	if !strings.Contains(string(result), "/opt/azure/containers/scriptless-cse-overrides.txt") {
+		return fmt.Errorf("custom data contains other script content, but scriptless CSE CMD is enabled")
+	}
+	return nil
+}
+
+func createVMSSModel(ctx context.Context, s *Scenario) (armcompute.VirtualMachineScaleSet, error) {
+	if s == nil || s.Runtime == nil || s.Runtime.Cluster == nil || s.Runtime.Cluster.Model == nil ||
+		s.Runtime.Cluster.Model.Name == nil || s.Runtime.Cluster.Model.Properties == nil ||
+		s.Runtime.Cluster.Model.Properties.NodeResourceGroup == nil || s.Runtime.Cluster.KubeletIdentity == nil ||
+		s.Runtime.Cluster.KubeletIdentity.ResourceID == nil || s.VHD == nil {
+		return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("scenario runtime is incomplete for VMSS model creation")
+	}
+	cluster := s.Runtime.Cluster
+	nodeBootstrapping, err := getNodeBootstrapping(ctx, s)
+	if err != nil {
+		return armcompute.VirtualMachineScaleSet{}, err
 	}
 
 	scriptlessNBCCSECmdEnabled := usesScriptlessNBCCSECmd(s)
 
-	cse = nodeBootstrapping.CSE
-	customData = nodeBootstrapping.CustomData
+	cse := nodeBootstrapping.CSE
+	customData := nodeBootstrapping.CustomData
 	if enableScriptlessCompilation(s) {
 		binaryURL, err := CachedCompileAndUploadAKSNodeController(ctx, s.VHD.Arch)
 		if err != nil {
@@ -278,26 +333,8 @@ func createVMSSModel(ctx context.Context, s *Scenario) (armcompute.VirtualMachin
 		}
 	}
 	if !config.Config.DisableScriptless && !scriptlessNBCCSECmdEnabled && s.VHD.SupportsScriptless() {
-		// Validate that the custom data doesn't contain any script content,
-		// which indicates that the scriptless CSE is working as intended
-		decodedCustomData, err := base64.StdEncoding.DecodeString(customData)
-		if err != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("decode custom data: %w", err)
-		}
-		reader, err := gzip.NewReader(bytes.NewReader(decodedCustomData))
-		if err != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("create custom data gzip reader: %w", err)
-		}
-		result, err := io.ReadAll(reader)
-		closeErr := reader.Close()
-		if err != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("read gzip custom data: %w", err)
-		}
-		if closeErr != nil {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("close custom data gzip reader: %w", closeErr)
-		}
-		if !strings.Contains(string(result), "/opt/azure/containers/scriptless-cse-overrides.txt") {
-			return armcompute.VirtualMachineScaleSet{}, fmt.Errorf("custom data contains other script content, but scriptless CSE CMD is enabled")
+		if err := validateScriptlessCustomData(customData); err != nil {
+			return armcompute.VirtualMachineScaleSet{}, err
 		}
 	}
 
@@ -377,53 +414,25 @@ func CreateVMSSWithRetry(ctx context.Context, s *Scenario) (*ScenarioVM, error)
 	}
 	resourceGroupName := *s.Runtime.Cluster.Model.Properties.NodeResourceGroup
 
-	delay := 5 * time.Second
-	retryOn := func(err error) bool {
-		var respErr *azcore.ResponseError
-		// only retry on Azure API errors with specific error codes
-		if !errors.As(err, &respErr) {
-			return false
-		}
-		// AllocationFailed sometimes happens for exotic SKUs (new GPUs) with limited availability, sometimes retrying helps
-		// It's not a quota issue
-		if respErr.StatusCode == 200 && respErr.ErrorCode == "AllocationFailed" {
-			return true
-		}
-		// GalleryImageNotFound can happen transiently after image replication completes
-		// due to Azure eventual consistency - the gallery API reports success but the
-		// compute fabric in the target region hasn't fully propagated the image yet
-		if respErr.StatusCode == 404 && respErr.ErrorCode == "GalleryImageNotFound" {
-			return true
-		}
-		return false
+	var vm *ScenarioVM
+	retrier := errorclass.Retrier{
+		Phase: errorclass.PhaseVMSS,
+		BeforeRetry: func(ctx context.Context, a errorclass.Attempt) {
+			s.record.retried(a)
+		},
+		Logf: func(format string, args ...any) {
+			toolkit.Logf(ctx, format, args...)
+		},
 	}
-
-	maxAttempts := 10
-	attempt := 0
-
-	for {
-		attempt++
-		vm, err := CreateVMSS(ctx, s, resourceGroupName)
-		if err == nil {
-			return vm, nil
-		}
-
-		// not a retryable error
-		if !retryOn(err) {
-			return vm, err
-		}
-
-		if attempt >= maxAttempts {
-			return vm, fmt.Errorf("failed to create VMSS after %d retries: %w", maxAttempts, err)
-		}
-
-		toolkit.Logf(ctx, "failed to create VMSS: %v, attempt: %v, retrying in %v", err, attempt, delay)
-		select {
-		case <-ctx.Done():
-			return vm, err
-		case <-time.After(delay):
-		}
+	result, err := retrier.Do(ctx, func(ctx context.Context) error {
+		var err error
+		vm, err = CreateVMSS(ctx, s, resourceGroupName)
+		return err
+	})
+	if result.Exhausted() {
+		return vm, fmt.Errorf("failed to create VMSS after %d retries: %w", result.Attempts, err)
 	}
+	return vm, err
 }
 
 func CreateVMSS(ctx context.Context, s *Scenario, resourceGroupName string) (*ScenarioVM, error) {
@@ -669,23 +678,14 @@ func getPrivateIPFromVMSSVM(ctx context.Context, resourceGroup, vmssName, instan
 	return *ipConfig.Properties.PrivateIPAddress, nil
 }
 
-func skipTestIfSKUNotAvailableErr(t testing.TB, err error) {
-	if !config.Config.SkipTestsWithSKUCapacityIssue {
+// skipScenarioByErrorClass skips the scenario when err is classified as an error to skip, such as a SKU which isn't
+// available or an exceeded quota, and SKIP_TESTS_WITH_SKU_CAPACITY_ISSUE is set.
+func skipScenarioByErrorClass(t testing.TB, phase string, err error) {
+	if !config.Config.SkipTestsWithSKUCapacityIssue || err == nil {
 		return
 	}
-	var respErr *azcore.ResponseError
-	if !errors.As(err, &respErr) || respErr.StatusCode != 409 {
-		return
-	}
-	// sometimes the SKU is not available and we can't do anything. Skip the test in this case.
-	if respErr.ErrorCode == "SkuNotAvailable" {
-		t.Skip("skipping scenario SKU not available", t.Name(), err)
-	}
-	// sometimes the SKU quota is exceeded and we can't do anything. Skip the test in this case.
-	if respErr.ErrorCode == "OperationNotAllowed" &&
-		strings.Contains(respErr.Error(), "exceeding approved") &&
-		strings.Contains(respErr.Error(), "quota") {
-		t.Skip("skipping scenario SKU quota exceeded", t.Name(), err)
+	if c := errorclass.Default.Classify(errorclass.Failure{Phase: phase, Err: err}); c.Action == errorclass.Skip {
+		t.Skipf("skipping scenario %q: %s failed with %s error (rule %s): %v", t.Name(), phase, c.Class, c.Rule, err)
 	}
 }
 
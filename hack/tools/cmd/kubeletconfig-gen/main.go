@@ -0,0 +1,194 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Command kubeletconfig-gen writes the KubeletConfiguration type of pkg/agent/kubeletconfig from the upstream
+// k8s.io/kubelet/config/v1beta1 type, so the node binaries get every upstream field without depending on the
+// k8s.io/kubelet, apimachinery and component-base modules.
+//
+// The upstream types are copied field by field with the same JSON tags. metav1.Duration and the logging
+// TimeOrMetaDuration become the local Duration, metav1.Time becomes time.Time, resource quantities become strings and
+// the named types of the other upstream packages become their underlying type.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logsapi "k8s.io/component-base/logs/api/v1"
+	kubeletv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+const kubeletModule = "k8s.io/kubelet"
+
+//nolint:gochecknoglobals
+var (
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// localTypes are the upstream types written as a type declared by hand in pkg/agent/kubeletconfig or in the
+	// standard library.
+	localTypes = map[reflect.Type]string{
+		reflect.TypeOf(metav1.TypeMeta{}):            "TypeMeta",
+		reflect.TypeOf(metav1.Duration{}):            "Duration",
+		reflect.TypeOf(logsapi.TimeOrMetaDuration{}): "Duration",
+		reflect.TypeOf(metav1.Time{}):                "time.Time",
+		reflect.TypeOf(resource.Quantity{}):          "string",
+		reflect.TypeOf(resource.QuantityValue{}):     "string",
+	}
+)
+
+func main() {
+	out := flag.String("o", "", "output file, stdout when empty")
+	pkg := flag.String("package", "kubeletconfig", "package of the output file")
+	flag.Parse()
+
+	src, err := generate(*pkg, reflect.TypeOf(kubeletv1beta1.KubeletConfiguration{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644) //nolint:gosec // generated source file
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generator writes the declarations of the upstream types reachable from the root type, in the order they are found.
+type generator struct {
+	queue    []reflect.Type
+	names    map[string]reflect.Type
+	usesTime bool
+}
+
+func generate(pkg string, root reflect.Type) ([]byte, error) {
+	g := &generator{names: map[string]reflect.Type{}}
+	if _, err := g.typeName(root); err != nil {
+		return nil, err
+	}
+	var decls bytes.Buffer
+	for i := 0; i < len(g.queue); i++ {
+		if err := g.writeDecl(&decls, g.queue[i], i == 0); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by kubeletconfig-gen from %s %s. DO NOT EDIT.\n\n", kubeletModule, moduleVersion(kubeletModule))
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if g.usesTime {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	buf.Write(decls.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated source: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) writeDecl(buf *bytes.Buffer, t reflect.Type, root bool) error {
+	if root {
+		fmt.Fprintf(buf, "// %s is the kubelet config file, generated from %s.%s.\n", t.Name(), t.PkgPath(), t.Name())
+		buf.WriteString("// See https://kubernetes.io/docs/reference/config-api/kubelet-config.v1beta1/.\n")
+	} else {
+		fmt.Fprintf(buf, "// %s is generated from %s.%s.\n", t.Name(), t.PkgPath(), t.Name())
+	}
+	if t.Kind() != reflect.Struct {
+		fmt.Fprintf(buf, "type %s %s\n\n", t.Name(), t.Kind())
+		return nil
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", t.Name())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		typ, err := g.typeName(sf.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), sf.Name, err)
+		}
+		tag := fmt.Sprintf("`json:%q`", sf.Tag.Get("json"))
+		if sf.Anonymous {
+			fmt.Fprintf(buf, "\t%s %s\n", typ, tag)
+		} else {
+			fmt.Fprintf(buf, "\t%s %s %s\n", sf.Name, typ, tag)
+		}
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// typeName returns the local name of the upstream type, queueing the declaration of the upstream structs and of the
+// named basic types of the root package.
+func (g *generator) typeName(t reflect.Type) (string, error) {
+	if name, ok := localTypes[t]; ok {
+		if strings.HasPrefix(name, "time.") {
+			g.usesTime = true
+		}
+		return name, nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := g.typeName(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeName(t.Elem())
+		return "[]" + elem, err
+	case reflect.Map:
+		key, err := g.typeName(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeName(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Struct:
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			return "", fmt.Errorf("%s has its own JSON encoding, add it to localTypes", t)
+		}
+		return g.declare(t)
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t.Name() != t.Kind().String() && len(g.queue) > 0 && t.PkgPath() == g.queue[0].PkgPath() {
+			return g.declare(t)
+		}
+		return t.Kind().String(), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+func (g *generator) declare(t reflect.Type) (string, error) {
+	if prev, ok := g.names[t.Name()]; ok {
+		if prev != t {
+			return "", fmt.Errorf("%s and %s have the same name", prev, t)
+		}
+		return t.Name(), nil
+	}
+	g.names[t.Name()] = t
+	g.queue = append(g.queue, t)
+	return t.Name(), nil
+}
+
+func moduleVersion(path string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == path {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
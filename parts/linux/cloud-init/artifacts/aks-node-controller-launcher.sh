@@ -46,7 +46,8 @@ fi
 # today's behavior exactly. We PARSE KEY=VALUE lines rather than sourcing the file, so a malformed
 # file can never execute arbitrary shell or exit the wrapper (fail-open). The file is fully
 # controlled by the producer, so any valid identifier=value is accepted (not a fixed key list);
-# blank lines, comments, and non-identifier keys are skipped. The "|| [ -n "$_key" ]" guard
+# blank lines, comments, and non-identifier keys are skipped. Producers validate the keys against
+# pkg/agent/enabledfeatures/registry.json, and "aks-node-controller features" reports the result. The "|| [ -n "$_key" ]" guard
 # ensures the final line is still parsed even if the file has no trailing newline (read returns
 # non-zero at EOF but still populates the variables).
 if [ -f "$FEATURES_PATH" ]; then
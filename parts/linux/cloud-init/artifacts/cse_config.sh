@@ -565,6 +565,10 @@ EOF
 
   mkdir -p /etc/containerd
 
+  if [ -n "${CONTAINERD_CONFIG_VERSION_SOURCE:-}" ]; then
+    echo "containerd config generated for containerd version '${CONTAINERD_CONFIG_VERSION:-}' resolved from ${CONTAINERD_CONFIG_VERSION_SOURCE}"
+  fi
+
   if grep -q 'BinaryName = "/usr/bin/nvidia-container-runtime"' /etc/containerd/config.toml 2>/dev/null; then
     echo "NVIDIA containerd config already exists at /etc/containerd/config.toml, skipping generation"
   else
@@ -587,6 +591,11 @@ EOF
     fi
   fi
 
+  # registry mirrors from the AKSNodeConfig are written first, so the hosts.toml files managed by AKS below take precedence.
+  if [ -n "${CONTAINERD_REGISTRY_HOSTS_CONTENT:-}" ]; then
+    logs_to_events "AKS.CSE.ensureContainerd.configureContainerdRegistryMirrors" configureContainerdRegistryMirrors
+  fi
+
   export -f should_e2e_mock_azure_china_cloud
   E2EMockAzureChinaCloud=$(should_e2e_mock_azure_china_cloud)
   if [ -n "${BOOTSTRAP_PROFILE_CONTAINER_REGISTRY_SERVER}" ]; then
@@ -631,6 +640,18 @@ configureContainerdRegistryHost() {
 EOF
 }
 
+# CONTAINERD_REGISTRY_HOSTS_CONTENT is set by aks-node-controller from the registry mirrors of the AKSNodeConfig containerd overlay.
+# Once decoded, each line holds a registry host and the base64 encoded content of its hosts.toml file.
+configureContainerdRegistryMirrors() {
+  while read -r registry_host hosts_toml_content; do
+    [ -z "${registry_host}" ] && continue
+    hosts_toml_file="/etc/containerd/certs.d/${registry_host}/hosts.toml"
+    mkdir -p "$(dirname "${hosts_toml_file}")"
+    echo "${hosts_toml_content}" | base64 -d > "${hosts_toml_file}" || exit $ERR_FILE_WATCH_TIMEOUT
+    chmod 0644 "${hosts_toml_file}"
+  done < <(echo "${CONTAINERD_REGISTRY_HOSTS_CONTENT}" | base64 -d)
+}
+
 # this function craetes containerd host config to map mcr.azk8s.cn host to mcr.azure.cn
 # containerd will resolve mcr.azk8s.cn as mcr.azure.cn and pull the image. If failed, it will fallback to mcr.azk8s.cn
 # https://github.com/containerd/containerd/blob/main/docs/hosts.md#registry-configuration---examples
@@ -918,6 +939,39 @@ validateKubeletNodeLabels() {
     KUBELET_NODE_LABELS="$validated_labels"
 }
 
+# configureMSIKubeconfig installs the kubeconfig rendered by aks-node-controller for the arc_msi and azure_msi bootstrapping
+# auth methods. Its exec credential plugin gets the managed identity token, so there is no secret to validate.
+# With the use_bootstrapping_auth cluster join method the kubelet keeps using the managed identity instead of a client certificate.
+configureMSIKubeconfig() {
+    if [ -z "${BOOTSTRAPPING_KUBECONFIG_CONTENT:-}" ]; then
+        echo "BOOTSTRAPPING_KUBECONFIG_CONTENT must be set when using the ${BOOTSTRAPPING_AUTH_METHOD} bootstrapping auth method"
+        exit $ERR_KUBELET_START_FAIL
+    fi
+
+    MSI_KUBECONFIG_FILE=/var/lib/kubelet/bootstrap-kubeconfig
+    KUBELET_TLS_BOOTSTRAP_FLAGS="--kubeconfig /var/lib/kubelet/kubeconfig --bootstrap-kubeconfig /var/lib/kubelet/bootstrap-kubeconfig"
+    if [ "${CLUSTER_JOIN_METHOD:-}" = "use_bootstrapping_auth" ]; then
+        echo "using ${BOOTSTRAPPING_AUTH_METHOD} to generate a kubeconfig"
+        MSI_KUBECONFIG_FILE=/var/lib/kubelet/kubeconfig
+        KUBELET_TLS_BOOTSTRAP_FLAGS="--kubeconfig /var/lib/kubelet/kubeconfig"
+    else
+        echo "using ${BOOTSTRAPPING_AUTH_METHOD} to generate a bootstrap-kubeconfig"
+    fi
+
+    KUBELET_TLS_DROP_IN="/etc/systemd/system/kubelet.service.d/10-tlsbootstrap.conf"
+    mkdir -p "$(dirname "${KUBELET_TLS_DROP_IN}")"
+    touch "${KUBELET_TLS_DROP_IN}"
+    chmod 0600 "${KUBELET_TLS_DROP_IN}"
+    tee "${KUBELET_TLS_DROP_IN}" > /dev/null <<EOF
+[Service]
+Environment="KUBELET_TLS_BOOTSTRAP_FLAGS=${KUBELET_TLS_BOOTSTRAP_FLAGS}"
+EOF
+    mkdir -p "$(dirname "${MSI_KUBECONFIG_FILE}")"
+    touch "${MSI_KUBECONFIG_FILE}"
+    chmod 0644 "${MSI_KUBECONFIG_FILE}"
+    echo "${BOOTSTRAPPING_KUBECONFIG_CONTENT}" | base64 -d > "${MSI_KUBECONFIG_FILE}"
+}
+
 ensureKubelet() {
     KUBELET_DEFAULT_FILE=/etc/default/kubelet
     mkdir -p /etc/default
@@ -957,7 +1011,9 @@ EOF
     # to ensure we don't expose bootstrap token secrets in provisioning logs
     set +x
 
-    if [ -n "${TLS_BOOTSTRAP_TOKEN:-}" ]; then
+    if [ "${BOOTSTRAPPING_AUTH_METHOD:-}" = "arc_msi" ] || [ "${BOOTSTRAPPING_AUTH_METHOD:-}" = "azure_msi" ]; then
+        configureMSIKubeconfig
+    elif [ -n "${TLS_BOOTSTRAP_TOKEN:-}" ]; then
         echo "using bootstrap token to generate a bootstrap-kubeconfig"
 
         CREDENTIAL_VALIDATION_DROP_IN="/etc/systemd/system/kubelet.service.d/10-credential-validation.conf"
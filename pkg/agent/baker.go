@@ -10,16 +10,20 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
-	"regexp"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/Azure/agentbaker/parts"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	"github.com/Azure/agentbaker/pkg/agent/enabledfeatures"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletconfig"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
+	"github.com/Azure/agentbaker/pkg/agent/localdns"
+	"github.com/Azure/agentbaker/pkg/agent/sysctlpolicy"
 	"github.com/Azure/go-autorest/autorest/to"
 	base0_5 "github.com/coreos/butane/base/v0_5"
 	butanecommon "github.com/coreos/butane/config/common"
@@ -186,7 +190,7 @@ func (t *TemplateGenerator) getScriptlessBoothook(config *datamodel.NodeBootstra
 	// contents are read by the aks-node-controller wrapper (FEATURES_PATH). Empty content =>
 	// skipped by buildScriptlessCustomData, keeping custom data byte-identical when no toggle is set.
 	var encodedEnabledFeatures string
-	if content := renderEnabledFeatures(config.EnabledFeatures); content != "" {
+	if content := enabledfeatures.Render(config.EnabledFeatures); content != "" {
 		encodedEnabledFeatures = getBase64EncodedGzippedCustomScriptFromStr(content)
 	}
 
@@ -260,39 +264,6 @@ func supportsScriptlessPhase2(config *datamodel.NodeBootstrappingConfiguration)
 	return config.EnableScriptlessNBCCSECmd && !config.PreProvisionOnly
 }
 
-// renderEnabledFeatures serializes the feature toggle map into sorted KEY=VALUE lines for
-// enabled_features.sh. Keys are sorted so the output is deterministic (Go map iteration is
-// randomized) and filtered to valid shell identifiers - the same set the aks-node-controller
-// wrapper accepts. Entries whose value contains a newline or carriage return are dropped so a
-// single map entry can never expand into multiple lines (preserving the one-KEY=VALUE-per-line
-// contract). Returns "" when no valid entry remains so custom data stays byte-identical to today.
-func renderEnabledFeatures(features map[string]string) string {
-	keys := make([]string, 0, len(features))
-	for k, v := range features {
-		if isValidFeatureKey(k) && !strings.ContainsAny(v, "\n\r") {
-			keys = append(keys, k)
-		}
-	}
-	if len(keys) == 0 {
-		return ""
-	}
-	sort.Strings(keys)
-	var b strings.Builder
-	for _, k := range keys {
-		fmt.Fprintf(&b, "%s=%s\n", k, features[k])
-	}
-	return b.String()
-}
-
-// featureKeyRe matches a valid shell identifier ([a-zA-Z_][a-zA-Z0-9_]*) - the same set the
-// aks-node-controller wrapper parses out of enabled_features.sh.
-var featureKeyRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
-
-// isValidFeatureKey reports whether k is a valid shell identifier the wrapper would accept.
-func isValidFeatureKey(k string) bool {
-	return featureKeyRe.MatchString(k)
-}
-
 func buildScriptlessCustomData(cloudInitTemplate, fileListTemplate, separator string, encodedFiles []encodedFile) string {
 	var fileList []string
 	for _, f := range encodedFiles {
@@ -729,7 +700,15 @@ func ValidateAndSetLinuxNodeBootstrappingConfiguration(config *datamodel.NodeBoo
 
 // ValidateAndSetLinuxNodeBootstrappingConfigurationWithError validates and updates Linux node bootstrapping configuration.
 func ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config *datamodel.NodeBootstrappingConfiguration) error {
-	if err := validateCustomLinuxOSConfig(config.AgentPoolProfile.GetCustomLinuxOSConfig()); err != nil {
+	if err := validateCustomLinuxOSConfig(config.AgentPoolProfile, config.AgentPoolProfile.GetCustomLinuxOSConfig()); err != nil {
+		return err
+	}
+
+	if err := validateLocalDNSProfile(config.AgentPoolProfile); err != nil {
+		return err
+	}
+
+	if err := enabledfeatures.Embedded().Validate(config.EnabledFeatures); err != nil {
 		return err
 	}
 
@@ -790,19 +769,29 @@ func ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config *datamode
 		kubeletFlags["--feature-gates"] = addFeatureGateString(kubeletFlags["--feature-gates"], "DisableAcceleratorUsageMetrics", false)
 	}
 
-	// streamingConnectionIdleTimeout was removed from KubeletConfiguration in k8s 1.34+.
-	// It must not appear on the command line or in the config file for those versions.
-	if IsKubernetesVersionGe(config.ContainerService.Properties.OrchestratorProfile.OrchestratorVersion, "1.34.0") {
-		delete(kubeletFlags, "--streaming-connection-idle-timeout")
+	// Flags removed by the target Kubernetes version must not appear on the command line or in the config file,
+	// flags the kubelet would not start with are rejected.
+	kubeletConfigFileEnabled := config.AgentPoolProfile != nil &&
+		IsKubeletConfigFileEnabled(config.ContainerService, config.AgentPoolProfile, config.EnableKubeletConfigFile)
+	k8sVersion := config.ContainerService.Properties.OrchestratorProfile.OrchestratorVersion
+	if _, err := kubeletpolicy.Embedded().Apply(k8sVersion, kubeletFlags, kubeletConfigFileEnabled); err != nil {
+		return err
+	}
+	if kubeletConfigFileEnabled {
+		return kubeletconfig.Validate(getKubeletConfiguration(kubeletFlags, config.AgentPoolProfile.CustomKubeletConfig, k8sVersion), k8sVersion)
 	}
 	return nil
 }
 
-func validateCustomLinuxOSConfig(config *datamodel.CustomLinuxOSConfig) error {
+func validateCustomLinuxOSConfig(profile *datamodel.AgentPoolProfile, config *datamodel.CustomLinuxOSConfig) error {
 	if config == nil {
 		return nil
 	}
 
+	if _, err := evaluateSysctlPolicy(profile, config); err != nil {
+		return err
+	}
+
 	if err := validateTransparentHugePageConfigValue(
 		"transparentHugePageEnabled",
 		config.TransparentHugePageEnabled,
@@ -818,6 +807,51 @@ func validateCustomLinuxOSConfig(config *datamodel.CustomLinuxOSConfig) error {
 	)
 }
 
+// evaluateSysctlPolicy checks the customer sysctls and containerd ulimits against the sysctl policy and returns
+// the values to render on the nodes of the agent pool, including the defaults AgentBaker sets on every node.
+func evaluateSysctlPolicy(profile *datamodel.AgentPoolProfile, config *datamodel.CustomLinuxOSConfig) (*sysctlpolicy.Result, error) {
+	policy := sysctlpolicy.Embedded()
+	sysctls := map[string]string{}
+	if config != nil && config.Sysctls != nil {
+		sysctlConfig, err := json.Marshal(config.Sysctls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal sysctl config: %w", err)
+		}
+		if sysctls, err = policy.SysctlValues(sysctlConfig); err != nil {
+			return nil, err
+		}
+	}
+	ulimits := map[string]string{}
+	if u := config.GetUlimitConfig(); u != nil {
+		if u.MaxLockedMemory != "" {
+			ulimits["LimitMEMLOCK"] = u.MaxLockedMemory
+		}
+		if u.NoFile != "" {
+			ulimits["LimitNOFILE"] = u.NoFile
+		}
+	}
+	var target sysctlpolicy.Target
+	if capabilities, ok := profile.Distro.Capabilities(); ok {
+		target = sysctlpolicy.Target{
+			OSFamily:               string(capabilities.OSFamily),
+			ContainerdMajorVersion: capabilities.ContainerdMajorVersion,
+		}
+	}
+	return policy.Evaluate(target, sysctls, ulimits)
+}
+
+// validateLocalDNSProfile rejects localdns overrides and critical FQDNs the localdns Corefile cannot express.
+func validateLocalDNSProfile(profile *datamodel.AgentPoolProfile) error {
+	if !profile.ShouldEnableLocalDNS() {
+		return nil
+	}
+	corefile, err := newLocalDNSCorefile(profile.GetLocalDNSCoreFileData(), profile.ShouldEnableHostsPlugin())
+	if err != nil {
+		return err
+	}
+	return localdns.Validate(corefile, profile.LocalDNSProfile.CriticalFQDNs)
+}
+
 func validateTransparentHugePageConfigValue(fieldName, value string, allowedValues []string) error {
 	if value == "" {
 		return nil
@@ -830,7 +864,7 @@ func validateTransparentHugePageConfigValue(fieldName, value string, allowedValu
 	return fmt.Errorf("customLinuxOSConfig.%s value %q is invalid; allowed values are: %s", fieldName, value, strings.Join(allowedValues, ", "))
 }
 
-func validateAndSetWindowsNodeBootstrappingConfiguration(config *datamodel.NodeBootstrappingConfiguration) {
+func validateAndSetWindowsNodeBootstrappingConfiguration(config *datamodel.NodeBootstrappingConfiguration) error {
 	if IsTLSBootstrappingEnabledWithHardCodedToken(config.KubeletClientTLSBootstrapToken) {
 		// backfill proper flags for Windows agent node TLS bootstrapping
 		if config.KubeletConfig == nil {
@@ -854,11 +888,13 @@ func validateAndSetWindowsNodeBootstrappingConfiguration(config *datamodel.NodeB
 			kubeletFlags["--feature-gates"] = addFeatureGateString(kubeletFlags["--feature-gates"], "RotateKubeletServerCertificate", true)
 		}
 
-		// streamingConnectionIdleTimeout was removed from KubeletConfiguration in k8s 1.34+.
-		if IsKubernetesVersionGe(config.ContainerService.Properties.OrchestratorProfile.OrchestratorVersion, "1.34.0") {
-			delete(kubeletFlags, "--streaming-connection-idle-timeout")
+		// Flags removed by the target Kubernetes version must not appear on the command line,
+		// flags the kubelet would not start with are rejected.
+		if _, err := kubeletpolicy.Embedded().Apply(config.ContainerService.Properties.OrchestratorProfile.OrchestratorVersion, kubeletFlags, false); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 // getContainerServiceFuncMap returns all functions used in template generation.
@@ -900,10 +936,10 @@ func getContainerServiceFuncMap(config *datamodel.NodeBootstrappingConfiguration
 			return config.MigStrategy
 		},
 		"GetKubeletConfigFileContent": func() string {
-			return GetKubeletConfigFileContent(config.KubeletConfig, profile.CustomKubeletConfig)
+			return GetKubeletConfigFileContent(config.KubeletConfig, profile.CustomKubeletConfig, cs.Properties.OrchestratorProfile.OrchestratorVersion)
 		},
 		"GetKubeletConfigFileContentBase64": func() string {
-			return base64.StdEncoding.EncodeToString([]byte(GetKubeletConfigFileContent(config.KubeletConfig, profile.CustomKubeletConfig)))
+			return base64.StdEncoding.EncodeToString([]byte(GetKubeletConfigFileContent(config.KubeletConfig, profile.CustomKubeletConfig, cs.Properties.OrchestratorProfile.OrchestratorVersion)))
 		},
 		"IsKubeletConfigFileEnabled": func() bool {
 			return IsKubeletConfigFileEnabled(cs, profile, config.EnableKubeletConfigFile)
@@ -954,8 +990,7 @@ func getContainerServiceFuncMap(config *datamodel.NodeBootstrappingConfiguration
 			return config.GetOrderedKubeproxyConfigStringForPowershell()
 		},
 		"IsCgroupV2": func() bool {
-			return profile.Is2204VHDDistro() || profile.Is2404VHDDistro() || profile.Is2604VHDDistro() ||
-				config.IsAzureLinux() || config.IsFlatcar() || config.IsACL()
+			return isCgroupV2(config)
 		},
 		"GetKubeProxyFeatureGatesPsh": func() string {
 			return cs.Properties.GetKubeProxyFeatureGatesWindowsArguments()
@@ -1000,24 +1035,22 @@ func getContainerServiceFuncMap(config *datamodel.NodeBootstrappingConfiguration
 		"ShouldConfigContainerdUlimits": func() bool {
 			return profile.GetCustomLinuxOSConfig().GetUlimitConfig() != nil
 		},
-		"GetContainerdUlimitString": func() string {
-			ulimitConfig := profile.GetCustomLinuxOSConfig().GetUlimitConfig()
-			if ulimitConfig == nil {
-				return ""
+		"GetContainerdUlimitString": func() (string, error) {
+			if profile.GetCustomLinuxOSConfig().GetUlimitConfig() == nil {
+				return "", nil
+			}
+			result, err := evaluateSysctlPolicy(profile, profile.GetCustomLinuxOSConfig())
+			if err != nil {
+				return "", err
 			}
 			var sb strings.Builder
 			sb.WriteString("[Service]\n")
-			if ulimitConfig.MaxLockedMemory != "" {
-				sb.WriteString(fmt.Sprintf("LimitMEMLOCK=%s\n", ulimitConfig.MaxLockedMemory))
+			// LimitNOFILE is skipped by the sysctl policy on Ubuntu with containerd 2.0+, which removed it
+			// https://github.com/containerd/containerd/blob/main/docs/containerd-2.0.md#limitnofile-configuration-has-been-removed
+			for _, line := range result.UlimitLines() {
+				sb.WriteString(line + "\n")
 			}
-			if ulimitConfig.NoFile != "" {
-				// ulimit is removed in containerd 2.0+, which is available only in ubuntu2404/ubuntu2604 distros
-				// https://github.com/containerd/containerd/blob/main/docs/containerd-2.0.md#limitnofile-configuration-has-been-removed
-				if !profile.Is2404VHDDistro() && !profile.Is2604VHDDistro() {
-					sb.WriteString(fmt.Sprintf("LimitNOFILE=%s\n", ulimitConfig.NoFile))
-				}
-			}
-			return sb.String()
+			return sb.String(), nil
 		},
 		"IsKubernetes": func() bool {
 			return cs.Properties.OrchestratorProfile.IsKubernetes()
@@ -1221,24 +1254,14 @@ func getContainerServiceFuncMap(config *datamodel.NodeBootstrappingConfiguration
 			return base64.StdEncoding.EncodeToString([]byte(kubenetCniTemplate))
 		},
 		"GetContainerdConfigContent": func() string {
-			output, err := containerdConfigFromTemplate(config, profile, func(profile *datamodel.AgentPoolProfile) ContainerdConfigTemplate {
-				if profile.IsContainerdV2Distro() {
-					return containerdV2ConfigTemplate
-				}
-				return containerdV1ConfigTemplate
-			}(profile))
+			output, err := containerdConfigContent(config, false)
 			if err != nil {
 				panic(err)
 			}
 			return output
 		},
 		"GetContainerdConfigNoGPUContent": func() string {
-			output, err := containerdConfigFromTemplate(config, profile, func(profile *datamodel.AgentPoolProfile) ContainerdConfigTemplate {
-				if profile.IsContainerdV2Distro() {
-					return containerdV2NoGPUConfigTemplate
-				}
-				return containerdV1NoGPUConfigTemplate
-			}(profile))
+			output, err := containerdConfigContent(config, true)
 			if err != nil {
 				panic(err)
 			}
@@ -1526,18 +1549,11 @@ func getContainerServiceFuncMap(config *datamodel.NodeBootstrappingConfiguration
 			return config.SSHStatus == datamodel.EntraIDSSH
 		},
 		"GetSysctlContent": func() (string, error) {
-			templateFuncMap := make(template.FuncMap)
-			templateFuncMap["getPortRangeEndValue"] = getPortRangeEndValue
-			sysctlTemplate, err := template.New("sysctl").Funcs(templateFuncMap).Parse(sysctlTemplateString)
+			result, err := evaluateSysctlPolicy(profile, profile.GetCustomLinuxOSConfig())
 			if err != nil {
-				return "", fmt.Errorf("failed to parse sysctl template: %w", err)
-			}
-
-			var b bytes.Buffer
-			if err = sysctlTemplate.Execute(&b, profile); err != nil {
-				return "", fmt.Errorf("failed to execute sysctl template: %w", err)
+				return "", err
 			}
-			return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+			return base64.StdEncoding.EncodeToString([]byte(result.SysctlContent())), nil
 		},
 		"ShouldEnableCustomData": func() bool {
 			return !config.DisableCustomData && !config.IsFlatcar() && !config.IsACL()
@@ -1685,15 +1701,6 @@ func GetKubernetesEndpoint(cs *datamodel.ContainerService) string {
 	return cs.Properties.HostedMasterProfile.FQDN
 }
 
-func getPortRangeEndValue(portRange string) int {
-	arr := strings.Split(portRange, " ")
-	num, err := strconv.Atoi(arr[1])
-	if err != nil {
-		return -1
-	}
-	return num
-}
-
 // NV series GPUs target graphics workloads vs NC which targets compute.
 // they typically use GRID, not CUDA drivers, and will fail to install CUDA drivers.
 // NVv1 seems to run with CUDA, NVv5 requires GRID.
@@ -1767,133 +1774,6 @@ func isMariner(osSku string) bool {
 	return osSku == datamodel.OSSKUCBLMariner || osSku == datamodel.OSSKUMariner || osSku == datamodel.OSSKUAzureLinux
 }
 
-const sysctlTemplateString = `# This is a partial workaround to this upstream Kubernetes issue:
-# https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
-net.ipv4.tcp_retries2=8
-net.core.message_burst=80
-net.core.message_cost=40
-{{- if .CustomLinuxOSConfig}}
-{{- if .CustomLinuxOSConfig.Sysctls}}
-{{- if .CustomLinuxOSConfig.Sysctls.NetCoreSomaxconn}}
-net.core.somaxconn={{.CustomLinuxOSConfig.Sysctls.NetCoreSomaxconn}}
-{{- else}}
-net.core.somaxconn=16384
-{{- end}}
-{{- if .CustomLinuxOSConfig.Sysctls.NetIpv4TcpMaxSynBacklog}}
-net.ipv4.tcp_max_syn_backlog={{.CustomLinuxOSConfig.Sysctls.NetIpv4TcpMaxSynBacklog}}
-{{- else}}
-net.ipv4.tcp_max_syn_backlog=16384
-{{- end}}
-{{- if .CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh1}}
-net.ipv4.neigh.default.gc_thresh1={{.CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh1}}
-{{- else}}
-net.ipv4.neigh.default.gc_thresh1=4096
-{{- end}}
-{{- if .CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh2}}
-net.ipv4.neigh.default.gc_thresh2={{.CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh2}}
-{{- else}}
-net.ipv4.neigh.default.gc_thresh2=8192
-{{- end}}
-{{- if .CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh3}}
-net.ipv4.neigh.default.gc_thresh3={{.CustomLinuxOSConfig.Sysctls.NetIpv4NeighDefaultGcThresh3}}
-{{- else}}
-net.ipv4.neigh.default.gc_thresh3=16384
-{{- end}}
-{{- else}}
-net.core.somaxconn=16384
-net.ipv4.tcp_max_syn_backlog=16384
-net.ipv4.neigh.default.gc_thresh1=4096
-net.ipv4.neigh.default.gc_thresh2=8192
-net.ipv4.neigh.default.gc_thresh3=16384
-{{- end}}
-{{- else}}
-net.core.somaxconn=16384
-net.ipv4.tcp_max_syn_backlog=16384
-net.ipv4.neigh.default.gc_thresh1=4096
-net.ipv4.neigh.default.gc_thresh2=8192
-net.ipv4.neigh.default.gc_thresh3=16384
-{{- end}}
-{{- if .CustomLinuxOSConfig}}
-{{- if .CustomLinuxOSConfig.Sysctls}}
-# The following are sysctl configs passed from API
-{{- $s:=.CustomLinuxOSConfig.Sysctls}}
-{{- if $s.NetCoreNetdevMaxBacklog}}
-net.core.netdev_max_backlog={{$s.NetCoreNetdevMaxBacklog}}
-{{- end}}
-{{- if $s.NetCoreRmemDefault}}
-net.core.rmem_default={{$s.NetCoreRmemDefault}}
-{{- end}}
-{{- if $s.NetCoreRmemMax}}
-net.core.rmem_max={{$s.NetCoreRmemMax}}
-{{- end}}
-{{- if $s.NetCoreWmemDefault}}
-net.core.wmem_default={{$s.NetCoreWmemDefault}}
-{{- end}}
-{{- if $s.NetCoreWmemMax}}
-net.core.wmem_max={{$s.NetCoreWmemMax}}
-{{- end}}
-{{- if $s.NetCoreOptmemMax}}
-net.core.optmem_max={{$s.NetCoreOptmemMax}}
-{{- end}}
-{{- if $s.NetIpv4TcpMaxTwBuckets}}
-net.ipv4.tcp_max_tw_buckets={{$s.NetIpv4TcpMaxTwBuckets}}
-{{- end}}
-{{- if $s.NetIpv4TcpFinTimeout}}
-net.ipv4.tcp_fin_timeout={{$s.NetIpv4TcpFinTimeout}}
-{{- end}}
-{{- if $s.NetIpv4TcpKeepaliveTime}}
-net.ipv4.tcp_keepalive_time={{$s.NetIpv4TcpKeepaliveTime}}
-{{- end}}
-{{- if $s.NetIpv4TcpKeepaliveProbes}}
-net.ipv4.tcp_keepalive_probes={{$s.NetIpv4TcpKeepaliveProbes}}
-{{- end}}
-{{- if $s.NetIpv4TcpkeepaliveIntvl}}
-net.ipv4.tcp_keepalive_intvl={{$s.NetIpv4TcpkeepaliveIntvl}}
-{{- end}}
-{{- if $s.NetIpv4TcpTwReuse}}
-net.ipv4.tcp_tw_reuse={{if $s.NetIpv4TcpTwReuse}}1{{else}}0{{end}}
-{{- end}}
-{{- if $s.NetIpv4IpLocalPortRange}}
-net.ipv4.ip_local_port_range={{$s.NetIpv4IpLocalPortRange}}
-{{$rangeEnd := getPortRangeEndValue $s.NetIpv4IpLocalPortRange}}
-{{ if ge $rangeEnd 65330}}
-net.ipv4.ip_local_reserved_ports=65330
-{{- end}}
-{{- end}}
-{{- if $s.NetNetfilterNfConntrackMax}}
-net.netfilter.nf_conntrack_max={{$s.NetNetfilterNfConntrackMax}}
-{{- end}}
-{{- if $s.NetNetfilterNfConntrackBuckets}}
-net.netfilter.nf_conntrack_buckets={{$s.NetNetfilterNfConntrackBuckets}}
-{{- end}}
-{{- if $s.FsInotifyMaxUserWatches}}
-fs.inotify.max_user_watches={{$s.FsInotifyMaxUserWatches}}
-{{- end}}
-{{- if $s.FsFileMax}}
-fs.file-max={{$s.FsFileMax}}
-{{- end}}
-{{- if $s.FsAioMaxNr}}
-fs.aio-max-nr={{$s.FsAioMaxNr}}
-{{- end}}
-{{- if $s.FsNrOpen}}
-fs.nr_open={{$s.FsNrOpen}}
-{{- end}}
-{{- if $s.KernelThreadsMax}}
-kernel.threads-max={{$s.KernelThreadsMax}}
-{{- end}}
-{{- if $s.VMMaxMapCount}}
-vm.max_map_count={{$s.VMMaxMapCount}}
-{{- end}}
-{{- if $s.VMSwappiness}}
-vm.swappiness={{$s.VMSwappiness}}
-{{- end}}
-{{- if $s.VMVfsCachePressure}}
-vm.vfs_cache_pressure={{$s.VMVfsCachePressure}}
-{{- end}}
-{{- end}}
-{{- end}}
-`
-
 const kubenetCniTemplate = `{
 	"cniVersion": "0.3.1",
 	"name": "kubenet",
@@ -1920,594 +1800,79 @@ const kubenetCniTemplate = `{
 }
 `
 
-type ContainerdConfigTemplate string
-
-// this pains me, but to make it respect mutability of vmss tags,
-// we cannot use go templates at runtime.
-// CSE needs to be able to generate the full config, with all params,
-// with the tags pulled from wireserver. this is a hack to avoid
-// moving all the go templates to CSE -- we allow two options,
-// duplicate them in CSE base64-encoded, and pick the right one.
-// they're identical except for GPU runtime class.
-const (
-	containerdV1ConfigTemplate ContainerdConfigTemplate = `version = 2
-oom_score = -999{{if HasDataDir }}
-root = "{{GetDataDir}}"{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.snapshotter.v1.erofs"]
-  default_size = "10G"
-  enable_fsverity = false
-  ovl_mount_options = []
-
-[plugins."io.containerd.service.v1.diff-service"]
-  default = ["erofs", "walking"]
-
-[plugins."io.containerd.differ.v1.erofs"]
-  mkfs_options = ["-T0", "--mkfs-time", "--sort=none"]
-  enable_tar_index = false
-{{- end}}
-[plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = "{{GetPodInfraContainerSpec}}"
-  enable_cdi = true
-  [plugins."io.containerd.grpc.v1.cri".containerd]
-    {{- if IsKata }}
-    disable_snapshot_annotations = false
-    snapshotter = "overlayfs"
-    {{- end}}
-    {{- if IsArtifactStreamingEnabled }}
-    snapshotter = "overlaybd"
-    disable_snapshot_annotations = false
-    {{- end}}
-    {{- if IsNSeriesSKU }}
-    default_runtime_name = "nvidia-container-runtime"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
-      {{- if IsCgroupV2 }}
-      SystemdCgroup = true
-      {{- end}}
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
-    {{- else}}
-    default_runtime_name = "runc"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      {{- if IsCgroupV2 }}
-      SystemdCgroup = true
-      {{- end}}
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
-    {{- end}}
-  {{- if and (IsKubenet) (not HasCalicoNetworkPolicy) }}
-  [plugins."io.containerd.grpc.v1.cri".cni]
-    bin_dir = "/opt/cni/bin"
-    conf_dir = "/etc/cni/net.d"
-    conf_template = "/etc/containerd/kubenet_template.conf"
-  {{- end}}
-  {{- if IsKubernetesVersionGe "1.22.0"}}
-  [plugins."io.containerd.grpc.v1.cri".registry]
-    config_path = "/etc/containerd/certs.d"
-  {{- end}}
-  [plugins."io.containerd.grpc.v1.cri".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
-[metrics]
-  address = "0.0.0.0:10257"
-{{- if IsArtifactStreamingEnabled }}
-[proxy_plugins]
-  [proxy_plugins.overlaybd]
-    type = "snapshot"
-    address = "/run/overlaybd-snapshotter/overlaybd.sock"
-{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "overlayfs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration.toml"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "erofs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
-[proxy_plugins]
-  [proxy_plugins.tardev]
-    type = "snapshot"
-    address = "/run/containerd/tardev-snapshotter.sock"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc]
-  snapshotter = "tardev"
-  runtime_type = "io.containerd.kata-cc.v2"
-  privileged_without_host_devices = true
-  pod_annotations = ["io.katacontainers.*"]
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc.options]
-    ConfigPath = "/opt/confidential-containers/share/defaults/kata-containers/configuration-clh-snp.toml"
-{{- end}}
-`
-	containerdV2ConfigTemplate ContainerdConfigTemplate = `version = 2
-oom_score = -999{{if HasDataDir }}
-root = "{{GetDataDir}}"{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.snapshotter.v1.erofs"]
-  default_size = "10G"
-  enable_fsverity = false
-  ovl_mount_options = []
-
-[plugins."io.containerd.service.v1.diff-service"]
-  default = ["erofs", "walking"]
-
-[plugins."io.containerd.differ.v1.erofs"]
-  mkfs_options = ["-T0", "--mkfs-time", "--sort=none"]
-  enable_tar_index = false
-{{- end}}
-[plugins."io.containerd.cri.v1.images"]
-{{- if IsArtifactStreamingEnabled }}
-  snapshotter = "overlaybd"
-  disable_snapshot_annotations = false
-{{- end}}
-  [plugins."io.containerd.cri.v1.images".pinned_images]
-    sandbox = "{{GetPodInfraContainerSpec}}"
-  {{- if IsKubernetesVersionGe "1.22.0"}}
-  [plugins."io.containerd.cri.v1.images".registry]
-    config_path = "/etc/containerd/certs.d"
-  {{- end}}
-  [plugins."io.containerd.cri.v1.images".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
-[plugins."io.containerd.cri.v1.runtime".containerd]
-    {{- if IsNSeriesSKU }}
-    default_runtime_name = "nvidia-container-runtime"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia-container-runtime]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.nvidia-container-runtime.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
-      SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/nvidia-container-runtime"
-    {{- else}}
-    default_runtime_name = "runc"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
-    {{- end}}
-  {{- if and (IsKubenet) (not HasCalicoNetworkPolicy) }}
-  [plugins."io.containerd.cri.v1.runtime".cni]
-    bin_dir = "/opt/cni/bin"
-    conf_dir = "/etc/cni/net.d"
-    conf_template = "/etc/containerd/kubenet_template.conf"
-  {{- end}}
-[metrics]
-  address = "0.0.0.0:10257"
-{{- if IsArtifactStreamingEnabled }}
-[proxy_plugins]
-  [proxy_plugins.overlaybd]
-    type = "snapshot"
-    address = "/run/overlaybd-snapshotter/overlaybd.sock"
-{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "overlayfs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration.toml"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "erofs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
-[proxy_plugins]
-  [proxy_plugins.tardev]
-    type = "snapshot"
-    address = "/run/containerd/tardev-snapshotter.sock"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc]
-  snapshotter = "tardev"
-  runtime_type = "io.containerd.kata-cc.v2"
-  privileged_without_host_devices = true
-  pod_annotations = ["io.katacontainers.*"]
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc.options]
-    ConfigPath = "/opt/confidential-containers/share/defaults/kata-containers/configuration-clh-snp.toml"
-{{- end}}
-`
-	containerdV2NoGPUConfigTemplate ContainerdConfigTemplate = `version = 2
-oom_score = -999{{if HasDataDir }}
-root = "{{GetDataDir}}"{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.snapshotter.v1.erofs"]
-  default_size = "10G"
-  enable_fsverity = false
-  ovl_mount_options = []
-
-[plugins."io.containerd.service.v1.diff-service"]
-  default = ["erofs", "walking"]
-
-[plugins."io.containerd.differ.v1.erofs"]
-  mkfs_options = ["-T0", "--mkfs-time", "--sort=none"]
-  enable_tar_index = false
-{{- end}}
-[plugins."io.containerd.cri.v1.images"]
-{{- if IsArtifactStreamingEnabled }}
-  snapshotter = "overlaybd"
-  disable_snapshot_annotations = false
-{{- end}}
-  [plugins."io.containerd.cri.v1.images".pinned_images]
-    sandbox = "{{GetPodInfraContainerSpec}}"
-  {{- if IsKubernetesVersionGe "1.22.0"}}
-  [plugins."io.containerd.cri.v1.images".registry]
-    config_path = "/etc/containerd/certs.d"
-  {{- end}}
-  [plugins."io.containerd.cri.v1.images".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
-[plugins."io.containerd.cri.v1.runtime".containerd]
-    default_runtime_name = "runc"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      SystemdCgroup = true
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.cri.v1.runtime".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
-  {{- if and (IsKubenet) (not HasCalicoNetworkPolicy) }}
-  [plugins."io.containerd.cri.v1.runtime".cni]
-    bin_dir = "/opt/cni/bin"
-    conf_dir = "/etc/cni/net.d"
-    conf_template = "/etc/containerd/kubenet_template.conf"
-  {{- end}}
-[metrics]
-  address = "0.0.0.0:10257"
-{{- if IsArtifactStreamingEnabled }}
-[proxy_plugins]
-  [proxy_plugins.overlaybd]
-    type = "snapshot"
-    address = "/run/overlaybd-snapshotter/overlaybd.sock"
-{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "overlayfs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration.toml"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "erofs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
-[proxy_plugins]
-  [proxy_plugins.tardev]
-    type = "snapshot"
-    address = "/run/containerd/tardev-snapshotter.sock"
-{{- end}}
-`
-	containerdV1NoGPUConfigTemplate ContainerdConfigTemplate = `version = 2
-oom_score = -999{{if HasDataDir }}
-root = "{{GetDataDir}}"{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.snapshotter.v1.erofs"]
-  default_size = "10G"
-  enable_fsverity = false
-  ovl_mount_options = []
-
-[plugins."io.containerd.service.v1.diff-service"]
-  default = ["erofs", "walking"]
-
-[plugins."io.containerd.differ.v1.erofs"]
-  mkfs_options = ["-T0", "--mkfs-time", "--sort=none"]
-  enable_tar_index = false
-{{- end}}
-[plugins."io.containerd.grpc.v1.cri"]
-  sandbox_image = "{{GetPodInfraContainerSpec}}"
-  [plugins."io.containerd.grpc.v1.cri".containerd]
-    {{- if IsKata }}
-    disable_snapshot_annotations = false
-    snapshotter = "overlayfs"
-    {{- end}}
-    {{- if IsArtifactStreamingEnabled }}
-    snapshotter = "overlaybd"
-    disable_snapshot_annotations = false
-    {{- end}}
-    default_runtime_name = "runc"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
-      BinaryName = "/usr/bin/runc"
-      {{- if IsCgroupV2 }}
-      SystemdCgroup = true
-      {{- end}}
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
-      runtime_type = "io.containerd.runc.v2"
-    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
-      BinaryName = "/usr/bin/runc"
-  {{- if and (IsKubenet) (not HasCalicoNetworkPolicy) }}
-  [plugins."io.containerd.grpc.v1.cri".cni]
-    bin_dir = "/opt/cni/bin"
-    conf_dir = "/etc/cni/net.d"
-    conf_template = "/etc/containerd/kubenet_template.conf"
-  {{- end}}
-  {{- if IsKubernetesVersionGe "1.22.0"}}
-  [plugins."io.containerd.grpc.v1.cri".registry]
-    config_path = "/etc/containerd/certs.d"
-  {{- end}}
-  [plugins."io.containerd.grpc.v1.cri".registry.headers]
-    X-Meta-Source-Client = ["azure/aks"]
-[metrics]
-  address = "0.0.0.0:10257"
-{{- if IsArtifactStreamingEnabled }}
-[proxy_plugins]
-  [proxy_plugins.overlaybd]
-    type = "snapshot"
-    address = "/run/overlaybd-snapshotter/overlaybd.sock"
-{{- end}}
-{{- if IsKata }}
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "overlayfs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration.toml"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview]
-  runtime_type = "io.containerd.kata.v2"
-  privileged_without_host_devices = true
-  snapshotter = "erofs"
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-preview.options]
-    ConfigPath = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
-[proxy_plugins]
-  [proxy_plugins.tardev]
-    type = "snapshot"
-    address = "/run/containerd/tardev-snapshotter.sock"
-[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc]
-  snapshotter = "tardev"
-  runtime_type = "io.containerd.kata-cc.v2"
-  privileged_without_host_devices = true
-  pod_annotations = ["io.katacontainers.*"]
-  [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc.options]
-    ConfigPath = "/opt/confidential-containers/share/defaults/kata-containers/configuration-clh-snp.toml"
-{{- end}}
-`
-)
-
-func containerdConfigFromTemplate(
-	config *datamodel.NodeBootstrappingConfiguration,
-	profile *datamodel.AgentPoolProfile,
-	tmpl ContainerdConfigTemplate,
-) (string, error) {
-	parameters := getParameters(config)
-	variables := getCustomDataVariables(config)
-	bakerFuncMap := getBakerFuncMap(config, parameters, variables)
-	containerdConfigTemplate := template.Must(template.New("kubenet").Funcs(bakerFuncMap).Parse(string(tmpl)))
-	var b bytes.Buffer
-	if err := containerdConfigTemplate.Execute(&b, profile); err != nil {
-		return "", fmt.Errorf("failed to execute sysctl template: %w", err)
-	}
-	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
-}
-
 // ----------------------- Start of changes related to localdns ------------------------------------------.
-// Parse and generate localdns Corefile from template and LocalDNSProfile.
+// Generate localdns Corefile from LocalDNSProfile.
 // includeHostsPlugin controls whether the hosts plugin blocks for caching critical AKS FQDNs
-// are included in the generated Corefile. When false, the same template is rendered without
+// are included in the generated Corefile. When false, the same Corefile is rendered without
 // the hosts blocks, used as a fallback when enableAKSLocalDNSHostsSetup fails at provisioning time.
 func GenerateLocalDNSCoreFile(
 	config *datamodel.NodeBootstrappingConfiguration,
 	profile *datamodel.AgentPoolProfile,
 	includeHostsPlugin bool,
 ) (string, error) {
-	parameters := getParameters(config)
-	variables := getCustomDataVariables(config)
-	bakerFuncMap := getBakerFuncMap(config, parameters, variables)
-
 	if profile == nil || profile.LocalDNSProfile == nil || !profile.ShouldEnableLocalDNS() {
 		return "", nil
 	}
 
-	funcMapForHasSuffix := template.FuncMap{
-		"hasSuffix": strings.HasSuffix,
+	corefile, err := newLocalDNSCorefile(profile.GetLocalDNSCoreFileData(), includeHostsPlugin)
+	if err != nil {
+		return "", err
 	}
-	localDNSCoreFileData := profile.GetLocalDNSCoreFileData()
-	localDNSCoreFileData.IncludeHostsPlugin = includeHostsPlugin
-	localDNSCorefileTemplate, err := template.New("localdnscorefile").Funcs(bakerFuncMap).Funcs(funcMapForHasSuffix).Parse(localDNSCoreFileTemplateString)
+	rendered, err := corefile.Render()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse localdns corefile template: %w", err)
+		return "", fmt.Errorf("failed to render localdns corefile: %w", err)
 	}
+	return rendered, nil
+}
 
-	// Generate the Corefile content.
-	var corefileBuffer bytes.Buffer
-	if err := localDNSCorefileTemplate.Execute(&corefileBuffer, localDNSCoreFileData); err != nil {
-		return "", fmt.Errorf("failed to execute localdns corefile template: %w", err)
+// newLocalDNSCorefile converts the localdns Corefile data of the agent pool to the shared localdns model.
+// Overrides are ordered by domain.
+func newLocalDNSCorefile(data datamodel.LocalDNSCoreFileData, includeHostsPlugin bool) (*localdns.Corefile, error) {
+	vnetDNSOverrides, err := newLocalDNSOverrides(data.VnetDNSOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VnetDNS overrides: %w", err)
 	}
-
-	// Return the rendered Corefile as a plain string.
-	return corefileBuffer.String(), nil
+	kubeDNSOverrides, err := newLocalDNSOverrides(data.KubeDNSOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KubeDNS overrides: %w", err)
+	}
+	return &localdns.Corefile{
+		NodeListenerIP:     data.NodeListenerIP,
+		ClusterListenerIP:  data.ClusterListenerIP,
+		CoreDNSServiceIP:   data.CoreDNSServiceIP,
+		AzureDNSIP:         data.AzureDNSIP,
+		IncludeHostsPlugin: includeHostsPlugin,
+		VnetDNSOverrides:   vnetDNSOverrides,
+		KubeDNSOverrides:   kubeDNSOverrides,
+	}, nil
 }
 
-// Template to create corefile that will be used by localdns service.
-// When IncludeHostsPlugin is true, the hosts plugin blocks for caching critical AKS FQDNs
-// (mcr.microsoft.com, packages.aks.azure.com, etc.) are included in root domain server blocks.
-// When false, hosts blocks are omitted — used as a fallback when enableAKSLocalDNSHostsSetup fails at
-// provisioning time, following the same dual-config pattern used for containerd GPU/no-GPU configs.
-const localDNSCoreFileTemplateString = `# ***********************************************************************************
-# WARNING: Changes to this file will be overwritten and not persisted.
-# ***********************************************************************************
-# whoami (used for health check of DNS)
-health-check.localdns.local:53 {
-    bind {{$.NodeListenerIP}} {{$.ClusterListenerIP}}
-    whoami
-}
-# VnetDNS overrides apply to DNS traffic from pods with dnsPolicy:default or kubelet (referred to as VnetDNS traffic).
-{{- range $domain, $override := $.VnetDNSOverrides -}}
-{{- $isRootDomain := eq $domain "." -}}
-{{- $fwdToClusterCoreDNS := or (hasSuffix $domain "cluster.local") (eq $override.ForwardDestination "ClusterCoreDNS")}}
-{{- $forwardPolicy := "sequential" -}}
-{{- if eq $override.ForwardPolicy "RoundRobin" -}}
-    {{- $forwardPolicy = "round_robin" -}}
-{{- else if eq $override.ForwardPolicy "Random" -}}
-    {{- $forwardPolicy = "random" -}}
-{{- end }}
-{{$domain}}:53 {
-	{{- if eq $override.QueryLogging "Error" }}
-    errors
-    {{- else if eq $override.QueryLogging "Log" }}
-    log
-    {{- end }}
-    bind {{$.NodeListenerIP}}
-    {{- if and $isRootDomain $.IncludeHostsPlugin}}
-    # Check /etc/localdns/hosts first for critical AKS FQDNs (mcr.microsoft.com, packages.aks.azure.com, etc.)
-    hosts /etc/localdns/hosts {
-        ttl 5
-        reload 5s
-        fallthrough
-    }
-    {{- end}}
-    {{- if $isRootDomain}}
-    forward . {{$.AzureDNSIP}} {
-    {{- else}}
-    {{- if $fwdToClusterCoreDNS}}
-    forward . {{$.CoreDNSServiceIP}} {
-    {{- else}}
-    forward . {{$.AzureDNSIP}} {
-    {{- end}}
-	{{- end}}
-        {{- if eq $override.Protocol "ForceTCP"}}
-        force_tcp
-        {{- end}}
-        {{- if eq $override.Protocol "PreferUDP"}}
-        prefer_udp
-        {{- end}}
-        policy {{$forwardPolicy}}
-        max_concurrent {{$override.MaxConcurrent}}
-        {{- if and $override.HealthCheck $override.HealthCheck.GetDuration}}
-        {{- $duration := $override.HealthCheck.GetDuration}}
-        {{- $noRec := $override.HealthCheck.GetNoRec}}
-        {{- $domain := $override.HealthCheck.GetDomain}}
-        health_check {{$duration}}{{if $noRec}} no_rec{{end}}{{if $domain}} domain {{$domain}}{{end}}
-        {{- end}}
-        {{- if $override.GetFailfastAllUnhealthyUpstreams}}
-        failfast_all_unhealthy_upstreams
-        {{- end}}
-    }
-    ready {{$.NodeListenerIP}}:8181
-    cache {{$override.CacheDurationInSeconds}} {
-        success 9984
-        denial 9984
-        {{- if ne $override.ServeStale "Disable"}}
-        {{- if eq $override.ServeStale "Verify"}}
-        serve_stale {{$override.ServeStaleDurationInSeconds}}s verify
-        {{- else if eq $override.ServeStale "Immediate"}}
-        serve_stale {{$override.ServeStaleDurationInSeconds}}s immediate
-        {{- end }}
-        {{- end }}
-        servfail 0
-    }
-    loop
-    nsid localdns
-    prometheus :9253
-    {{- if $isRootDomain}}
-    template ANY ANY internal.cloudapp.net {
-        match "^(?:[^.]+\.){4,}internal\.cloudapp\.net\.$"
-        rcode NXDOMAIN
-        fallthrough
-    }
-    template ANY ANY reddog.microsoft.com {
-        rcode NXDOMAIN
-    }
-    {{- end}}
-}
-{{- end}}
-# KubeDNS overrides apply to DNS traffic from pods with dnsPolicy:ClusterFirst (referred to as KubeDNS traffic).
-{{- range $domain, $override := $.KubeDNSOverrides}}
-{{- $isRootDomain := eq $domain "." -}}
-{{- $fwdToClusterCoreDNS := or (hasSuffix $domain "cluster.local") (eq $override.ForwardDestination "ClusterCoreDNS")}}
-{{- $forwardPolicy := "" }}
-{{- $forwardPolicy := "sequential" -}}
-{{- if eq $override.ForwardPolicy "RoundRobin" -}}
-    {{- $forwardPolicy = "round_robin" -}}
-{{- else if eq $override.ForwardPolicy "Random" -}}
-    {{- $forwardPolicy = "random" -}}
-{{- end }}
-{{$domain}}:53 {
-	{{- if eq $override.QueryLogging "Error" }}
-    errors
-    {{- else if eq $override.QueryLogging "Log" }}
-    log
-    {{- end }}
-    bind {{$.ClusterListenerIP}}
-    {{- if and $isRootDomain $.IncludeHostsPlugin}}
-    # Check /etc/localdns/hosts first for critical AKS FQDNs (mcr.microsoft.com, packages.aks.azure.com, etc.)
-    hosts /etc/localdns/hosts {
-        ttl 5
-        reload 5s
-        fallthrough
-    }
-    {{- end}}
-    {{- if $fwdToClusterCoreDNS}}
-    forward . {{$.CoreDNSServiceIP}} {
-    {{- else}}
-    forward . {{$.AzureDNSIP}} {
-    {{- end}}
-        {{- if eq $override.Protocol "ForceTCP"}}
-        force_tcp
-        {{- end}}
-        {{- if eq $override.Protocol "PreferUDP"}}
-        prefer_udp
-        {{- end}}
-        policy {{$forwardPolicy}}
-        max_concurrent {{$override.MaxConcurrent}}
-        {{- if and $override.HealthCheck $override.HealthCheck.GetDuration}}
-        {{- $duration := $override.HealthCheck.GetDuration}}
-        {{- $noRec := $override.HealthCheck.GetNoRec}}
-        {{- $domain := $override.HealthCheck.GetDomain}}
-        health_check {{$duration}}{{if $noRec}} no_rec{{end}}{{if $domain}} domain {{$domain}}{{end}}
-        {{- end}}
-        {{- if $override.GetFailfastAllUnhealthyUpstreams}}
-        failfast_all_unhealthy_upstreams
-        {{- end}}
-    }
-    ready {{$.ClusterListenerIP}}:8181
-    cache {{$override.CacheDurationInSeconds}} {
-        success 9984
-        denial 9984
-        {{- if ne $override.ServeStale "Disable"}}
-        {{- if eq $override.ServeStale "Verify"}}
-        serve_stale {{$override.ServeStaleDurationInSeconds}}s verify
-        {{- else if eq $override.ServeStale "Immediate"}}
-        serve_stale {{$override.ServeStaleDurationInSeconds}}s immediate
-        {{- end }}
-        {{- end }}
-        servfail 0
-    }
-    loop
-    nsid localdns-pod
-    prometheus :9253
-    {{- if $isRootDomain}}
-    template ANY ANY internal.cloudapp.net {
-        match "^(?:[^.]+\.){4,}internal\.cloudapp\.net\.$"
-        rcode NXDOMAIN
-        fallthrough
-    }
-    template ANY ANY reddog.microsoft.com {
-        rcode NXDOMAIN
-    }
-    {{- end}}
+func newLocalDNSOverrides(overrides map[string]*datamodel.LocalDNSOverrides) ([]localdns.Override, error) {
+	result := make([]localdns.Override, 0, len(overrides))
+	for _, domain := range slices.Sorted(maps.Keys(overrides)) {
+		o := overrides[domain]
+		if o == nil {
+			return nil, fmt.Errorf("override for domain %q is nil", domain)
+		}
+		result = append(result, localdns.Override{
+			Domain:                      domain,
+			QueryLogging:                localdns.QueryLogging(o.QueryLogging),
+			Protocol:                    localdns.Protocol(o.Protocol),
+			ForwardDestination:          localdns.ForwardDestination(o.ForwardDestination),
+			ForwardPolicy:               localdns.ForwardPolicy(o.ForwardPolicy),
+			MaxConcurrent:               o.MaxConcurrent,
+			CacheDurationInSeconds:      o.CacheDurationInSeconds,
+			ServeStale:                  localdns.ServeStale(o.ServeStale),
+			ServeStaleDurationInSeconds: o.ServeStaleDurationInSeconds,
+			HealthCheck: localdns.HealthCheck{
+				Duration: o.HealthCheck.GetDuration(),
+				NoRec:    o.HealthCheck.GetNoRec(),
+				Domain:   o.HealthCheck.GetDomain(),
+			},
+			FailfastAllUnhealthyUpstreams: o.GetFailfastAllUnhealthyUpstreams(),
+		})
+	}
+	return result, nil
 }
-{{- end}}
-`
 
 // ----------------------- End of changes related to localdns ------------------------------------------.
@@ -117,15 +117,6 @@ var _ = Describe("Assert generated customData and cseCmd", func() {
 			})
 		})
 
-		Describe(".getPortRangeEndValue()", func() {
-			It("given a port range with 2 numbers, it returns an the second number", func() {
-				Expect(getPortRangeEndValue("1 2")).To(Equal(2))
-			})
-			It("given a port range with 3 numbers, it returns an the second number", func() {
-				Expect(getPortRangeEndValue("1 2 3")).To(Equal(2))
-			})
-		})
-
 		Describe(".areCustomCATrustCertsPopulated()", func() {
 			It("given an empty profile, it returns false", func() {
 				Expect(areCustomCATrustCertsPopulated(*config)).To(BeFalse())
@@ -1415,6 +1406,36 @@ var _ = Describe("getLinuxNodeCSECommand", func() {
 		Expect(string(decodedSysctl)).To(ContainSubstring("net.core.somaxconn"))
 	})
 
+	It("should render net.ipv4.tcp_tw_reuse false as 0", func() {
+		tcpTwReuse := false
+		baseConfig.ContainerService.Properties.AgentPoolProfiles[0].CustomLinuxOSConfig = &datamodel.CustomLinuxOSConfig{
+			Sysctls: &datamodel.SysctlConfig{
+				NetIpv4TcpTwReuse: &tcpTwReuse,
+			},
+		}
+
+		vars := decodeCSEVars(templateGenerator.getLinuxNodeCSECommand(baseConfig))
+		decodedSysctl, decodeErr := base64.StdEncoding.DecodeString(vars["SYSCTL_CONTENT"])
+		Expect(decodeErr).NotTo(HaveOccurred())
+		Expect(string(decodedSysctl)).To(ContainSubstring("\nnet.ipv4.tcp_tw_reuse=0\n"))
+	})
+
+	It("should not set LimitNOFILE on Ubuntu with containerd 2.x", func() {
+		baseConfig.ContainerService.Properties.AgentPoolProfiles[0].CustomLinuxOSConfig = &datamodel.CustomLinuxOSConfig{
+			UlimitConfig: &datamodel.UlimitConfig{MaxLockedMemory: "75000", NoFile: "1048"},
+		}
+		for distro, expected := range map[datamodel.Distro]string{
+			datamodel.AKSUbuntuContainerd2204: "[Service] LimitMEMLOCK=75000 LimitNOFILE=1048 ",
+			datamodel.AKSUbuntuContainerd2404: "[Service] LimitMEMLOCK=75000 ",
+			datamodel.AKSAzureLinuxV3Gen2:     "[Service] LimitMEMLOCK=75000 LimitNOFILE=1048 ",
+		} {
+			baseConfig.AgentPoolProfile.Distro = distro
+
+			vars := decodeCSEVars(templateGenerator.getLinuxNodeCSECommand(baseConfig))
+			Expect(vars).To(HaveKeyWithValue("CONTAINERD_ULIMITS", expected), string(distro))
+		}
+	})
+
 	It("should handle SSH configuration", func() {
 		baseConfig.SSHStatus = datamodel.SSHOff
 
@@ -38,7 +38,9 @@ func (agentBaker *agentBakerImpl) WithToggles(toggles toggles.Toggles) *agentBak
 func (agentBaker *agentBakerImpl) GetNodeBootstrapping(ctx context.Context, config *datamodel.NodeBootstrappingConfiguration) (*datamodel.NodeBootstrapping, error) {
 	// validate and fix input before passing config to the template generator.
 	if config.AgentPoolProfile.IsWindows() {
-		validateAndSetWindowsNodeBootstrappingConfiguration(config)
+		if err := validateAndSetWindowsNodeBootstrappingConfiguration(config); err != nil {
+			return nil, err
+		}
 	} else {
 		if err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config); err != nil {
 			return nil, err
@@ -110,9 +112,19 @@ func (agentBaker *agentBakerImpl) GetLatestSigImageConfig(sigConfig datamodel.SI
 			sigImageConfig.Version = imageVersion
 		}
 	}
+
+	manifest := datamodel.GetSIGImageReplicationManifest()
+	version, reason, ok := manifest.ResolveVersion(distro, sigImageConfig.Version, envInfo.Region)
+	if !ok {
+		return nil, fmt.Errorf("can't find SIG image config for distro %s in region %s: %s", distro, envInfo.Region, reason)
+	}
+	sigImageConfig.Version = version
+	sigImageConfig.VersionFallbackReason = reason
 	return sigImageConfig, nil
 }
 
+// GetDistroSigImageConfig returns the SIG image config of every distro in the region. A distro without any image version
+// replicated to the region is left out rather than failing the other distros.
 func (agentBaker *agentBakerImpl) GetDistroSigImageConfig(
 	sigConfig datamodel.SIGConfig, envInfo *datamodel.EnvironmentInfo) (map[datamodel.Distro]datamodel.SigImageConfig, error) {
 	allAzureSigConfig, err := datamodel.GetSIGAzureCloudSpecConfig(sigConfig, envInfo.Region)
@@ -175,6 +187,18 @@ func (agentBaker *agentBakerImpl) GetDistroSigImageConfig(
 		allDistros[distro] = sigConfig
 	}
 
+	manifest := datamodel.GetSIGImageReplicationManifest()
+	for distro, sigConfig := range allDistros {
+		version, reason, ok := manifest.ResolveVersion(distro, sigConfig.Version, envInfo.Region)
+		if !ok {
+			delete(allDistros, distro)
+			continue
+		}
+		sigConfig.Version = version
+		sigConfig.VersionFallbackReason = reason
+		allDistros[distro] = sigConfig
+	}
+
 	return allDistros, nil
 }
 
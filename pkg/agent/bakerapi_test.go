@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"time"
 
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
 	agenttoggles "github.com/Azure/agentbaker/pkg/agent/toggles"
@@ -349,6 +350,91 @@ var _ = Describe("AgentBaker API implementation tests", func() {
 			})
 			Expect(err).To(HaveOccurred())
 		})
+
+		Context("with a replication manifest", func() {
+			BeforeEach(func() {
+				datamodel.SetSIGImageReplicationManifest(&datamodel.SIGImageReplicationManifest{
+					Images: map[datamodel.Distro][]datamodel.SIGImageVersionReplication{
+						datamodel.AKSUbuntuContainerd2404Gen2: {
+							{
+								Version:     datamodel.LinuxSIGImageVersion,
+								Regions:     []string{"eastus"},
+								PublishedAt: time.Date(2026, 8, 14, 0, 0, 0, 0, time.UTC),
+							},
+							{
+								Version:     "202607.01.0",
+								Clouds:      []string{datamodel.AzurePublicCloud},
+								PublishedAt: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+							},
+						},
+					},
+				})
+			})
+
+			AfterEach(func() {
+				datamodel.SetSIGImageReplicationManifest(nil)
+			})
+
+			It("should return the catalog version when it is replicated to the region", func() {
+				agentBaker, err := NewAgentBaker()
+				Expect(err).NotTo(HaveOccurred())
+
+				sigImageConfig, err := agentBaker.GetLatestSigImageConfig(config.SIGConfig, datamodel.AKSUbuntuContainerd2404Gen2, &datamodel.EnvironmentInfo{
+					Region: "eastus",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sigImageConfig.Version).To(Equal(datamodel.LinuxSIGImageVersion))
+				Expect(sigImageConfig.VersionFallbackReason).To(BeEmpty())
+			})
+
+			It("should fall back to the newest replicated version with a reason", func() {
+				agentBaker, err := NewAgentBaker()
+				Expect(err).NotTo(HaveOccurred())
+
+				sigImageConfig, err := agentBaker.GetLatestSigImageConfig(config.SIGConfig, datamodel.AKSUbuntuContainerd2404Gen2, &datamodel.EnvironmentInfo{
+					Region: cs.Location,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sigImageConfig.Version).To(Equal("202607.01.0"))
+				Expect(sigImageConfig.VersionFallbackReason).To(ContainSubstring("is not replicated to region southcentralus"))
+			})
+
+			It("should return an error when no version is replicated to the region", func() {
+				agentBaker, err := NewAgentBaker()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = agentBaker.GetLatestSigImageConfig(config.SIGConfig, datamodel.AKSUbuntuContainerd2404Gen2, &datamodel.EnvironmentInfo{
+					Region: "usgovvirginia",
+				})
+				Expect(err).To(MatchError(ContainSubstring("no older version is")))
+			})
+
+			It("should report the fallback in GetDistroSigImageConfig", func() {
+				agentBaker, err := NewAgentBaker()
+				Expect(err).NotTo(HaveOccurred())
+
+				configs, err := agentBaker.GetDistroSigImageConfig(config.SIGConfig, &datamodel.EnvironmentInfo{
+					Region: cs.Location,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs[datamodel.AKSUbuntuContainerd2404Gen2].Version).To(Equal("202607.01.0"))
+				Expect(configs[datamodel.AKSUbuntuContainerd2404Gen2].VersionFallbackReason).NotTo(BeEmpty())
+				Expect(configs[datamodel.AKSUbuntuContainerd2204Gen2].VersionFallbackReason).To(BeEmpty())
+			})
+
+			It("should leave out of GetDistroSigImageConfig only the distros with no version replicated to the region", func() {
+				agentBaker, err := NewAgentBaker()
+				Expect(err).NotTo(HaveOccurred())
+
+				configs, err := agentBaker.GetDistroSigImageConfig(config.SIGConfig, &datamodel.EnvironmentInfo{
+					Region: "usgovvirginia",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).NotTo(HaveKey(datamodel.AKSUbuntuContainerd2404Gen2))
+				Expect(configs).To(HaveKey(datamodel.AKSUbuntuContainerd2204Gen2))
+				Expect(configs[datamodel.AKSUbuntuContainerd2204Gen2].Version).To(Equal(datamodel.LinuxSIGImageVersion))
+			})
+		})
 	})
 
 	Context("GetDistroSigImageConfig", func() {
@@ -0,0 +1,187 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Azure/agentbaker/pkg/agent/containerdconfig"
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+)
+
+const (
+	containerdRuncRuntimeType              = "io.containerd.runc.v2"
+	containerdKataRuntimeType              = "io.containerd.kata.v2"
+	containerdRuncBinaryPath               = "/usr/bin/runc"
+	containerdNvidiaRuntimeName            = "nvidia-container-runtime"
+	containerdNvidiaRuntimeBinaryPath      = "/usr/bin/nvidia-container-runtime"
+	containerdKataConfigPath               = "/usr/share/defaults/kata-containers/configuration.toml"
+	containerdKataPreviewConfigPath        = "/usr/share/defaults/kata-containers/configuration-clh-preview.toml"
+	containerdKataCCConfigPath             = "/opt/confidential-containers/share/defaults/kata-containers/configuration-clh-snp.toml"
+	containerdMetricsAddress               = "0.0.0.0:10257"
+	containerdOOMScore                     = -999
+	containerdKubenetConfTemplate          = "/etc/containerd/kubenet_template.conf"
+	containerdArtifactStreamingSnapshotter = "overlaybd"
+	containerdArtifactStreamingAddress     = "/run/overlaybd-snapshotter/overlaybd.sock"
+	containerdTardevSnapshotterAddress     = "/run/containerd/tardev-snapshotter.sock"
+)
+
+// this pains me, but to make it respect mutability of vmss tags,
+// we cannot generate the config at runtime.
+// CSE needs to be able to write the full config, with all params,
+// with the tags pulled from wireserver. this is a hack to avoid
+// moving the config generation to CSE -- we generate two configs,
+// pass both to CSE base64-encoded, and it picks the right one.
+// they're identical except for GPU runtime class.
+
+// containerdConfigContent returns the base64 encoded containerd config of the node, without the GPU runtime class when noGPU is set.
+func containerdConfigContent(config *datamodel.NodeBootstrappingConfiguration, noGPU bool) (string, error) {
+	out, err := buildContainerdConfig(config, noGPU).Marshal()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate containerd config: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// buildContainerdConfig returns the containerd config of the node.
+// Containerd 2.x splits the CRI plugin (io.containerd.grpc.v1.cri) into io.containerd.cri.v1.images and io.containerd.cri.v1.runtime.
+func buildContainerdConfig(config *datamodel.NodeBootstrappingConfiguration, noGPU bool) *containerdconfig.Config {
+	cs := config.ContainerService
+	profile := config.AgentPoolProfile
+	v2 := profile.IsContainerdV2Distro()
+	kata := profile.Distro.IsKataDistro()
+
+	containerdConfig := &containerdconfig.Config{
+		Version:  2,
+		OOMScore: containerdOOMScore,
+		Plugins:  map[string]any{},
+		Metrics:  &containerdconfig.Metrics{Address: containerdMetricsAddress},
+	}
+	if HasDataDir(config) {
+		containerdConfig.Root = GetDataDir(config)
+	}
+
+	registry := &containerdconfig.RegistryConfig{Headers: map[string][]string{"X-Meta-Source-Client": {"azure/aks"}}}
+	if cs.Properties.OrchestratorProfile.IsKubernetes() &&
+		IsKubernetesVersionGe(cs.Properties.OrchestratorProfile.OrchestratorVersion, "1.22.0") {
+		registry.ConfigPath = containerdconfig.RegistryConfigPath
+	}
+	var cni *containerdconfig.CNIConfig
+	kubernetesConfig := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if kubernetesConfig.NetworkPlugin == NetworkPluginKubenet && kubernetesConfig.NetworkPolicy != NetworkPolicyCalico {
+		cni = &containerdconfig.CNIConfig{BinDir: "/opt/cni/bin", ConfDir: "/etc/cni/net.d", ConfTemplate: containerdKubenetConfTemplate}
+	}
+	criContainerd := &containerdconfig.CRIContainerdConfig{}
+	criContainerd.DefaultRuntimeName, criContainerd.Runtimes = defaultContainerdRuntimes(config.EnableNvidia && !noGPU,
+		v2 || isCgroupV2(config))
+
+	// snapshotter and disable_snapshot_annotations are CRI images plugin settings with containerd 2.x.
+	var snapshotter string
+	var disableSnapshotAnnotations *bool
+	disabled := false
+	if kata {
+		snapshotter, disableSnapshotAnnotations = "overlayfs", &disabled
+	}
+	if config.EnableArtifactStreaming {
+		snapshotter, disableSnapshotAnnotations = containerdArtifactStreamingSnapshotter, &disabled
+		containerdConfig.ProxyPlugins = map[string]*containerdconfig.ProxyPlugin{
+			containerdArtifactStreamingSnapshotter: {Type: "snapshot", Address: containerdArtifactStreamingAddress},
+		}
+	}
+
+	var sandboxImage string
+	if config.K8sComponents != nil {
+		sandboxImage = config.K8sComponents.PodInfraContainerImageURL
+	}
+	if v2 {
+		images := &containerdconfig.CRIImagesConfig{
+			Snapshotter:                snapshotter,
+			DisableSnapshotAnnotations: disableSnapshotAnnotations,
+			Registry:                   registry,
+		}
+		if sandboxImage != "" {
+			images.PinnedImages = &containerdconfig.PinnedImages{Sandbox: sandboxImage}
+		}
+		containerdConfig.Plugins[containerdconfig.CRIImagesPluginID] = images
+		containerdConfig.Plugins[containerdconfig.CRIRuntimePluginID] = &containerdconfig.CRIRuntimeConfig{Containerd: criContainerd, CNI: cni}
+	} else {
+		criContainerd.Snapshotter, criContainerd.DisableSnapshotAnnotations = snapshotter, disableSnapshotAnnotations
+		containerdConfig.Plugins[containerdconfig.CRIPluginID] = &containerdconfig.CRIConfig{
+			SandboxImage: sandboxImage,
+			EnableCDI:    !noGPU,
+			Containerd:   criContainerd,
+			CNI:          cni,
+			Registry:     registry,
+		}
+	}
+
+	if kata {
+		addKataContainerdConfig(containerdConfig, criContainerd)
+	}
+	return containerdConfig
+}
+
+// isCgroupV2 returns true if the distro of the node boots with cgroup v2.
+func isCgroupV2(config *datamodel.NodeBootstrappingConfiguration) bool {
+	profile := config.AgentPoolProfile
+	return profile.Is2204VHDDistro() || profile.Is2404VHDDistro() || profile.Is2604VHDDistro() ||
+		config.IsAzureLinux() || config.IsFlatcar() || config.IsACL()
+}
+
+// defaultContainerdRuntimes returns the default runtime handler and the runtime handlers of every node.
+func defaultContainerdRuntimes(enableNvidia, systemdCgroup bool) (string, map[string]*containerdconfig.Runtime) {
+	name, binary := "runc", containerdRuncBinaryPath
+	if enableNvidia {
+		name, binary = containerdNvidiaRuntimeName, containerdNvidiaRuntimeBinaryPath
+	}
+	defaultOptions := map[string]any{"BinaryName": binary}
+	if systemdCgroup {
+		defaultOptions["SystemdCgroup"] = true
+	}
+	return name, map[string]*containerdconfig.Runtime{
+		name:        {RuntimeType: containerdRuncRuntimeType, Options: defaultOptions},
+		"untrusted": {RuntimeType: containerdRuncRuntimeType, Options: map[string]any{"BinaryName": binary}},
+	}
+}
+
+// addKataContainerdConfig adds the erofs snapshotter and the kata runtime handlers of Kata Containers nodes.
+// Kata distros are never containerd 2.x distros, see IsContainerdV2Distro, so the handlers go to the containerd 1.x CRI
+// plugin.
+func addKataContainerdConfig(containerdConfig *containerdconfig.Config, criContainerd *containerdconfig.CRIContainerdConfig) {
+	containerdConfig.Plugins[containerdconfig.SnapshotterPluginIDPrefix+"erofs"] = map[string]any{
+		"default_size":      "10G",
+		"enable_fsverity":   false,
+		"ovl_mount_options": []string{},
+	}
+	containerdConfig.Plugins["io.containerd.service.v1.diff-service"] = map[string]any{"default": []string{"erofs", "walking"}}
+	containerdConfig.Plugins["io.containerd.differ.v1.erofs"] = map[string]any{
+		"mkfs_options":     []string{"-T0", "--mkfs-time", "--sort=none"},
+		"enable_tar_index": false,
+	}
+	if containerdConfig.ProxyPlugins == nil {
+		containerdConfig.ProxyPlugins = map[string]*containerdconfig.ProxyPlugin{}
+	}
+	containerdConfig.ProxyPlugins["tardev"] = &containerdconfig.ProxyPlugin{Type: "snapshot", Address: containerdTardevSnapshotterAddress}
+
+	criContainerd.Runtimes["kata"] = &containerdconfig.Runtime{
+		RuntimeType:                  containerdKataRuntimeType,
+		Snapshotter:                  "overlayfs",
+		PrivilegedWithoutHostDevices: true,
+		Options:                      map[string]any{"ConfigPath": containerdKataConfigPath},
+	}
+	criContainerd.Runtimes["kata-preview"] = &containerdconfig.Runtime{
+		RuntimeType:                  containerdKataRuntimeType,
+		Snapshotter:                  "erofs",
+		PrivilegedWithoutHostDevices: true,
+		Options:                      map[string]any{"ConfigPath": containerdKataPreviewConfigPath},
+	}
+	criContainerd.Runtimes["kata-cc"] = &containerdconfig.Runtime{
+		RuntimeType:                  "io.containerd.kata-cc.v2",
+		Snapshotter:                  "tardev",
+		PrivilegedWithoutHostDevices: true,
+		PodAnnotations:               []string{"io.katacontainers.*"},
+		Options:                      map[string]any{"ConfigPath": containerdKataCCConfigPath},
+	}
+}
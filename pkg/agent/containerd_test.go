@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"encoding/base64"
+
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("containerd config", func() {
+	newConfig := func(distro datamodel.Distro) *datamodel.NodeBootstrappingConfiguration {
+		agentPool := &datamodel.AgentPoolProfile{Name: "agentpool", Distro: distro}
+		return &datamodel.NodeBootstrappingConfiguration{
+			ContainerService: &datamodel.ContainerService{
+				Properties: &datamodel.Properties{
+					OrchestratorProfile: &datamodel.OrchestratorProfile{
+						OrchestratorType:    datamodel.Kubernetes,
+						OrchestratorVersion: "1.32.1",
+						KubernetesConfig:    &datamodel.KubernetesConfig{NetworkPlugin: NetworkPluginKubenet},
+					},
+					AgentPoolProfiles: []*datamodel.AgentPoolProfile{agentPool},
+				},
+			},
+			AgentPoolProfile: agentPool,
+			K8sComponents:    &datamodel.K8sComponents{PodInfraContainerImageURL: "mcr.microsoft.com/oss/kubernetes/pause:3.6"},
+		}
+	}
+	render := func(config *datamodel.NodeBootstrappingConfiguration, noGPU bool) string {
+		content, err := containerdConfigContent(config, noGPU)
+		Expect(err).NotTo(HaveOccurred())
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		Expect(err).NotTo(HaveOccurred())
+		return string(decoded)
+	}
+
+	It("should configure the containerd 1.x CRI plugin with the nvidia runtime on GPU nodes", func() {
+		config := newConfig(datamodel.AKSUbuntuContainerd2204Gen2)
+		config.EnableNvidia = true
+		Expect(render(config, false)).To(Equal(`version = 2
+oom_score = -999
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "mcr.microsoft.com/oss/kubernetes/pause:3.6"
+  enable_cdi = true
+  [plugins."io.containerd.grpc.v1.cri".containerd]
+    default_runtime_name = "nvidia-container-runtime"
+    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime]
+      runtime_type = "io.containerd.runc.v2"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia-container-runtime.options]
+        BinaryName = "/usr/bin/nvidia-container-runtime"
+        SystemdCgroup = true
+    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted]
+      runtime_type = "io.containerd.runc.v2"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.untrusted.options]
+        BinaryName = "/usr/bin/nvidia-container-runtime"
+  [plugins."io.containerd.grpc.v1.cri".cni]
+    bin_dir = "/opt/cni/bin"
+    conf_dir = "/etc/cni/net.d"
+    conf_template = "/etc/containerd/kubenet_template.conf"
+  [plugins."io.containerd.grpc.v1.cri".registry]
+    config_path = "/etc/containerd/certs.d"
+    [plugins."io.containerd.grpc.v1.cri".registry.headers]
+      X-Meta-Source-Client = ["azure/aks"]
+[metrics]
+  address = "0.0.0.0:10257"
+`))
+
+		noGPU := render(config, true)
+		Expect(noGPU).To(ContainSubstring(`default_runtime_name = "runc"`))
+		Expect(noGPU).NotTo(ContainSubstring("nvidia"))
+		Expect(noGPU).NotTo(ContainSubstring("enable_cdi"))
+	})
+
+	It("should split the CRI plugin on containerd 2.x distros", func() {
+		config := newConfig(datamodel.AKSUbuntuContainerd2404Gen2)
+		config.EnableArtifactStreaming = true
+		Expect(config.AgentPoolProfile.IsContainerdV2Distro()).To(BeTrue())
+
+		rendered := render(config, false)
+		Expect(rendered).To(ContainSubstring(`[plugins."io.containerd.cri.v1.images"]
+  snapshotter = "overlaybd"
+  disable_snapshot_annotations = false
+  [plugins."io.containerd.cri.v1.images".pinned_images]
+    sandbox = "mcr.microsoft.com/oss/kubernetes/pause:3.6"
+`))
+		Expect(rendered).To(ContainSubstring(`[plugins."io.containerd.cri.v1.runtime".containerd.runtimes.runc.options]`))
+		Expect(rendered).To(ContainSubstring(`[proxy_plugins.overlaybd]`))
+		Expect(rendered).NotTo(ContainSubstring(`io.containerd.grpc.v1.cri`))
+	})
+
+	It("should add the kata runtime handlers on Kata distros", func() {
+		config := newConfig(datamodel.AKSAzureLinuxV3Gen2Kata)
+		config.EnableArtifactStreaming = true
+
+		rendered := render(config, true)
+		Expect(rendered).To(ContainSubstring(`[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata]
+      runtime_type = "io.containerd.kata.v2"
+      snapshotter = "overlayfs"
+      privileged_without_host_devices = true
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata.options]
+        ConfigPath = "/usr/share/defaults/kata-containers/configuration.toml"
+`))
+		Expect(rendered).To(ContainSubstring(`[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.kata-cc]`))
+		Expect(rendered).To(ContainSubstring(`[plugins."io.containerd.snapshotter.v1.erofs"]`))
+		// artifact streaming takes over the snapshotter, both proxy plugins share one table.
+		Expect(rendered).To(ContainSubstring(`    snapshotter = "overlaybd"`))
+		Expect(rendered).To(ContainSubstring("[proxy_plugins.overlaybd]"))
+		Expect(rendered).To(ContainSubstring("[proxy_plugins.tardev]"))
+	})
+
+	It("should omit an empty sandbox image and write the data dir", func() {
+		config := newConfig(datamodel.AKSUbuntuContainerd2204Gen2)
+		config.K8sComponents = nil
+		config.ContainerService.Properties.OrchestratorProfile.KubernetesConfig.ContainerRuntimeConfig = map[string]string{
+			datamodel.ContainerDataDirKey: "/mnt/containerd",
+		}
+
+		rendered := render(config, true)
+		Expect(rendered).To(HavePrefix("version = 2\noom_score = -999\nroot = \"/mnt/containerd\"\n"))
+		Expect(rendered).NotTo(ContainSubstring("sandbox_image"))
+	})
+})
@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package containerdconfig models the containerd config.toml and the registry hosts.toml files written to AKS nodes,
+// so they are generated from Go types and can be customized with overlays rather than by forking text templates.
+// It is shared by the baker and by aks-node-controller, which build the config from their own inputs.
+package containerdconfig
+
+const (
+	// CRIPluginID is the ID of the CRI plugin of containerd 1.x.
+	CRIPluginID = "io.containerd.grpc.v1.cri"
+	// CRIImagesPluginID and CRIRuntimePluginID split the config of CRIPluginID in containerd 2.x.
+	CRIImagesPluginID  = "io.containerd.cri.v1.images"
+	CRIRuntimePluginID = "io.containerd.cri.v1.runtime"
+	// SnapshotterPluginIDPrefix prefixes the snapshotter name in the ID of snapshotter plugins, e.g. io.containerd.snapshotter.v1.overlayfs.
+	SnapshotterPluginIDPrefix = "io.containerd.snapshotter.v1."
+	// RegistryConfigPath is the directory holding the hosts.toml file of each registry host.
+	RegistryConfigPath = "/etc/containerd/certs.d"
+)
+
+// Config is the containerd config.toml.
+type Config struct {
+	Version  int    `toml:"version"`
+	OOMScore int    `toml:"oom_score"`
+	Root     string `toml:"root,omitempty"`
+	// Plugins holds the plugin configs keyed by plugin ID. Values are either one of the plugin configs
+	// of this package, e.g. *CRIConfig, or a map[string]any.
+	Plugins      map[string]any          `toml:"plugins,omitempty"`
+	Metrics      *Metrics                `toml:"metrics,omitempty"`
+	ProxyPlugins map[string]*ProxyPlugin `toml:"proxy_plugins,omitempty"`
+}
+
+// CRIConfig is the config of the containerd 1.x CRI plugin.
+type CRIConfig struct {
+	SandboxImage string               `toml:"sandbox_image,omitempty"`
+	EnableCDI    bool                 `toml:"enable_cdi,omitempty"`
+	Containerd   *CRIContainerdConfig `toml:"containerd,omitempty"`
+	CNI          *CNIConfig           `toml:"cni,omitempty"`
+	Registry     *RegistryConfig      `toml:"registry,omitempty"`
+}
+
+// CRIImagesConfig is the config of the containerd 2.x CRI images plugin.
+type CRIImagesConfig struct {
+	Snapshotter                string          `toml:"snapshotter,omitempty"`
+	DisableSnapshotAnnotations *bool           `toml:"disable_snapshot_annotations,omitempty"`
+	PinnedImages               *PinnedImages   `toml:"pinned_images,omitempty"`
+	Registry                   *RegistryConfig `toml:"registry,omitempty"`
+}
+
+// PinnedImages lists the images which are never garbage collected by the containerd 2.x CRI images plugin.
+type PinnedImages struct {
+	Sandbox string `toml:"sandbox,omitempty"`
+}
+
+// CRIRuntimeConfig is the config of the containerd 2.x CRI runtime plugin.
+type CRIRuntimeConfig struct {
+	Containerd *CRIContainerdConfig `toml:"containerd,omitempty"`
+	CNI        *CNIConfig           `toml:"cni,omitempty"`
+}
+
+// CRIContainerdConfig configures the runtimes of the CRI plugin.
+type CRIContainerdConfig struct {
+	// Snapshotter and DisableSnapshotAnnotations are only supported by the containerd 1.x CRI plugin,
+	// they are set on CRIImagesConfig with containerd 2.x.
+	Snapshotter                string `toml:"snapshotter,omitempty"`
+	DisableSnapshotAnnotations *bool  `toml:"disable_snapshot_annotations,omitempty"`
+	DefaultRuntimeName         string `toml:"default_runtime_name,omitempty"`
+	// Runtimes holds the runtime handlers keyed by name, as referenced by the RuntimeClass objects.
+	Runtimes map[string]*Runtime `toml:"runtimes,omitempty"`
+}
+
+// Runtime is a CRI runtime handler.
+type Runtime struct {
+	RuntimeType                  string   `toml:"runtime_type"`
+	Snapshotter                  string   `toml:"snapshotter,omitempty"`
+	PrivilegedWithoutHostDevices bool     `toml:"privileged_without_host_devices,omitempty"`
+	PodAnnotations               []string `toml:"pod_annotations,omitempty"`
+	// Options are passed to the runtime shim, e.g. BinaryName or SystemdCgroup for runc.
+	Options map[string]any `toml:"options,omitempty"`
+}
+
+// CNIConfig configures the CNI plugins called by the CRI plugin.
+type CNIConfig struct {
+	BinDir       string `toml:"bin_dir,omitempty"`
+	ConfDir      string `toml:"conf_dir,omitempty"`
+	ConfTemplate string `toml:"conf_template,omitempty"`
+}
+
+// RegistryConfig configures how the CRI plugin pulls images.
+type RegistryConfig struct {
+	// ConfigPath is the directory holding the hosts.toml files, usually RegistryConfigPath.
+	ConfigPath string `toml:"config_path,omitempty"`
+	// Headers are added to every request sent to a registry.
+	Headers map[string][]string `toml:"headers,omitempty"`
+}
+
+// Metrics configures the containerd metrics endpoint.
+type Metrics struct {
+	Address string `toml:"address,omitempty"`
+}
+
+// ProxyPlugin is a plugin served by another process over a unix socket, e.g. a remote snapshotter.
+type ProxyPlugin struct {
+	Type    string `toml:"type"`
+	Address string `toml:"address"`
+}
+
+// Marshal renders the config as TOML.
+func (c *Config) Marshal() ([]byte, error) {
+	return Marshal(c)
+}
+
+// criContainerdConfigs returns the runtime configs of the CRI plugins, the containerd 2.x CRI runtime plugin first.
+func (c *Config) criContainerdConfigs() []*CRIContainerdConfig {
+	var configs []*CRIContainerdConfig
+	if runtime, ok := c.Plugins[CRIRuntimePluginID].(*CRIRuntimeConfig); ok && runtime.Containerd != nil {
+		configs = append(configs, runtime.Containerd)
+	}
+	if cri, ok := c.Plugins[CRIPluginID].(*CRIConfig); ok && cri.Containerd != nil {
+		configs = append(configs, cri.Containerd)
+	}
+	return configs
+}
+
+// registryConfig returns the registry config of the CRI images plugin, or of the containerd 1.x CRI plugin.
+func (c *Config) registryConfig() *RegistryConfig {
+	if images, ok := c.Plugins[CRIImagesPluginID].(*CRIImagesConfig); ok {
+		return images.Registry
+	}
+	if cri, ok := c.Plugins[CRIPluginID].(*CRIConfig); ok {
+		return cri.Registry
+	}
+	return nil
+}
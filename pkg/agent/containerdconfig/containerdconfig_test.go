@@ -0,0 +1,218 @@
+package containerdconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig() *Config {
+	return &Config{
+		Version:  2,
+		OOMScore: -999,
+		Plugins: map[string]any{
+			CRIPluginID: &CRIConfig{
+				SandboxImage: "mcr.microsoft.com/oss/kubernetes/pause:3.6",
+				Containerd: &CRIContainerdConfig{
+					DefaultRuntimeName: "runc",
+					Runtimes: map[string]*Runtime{
+						"runc": {RuntimeType: "io.containerd.runc.v2", Options: map[string]any{"BinaryName": "/usr/bin/runc", "SystemdCgroup": true}},
+					},
+				},
+				Registry: &RegistryConfig{ConfigPath: RegistryConfigPath},
+			},
+		},
+		Metrics: &Metrics{Address: "0.0.0.0:10257"},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	out, err := newTestConfig().Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, `version = 2
+oom_score = -999
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "mcr.microsoft.com/oss/kubernetes/pause:3.6"
+  [plugins."io.containerd.grpc.v1.cri".containerd]
+    default_runtime_name = "runc"
+    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+      runtime_type = "io.containerd.runc.v2"
+      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+        BinaryName = "/usr/bin/runc"
+        SystemdCgroup = true
+  [plugins."io.containerd.grpc.v1.cri".registry]
+    config_path = "/etc/containerd/certs.d"
+[metrics]
+  address = "0.0.0.0:10257"
+`, string(out))
+}
+
+func TestMarshal_Values(t *testing.T) {
+	out, err := Marshal(map[string]any{
+		"string":     "quote \" backslash \\ newline \n tab \t bell \a",
+		"int":        -1,
+		"uint":       uint8(2),
+		"bool":       false,
+		"empty":      []string{},
+		"list":       []any{"a", 1, true},
+		"nil":        nil,
+		"dotted.key": map[string]any{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `bool = false
+empty = []
+int = -1
+list = ["a", 1, true]
+string = "quote \" backslash \\ newline \n tab \t bell \u0007"
+uint = 2
+["dotted.key"]
+`, string(out))
+
+	_, err = Marshal(map[string]any{"table": map[string]any{"float": 1.5}})
+	assert.ErrorContains(t, err, "table.float: unsupported value type float64")
+	_, err = Marshal(map[string]any{"tables": []map[string]any{{}}})
+	assert.ErrorContains(t, err, "arrays of tables are not supported")
+}
+
+func TestApplyOverlay(t *testing.T) {
+	config := newTestConfig()
+	config.Plugins[SnapshotterPluginIDPrefix+"erofs"] = map[string]any{"default_size": "10G", "enable_fsverity": false}
+	err := config.ApplyOverlay(&Overlay{
+		RegistryMirrors: map[string]*RegistryMirror{"docker.io": {Endpoints: []string{"https://mirror.example.com"}}},
+		RuntimeHandlers: map[string]*Runtime{"gvisor": {RuntimeType: "io.containerd.runsc.v1"}},
+		SnapshotterOptions: map[string]map[string]any{
+			"erofs":     {"enable_fsverity": true},
+			"overlayfs": {"upperdir_label": true},
+		},
+	})
+	require.NoError(t, err)
+
+	runtimes := config.Plugins[CRIPluginID].(*CRIConfig).Containerd.Runtimes
+	assert.Equal(t, &Runtime{RuntimeType: "io.containerd.runsc.v1"}, runtimes["gvisor"])
+	assert.Contains(t, runtimes, "runc")
+	assert.Equal(t, map[string]any{"default_size": "10G", "enable_fsverity": true}, config.Plugins[SnapshotterPluginIDPrefix+"erofs"])
+	assert.Equal(t, map[string]any{"upperdir_label": true}, config.Plugins[SnapshotterPluginIDPrefix+"overlayfs"])
+}
+
+func TestApplyOverlay_ContainerdV2(t *testing.T) {
+	config := &Config{Plugins: map[string]any{
+		CRIRuntimePluginID: &CRIRuntimeConfig{Containerd: &CRIContainerdConfig{Runtimes: map[string]*Runtime{"runc": {RuntimeType: "io.containerd.runc.v2"}}}},
+		// kata runtime handlers are still configured in the containerd 1.x CRI plugin.
+		CRIPluginID: &CRIConfig{Containerd: &CRIContainerdConfig{Runtimes: map[string]*Runtime{"kata": {RuntimeType: "io.containerd.kata.v2"}}}},
+	}}
+	err := config.ApplyOverlay(&Overlay{RuntimeHandlers: map[string]*Runtime{
+		"gvisor": {RuntimeType: "io.containerd.runsc.v1"},
+		"kata":   {RuntimeType: "io.containerd.kata.v2"},
+	}})
+	assert.EqualError(t, err, `runtime handler "kata" is configured by AKS and cannot be overridden`)
+	assert.Contains(t, config.Plugins[CRIRuntimePluginID].(*CRIRuntimeConfig).Containerd.Runtimes, "gvisor")
+}
+
+func TestApplyOverlay_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlay *Overlay
+		wantErr string
+	}{
+		{
+			name:    "overridden runtime handler",
+			overlay: &Overlay{RuntimeHandlers: map[string]*Runtime{"runc": {RuntimeType: "io.containerd.runc.v2"}}},
+			wantErr: `runtime handler "runc" is configured by AKS and cannot be overridden`,
+		},
+		{
+			name:    "invalid runtime handler name",
+			overlay: &Overlay{RuntimeHandlers: map[string]*Runtime{"My_Runtime": {RuntimeType: "io.containerd.runc.v2"}}},
+			wantErr: `runtime handler name "My_Runtime" is not a valid RFC 1123 label`,
+		},
+		{
+			name:    "runtime handler without runtime type",
+			overlay: &Overlay{RuntimeHandlers: map[string]*Runtime{"gvisor": {}}},
+			wantErr: `runtime handler "gvisor" has no runtime type`,
+		},
+		{
+			name:    "invalid snapshotter name",
+			overlay: &Overlay{SnapshotterOptions: map[string]map[string]any{"../overlayfs": {"a": 1}}},
+			wantErr: `snapshotter name "../overlayfs" is invalid`,
+		},
+		{
+			name:    "snapshotter option without name",
+			overlay: &Overlay{SnapshotterOptions: map[string]map[string]any{"overlayfs": {"": 1}}},
+			wantErr: `snapshotter "overlayfs" has an option without name`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, newTestConfig().ApplyOverlay(tt.overlay), tt.wantErr)
+		})
+	}
+
+	config := newTestConfig()
+	config.Plugins[CRIPluginID].(*CRIConfig).Registry = nil
+	err := config.ApplyOverlay(&Overlay{RegistryMirrors: map[string]*RegistryMirror{"docker.io": {Endpoints: []string{"https://mirror.example.com"}}}})
+	assert.ErrorContains(t, err, "registry mirrors require the CRI plugin registry config_path")
+}
+
+func TestHostsFiles(t *testing.T) {
+	overlay := &Overlay{RegistryMirrors: map[string]*RegistryMirror{
+		"docker.io": {
+			Endpoints:    []string{"https://z.example.com", "http://a.example.com:5000/v2"},
+			Capabilities: []string{"pull"},
+			SkipVerify:   true,
+			OverridePath: true,
+		},
+		DefaultRegistryHost: {Endpoints: []string{"https://mirror.example.com"}},
+	}}
+	files, err := overlay.HostsFiles()
+	require.NoError(t, err)
+	// mirrors are tried in order, so the endpoints must not be sorted.
+	assert.Equal(t, `[host."https://z.example.com"]
+  capabilities = ["pull"]
+  skip_verify = true
+  override_path = true
+[host."http://a.example.com:5000/v2"]
+  capabilities = ["pull"]
+  skip_verify = true
+  override_path = true
+`, string(files["docker.io"]))
+	assert.Equal(t, `[host."https://mirror.example.com"]
+  capabilities = ["pull", "resolve"]
+`, string(files[DefaultRegistryHost]))
+
+	var nilOverlay *Overlay
+	files, err = nilOverlay.HostsFiles()
+	assert.NoError(t, err)
+	assert.Nil(t, files)
+}
+
+func TestHostsFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		mirror  *RegistryMirror
+		wantErr string
+	}{
+		{name: "path traversal", host: "../etc", mirror: &RegistryMirror{Endpoints: []string{"https://m"}}, wantErr: `registry host "../etc" is invalid`},
+		{name: "no endpoint", host: "docker.io", mirror: &RegistryMirror{}, wantErr: `registry mirror of "docker.io" has no endpoint`},
+		{name: "not a URL", host: "docker.io", mirror: &RegistryMirror{Endpoints: []string{"mirror.example.com"}}, wantErr: "is not a http(s) URL"},
+		{
+			name: "unknown capability", host: "docker.io",
+			mirror:  &RegistryMirror{Endpoints: []string{"https://m"}, Capabilities: []string{"delete"}},
+			wantErr: `registry mirror of "docker.io" has unknown capability "delete"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&Overlay{RegistryMirrors: map[string]*RegistryMirror{tt.host: tt.mirror}}).HostsFiles()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseOptionValue(t *testing.T) {
+	assert.Equal(t, true, ParseOptionValue("true"))
+	assert.Equal(t, false, ParseOptionValue("false"))
+	assert.Equal(t, int64(-5), ParseOptionValue("-5"))
+	assert.Equal(t, "True", ParseOptionValue("True"))
+	assert.Equal(t, "1.5", ParseOptionValue("1.5"))
+}
@@ -0,0 +1,213 @@
+package containerdconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	// runtimeHandlerNamePattern matches RFC 1123 labels, the valid RuntimeClass handler names.
+	runtimeHandlerNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	snapshotterNamePattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	// registryHostPattern matches a registry host with an optional port, e.g. myregistry.azurecr.io or localhost:5000.
+	registryHostPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?(:[0-9]{1,5})?$`)
+)
+
+// DefaultRegistryHost configures the mirrors of every registry without its own hosts.toml file.
+const DefaultRegistryHost = "_default"
+
+// Overlay holds the customizations merged into the containerd config generated by AKS.
+type Overlay struct {
+	// RegistryMirrors are keyed by the registry host they mirror, or DefaultRegistryHost.
+	RegistryMirrors map[string]*RegistryMirror
+	// RuntimeHandlers are added to the runtimes of the CRI plugin, keyed by handler name.
+	RuntimeHandlers map[string]*Runtime
+	// SnapshotterOptions are merged into the config of the snapshotter plugins, keyed by snapshotter name.
+	SnapshotterOptions map[string]map[string]any
+}
+
+// RegistryMirror lists the mirrors containerd tries, in order, before the upstream registry.
+type RegistryMirror struct {
+	Endpoints []string
+	// Capabilities defaults to pull and resolve.
+	Capabilities []string
+	SkipVerify   bool
+	OverridePath bool
+}
+
+// hostConfig is a host entry of a hosts.toml file.
+type hostConfig struct {
+	Capabilities []string `toml:"capabilities,omitempty"`
+	SkipVerify   bool     `toml:"skip_verify,omitempty"`
+	OverridePath bool     `toml:"override_path,omitempty"`
+}
+
+// ParseOptionValue converts an option value to the TOML type containerd expects: "true" and "false" are
+// booleans and integers are integers, anything else is a string.
+func ParseOptionValue(s string) any {
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	return s
+}
+
+// ApplyOverlay merges the overlay into the config. Runtime handlers configured by AKS cannot be overridden.
+// The registry mirrors are not part of config.toml, see HostsFiles, but they require the CRI plugin to read
+// the hosts.toml files from RegistryConfigPath.
+func (c *Config) ApplyOverlay(o *Overlay) error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	if len(o.RegistryMirrors) > 0 {
+		if registry := c.registryConfig(); registry == nil || registry.ConfigPath != RegistryConfigPath {
+			errs = append(errs, fmt.Errorf("registry mirrors require the CRI plugin registry config_path %s, which is only set from Kubernetes 1.22", RegistryConfigPath))
+		}
+	}
+	errs = append(errs, c.applyRuntimeHandlers(o.RuntimeHandlers)...)
+	errs = append(errs, c.applySnapshotterOptions(o.SnapshotterOptions)...)
+	return errors.Join(errs...)
+}
+
+func (c *Config) applyRuntimeHandlers(handlers map[string]*Runtime) []error {
+	if len(handlers) == 0 {
+		return nil
+	}
+	configs := c.criContainerdConfigs()
+	if len(configs) == 0 {
+		return []error{errors.New("runtime handlers require the CRI plugin")}
+	}
+	var errs []error
+	for _, name := range sortedKeys(handlers) {
+		handler := handlers[name]
+		switch {
+		case !runtimeHandlerNamePattern.MatchString(name) || len(name) > 63:
+			errs = append(errs, fmt.Errorf("runtime handler name %q is not a valid RFC 1123 label", name))
+			continue
+		case handler == nil || handler.RuntimeType == "":
+			errs = append(errs, fmt.Errorf("runtime handler %q has no runtime type", name))
+			continue
+		}
+		if isConfigured(configs, name) {
+			errs = append(errs, fmt.Errorf("runtime handler %q is configured by AKS and cannot be overridden", name))
+			continue
+		}
+		target := configs[0]
+		if target.Runtimes == nil {
+			target.Runtimes = map[string]*Runtime{}
+		}
+		target.Runtimes[name] = handler
+	}
+	return errs
+}
+
+func isConfigured(configs []*CRIContainerdConfig, name string) bool {
+	for _, config := range configs {
+		if _, ok := config.Runtimes[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) applySnapshotterOptions(snapshotters map[string]map[string]any) []error {
+	var errs []error
+	for _, name := range sortedKeys(snapshotters) {
+		if !snapshotterNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("snapshotter name %q is invalid", name))
+			continue
+		}
+		pluginID := SnapshotterPluginIDPrefix + name
+		options, ok := c.Plugins[pluginID].(map[string]any)
+		if !ok {
+			if c.Plugins[pluginID] != nil {
+				errs = append(errs, fmt.Errorf("the options of snapshotter %q cannot be merged", name))
+				continue
+			}
+			options = map[string]any{}
+		}
+		for key, value := range snapshotters[name] {
+			if key == "" {
+				errs = append(errs, fmt.Errorf("snapshotter %q has an option without name", name))
+				continue
+			}
+			options[key] = value
+		}
+		if c.Plugins == nil {
+			c.Plugins = map[string]any{}
+		}
+		c.Plugins[pluginID] = options
+	}
+	return errs
+}
+
+// HostsFiles renders the hosts.toml file of each registry mirror, keyed by registry host. The files belong
+// to RegistryConfigPath/<registry host>/hosts.toml.
+func (o *Overlay) HostsFiles() (map[string][]byte, error) {
+	if o == nil || len(o.RegistryMirrors) == 0 {
+		return nil, nil
+	}
+	files := make(map[string][]byte, len(o.RegistryMirrors))
+	var errs []error
+	for _, host := range sortedKeys(o.RegistryMirrors) {
+		content, err := hostsFile(host, o.RegistryMirrors[host])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		files[host] = content
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return files, nil
+}
+
+func hostsFile(host string, mirror *RegistryMirror) ([]byte, error) {
+	if host != DefaultRegistryHost && !registryHostPattern.MatchString(host) {
+		return nil, fmt.Errorf("registry host %q is invalid", host)
+	}
+	if mirror == nil || len(mirror.Endpoints) == 0 {
+		return nil, fmt.Errorf("registry mirror of %q has no endpoint", host)
+	}
+	capabilities := mirror.Capabilities
+	if len(capabilities) == 0 {
+		capabilities = []string{"pull", "resolve"}
+	}
+	for _, capability := range capabilities {
+		if capability != "pull" && capability != "resolve" && capability != "push" {
+			return nil, fmt.Errorf("registry mirror of %q has unknown capability %q", host, capability)
+		}
+	}
+
+	// The mirrors are tried in the order of the host tables, so they are written one by one rather than as a map.
+	e := &encoder{}
+	for _, endpoint := range mirror.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("registry mirror endpoint %q of %q is not a http(s) URL", endpoint, host)
+		}
+		config := hostConfig{Capabilities: capabilities, SkipVerify: mirror.SkipVerify, OverridePath: mirror.OverridePath}
+		if err := e.subTable([]string{"host", endpoint}, reflect.ValueOf(config), 0); err != nil {
+			return nil, err
+		}
+	}
+	return e.buf.Bytes(), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
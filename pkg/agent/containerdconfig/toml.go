@@ -0,0 +1,232 @@
+package containerdconfig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bareKeyPattern matches the TOML keys which don't need to be quoted.
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+const indentUnit = "  "
+
+// Marshal renders v, a struct or a map with string keys, as a TOML document. Struct fields are named by their
+// toml tag and written in declaration order, map keys are sorted. Nil values and empty fields tagged omitempty
+// are skipped.
+//
+// Unlike general purpose TOML encoders, it writes double quoted strings and indents the tables like the config
+// files shipped by containerd, and it skips the headers of the tables which only hold other tables.
+func Marshal(v any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.table(nil, reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+type entry struct {
+	key   string
+	value reflect.Value
+}
+
+// table writes the key/values of the table at path, then its sub-tables.
+func (e *encoder) table(path []string, v reflect.Value, indent int) error {
+	values, tables, err := tableEntries(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+	for _, kv := range values {
+		s, err := encodeValue(kv.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(append(path, kv.key), "."), err)
+		}
+		fmt.Fprintf(&e.buf, "%s%s = %s\n", strings.Repeat(indentUnit, indent), quoteKey(kv.key), s)
+	}
+	for _, t := range tables {
+		if err := e.subTable(append(path[:len(path):len(path)], t.key), t.value, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subTable writes the header of the table at path, unless the table only holds other tables, then its content.
+func (e *encoder) subTable(path []string, v reflect.Value, indent int) error {
+	values, tables, err := tableEntries(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+	if len(values) > 0 || len(tables) == 0 {
+		keys := make([]string, 0, len(path))
+		for _, key := range path {
+			keys = append(keys, quoteKey(key))
+		}
+		fmt.Fprintf(&e.buf, "%s[%s]\n", strings.Repeat(indentUnit, indent), strings.Join(keys, "."))
+		indent++
+	}
+	return e.table(path, v, indent)
+}
+
+// tableEntries splits the entries of a struct or map into its plain values and its sub-tables.
+func tableEntries(v reflect.Value) (values, tables []entry, err error) {
+	v = indirect(v)
+	var entries []entry
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fv := v.Field(i)
+			if opts == "omitempty" && isEmpty(fv) {
+				continue
+			}
+			entries = append(entries, entry{key: name, value: fv})
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, nil, fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entries = append(entries, entry{key: k, value: v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key()))})
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported table type %s", v.Kind())
+	}
+
+	for _, en := range entries {
+		switch {
+		case isNil(en.value):
+			// TOML has no null value.
+		case isTable(en.value):
+			tables = append(tables, en)
+		default:
+			values = append(values, en)
+		}
+	}
+	return values, tables, nil
+}
+
+func encodeValue(v reflect.Value) (string, error) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		return quoteString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if isTable(v.Index(i)) {
+				return "", fmt.Errorf("arrays of tables are not supported")
+			}
+			s, err := encodeValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, s)
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	default:
+		return "", fmt.Errorf("unsupported value type %s", v.Kind())
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	default:
+		return !v.IsValid()
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func isTable(v reflect.Value) bool {
+	kind := indirect(v).Kind()
+	return kind == reflect.Struct || kind == reflect.Map
+}
+
+func quoteKey(key string) string {
+	if bareKeyPattern.MatchString(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// quoteString returns s as a TOML basic string.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,875 @@
+package datamodel
+
+import (
+	"sort"
+)
+
+// OSFamily is the operating system family of a distro.
+type OSFamily string
+
+const (
+	OSFamilyUbuntu     OSFamily = "Ubuntu"
+	OSFamilyAzureLinux OSFamily = "AzureLinux" // includes CBL-Mariner, the former name of Azure Linux.
+	OSFamilyFlatcar    OSFamily = "Flatcar"
+	OSFamilyACL        OSFamily = "ACL"
+	OSFamilyWindows    OSFamily = "Windows"
+	// OSFamilyCustom is used by customized Linux images, whose OS is not known to AgentBaker.
+	OSFamilyCustom OSFamily = "Custom"
+)
+
+// Arch is the CPU architecture of a distro.
+type Arch string
+
+const (
+	ArchAmd64 Arch = "amd64"
+	ArchArm64 Arch = "arm64"
+)
+
+// ImageSource is where the images of a distro are published.
+type ImageSource string
+
+const (
+	// ImageSourceSIG images are published to an AKS shared image gallery.
+	ImageSourceSIG ImageSource = "SIG"
+	// ImageSourceMarketplace images are published to the platform image repository.
+	ImageSourceMarketplace ImageSource = "Marketplace"
+	// ImageSourceCustom images are provided by the user.
+	ImageSourceCustom ImageSource = "Custom"
+)
+
+// DistroCapabilities declares the properties of the image used by a distro.
+// Zero values mean "not applicable" or "unknown", e.g. for customized images.
+type DistroCapabilities struct {
+	OSFamily OSFamily
+	// OSVersion is the version of the OS family, e.g. "22.04" for Ubuntu, "3.0" for Azure Linux, "2022" for Windows.
+	OSVersion string
+	Arch      Arch
+	// HyperVGeneration is 1 or 2.
+	HyperVGeneration int
+	// CgroupVersion is 1 or 2.
+	CgroupVersion int
+	// ContainerdMajorVersion is the major version of containerd shipped on the image, 0 if it does not use containerd.
+	ContainerdMajorVersion int
+	ImageSource            ImageSource
+	// Gallery is the default shared image gallery name of the distro, empty if ImageSource is not ImageSourceSIG.
+	Gallery string
+	// VHD is true if the distro uses an image built by AKS with all components cached.
+	VHD            bool
+	FIPS           bool
+	TrustedLaunch  bool
+	Kata           bool
+	ConfidentialVM bool
+	EdgeZone       bool
+	OSGuard        bool
+}
+
+// distroCapabilities is the declarative capability table of every distro.
+// Adding a new image SKU should only require a new Distro constant and a new entry in this table.
+//
+//nolint:gochecknoglobals
+var distroCapabilities = map[Distro]DistroCapabilities{
+	Ubuntu: {
+		OSFamily:    OSFamilyUbuntu,
+		Arch:        ArchAmd64,
+		ImageSource: ImageSourceMarketplace,
+	},
+	AKSUbuntuFipsContainerd2004: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "20.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSUbuntuFipsContainerd2004Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "20.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSUbuntuContainerd2004CVMGen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "20.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		ConfidentialVM:         true,
+	},
+	AKSUbuntuFipsContainerd2204: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSUbuntuFipsContainerd2204Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSUbuntuFipsContainerd2204TLGen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+		TrustedLaunch:          true,
+	},
+	AKSUbuntuEdgeZoneContainerd2204: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSUbuntuEdgeZoneContainerd2204Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSUbuntuEdgeZoneContainerd2404: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSUbuntuEdgeZoneContainerd2404Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSUbuntuContainerd2204: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuContainerd2204Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuArm64Containerd2204Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuArm64Containerd2404Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuArm64GB200Containerd2404Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuContainerd2404CVMGen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		ConfidentialVM:         true,
+	},
+	AKSUbuntuContainerd2204TLGen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSUbuntuEgressContainerd2204Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "22.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+	},
+	AKSUbuntuContainerd2404: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuContainerd2404Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuContainerd2404TLGen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "24.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSUbuntuMinimalContainerd2604Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "26.04",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSUbuntuMinimalArm64Containerd2604Gen2: {
+		OSFamily:               OSFamilyUbuntu,
+		OSVersion:              "26.04",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSUbuntuGalleryName,
+		VHD:                    true,
+	},
+	AKSCBLMarinerV1: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "1.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+	},
+	AKSCBLMarinerV2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+	},
+	AKSCBLMarinerV2Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+	},
+	AKSCBLMarinerV2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSCBLMarinerV2Gen2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSCBLMarinerV2Gen2Kata: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+		Kata:                   true,
+	},
+	AKSCBLMarinerV2Gen2TL: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSCBLMarinerV2KataGen2TL: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+		Kata:                   true,
+	},
+	AKSCBLMarinerV2Arm64Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSCBLMarinerGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV2Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSAzureLinuxV2Gen2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSAzureLinuxV2Gen2Kata: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		Kata:                   true,
+	},
+	AKSAzureLinuxV2Gen2TL: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSAzureLinuxV2Arm64Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "2.0",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV3: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV3Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV3FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSAzureLinuxV3Gen2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSAzureLinuxV3Gen2Kata: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		Kata:                   true,
+	},
+	AKSAzureLinuxV3Gen2TL: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSAzureLinuxV3Arm64Gen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+	},
+	AKSAzureLinuxV3Arm64Gen2FIPS: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+	},
+	AKSAzureLinuxV3CVMGen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		ConfidentialVM:         true,
+	},
+	AKSAzureLinuxV3OSGuardGen2FIPSTL: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+		TrustedLaunch:          true,
+		OSGuard:                true,
+	},
+	AKSAzureLinuxV3EdgeZone: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSAzureLinuxV3EdgeZoneGen2: {
+		OSFamily:               OSFamilyAzureLinux,
+		OSVersion:              "3.0",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxEdgeZoneGalleryName,
+		VHD:                    true,
+		EdgeZone:               true,
+	},
+	AKSFlatcarGen2: {
+		OSFamily:               OSFamilyFlatcar,
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSFlatcarGalleryName,
+		VHD:                    true,
+	},
+	AKSFlatcarArm64Gen2: {
+		OSFamily:               OSFamilyFlatcar,
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSFlatcarGalleryName,
+		VHD:                    true,
+	},
+	AKSACLGen2TL: {
+		OSFamily:               OSFamilyACL,
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSACLArm64Gen2TL: {
+		OSFamily:               OSFamilyACL,
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		TrustedLaunch:          true,
+	},
+	AKSACLGen2FIPSTL: {
+		OSFamily:               OSFamilyACL,
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+		TrustedLaunch:          true,
+	},
+	AKSACLArm64Gen2FIPSTL: {
+		OSFamily:               OSFamilyACL,
+		Arch:                   ArchArm64,
+		HyperVGeneration:       2,
+		CgroupVersion:          2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSAzureLinuxGalleryName,
+		VHD:                    true,
+		FIPS:                   true,
+		TrustedLaunch:          true,
+	},
+	AKSWindows2019: {
+		OSFamily:         OSFamilyWindows,
+		OSVersion:        "2019",
+		Arch:             ArchAmd64,
+		HyperVGeneration: 1,
+		ImageSource:      ImageSourceSIG,
+		Gallery:          AKSWindowsGalleryName,
+	},
+	AKSWindows2019Containerd: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2019",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows2022Containerd: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2022",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows2022ContainerdGen2: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2022",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows23H2: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "23H2",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows23H2Gen2: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "23H2",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		ContainerdMajorVersion: 1,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows2025: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2025",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       1,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows2025Gen2: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2025",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+	},
+	AKSWindows2025Gen2TL: {
+		OSFamily:               OSFamilyWindows,
+		OSVersion:              "2025",
+		Arch:                   ArchAmd64,
+		HyperVGeneration:       2,
+		ContainerdMajorVersion: 2,
+		ImageSource:            ImageSourceSIG,
+		Gallery:                AKSWindowsGalleryName,
+		TrustedLaunch:          true,
+	},
+	AKSWindows2019PIR: {
+		OSFamily:         OSFamilyWindows,
+		OSVersion:        "2019",
+		Arch:             ArchAmd64,
+		HyperVGeneration: 1,
+		ImageSource:      ImageSourceMarketplace,
+	},
+	CustomizedImage: {
+		OSFamily:    OSFamilyCustom,
+		ImageSource: ImageSourceCustom,
+	},
+	CustomizedImageKata: {
+		OSFamily:    OSFamilyCustom,
+		ImageSource: ImageSourceCustom,
+		Kata:        true,
+	},
+	CustomizedImageLinuxGuard: {
+		OSFamily:    OSFamilyCustom,
+		ImageSource: ImageSourceCustom,
+	},
+	CustomizedImageTrustedLaunch: {
+		OSFamily:      OSFamilyCustom,
+		ImageSource:   ImageSourceCustom,
+		TrustedLaunch: true,
+	},
+	CustomizedWindowsOSImage: {
+		OSFamily:    OSFamilyWindows,
+		ImageSource: ImageSourceCustom,
+	},
+}
+
+// Capabilities returns the capabilities of the distro, and false if the distro is not registered.
+func (d Distro) Capabilities() (DistroCapabilities, bool) {
+	c, ok := distroCapabilities[d]
+	return c, ok
+}
+
+// RegisteredDistros returns all the distros with registered capabilities, sorted by name.
+func RegisteredDistros() []Distro {
+	return DistrosWhere(func(Distro, DistroCapabilities) bool { return true })
+}
+
+// DistrosWhere returns the registered distros matching the predicate, sorted by name.
+func DistrosWhere(predicate func(Distro, DistroCapabilities) bool) []Distro {
+	var distros []Distro
+	for d, c := range distroCapabilities {
+		if predicate(d, c) {
+			distros = append(distros, d)
+		}
+	}
+	sort.Slice(distros, func(i, j int) bool { return distros[i] < distros[j] })
+	return distros
+}
+
+// matches reports whether the distro is registered and its capabilities match the predicate.
+func (d Distro) matches(predicate func(DistroCapabilities) bool) bool {
+	c, ok := distroCapabilities[d]
+	return ok && predicate(c)
+}
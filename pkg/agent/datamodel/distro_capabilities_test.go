@@ -0,0 +1,110 @@
+package datamodel
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// declaredDistros returns the names and values of all Distro constants declared in types.go.
+func declaredDistros() map[string]Distro {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "types.go", nil, 0)
+	Expect(err).NotTo(HaveOccurred())
+
+	distros := map[string]Distro{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value, ok := spec.(*ast.ValueSpec)
+			if !ok || value.Type == nil {
+				continue
+			}
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Distro" {
+				continue
+			}
+			for i, name := range value.Names {
+				lit, ok := value.Values[i].(*ast.BasicLit)
+				Expect(ok).To(BeTrue(), "distro %s is not a string literal", name.Name)
+				distros[name.Name] = Distro(lit.Value[1 : len(lit.Value)-1])
+			}
+		}
+	}
+	return distros
+}
+
+var _ = Describe("Distro capabilities", func() {
+	It("should register every Distro constant", func() {
+		declared := declaredDistros()
+		Expect(declared).NotTo(BeEmpty())
+		for name, distro := range declared {
+			_, ok := distro.Capabilities()
+			Expect(ok).To(BeTrue(), "distro constant %s (%s) is missing from distroCapabilities", name, distro)
+		}
+		Expect(RegisteredDistros()).To(HaveLen(len(declared)))
+	})
+
+	It("should declare consistent capabilities", func() {
+		for _, distro := range RegisteredDistros() {
+			c, _ := distro.Capabilities()
+			Expect(c.OSFamily).NotTo(BeEmpty(), "distro %s", distro)
+			Expect(c.ImageSource).NotTo(BeEmpty(), "distro %s", distro)
+			Expect(c.HyperVGeneration).To(BeNumerically("<=", 2), "distro %s", distro)
+			Expect(c.CgroupVersion).To(BeNumerically("<=", 2), "distro %s", distro)
+			if c.ImageSource != ImageSourceSIG {
+				Expect(c.Gallery).To(BeEmpty(), "distro %s", distro)
+				Expect(c.VHD).To(BeFalse(), "distro %s", distro)
+			}
+			if c.ImageSource != ImageSourceCustom {
+				Expect(c.Arch).NotTo(BeEmpty(), "distro %s", distro)
+			}
+		}
+	})
+
+	It("should match the gallery of the compiled-in SIG image configs", func() {
+		sigConfigs := getDefaultSIGImageConfigMap()
+		for _, distro := range RegisteredDistros() {
+			c, _ := distro.Capabilities()
+			config, hasSIGConfig := sigConfigs[distro]
+			if c.ImageSource != ImageSourceSIG {
+				Expect(hasSIGConfig).To(BeFalse(), "distro %s is not a SIG distro but has a SIG image config", distro)
+				continue
+			}
+			Expect(hasSIGConfig).To(BeTrue(), "SIG distro %s has no SIG image config", distro)
+			Expect(config.Gallery).To(Equal(c.Gallery), "distro %s", distro)
+		}
+	})
+
+	It("should query distros by capability", func() {
+		Expect(DistrosWhere(func(_ Distro, c DistroCapabilities) bool {
+			return c.OSFamily == OSFamilyUbuntu && c.Arch == ArchArm64 && c.OSVersion == "24.04"
+		})).To(Equal([]Distro{AKSUbuntuArm64Containerd2404Gen2, AKSUbuntuArm64GB200Containerd2404Gen2}))
+
+		Expect(AvailableACLDistros).To(ConsistOf(AKSACLGen2TL, AKSACLArm64Gen2TL, AKSACLGen2FIPSTL, AKSACLArm64Gen2FIPSTL))
+		Expect(AvailableWindowsSIGDistros).To(ContainElement(CustomizedWindowsOSImage))
+		Expect(AvailableWindowsSIGDistros).NotTo(ContainElement(AKSWindows2019PIR))
+
+		// the egress distro was never in the containerd and gen2 lists, deriving them must not change that.
+		Expect(AKSUbuntuEgressContainerd2204Gen2.IsContainerdDistro()).To(BeFalse())
+		Expect(AKSUbuntuEgressContainerd2204Gen2.IsGen2Distro()).To(BeFalse())
+
+		_, ok := Distro("not-a-distro").Capabilities()
+		Expect(ok).To(BeFalse())
+		Expect(Distro("not-a-distro").IsVHDDistro()).To(BeFalse())
+	})
+
+	It("should only report containerd v2 for non-kata Linux distros shipping containerd 2", func() {
+		Expect((&AgentPoolProfile{Distro: AKSAzureLinuxV3Gen2}).IsContainerdV2Distro()).To(BeTrue())
+		Expect((&AgentPoolProfile{Distro: AKSUbuntuContainerd2404Gen2}).IsContainerdV2Distro()).To(BeTrue())
+		Expect((&AgentPoolProfile{Distro: AKSACLGen2TL}).IsContainerdV2Distro()).To(BeTrue())
+		Expect((&AgentPoolProfile{Distro: AKSAzureLinuxV3Gen2Kata}).IsContainerdV2Distro()).To(BeFalse())
+		Expect((&AgentPoolProfile{Distro: AKSUbuntuContainerd2204Gen2}).IsContainerdV2Distro()).To(BeFalse())
+		Expect((&AgentPoolProfile{Distro: AKSWindows2025Gen2}).IsContainerdV2Distro()).To(BeFalse())
+	})
+})
@@ -0,0 +1,164 @@
+package datamodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxSIGConfigSourceSize bounds the size of a SIG image catalog or replication manifest fetched from a URL.
+const maxSIGConfigSourceSize = 4 << 20
+
+// sigConfigSourceClient fetches SIG image catalogs and replication manifests. The timeout keeps a slow endpoint from
+// hanging apiserver startup when the caller's context has no deadline.
+var sigConfigSourceClient = &http.Client{Timeout: 30 * time.Second}
+
+// SIGImageCatalogEntry overrides the compiled-in SIG image reference of a single distro.
+// Empty fields keep the compiled-in value.
+type SIGImageCatalogEntry struct {
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+	Gallery       string `json:"gallery,omitempty"`
+	Definition    string `json:"definition,omitempty"`
+	Version       string `json:"version,omitempty"`
+}
+
+// SIGImageCatalog is a runtime-configurable distro -> SIG image mapping which is layered on top of
+// the compiled-in SIG image config templates (LinuxSIGImageVersion and the Frozen*SIGImageVersion constants).
+// It allows image pins to be managed operationally, without building a new AgentBaker.
+type SIGImageCatalog struct {
+	Images map[Distro]SIGImageCatalogEntry `json:"images"`
+}
+
+// activeSIGImageCatalog is the catalog applied by GetSIGAzureCloudSpecConfig and GetMaintainedLinuxSIGImageConfigMap.
+// A nil catalog means the compiled-in values are used as is.
+//
+//nolint:gochecknoglobals
+var activeSIGImageCatalog atomic.Pointer[SIGImageCatalog]
+
+// SetSIGImageCatalog atomically replaces the active SIG image catalog. Passing nil restores the compiled-in defaults.
+func SetSIGImageCatalog(catalog *SIGImageCatalog) {
+	activeSIGImageCatalog.Store(catalog)
+}
+
+// GetSIGImageCatalog returns the active SIG image catalog, or nil when only compiled-in defaults are used.
+func GetSIGImageCatalog() *SIGImageCatalog {
+	return activeSIGImageCatalog.Load()
+}
+
+// ParseSIGImageCatalog parses and validates a JSON SIG image catalog.
+func ParseSIGImageCatalog(contents []byte) (*SIGImageCatalog, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+
+	var catalog SIGImageCatalog
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse SIG image catalog: %w", err)
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// Validate checks that every catalog entry refers to a distro with a compiled-in SIG image config,
+// and that it overrides at least one field.
+func (c *SIGImageCatalog) Validate() error {
+	known := getDefaultSIGImageConfigMap()
+	var errs []string
+	for distro, entry := range c.Images {
+		if _, ok := known[distro]; !ok {
+			errs = append(errs, fmt.Sprintf("distro %q has no SIG image config", distro))
+			continue
+		}
+		if entry == (SIGImageCatalogEntry{}) {
+			errs = append(errs, fmt.Sprintf("distro %q does not override any field", distro))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid SIG image catalog: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// applyTo overrides the SIG image configs of the given map with the catalog entries, in place.
+func (c *SIGImageCatalog) applyTo(configs map[Distro]SigImageConfig) {
+	if c == nil {
+		return
+	}
+	for distro, config := range configs {
+		entry, ok := c.Images[distro]
+		if !ok {
+			continue
+		}
+		if entry.ResourceGroup != "" {
+			config.ResourceGroup = entry.ResourceGroup
+		}
+		if entry.Gallery != "" {
+			config.Gallery = entry.Gallery
+		}
+		if entry.Definition != "" {
+			config.Definition = entry.Definition
+		}
+		if entry.Version != "" {
+			config.Version = entry.Version
+		}
+		configs[distro] = config
+	}
+}
+
+// LoadSIGImageCatalog reads a SIG image catalog from a local file path or from an http(s) URL, and validates it.
+func LoadSIGImageCatalog(ctx context.Context, source string) (*SIGImageCatalog, error) {
+	contents, err := readSIGConfigSource(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SIG image catalog from %s: %w", source, err)
+	}
+	return ParseSIGImageCatalog(contents)
+}
+
+// readSIGConfigSource reads the content of a local file path or of an http(s) URL.
+func readSIGConfigSource(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sigConfigSourceClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSIGConfigSourceSize))
+}
+
+// getDefaultSIGImageConfigMap returns the compiled-in SIG image configs of all distros, without any option applied.
+func getDefaultSIGImageConfigMap() map[Distro]SigImageConfig {
+	all := map[Distro]SigImageConfig{}
+	for _, m := range []map[Distro]SigImageConfig{
+		getSigUbuntuImageConfigMapWithOpts(),
+		getSigCBLMarinerImageConfigMapWithOpts(),
+		getSigAzureLinuxImageConfigMapWithOpts(),
+		getSigFlatcarImageConfigMapWithOpts(),
+		getSigWindowsImageConfigMapWithOpts(),
+		getSigUbuntuEdgeZoneImageConfigMapWithOpts(),
+		getSigAzureLinuxEdgeZoneImageConfigMapWithOpts(),
+	} {
+		for distro, config := range m {
+			all[distro] = config
+		}
+	}
+	return all
+}
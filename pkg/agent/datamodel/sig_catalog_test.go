@@ -0,0 +1,150 @@
+package datamodel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SIGImageCatalog", func() {
+	const catalogJSON = `{
+  "images": {
+    "aks-cblmariner-v2-gen2": {"version": "202601.01.0"},
+    "aks-ubuntu-containerd-22.04-gen2": {"definition": "2204gen2containerdv2", "version": "202601.02.0"}
+  }
+}`
+
+	var sigConfig SIGConfig
+
+	BeforeEach(func() {
+		sigConfig = SIGConfig{
+			TenantID:       "sometenantid",
+			SubscriptionID: "somesubid",
+			Galleries: map[string]SIGGalleryConfig{
+				"AKSUbuntu":     {GalleryName: "aksubuntu", ResourceGroup: "resourcegroup"},
+				"AKSCBLMariner": {GalleryName: "akscblmariner", ResourceGroup: "resourcegroup"},
+				"AKSAzureLinux": {GalleryName: "aksazurelinux", ResourceGroup: "resourcegroup"},
+				"AKSWindows":    {GalleryName: "AKSWindows", ResourceGroup: "AKS-Windows"},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		SetSIGImageCatalog(nil)
+	})
+
+	Context("ParseSIGImageCatalog", func() {
+		It("should parse a valid catalog", func() {
+			catalog, err := ParseSIGImageCatalog([]byte(catalogJSON))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalog.Images).To(HaveLen(2))
+			Expect(catalog.Images[AKSCBLMarinerV2Gen2].Version).To(Equal("202601.01.0"))
+		})
+
+		It("should reject distros without a SIG image config", func() {
+			_, err := ParseSIGImageCatalog([]byte(`{"images": {"not-a-distro": {"version": "1"}, "CustomizedImage": {"version": "1"}}}`))
+			Expect(err).To(MatchError(ContainSubstring(`distro "not-a-distro" has no SIG image config`)))
+			Expect(err).To(MatchError(ContainSubstring(`distro "CustomizedImage" has no SIG image config`)))
+		})
+
+		It("should reject empty entries and unknown fields", func() {
+			_, err := ParseSIGImageCatalog([]byte(`{"images": {"aks-azurelinux-v3": {}}}`))
+			Expect(err).To(MatchError(ContainSubstring("does not override any field")))
+
+			_, err = ParseSIGImageCatalog([]byte(`{"images": {"aks-azurelinux-v3": {"verison": "1"}}}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when a catalog is active", func() {
+		BeforeEach(func() {
+			catalog, err := ParseSIGImageCatalog([]byte(catalogJSON))
+			Expect(err).NotTo(HaveOccurred())
+			SetSIGImageCatalog(catalog)
+		})
+
+		It("should override the compiled-in values in GetSIGAzureCloudSpecConfig", func() {
+			config, err := GetSIGAzureCloudSpecConfig(sigConfig, "westus")
+			Expect(err).NotTo(HaveOccurred())
+
+			mariner := config.SigCBLMarinerImageConfig[AKSCBLMarinerV2Gen2]
+			Expect(mariner.Version).To(Equal("202601.01.0"))
+			Expect(mariner.Gallery).To(Equal("akscblmariner"))
+			Expect(mariner.Definition).To(Equal("V2gen2"))
+
+			ubuntu := config.SigUbuntuImageConfig[AKSUbuntuContainerd2204Gen2]
+			Expect(ubuntu.Version).To(Equal("202601.02.0"))
+			Expect(ubuntu.Definition).To(Equal("2204gen2containerdv2"))
+			Expect(ubuntu.SubscriptionID).To(Equal("somesubid"))
+
+			Expect(config.SigAzureLinuxImageConfig[AKSAzureLinuxV3].Version).To(Equal(LinuxSIGImageVersion))
+		})
+
+		It("should not report pinned distros as maintained", func() {
+			maintained := GetMaintainedLinuxSIGImageConfigMap()
+			Expect(maintained).NotTo(HaveKey(AKSUbuntuContainerd2204Gen2))
+			Expect(maintained).To(HaveKey(AKSUbuntuContainerd2404Gen2))
+		})
+
+		It("should restore the compiled-in values when reset", func() {
+			SetSIGImageCatalog(nil)
+			config, err := GetSIGAzureCloudSpecConfig(sigConfig, "westus")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.SigUbuntuImageConfig[AKSUbuntuContainerd2204Gen2].Version).To(Equal(LinuxSIGImageVersion))
+		})
+	})
+
+	Context("LoadSIGImageCatalog", func() {
+		It("should load a catalog from a file", func() {
+			dir, err := os.MkdirTemp("", "sigcatalog")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "catalog.json")
+			Expect(os.WriteFile(path, []byte(catalogJSON), 0600)).To(Succeed())
+
+			catalog, err := LoadSIGImageCatalog(context.Background(), path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalog.Images).To(HaveLen(2))
+		})
+
+		It("should load a catalog from a URL", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(catalogJSON))
+			}))
+			defer server.Close()
+
+			catalog, err := LoadSIGImageCatalog(context.Background(), server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalog.Images).To(HaveKey(AKSCBLMarinerV2Gen2))
+		})
+
+		It("should fail on a non-OK response", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			defer server.Close()
+
+			_, err := LoadSIGImageCatalog(context.Background(), server.URL)
+			Expect(err).To(MatchError(ContainSubstring("unexpected status code 404")))
+		})
+
+		It("should time out on a slow endpoint", func() {
+			done := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+				<-done
+			}))
+			defer server.Close()
+			defer close(done)
+			defaultClient := sigConfigSourceClient
+			sigConfigSourceClient = &http.Client{Timeout: 10 * time.Millisecond}
+			defer func() { sigConfigSourceClient = defaultClient }()
+
+			_, err := LoadSIGImageCatalog(context.Background(), server.URL)
+			Expect(err).To(MatchError(ContainSubstring("Client.Timeout exceeded")))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -77,274 +78,63 @@ func GetCloudTargetEnv(location string) string {
 	}
 }
 
+// The distro lists below are derived from the distro capability table, see distroCapabilities.
+//
 //nolint:gochecknoglobals
-var AvailableUbuntu2004Distros = []Distro{
-	AKSUbuntuContainerd2004CVMGen2,
-	AKSUbuntuFipsContainerd2004,
-	AKSUbuntuFipsContainerd2004Gen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableUbuntu2204Distros = []Distro{
-	AKSUbuntuContainerd2204,
-	AKSUbuntuContainerd2204Gen2,
-	AKSUbuntuArm64Containerd2204Gen2,
-	AKSUbuntuContainerd2204TLGen2,
-	AKSUbuntuEdgeZoneContainerd2204,
-	AKSUbuntuEdgeZoneContainerd2204Gen2,
-	AKSUbuntuFipsContainerd2204,
-	AKSUbuntuFipsContainerd2204Gen2,
-	AKSUbuntuFipsContainerd2204TLGen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableUbuntu2404Distros = []Distro{
-	AKSUbuntuContainerd2404,
-	AKSUbuntuContainerd2404Gen2,
-	AKSUbuntuArm64Containerd2404Gen2,
-	AKSUbuntuArm64GB200Containerd2404Gen2,
-	AKSUbuntuContainerd2404CVMGen2,
-	AKSUbuntuContainerd2404TLGen2,
-	AKSUbuntuEdgeZoneContainerd2404,
-	AKSUbuntuEdgeZoneContainerd2404Gen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableUbuntu2604Distros = []Distro{
-	AKSUbuntuMinimalContainerd2604Gen2,
-	AKSUbuntuMinimalArm64Containerd2604Gen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableContainerdDistros = []Distro{
-	AKSUbuntuFipsContainerd2004,
-	AKSUbuntuFipsContainerd2004Gen2,
-	AKSUbuntuFipsContainerd2204,
-	AKSUbuntuFipsContainerd2204Gen2,
-	AKSUbuntuFipsContainerd2204TLGen2,
-	AKSFlatcarGen2,
-	AKSFlatcarArm64Gen2,
-	AKSACLGen2TL,
-	AKSACLArm64Gen2TL,
-	AKSACLGen2FIPSTL,
-	AKSACLArm64Gen2FIPSTL,
-	AKSCBLMarinerV1,
-	AKSCBLMarinerV2,
-	AKSAzureLinuxV2,
-	AKSAzureLinuxV3,
-	AKSCBLMarinerV2Gen2,
-	AKSAzureLinuxV2Gen2,
-	AKSAzureLinuxV3Gen2,
-	AKSCBLMarinerV2FIPS,
-	AKSAzureLinuxV2FIPS,
-	AKSAzureLinuxV3FIPS,
-	AKSCBLMarinerV2Gen2FIPS,
-	AKSAzureLinuxV2Gen2FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSCBLMarinerV2Gen2Kata,
-	AKSAzureLinuxV2Gen2Kata,
-	AKSAzureLinuxV3Gen2Kata,
-	AKSCBLMarinerV2Gen2TL,
-	AKSAzureLinuxV2Gen2TL,
-	AKSAzureLinuxV3Gen2TL,
-	AKSCBLMarinerV2KataGen2TL,
-	AKSUbuntuArm64Containerd2204Gen2,
-	AKSUbuntuArm64Containerd2404Gen2,
-	AKSUbuntuArm64GB200Containerd2404Gen2,
-	AKSUbuntuContainerd2404CVMGen2,
-	AKSCBLMarinerV2Arm64Gen2,
-	AKSAzureLinuxV2Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSUbuntuContainerd2204,
-	AKSUbuntuContainerd2204Gen2,
-	AKSUbuntuContainerd2004CVMGen2,
-	AKSUbuntuContainerd2204TLGen2,
-	AKSUbuntuEdgeZoneContainerd2204,
-	AKSUbuntuEdgeZoneContainerd2204Gen2,
-	AKSUbuntuEdgeZoneContainerd2404,
-	AKSUbuntuEdgeZoneContainerd2404Gen2,
-	AKSAzureLinuxV3EdgeZone,
-	AKSAzureLinuxV3EdgeZoneGen2,
-	AKSUbuntuContainerd2404,
-	AKSUbuntuContainerd2404Gen2,
-	AKSAzureLinuxV3CVMGen2,
-	AKSUbuntuContainerd2404TLGen2,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-	AKSUbuntuMinimalContainerd2604Gen2,
-	AKSUbuntuMinimalArm64Containerd2604Gen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableGen2Distros = []Distro{
-	AKSUbuntuFipsContainerd2004Gen2,
-	AKSUbuntuFipsContainerd2204Gen2,
-	AKSUbuntuFipsContainerd2204TLGen2,
-	AKSUbuntuArm64Containerd2204Gen2,
-	AKSUbuntuArm64Containerd2404Gen2,
-	AKSUbuntuArm64GB200Containerd2404Gen2,
-	AKSUbuntuContainerd2404CVMGen2,
-	AKSUbuntuContainerd2204Gen2,
-	AKSUbuntuContainerd2004CVMGen2,
-	AKSUbuntuContainerd2204TLGen2,
-	AKSUbuntuEdgeZoneContainerd2204Gen2,
-	AKSUbuntuEdgeZoneContainerd2404Gen2,
-	AKSAzureLinuxV3EdgeZoneGen2,
-	AKSUbuntuContainerd2404Gen2,
-	AKSUbuntuContainerd2404TLGen2,
-	AKSUbuntuMinimalContainerd2604Gen2,
-	AKSUbuntuMinimalArm64Containerd2604Gen2,
-	AKSFlatcarGen2,
-	AKSFlatcarArm64Gen2,
-	AKSACLGen2TL,
-	AKSACLArm64Gen2TL,
-	AKSACLGen2FIPSTL,
-	AKSACLArm64Gen2FIPSTL,
-	AKSCBLMarinerV2Gen2,
-	AKSAzureLinuxV2Gen2,
-	AKSAzureLinuxV3Gen2,
-	AKSCBLMarinerV2Gen2FIPS,
-	AKSAzureLinuxV2Gen2FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSCBLMarinerV2Gen2Kata,
-	AKSAzureLinuxV2Gen2Kata,
-	AKSAzureLinuxV3Gen2Kata,
-	AKSCBLMarinerV2Gen2TL,
-	AKSAzureLinuxV2Gen2TL,
-	AKSAzureLinuxV3Gen2TL,
-	AKSCBLMarinerV2KataGen2TL,
-	AKSCBLMarinerV2Arm64Gen2,
-	AKSAzureLinuxV2Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSAzureLinuxV3CVMGen2,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-}
-
-//nolint:gochecknoglobals
-var AvailableAzureLinuxDistros = []Distro{
-	AKSCBLMarinerV1,
-	AKSCBLMarinerV2,
-	AKSAzureLinuxV2,
-	AKSAzureLinuxV3,
-	AKSCBLMarinerV2Gen2,
-	AKSAzureLinuxV2Gen2,
-	AKSAzureLinuxV3Gen2,
-	AKSCBLMarinerV2FIPS,
-	AKSAzureLinuxV2FIPS,
-	AKSAzureLinuxV3FIPS,
-	AKSCBLMarinerV2Gen2FIPS,
-	AKSAzureLinuxV2Gen2FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSCBLMarinerV2Gen2Kata,
-	AKSAzureLinuxV2Gen2Kata,
-	AKSAzureLinuxV3Gen2Kata,
-	AKSCBLMarinerV2Arm64Gen2,
-	AKSAzureLinuxV2Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSCBLMarinerV2Gen2TL,
-	AKSAzureLinuxV2Gen2TL,
-	AKSAzureLinuxV3Gen2TL,
-	AKSCBLMarinerV2KataGen2TL,
-	AKSAzureLinuxV3CVMGen2,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-	AKSAzureLinuxV3EdgeZone,
-	AKSAzureLinuxV3EdgeZoneGen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableAzureLinuxCgroupV2Distros = []Distro{
-	AKSAzureLinuxV2,
-	AKSAzureLinuxV3,
-	AKSAzureLinuxV2Gen2,
-	AKSAzureLinuxV3Gen2,
-	AKSAzureLinuxV2FIPS,
-	AKSAzureLinuxV3FIPS,
-	AKSAzureLinuxV2Gen2FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSCBLMarinerV2Gen2Kata, // Per mheberling, AKSCBLMarinerV2Gen2Kata is equal to AKSAzureLinuxV2Gen2Kata. AKSCBLMarinerV2Gen2Kata is added for now to unblock scenario_kata.
-	AKSAzureLinuxV2Gen2Kata,
-	AKSAzureLinuxV3Gen2Kata,
-	AKSAzureLinuxV2Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSAzureLinuxV2Gen2TL,
-	AKSAzureLinuxV3Gen2TL,
-	AKSAzureLinuxV3CVMGen2,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-	AKSAzureLinuxV3EdgeZone,
-	AKSAzureLinuxV3EdgeZoneGen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableAzureLinuxV3Distros = []Distro{
-	AKSAzureLinuxV3,
-	AKSAzureLinuxV3Gen2,
-	AKSAzureLinuxV3FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSAzureLinuxV3Gen2Kata,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSAzureLinuxV3Gen2TL,
-	AKSAzureLinuxV3CVMGen2,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-	AKSAzureLinuxV3EdgeZone,
-	AKSAzureLinuxV3EdgeZoneGen2,
-}
-
-//nolint:gochecknoglobals
-var AvailableAzureLinuxOSGuardDistros = []Distro{
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-}
+var (
+	AvailableUbuntu2004Distros = ubuntuVHDDistros("20.04")
+	AvailableUbuntu2204Distros = ubuntuVHDDistros("22.04")
+	AvailableUbuntu2404Distros = ubuntuVHDDistros("24.04")
+	AvailableUbuntu2604Distros = ubuntuVHDDistros("26.04")
+
+	AvailableContainerdDistros = DistrosWhere(func(d Distro, c DistroCapabilities) bool {
+		return c.OSFamily != OSFamilyWindows && c.ContainerdMajorVersion > 0 && !slices.Contains(unlistedContainerdGen2Distros, d)
+	})
+	AvailableGen2Distros = DistrosWhere(func(d Distro, c DistroCapabilities) bool {
+		return c.OSFamily != OSFamilyWindows && c.HyperVGeneration == 2 && !slices.Contains(unlistedContainerdGen2Distros, d)
+	})
+	AvailableAzureLinuxDistros = DistrosWhere(func(_ Distro, c DistroCapabilities) bool {
+		return c.OSFamily == OSFamilyAzureLinux
+	})
+	AvailableAzureLinuxCgroupV2Distros = DistrosWhere(func(d Distro, _ DistroCapabilities) bool {
+		return d.IsAzureLinuxCgroupV2VHDDistro()
+	})
+	AvailableAzureLinuxV3Distros = DistrosWhere(func(d Distro, _ DistroCapabilities) bool {
+		return d.IsAzureLinuxV3Distro()
+	})
+	AvailableAzureLinuxOSGuardDistros = DistrosWhere(func(_ Distro, c DistroCapabilities) bool { return c.OSGuard })
+	AvailableFlatcarDistros           = DistrosWhere(func(_ Distro, c DistroCapabilities) bool { return c.OSFamily == OSFamilyFlatcar })
+	AvailableACLDistros               = DistrosWhere(func(_ Distro, c DistroCapabilities) bool { return c.OSFamily == OSFamilyACL })
+)
 
+// unlistedContainerdGen2Distros are containerd gen2 distros which were never in AvailableContainerdDistros and
+// AvailableGen2Distros, so IsContainerdDistro and IsGen2Distro report false for them. They are kept out of the derived
+// lists so that deriving them doesn't change what the baker generates for these distros.
+//
 //nolint:gochecknoglobals
-var AvailableFlatcarDistros = []Distro{
-	AKSFlatcarGen2,
-	AKSFlatcarArm64Gen2,
-}
+var unlistedContainerdGen2Distros = []Distro{AKSUbuntuEgressContainerd2204Gen2}
 
-//nolint:gochecknoglobals
-var AvailableACLDistros = []Distro{
-	AKSACLGen2TL,
-	AKSACLArm64Gen2TL,
-	AKSACLGen2FIPSTL,
-	AKSACLArm64Gen2FIPSTL,
+func ubuntuVHDDistros(version string) []Distro {
+	return DistrosWhere(func(_ Distro, c DistroCapabilities) bool {
+		return c.OSFamily == OSFamilyUbuntu && c.OSVersion == version && c.VHD
+	})
 }
 
 // IsContainerdSKU returns true if distro type is containerd-enabled.
 func (d Distro) IsContainerdDistro() bool {
-	for _, distro := range AvailableContainerdDistros {
-		if d == distro {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(AvailableContainerdDistros, d)
 }
 
 func (d Distro) IsGen2Distro() bool {
-	for _, distro := range AvailableGen2Distros {
-		if d == distro {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(AvailableGen2Distros, d)
 }
+
 func (d Distro) IsAzureLinuxDistro() bool {
-	for _, distro := range AvailableAzureLinuxDistros {
-		if d == distro {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(AvailableAzureLinuxDistros, d)
 }
+
 func (d Distro) IsWindowsSIGDistro() bool {
-	for _, distro := range AvailableWindowsSIGDistros {
-		if d == distro {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(AvailableWindowsSIGDistros, d)
 }
 
 func (d Distro) IsWindowsDistro() bool {
@@ -363,6 +153,10 @@ type SigImageConfigTemplate struct {
 type SigImageConfig struct {
 	SigImageConfigTemplate
 	SubscriptionID string
+	// VersionFallbackReason is set when Version differs from the version in the SIG image catalog,
+	// because that version is not replicated to the requested region, or when the replication of
+	// Version to the region is unknown.
+	VersionFallbackReason string `json:",omitempty"`
 }
 
 // WithOptions converts a SigImageConfigTemplate to SigImageConfig instance via function opts.
@@ -377,18 +171,9 @@ func (template SigImageConfigTemplate) WithOptions(options ...SigImageConfigOpt)
 }
 
 //nolint:gochecknoglobals
-var AvailableWindowsSIGDistros = []Distro{
-	AKSWindows2019,
-	AKSWindows2019Containerd,
-	AKSWindows2022Containerd,
-	AKSWindows2022ContainerdGen2,
-	AKSWindows23H2,
-	AKSWindows23H2Gen2,
-	AKSWindows2025,
-	AKSWindows2025Gen2,
-	AKSWindows2025Gen2TL,
-	CustomizedWindowsOSImage,
-}
+var AvailableWindowsSIGDistros = DistrosWhere(func(_ Distro, c DistroCapabilities) bool {
+	return c.OSFamily == OSFamilyWindows && c.ImageSource != ImageSourceMarketplace
+})
 
 // SIG const.
 const (
@@ -902,6 +687,7 @@ func (s SigImageConfig) GomegaString() string {
 
 // GetMaintainedLinuxSIGImageConfigMap returns a set of Distro -> SigImageConfig mappings
 // for ALL Linux distros that are currently built and maintained by AKS Node SIG (Version == LinuxSIGImageVersion).
+// Distros pinned to another version by the active SIGImageCatalog are not considered maintained.
 // Note that each distro's SigImageConfig SubscriptionID field will be empty.
 // This can be used downstream to make sure that all expected images have been properly replicated.
 // NOTE: corresponding unit tests need to be updated whenever any new distros are added or existing distros are frozen.
@@ -914,8 +700,10 @@ func GetMaintainedLinuxSIGImageConfigMap() map[Distro]SigImageConfig {
 		getSigFlatcarImageConfigMapWithOpts(),
 	}
 
+	catalog := GetSIGImageCatalog()
 	maintained := map[Distro]SigImageConfig{}
 	for _, m := range imageConfigMaps {
+		catalog.applyTo(m)
 		for distro, config := range m {
 			if config.Version == LinuxSIGImageVersion {
 				maintained[distro] = config
@@ -1119,6 +907,20 @@ func GetSIGAzureCloudSpecConfig(sigConfig SIGConfig, region string) (SIGAzureEnv
 
 	fromACSAzureLinuxEdgeZone := withAzureLinuxEdgeZoneConfig(sigConfig)
 	c.SigAzureLinuxEdgeZoneImageConfig = getSigAzureLinuxEdgeZoneImageConfigMapWithOpts(fromACSAzureLinuxEdgeZone)
+
+	// image references pinned at runtime take precedence over the compiled-in ones.
+	catalog := GetSIGImageCatalog()
+	for _, m := range []map[Distro]SigImageConfig{
+		c.SigUbuntuImageConfig,
+		c.SigCBLMarinerImageConfig,
+		c.SigAzureLinuxImageConfig,
+		c.SigFlatcarImageConfig,
+		c.SigWindowsImageConfig,
+		c.SigUbuntuEdgeZoneImageConfig,
+		c.SigAzureLinuxEdgeZoneImageConfig,
+	} {
+		catalog.applyTo(m)
+	}
 	return *c, nil
 }
 
@@ -0,0 +1,181 @@
+package datamodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SIGImageVersionReplication describes where a single SIG image version has been replicated to.
+type SIGImageVersionReplication struct {
+	Version string `json:"version"`
+	// Regions lists the regions the image version has been replicated to.
+	Regions []string `json:"regions,omitempty"`
+	// Clouds lists the clouds (e.g. AzurePublicCloud) the image version has been replicated to in all regions.
+	Clouds []string `json:"clouds,omitempty"`
+	// PublishedAt is when the image version was published, it is used to order versions.
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// SIGImageReplicationManifest records, per distro, which SIG image versions are available in which regions and clouds.
+// Distros missing from the manifest are assumed to be available everywhere.
+type SIGImageReplicationManifest struct {
+	Images map[Distro][]SIGImageVersionReplication `json:"images"`
+}
+
+// activeSIGImageReplicationManifest is the manifest consulted by the SIG image config APIs.
+// A nil manifest means replication is not checked.
+//
+//nolint:gochecknoglobals
+var activeSIGImageReplicationManifest atomic.Pointer[SIGImageReplicationManifest]
+
+// SetSIGImageReplicationManifest atomically replaces the active SIG image replication manifest.
+// Passing nil disables replication checks.
+func SetSIGImageReplicationManifest(manifest *SIGImageReplicationManifest) {
+	activeSIGImageReplicationManifest.Store(manifest)
+}
+
+// GetSIGImageReplicationManifest returns the active SIG image replication manifest, or nil if none is set.
+func GetSIGImageReplicationManifest() *SIGImageReplicationManifest {
+	return activeSIGImageReplicationManifest.Load()
+}
+
+// ParseSIGImageReplicationManifest parses and validates a JSON SIG image replication manifest.
+func ParseSIGImageReplicationManifest(contents []byte) (*SIGImageReplicationManifest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+
+	var manifest SIGImageReplicationManifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse SIG image replication manifest: %w", err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// LoadSIGImageReplicationManifest reads a SIG image replication manifest from a local file path or from an
+// http(s) URL, and validates it.
+func LoadSIGImageReplicationManifest(ctx context.Context, source string) (*SIGImageReplicationManifest, error) {
+	contents, err := readSIGConfigSource(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SIG image replication manifest from %s: %w", source, err)
+	}
+	return ParseSIGImageReplicationManifest(contents)
+}
+
+// Validate checks that every manifest entry refers to a distro with a SIG image config, and that
+// image versions are unique and carry a publication date.
+func (m *SIGImageReplicationManifest) Validate() error {
+	known := getDefaultSIGImageConfigMap()
+	var errs []string
+	for distro, versions := range m.Images {
+		if _, ok := known[distro]; !ok {
+			errs = append(errs, fmt.Sprintf("distro %q has no SIG image config", distro))
+			continue
+		}
+		seen := map[string]bool{}
+		for _, v := range versions {
+			switch {
+			case v.Version == "":
+				errs = append(errs, fmt.Sprintf("distro %q has an image version without version", distro))
+			case seen[v.Version]:
+				errs = append(errs, fmt.Sprintf("distro %q lists image version %s more than once", distro, v.Version))
+			case v.PublishedAt.IsZero():
+				errs = append(errs, fmt.Sprintf("distro %q image version %s has no publication date", distro, v.Version))
+			}
+			seen[v.Version] = true
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid SIG image replication manifest: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ResolveVersion returns the newest image version of the distro which is available in the region,
+// and is not newer than the requested version.
+// reason is empty when the requested version is available or the manifest has no information about the distro,
+// otherwise it explains why a different version was returned. A requested version missing from the manifest is
+// returned unchanged with a reason, as its publication date, and so which versions are older, is unknown.
+// ok is false when no version of the distro is known to be available in the region.
+func (m *SIGImageReplicationManifest) ResolveVersion(distro Distro, requested, region string) (version, reason string, ok bool) {
+	if m == nil {
+		return requested, "", true
+	}
+	versions, found := m.Images[distro]
+	if !found || len(versions) == 0 {
+		return requested, "", true
+	}
+
+	cloud := GetCloudTargetEnv(region)
+	var (
+		requestedEntry *SIGImageVersionReplication
+		candidates     []SIGImageVersionReplication
+	)
+	for i := range versions {
+		v := versions[i]
+		if v.Version == requested {
+			requestedEntry = &v
+		}
+		if v.isAvailableIn(region, cloud) {
+			candidates = append(candidates, v)
+		}
+	}
+	if requestedEntry == nil {
+		return requested, fmt.Sprintf("image version %s of %s is not in the replication manifest, its replication to region %s (%s) is unknown",
+			requested, distro, region, cloud), true
+	}
+	if requestedEntry.isAvailableIn(region, cloud) {
+		return requested, "", true
+	}
+
+	// never fall forward to a version newer than the one that was asked for.
+	filtered := candidates[:0]
+	for _, c := range candidates {
+		if !c.PublishedAt.After(requestedEntry.PublishedAt) {
+			filtered = append(filtered, c)
+		}
+	}
+	candidates = filtered
+	if len(candidates) == 0 {
+		return requested, fmt.Sprintf("image version %s of %s is not replicated to region %s (%s) and no older version is",
+			requested, distro, region, cloud), false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].PublishedAt.Equal(candidates[j].PublishedAt) {
+			return candidates[i].Version > candidates[j].Version
+		}
+		return candidates[i].PublishedAt.After(candidates[j].PublishedAt)
+	})
+	newest := candidates[0].Version
+	return newest, fmt.Sprintf("image version %s of %s is not replicated to region %s (%s), falling back to %s",
+		requested, distro, region, cloud, newest), true
+}
+
+func (v SIGImageVersionReplication) isAvailableIn(region, cloud string) bool {
+	normalizedRegion := normalizeRegion(region)
+	for _, r := range v.Regions {
+		if normalizeRegion(r) == normalizedRegion {
+			return true
+		}
+	}
+	for _, c := range v.Clouds {
+		if strings.EqualFold(c, cloud) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRegion(region string) string {
+	return strings.ToLower(strings.Join(strings.Fields(region), ""))
+}
@@ -0,0 +1,82 @@
+package datamodel
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SIGImageReplicationManifest", func() {
+	const manifestJSON = `{
+  "images": {
+    "aks-azurelinux-v3-gen2": [
+      {"version": "202603.01.0", "regions": ["eastus", "West Europe"], "publishedAt": "2026-03-01T00:00:00Z"},
+      {"version": "202602.01.0", "clouds": ["AzurePublicCloud"], "publishedAt": "2026-02-01T00:00:00Z"},
+      {"version": "202601.01.0", "clouds": ["AzurePublicCloud", "AzureUSGovernmentCloud"], "publishedAt": "2026-01-01T00:00:00Z"}
+    ]
+  }
+}`
+
+	var manifest *SIGImageReplicationManifest
+
+	BeforeEach(func() {
+		var err error
+		manifest, err = ParseSIGImageReplicationManifest([]byte(manifestJSON))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject invalid manifests", func() {
+		_, err := ParseSIGImageReplicationManifest([]byte(`{"images": {"not-a-distro": []}}`))
+		Expect(err).To(MatchError(ContainSubstring(`distro "not-a-distro" has no SIG image config`)))
+
+		_, err = ParseSIGImageReplicationManifest([]byte(`{"images": {"aks-azurelinux-v3": [{"version": "1", "publishedAt": "2026-01-01T00:00:00Z"}, {"version": "1", "publishedAt": "2026-01-01T00:00:00Z"}]}}`))
+		Expect(err).To(MatchError(ContainSubstring("more than once")))
+
+		_, err = ParseSIGImageReplicationManifest([]byte(`{"images": {"aks-azurelinux-v3": [{"version": "1"}]}}`))
+		Expect(err).To(MatchError(ContainSubstring("has no publication date")))
+	})
+
+	It("should return the requested version when it is replicated to the region", func() {
+		version, reason, ok := manifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202603.01.0", "westeurope")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202603.01.0"))
+		Expect(reason).To(BeEmpty())
+	})
+
+	It("should fall back to the newest older version replicated to the region", func() {
+		version, reason, ok := manifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202603.01.0", "southcentralus")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202602.01.0"))
+		Expect(reason).To(Equal("image version 202603.01.0 of aks-azurelinux-v3-gen2 is not replicated to region southcentralus (AzurePublicCloud), falling back to 202602.01.0"))
+
+		version, _, ok = manifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202603.01.0", "usgovvirginia")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202601.01.0"))
+	})
+
+	It("should not fall forward to a newer version", func() {
+		version, reason, ok := manifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202601.01.0", "chinaeast2")
+		Expect(ok).To(BeFalse())
+		Expect(version).To(Equal("202601.01.0"))
+		Expect(reason).To(ContainSubstring("no older version is"))
+	})
+
+	It("should keep versions missing from the manifest with a reason", func() {
+		version, reason, ok := manifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202512.01.0", "eastus")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202512.01.0"))
+		Expect(reason).To(ContainSubstring("is not in the replication manifest"))
+	})
+
+	It("should not check distros missing from the manifest or a nil manifest", func() {
+		version, reason, ok := manifest.ResolveVersion(AKSUbuntuContainerd2404Gen2, "202603.01.0", "chinaeast2")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202603.01.0"))
+		Expect(reason).To(BeEmpty())
+
+		var nilManifest *SIGImageReplicationManifest
+		version, reason, ok = nilManifest.ResolveVersion(AKSAzureLinuxV3Gen2, "202603.01.0", "chinaeast2")
+		Expect(ok).To(BeTrue())
+		Expect(version).To(Equal("202603.01.0"))
+		Expect(reason).To(BeEmpty())
+	})
+})
@@ -10,11 +10,11 @@ import (
 	"hash/fnv"
 	"math/rand"
 	neturl "net/url"
-	"slices"
 	"sort"
 	"strings"
 	"sync"
 
+	"github.com/Azure/agentbaker/pkg/agent/kubeletconfig"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/Masterminds/semver/v3"
 )
@@ -235,64 +235,10 @@ const (
 	USSecCloud = "USSecCloud"
 )
 
+// AKSDistrosAvailableOnVHD lists the distros using an image built by AKS with all components cached.
+//
 //nolint:gochecknoglobals
-var AKSDistrosAvailableOnVHD = []Distro{
-	AKSCBLMarinerV1,
-	AKSCBLMarinerV2,
-	AKSAzureLinuxV2,
-	AKSAzureLinuxV3,
-	AKSCBLMarinerV2Gen2,
-	AKSAzureLinuxV2Gen2,
-	AKSAzureLinuxV3Gen2,
-	AKSCBLMarinerV2FIPS,
-	AKSAzureLinuxV2FIPS,
-	AKSAzureLinuxV3FIPS,
-	AKSCBLMarinerV2Gen2FIPS,
-	AKSAzureLinuxV2Gen2FIPS,
-	AKSAzureLinuxV3Gen2FIPS,
-	AKSCBLMarinerV2Gen2Kata,
-	AKSAzureLinuxV2Gen2Kata,
-	AKSCBLMarinerV2Gen2TL,
-	AKSAzureLinuxV2Gen2TL,
-	AKSAzureLinuxV3Gen2TL,
-	AKSAzureLinuxV3OSGuardGen2FIPSTL,
-	AKSCBLMarinerV2KataGen2TL,
-	AKSUbuntuFipsContainerd2004,
-	AKSUbuntuFipsContainerd2004Gen2,
-	AKSUbuntuFipsContainerd2204,
-	AKSUbuntuFipsContainerd2204Gen2,
-	AKSUbuntuFipsContainerd2204TLGen2,
-	AKSUbuntuEdgeZoneContainerd2204,
-	AKSUbuntuEdgeZoneContainerd2204Gen2,
-	AKSUbuntuEdgeZoneContainerd2404,
-	AKSUbuntuEdgeZoneContainerd2404Gen2,
-	AKSAzureLinuxV3EdgeZone,
-	AKSAzureLinuxV3EdgeZoneGen2,
-	AKSUbuntuContainerd2204,
-	AKSUbuntuContainerd2204Gen2,
-	AKSUbuntuContainerd2004CVMGen2,
-	AKSAzureLinuxV3CVMGen2,
-	AKSUbuntuArm64Containerd2204Gen2,
-	AKSUbuntuArm64Containerd2404Gen2,
-	AKSUbuntuArm64GB200Containerd2404Gen2,
-	AKSUbuntuContainerd2404CVMGen2,
-	AKSCBLMarinerV2Arm64Gen2,
-	AKSAzureLinuxV2Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2,
-	AKSAzureLinuxV3Arm64Gen2FIPS,
-	AKSUbuntuContainerd2204TLGen2,
-	AKSUbuntuContainerd2404,
-	AKSUbuntuContainerd2404Gen2,
-	AKSUbuntuContainerd2404TLGen2,
-	AKSUbuntuMinimalContainerd2604Gen2,
-	AKSUbuntuMinimalArm64Containerd2604Gen2,
-	AKSFlatcarGen2,
-	AKSFlatcarArm64Gen2,
-	AKSACLGen2TL,
-	AKSACLArm64Gen2TL,
-	AKSACLGen2FIPSTL,
-	AKSACLArm64Gen2FIPSTL,
-}
+var AKSDistrosAvailableOnVHD = DistrosWhere(func(_ Distro, c DistroCapabilities) bool { return c.VHD })
 
 type CustomConfigurationComponent string
 
@@ -302,44 +248,55 @@ const (
 )
 
 func (d Distro) IsVHDDistro() bool {
-	return slices.Contains(AKSDistrosAvailableOnVHD, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.VHD })
 }
 
 func (d Distro) Is2204VHDDistro() bool {
-	return slices.Contains(AvailableUbuntu2204Distros, d)
+	return d.isUbuntuVHDDistro("22.04")
 }
 
 // This function will later be consumed by CSE to determine cgroupv2 usage.
 func (d Distro) Is2404VHDDistro() bool {
-	return slices.Contains(AvailableUbuntu2404Distros, d)
+	return d.isUbuntuVHDDistro("24.04")
 }
 
 func (d Distro) Is2604VHDDistro() bool {
-	return slices.Contains(AvailableUbuntu2604Distros, d)
+	return d.isUbuntuVHDDistro("26.04")
+}
+
+func (d Distro) isUbuntuVHDDistro(version string) bool {
+	return d.matches(func(c DistroCapabilities) bool {
+		return c.OSFamily == OSFamilyUbuntu && c.OSVersion == version && c.VHD
+	})
 }
 
 func (d Distro) IsAzureLinuxCgroupV2VHDDistro() bool {
-	return slices.Contains(AvailableAzureLinuxCgroupV2Distros, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.OSFamily == OSFamilyAzureLinux && c.CgroupVersion == 2 })
 }
 
 func (d Distro) IsKataDistro() bool {
-	return d == AKSCBLMarinerV2Gen2Kata || d == AKSAzureLinuxV3Gen2Kata || d == AKSAzureLinuxV2Gen2Kata || d == AKSCBLMarinerV2KataGen2TL || d == CustomizedImageKata
+	return d.matches(func(c DistroCapabilities) bool { return c.Kata })
 }
 
 func (d Distro) IsFlatcarDistro() bool {
-	return slices.Contains(AvailableFlatcarDistros, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.OSFamily == OSFamilyFlatcar })
 }
 
 func (d Distro) IsACLDistro() bool {
-	return slices.Contains(AvailableACLDistros, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.OSFamily == OSFamilyACL })
 }
 
 func (d Distro) IsAzureLinuxOSGuardDistro() bool {
-	return slices.Contains(AvailableAzureLinuxOSGuardDistros, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.OSGuard })
 }
 
 func (d Distro) IsAzureLinuxV3Distro() bool {
-	return slices.Contains(AvailableAzureLinuxV3Distros, d)
+	return d.matches(func(c DistroCapabilities) bool { return c.OSFamily == OSFamilyAzureLinux && c.OSVersion == "3.0" })
+}
+
+// IsContainerdV2Distro returns true if the distro ships containerd 2.x.
+func (d Distro) IsContainerdV2Distro() bool {
+	return d.matches(func(c DistroCapabilities) bool { return c.ContainerdMajorVersion == 2 })
 }
 
 /*
@@ -1196,11 +1153,12 @@ func (a *AgentPoolProfile) Is2604VHDDistro() bool {
 	return a.Distro.Is2604VHDDistro()
 }
 
+// IsContainerdV2Distro returns true if the distro ships containerd 2.x.
 func (a *AgentPoolProfile) IsContainerdV2Distro() bool {
-	if a.Distro.IsKataDistro() {
+	if a.Distro.IsWindowsDistro() {
 		return false
 	}
-	return a.Distro.Is2604VHDDistro() || a.Distro.Is2404VHDDistro() || a.Distro.IsACLDistro() || a.Distro.IsAzureLinuxV3Distro()
+	return a.Distro.IsContainerdV2Distro()
 }
 
 // IsAzureLinuxCgroupV2VHDDistro returns true if the distro uses Azure Linux CgrpupV2 VHD.
@@ -1788,8 +1746,9 @@ type NodeBootstrappingConfiguration struct {
 	// EnabledFeatures is a generic set of feature toggles delivered to the node as KEY=VALUE
 	// lines in enabled_features.sh, which the aks-node-controller wrapper reads and exports as
 	// environment variables (e.g. "ENABLE_PROVISIONING_HOTFIX" -> "true") to gate provisioning
-	// steps such as check-hotfix. Using a map lets RP add new toggles without producer-side code
-	// changes. Empty/nil => no file is written and scriptless custom data is byte-identical to today.
+	// steps such as check-hotfix. Toggles must be registered in pkg/agent/enabledfeatures/registry.json,
+	// validation rejects unknown names and values of the wrong type. Empty/nil => no file is written and
+	// scriptless custom data is byte-identical to today.
 	EnabledFeatures map[string]string
 	// Version is required for aks-node-controller application to determine the version of the config file.
 	Version string
@@ -1986,440 +1945,33 @@ func (c *SecureTLSBootstrappingConfig) GetGetCredentialTimeout() string {
 }
 
 // AKSKubeletConfiguration contains the configuration for the Kubelet that AKS set.
-/* this is a subset of KubeletConfiguration defined in
-https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/kubelet/config/v1beta1/types.go
-changed metav1.Duration to Duration and pointers to values to simplify translation. */
-type AKSKubeletConfiguration struct {
-	// Kind is a string value representing the REST resource this object represents.
-	// Servers may infer this from the endpoint the client submits requests to.
-	// Cannot be updated.
-	// In CamelCase.
-	// More info:
-	// https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds.
-	// +optional.
-	Kind string `json:"kind,omitempty" protobuf:"bytes,1,opt,name=kind"`
-	/* APIVersion defines the versioned schema of this representation of an object.
-	Servers should convert recognized schemas to the latest internal value, and
-	may reject unrecognized values.
-	More info:
-	https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources
-	+optional. */
-	APIVersion string `json:"apiVersion,omitempty" protobuf:"bytes,2,opt,name=apiVersion"`
-	/* staticPodPath is the path to the directory containing local (static) pods to
-	run, or the path to a single static pod file.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	the set of static pods specified at the new path may be different than the
-	ones the Kubelet initially started with, and this may disrupt your node.
-	Default: ""
-	+optional. */
-	StaticPodPath string `json:"staticPodPath,omitempty"`
-	/* address is the IP address for the Kubelet to serve on (set to 0.0.0.0
-	for all interfaces).
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Default: "0.0.0.0"
-	+optional. */
-	Address string `json:"address,omitempty"`
-	/* readOnlyPort is the read-only port for the Kubelet to serve on with
-	no authentication/authorization.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Default: 0 (disabled)
-	+optional. */
-	ReadOnlyPort int32 `json:"readOnlyPort,omitempty"`
-	/* tlsCertFile is the file containing x509 Certificate for HTTPS. (CA cert,
-	if any, concatenated after server cert). If tlsCertFile and
-	tlsPrivateKeyFile are not provided, a self-signed certificate
-	and key are generated for the public address and saved to the directory
-	passed to the Kubelet's --cert-dir flag.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Default: ""
-	+optional. */
-	TLSCertFile string `json:"tlsCertFile,omitempty"`
-	/* tlsPrivateKeyFile is the file containing x509 private key matching tlsCertFile
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Default: ""
-	+optional. */
-	TLSPrivateKeyFile string `json:"tlsPrivateKeyFile,omitempty"`
-	/* TLSCipherSuites is the list of allowed cipher suites for the server.
-	Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants).
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Default: nil
-	+optional. */
-	TLSCipherSuites []string `json:"tlsCipherSuites,omitempty"`
-	/* rotateCertificates enables client certificate rotation. The Kubelet will request a
-	new certificate from the certificates.k8s.io API. This requires an approver to approve the
-	certificate signing requests.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	disabling it may disrupt the Kubelet's ability to authenticate with the API server
-	after the current certificate expires.
-	Default: false
-	+optional. */
-	RotateCertificates bool `json:"rotateCertificates,omitempty"`
-	// serverTLSBootstrap enables server certificate bootstrap. Instead of self
-	// signing a serving certificate, the Kubelet will request a certificate from
-	// the 'certificates.k8s.io' API. This requires an approver to approve the
-	// certificate signing requests (CSR). The RotateKubeletServerCertificate feature
-	// must be enabled when setting this field.
-	// Default: false
-	// +optional
-	ServerTLSBootstrap bool `json:"serverTLSBootstrap,omitempty"`
-	/* authentication specifies how requests to the Kubelet's server are authenticated
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Defaults:
-	  anonymous:
-	    enabled: false
-	  webhook:
-	    enabled: true
-	    cacheTTL: "2m"
-	+optional. */
-	Authentication KubeletAuthentication `json:"authentication"`
-	/* authorization specifies how requests to the Kubelet's server are authorized
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may disrupt components that interact with the Kubelet server.
-	Defaults:
-	  mode: Webhook
-	  webhook:
-	    cacheAuthorizedTTL: "5m"
-	    cacheUnauthorizedTTL: "30s"
-	+optional. */
-	Authorization KubeletAuthorization `json:"authorization"`
-	/* eventRecordQPS is the maximum event creations per second. If 0, there
-	is no limit enforced.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may impact scalability by changing the amount of traffic produced by
-	event creations.
-	Default: 5
-	+optional. */
-	EventRecordQPS *int32 `json:"eventRecordQPS,omitempty"`
-	/* clusterDomain is the DNS domain for this cluster. If set, kubelet will
-	configure all containers to search this domain in addition to the
-	host's search domains.
-	Dynamic Kubelet Config (beta): Dynamically updating this field is not recommended,
-	as it should be kept in sync with the rest of the cluster.
-	Default: ""
-	+optional. */
-	ClusterDomain string `json:"clusterDomain,omitempty"`
-	/* clusterDNS is a list of IP addresses for the cluster DNS server. If set,
-	kubelet will configure all containers to use this for DNS resolution
-	instead of the host's DNS servers.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	changes will only take effect on Pods created after the update. Draining
-	the node is recommended before changing this field.
-	Default: nil
-	+optional. */
-	ClusterDNS []string `json:"clusterDNS,omitempty"`
-	/* Deprecated: streamingConnectionIdleTimeout was removed from KubeletConfiguration in k8s 1.34.
-		Retained for backward compatibility with k8s < 1.34. Do not use for new code.
-		For k8s >= 1.34, this field is cleared by baker/ANC and omitted from the config file via omitempty.
-		Default: "4h"
-	   +optional. */
-	StreamingConnectionIdleTimeout Duration `json:"streamingConnectionIdleTimeout,omitempty"`
-	/* nodeStatusUpdateFrequency is the frequency that kubelet computes node
-	status. If node lease feature is not enabled, it is also the frequency that
-	kubelet posts node status to master.
-	Note: When node lease feature is not enabled, be cautious when changing the
-	constant, it must work with nodeMonitorGracePeriod in nodecontroller.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may impact node scalability, and also that the node controller's
-	nodeMonitorGracePeriod must be set to N*NodeStatusUpdateFrequency,
-	where N is the number of retries before the node controller marks
-	the node unhealthy.
-	Default: "10s"
-	+optional. */
-	NodeStatusUpdateFrequency Duration `json:"nodeStatusUpdateFrequency,omitempty"`
-	/* nodeStatusReportFrequency is the frequency that kubelet posts node
-	status to master if node status does not change. Kubelet will ignore this
-	frequency and post node status immediately if any change is detected. It is
-	only used when node lease feature is enabled. nodeStatusReportFrequency's
-	default value is 5m. But if nodeStatusUpdateFrequency is set explicitly,
-	nodeStatusReportFrequency's default value will be set to
-	nodeStatusUpdateFrequency for backward compatibility.
-	Default: "5m"
-	+optional. */
-	NodeStatusReportFrequency Duration `json:"nodeStatusReportFrequency,omitempty"`
-	/* imageGCHighThresholdPercent is the percent of disk usage after which
-	image garbage collection is always run. The percent is calculated as
-	this field value out of 100.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may trigger or delay garbage collection, and may change the image overhead
-	on the node.
-	Default: 85
-	+optional. */
-	ImageGCHighThresholdPercent *int32 `json:"imageGCHighThresholdPercent,omitempty"`
-	/* imageGCLowThresholdPercent is the percent of disk usage before which
-	image garbage collection is never run. Lowest disk usage to garbage
-	collect to. The percent is calculated as this field value out of 100.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may trigger or delay garbage collection, and may change the image overhead
-	on the node.
-	Default: 80
-	+optional. */
-	ImageGCLowThresholdPercent *int32 `json:"imageGCLowThresholdPercent,omitempty"`
-	/* Enable QoS based Cgroup hierarchy: top level cgroups for QoS Classes
-	And all Burstable and BestEffort pods are brought up under their
-	specific top level QoS cgroup.
-	Dynamic Kubelet Config (beta): This field should not be updated without a full node
-	reboot. It is safest to keep this value the same as the local config.
-	Default: true
-	+optional. */
-	CgroupsPerQOS *bool `json:"cgroupsPerQOS,omitempty"`
-	/* CPUManagerPolicy is the name of the policy to use.
-	Requires the CPUManager feature gate to be enabled.
-	Dynamic Kubelet Config (beta): This field should not be updated without a full node
-	reboot. It is safest to keep this value the same as the local config.
-	Default: "none"
-	+optional. */
-	CPUManagerPolicy string `json:"cpuManagerPolicy,omitempty"`
-	/* TopologyManagerPolicy is the name of the policy to use.
-	Policies other than "none" require the TopologyManager feature gate to be enabled.
-	Dynamic Kubelet Config (beta): This field should not be updated without a full node
-	reboot. It is safest to keep this value the same as the local config.
-	Default: "none"
-	+optional. */
-	TopologyManagerPolicy string `json:"topologyManagerPolicy,omitempty"`
-	/* maxPods is the number of pods that can run on this Kubelet.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	changes may cause Pods to fail admission on Kubelet restart, and may change
-	the value reported in Node.Status.Capacity[v1.ResourcePods], thus affecting
-	future scheduling decisions. Increasing this value may also decrease performance,
-	as more Pods can be packed into a single node.
-	Default: 110
-	+optional. */
-	MaxPods int32 `json:"maxPods,omitempty"`
-	/* PodPidsLimit is the maximum number of pids in any pod.
-	Requires the SupportPodPidsLimit feature gate to be enabled.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	lowering it may prevent container processes from forking after the change.
-	Default: -1
-	+optional. */
-	PodPidsLimit *int64 `json:"podPidsLimit,omitempty"`
-	/* ResolverConfig is the resolver configuration file used as the basis
-	for the container DNS resolution configuration.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	changes will only take effect on Pods created after the update. Draining
-	the node is recommended before changing this field.
-	Default: "/etc/resolv.conf"
-	+optional. */
-	ResolverConfig string `json:"resolvConf,omitempty"`
-	/* cpuCFSQuota enables CPU CFS quota enforcement for containers that
-	specify CPU limits.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	disabling it may reduce node stability.
-	Default: true
-	+optional. */
-	CPUCFSQuota *bool `json:"cpuCFSQuota,omitempty"`
-	/* CPUCFSQuotaPeriod is the CPU CFS quota period value, cpu.cfs_period_us.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	limits set for containers will result in different cpu.cfs_quota settings. This
-	will trigger container restarts on the node being reconfigured.
-	Default: "100ms"
-	+optional. */
-	CPUCFSQuotaPeriod Duration `json:"cpuCFSQuotaPeriod,omitempty"`
-	/* Map of signal names to quantities that defines hard eviction thresholds. For example: {"memory.available": "300Mi"}.
-	To explicitly disable, pass a 0% or 100% threshold on an arbitrary resource.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may trigger or delay Pod evictions.
-	Default:
-	  memory.available:  "100Mi"
-	  nodefs.available:  "10%"
-	  nodefs.inodesFree: "5%"
-	  imagefs.available: "15%"
-	+optional. */
-	EvictionHard map[string]string `json:"evictionHard,omitempty"`
-	/* evictionSoft is a map of signal names to quantities that defines soft eviction thresholds.
-	For example: {"memory.available": "300Mi"}.
-	Each signal listed here must also have a corresponding entry in evictionSoftGracePeriod.
-	Soft eviction terminates pods gracefully (respecting terminationGracePeriodSeconds, capped by
-	evictionMaxPodGracePeriod) once the threshold is breached for the configured grace period.
-	+optional. */
-	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
-	/* evictionSoftGracePeriod is a map of signal names to durations defining how long the soft
-	eviction threshold must be breached before triggering eviction. Example:
-	{"memory.available": "30s", "nodefs.available": "2m"}.
-	Each entry must correspond to a signal listed in evictionSoft.
-	+optional. */
-	EvictionSoftGracePeriod map[string]string `json:"evictionSoftGracePeriod,omitempty"`
-	/* evictionMaxPodGracePeriod is the maximum allowed grace period (in seconds) to use when
-	terminating pods in response to a soft eviction threshold being met. Setting this value
-	caps the pod's terminationGracePeriodSeconds during soft eviction.
-	+optional. */
-	EvictionMaxPodGracePeriod int32 `json:"evictionMaxPodGracePeriod,omitempty"`
-	/* protectKernelDefaults, if true, causes the Kubelet to error if kernel
-	flags are not as it expects. Otherwise the Kubelet will attempt to modify
-	kernel flags to match its expectation.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	enabling it may cause the Kubelet to crash-loop if the Kernel is not configured as
-	Kubelet expects.
-	Default: false
-	+optional. */
-	ProtectKernelDefaults bool `json:"protectKernelDefaults,omitempty"`
-	/* featureGates is a map of feature names to bools that enable or disable alpha/experimental
-	features. This field modifies piecemeal the built-in default values from
-	"k8s.io/kubernetes/pkg/features/kube_features.go".
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider the
-	documentation for the features you are enabling or disabling. While we
-	encourage feature developers to make it possible to dynamically enable
-	and disable features, some changes may require node reboots, and some
-	features may require careful coordination to retroactively disable.
-	Default: nil
-	+optional. */
-	FeatureGates map[string]bool `json:"featureGates,omitempty"`
-	/* failSwapOn tells the Kubelet to fail to start if swap is enabled on the node.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	setting it to true will cause the Kubelet to crash-loop if swap is enabled.
-	Default: true
-	+optional. */
-	FailSwapOn *bool `json:"failSwapOn,omitempty"`
-	/* A quantity defines the maximum size of the container log file before it is rotated.
-	For example: "5Mi" or "256Ki".
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may trigger log rotation.
-	Default: "10Mi"
-	+optional. */
-	ContainerLogMaxSize string `json:"containerLogMaxSize,omitempty"`
-	/* Maximum number of container log files that can be present for a container.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	lowering it may cause log files to be deleted.
-	Default: 5
-	+optional. */
-	ContainerLogMaxFiles *int32 `json:"containerLogMaxFiles,omitempty"`
-
-	/* the following fields are meant for Node Allocatable */
-
-	/* systemReserved is a set of ResourceName=ResourceQuantity (e.g. cpu=200m,memory=150G)
-	pairs that describe resources reserved for non-kubernetes components.
-	Currently only cpu and memory are supported.
-	See http://kubernetes.io/docs/user-guide/compute-resources for more detail.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may not be possible to increase the reserved resources, because this
-	requires resizing cgroups. Always look for a NodeAllocatableEnforced event
-	after updating this field to ensure that the update was successful.
-	Default: nil
-	+optional. */
-	SystemReserved map[string]string `json:"systemReserved,omitempty"`
-	/* A set of ResourceName=ResourceQuantity (e.g. cpu=200m,memory=150G) pairs
-	that describe resources reserved for kubernetes system components.
-	Currently cpu, memory and local storage for root file system are supported.
-	See http://kubernetes.io/docs/user-guide/compute-resources for more detail.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	it may not be possible to increase the reserved resources, because this
-	requires resizing cgroups. Always look for a NodeAllocatableEnforced event
-	after updating this field to ensure that the update was successful.
-	Default: nil
-	+optional. */
-	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
-	/* This flag specifies the various Node Allocatable enforcements that Kubelet needs to perform.
-	This flag accepts a list of options. Acceptable options are `none`, `pods`, `system-reserved` &
-	`kube-reserved`. If `none` is specified, no other options may be specified.
-	Refer to
-	[Node Allocatable](https://git.k8s.io/community/contributors/design-proposals/node/node-allocatable.md)
-	doc for more information.
-	Dynamic Kubelet Config (beta): If dynamically updating this field, consider that
-	removing enforcements may reduce the stability of the node. Alternatively, adding
-	enforcements may reduce the stability of components which were using more than
-	the reserved amount of resources; for example, enforcing kube-reserved may cause
-	Kubelets to OOM if it uses more than the reserved resources, and enforcing system-reserved
-	may cause system daemons to OOM if they use more than the reserved resources.
-	Default: ["pods"]
-	+optional. */
-	EnforceNodeAllocatable []string `json:"enforceNodeAllocatable,omitempty"`
-	/* kubeReservedCgroup is the absolute name of the cgroup the kubelet should manage
-	for the kube-reserved compute resources. When enforce-node-allocatable contains
-	this cgroup must exist before kubelet starts. Example: "/kubereserved.slice".
-	+optional. */
-	KubeReservedCgroup string `json:"kubeReservedCgroup,omitempty"`
-	/* systemReservedCgroup is the absolute name of the cgroup the kubelet should manage
-	for the system-reserved compute resources. When enforce-node-allocatable contains
-	"system-reserved", this cgroup must exist before kubelet starts. Example: "/system.slice".
-	+optional. */
-	SystemReservedCgroup string `json:"systemReservedCgroup,omitempty"`
-	/* A comma separated whitelist of unsafe sysctls or sysctl patterns (ending in *).
-	Unsafe sysctl groups are kernel.shm*, kernel.msg*, kernel.sem, fs.mqueue.*, and net.*.
-	These sysctls are namespaced but not allowed by default.
-	For example: "kernel.msg*,net.ipv4.route.min_pmtu"
-	Default: []
-	+optional. */
-	AllowedUnsafeSysctls []string `json:"allowedUnsafeSysctls,omitempty"`
-	// serializeImagePulls when enabled, tells the Kubelet to pull images one
-	// at a time. We recommend *not* changing the default value on nodes that
-	// run docker daemon with version  < 1.9 or an Aufs storage backend.
-	// Issue #10959 has more details.
-	// Default: true
-	// +optional
-	SerializeImagePulls *bool `json:"serializeImagePulls,omitempty"`
-	// SeccompDefault enables the use of `RuntimeDefault` as the default seccomp profile for all workloads.
-	// Default: false
-	// +optional
-	SeccompDefault *bool `json:"seccompDefault,omitempty"`
-}
-
-type Duration string
-
-// below are copied from Kubernetes.
-type KubeletAuthentication struct {
-	// x509 contains settings related to x509 client certificate authentication.
-	// +optional.
-	X509 KubeletX509Authentication `json:"x509"`
-	// webhook contains settings related to webhook bearer token authentication.
-	// +optional.
-	Webhook KubeletWebhookAuthentication `json:"webhook"`
-	// anonymous contains settings related to anonymous authentication.
-	// +optional.
-	Anonymous KubeletAnonymousAuthentication `json:"anonymous"`
-}
-
-type KubeletX509Authentication struct {
-	/* clientCAFile is the path to a PEM-encoded certificate bundle. If set, any request presenting a client certificate
-	signed by one of the authorities in the bundle is authenticated with a username corresponding to the CommonName,
-	and groups corresponding to the Organization in the client certificate.
-	+optional. */
-	ClientCAFile string `json:"clientCAFile,omitempty"`
-}
-
-type KubeletWebhookAuthentication struct {
-	// enabled allows bearer token authentication backed by the tokenreviews.authentication.k8s.io API.
-	// +optional.
-	Enabled bool `json:"enabled,omitempty"`
-	// cacheTTL enables caching of authentication results.
-	// +optional.
-	CacheTTL Duration `json:"cacheTTL,omitempty"`
-}
+//
+// Deprecated: use kubeletconfig.KubeletConfiguration, which mirrors the upstream KubeletConfiguration type.
+type AKSKubeletConfiguration = kubeletconfig.KubeletConfiguration
 
-type KubeletAnonymousAuthentication struct {
-	// enabled allows anonymous requests to the kubelet server.
-	// Requests that are not rejected by another authentication method are treated as anonymous requests.
-	// Anonymous requests have a username of system:anonymous, and a group name of system:unauthenticated.
-	// +optional.
-	Enabled bool `json:"enabled,omitempty"`
-}
+// Deprecated: use kubeletconfig.Duration.
+type Duration = kubeletconfig.Duration
 
-type KubeletAuthorization struct {
-	// mode is the authorization mode to apply to requests to the kubelet server.
-	// Valid values are AlwaysAllow and Webhook.
-	// Webhook mode uses the SubjectAccessReview API to determine authorization.
-	// +optional.
-	Mode KubeletAuthorizationMode `json:"mode,omitempty"`
+// Deprecated: use kubeletconfig.KubeletAuthentication.
+type KubeletAuthentication = kubeletconfig.KubeletAuthentication
 
-	// webhook contains settings related to Webhook authorization.
-	// +optional.
-	Webhook KubeletWebhookAuthorization `json:"webhook"`
-}
+// Deprecated: use kubeletconfig.KubeletX509Authentication.
+type KubeletX509Authentication = kubeletconfig.KubeletX509Authentication
 
-type KubeletAuthorizationMode string
+// Deprecated: use kubeletconfig.KubeletWebhookAuthentication.
+type KubeletWebhookAuthentication = kubeletconfig.KubeletWebhookAuthentication
 
-type KubeletWebhookAuthorization struct {
-	// cacheAuthorizedTTL is the duration to cache 'authorized' responses from the webhook authorizer.
-	// +optional.
-	CacheAuthorizedTTL Duration `json:"cacheAuthorizedTTL,omitempty"`
-	// cacheUnauthorizedTTL is the duration to cache 'unauthorized' responses from the webhook authorizer.
-	// +optional.
-	CacheUnauthorizedTTL Duration `json:"cacheUnauthorizedTTL,omitempty"`
-}
+// Deprecated: use kubeletconfig.KubeletAnonymousAuthentication.
+type KubeletAnonymousAuthentication = kubeletconfig.KubeletAnonymousAuthentication
+
+// Deprecated: use kubeletconfig.KubeletAuthorization.
+type KubeletAuthorization = kubeletconfig.KubeletAuthorization
+
+// Deprecated: use kubeletconfig.KubeletAuthorizationMode.
+type KubeletAuthorizationMode = kubeletconfig.KubeletAuthorizationMode
+
+// Deprecated: use kubeletconfig.KubeletWebhookAuthorization.
+type KubeletWebhookAuthorization = kubeletconfig.KubeletWebhookAuthorization
 
 type CSEStatus struct {
 	// ExitCode stores the exitCode from CSE output.
@@ -0,0 +1,13 @@
+package enabledfeatures
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEnabledFeatures(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "enabledfeatures suite")
+}
@@ -0,0 +1,300 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package enabledfeatures is the registry of the feature toggles delivered to nodes through
+// NodeBootstrappingConfiguration.EnabledFeatures and AKSNodeConfig enabled_features.
+// The toggles are written as KEY=VALUE lines to enabled_features.sh, which the aks-node-controller launcher
+// exports before provisioning. Producers validate the toggles against the registry so typos are caught before
+// rollout, and aks-node-controller reports the effective set on a node. Adding a toggle only requires a
+// registry.json entry.
+package enabledfeatures
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// SchemaVersion is the registry.json version understood by this package.
+const SchemaVersion = 1
+
+// Type is the type of the value of a feature.
+type Type string
+
+const (
+	// TypeBool values are "true" or "false", the launcher and the provisioning scripts only test for "true".
+	TypeBool Type = "bool"
+	// TypeInt values are decimal integers.
+	TypeInt Type = "int"
+	// TypeString values are any single line.
+	TypeString Type = "string"
+)
+
+// Feature describes a feature toggle.
+type Feature struct {
+	// Name is the environment variable exported by the launcher.
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Default is the value in effect when the toggle is not delivered.
+	Default string `json:"default"`
+	// MinANCVersion is the first aks-node-controller version honoring the toggle.
+	MinANCVersion string `json:"minAncVersion"`
+	Description   string `json:"description"`
+}
+
+// Registry is the table of supported feature toggles.
+type Registry struct {
+	Version  int       `json:"version"`
+	Features []Feature `json:"features"`
+}
+
+// nameRe matches a valid shell identifier, the set of keys the aks-node-controller launcher exports.
+var nameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+//go:embed registry.json
+var embeddedRegistryJSON []byte
+
+//nolint:gochecknoglobals
+var embeddedRegistry = mustParse(embeddedRegistryJSON)
+
+// Embedded returns the registry embedded in the binary.
+func Embedded() *Registry {
+	return embeddedRegistry
+}
+
+func mustParse(contents []byte) *Registry {
+	r, err := Parse(contents)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Parse parses and validates a JSON feature registry.
+func Parse(contents []byte) (*Registry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+
+	var r Registry
+	if err := decoder.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse feature registry: %w", err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *Registry) validate() error {
+	var errs []string
+	if r.Version != SchemaVersion {
+		errs = append(errs, fmt.Sprintf("version %d is not supported, expected %d", r.Version, SchemaVersion))
+	}
+	seen := map[string]bool{}
+	for _, f := range r.Features {
+		if !nameRe.MatchString(f.Name) {
+			errs = append(errs, fmt.Sprintf("feature %q is not a valid shell identifier", f.Name))
+			continue
+		}
+		if seen[f.Name] {
+			errs = append(errs, fmt.Sprintf("feature %s is duplicated", f.Name))
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case TypeBool, TypeInt, TypeString:
+			if err := f.check(f.Default); err != nil {
+				errs = append(errs, fmt.Sprintf("feature %s default: %s", f.Name, err))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("feature %s has unknown type %q", f.Name, f.Type))
+		}
+		if _, err := semver.NewVersion(f.MinANCVersion); err != nil {
+			errs = append(errs, fmt.Sprintf("feature %s minAncVersion %q is not a version", f.Name, f.MinANCVersion))
+		}
+		if f.Description == "" {
+			errs = append(errs, fmt.Sprintf("feature %s has no description", f.Name))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid feature registry: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Lookup returns the registered feature with the given name.
+func (r *Registry) Lookup(name string) (Feature, bool) {
+	for _, f := range r.Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// check validates a value of the feature.
+func (f Feature) check(value string) error {
+	if strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("value %q must be a single line", value)
+	}
+	switch f.Type {
+	case TypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("value %q must be true or false", value)
+		}
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value %q must be an integer", value)
+		}
+	case TypeString:
+	}
+	return nil
+}
+
+// Validate rejects toggles which are not registered or whose value does not match the registered type.
+func (r *Registry) Validate(features map[string]string) error {
+	var errs []string
+	for _, name := range sortedKeys(features) {
+		f, ok := r.Lookup(name)
+		if !ok {
+			msg := fmt.Sprintf("unknown feature %q", name)
+			if suggestion := r.suggest(name); suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			errs = append(errs, msg)
+			continue
+		}
+		if err := f.check(features[name]); err != nil {
+			errs = append(errs, fmt.Sprintf("feature %s: %s", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid enabled features: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// suggest returns the registered feature closest to the unknown name, or "" when none is close enough to be a typo.
+func (r *Registry) suggest(name string) string {
+	const maxDistance = 3
+	best, bestDistance := "", maxDistance+1
+	for _, f := range r.Features {
+		if d := distance(strings.ToUpper(name), f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// Render serializes the toggles into the sorted KEY=VALUE lines of enabled_features.sh. Keys are sorted so the
+// output is deterministic and filtered to valid shell identifiers, the same set the launcher accepts. Entries
+// whose value contains a newline or carriage return are dropped so a single entry can never expand into multiple
+// lines. Returns "" when no valid entry remains, so custom data stays byte-identical when no toggle is set.
+func Render(features map[string]string) string {
+	var b strings.Builder
+	for _, name := range sortedKeys(features) {
+		if nameRe.MatchString(name) && !strings.ContainsAny(features[name], "\n\r") {
+			fmt.Fprintf(&b, "%s=%s\n", name, features[name])
+		}
+	}
+	return b.String()
+}
+
+// ParseFile parses enabled_features.sh the way the launcher does: blank lines, comments and lines whose key is not
+// a valid shell identifier are skipped, and the value is everything after the first "=".
+func ParseFile(contents []byte) map[string]string {
+	features := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		name, value, _ := strings.Cut(scanner.Text(), "=")
+		if nameRe.MatchString(name) {
+			features[name] = value
+		}
+	}
+	return features
+}
+
+// Source is where the effective value of a feature comes from.
+type Source string
+
+const (
+	// SourceDefault values are the registry defaults of toggles which were not delivered.
+	SourceDefault Source = "default"
+	// SourceDelivered values were delivered in enabled_features.sh.
+	SourceDelivered Source = "delivered"
+)
+
+// State is the effective value of a feature on a node.
+type State struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Source      Source `json:"source"`
+	Description string `json:"description,omitempty"`
+	// Problem explains why a delivered toggle does not behave as expected, it is empty otherwise.
+	Problem string `json:"problem,omitempty"`
+}
+
+// Effective returns the state of the registered features and of the delivered toggles, sorted by name.
+// Toggles requiring a newer aks-node-controller are reported when ancVersion is a version; development
+// builds are not checked.
+func (r *Registry) Effective(delivered map[string]string, ancVersion string) []State {
+	current, versionErr := semver.NewVersion(ancVersion)
+	var states []State
+	for _, f := range r.Features {
+		state := State{Name: f.Name, Value: f.Default, Source: SourceDefault, Description: f.Description}
+		if value, ok := delivered[f.Name]; ok {
+			state.Value, state.Source = value, SourceDelivered
+			if err := f.check(value); err != nil {
+				state.Problem = err.Error()
+			} else if minVersion, err := semver.NewVersion(f.MinANCVersion); versionErr == nil && err == nil && current.LessThan(minVersion) {
+				state.Problem = fmt.Sprintf("requires aks-node-controller %s or later, running %s", f.MinANCVersion, ancVersion)
+			}
+		}
+		states = append(states, state)
+	}
+	for _, name := range sortedKeys(delivered) {
+		if _, ok := r.Lookup(name); !ok {
+			states = append(states, State{Name: name, Value: delivered[name], Source: SourceDelivered, Problem: "unknown feature"})
+		}
+	}
+	sort.SliceStable(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
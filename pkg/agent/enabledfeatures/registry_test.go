@@ -0,0 +1,123 @@
+package enabledfeatures
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("feature registry", func() {
+	Context("default registry", func() {
+		It("should parse the embedded registry", func() {
+			Expect(Embedded().Version).To(Equal(SchemaVersion))
+			f, ok := Embedded().Lookup("ENABLE_PROVISIONING_HOTFIX")
+			Expect(ok).To(BeTrue())
+			Expect(f.Type).To(Equal(TypeBool))
+			Expect(f.Default).To(Equal("false"))
+		})
+	})
+
+	Context("Validate", func() {
+		It("should accept registered features", func() {
+			Expect(Embedded().Validate(nil)).To(Succeed())
+			Expect(Embedded().Validate(map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"})).To(Succeed())
+		})
+
+		It("should reject unknown features and suggest the registered name of typos", func() {
+			Expect(Embedded().Validate(map[string]string{
+				"ENABLE_PROVISONING_HOTFIX":  "true",
+				"enable_provisioning_hotfix": "true",
+				"SOMETHING_ELSE":             "1",
+			})).To(MatchError("invalid enabled features: " +
+				`unknown feature "ENABLE_PROVISONING_HOTFIX", did you mean ENABLE_PROVISIONING_HOTFIX?; ` +
+				`unknown feature "SOMETHING_ELSE"; ` +
+				`unknown feature "enable_provisioning_hotfix", did you mean ENABLE_PROVISIONING_HOTFIX?`))
+		})
+
+		It("should reject values not matching the registered type", func() {
+			r, err := Parse([]byte(`{"version": 1, "features": [
+				{"name": "A_BOOL", "type": "bool", "default": "false", "minAncVersion": "202604.01.0", "description": "a"},
+				{"name": "AN_INT", "type": "int", "default": "0", "minAncVersion": "202604.01.0", "description": "b"},
+				{"name": "A_STRING", "type": "string", "default": "", "minAncVersion": "202604.01.0", "description": "c"}
+			]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Validate(map[string]string{"A_BOOL": "1", "AN_INT": "ten", "A_STRING": "x\nEVIL=1"})).To(MatchError("invalid enabled features: " +
+				`feature AN_INT: value "ten" must be an integer; ` +
+				`feature A_BOOL: value "1" must be true or false; ` +
+				`feature A_STRING: value "x\nEVIL=1" must be a single line`))
+			Expect(r.Validate(map[string]string{"A_BOOL": "false", "AN_INT": "-3", "A_STRING": "anything"})).To(Succeed())
+		})
+	})
+
+	Context("Render and ParseFile", func() {
+		It("should render sorted single line entries with valid names only", func() {
+			Expect(Render(nil)).To(BeEmpty())
+			Expect(Render(map[string]string{"1BAD": "x", "INJECT": "x\nEVIL=1"})).To(BeEmpty())
+			Expect(Render(map[string]string{"ZED": "1", "ENABLE_PROVISIONING_HOTFIX": "true", "has-dash": "y"})).
+				To(Equal("ENABLE_PROVISIONING_HOTFIX=true\nZED=1\n"))
+		})
+
+		It("should parse the file the way the launcher does", func() {
+			Expect(ParseFile([]byte("# comment\n\nENABLE_PROVISIONING_HOTFIX=true\nhas-dash=1\nURL=a=b\nLAST=1"))).To(Equal(map[string]string{
+				"ENABLE_PROVISIONING_HOTFIX": "true",
+				"URL":                        "a=b",
+				"LAST":                       "1",
+			}))
+		})
+
+		It("should round trip rendered features", func() {
+			features := map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true", "EMPTY": ""}
+			Expect(ParseFile([]byte(Render(features)))).To(Equal(features))
+		})
+	})
+
+	Context("Effective", func() {
+		It("should report defaults when nothing is delivered", func() {
+			Expect(Embedded().Effective(nil, "202605.30.0")).To(Equal([]State{{
+				Name:        "ENABLE_PROVISIONING_HOTFIX",
+				Value:       "false",
+				Source:      SourceDefault,
+				Description: Embedded().Features[0].Description,
+			}}))
+		})
+
+		It("should report delivered, invalid and unknown toggles", func() {
+			states := Embedded().Effective(map[string]string{"ENABLE_PROVISIONING_HOTFIX": "yes", "ZED": "1"}, "dev")
+			Expect(states).To(HaveLen(2))
+			Expect(states[0].Name).To(Equal("ENABLE_PROVISIONING_HOTFIX"))
+			Expect(states[0].Source).To(Equal(SourceDelivered))
+			Expect(states[0].Problem).To(Equal(`value "yes" must be true or false`))
+			Expect(states[1]).To(Equal(State{Name: "ZED", Value: "1", Source: SourceDelivered, Problem: "unknown feature"}))
+		})
+
+		It("should report toggles the running aks-node-controller does not honor", func() {
+			states := Embedded().Effective(map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"}, "202603.15.2")
+			Expect(states[0].Problem).To(Equal("requires aks-node-controller 202604.01.0 or later, running 202603.15.2"))
+
+			states = Embedded().Effective(map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"}, "202604.01.0")
+			Expect(states[0].Problem).To(BeEmpty())
+		})
+	})
+
+	Context("parsing", func() {
+		It("should reject unknown fields", func() {
+			_, err := Parse([]byte(`{"version": 1, "features": [{"name": "A", "kind": "bool"}]}`))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "kind"`)))
+		})
+
+		It("should reject inconsistent features", func() {
+			_, err := Parse([]byte(`{"version": 2, "features": [
+				{"name": "A", "type": "bool", "default": "yes", "minAncVersion": "202604.01.0", "description": "a"},
+				{"name": "A", "type": "float", "default": "1", "minAncVersion": "latest"},
+				{"name": "has-dash", "type": "string"}
+			]}`))
+			Expect(err).To(MatchError("invalid feature registry: " +
+				`feature "has-dash" is not a valid shell identifier; ` +
+				`feature A default: value "yes" must be true or false; ` +
+				`feature A has no description; ` +
+				`feature A has unknown type "float"; ` +
+				`feature A is duplicated; ` +
+				`feature A minAncVersion "latest" is not a version; ` +
+				`version 2 is not supported, expected 1`))
+		})
+	})
+})
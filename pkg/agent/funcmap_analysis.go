@@ -0,0 +1,256 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package agent
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Azure/agentbaker/parts"
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+)
+
+const (
+	linuxTemplatesDir   = "linux"
+	windowsTemplatesDir = "windows"
+)
+
+// templateBuiltinFuncs are the functions predefined by text/template, they are always available to templates.
+//
+//nolint:gochecknoglobals
+var templateBuiltinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true, "js": true, "len": true,
+	"not": true, "or": true, "print": true, "printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// FuncMapReport describes how the templates embedded in parts.Templates use the baker func map.
+type FuncMapReport struct {
+	// Templates is the sorted list of embedded templates that were analyzed.
+	Templates []string
+	// Unparsable maps a template path to the error returned when parsing it.
+	// Files that are not go templates (e.g. static artifacts) usually land here.
+	Unparsable map[string]string
+	// Calls maps a func map entry to the sorted list of templates calling it.
+	Calls map[string][]string
+	// Unused lists func map entries that are not called by any template.
+	Unused []string
+	// Unknown maps a function name which is called by templates but is neither registered
+	// in the func map nor a text/template builtin, to the templates calling it.
+	Unknown map[string][]string
+	// LinuxOnly lists func map entries only called by templates under parts/linux.
+	LinuxOnly []string
+	// WindowsOnly lists func map entries only called by templates under parts/windows.
+	WindowsOnly []string
+	// PlatformDivergent lists func map entries called by both Linux and Windows templates which
+	// take no argument and return different values for the Linux and the Windows configuration.
+	PlatformDivergent []string
+}
+
+// AnalyzeBakerFuncMap cross-references the functions called by every template embedded in parts.Templates
+// against the func map returned by getBakerFuncMap.
+// linuxConfig and windowsConfig are used to build the func maps, and to evaluate the functions shared by
+// both platforms. Functions are only invoked when both configurations are provided.
+func AnalyzeBakerFuncMap(linuxConfig, windowsConfig *datamodel.NodeBootstrappingConfiguration) (*FuncMapReport, error) {
+	if linuxConfig == nil {
+		return nil, fmt.Errorf("linux configuration is required to build the func map")
+	}
+	linuxFuncMap := getBakerFuncMap(linuxConfig, paramsMap{}, paramsMap{})
+	var windowsFuncMap template.FuncMap
+	if windowsConfig != nil {
+		windowsFuncMap = getBakerFuncMap(windowsConfig, paramsMap{}, paramsMap{})
+	}
+
+	report := &FuncMapReport{
+		Unparsable: map[string]string{},
+		Calls:      map[string][]string{},
+		Unknown:    map[string][]string{},
+	}
+
+	err := fs.WalkDir(parts.Templates, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.Contains(path, "/") {
+			// files at the root of parts are go sources, not templates.
+			return nil
+		}
+		b, err := parts.Templates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isLinuxTemplate(path) {
+			// mirror what the baker does before parsing Linux templates.
+			b = removeComments(b)
+		}
+		report.Templates = append(report.Templates, path)
+		names, err := templateFuncCalls(path, string(b))
+		if err != nil {
+			report.Unparsable[path] = err.Error()
+			return nil
+		}
+		for _, name := range names {
+			if templateBuiltinFuncs[name] {
+				continue
+			}
+			if _, ok := linuxFuncMap[name]; ok {
+				report.Calls[name] = append(report.Calls[name], path)
+			} else {
+				report.Unknown[name] = append(report.Unknown[name], path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk embedded templates: %w", err)
+	}
+
+	for name := range linuxFuncMap {
+		templates, ok := report.Calls[name]
+		if !ok {
+			report.Unused = append(report.Unused, name)
+			continue
+		}
+		usedByLinux, usedByWindows := false, false
+		for _, path := range templates {
+			usedByLinux = usedByLinux || isLinuxTemplate(path)
+			usedByWindows = usedByWindows || isWindowsTemplate(path)
+		}
+		switch {
+		case usedByLinux && !usedByWindows:
+			report.LinuxOnly = append(report.LinuxOnly, name)
+		case usedByWindows && !usedByLinux:
+			report.WindowsOnly = append(report.WindowsOnly, name)
+		case usedByLinux && usedByWindows && windowsFuncMap != nil:
+			if funcResultsDiffer(linuxFuncMap[name], windowsFuncMap[name]) {
+				report.PlatformDivergent = append(report.PlatformDivergent, name)
+			}
+		}
+	}
+
+	sort.Strings(report.Templates)
+	sort.Strings(report.Unused)
+	sort.Strings(report.LinuxOnly)
+	sort.Strings(report.WindowsOnly)
+	sort.Strings(report.PlatformDivergent)
+	for _, templates := range report.Calls {
+		sort.Strings(templates)
+	}
+	for _, templates := range report.Unknown {
+		sort.Strings(templates)
+	}
+	return report, nil
+}
+
+// templateFuncCalls returns the deduplicated names of all functions called by the template content.
+func templateFuncCalls(name, content string) ([]string, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := map[string]*parse.Tree{}
+	if _, err := tree.Parse(content, "", "", treeSet); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, t := range treeSet {
+		if t.Root != nil {
+			collectIdentifiers(t.Root, seen)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+//nolint:cyclop // a switch over all the parse node types is the clearest way to walk the tree.
+func collectIdentifiers(node parse.Node, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.IdentifierNode:
+		seen[n.Ident] = true
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectIdentifiers(child, seen)
+		}
+	case *parse.ActionNode:
+		collectIdentifiers(n.Pipe, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectIdentifiers(cmd, seen)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectIdentifiers(arg, seen)
+		}
+	case *parse.ChainNode:
+		collectIdentifiers(n.Node, seen)
+	case *parse.IfNode:
+		collectBranchIdentifiers(&n.BranchNode, seen)
+	case *parse.RangeNode:
+		collectBranchIdentifiers(&n.BranchNode, seen)
+	case *parse.WithNode:
+		collectBranchIdentifiers(&n.BranchNode, seen)
+	case *parse.TemplateNode:
+		collectIdentifiers(n.Pipe, seen)
+	}
+}
+
+func collectBranchIdentifiers(n *parse.BranchNode, seen map[string]bool) {
+	collectIdentifiers(n.Pipe, seen)
+	collectIdentifiers(n.List, seen)
+	if n.ElseList != nil {
+		collectIdentifiers(n.ElseList, seen)
+	}
+}
+
+// funcResultsDiffer calls two argument-less template functions and reports whether their results differ.
+// Functions taking arguments, or panicking on the given configuration, are reported as not differing.
+func funcResultsDiffer(linuxFn, windowsFn interface{}) bool {
+	linuxResult, ok := callNullaryFunc(linuxFn)
+	if !ok {
+		return false
+	}
+	windowsResult, ok := callNullaryFunc(windowsFn)
+	if !ok {
+		return false
+	}
+	return !reflect.DeepEqual(linuxResult, windowsResult)
+}
+
+func callNullaryFunc(fn interface{}) (results []interface{}, ok bool) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.Type().NumIn() != 0 {
+		return nil, false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			results, ok = nil, false
+		}
+	}()
+	for _, out := range v.Call(nil) {
+		results = append(results, out.Interface())
+	}
+	return results, true
+}
+
+func isLinuxTemplate(path string) bool {
+	return strings.HasPrefix(path, linuxTemplatesDir+"/")
+}
+
+func isWindowsTemplate(path string) bool {
+	return strings.HasPrefix(path, windowsTemplatesDir+"/")
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// knownUnusedFuncs lists the func map entries no template calls. Remove an entry when a template starts calling it, or
+// the func when it is dead; a new func map entry must be called by a template or added here on purpose.
+var knownUnusedFuncs = []string{
+	"AKSCustomCloudActiveDirectoryEndpoint",
+	"AKSCustomCloudBatchManagementEndpoint",
+	"AKSCustomCloudCosmosDBDNSSuffix",
+	"AKSCustomCloudGalleryEndpoint",
+	"AKSCustomCloudGraphEndpoint",
+	"AKSCustomCloudKeyVaultDNSSuffix",
+	"AKSCustomCloudKeyVaultEndpoint",
+	"AKSCustomCloudManagementPortalURL",
+	"AKSCustomCloudPublishSettingsURL",
+	"AKSCustomCloudResourceIdentifiersBatch",
+	"AKSCustomCloudResourceIdentifiersDatalake",
+	"AKSCustomCloudResourceIdentifiersGraph",
+	"AKSCustomCloudResourceIdentifiersKeyVault",
+	"AKSCustomCloudResourceIdentifiersOperationalInsights",
+	"AKSCustomCloudResourceIdentifiersStorage",
+	"AKSCustomCloudResourceManagerEndpoint",
+	"AKSCustomCloudResourceManagerVMDNSSuffix",
+	"AKSCustomCloudServiceBusEndpoint",
+	"AKSCustomCloudServiceBusEndpointSuffix",
+	"AKSCustomCloudServiceManagementEndpoint",
+	"AKSCustomCloudServiceManagementVMDNSSuffix",
+	"AKSCustomCloudSqlDatabaseDNSSuffix",
+	"AKSCustomCloudStorageEndpointSuffix",
+	"AKSCustomCloudTokenAudience",
+	"AKSCustomCloudTrafficManagerDNSSuffix",
+	"BlockIptables",
+	"BoolPtrToInt",
+	"CloseBraces",
+	"GetBase64CertificateAuthorityData",
+	"GetCustomSysctlConfigByName",
+	"GetDataDir",
+	"GetKubeletConfigFileContent",
+	"GetKubeletDiskType",
+	"GetKubernetesAgentPreprovisionYaml",
+	"GetPodInfraContainerSpec",
+	"HasAntreaNetworkPolicy",
+	"HasDCSeriesSKU",
+	"HasDataDir",
+	"HasFlannelNetworkPlugin",
+	"HasHTTPProxy",
+	"HasHTTPSProxy",
+	"HasKubeletClientKey",
+	"HasNoProxy",
+	"HasPrivateAzureRegistryServer",
+	"HasServicePrincipalSecret",
+	"IsAzureCNI",
+	"IsIPMasqAgentEnabled",
+	"IsKubernetes",
+	"IsMIGEnabledNode",
+	"IsNSeriesSKU",
+	"IsNoneCNI",
+	"OpenBraces",
+	"ShouldConfigCustomSysctl",
+	"UseManagedIdentity",
+	"UseRuncShimV2",
+}
+
+var _ = Describe("Baker func map analysis", func() {
+	newConfig := func(osType datamodel.OSType) *datamodel.NodeBootstrappingConfiguration {
+		agentPool := &datamodel.AgentPoolProfile{
+			Name:   "agentpool",
+			OSType: osType,
+		}
+		return &datamodel.NodeBootstrappingConfiguration{
+			ContainerService: &datamodel.ContainerService{
+				Properties: &datamodel.Properties{
+					OrchestratorProfile: &datamodel.OrchestratorProfile{
+						OrchestratorType:    datamodel.Kubernetes,
+						OrchestratorVersion: "1.32.1",
+						KubernetesConfig:    &datamodel.KubernetesConfig{},
+					},
+					HostedMasterProfile: &datamodel.HostedMasterProfile{},
+					AgentPoolProfiles:   []*datamodel.AgentPoolProfile{agentPool},
+				},
+			},
+			AgentPoolProfile: agentPool,
+			CloudSpecConfig:  datamodel.AzurePublicCloudSpecForTest,
+			K8sComponents:    &datamodel.K8sComponents{},
+		}
+	}
+
+	It("should find every template function call in the func map", func() {
+		report, err := AnalyzeBakerFuncMap(newConfig(datamodel.Linux), newConfig(datamodel.Windows))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(report.Templates).To(ContainElements(kubernetesCSECommandString, kubernetesWindowsAgentCustomDataPS1))
+		Expect(report.Unparsable).To(BeEmpty())
+		Expect(report.Unknown).To(BeEmpty(), "templates call functions missing from getBakerFuncMap")
+		Expect(report.Calls).To(HaveKey("GetVariableProperty"))
+
+		Expect(report.Unused).To(Equal(knownUnusedFuncs), "func map entries not called by any template must be listed in knownUnusedFuncs")
+		// Both configurations describe the same cluster, so the argument-less entries shared by the Linux and Windows
+		// templates must render the same values on both platforms.
+		Expect(report.PlatformDivergent).To(BeEmpty(), "func map entries shared by Linux and Windows templates return different values")
+	})
+
+	It("should fail without a Linux configuration", func() {
+		_, err := AnalyzeBakerFuncMap(nil, newConfig(datamodel.Windows))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("templateFuncCalls", func() {
+		It("should collect function calls from nested actions", func() {
+			names, err := templateFuncCalls("test", `{{if IsKubernetes}}{{range $k, $v := GetKeys .}}{{$k | Quote}}{{end}}{{else}}{{with Foo}}{{.Bar}}{{end}}{{end}}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"Foo", "GetKeys", "IsKubernetes", "Quote"}))
+		})
+
+		It("should return an error for a malformed template", func() {
+			_, err := templateFuncCalls("test", `{{if IsKubernetes}}`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("funcResultsDiffer", func() {
+		It("should compare argument-less functions only", func() {
+			Expect(funcResultsDiffer(func() bool { return true }, func() bool { return false })).To(BeTrue())
+			Expect(funcResultsDiffer(func() string { return "a" }, func() string { return "a" })).To(BeFalse())
+			Expect(funcResultsDiffer(func(s string) string { return s }, func(s string) string { return s + "x" })).To(BeFalse())
+			Expect(funcResultsDiffer(func() string { panic("nil profile") }, func() string { return "a" })).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,308 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package kubeletconfig generates the kubelet config file from the upstream KubeletConfiguration type, copied into
+// types.go by hack/tools/cmd/kubeletconfig-gen, with defaulting and validation for the target Kubernetes minor version.
+// It is shared by the baker and by aks-node-controller, so the fields added by new kubelet releases are
+// added once for both.
+package kubeletconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	// Kind and APIVersion are the kind and API version of the kubelet config file.
+	Kind       = "KubeletConfiguration"
+	APIVersion = "kubelet.config.k8s.io/v1beta1"
+)
+
+// fieldMinVersions is the first Kubernetes minor version accepting each KubeletConfiguration field, in its JSON form,
+// which is newer than the oldest Kubernetes version supported by AKS.
+//
+//nolint:gochecknoglobals
+var fieldMinVersions = map[string]string{
+	"containerRuntimeEndpoint":     "1.27",
+	"imageServiceEndpoint":         "1.27",
+	"maxParallelImagePulls":        "1.27",
+	"imageMaximumGCAge":            "1.29",
+	"containerLogMaxWorkers":       "1.30",
+	"containerLogMonitorInterval":  "1.30",
+	"failCgroupV1":                 "1.31",
+	"crashLoopBackOff":             "1.32",
+	"singleProcessOOMKill":         "1.32",
+	"mergeDefaultEvictionSettings": "1.34",
+}
+
+// New returns an empty kubelet config file.
+func New() *KubeletConfiguration {
+	kc := &KubeletConfiguration{}
+	kc.Kind = Kind
+	kc.APIVersion = APIVersion
+	return kc
+}
+
+// SetDefaults sets the kind and API version of the kubelet config file and clears the fields of the kubelet flags
+// dropped at the given Kubernetes version, see kubeletpolicy. The kubelet defaults the fields left unset.
+func SetDefaults(kc *KubeletConfiguration, k8sVersion string) {
+	if kc.Kind == "" {
+		kc.Kind = Kind
+	}
+	if kc.APIVersion == "" {
+		kc.APIVersion = APIVersion
+	}
+	for _, field := range kubeletpolicy.Embedded().DroppedConfigFields(k8sVersion) {
+		clearField(reflect.ValueOf(kc).Elem(), field)
+	}
+}
+
+// clearField sets the field with the given JSON name to its zero value.
+func clearField(v reflect.Value, name string) bool {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		jsonName, _ := parseTag(sf)
+		if sf.Anonymous && jsonName == "" && sf.Type.Kind() == reflect.Struct {
+			if clearField(v.Field(i), name) {
+				return true
+			}
+			continue
+		}
+		if jsonName == name {
+			v.Field(i).SetZero()
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the kubelet would not start with the kubelet config file at the given Kubernetes version.
+// The version is not checked when it is empty or invalid.
+func Validate(kc *KubeletConfiguration, k8sVersion string) error {
+	var errs []string
+	if kc.Kind != Kind || kc.APIVersion != APIVersion {
+		errs = append(errs, fmt.Sprintf("kind and apiVersion must be %s and %s, got %q and %q", Kind, APIVersion, kc.Kind, kc.APIVersion))
+	}
+	if v, err := semver.NewVersion(k8sVersion); err == nil {
+		release, _ := v.SetPrerelease("")
+		for _, field := range setFields(reflect.ValueOf(kc).Elem()) {
+			minVersion, ok := fieldMinVersions[field]
+			if ok && release.LessThan(semver.MustParse(minVersion+".0")) {
+				errs = append(errs, fmt.Sprintf("%s requires Kubernetes %s or later, got %s", field, minVersion, k8sVersion))
+			}
+		}
+	}
+	errs = append(errs, validateValues(kc)...)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid kubelet config file: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//nolint:gocognit,cyclop
+func validateValues(kc *KubeletConfiguration) []string {
+	var errs []string
+	if kc.ImageGCHighThresholdPercent != nil && (*kc.ImageGCHighThresholdPercent < 0 || *kc.ImageGCHighThresholdPercent > 100) {
+		errs = append(errs, fmt.Sprintf("imageGCHighThresholdPercent %d must be between 0 and 100", *kc.ImageGCHighThresholdPercent))
+	}
+	if kc.ImageGCLowThresholdPercent != nil && (*kc.ImageGCLowThresholdPercent < 0 || *kc.ImageGCLowThresholdPercent > 100) {
+		errs = append(errs, fmt.Sprintf("imageGCLowThresholdPercent %d must be between 0 and 100", *kc.ImageGCLowThresholdPercent))
+	}
+	if kc.ImageGCHighThresholdPercent != nil && kc.ImageGCLowThresholdPercent != nil &&
+		*kc.ImageGCLowThresholdPercent >= *kc.ImageGCHighThresholdPercent {
+		errs = append(errs, fmt.Sprintf("imageGCLowThresholdPercent %d must be less than imageGCHighThresholdPercent %d",
+			*kc.ImageGCLowThresholdPercent, *kc.ImageGCHighThresholdPercent))
+	}
+	if kc.EventRecordQPS != nil && *kc.EventRecordQPS < 0 {
+		errs = append(errs, fmt.Sprintf("eventRecordQPS %d must not be negative", *kc.EventRecordQPS))
+	}
+	if kc.MaxPods < 0 {
+		errs = append(errs, fmt.Sprintf("maxPods %d must not be negative", kc.MaxPods))
+	}
+	if kc.ContainerLogMaxFiles != nil && *kc.ContainerLogMaxFiles < 2 {
+		errs = append(errs, fmt.Sprintf("containerLogMaxFiles %d must be greater than 1", *kc.ContainerLogMaxFiles))
+	}
+	if kc.CPUCFSQuotaPeriod != nil && kc.CPUCFSQuotaPeriod.Duration != 0 &&
+		(kc.CPUCFSQuotaPeriod.Milliseconds() < 1 || kc.CPUCFSQuotaPeriod.Seconds() > 1) {
+		errs = append(errs, fmt.Sprintf("cpuCFSQuotaPeriod %s must be between 1ms and 1s", kc.CPUCFSQuotaPeriod.Duration))
+	}
+	switch kc.CPUManagerPolicy {
+	case "", "none", "static":
+	default:
+		errs = append(errs, fmt.Sprintf("unknown cpuManagerPolicy %q", kc.CPUManagerPolicy))
+	}
+	switch kc.TopologyManagerPolicy {
+	case "", "none", "best-effort", "restricted", "single-numa-node":
+	default:
+		errs = append(errs, fmt.Sprintf("unknown topologyManagerPolicy %q", kc.TopologyManagerPolicy))
+	}
+	switch kc.Authorization.Mode {
+	case "", KubeletAuthorizationModeAlwaysAllow, KubeletAuthorizationModeWebhook:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown authorization mode %q", kc.Authorization.Mode))
+	}
+	for _, eviction := range []struct {
+		name    string
+		signals map[string]string
+	}{
+		{"evictionHard", kc.EvictionHard},
+		{"evictionSoft", kc.EvictionSoft},
+		{"evictionSoftGracePeriod", kc.EvictionSoftGracePeriod},
+		{"evictionMinimumReclaim", kc.EvictionMinimumReclaim},
+	} {
+		for _, signal := range slices.Sorted(maps.Keys(eviction.signals)) {
+			if !IsEvictionSignal(signal) {
+				errs = append(errs, fmt.Sprintf("%s has unknown eviction signal %q", eviction.name, signal))
+			}
+		}
+	}
+	return errs
+}
+
+// IsEvictionSignal reports whether the given key is an eviction signal recognized by kubelet.
+// See https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/#eviction-signals.
+func IsEvictionSignal(signal string) bool {
+	switch signal {
+	case "memory.available",
+		"nodefs.available",
+		"nodefs.inodesFree",
+		"imagefs.available",
+		"imagefs.inodesFree",
+		"pid.available",
+		"allocatableMemory.available":
+		return true
+	default:
+		return false
+	}
+}
+
+// Marshal returns the indented JSON kubelet config file. Unlike encoding/json, it omits the zero values of the
+// fields without omitempty, such as durations and nested structs, so the file only holds the fields which are set.
+// Fields are written in the order of KubeletConfiguration.
+func Marshal(kc *KubeletConfiguration) ([]byte, error) {
+	compact, err := json.Marshal(nonZeroFields(reflect.ValueOf(kc).Elem()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet config file: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, "", "    "); err != nil {
+		return nil, fmt.Errorf("failed to indent kubelet config file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// object is a JSON object which keeps the order of its fields.
+type object []field
+
+type field struct {
+	name  string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+//nolint:gochecknoglobals
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// nonZeroFields returns the fields of the struct which are not zero, nested structs are returned as objects.
+// Pointers are kept when they are set, even to a zero value, e.g. eventRecordQPS: 0 disables the limit.
+func nonZeroFields(v reflect.Value) object {
+	var fields object
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, omitEmpty := parseTag(sf)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			fields = append(fields, nonZeroFields(fv)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if fv.IsZero() || (omitEmpty && isEmpty(fv)) {
+			continue
+		}
+		if isPlainStruct(fv.Type()) {
+			if nested := nonZeroFields(fv); len(nested) > 0 {
+				fields = append(fields, field{name: name, value: nested})
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Pointer && isPlainStruct(fv.Type().Elem()) {
+			// a set pointer to a struct is kept even when the struct is empty.
+			fields = append(fields, field{name: name, value: append(object{}, nonZeroFields(fv.Elem())...)})
+			continue
+		}
+		fields = append(fields, field{name: name, value: fv.Interface()})
+	}
+	return fields
+}
+
+// isPlainStruct reports whether t is a struct encoded field by field, rather than by its own MarshalJSON method,
+// like Duration.
+func isPlainStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !t.Implements(marshalerType) && !reflect.PointerTo(t).Implements(marshalerType)
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+func parseTag(sf reflect.StructField) (name string, omitEmpty bool) {
+	name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+// setFields returns the JSON names of the top level fields which are set.
+func setFields(v reflect.Value) []string {
+	var names []string
+	for _, f := range nonZeroFields(v) {
+		names = append(names, f.name)
+	}
+	return names
+}
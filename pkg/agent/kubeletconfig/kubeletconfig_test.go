@@ -0,0 +1,106 @@
+package kubeletconfig
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubelet config file", func() {
+	Context("Marshal", func() {
+		It("should only write the fields which are set, in the order of KubeletConfiguration", func() {
+			kc := New()
+			kc.MaxPods = 110
+			kc.NodeStatusUpdateFrequency = Duration{Duration: 10 * time.Second}
+			kc.Authentication.Webhook.Enabled = ptr(true)
+			kc.EventRecordQPS = ptr(int32(0))
+			kc.FeatureGates = map[string]bool{"b": false, "a": true}
+			kc.UserNamespaces = &UserNamespaces{}
+			kc.TLSCipherSuites = []string{}
+
+			content, err := Marshal(kc)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(`{
+    "kind": "KubeletConfiguration",
+    "apiVersion": "kubelet.config.k8s.io/v1beta1",
+    "authentication": {
+        "webhook": {
+            "enabled": true
+        }
+    },
+    "eventRecordQPS": 0,
+    "nodeStatusUpdateFrequency": "10s",
+    "maxPods": 110,
+    "featureGates": {
+        "a": true,
+        "b": false
+    },
+    "userNamespaces": {}
+}`))
+		})
+	})
+
+	Context("SetDefaults", func() {
+		It("should set the kind and clear the fields removed by the Kubernetes version", func() {
+			kc := &KubeletConfiguration{
+				StreamingConnectionIdleTimeout: Duration{Duration: 4 * time.Hour},
+				MaxPods:                        30,
+			}
+			SetDefaults(kc, "1.33.2")
+			Expect(kc.Kind).To(Equal(Kind))
+			Expect(kc.APIVersion).To(Equal(APIVersion))
+			Expect(kc.StreamingConnectionIdleTimeout.Duration).To(Equal(4 * time.Hour))
+
+			SetDefaults(kc, "1.34.0")
+			Expect(kc.StreamingConnectionIdleTimeout.Duration).To(BeZero())
+			Expect(kc.MaxPods).To(Equal(int32(30)))
+		})
+	})
+
+	Context("Validate", func() {
+		It("should accept a valid config file", func() {
+			kc := New()
+			kc.ImageGCHighThresholdPercent = ptr(int32(85))
+			kc.ImageGCLowThresholdPercent = ptr(int32(80))
+			kc.CPUManagerPolicy = "static"
+			kc.EvictionHard = map[string]string{"memory.available": "750Mi"}
+			kc.ImageMaximumGCAge = Duration{Duration: time.Hour}
+			Expect(Validate(kc, "1.29.0")).To(Succeed())
+			Expect(Validate(kc, "")).To(Succeed())
+		})
+
+		It("should reject the fields newer than the Kubernetes version", func() {
+			kc := New()
+			kc.ImageMaximumGCAge = Duration{Duration: time.Hour}
+			kc.FailCgroupV1 = ptr(false)
+			Expect(Validate(kc, "1.28.5")).To(MatchError("invalid kubelet config file: " +
+				"imageMaximumGCAge requires Kubernetes 1.29 or later, got 1.28.5; failCgroupV1 requires Kubernetes 1.31 or later, got 1.28.5"))
+		})
+
+		It("should reject the values the kubelet does not start with", func() {
+			kc := New()
+			kc.ImageGCHighThresholdPercent = ptr(int32(70))
+			kc.ImageGCLowThresholdPercent = ptr(int32(80))
+			kc.ContainerLogMaxFiles = ptr(int32(1))
+			kc.CPUCFSQuotaPeriod = &Duration{Duration: 2 * time.Second}
+			kc.TopologyManagerPolicy = "best"
+			kc.EvictionSoft = map[string]string{"memory.available": "1Gi", "bogus": "1Gi"}
+			err := Validate(kc, "1.33.0")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("imageGCLowThresholdPercent 80 must be less than imageGCHighThresholdPercent 70"))
+			Expect(err.Error()).To(ContainSubstring("containerLogMaxFiles 1 must be greater than 1"))
+			Expect(err.Error()).To(ContainSubstring("cpuCFSQuotaPeriod 2s must be between 1ms and 1s"))
+			Expect(err.Error()).To(ContainSubstring(`unknown topologyManagerPolicy "best"`))
+			Expect(err.Error()).To(ContainSubstring(`evictionSoft has unknown eviction signal "bogus"`))
+		})
+
+		It("should reject a config file of another kind", func() {
+			Expect(Validate(&KubeletConfiguration{}, "")).To(MatchError(ContainSubstring("kind and apiVersion must be")))
+		})
+	})
+})
+
+func ptr[T any](v T) *T {
+	return &v
+}
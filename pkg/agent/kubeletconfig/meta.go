@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubeletconfig
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// types.go is generated from k8s.io/kubelet, so the node binaries don't depend on it. Bump k8s.io/kubelet in
+// hack/tools/go.mod and run go generate to get the fields of a new kubelet release.
+//go:generate go -C ../../../hack/tools run ./cmd/kubeletconfig-gen -o ../../pkg/agent/kubeletconfig/types.go
+
+// TypeMeta holds the kind and API version of the kubelet config file.
+type TypeMeta struct {
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+const (
+	// KubeletAuthorizationModeAlwaysAllow authorizes all authenticated requests.
+	KubeletAuthorizationModeAlwaysAllow KubeletAuthorizationMode = "AlwaysAllow"
+	// KubeletAuthorizationModeWebhook uses the SubjectAccessReview API to determine authorization.
+	KubeletAuthorizationModeWebhook KubeletAuthorizationMode = "Webhook"
+)
+
+// Duration is a time.Duration written as a string in the kubelet config file, like the metav1.Duration of apimachinery.
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
@@ -0,0 +1,256 @@
+// Code generated by kubeletconfig-gen from k8s.io/kubelet v0.35.9. DO NOT EDIT.
+
+package kubeletconfig
+
+import "time"
+
+// KubeletConfiguration is the kubelet config file, generated from k8s.io/kubelet/config/v1beta1.KubeletConfiguration.
+// See https://kubernetes.io/docs/reference/config-api/kubelet-config.v1beta1/.
+type KubeletConfiguration struct {
+	TypeMeta                                  `json:",inline"`
+	EnableServer                              *bool                                  `json:"enableServer,omitempty"`
+	StaticPodPath                             string                                 `json:"staticPodPath,omitempty"`
+	PodLogsDir                                string                                 `json:"podLogsDir,omitempty"`
+	SyncFrequency                             Duration                               `json:"syncFrequency,omitempty"`
+	FileCheckFrequency                        Duration                               `json:"fileCheckFrequency,omitempty"`
+	HTTPCheckFrequency                        Duration                               `json:"httpCheckFrequency,omitempty"`
+	StaticPodURL                              string                                 `json:"staticPodURL,omitempty"`
+	StaticPodURLHeader                        map[string][]string                    `json:"staticPodURLHeader,omitempty"`
+	Address                                   string                                 `json:"address,omitempty"`
+	Port                                      int32                                  `json:"port,omitempty"`
+	ReadOnlyPort                              int32                                  `json:"readOnlyPort,omitempty"`
+	TLSCertFile                               string                                 `json:"tlsCertFile,omitempty"`
+	TLSPrivateKeyFile                         string                                 `json:"tlsPrivateKeyFile,omitempty"`
+	TLSCipherSuites                           []string                               `json:"tlsCipherSuites,omitempty"`
+	TLSMinVersion                             string                                 `json:"tlsMinVersion,omitempty"`
+	RotateCertificates                        bool                                   `json:"rotateCertificates,omitempty"`
+	ServerTLSBootstrap                        bool                                   `json:"serverTLSBootstrap,omitempty"`
+	Authentication                            KubeletAuthentication                  `json:"authentication"`
+	Authorization                             KubeletAuthorization                   `json:"authorization"`
+	RegistryPullQPS                           *int32                                 `json:"registryPullQPS,omitempty"`
+	RegistryBurst                             int32                                  `json:"registryBurst,omitempty"`
+	ImagePullCredentialsVerificationPolicy    ImagePullCredentialsVerificationPolicy `json:"imagePullCredentialsVerificationPolicy,omitempty"`
+	PreloadedImagesVerificationAllowlist      []string                               `json:"preloadedImagesVerificationAllowlist,omitempty"`
+	EventRecordQPS                            *int32                                 `json:"eventRecordQPS,omitempty"`
+	EventBurst                                int32                                  `json:"eventBurst,omitempty"`
+	EnableDebuggingHandlers                   *bool                                  `json:"enableDebuggingHandlers,omitempty"`
+	EnableContentionProfiling                 bool                                   `json:"enableContentionProfiling,omitempty"`
+	HealthzPort                               *int32                                 `json:"healthzPort,omitempty"`
+	HealthzBindAddress                        string                                 `json:"healthzBindAddress,omitempty"`
+	OOMScoreAdj                               *int32                                 `json:"oomScoreAdj,omitempty"`
+	ClusterDomain                             string                                 `json:"clusterDomain,omitempty"`
+	ClusterDNS                                []string                               `json:"clusterDNS,omitempty"`
+	StreamingConnectionIdleTimeout            Duration                               `json:"streamingConnectionIdleTimeout,omitempty"`
+	NodeStatusUpdateFrequency                 Duration                               `json:"nodeStatusUpdateFrequency,omitempty"`
+	NodeStatusReportFrequency                 Duration                               `json:"nodeStatusReportFrequency,omitempty"`
+	NodeLeaseDurationSeconds                  int32                                  `json:"nodeLeaseDurationSeconds,omitempty"`
+	ImageMinimumGCAge                         Duration                               `json:"imageMinimumGCAge,omitempty"`
+	ImageMaximumGCAge                         Duration                               `json:"imageMaximumGCAge,omitempty"`
+	ImageGCHighThresholdPercent               *int32                                 `json:"imageGCHighThresholdPercent,omitempty"`
+	ImageGCLowThresholdPercent                *int32                                 `json:"imageGCLowThresholdPercent,omitempty"`
+	VolumeStatsAggPeriod                      Duration                               `json:"volumeStatsAggPeriod,omitempty"`
+	KubeletCgroups                            string                                 `json:"kubeletCgroups,omitempty"`
+	SystemCgroups                             string                                 `json:"systemCgroups,omitempty"`
+	CgroupRoot                                string                                 `json:"cgroupRoot,omitempty"`
+	CgroupsPerQOS                             *bool                                  `json:"cgroupsPerQOS,omitempty"`
+	CgroupDriver                              string                                 `json:"cgroupDriver,omitempty"`
+	CPUManagerPolicy                          string                                 `json:"cpuManagerPolicy,omitempty"`
+	SingleProcessOOMKill                      *bool                                  `json:"singleProcessOOMKill,omitempty"`
+	CPUManagerPolicyOptions                   map[string]string                      `json:"cpuManagerPolicyOptions,omitempty"`
+	CPUManagerReconcilePeriod                 Duration                               `json:"cpuManagerReconcilePeriod,omitempty"`
+	MemoryManagerPolicy                       string                                 `json:"memoryManagerPolicy,omitempty"`
+	TopologyManagerPolicy                     string                                 `json:"topologyManagerPolicy,omitempty"`
+	TopologyManagerScope                      string                                 `json:"topologyManagerScope,omitempty"`
+	TopologyManagerPolicyOptions              map[string]string                      `json:"topologyManagerPolicyOptions,omitempty"`
+	QOSReserved                               map[string]string                      `json:"qosReserved,omitempty"`
+	RuntimeRequestTimeout                     Duration                               `json:"runtimeRequestTimeout,omitempty"`
+	HairpinMode                               string                                 `json:"hairpinMode,omitempty"`
+	MaxPods                                   int32                                  `json:"maxPods,omitempty"`
+	PodCIDR                                   string                                 `json:"podCIDR,omitempty"`
+	PodPidsLimit                              *int64                                 `json:"podPidsLimit,omitempty"`
+	ResolverConfig                            *string                                `json:"resolvConf,omitempty"`
+	RunOnce                                   bool                                   `json:"runOnce,omitempty"`
+	CPUCFSQuota                               *bool                                  `json:"cpuCFSQuota,omitempty"`
+	CPUCFSQuotaPeriod                         *Duration                              `json:"cpuCFSQuotaPeriod,omitempty"`
+	NodeStatusMaxImages                       *int32                                 `json:"nodeStatusMaxImages,omitempty"`
+	MaxOpenFiles                              int64                                  `json:"maxOpenFiles,omitempty"`
+	ContentType                               string                                 `json:"contentType,omitempty"`
+	KubeAPIQPS                                *int32                                 `json:"kubeAPIQPS,omitempty"`
+	KubeAPIBurst                              int32                                  `json:"kubeAPIBurst,omitempty"`
+	SerializeImagePulls                       *bool                                  `json:"serializeImagePulls,omitempty"`
+	MaxParallelImagePulls                     *int32                                 `json:"maxParallelImagePulls,omitempty"`
+	EvictionHard                              map[string]string                      `json:"evictionHard,omitempty"`
+	EvictionSoft                              map[string]string                      `json:"evictionSoft,omitempty"`
+	EvictionSoftGracePeriod                   map[string]string                      `json:"evictionSoftGracePeriod,omitempty"`
+	EvictionPressureTransitionPeriod          Duration                               `json:"evictionPressureTransitionPeriod,omitempty"`
+	EvictionMaxPodGracePeriod                 int32                                  `json:"evictionMaxPodGracePeriod,omitempty"`
+	EvictionMinimumReclaim                    map[string]string                      `json:"evictionMinimumReclaim,omitempty"`
+	MergeDefaultEvictionSettings              *bool                                  `json:"mergeDefaultEvictionSettings,omitempty"`
+	PodsPerCore                               int32                                  `json:"podsPerCore,omitempty"`
+	EnableControllerAttachDetach              *bool                                  `json:"enableControllerAttachDetach,omitempty"`
+	ProtectKernelDefaults                     bool                                   `json:"protectKernelDefaults,omitempty"`
+	MakeIPTablesUtilChains                    *bool                                  `json:"makeIPTablesUtilChains,omitempty"`
+	IPTablesMasqueradeBit                     *int32                                 `json:"iptablesMasqueradeBit,omitempty"`
+	IPTablesDropBit                           *int32                                 `json:"iptablesDropBit,omitempty"`
+	FeatureGates                              map[string]bool                        `json:"featureGates,omitempty"`
+	FailSwapOn                                *bool                                  `json:"failSwapOn,omitempty"`
+	MemorySwap                                MemorySwapConfiguration                `json:"memorySwap,omitempty"`
+	ContainerLogMaxSize                       string                                 `json:"containerLogMaxSize,omitempty"`
+	ContainerLogMaxFiles                      *int32                                 `json:"containerLogMaxFiles,omitempty"`
+	ContainerLogMaxWorkers                    *int32                                 `json:"containerLogMaxWorkers,omitempty"`
+	ContainerLogMonitorInterval               *Duration                              `json:"containerLogMonitorInterval,omitempty"`
+	ConfigMapAndSecretChangeDetectionStrategy ResourceChangeDetectionStrategy        `json:"configMapAndSecretChangeDetectionStrategy,omitempty"`
+	SystemReserved                            map[string]string                      `json:"systemReserved,omitempty"`
+	KubeReserved                              map[string]string                      `json:"kubeReserved,omitempty"`
+	ReservedSystemCPUs                        string                                 `json:"reservedSystemCPUs,omitempty"`
+	ShowHiddenMetricsForVersion               string                                 `json:"showHiddenMetricsForVersion,omitempty"`
+	SystemReservedCgroup                      string                                 `json:"systemReservedCgroup,omitempty"`
+	KubeReservedCgroup                        string                                 `json:"kubeReservedCgroup,omitempty"`
+	EnforceNodeAllocatable                    []string                               `json:"enforceNodeAllocatable,omitempty"`
+	AllowedUnsafeSysctls                      []string                               `json:"allowedUnsafeSysctls,omitempty"`
+	VolumePluginDir                           string                                 `json:"volumePluginDir,omitempty"`
+	ProviderID                                string                                 `json:"providerID,omitempty"`
+	KernelMemcgNotification                   bool                                   `json:"kernelMemcgNotification,omitempty"`
+	Logging                                   LoggingConfiguration                   `json:"logging,omitempty"`
+	EnableSystemLogHandler                    *bool                                  `json:"enableSystemLogHandler,omitempty"`
+	EnableSystemLogQuery                      *bool                                  `json:"enableSystemLogQuery,omitempty"`
+	ShutdownGracePeriod                       Duration                               `json:"shutdownGracePeriod,omitempty"`
+	ShutdownGracePeriodCriticalPods           Duration                               `json:"shutdownGracePeriodCriticalPods,omitempty"`
+	ShutdownGracePeriodByPodPriority          []ShutdownGracePeriodByPodPriority     `json:"shutdownGracePeriodByPodPriority,omitempty"`
+	CrashLoopBackOff                          CrashLoopBackOffConfig                 `json:"crashLoopBackOff,omitempty"`
+	ReservedMemory                            []MemoryReservation                    `json:"reservedMemory,omitempty"`
+	EnableProfilingHandler                    *bool                                  `json:"enableProfilingHandler,omitempty"`
+	EnableDebugFlagsHandler                   *bool                                  `json:"enableDebugFlagsHandler,omitempty"`
+	SeccompDefault                            *bool                                  `json:"seccompDefault,omitempty"`
+	MemoryThrottlingFactor                    *float64                               `json:"memoryThrottlingFactor,omitempty"`
+	RegisterWithTaints                        []Taint                                `json:"registerWithTaints,omitempty"`
+	RegisterNode                              *bool                                  `json:"registerNode,omitempty"`
+	Tracing                                   *TracingConfiguration                  `json:"tracing,omitempty"`
+	LocalStorageCapacityIsolation             *bool                                  `json:"localStorageCapacityIsolation,omitempty"`
+	ContainerRuntimeEndpoint                  string                                 `json:"containerRuntimeEndpoint"`
+	ImageServiceEndpoint                      string                                 `json:"imageServiceEndpoint,omitempty"`
+	FailCgroupV1                              *bool                                  `json:"failCgroupV1,omitempty"`
+	UserNamespaces                            *UserNamespaces                        `json:"userNamespaces,omitempty"`
+}
+
+// KubeletAuthentication is generated from k8s.io/kubelet/config/v1beta1.KubeletAuthentication.
+type KubeletAuthentication struct {
+	X509      KubeletX509Authentication      `json:"x509"`
+	Webhook   KubeletWebhookAuthentication   `json:"webhook"`
+	Anonymous KubeletAnonymousAuthentication `json:"anonymous"`
+}
+
+// KubeletAuthorization is generated from k8s.io/kubelet/config/v1beta1.KubeletAuthorization.
+type KubeletAuthorization struct {
+	Mode    KubeletAuthorizationMode    `json:"mode,omitempty"`
+	Webhook KubeletWebhookAuthorization `json:"webhook"`
+}
+
+// ImagePullCredentialsVerificationPolicy is generated from k8s.io/kubelet/config/v1beta1.ImagePullCredentialsVerificationPolicy.
+type ImagePullCredentialsVerificationPolicy string
+
+// MemorySwapConfiguration is generated from k8s.io/kubelet/config/v1beta1.MemorySwapConfiguration.
+type MemorySwapConfiguration struct {
+	SwapBehavior string `json:"swapBehavior,omitempty"`
+}
+
+// ResourceChangeDetectionStrategy is generated from k8s.io/kubelet/config/v1beta1.ResourceChangeDetectionStrategy.
+type ResourceChangeDetectionStrategy string
+
+// LoggingConfiguration is generated from k8s.io/component-base/logs/api/v1.LoggingConfiguration.
+type LoggingConfiguration struct {
+	Format         string        `json:"format,omitempty"`
+	FlushFrequency Duration      `json:"flushFrequency"`
+	Verbosity      uint32        `json:"verbosity"`
+	VModule        []VModuleItem `json:"vmodule,omitempty"`
+	Options        FormatOptions `json:"options,omitempty"`
+}
+
+// ShutdownGracePeriodByPodPriority is generated from k8s.io/kubelet/config/v1beta1.ShutdownGracePeriodByPodPriority.
+type ShutdownGracePeriodByPodPriority struct {
+	Priority                   int32 `json:"priority"`
+	ShutdownGracePeriodSeconds int64 `json:"shutdownGracePeriodSeconds"`
+}
+
+// CrashLoopBackOffConfig is generated from k8s.io/kubelet/config/v1beta1.CrashLoopBackOffConfig.
+type CrashLoopBackOffConfig struct {
+	MaxContainerRestartPeriod *Duration `json:"maxContainerRestartPeriod,omitempty"`
+}
+
+// MemoryReservation is generated from k8s.io/kubelet/config/v1beta1.MemoryReservation.
+type MemoryReservation struct {
+	NumaNode int32             `json:"numaNode"`
+	Limits   map[string]string `json:"limits"`
+}
+
+// Taint is generated from k8s.io/api/core/v1.Taint.
+type Taint struct {
+	Key       string     `json:"key"`
+	Value     string     `json:"value,omitempty"`
+	Effect    string     `json:"effect"`
+	TimeAdded *time.Time `json:"timeAdded,omitempty"`
+}
+
+// TracingConfiguration is generated from k8s.io/component-base/tracing/api/v1.TracingConfiguration.
+type TracingConfiguration struct {
+	Endpoint               *string `json:"endpoint,omitempty"`
+	SamplingRatePerMillion *int32  `json:"samplingRatePerMillion,omitempty"`
+}
+
+// UserNamespaces is generated from k8s.io/kubelet/config/v1beta1.UserNamespaces.
+type UserNamespaces struct {
+	IDsPerPod *int64 `json:"idsPerPod,omitempty"`
+}
+
+// KubeletX509Authentication is generated from k8s.io/kubelet/config/v1beta1.KubeletX509Authentication.
+type KubeletX509Authentication struct {
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+}
+
+// KubeletWebhookAuthentication is generated from k8s.io/kubelet/config/v1beta1.KubeletWebhookAuthentication.
+type KubeletWebhookAuthentication struct {
+	Enabled  *bool    `json:"enabled,omitempty"`
+	CacheTTL Duration `json:"cacheTTL,omitempty"`
+}
+
+// KubeletAnonymousAuthentication is generated from k8s.io/kubelet/config/v1beta1.KubeletAnonymousAuthentication.
+type KubeletAnonymousAuthentication struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// KubeletAuthorizationMode is generated from k8s.io/kubelet/config/v1beta1.KubeletAuthorizationMode.
+type KubeletAuthorizationMode string
+
+// KubeletWebhookAuthorization is generated from k8s.io/kubelet/config/v1beta1.KubeletWebhookAuthorization.
+type KubeletWebhookAuthorization struct {
+	CacheAuthorizedTTL   Duration `json:"cacheAuthorizedTTL,omitempty"`
+	CacheUnauthorizedTTL Duration `json:"cacheUnauthorizedTTL,omitempty"`
+}
+
+// VModuleItem is generated from k8s.io/component-base/logs/api/v1.VModuleItem.
+type VModuleItem struct {
+	FilePattern string `json:"filePattern"`
+	Verbosity   uint32 `json:"verbosity"`
+}
+
+// FormatOptions is generated from k8s.io/component-base/logs/api/v1.FormatOptions.
+type FormatOptions struct {
+	Text TextOptions `json:"text,omitempty"`
+	JSON JSONOptions `json:"json,omitempty"`
+}
+
+// TextOptions is generated from k8s.io/component-base/logs/api/v1.TextOptions.
+type TextOptions struct {
+	OutputRoutingOptions `json:",inline"`
+}
+
+// JSONOptions is generated from k8s.io/component-base/logs/api/v1.JSONOptions.
+type JSONOptions struct {
+	OutputRoutingOptions `json:",inline"`
+}
+
+// OutputRoutingOptions is generated from k8s.io/component-base/logs/api/v1.OutputRoutingOptions.
+type OutputRoutingOptions struct {
+	SplitStream    bool   `json:"splitStream,omitempty"`
+	InfoBufferSize string `json:"infoBufferSize,omitempty"`
+}
@@ -0,0 +1,13 @@
+package kubeletpolicy
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestKubeletPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "kubeletpolicy suite")
+}
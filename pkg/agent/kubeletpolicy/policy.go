@@ -0,0 +1,275 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package kubeletpolicy describes, per Kubernetes minor version, which kubelet command line flags are allowed,
+// deprecated, removed, or only accepted through the kubelet config file.
+// It is shared by the baker and by aks-node-controller, so supporting a new Kubernetes release only requires
+// updating policy.json.
+package kubeletpolicy
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// State is the state of a kubelet flag at a given Kubernetes version.
+type State string
+
+const (
+	// StateAllowed flags are accepted on the kubelet command line. Flags missing from the policy are allowed.
+	StateAllowed State = "allowed"
+	// StateDeprecated flags are still accepted, but will be removed by a future Kubernetes release.
+	StateDeprecated State = "deprecated"
+	// StateConfigFile flags are no longer accepted on the command line, and must be set through the kubelet config file.
+	StateConfigFile State = "configFile"
+	// StateRemoved flags are not known to the kubelet anymore.
+	StateRemoved State = "removed"
+)
+
+// Rule sets the state of a flag from a Kubernetes minor version onwards, until the next rule of the same flag.
+type Rule struct {
+	// Since is the first Kubernetes minor version the rule applies to, e.g. "1.34".
+	Since string `json:"since"`
+	State State  `json:"state"`
+	// Drop makes a removed flag silently dropped instead of rejected. It is used for flags which are
+	// still sent by older callers and are safe to ignore.
+	Drop   bool   `json:"drop,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	since *semver.Version
+}
+
+// FlagPolicy is the versioned policy of a single kubelet flag.
+type FlagPolicy struct {
+	// ConfigField is the KubeletConfiguration field, in its JSON form, set by the flag.
+	// It is cleared from the kubelet config file together with a dropped flag.
+	ConfigField string `json:"configField,omitempty"`
+	// Rules are sorted by ascending Since version.
+	Rules []Rule `json:"rules"`
+}
+
+// Policy is the versioned kubelet flag policy table.
+type Policy struct {
+	// LatestMinor is the newest Kubernetes minor version the table has been reviewed against.
+	LatestMinor string                `json:"latestMinor"`
+	Flags       map[string]FlagPolicy `json:"flags"`
+}
+
+//go:embed policy.json
+var embeddedPolicyJSON []byte
+
+//nolint:gochecknoglobals
+var embeddedPolicy = mustParse(embeddedPolicyJSON)
+
+// Embedded returns the policy table embedded in the binary.
+func Embedded() *Policy {
+	return embeddedPolicy
+}
+
+func mustParse(contents []byte) *Policy {
+	p, err := Parse(contents)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Parse parses and validates a JSON kubelet flag policy table.
+func Parse(contents []byte) (*Policy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+
+	var p Policy
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet flag policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	var errs []string
+	if _, err := parseMinor(p.LatestMinor); err != nil {
+		errs = append(errs, fmt.Sprintf("latestMinor: %s", err))
+	}
+	for flag, fp := range p.Flags {
+		if !strings.HasPrefix(flag, "--") {
+			errs = append(errs, fmt.Sprintf("flag %q must start with --", flag))
+		}
+		if len(fp.Rules) == 0 {
+			errs = append(errs, fmt.Sprintf("flag %s has no rule", flag))
+		}
+		for i := range fp.Rules {
+			rule := &fp.Rules[i]
+			v, err := parseMinor(rule.Since)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("flag %s: %s", flag, err))
+				continue
+			}
+			rule.since = v
+			switch rule.State {
+			case StateAllowed, StateDeprecated, StateConfigFile, StateRemoved:
+			default:
+				errs = append(errs, fmt.Sprintf("flag %s has unknown state %q", flag, rule.State))
+			}
+			if rule.Drop && rule.State != StateRemoved {
+				errs = append(errs, fmt.Sprintf("flag %s can only be dropped once removed", flag))
+			}
+			if i > 0 && fp.Rules[i-1].since != nil && !rule.since.GreaterThan(fp.Rules[i-1].since) {
+				errs = append(errs, fmt.Sprintf("flag %s rules are not sorted by ascending version", flag))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid kubelet flag policy: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parseMinor parses a "major.minor" version.
+func parseMinor(minor string) (*semver.Version, error) {
+	if strings.Count(minor, ".") != 1 {
+		return nil, fmt.Errorf("%q is not a major.minor version", minor)
+	}
+	v, err := semver.StrictNewVersion(minor + ".0")
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a major.minor version: %w", minor, err)
+	}
+	return v, nil
+}
+
+// RuleFor returns the rule applying to the flag at the given Kubernetes version.
+// ok is false when the flag is allowed because no rule applies, including when the version cannot be parsed.
+func (p *Policy) RuleFor(flag, k8sVersion string) (rule Rule, ok bool) {
+	fp, found := p.Flags[flag]
+	if !found {
+		return Rule{}, false
+	}
+	v, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return Rule{}, false
+	}
+	// pre-release versions (e.g. 1.34.0-alpha.1) are treated as their release.
+	release, _ := v.SetPrerelease("")
+	for i := len(fp.Rules) - 1; i >= 0; i-- {
+		if !release.LessThan(fp.Rules[i].since) {
+			return fp.Rules[i], true
+		}
+	}
+	return Rule{}, false
+}
+
+// StateOf returns the state of the flag at the given Kubernetes version.
+func (p *Policy) StateOf(flag, k8sVersion string) State {
+	rule, ok := p.RuleFor(flag, k8sVersion)
+	if !ok {
+		return StateAllowed
+	}
+	return rule.State
+}
+
+// DroppedFlags returns the removed flags which are silently dropped at the given Kubernetes version.
+func (p *Policy) DroppedFlags(k8sVersion string) map[string]bool {
+	flags := map[string]bool{}
+	for flag := range p.Flags {
+		if rule, ok := p.RuleFor(flag, k8sVersion); ok && rule.Drop {
+			flags[flag] = true
+		}
+	}
+	return flags
+}
+
+// DroppedConfigFields returns the KubeletConfiguration fields of the flags dropped at the given Kubernetes version.
+func (p *Policy) DroppedConfigFields(k8sVersion string) []string {
+	var fields []string
+	for flag := range p.DroppedFlags(k8sVersion) {
+		if field := p.Flags[flag].ConfigField; field != "" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Violation is a flag that must not be passed to the kubelet.
+type Violation struct {
+	Flag   string
+	Reason string
+}
+
+// InvalidFlagsError is returned when kubelet flags are not accepted at the requested Kubernetes version.
+type InvalidFlagsError struct {
+	KubernetesVersion string
+	Violations        []Violation
+}
+
+func (e *InvalidFlagsError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("%s (%s)", v.Flag, v.Reason))
+	}
+	return fmt.Sprintf("kubelet flags are not supported by Kubernetes %s: %s", e.KubernetesVersion, strings.Join(msgs, ", "))
+}
+
+// Result is the outcome of evaluating kubelet flags against the policy. All lists are sorted.
+type Result struct {
+	// Dropped are removed flags which must be deleted before the flags are rendered.
+	Dropped []string
+	// Deprecated are flags which are still accepted, but will be removed by a future Kubernetes release.
+	Deprecated []string
+	// ConfigFileOnly are flags which must be rendered in the kubelet config file instead of the command line.
+	ConfigFileOnly []string
+	// Violations are flags which are not accepted at all.
+	Violations []Violation
+}
+
+// Evaluate checks the kubelet flags against the policy at the given Kubernetes version.
+// configFileEnabled tells whether the kubelet config file is generated, it is required by StateConfigFile flags.
+// The flags are not modified. An *InvalidFlagsError is returned together with the result when a flag is not accepted.
+func (p *Policy) Evaluate(k8sVersion string, flags map[string]string, configFileEnabled bool) (*Result, error) {
+	result := &Result{}
+	for flag := range flags {
+		rule, ok := p.RuleFor(flag, k8sVersion)
+		if !ok {
+			continue
+		}
+		switch {
+		case rule.State == StateDeprecated:
+			result.Deprecated = append(result.Deprecated, flag)
+		case rule.State == StateConfigFile && configFileEnabled:
+			result.ConfigFileOnly = append(result.ConfigFileOnly, flag)
+		case rule.State == StateConfigFile:
+			result.Violations = append(result.Violations, Violation{Flag: flag, Reason: "only supported in the kubelet config file, which is disabled"})
+		case rule.State == StateRemoved && rule.Drop:
+			result.Dropped = append(result.Dropped, flag)
+		case rule.State == StateRemoved:
+			result.Violations = append(result.Violations, Violation{Flag: flag, Reason: fmt.Sprintf("removed in %s: %s", rule.Since, rule.Reason)})
+		}
+	}
+	sort.Strings(result.Dropped)
+	sort.Strings(result.Deprecated)
+	sort.Strings(result.ConfigFileOnly)
+	sort.Slice(result.Violations, func(i, j int) bool { return result.Violations[i].Flag < result.Violations[j].Flag })
+	if len(result.Violations) > 0 {
+		return result, &InvalidFlagsError{KubernetesVersion: k8sVersion, Violations: result.Violations}
+	}
+	return result, nil
+}
+
+// Apply evaluates the flags like Evaluate, and deletes the dropped flags from the map.
+func (p *Policy) Apply(k8sVersion string, flags map[string]string, configFileEnabled bool) (*Result, error) {
+	result, err := p.Evaluate(k8sVersion, flags, configFileEnabled)
+	for _, flag := range result.Dropped {
+		delete(flags, flag)
+	}
+	return result, err
+}
@@ -0,0 +1,166 @@
+package kubeletpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("kubelet flag policy", func() {
+	Context("default policy", func() {
+		It("should parse the embedded table", func() {
+			Expect(Embedded()).NotTo(BeNil())
+			Expect(Embedded().Flags).NotTo(BeEmpty())
+		})
+
+		It("should be reviewed against the newest kubelet shipped on the VHDs", func() {
+			contents, err := os.ReadFile(filepath.Join("..", "..", "..", "parts", "common", "components.json"))
+			Expect(err).NotTo(HaveOccurred())
+			var components struct {
+				Packages []struct {
+					Name         string `json:"name"`
+					DownloadURIs map[string]map[string]struct {
+						VersionsV2 []struct {
+							K8sVersion string `json:"k8sVersion"`
+						} `json:"versionsV2"`
+					} `json:"downloadURIs"`
+				} `json:"Packages"`
+			}
+			Expect(json.Unmarshal(contents, &components)).To(Succeed())
+
+			latest, err := parseMinor(Embedded().LatestMinor)
+			Expect(err).NotTo(HaveOccurred())
+			found := false
+			for _, pkg := range components.Packages {
+				if pkg.Name != "kubelet" {
+					continue
+				}
+				for _, releases := range pkg.DownloadURIs {
+					for _, release := range releases {
+						for _, v := range release.VersionsV2 {
+							minor, err := parseMinor(v.K8sVersion)
+							Expect(err).NotTo(HaveOccurred())
+							found = true
+							Expect(minor.GreaterThan(latest)).To(BeFalse(),
+								"kubelet %s is shipped, review policy.json and bump latestMinor", v.K8sVersion)
+						}
+					}
+				}
+			}
+			Expect(found).To(BeTrue())
+		})
+
+		It("should remove streaming-connection-idle-timeout from 1.34", func() {
+			p := Embedded()
+			Expect(p.StateOf("--streaming-connection-idle-timeout", "1.24.0")).To(Equal(StateAllowed))
+			Expect(p.StateOf("--streaming-connection-idle-timeout", "1.33.5")).To(Equal(StateDeprecated))
+			Expect(p.StateOf("--streaming-connection-idle-timeout", "1.34.0")).To(Equal(StateRemoved))
+			Expect(p.StateOf("--streaming-connection-idle-timeout", "1.34.0-alpha.1")).To(Equal(StateRemoved))
+			Expect(p.DroppedConfigFields("1.34.0")).To(ContainElement("streamingConnectionIdleTimeout"))
+			Expect(p.DroppedConfigFields("1.33.0")).NotTo(ContainElement("streamingConnectionIdleTimeout"))
+		})
+
+		It("should allow flags without rules and unparsable versions", func() {
+			Expect(Embedded().StateOf("--max-pods", "1.34.0")).To(Equal(StateAllowed))
+			Expect(Embedded().StateOf("--container-runtime", "latest")).To(Equal(StateAllowed))
+		})
+	})
+
+	Context("Parse", func() {
+		It("should reject invalid tables", func() {
+			_, err := Parse([]byte(`{"latestMinor": "1.34.1", "flags": {}}`))
+			Expect(err).To(MatchError(ContainSubstring("latestMinor")))
+
+			_, err = Parse([]byte(`{"latestMinor": "1.34", "flags": {"max-pods": {"rules": [{"since": "1.20", "state": "removed"}]}}}`))
+			Expect(err).To(MatchError(ContainSubstring("must start with --")))
+
+			_, err = Parse([]byte(`{"latestMinor": "1.34", "flags": {"--a": {"rules": [{"since": "1.20", "state": "gone"}]}}}`))
+			Expect(err).To(MatchError(ContainSubstring(`unknown state "gone"`)))
+
+			_, err = Parse([]byte(`{"latestMinor": "1.34", "flags": {"--a": {"rules": [{"since": "1.20", "state": "deprecated", "drop": true}]}}}`))
+			Expect(err).To(MatchError(ContainSubstring("can only be dropped once removed")))
+
+			_, err = Parse([]byte(`{"latestMinor": "1.34", "flags": {"--a": {"rules": [
+				{"since": "1.30", "state": "removed"}, {"since": "1.20", "state": "deprecated"}]}}}`))
+			Expect(err).To(MatchError(ContainSubstring("not sorted")))
+
+			_, err = Parse([]byte(`{"latestMinor": "1.34", "flags": {}, "unknown": true}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Evaluate", func() {
+		var p *Policy
+
+		BeforeEach(func() {
+			var err error
+			p, err = Parse([]byte(`{
+				"latestMinor": "1.34",
+				"flags": {
+					"--deprecated": {"rules": [{"since": "1.30", "state": "deprecated"}]},
+					"--config-only": {"rules": [{"since": "1.31", "state": "configFile"}]},
+					"--dropped": {"configField": "dropped", "rules": [
+						{"since": "1.30", "state": "deprecated"},
+						{"since": "1.32", "state": "removed", "drop": true}
+					]},
+					"--removed": {"rules": [{"since": "1.33", "state": "removed", "reason": "gone"}]}
+				}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should classify the flags", func() {
+			flags := map[string]string{"--deprecated": "", "--config-only": "", "--dropped": "", "--max-pods": "30"}
+			result, err := p.Evaluate("1.32.3", flags, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Deprecated).To(Equal([]string{"--deprecated"}))
+			Expect(result.ConfigFileOnly).To(Equal([]string{"--config-only"}))
+			Expect(result.Dropped).To(Equal([]string{"--dropped"}))
+			Expect(result.Violations).To(BeEmpty())
+			Expect(flags).To(HaveLen(4), "Evaluate must not modify the flags")
+		})
+
+		It("should reject removed flags and config file flags when the config file is disabled", func() {
+			flags := map[string]string{"--config-only": "", "--removed": "", "--dropped": ""}
+			result, err := p.Apply("1.33.0", flags, false)
+			var invalid *InvalidFlagsError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.KubernetesVersion).To(Equal("1.33.0"))
+			Expect(invalid.Violations).To(HaveLen(2))
+			Expect(invalid.Violations[0].Flag).To(Equal("--config-only"))
+			Expect(invalid.Violations[1].Flag).To(Equal("--removed"))
+			Expect(err.Error()).To(ContainSubstring("--removed (removed in 1.33: gone)"))
+			Expect(result.Dropped).To(Equal([]string{"--dropped"}))
+			Expect(flags).NotTo(HaveKey("--dropped"))
+		})
+
+		It("should accept every flag before the rules apply", func() {
+			flags := map[string]string{"--config-only": "", "--removed": "", "--dropped": "", "--deprecated": ""}
+			result, err := p.Apply("1.29.9", flags, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*result).To(Equal(Result{}))
+			Expect(flags).To(HaveLen(4))
+		})
+
+		It("should list dropped flags and config fields", func() {
+			Expect(p.DroppedFlags("1.31.0")).To(BeEmpty())
+			Expect(p.DroppedFlags("1.32.0")).To(Equal(map[string]bool{"--dropped": true}))
+			Expect(p.DroppedConfigFields("1.40.0")).To(Equal([]string{"dropped"}))
+		})
+	})
+
+	It("parseMinor should only accept major.minor versions", func() {
+		v, err := parseMinor("1.34")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Equal(semver.MustParse("1.34.0"))).To(BeTrue())
+		_, err = parseMinor("1.34.0")
+		Expect(err).To(HaveOccurred())
+		_, err = parseMinor("v1.34")
+		Expect(err).To(HaveOccurred())
+	})
+})
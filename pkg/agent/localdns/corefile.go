@@ -0,0 +1,299 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package localdns models the localdns configuration of a node and renders and parses the Corefile served by
+// the localdns service.
+// It is shared by the baker and by aks-node-controller, so both produce byte-identical Corefiles from their own
+// configuration types.
+package localdns
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultAzureDNSIP is the Azure-provided DNS server address.
+	DefaultAzureDNSIP = "168.63.129.16"
+	// RootDomain is the domain matching every query not matched by a more specific override.
+	RootDomain = "."
+	// HostsFile is the file served by the hosts plugin, populated with critical AKS FQDNs at provisioning time.
+	HostsFile = "/etc/localdns/hosts"
+
+	corefilePort         = "53"
+	readyPort            = "8181"
+	prometheusListener   = ":9253"
+	clusterDomainSuffix  = "cluster.local"
+	healthCheckServer    = "health-check.localdns.local"
+	cacheSuccessCapacity = "9984"
+	cacheDenialCapacity  = "9984"
+)
+
+// QueryLogging is the log level for DNS queries served by an override.
+type QueryLogging string
+
+const (
+	QueryLoggingError QueryLogging = "Error"
+	QueryLoggingLog   QueryLogging = "Log"
+)
+
+// Protocol is the protocol localdns uses towards the upstream DNS server.
+type Protocol string
+
+const (
+	ProtocolPreferUDP Protocol = "PreferUDP"
+	ProtocolForceTCP  Protocol = "ForceTCP"
+)
+
+// ForwardDestination is the upstream DNS server queries are forwarded to.
+type ForwardDestination string
+
+const (
+	ForwardDestinationVnetDNS        ForwardDestination = "VnetDNS"
+	ForwardDestinationClusterCoreDNS ForwardDestination = "ClusterCoreDNS"
+)
+
+// ForwardPolicy is the policy used to pick an upstream DNS server.
+type ForwardPolicy string
+
+const (
+	ForwardPolicySequential ForwardPolicy = "Sequential"
+	ForwardPolicyRoundRobin ForwardPolicy = "RoundRobin"
+	ForwardPolicyRandom     ForwardPolicy = "Random"
+)
+
+// ServeStale is the policy for serving stale cache entries.
+type ServeStale string
+
+const (
+	ServeStaleVerify    ServeStale = "Verify"
+	ServeStaleImmediate ServeStale = "Immediate"
+	ServeStaleDisable   ServeStale = "Disable"
+)
+
+// Traffic identifies the DNS traffic a server block applies to.
+type Traffic string
+
+const (
+	// VnetDNSTraffic is DNS traffic from pods with dnsPolicy:default or from kubelet.
+	VnetDNSTraffic Traffic = "VnetDNS"
+	// KubeDNSTraffic is DNS traffic from pods with dnsPolicy:ClusterFirst.
+	KubeDNSTraffic Traffic = "KubeDNS"
+)
+
+// Corefile is the localdns configuration of a node.
+type Corefile struct {
+	// NodeListenerIP is the address serving VnetDNS traffic.
+	NodeListenerIP string
+	// ClusterListenerIP is the address serving KubeDNS traffic.
+	ClusterListenerIP string
+	// CoreDNSServiceIP is the cluster CoreDNS service address.
+	CoreDNSServiceIP string
+	// AzureDNSIP is the Azure-provided DNS server address.
+	AzureDNSIP string
+	// IncludeHostsPlugin serves HostsFile ahead of forwarding in the root domain server blocks.
+	IncludeHostsPlugin bool
+	// VnetDNSOverrides and KubeDNSOverrides are rendered in order, one server block per override.
+	VnetDNSOverrides []Override
+	KubeDNSOverrides []Override
+}
+
+// Override is the configuration of the server block of one domain.
+type Override struct {
+	Domain                        string
+	QueryLogging                  QueryLogging
+	Protocol                      Protocol
+	ForwardDestination            ForwardDestination
+	ForwardPolicy                 ForwardPolicy
+	MaxConcurrent                 *int32
+	CacheDurationInSeconds        *int32
+	ServeStale                    ServeStale
+	ServeStaleDurationInSeconds   *int32
+	HealthCheck                   HealthCheck
+	FailfastAllUnhealthyUpstreams bool
+}
+
+// HealthCheck is the health checking of upstream servers by the forward plugin.
+// It is only rendered when Duration is set.
+type HealthCheck struct {
+	// Duration is a Go duration string, for example "500ms".
+	Duration string
+	NoRec    bool
+	Domain   string
+}
+
+// IsRoot reports whether the override applies to the root domain.
+func (o Override) IsRoot() bool {
+	return o.Domain == RootDomain
+}
+
+// Upstream returns the address queries for the override are forwarded to.
+// VnetDNS traffic for the root domain always goes to Azure DNS, and cluster.local domains always go to CoreDNS.
+func (c *Corefile) Upstream(traffic Traffic, o Override) string {
+	if traffic == VnetDNSTraffic && o.IsRoot() {
+		return c.AzureDNSIP
+	}
+	if strings.HasSuffix(o.Domain, clusterDomainSuffix) || o.ForwardDestination == ForwardDestinationClusterCoreDNS {
+		return c.CoreDNSServiceIP
+	}
+	return c.AzureDNSIP
+}
+
+// listenerIP returns the address serving the given traffic.
+func (c *Corefile) listenerIP(traffic Traffic) string {
+	if traffic == KubeDNSTraffic {
+		return c.ClusterListenerIP
+	}
+	return c.NodeListenerIP
+}
+
+// nsid returns the server identifier of the given traffic.
+func nsid(traffic Traffic) string {
+	if traffic == KubeDNSTraffic {
+		return "localdns-pod"
+	}
+	return "localdns"
+}
+
+// sectionComment returns the comment preceding the server blocks of the given traffic.
+func sectionComment(traffic Traffic) string {
+	if traffic == KubeDNSTraffic {
+		return "# KubeDNS overrides apply to DNS traffic from pods with dnsPolicy:ClusterFirst (referred to as KubeDNS traffic)."
+	}
+	return "# VnetDNS overrides apply to DNS traffic from pods with dnsPolicy:default or kubelet (referred to as VnetDNS traffic)."
+}
+
+func (p ForwardPolicy) directive() string {
+	switch p {
+	case ForwardPolicyRoundRobin:
+		return "round_robin"
+	case ForwardPolicyRandom:
+		return "random"
+	default:
+		return "sequential"
+	}
+}
+
+const corefileHeader = `# ***********************************************************************************
+# WARNING: Changes to this file will be overwritten and not persisted.
+# ***********************************************************************************
+# whoami (used for health check of DNS)
+`
+
+const hostsBlock = `    # Check /etc/localdns/hosts first for critical AKS FQDNs (mcr.microsoft.com, packages.aks.azure.com, etc.)
+    hosts ` + HostsFile + ` {
+        ttl 5
+        reload 5s
+        fallthrough
+    }
+`
+
+const rootDomainTemplates = `    template ANY ANY internal.cloudapp.net {
+        match "^(?:[^.]+\.){4,}internal\.cloudapp\.net\.$"
+        rcode NXDOMAIN
+        fallthrough
+    }
+    template ANY ANY reddog.microsoft.com {
+        rcode NXDOMAIN
+    }
+`
+
+// Render returns the Corefile text.
+func (c *Corefile) Render() (string, error) {
+	var b strings.Builder
+	b.WriteString(corefileHeader)
+	fmt.Fprintf(&b, "%s:%s {\n    bind %s %s\n    whoami\n}\n", healthCheckServer, corefilePort, c.NodeListenerIP, c.ClusterListenerIP)
+	for _, traffic := range []Traffic{VnetDNSTraffic, KubeDNSTraffic} {
+		b.WriteString(sectionComment(traffic))
+		b.WriteString("\n")
+		for _, o := range c.Overrides(traffic) {
+			if o.Domain == "" {
+				return "", fmt.Errorf("%s override has an empty domain", traffic)
+			}
+			c.renderServerBlock(&b, traffic, o)
+		}
+	}
+	return b.String(), nil
+}
+
+// Overrides returns the overrides of the given traffic.
+func (c *Corefile) Overrides(traffic Traffic) []Override {
+	if traffic == KubeDNSTraffic {
+		return c.KubeDNSOverrides
+	}
+	return c.VnetDNSOverrides
+}
+
+func (c *Corefile) renderServerBlock(b *strings.Builder, traffic Traffic, o Override) {
+	listener := c.listenerIP(traffic)
+	fmt.Fprintf(b, "%s:%s {\n", o.Domain, corefilePort)
+	switch o.QueryLogging {
+	case QueryLoggingError:
+		b.WriteString("    errors\n")
+	case QueryLoggingLog:
+		b.WriteString("    log\n")
+	}
+	fmt.Fprintf(b, "    bind %s\n", listener)
+	if o.IsRoot() && c.IncludeHostsPlugin {
+		b.WriteString(hostsBlock)
+	}
+
+	fmt.Fprintf(b, "    forward . %s {\n", c.Upstream(traffic, o))
+	switch o.Protocol {
+	case ProtocolForceTCP:
+		b.WriteString("        force_tcp\n")
+	case ProtocolPreferUDP:
+		b.WriteString("        prefer_udp\n")
+	}
+	fmt.Fprintf(b, "        policy %s\n", o.ForwardPolicy.directive())
+	if o.MaxConcurrent != nil {
+		fmt.Fprintf(b, "        max_concurrent %d\n", *o.MaxConcurrent)
+	}
+	if o.HealthCheck.Duration != "" {
+		b.WriteString("        health_check " + o.HealthCheck.Duration)
+		if o.HealthCheck.NoRec {
+			b.WriteString(" no_rec")
+		}
+		if o.HealthCheck.Domain != "" {
+			b.WriteString(" domain " + o.HealthCheck.Domain)
+		}
+		b.WriteString("\n")
+	}
+	if o.FailfastAllUnhealthyUpstreams {
+		b.WriteString("        failfast_all_unhealthy_upstreams\n")
+	}
+	b.WriteString("    }\n")
+
+	fmt.Fprintf(b, "    ready %s:%s\n", listener, readyPort)
+	b.WriteString("    cache")
+	if o.CacheDurationInSeconds != nil {
+		fmt.Fprintf(b, " %d", *o.CacheDurationInSeconds)
+	}
+	b.WriteString(" {\n")
+	fmt.Fprintf(b, "        success %s\n        denial %s\n", cacheSuccessCapacity, cacheDenialCapacity)
+	if mode := serveStaleMode(o.ServeStale); mode != "" {
+		b.WriteString("        serve_stale ")
+		if o.ServeStaleDurationInSeconds != nil {
+			fmt.Fprintf(b, "%ds ", *o.ServeStaleDurationInSeconds)
+		}
+		b.WriteString(mode + "\n")
+	}
+	b.WriteString("        servfail 0\n    }\n")
+	fmt.Fprintf(b, "    loop\n    nsid %s\n    prometheus %s\n", nsid(traffic), prometheusListener)
+	if o.IsRoot() {
+		b.WriteString(rootDomainTemplates)
+	}
+	b.WriteString("}\n")
+}
+
+// serveStaleMode returns the refresh mode of the serve_stale directive, or "" when stale entries are not served.
+func serveStaleMode(s ServeStale) string {
+	switch s {
+	case ServeStaleVerify:
+		return "verify"
+	case ServeStaleImmediate:
+		return "immediate"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,285 @@
+package localdns
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testCorefile() *Corefile {
+	return &Corefile{
+		NodeListenerIP:     "169.254.10.10",
+		ClusterListenerIP:  "169.254.10.11",
+		CoreDNSServiceIP:   "10.0.0.10",
+		AzureDNSIP:         DefaultAzureDNSIP,
+		IncludeHostsPlugin: true,
+		VnetDNSOverrides: []Override{
+			{
+				Domain:                      RootDomain,
+				QueryLogging:                QueryLoggingLog,
+				Protocol:                    ProtocolPreferUDP,
+				ForwardDestination:          ForwardDestinationVnetDNS,
+				ForwardPolicy:               ForwardPolicyRoundRobin,
+				MaxConcurrent:               ptr(int32(1000)),
+				CacheDurationInSeconds:      ptr(int32(3600)),
+				ServeStale:                  ServeStaleImmediate,
+				ServeStaleDurationInSeconds: ptr(int32(3600)),
+				HealthCheck:                 HealthCheck{Duration: "500ms", NoRec: true, Domain: "example.com"},
+			},
+			{
+				Domain:                        "cluster.local",
+				QueryLogging:                  QueryLoggingError,
+				Protocol:                      ProtocolForceTCP,
+				ForwardDestination:            ForwardDestinationClusterCoreDNS,
+				ForwardPolicy:                 ForwardPolicySequential,
+				MaxConcurrent:                 ptr(int32(1000)),
+				CacheDurationInSeconds:        ptr(int32(3600)),
+				ServeStale:                    ServeStaleDisable,
+				ServeStaleDurationInSeconds:   ptr(int32(3600)),
+				FailfastAllUnhealthyUpstreams: true,
+			},
+		},
+		KubeDNSOverrides: []Override{
+			{
+				Domain:                      RootDomain,
+				QueryLogging:                QueryLoggingError,
+				Protocol:                    ProtocolPreferUDP,
+				ForwardDestination:          ForwardDestinationClusterCoreDNS,
+				ForwardPolicy:               ForwardPolicyRandom,
+				MaxConcurrent:               ptr(int32(2000)),
+				CacheDurationInSeconds:      ptr(int32(3600)),
+				ServeStale:                  ServeStaleVerify,
+				ServeStaleDurationInSeconds: ptr(int32(72000)),
+				HealthCheck:                 HealthCheck{Duration: "1s"},
+			},
+		},
+	}
+}
+
+const testCorefileText = `# ***********************************************************************************
+# WARNING: Changes to this file will be overwritten and not persisted.
+# ***********************************************************************************
+# whoami (used for health check of DNS)
+health-check.localdns.local:53 {
+    bind 169.254.10.10 169.254.10.11
+    whoami
+}
+# VnetDNS overrides apply to DNS traffic from pods with dnsPolicy:default or kubelet (referred to as VnetDNS traffic).
+.:53 {
+    log
+    bind 169.254.10.10
+    # Check /etc/localdns/hosts first for critical AKS FQDNs (mcr.microsoft.com, packages.aks.azure.com, etc.)
+    hosts /etc/localdns/hosts {
+        ttl 5
+        reload 5s
+        fallthrough
+    }
+    forward . 168.63.129.16 {
+        prefer_udp
+        policy round_robin
+        max_concurrent 1000
+        health_check 500ms no_rec domain example.com
+    }
+    ready 169.254.10.10:8181
+    cache 3600 {
+        success 9984
+        denial 9984
+        serve_stale 3600s immediate
+        servfail 0
+    }
+    loop
+    nsid localdns
+    prometheus :9253
+    template ANY ANY internal.cloudapp.net {
+        match "^(?:[^.]+\.){4,}internal\.cloudapp\.net\.$"
+        rcode NXDOMAIN
+        fallthrough
+    }
+    template ANY ANY reddog.microsoft.com {
+        rcode NXDOMAIN
+    }
+}
+cluster.local:53 {
+    errors
+    bind 169.254.10.10
+    forward . 10.0.0.10 {
+        force_tcp
+        policy sequential
+        max_concurrent 1000
+        failfast_all_unhealthy_upstreams
+    }
+    ready 169.254.10.10:8181
+    cache 3600 {
+        success 9984
+        denial 9984
+        servfail 0
+    }
+    loop
+    nsid localdns
+    prometheus :9253
+}
+# KubeDNS overrides apply to DNS traffic from pods with dnsPolicy:ClusterFirst (referred to as KubeDNS traffic).
+.:53 {
+    errors
+    bind 169.254.10.11
+    # Check /etc/localdns/hosts first for critical AKS FQDNs (mcr.microsoft.com, packages.aks.azure.com, etc.)
+    hosts /etc/localdns/hosts {
+        ttl 5
+        reload 5s
+        fallthrough
+    }
+    forward . 10.0.0.10 {
+        prefer_udp
+        policy random
+        max_concurrent 2000
+        health_check 1s
+    }
+    ready 169.254.10.11:8181
+    cache 3600 {
+        success 9984
+        denial 9984
+        serve_stale 72000s verify
+        servfail 0
+    }
+    loop
+    nsid localdns-pod
+    prometheus :9253
+    template ANY ANY internal.cloudapp.net {
+        match "^(?:[^.]+\.){4,}internal\.cloudapp\.net\.$"
+        rcode NXDOMAIN
+        fallthrough
+    }
+    template ANY ANY reddog.microsoft.com {
+        rcode NXDOMAIN
+    }
+}
+`
+
+var _ = Describe("Corefile", func() {
+	Context("Render", func() {
+		It("should render one server block per override, vnet overrides first", func() {
+			rendered, err := testCorefile().Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).To(Equal(testCorefileText))
+		})
+
+		It("should render only the health check server without overrides", func() {
+			c := testCorefile()
+			c.VnetDNSOverrides, c.KubeDNSOverrides = nil, nil
+			rendered, err := c.Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).To(HaveSuffix("    whoami\n}\n" + sectionComment(VnetDNSTraffic) + "\n" + sectionComment(KubeDNSTraffic) + "\n"))
+		})
+
+		It("should omit the hosts plugin when it is not included", func() {
+			c := testCorefile()
+			c.IncludeHostsPlugin = false
+			rendered, err := c.Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).ToNot(ContainSubstring("hosts " + HostsFile))
+		})
+
+		It("should omit unset values", func() {
+			c := testCorefile()
+			c.VnetDNSOverrides = []Override{{Domain: "example.com", ServeStale: ServeStaleVerify}}
+			rendered, err := c.Render()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).To(ContainSubstring("        policy sequential\n    }\n"))
+			Expect(rendered).To(ContainSubstring("    cache {\n"))
+			Expect(rendered).To(ContainSubstring("        serve_stale verify\n"))
+			Expect(rendered).ToNot(ContainSubstring("<nil>"))
+		})
+
+		It("should fail on an override without a domain", func() {
+			c := testCorefile()
+			c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{})
+			_, err := c.Render()
+			Expect(err).To(MatchError("KubeDNS override has an empty domain"))
+		})
+	})
+
+	Context("Upstream", func() {
+		c := testCorefile()
+		It("should always forward vnet traffic for the root domain to Azure DNS", func() {
+			Expect(c.Upstream(VnetDNSTraffic, Override{Domain: RootDomain, ForwardDestination: ForwardDestinationClusterCoreDNS})).To(Equal(DefaultAzureDNSIP))
+			Expect(c.Upstream(KubeDNSTraffic, Override{Domain: RootDomain, ForwardDestination: ForwardDestinationClusterCoreDNS})).To(Equal("10.0.0.10"))
+		})
+		It("should always forward cluster.local domains to CoreDNS", func() {
+			Expect(c.Upstream(VnetDNSTraffic, Override{Domain: "svc.cluster.local", ForwardDestination: ForwardDestinationVnetDNS})).To(Equal("10.0.0.10"))
+		})
+		It("should follow the forward destination otherwise", func() {
+			Expect(c.Upstream(KubeDNSTraffic, Override{Domain: "example.com", ForwardDestination: ForwardDestinationVnetDNS})).To(Equal(DefaultAzureDNSIP))
+			Expect(c.Upstream(KubeDNSTraffic, Override{Domain: "example.com", ForwardDestination: ForwardDestinationClusterCoreDNS})).To(Equal("10.0.0.10"))
+		})
+	})
+
+	Context("Parse", func() {
+		It("should parse a rendered Corefile back into the model", func() {
+			c, err := Parse(testCorefileText)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c).To(Equal(&Corefile{
+				NodeListenerIP:     "169.254.10.10",
+				ClusterListenerIP:  "169.254.10.11",
+				CoreDNSServiceIP:   "10.0.0.10",
+				AzureDNSIP:         DefaultAzureDNSIP,
+				IncludeHostsPlugin: true,
+				VnetDNSOverrides: []Override{
+					testCorefile().VnetDNSOverrides[0],
+					func() Override {
+						// serve_stale is not rendered when disabled, so its duration is lost.
+						o := testCorefile().VnetDNSOverrides[1]
+						o.ServeStaleDurationInSeconds = nil
+						return o
+					}(),
+				},
+				KubeDNSOverrides: testCorefile().KubeDNSOverrides,
+			}))
+		})
+
+		It("should round-trip", func() {
+			c := testCorefile()
+			c.VnetDNSOverrides = append(c.VnetDNSOverrides,
+				Override{Domain: "example.com", ForwardDestination: ForwardDestinationClusterCoreDNS},
+				Override{Domain: "contoso.com", ServeStale: ServeStaleImmediate, HealthCheck: HealthCheck{Duration: "2s", Domain: "."}},
+			)
+			c.KubeDNSOverrides[0].Domain = "example.org"
+			rendered, err := c.Render()
+			Expect(err).ToNot(HaveOccurred())
+			parsed, err := Parse(rendered)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.Render()).To(Equal(rendered))
+		})
+
+		for _, tc := range []struct {
+			name, old, replacement, expectedErr string
+		}{
+			{"unknown directive", "    loop\n", "    loop\n    rewrite name a b\n", "line 33: rewrite: unsupported directive"},
+			{"unknown forward option", "        prefer_udp\n", "        expire 10s\n", "line 20: expire: unsupported forward option"},
+			{"unknown policy", "policy round_robin", "policy fastest", `unknown policy "fastest"`},
+			{"invalid max_concurrent", "max_concurrent 1000", "max_concurrent many", `invalid value "many"`},
+			{"invalid serve_stale", "serve_stale 3600s immediate", "serve_stale 3600 immediate", `invalid value "3600"`},
+			{"unknown nsid", "nsid localdns-pod", "nsid other", `unknown identifier "other"`},
+			{"wrong listener", "    bind 169.254.10.11\n", "    bind 169.254.10.10\n", `KubeDNS server bound to "169.254.10.10", expected "169.254.10.11"`},
+			{"hosts outside the root domain", "    errors\n    bind 169.254.10.10\n", "    hosts /etc/hosts {\n    }\n    bind 169.254.10.10\n", "hosts plugin is only supported in the root domain"},
+			{"several CoreDNS addresses", "forward . 10.0.0.10 {\n        prefer_udp", "forward . 10.0.0.11 {\n        prefer_udp", `forwards to "10.0.0.11", other server blocks forward to CoreDNS at "10.0.0.10"`},
+			{"missing health check server", "health-check.localdns.local:53 {\n    bind 169.254.10.10 169.254.10.11\n    whoami\n}\n", "", "missing health-check.localdns.local server block"},
+			{"not a server block", "cluster.local:53 {", "cluster.local {", "expected a <domain>:53 server block"},
+			{"unterminated block", "", "example.com:53 {\n", "unterminated block"},
+		} {
+			It("should reject a Corefile with "+tc.name, func() {
+				Expect(testCorefileText).To(ContainSubstring(tc.old))
+				corefile := strings.Replace(testCorefileText, tc.old, tc.replacement, 1)
+				if tc.old == "" {
+					corefile = testCorefileText + tc.replacement
+				}
+				_, err := Parse(corefile)
+				Expect(err).To(MatchError(ContainSubstring(tc.expectedErr)))
+			})
+		}
+	})
+})
@@ -0,0 +1,13 @@
+package localdns
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLocalDNS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "localdns suite")
+}
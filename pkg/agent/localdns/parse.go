@@ -0,0 +1,303 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package localdns
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// block is a Corefile directive, with the directives of its body when it opens one.
+type block struct {
+	line int
+	args []string
+	body []*block
+}
+
+func (b *block) name() string {
+	return b.args[0]
+}
+
+func (b *block) errorf(format string, a ...any) error {
+	return fmt.Errorf("line %d: %s: %s", b.line, b.name(), fmt.Sprintf(format, a...))
+}
+
+// Parse parses a Corefile rendered by Render.
+// It accepts the subset of the Corefile syntax localdns renders, one directive per line, and rejects directives
+// the model cannot represent. Forward addresses other than DefaultAzureDNSIP are read as the CoreDNS service,
+// so Parse(Render(c)) renders the same Corefile as c even where ForwardDestination had no effect.
+func Parse(corefile string) (*Corefile, error) {
+	blocks, err := splitBlocks(corefile)
+	if err != nil {
+		return nil, err
+	}
+	c := &Corefile{AzureDNSIP: DefaultAzureDNSIP}
+	// The listener addresses are read from the health check server first, to check the bind of every server block.
+	i := slices.IndexFunc(blocks, func(b *block) bool { return b.name() == healthCheckServer+":"+corefilePort })
+	if i < 0 {
+		return nil, fmt.Errorf("missing %s server block", healthCheckServer)
+	}
+	if err := c.parseHealthCheckServer(blocks[i]); err != nil {
+		return nil, err
+	}
+	for _, b := range slices.Delete(blocks, i, i+1) {
+		if b.body == nil {
+			return nil, b.errorf("expected a server block")
+		}
+		traffic, o, err := c.parseServerBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		if traffic == KubeDNSTraffic {
+			c.KubeDNSOverrides = append(c.KubeDNSOverrides, o)
+		} else {
+			c.VnetDNSOverrides = append(c.VnetDNSOverrides, o)
+		}
+	}
+	return c, nil
+}
+
+// splitBlocks splits the Corefile into its top-level directives.
+func splitBlocks(corefile string) ([]*block, error) {
+	root := &block{args: []string{"<root>"}}
+	stack := []*block{root}
+	for i, raw := range strings.Split(corefile, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parent := stack[len(stack)-1]
+		if line == "}" {
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("line %d: unexpected }", i+1)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		b := &block{line: i + 1, args: strings.Fields(line)}
+		if b.args[len(b.args)-1] == "{" {
+			b.args = b.args[:len(b.args)-1]
+			b.body = []*block{}
+			if len(b.args) == 0 {
+				return nil, fmt.Errorf("line %d: block without a name", i+1)
+			}
+			stack = append(stack, b)
+		}
+		parent.body = append(parent.body, b)
+	}
+	if len(stack) != 1 {
+		return nil, stack[len(stack)-1].errorf("unterminated block")
+	}
+	return root.body, nil
+}
+
+func (c *Corefile) parseHealthCheckServer(b *block) error {
+	for _, d := range b.body {
+		switch d.name() {
+		case "bind":
+			if len(d.args) != 3 {
+				return d.errorf("expected node and cluster listener addresses")
+			}
+			c.NodeListenerIP, c.ClusterListenerIP = d.args[1], d.args[2]
+		case "whoami":
+		default:
+			return d.errorf("unexpected directive in %s", healthCheckServer)
+		}
+	}
+	return nil
+}
+
+// parseServerBlock parses the server block of one override. The traffic it applies to is read from its nsid.
+func (c *Corefile) parseServerBlock(b *block) (Traffic, Override, error) {
+	var (
+		o       Override
+		traffic Traffic
+		bind    string
+	)
+	domain, port, found := strings.Cut(b.name(), ":")
+	if !found || port != corefilePort || domain == "" || len(b.args) != 1 {
+		return "", o, b.errorf("expected a <domain>:%s server block", corefilePort)
+	}
+	o.Domain = domain
+	o.ServeStale = ServeStaleDisable
+	var forward *block
+	for _, d := range b.body {
+		var err error
+		switch d.name() {
+		case "errors":
+			o.QueryLogging = QueryLoggingError
+		case "log":
+			o.QueryLogging = QueryLoggingLog
+		case "bind":
+			if len(d.args) != 2 {
+				return "", o, d.errorf("expected one address")
+			}
+			bind = d.args[1]
+		case "hosts":
+			if !o.IsRoot() {
+				return "", o, d.errorf("hosts plugin is only supported in the root domain")
+			}
+			c.IncludeHostsPlugin = true
+		case "forward":
+			forward = d
+			err = parseForward(d, &o)
+		case "cache":
+			err = parseCache(d, &o)
+		case "nsid":
+			if len(d.args) != 2 {
+				return "", o, d.errorf("expected one identifier")
+			}
+			switch d.args[1] {
+			case nsid(VnetDNSTraffic):
+				traffic = VnetDNSTraffic
+			case nsid(KubeDNSTraffic):
+				traffic = KubeDNSTraffic
+			default:
+				return "", o, d.errorf("unknown identifier %q", d.args[1])
+			}
+		case "ready", "loop", "prometheus", "template":
+		default:
+			return "", o, d.errorf("unsupported directive")
+		}
+		if err != nil {
+			return "", o, err
+		}
+	}
+	if traffic == "" {
+		return "", o, b.errorf("missing nsid")
+	}
+	if forward == nil {
+		return "", o, b.errorf("missing forward")
+	}
+	if want := c.listenerIP(traffic); bind != want {
+		return "", o, b.errorf("%s server bound to %q, expected %q", traffic, bind, want)
+	}
+	upstream := forward.args[2]
+	if upstream == c.AzureDNSIP {
+		o.ForwardDestination = ForwardDestinationVnetDNS
+	} else {
+		if c.CoreDNSServiceIP != "" && c.CoreDNSServiceIP != upstream {
+			return "", o, forward.errorf("forwards to %q, other server blocks forward to CoreDNS at %q", upstream, c.CoreDNSServiceIP)
+		}
+		c.CoreDNSServiceIP = upstream
+		o.ForwardDestination = ForwardDestinationClusterCoreDNS
+	}
+	return traffic, o, nil
+}
+
+func parseForward(b *block, o *Override) error {
+	if len(b.args) != 3 || b.args[1] != "." {
+		return b.errorf("expected forward . <address>")
+	}
+	for _, d := range b.body {
+		var err error
+		switch d.name() {
+		case "force_tcp":
+			o.Protocol = ProtocolForceTCP
+		case "prefer_udp":
+			o.Protocol = ProtocolPreferUDP
+		case "policy":
+			if len(d.args) != 2 {
+				return d.errorf("expected one policy")
+			}
+			switch d.args[1] {
+			case "sequential":
+				o.ForwardPolicy = ForwardPolicySequential
+			case "round_robin":
+				o.ForwardPolicy = ForwardPolicyRoundRobin
+			case "random":
+				o.ForwardPolicy = ForwardPolicyRandom
+			default:
+				return d.errorf("unknown policy %q", d.args[1])
+			}
+		case "max_concurrent":
+			if len(d.args) != 2 {
+				return d.errorf("expected one value")
+			}
+			o.MaxConcurrent, err = parseInt32(d, d.args[1], "")
+		case "health_check":
+			err = parseHealthCheck(d, &o.HealthCheck)
+		case "failfast_all_unhealthy_upstreams":
+			o.FailfastAllUnhealthyUpstreams = true
+		default:
+			return d.errorf("unsupported forward option")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseHealthCheck(b *block, h *HealthCheck) error {
+	if len(b.args) < 2 {
+		return b.errorf("expected a duration")
+	}
+	h.Duration = b.args[1]
+	for args := b.args[2:]; len(args) > 0; {
+		switch {
+		case args[0] == "no_rec":
+			h.NoRec = true
+			args = args[1:]
+		case args[0] == "domain" && len(args) > 1:
+			h.Domain = args[1]
+			args = args[2:]
+		default:
+			return b.errorf("unexpected argument %q", args[0])
+		}
+	}
+	return nil
+}
+
+func parseCache(b *block, o *Override) error {
+	var err error
+	switch len(b.args) {
+	case 1:
+	case 2:
+		if o.CacheDurationInSeconds, err = parseInt32(b, b.args[1], ""); err != nil {
+			return err
+		}
+	default:
+		return b.errorf("expected at most one TTL")
+	}
+	for _, d := range b.body {
+		switch d.name() {
+		case "success", "denial", "servfail":
+		case "serve_stale":
+			args := d.args[1:]
+			if len(args) == 2 {
+				if o.ServeStaleDurationInSeconds, err = parseInt32(d, args[0], "s"); err != nil {
+					return err
+				}
+				args = args[1:]
+			}
+			if len(args) != 1 {
+				return d.errorf("expected [<duration>s] verify|immediate")
+			}
+			switch args[0] {
+			case "verify":
+				o.ServeStale = ServeStaleVerify
+			case "immediate":
+				o.ServeStale = ServeStaleImmediate
+			default:
+				return d.errorf("unknown refresh mode %q", args[0])
+			}
+		default:
+			return d.errorf("unsupported cache option")
+		}
+	}
+	return nil
+}
+
+// parseInt32 parses a directive argument with the given unit suffix.
+func parseInt32(b *block, arg, unit string) (*int32, error) {
+	v, err := strconv.ParseInt(strings.TrimSuffix(arg, unit), 10, 32)
+	if err != nil || !strings.HasSuffix(arg, unit) {
+		return nil, b.errorf("invalid value %q", arg)
+	}
+	n := int32(v)
+	return &n, nil
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package localdns
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxDomainLength = 253
+	// maxDurationInSeconds bounds the cache and serve stale durations to a day, longer ones would keep answers of
+	// moved records on the node long after their TTL expired.
+	maxDurationInSeconds = 24 * 60 * 60
+)
+
+// domainLabel matches one label of a domain name. Underscores are accepted for service records such as _tcp.
+var domainLabel = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]{0,61}[A-Za-z0-9_])?$`)
+
+// Validate rejects localdns configurations the Corefile cannot express as requested: unknown values, which
+// rendering would silently ignore, invalid domains, domains overlapping or shadowing another override served on the
+// same address, settings shadowed by the fixed forwarding rules, out of range values, and invalid critical FQDNs of
+// the hosts plugin. Every problem is reported.
+func Validate(c *Corefile, criticalFQDNs []string) error {
+	var errs []string
+	var served []servedZone
+	for _, traffic := range []Traffic{VnetDNSTraffic, KubeDNSTraffic} {
+		for _, o := range c.Overrides(traffic) {
+			prefix := fmt.Sprintf("%s override %q", traffic, o.Domain)
+			for _, problem := range validateOverride(traffic, o) {
+				errs = append(errs, prefix+": "+problem)
+			}
+			zone := servedZone{traffic: traffic, listener: c.listenerIP(traffic), zone: normalizeDomain(o.Domain), override: o}
+			for _, other := range served {
+				if problem := zone.conflict(other); problem != "" {
+					errs = append(errs, prefix+": "+problem)
+				}
+			}
+			served = append(served, zone)
+		}
+	}
+	errs = append(errs, validateCriticalFQDNs(criticalFQDNs)...)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid localdns configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// servedZone is the zone of an override and the address its server block is bound to.
+type servedZone struct {
+	traffic  Traffic
+	listener string
+	zone     string
+	override Override
+}
+
+// conflict returns why z can't be served along with other, or "" when it can. Server blocks bound to different
+// addresses never conflict, so the same domain may be overridden for both traffics, which the default profile does.
+// On one address a zone can only be served once, and CoreDNS answers a name from the most specific zone, so a
+// nested zone of the other traffic with different settings takes over the queries of that traffic for its names.
+// Nested zones of the same traffic are expected, such as cluster.local under the root domain.
+func (z servedZone) conflict(other servedZone) string {
+	if z.listener != other.listener {
+		return ""
+	}
+	owner := fmt.Sprintf("%s override %q", other.traffic, other.override.Domain)
+	switch {
+	case z.zone == other.zone:
+		if z.traffic == other.traffic {
+			return "overlaps with " + owner
+		}
+		return fmt.Sprintf("overlaps with %s, both are served on %s", owner, z.listener)
+	case z.traffic == other.traffic || sameSettings(z.override, other.override):
+		return ""
+	case isSubdomain(z.zone, other.zone):
+		return fmt.Sprintf("shadows %s for the names under %s, both are served on %s", owner, z.zone, z.listener)
+	case isSubdomain(other.zone, z.zone):
+		return fmt.Sprintf("is shadowed by %s for the names under %s, both are served on %s", owner, other.zone, z.listener)
+	default:
+		return ""
+	}
+}
+
+// isSubdomain reports whether the normalized zone is strictly under the normalized parent zone.
+func isSubdomain(zone, parent string) bool {
+	if zone == parent {
+		return false
+	}
+	return parent == RootDomain || strings.HasSuffix(zone, "."+parent)
+}
+
+// sameSettings reports whether two overrides render the same server block apart from their domain.
+func sameSettings(a, b Override) bool {
+	a.Domain, b.Domain = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+//nolint:gocognit,cyclop
+func validateOverride(traffic Traffic, o Override) []string {
+	var errs []string
+	if o.Domain != RootDomain {
+		if err := validateDomain(o.Domain); err != nil {
+			errs = append(errs, err.Error())
+		} else if normalizeDomain(o.Domain) == healthCheckServer {
+			errs = append(errs, fmt.Sprintf("domain is shadowed by the %s health check server", healthCheckServer))
+		}
+	}
+	errs = appendUnknown(errs, "queryLogging", o.QueryLogging, QueryLoggingError, QueryLoggingLog)
+	errs = appendUnknown(errs, "protocol", o.Protocol, ProtocolPreferUDP, ProtocolForceTCP)
+	errs = appendUnknown(errs, "forwardDestination", o.ForwardDestination, ForwardDestinationVnetDNS, ForwardDestinationClusterCoreDNS)
+	errs = appendUnknown(errs, "forwardPolicy", o.ForwardPolicy, ForwardPolicySequential, ForwardPolicyRoundRobin, ForwardPolicyRandom)
+	errs = appendUnknown(errs, "serveStale", o.ServeStale, ServeStaleVerify, ServeStaleImmediate, ServeStaleDisable)
+
+	switch {
+	case traffic == VnetDNSTraffic && o.IsRoot() && o.ForwardDestination == ForwardDestinationClusterCoreDNS:
+		errs = append(errs, fmt.Sprintf("forwardDestination %s is shadowed, VnetDNS traffic for the root domain is always forwarded to %s",
+			o.ForwardDestination, ForwardDestinationVnetDNS))
+	case strings.HasSuffix(o.Domain, clusterDomainSuffix) && o.ForwardDestination == ForwardDestinationVnetDNS:
+		errs = append(errs, fmt.Sprintf("forwardDestination %s is shadowed, %s domains are always forwarded to %s",
+			o.ForwardDestination, clusterDomainSuffix, ForwardDestinationClusterCoreDNS))
+	}
+
+	if o.MaxConcurrent != nil && *o.MaxConcurrent < 1 {
+		errs = append(errs, fmt.Sprintf("maxConcurrent %d must be positive", *o.MaxConcurrent))
+	}
+	if o.CacheDurationInSeconds != nil && (*o.CacheDurationInSeconds < 1 || *o.CacheDurationInSeconds > maxDurationInSeconds) {
+		errs = append(errs, fmt.Sprintf("cacheDurationInSeconds %d must be between 1 and %d", *o.CacheDurationInSeconds, maxDurationInSeconds))
+	}
+	if o.ServeStaleDurationInSeconds != nil && (*o.ServeStaleDurationInSeconds < 0 || *o.ServeStaleDurationInSeconds > maxDurationInSeconds) {
+		errs = append(errs, fmt.Sprintf("serveStaleDurationInSeconds %d must be between 0 and %d",
+			*o.ServeStaleDurationInSeconds, maxDurationInSeconds))
+	}
+
+	h := o.HealthCheck
+	if h.Duration == "" {
+		if h.NoRec || h.Domain != "" {
+			errs = append(errs, "healthCheck requires a duration, noRec and domain are ignored without it")
+		}
+		return errs
+	}
+	if d, err := time.ParseDuration(h.Duration); err != nil || d <= 0 {
+		errs = append(errs, fmt.Sprintf("healthCheck duration %q must be a positive duration such as 500ms", h.Duration))
+	}
+	if h.Domain != "" && h.Domain != RootDomain {
+		if err := validateDomain(h.Domain); err != nil {
+			errs = append(errs, "healthCheck "+err.Error())
+		}
+	}
+	return errs
+}
+
+// appendUnknown appends an error when value is set and is not one of the allowed values.
+func appendUnknown[T ~string](errs []string, field string, value T, allowed ...T) []string {
+	if value == "" || slices.Contains(allowed, value) {
+		return errs
+	}
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		names = append(names, string(a))
+	}
+	return append(errs, fmt.Sprintf("%s %q is invalid; allowed values are: %s", field, value, strings.Join(names, ", ")))
+}
+
+// validateCriticalFQDNs validates the FQDNs resolved into HostsFile. Blank entries are skipped when the list is
+// rendered, so they are accepted here.
+func validateCriticalFQDNs(fqdns []string) []string {
+	var errs []string
+	seen := map[string]bool{}
+	for _, fqdn := range fqdns {
+		fqdn = strings.TrimSpace(fqdn)
+		if fqdn == "" {
+			continue
+		}
+		if err := validateDomain(fqdn); err != nil {
+			errs = append(errs, "criticalFQDNs: "+err.Error())
+			continue
+		}
+		if zone := normalizeDomain(fqdn); seen[zone] {
+			errs = append(errs, fmt.Sprintf("criticalFQDNs: %q is duplicated", fqdn))
+		} else {
+			seen[zone] = true
+		}
+	}
+	return errs
+}
+
+// validateDomain checks domain is a domain name, optionally fully qualified with a trailing dot.
+func validateDomain(domain string) error {
+	name := strings.TrimSuffix(domain, ".")
+	if name == "" || len(name) > maxDomainLength {
+		return fmt.Errorf("domain %q must be between 1 and %d characters", domain, maxDomainLength)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !domainLabel.MatchString(label) {
+			return fmt.Errorf("domain %q has invalid label %q", domain, label)
+		}
+	}
+	return nil
+}
+
+// normalizeDomain returns the zone served for domain, DNS names are case insensitive.
+func normalizeDomain(domain string) string {
+	if domain == RootDomain {
+		return domain
+	}
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
@@ -0,0 +1,124 @@
+package localdns
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var c *Corefile
+	BeforeEach(func() {
+		c = testCorefile()
+	})
+
+	It("should accept a valid configuration", func() {
+		Expect(Validate(c, []string{"mcr.microsoft.com", " packages.aks.azure.com ", ""})).To(Succeed())
+	})
+
+	It("should accept unset values", func() {
+		c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{Domain: "example.com"})
+		Expect(Validate(c, nil)).To(Succeed())
+	})
+
+	It("should reject unknown values rendering would ignore", func() {
+		c.VnetDNSOverrides[1].ForwardPolicy = "Fastest"
+		c.VnetDNSOverrides[1].ServeStale = "Always"
+		c.KubeDNSOverrides[0].QueryLogging = "Debug"
+		c.KubeDNSOverrides[0].Protocol = "QUIC"
+		c.KubeDNSOverrides[0].ForwardDestination = "Custom"
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override "cluster.local": forwardPolicy "Fastest" is invalid; allowed values are: Sequential, RoundRobin, Random; ` +
+			`VnetDNS override "cluster.local": serveStale "Always" is invalid; allowed values are: Verify, Immediate, Disable; ` +
+			`KubeDNS override ".": queryLogging "Debug" is invalid; allowed values are: Error, Log; ` +
+			`KubeDNS override ".": protocol "QUIC" is invalid; allowed values are: PreferUDP, ForceTCP; ` +
+			`KubeDNS override ".": forwardDestination "Custom" is invalid; allowed values are: VnetDNS, ClusterCoreDNS`))
+	})
+
+	It("should reject overlapping domains of the same traffic", func() {
+		c.VnetDNSOverrides = append(c.VnetDNSOverrides, Override{Domain: "Cluster.Local."})
+		c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{Domain: "cluster.local"})
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override "Cluster.Local.": overlaps with VnetDNS override "cluster.local"`))
+	})
+
+	It("should reject invalid domains and domains shadowed by the health check server", func() {
+		c.VnetDNSOverrides = append(c.VnetDNSOverrides, Override{Domain: "*.example.com"}, Override{Domain: "health-check.localdns.local"})
+		c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{Domain: "-example.com"}, Override{Domain: "example..com"})
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override "*.example.com": domain "*.example.com" has invalid label "*"; ` +
+			`VnetDNS override "health-check.localdns.local": domain is shadowed by the health-check.localdns.local health check server; ` +
+			`KubeDNS override "-example.com": domain "-example.com" has invalid label "-example"; ` +
+			`KubeDNS override "example..com": domain "example..com" has invalid label ""`))
+	})
+
+	It("should reject forward destinations shadowed by the fixed forwarding rules", func() {
+		c.VnetDNSOverrides[0].ForwardDestination = ForwardDestinationClusterCoreDNS
+		c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{Domain: "svc.cluster.local", ForwardDestination: ForwardDestinationVnetDNS})
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override ".": forwardDestination ClusterCoreDNS is shadowed, VnetDNS traffic for the root domain is always forwarded to VnetDNS; ` +
+			`KubeDNS override "svc.cluster.local": forwardDestination VnetDNS is shadowed, cluster.local domains are always forwarded to ClusterCoreDNS`))
+	})
+
+	It("should accept the same and nested domains of both traffics on different addresses", func() {
+		c.VnetDNSOverrides = append(c.VnetDNSOverrides, Override{Domain: "example.com", Protocol: ProtocolForceTCP})
+		c.KubeDNSOverrides = append(c.KubeDNSOverrides, Override{Domain: "example.com"}, Override{Domain: "a.example.com"})
+		Expect(Validate(c, nil)).To(Succeed())
+	})
+
+	It("should reject domains of both traffics overlapping on a shared address", func() {
+		c.ClusterListenerIP = c.NodeListenerIP
+		c.KubeDNSOverrides = c.KubeDNSOverrides[:1]
+		c.VnetDNSOverrides = c.VnetDNSOverrides[:1]
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`KubeDNS override ".": overlaps with VnetDNS override ".", both are served on 169.254.10.10`))
+	})
+
+	It("should reject nested domains of both traffics with different settings on a shared address", func() {
+		c.ClusterListenerIP = c.NodeListenerIP
+		c.VnetDNSOverrides = []Override{{Domain: "example.com"}, {Domain: "b.example.com", Protocol: ProtocolForceTCP}}
+		c.KubeDNSOverrides = []Override{{Domain: "a.example.com", Protocol: ProtocolForceTCP}, {Domain: "B.Example.com", Protocol: ProtocolForceTCP}, {Domain: "com"}}
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`KubeDNS override "a.example.com": shadows VnetDNS override "example.com" for the names under a.example.com, both are served on 169.254.10.10; ` +
+			`KubeDNS override "B.Example.com": shadows VnetDNS override "example.com" for the names under b.example.com, both are served on 169.254.10.10; ` +
+			`KubeDNS override "B.Example.com": overlaps with VnetDNS override "b.example.com", both are served on 169.254.10.10; ` +
+			`KubeDNS override "com": is shadowed by VnetDNS override "b.example.com" for the names under b.example.com, both are served on 169.254.10.10`))
+	})
+
+	It("should reject out of range values", func() {
+		c.VnetDNSOverrides[0].MaxConcurrent = ptr(int32(0))
+		c.VnetDNSOverrides[0].CacheDurationInSeconds = ptr(int32(0))
+		c.VnetDNSOverrides[0].ServeStaleDurationInSeconds = ptr(int32(-1))
+		c.KubeDNSOverrides[0].CacheDurationInSeconds = ptr(int32(86401))
+		c.KubeDNSOverrides[0].ServeStaleDurationInSeconds = ptr(int32(86401))
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override ".": maxConcurrent 0 must be positive; ` +
+			`VnetDNS override ".": cacheDurationInSeconds 0 must be between 1 and 86400; ` +
+			`VnetDNS override ".": serveStaleDurationInSeconds -1 must be between 0 and 86400; ` +
+			`KubeDNS override ".": cacheDurationInSeconds 86401 must be between 1 and 86400; ` +
+			`KubeDNS override ".": serveStaleDurationInSeconds 86401 must be between 0 and 86400`))
+	})
+
+	It("should accept the largest durations", func() {
+		c.VnetDNSOverrides[0].CacheDurationInSeconds = ptr(int32(86400))
+		c.VnetDNSOverrides[0].ServeStaleDurationInSeconds = ptr(int32(86400))
+		Expect(Validate(c, nil)).To(Succeed())
+	})
+
+	It("should reject invalid health checks", func() {
+		c.VnetDNSOverrides[0].HealthCheck = HealthCheck{Duration: "0s", Domain: "bad domain"}
+		c.VnetDNSOverrides[1].HealthCheck = HealthCheck{NoRec: true}
+		c.KubeDNSOverrides[0].HealthCheck = HealthCheck{Duration: "soon"}
+		Expect(Validate(c, nil)).To(MatchError(`invalid localdns configuration: ` +
+			`VnetDNS override ".": healthCheck duration "0s" must be a positive duration such as 500ms; ` +
+			`VnetDNS override ".": healthCheck domain "bad domain" has invalid label "bad domain"; ` +
+			`VnetDNS override "cluster.local": healthCheck requires a duration, noRec and domain are ignored without it; ` +
+			`KubeDNS override ".": healthCheck duration "soon" must be a positive duration such as 500ms`))
+	})
+
+	It("should reject invalid and duplicated critical FQDNs", func() {
+		Expect(Validate(c, []string{"mcr.microsoft.com", "MCR.microsoft.com.", "a,b.com", "."})).To(MatchError(`invalid localdns configuration: ` +
+			`criticalFQDNs: "MCR.microsoft.com." is duplicated; ` +
+			`criticalFQDNs: domain "a,b.com" has invalid label "a,b"; ` +
+			`criticalFQDNs: domain "." must be between 1 and 253 characters`))
+	})
+})
@@ -0,0 +1,453 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package sysctlpolicy describes the kernel tunables and containerd ulimits customers may set through
+// CustomLinuxOSConfig, with their type, accepted range, distro applicability, and the defaults
+// AgentBaker sets on every node.
+// It is shared by the baker and by aks-node-controller, so both validate and render the same sysctl.d and
+// limits content, and supporting a new tunable only requires updating policy.json.
+package sysctlpolicy
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of the value of a sysctl or ulimit.
+type Type string
+
+const (
+	// TypeInt values are decimal integers within [Min, Max].
+	TypeInt Type = "int"
+	// TypeBool values are rendered as 1 or 0.
+	TypeBool Type = "bool"
+	// TypePortRange values are two ports "start end" within [Min, Max], with start lower than end.
+	TypePortRange Type = "portRange"
+	// TypeSize values are a number of bytes with an optional K, M, G or T suffix, at least Min.
+	TypeSize Type = "size"
+)
+
+// Setting is the policy of a single sysctl or ulimit.
+type Setting struct {
+	Key  string `json:"key"`
+	Type Type   `json:"type"`
+	Min  *int64 `json:"min,omitempty"`
+	Max  *int64 `json:"max,omitempty"`
+	// Infinity accepts "infinity" as a value, for ulimits.
+	Infinity bool `json:"infinity,omitempty"`
+	// Default is rendered when the customer does not set the key.
+	Default string `json:"default,omitempty"`
+	// Fixed settings are owned by AgentBaker and cannot be set by customers.
+	Fixed bool `json:"fixed,omitempty"`
+	// Field is the JSON name of the CustomLinuxOSConfig SysctlConfig field of the sysctl.
+	Field string `json:"field,omitempty"`
+	// Skip lists the nodes the setting does not apply to, customer values are not rendered on them.
+	Skip []Exclusion `json:"skip,omitempty"`
+	// Comment lines are rendered right above the setting, without the leading "# ".
+	Comment []string `json:"comment,omitempty"`
+}
+
+// Exclusion matches the nodes with all its non-zero fields. A target field which is unknown never matches.
+type Exclusion struct {
+	OSFamily                  string `json:"osFamily,omitempty"`
+	MinContainerdMajorVersion int    `json:"minContainerdMajorVersion,omitempty"`
+	// Reason is reported with the skipped values.
+	Reason string `json:"reason"`
+}
+
+// Policy is the table of supported sysctls and ulimits.
+type Policy struct {
+	// ReservedPort is added to net.ipv4.ip_local_reserved_ports when the local port range includes it.
+	ReservedPort int       `json:"reservedPort"`
+	Sysctls      []Setting `json:"sysctls"`
+	Ulimits      []Setting `json:"ulimits"`
+}
+
+const (
+	localPortRangeKey    = "net.ipv4.ip_local_port_range"
+	localReservedPortKey = "net.ipv4.ip_local_reserved_ports"
+	infinity             = "infinity"
+)
+
+var sizeValue = regexp.MustCompile(`^([0-9]+)([KMGT]?)$`)
+
+//go:embed policy.json
+var embeddedPolicyJSON []byte
+
+//nolint:gochecknoglobals
+var embeddedPolicy = mustParse(embeddedPolicyJSON)
+
+// Embedded returns the policy table embedded in the binary.
+func Embedded() *Policy {
+	return embeddedPolicy
+}
+
+func mustParse(contents []byte) *Policy {
+	p, err := Parse(contents)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Parse parses and validates a JSON sysctl policy table.
+func Parse(contents []byte) (*Policy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+
+	var p Policy
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse sysctl policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	var errs []string
+	for kind, settings := range map[string][]Setting{"sysctl": p.Sysctls, "ulimit": p.Ulimits} {
+		seen, seenFields := map[string]bool{}, map[string]bool{}
+		for _, s := range settings {
+			if s.Key == "" {
+				errs = append(errs, fmt.Sprintf("%s without a key", kind))
+				continue
+			}
+			if seen[s.Key] {
+				errs = append(errs, fmt.Sprintf("%s %s is duplicated", kind, s.Key))
+			}
+			seen[s.Key] = true
+			switch s.Type {
+			case TypeInt, TypeBool, TypePortRange, TypeSize:
+			default:
+				errs = append(errs, fmt.Sprintf("%s %s has unknown type %q", kind, s.Key, s.Type))
+			}
+			if s.Min != nil && s.Max != nil && *s.Min > *s.Max {
+				errs = append(errs, fmt.Sprintf("%s %s min is greater than max", kind, s.Key))
+			}
+			if s.Field != "" {
+				if seenFields[s.Field] {
+					errs = append(errs, fmt.Sprintf("%s %s field %s is duplicated", kind, s.Key, s.Field))
+				}
+				seenFields[s.Field] = true
+			}
+			for _, e := range s.Skip {
+				if e.Reason == "" || (e.OSFamily == "" && e.MinContainerdMajorVersion <= 0) {
+					errs = append(errs, fmt.Sprintf("%s %s skip needs a reason and a node condition", kind, s.Key))
+				}
+			}
+			if s.Default != "" {
+				if _, err := s.normalize(s.Default); err != nil {
+					errs = append(errs, fmt.Sprintf("%s %s default: %s", kind, s.Key, err))
+				}
+			}
+			if s.Fixed && s.Default == "" {
+				errs = append(errs, fmt.Sprintf("%s %s is fixed without a default", kind, s.Key))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid sysctl policy: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Target describes the node the values are rendered for. Applicability rules depending on an unknown, zero,
+// field are not checked.
+type Target struct {
+	// OSFamily is the OS family of the distro capabilities, e.g. Ubuntu or AzureLinux.
+	OSFamily               string
+	ContainerdMajorVersion int
+}
+
+// Rejection is a customer value which is not rendered.
+type Rejection struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+// RejectedValuesError is returned when customer sysctls or ulimits are rejected.
+type RejectedValuesError struct {
+	Rejected []Rejection
+}
+
+func (e *RejectedValuesError) Error() string {
+	msgs := make([]string, 0, len(e.Rejected))
+	for _, r := range e.Rejected {
+		msgs = append(msgs, fmt.Sprintf("invalid %s: %s", r.Key, r.Reason))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Result is the outcome of evaluating customer values against the policy.
+type Result struct {
+	// Sysctls and Ulimits are the values to render, including defaults, keyed by sysctl or ulimit name.
+	Sysctls map[string]string
+	Ulimits map[string]string
+	// Rejected are sorted by key.
+	Rejected []Rejection
+	// Skipped are the valid customer values which do not apply to the target, sorted by key. They are not an error.
+	Skipped []Rejection
+	// agentBakerKeys are the sysctls AgentBaker sets on every node.
+	agentBakerKeys map[string]bool
+	// comments are the comment lines of the sysctls, keyed by sysctl name.
+	comments map[string][]string
+}
+
+// SysctlValues returns the customer sysctls keyed by sysctl name from the JSON form of a CustomLinuxOSConfig
+// SysctlConfig, the baker and the AKSNodeConfig types share its field names. Unset and empty fields are left out.
+func (p *Policy) SysctlValues(sysctlConfig []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(sysctlConfig))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to parse sysctl config: %w", err)
+	}
+	values := make(map[string]string, len(fields))
+	for field, value := range fields {
+		i := slices.IndexFunc(p.Sysctls, func(s Setting) bool { return s.Field == field })
+		if i < 0 {
+			return nil, fmt.Errorf("sysctl config field %s has no sysctl", field)
+		}
+		switch v := value.(type) {
+		case nil:
+		case json.Number:
+			values[p.Sysctls[i].Key] = v.String()
+		case bool:
+			values[p.Sysctls[i].Key] = strconv.FormatBool(v)
+		case string:
+			if v != "" {
+				values[p.Sysctls[i].Key] = v
+			}
+		default:
+			return nil, fmt.Errorf("sysctl config field %s has an unexpected value %v", field, value)
+		}
+	}
+	return values, nil
+}
+
+// Evaluate validates the customer sysctls and ulimits, keyed by sysctl and ulimit name, and returns the values
+// to render. Rejected values are left out of the result, and a *RejectedValuesError is returned together with it.
+// Valid values which do not apply to the target are left out of the result as well, they are reported in Skipped.
+func (p *Policy) Evaluate(target Target, sysctls, ulimits map[string]string) (*Result, error) {
+	result := &Result{
+		Sysctls:        map[string]string{},
+		Ulimits:        map[string]string{},
+		agentBakerKeys: map[string]bool{},
+		comments:       map[string][]string{},
+	}
+	for _, s := range p.Sysctls {
+		if s.Default != "" {
+			result.agentBakerKeys[s.Key] = true
+		}
+		if len(s.Comment) > 0 {
+			result.comments[s.Key] = s.Comment
+		}
+	}
+	result.evaluate(target, p.Sysctls, sysctls, result.Sysctls)
+	result.evaluate(target, p.Ulimits, ulimits, result.Ulimits)
+	byKey := func(r []Rejection) func(i, j int) bool { return func(i, j int) bool { return r[i].Key < r[j].Key } }
+	sort.SliceStable(result.Rejected, byKey(result.Rejected))
+	sort.SliceStable(result.Skipped, byKey(result.Skipped))
+
+	if portRange, ok := result.Sysctls[localPortRangeKey]; ok && p.ReservedPort > 0 {
+		if _, end, err := parsePortRange(portRange); err == nil && end >= p.ReservedPort {
+			result.Sysctls[localReservedPortKey] = strconv.Itoa(p.ReservedPort)
+		}
+	}
+	if len(result.Rejected) > 0 {
+		return result, &RejectedValuesError{Rejected: result.Rejected}
+	}
+	return result, nil
+}
+
+func (r *Result) evaluate(target Target, settings []Setting, values, rendered map[string]string) {
+	for _, s := range settings {
+		if s.Default != "" {
+			rendered[s.Key] = s.Default
+		}
+	}
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		i := slices.IndexFunc(settings, func(s Setting) bool { return s.Key == key })
+		if i < 0 {
+			r.Rejected = append(r.Rejected, Rejection{Key: key, Value: value, Reason: "not a supported setting"})
+			continue
+		}
+		s := settings[i]
+		if s.Fixed {
+			r.Rejected = append(r.Rejected, Rejection{Key: key, Value: value, Reason: "set by AgentBaker and cannot be customized"})
+			continue
+		}
+		normalized, err := s.normalize(value)
+		if err != nil {
+			r.Rejected = append(r.Rejected, Rejection{Key: key, Value: value, Reason: err.Error()})
+			continue
+		}
+		if reason := s.notApplicable(target); reason != "" {
+			r.Skipped = append(r.Skipped, Rejection{Key: key, Value: value, Reason: reason})
+			continue
+		}
+		rendered[key] = normalized
+	}
+}
+
+// notApplicable returns why the setting does not apply to the target, or "" when it does.
+func (s Setting) notApplicable(target Target) string {
+	for _, e := range s.Skip {
+		if e.matches(target) {
+			return e.Reason
+		}
+	}
+	return ""
+}
+
+func (e Exclusion) matches(target Target) bool {
+	if e.OSFamily != "" && (target.OSFamily == "" || target.OSFamily != e.OSFamily) {
+		return false
+	}
+	if e.MinContainerdMajorVersion > 0 &&
+		(target.ContainerdMajorVersion == 0 || target.ContainerdMajorVersion < e.MinContainerdMajorVersion) {
+		return false
+	}
+	return true
+}
+
+// normalize validates the value and returns it as rendered.
+func (s Setting) normalize(value string) (string, error) {
+	if s.Infinity && value == infinity {
+		return value, nil
+	}
+	switch s.Type {
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		if b {
+			return "1", nil
+		}
+		return "0", nil
+	case TypePortRange:
+		start, end, err := parsePortRange(value)
+		if err != nil {
+			return "", err
+		}
+		if err := s.checkRange(int64(start)); err != nil {
+			return "", err
+		}
+		if err := s.checkRange(int64(end)); err != nil {
+			return "", err
+		}
+		return value, nil
+	case TypeSize:
+		m := sizeValue.FindStringSubmatch(value)
+		if m == nil {
+			return "", fmt.Errorf("%q is not a size in bytes with an optional K, M, G or T suffix", value)
+		}
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is out of range", value)
+		}
+		if err := s.checkRange(n); err != nil {
+			return "", err
+		}
+		return value, nil
+	default:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+		if err := s.checkRange(n); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+}
+
+func (s Setting) checkRange(n int64) error {
+	switch {
+	case s.Min != nil && s.Max != nil && (n < *s.Min || n > *s.Max):
+		return fmt.Errorf("%d must be between %d and %d", n, *s.Min, *s.Max)
+	case s.Min != nil && n < *s.Min:
+		return fmt.Errorf("%d must be at least %d", n, *s.Min)
+	case s.Max != nil && n > *s.Max:
+		return fmt.Errorf("%d must be at most %d", n, *s.Max)
+	}
+	return nil
+}
+
+// parsePortRange parses a port range in the format of "start end".
+func parsePortRange(portRange string) (start, end int, err error) {
+	arr := strings.Split(portRange, " ")
+	if len(arr) != 2 {
+		return 0, 0, fmt.Errorf("port range %q should be in the format of \"start end\"", portRange)
+	}
+	if start, err = strconv.Atoi(arr[0]); err != nil {
+		return 0, 0, fmt.Errorf("error converting port range start value to int: %w", err)
+	}
+	if end, err = strconv.Atoi(arr[1]); err != nil {
+		return 0, 0, fmt.Errorf("error converting port range end value to int: %w", err)
+	}
+	if start >= end {
+		return 0, 0, fmt.Errorf("port range end value should be greater than the start value: %d >= %d", start, end)
+	}
+	return start, end, nil
+}
+
+// SysctlContent renders the sysctl.d file, one key=value line per sysctl sorted by key, each preceded by the
+// comment lines of its policy. The sysctls AgentBaker sets on every node come first, followed by the other
+// customer sysctls.
+func (r *Result) SysctlContent() string {
+	var b strings.Builder
+	var customer []string
+	for _, key := range sortedKeys(r.Sysctls) {
+		if !r.agentBakerKeys[key] {
+			customer = append(customer, key)
+			continue
+		}
+		r.writeSysctl(&b, key)
+	}
+	if len(customer) > 0 {
+		b.WriteString("# The following are sysctl configs passed from API\n")
+		for _, key := range customer {
+			r.writeSysctl(&b, key)
+		}
+	}
+	return b.String()
+}
+
+func (r *Result) writeSysctl(b *strings.Builder, key string) {
+	for _, line := range r.comments[key] {
+		b.WriteString("# " + line + "\n")
+	}
+	fmt.Fprintf(b, "%s=%s\n", key, r.Sysctls[key])
+}
+
+// UlimitLines returns the key=value lines of the containerd service limits, sorted by key.
+func (r *Result) UlimitLines() []string {
+	lines := make([]string, 0, len(r.Ulimits))
+	for _, key := range sortedKeys(r.Ulimits) {
+		lines = append(lines, key+"="+r.Ulimits[key])
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,220 @@
+package sysctlpolicy
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sysctl policy", func() {
+	Context("default policy", func() {
+		It("should parse the embedded table", func() {
+			Expect(Embedded().Sysctls).NotTo(BeEmpty())
+			Expect(Embedded().Ulimits).NotTo(BeEmpty())
+		})
+
+		It("should render the defaults without customer values", func() {
+			result, err := Embedded().Evaluate(Target{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.SysctlContent()).To(Equal(`net.core.message_burst=80
+net.core.message_cost=40
+net.core.somaxconn=16384
+net.ipv4.neigh.default.gc_thresh1=4096
+net.ipv4.neigh.default.gc_thresh2=8192
+net.ipv4.neigh.default.gc_thresh3=16384
+net.ipv4.tcp_max_syn_backlog=16384
+# This is a partial workaround to this upstream Kubernetes issue:
+# https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
+net.ipv4.tcp_retries2=8
+`))
+			Expect(result.UlimitLines()).To(BeEmpty())
+		})
+
+		It("should render customer values over the defaults", func() {
+			result, err := Embedded().Evaluate(Target{}, map[string]string{
+				"net.core.somaxconn":           "4096",
+				"net.ipv4.tcp_tw_reuse":        "true",
+				"net.ipv4.ip_local_port_range": "32768 65535",
+				"vm.swappiness":                "010",
+			}, map[string]string{
+				"LimitMEMLOCK": "75000K",
+				"LimitNOFILE":  "1048",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Sysctls).To(HaveKeyWithValue("net.core.somaxconn", "4096"))
+			Expect(result.Sysctls).To(HaveKeyWithValue("net.ipv4.tcp_tw_reuse", "1"))
+			Expect(result.Sysctls).To(HaveKeyWithValue("net.ipv4.ip_local_port_range", "32768 65535"))
+			Expect(result.Sysctls).To(HaveKeyWithValue("net.ipv4.ip_local_reserved_ports", "65330"))
+			Expect(result.Sysctls).To(HaveKeyWithValue("vm.swappiness", "10"))
+			Expect(result.UlimitLines()).To(Equal([]string{"LimitMEMLOCK=75000K", "LimitNOFILE=1048"}))
+			Expect(result.SysctlContent()).To(Equal(`net.core.message_burst=80
+net.core.message_cost=40
+net.core.somaxconn=4096
+net.ipv4.neigh.default.gc_thresh1=4096
+net.ipv4.neigh.default.gc_thresh2=8192
+net.ipv4.neigh.default.gc_thresh3=16384
+net.ipv4.tcp_max_syn_backlog=16384
+# This is a partial workaround to this upstream Kubernetes issue:
+# https://github.com/kubernetes/kubernetes/issues/41916#issuecomment-312428731
+net.ipv4.tcp_retries2=8
+# The following are sysctl configs passed from API
+net.ipv4.ip_local_port_range=32768 65535
+net.ipv4.ip_local_reserved_ports=65330
+net.ipv4.tcp_tw_reuse=1
+vm.swappiness=10
+`))
+		})
+
+		It("should render a false boolean as 0", func() {
+			values, err := Embedded().SysctlValues([]byte(`{"netIpv4TcpTwReuse": false}`))
+			Expect(err).NotTo(HaveOccurred())
+			result, err := Embedded().Evaluate(Target{}, values, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.SysctlContent()).To(HaveSuffix("# The following are sysctl configs passed from API\nnet.ipv4.tcp_tw_reuse=0\n"))
+		})
+
+		It("should reserve ports up to the end of the local port range", func() {
+			for portRange, reserved := range map[string]bool{"1024 65330": true, "1024 65329": false} {
+				result, err := Embedded().Evaluate(Target{}, map[string]string{"net.ipv4.ip_local_port_range": portRange}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				if reserved {
+					Expect(result.Sysctls).To(HaveKeyWithValue("net.ipv4.ip_local_reserved_ports", "65330"), portRange)
+				} else {
+					Expect(result.Sysctls).NotTo(HaveKey("net.ipv4.ip_local_reserved_ports"), portRange)
+				}
+			}
+		})
+
+		It("should reject port ranges without exactly two ports", func() {
+			for _, portRange := range []string{"1024", "1024 2048 4096"} {
+				_, err := Embedded().Evaluate(Target{}, map[string]string{"net.ipv4.ip_local_port_range": portRange}, nil)
+				Expect(err).To(MatchError(ContainSubstring(`should be in the format of "start end"`)), portRange)
+			}
+		})
+
+		It("should not reserve ports outside the local port range", func() {
+			result, err := Embedded().Evaluate(Target{}, map[string]string{"net.ipv4.ip_local_port_range": "32768 60999"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Sysctls).NotTo(HaveKey("net.ipv4.ip_local_reserved_ports"))
+		})
+
+		It("should reject unsupported, fixed and out of range values", func() {
+			result, err := Embedded().Evaluate(Target{}, map[string]string{
+				"kernel.panic":                 "1",
+				"net.core.message_burst":       "10",
+				"net.core.rmem_max":            "9999",
+				"net.ipv4.ip_local_port_range": "32768 1024",
+				"net.ipv4.tcp_tw_reuse":        "maybe",
+				"vm.swappiness":                "101",
+				"vm.max_map_count":             "65530",
+			}, map[string]string{
+				"LimitMEMLOCK": "lots",
+				"LimitNOFILE":  "infinity",
+			})
+			var rejected *RejectedValuesError
+			Expect(errors.As(err, &rejected)).To(BeTrue())
+			Expect(err).To(MatchError("invalid LimitMEMLOCK: \"lots\" is not a size in bytes with an optional K, M, G or T suffix; " +
+				"invalid LimitNOFILE: \"infinity\" is not an integer; " +
+				"invalid kernel.panic: not a supported setting; " +
+				"invalid net.core.message_burst: set by AgentBaker and cannot be customized; " +
+				"invalid net.core.rmem_max: 9999 must be between 212992 and 134217728; " +
+				"invalid net.ipv4.ip_local_port_range: port range end value should be greater than the start value: 32768 >= 1024; " +
+				"invalid net.ipv4.tcp_tw_reuse: \"maybe\" is not a boolean; " +
+				"invalid vm.swappiness: 101 must be between 0 and 100"))
+			Expect(result.Rejected).To(HaveLen(8))
+			Expect(result.Rejected[0]).To(Equal(Rejection{Key: "LimitMEMLOCK", Value: "lots",
+				Reason: "\"lots\" is not a size in bytes with an optional K, M, G or T suffix"}))
+
+			By("rendering the accepted values and the defaults only")
+			Expect(result.Sysctls).To(HaveKeyWithValue("vm.max_map_count", "65530"))
+			Expect(result.Sysctls).To(HaveKeyWithValue("net.core.message_burst", "80"))
+			Expect(result.Sysctls).NotTo(HaveKey("kernel.panic"))
+			Expect(result.Sysctls).NotTo(HaveKey("net.ipv4.ip_local_port_range"))
+			Expect(result.Ulimits).To(BeEmpty())
+		})
+
+		It("should reject values injecting other settings", func() {
+			_, err := Embedded().Evaluate(Target{}, map[string]string{"net.ipv4.ip_local_port_range": "32768 65400\nkernel.panic=1"}, nil)
+			Expect(err).To(MatchError(ContainSubstring("invalid net.ipv4.ip_local_port_range: error converting port range end value to int")))
+		})
+	})
+
+	Context("applicability", func() {
+		It("should render LimitNOFILE when the node is not Ubuntu with containerd 2.x", func() {
+			for _, target := range []Target{
+				{},
+				{ContainerdMajorVersion: 2},
+				{OSFamily: "Ubuntu"},
+				{OSFamily: "Ubuntu", ContainerdMajorVersion: 1},
+				{OSFamily: "AzureLinux", ContainerdMajorVersion: 2},
+			} {
+				result, err := Embedded().Evaluate(target, nil, map[string]string{"LimitNOFILE": "1048576"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.UlimitLines()).To(Equal([]string{"LimitNOFILE=1048576"}), "%+v", target)
+				Expect(result.Skipped).To(BeEmpty())
+			}
+		})
+
+		It("should skip LimitNOFILE on Ubuntu with containerd 2.x, which removed it", func() {
+			result, err := Embedded().Evaluate(Target{OSFamily: "Ubuntu", ContainerdMajorVersion: 2}, nil, map[string]string{
+				"LimitMEMLOCK": "infinity",
+				"LimitNOFILE":  "1048576",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.UlimitLines()).To(Equal([]string{"LimitMEMLOCK=infinity"}))
+			Expect(result.Skipped).To(Equal([]Rejection{{Key: "LimitNOFILE", Value: "1048576",
+				Reason: "containerd 2.0 removed the LimitNOFILE configuration"}}))
+		})
+
+		It("should still reject invalid values which do not apply to the target", func() {
+			_, err := Embedded().Evaluate(Target{OSFamily: "Ubuntu", ContainerdMajorVersion: 2}, nil, map[string]string{"LimitNOFILE": "16"})
+			Expect(err).To(MatchError("invalid LimitNOFILE: 16 must be between 1024 and 1048576"))
+		})
+	})
+
+	Context("SysctlValues", func() {
+		It("should key the SysctlConfig fields by sysctl name", func() {
+			values, err := Embedded().SysctlValues([]byte(`{"netCoreSomaxconn": 4096, "netIpv4TcpTwReuse": false,
+				"netIpv4IpLocalPortRange": "32768 65535", "vmSwappiness": null, "netIpv4TcpkeepaliveIntvl": 30}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal(map[string]string{
+				"net.core.somaxconn":           "4096",
+				"net.ipv4.tcp_tw_reuse":        "false",
+				"net.ipv4.ip_local_port_range": "32768 65535",
+				"net.ipv4.tcp_keepalive_intvl": "30",
+			}))
+		})
+
+		It("should reject fields without sysctl", func() {
+			_, err := Embedded().SysctlValues([]byte(`{"kernelPanic": 1}`))
+			Expect(err).To(MatchError("sysctl config field kernelPanic has no sysctl"))
+		})
+	})
+
+	Context("parsing", func() {
+		It("should reject unknown fields", func() {
+			_, err := Parse([]byte(`{"sysctls": [{"key": "vm.swappiness", "type": "int", "maximum": 100}]}`))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "maximum"`)))
+		})
+
+		It("should reject inconsistent settings", func() {
+			_, err := Parse([]byte(`{"sysctls": [
+				{"key": "vm.swappiness", "field": "vmSwappiness", "type": "int", "min": 100, "max": 0},
+				{"key": "vm.swappiness", "type": "float"},
+				{"key": "net.core.message_cost", "type": "int", "fixed": true},
+				{"key": "net.core.somaxconn", "field": "vmSwappiness", "type": "int", "min": 4096, "default": "128"}
+			], "ulimits": [
+				{"key": "LimitNOFILE", "type": "int", "skip": [{"osFamily": "Ubuntu"}]}
+			]}`))
+			Expect(err).To(MatchError("invalid sysctl policy: " +
+				"sysctl net.core.message_cost is fixed without a default; " +
+				"sysctl net.core.somaxconn default: 128 must be at least 4096; " +
+				"sysctl net.core.somaxconn field vmSwappiness is duplicated; " +
+				"sysctl vm.swappiness has unknown type \"float\"; " +
+				"sysctl vm.swappiness is duplicated; " +
+				"sysctl vm.swappiness min is greater than max; " +
+				"ulimit LimitNOFILE skip needs a reason and a node condition"))
+		})
+	})
+})
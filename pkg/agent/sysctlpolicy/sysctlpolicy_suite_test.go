@@ -0,0 +1,13 @@
+package sysctlpolicy
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSysctlPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "sysctlpolicy suite")
+}
@@ -16,9 +16,12 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Azure/agentbaker/parts"
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletconfig"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/Masterminds/semver/v3"
 )
@@ -396,9 +399,14 @@ func GetOrderedKubeletConfigFlagString(config *datamodel.NodeBootstrappingConfig
 	kubeletConfigFileEnabled := IsKubeletConfigFileEnabled(cs, profile, kubeletConfigFileToggleEnabled)
 	keys := []string{}
 	ommitedKubletConfigFlags := datamodel.GetCommandLineOmittedKubeletConfigFlags()
+	var version string
+	if cs.Properties.OrchestratorProfile != nil {
+		version = cs.Properties.OrchestratorProfile.OrchestratorVersion
+	}
+	policy := kubeletpolicy.Embedded()
 	for key := range k {
 		if !kubeletConfigFileEnabled || !TranslatedKubeletConfigFlags[key] {
-			if !ommitedKubletConfigFlags[key] {
+			if !ommitedKubletConfigFlags[key] && (!kubeletConfigFileEnabled || policy.StateOf(key, version) != kubeletpolicy.StateConfigFile) {
 				keys = append(keys, key)
 			}
 		}
@@ -460,12 +468,7 @@ func getKubeletCustomConfiguration(properties *datamodel.Properties) map[string]
 // getDeprecatedKubeletFlags returns flags that have been removed from KubeletConfiguration
 // at the given k8s version and must not appear on the command line.
 func getDeprecatedKubeletFlags(k8sVersion string) map[string]bool {
-	flags := map[string]bool{}
-	// streamingConnectionIdleTimeout was removed from KubeletConfiguration in k8s 1.34+.
-	if IsKubernetesVersionGe(k8sVersion, "1.34.0") {
-		flags["--streaming-connection-idle-timeout"] = true
-	}
-	return flags
+	return kubeletpolicy.Embedded().DroppedFlags(k8sVersion)
 }
 
 // IsKubeletConfigFileEnabled get if dynamic kubelet is supported in AKS and toggle is on.
@@ -558,39 +561,36 @@ func setNodeHardeningCgroupFlags(kubeletFlags map[string]string) {
 	delete(kubeletFlags, "--system-reserved-cgroup")
 }
 
-func getAKSKubeletConfiguration(kc map[string]string) *datamodel.AKSKubeletConfiguration {
-	kubeletConfig := &datamodel.AKSKubeletConfiguration{
-		APIVersion:    "kubelet.config.k8s.io/v1beta1",
-		Kind:          "KubeletConfiguration",
-		Address:       kc["--address"],
-		StaticPodPath: kc["--pod-manifest-path"],
-		Authorization: datamodel.KubeletAuthorization{
-			Mode: datamodel.KubeletAuthorizationMode(kc["--authorization-mode"]),
-		},
-		ClusterDNS:                     strings.Split(kc["--cluster-dns"], ","),
-		CgroupsPerQOS:                  strToBoolPtr(kc["--cgroups-per-qos"]),
-		TLSCertFile:                    kc["--tls-cert-file"],
-		TLSPrivateKeyFile:              kc["--tls-private-key-file"],
-		TLSCipherSuites:                strings.Split(kc["--tls-cipher-suites"], ","),
-		ClusterDomain:                  kc["--cluster-domain"],
-		MaxPods:                        strToInt32(kc["--max-pods"]),
-		NodeStatusUpdateFrequency:      datamodel.Duration(kc["--node-status-update-frequency"]),
-		NodeStatusReportFrequency:      datamodel.Duration(kc["--node-status-report-frequency"]),
-		ImageGCHighThresholdPercent:    strToInt32Ptr(kc["--image-gc-high-threshold"]),
-		ImageGCLowThresholdPercent:     strToInt32Ptr(kc["--image-gc-low-threshold"]),
-		EventRecordQPS:                 strToInt32Ptr(kc["--event-qps"]),
-		PodPidsLimit:                   strToInt64Ptr(kc["--pod-max-pids"]),
-		EnforceNodeAllocatable:         strings.Split(kc["--enforce-node-allocatable"], ","),
-		KubeReservedCgroup:             kc["--kube-reserved-cgroup"],
-		SystemReservedCgroup:           kc["--system-reserved-cgroup"],
-		StreamingConnectionIdleTimeout: datamodel.Duration(kc["--streaming-connection-idle-timeout"]),
-		RotateCertificates:             strToBool(kc["--rotate-certificates"]),
-		ServerTLSBootstrap:             strToBool(kc["--rotate-server-certificates"]),
-		ReadOnlyPort:                   strToInt32(kc["--read-only-port"]),
-		ProtectKernelDefaults:          strToBool(kc["--protect-kernel-defaults"]),
-		ResolverConfig:                 kc["--resolv-conf"],
-		ContainerLogMaxSize:            kc["--container-log-max-size"],
-	}
+func getAKSKubeletConfiguration(kc map[string]string) *kubeletconfig.KubeletConfiguration {
+	kubeletConfig := kubeletconfig.New()
+	kubeletConfig.Address = kc["--address"]
+	kubeletConfig.StaticPodPath = kc["--pod-manifest-path"]
+	kubeletConfig.Authorization.Mode = kubeletconfig.KubeletAuthorizationMode(kc["--authorization-mode"])
+	kubeletConfig.ClusterDNS = strings.Split(kc["--cluster-dns"], ",")
+	kubeletConfig.CgroupsPerQOS = strToBoolPtr(kc["--cgroups-per-qos"])
+	kubeletConfig.TLSCertFile = kc["--tls-cert-file"]
+	kubeletConfig.TLSPrivateKeyFile = kc["--tls-private-key-file"]
+	kubeletConfig.TLSCipherSuites = strings.Split(kc["--tls-cipher-suites"], ",")
+	kubeletConfig.ClusterDomain = kc["--cluster-domain"]
+	kubeletConfig.MaxPods = strToInt32(kc["--max-pods"])
+	kubeletConfig.NodeStatusUpdateFrequency = strToDuration(kc["--node-status-update-frequency"])
+	kubeletConfig.NodeStatusReportFrequency = strToDuration(kc["--node-status-report-frequency"])
+	kubeletConfig.ImageGCHighThresholdPercent = strToInt32Ptr(kc["--image-gc-high-threshold"])
+	kubeletConfig.ImageGCLowThresholdPercent = strToInt32Ptr(kc["--image-gc-low-threshold"])
+	kubeletConfig.EventRecordQPS = strToInt32Ptr(kc["--event-qps"])
+	kubeletConfig.PodPidsLimit = strToInt64Ptr(kc["--pod-max-pids"])
+	kubeletConfig.EnforceNodeAllocatable = strings.Split(kc["--enforce-node-allocatable"], ",")
+	kubeletConfig.KubeReservedCgroup = kc["--kube-reserved-cgroup"]
+	kubeletConfig.SystemReservedCgroup = kc["--system-reserved-cgroup"]
+	kubeletConfig.StreamingConnectionIdleTimeout = strToDuration(kc["--streaming-connection-idle-timeout"])
+	kubeletConfig.RotateCertificates = strToBool(kc["--rotate-certificates"])
+	kubeletConfig.ServerTLSBootstrap = strToBool(kc["--rotate-server-certificates"])
+	kubeletConfig.ReadOnlyPort = strToInt32(kc["--read-only-port"])
+	kubeletConfig.ProtectKernelDefaults = strToBool(kc["--protect-kernel-defaults"])
+	if resolvConf := kc["--resolv-conf"]; resolvConf != "" {
+		kubeletConfig.ResolverConfig = &resolvConf
+	}
+	kubeletConfig.ContainerLogMaxSize = kc["--container-log-max-size"]
 
 	// Serialize Image Pulls will only be set for k8s >= 1.31, currently RP doesnt pass this flag
 	// It will starting with k8s 1.31
@@ -603,7 +603,7 @@ func getAKSKubeletConfiguration(kc map[string]string) *datamodel.AKSKubeletConfi
 
 //nolint:gocognit
 func setCustomKubeletConfig(customKc *datamodel.CustomKubeletConfig,
-	kubeletConfig *datamodel.AKSKubeletConfiguration) {
+	kubeletConfig *kubeletconfig.KubeletConfiguration) {
 	if customKc != nil { //nolint:nestif
 		if customKc.CPUManagerPolicy != "" {
 			kubeletConfig.CPUManagerPolicy = customKc.CPUManagerPolicy
@@ -612,7 +612,8 @@ func setCustomKubeletConfig(customKc *datamodel.CustomKubeletConfig,
 			kubeletConfig.CPUCFSQuota = customKc.CPUCfsQuota
 		}
 		if customKc.CPUCfsQuotaPeriod != "" {
-			kubeletConfig.CPUCFSQuotaPeriod = datamodel.Duration(customKc.CPUCfsQuotaPeriod)
+			period := strToDuration(customKc.CPUCfsQuotaPeriod)
+			kubeletConfig.CPUCFSQuotaPeriod = &period
 			// enable CustomCPUCFSQuotaPeriod feature gate is required for this configuration.
 			kubeletConfig.FeatureGates["CustomCPUCFSQuotaPeriod"] = true
 		}
@@ -646,30 +647,21 @@ func setCustomKubeletConfig(customKc *datamodel.CustomKubeletConfig,
 	}
 }
 
-// GetKubeletConfigFileContent converts kubelet flags we set to a file, and return the json content.
-func GetKubeletConfigFileContent(kc map[string]string, customKc *datamodel.CustomKubeletConfig) string {
-	if kc == nil {
-		return ""
-	}
+// getKubeletConfiguration converts kubelet flags we set to the kubelet config file of the given Kubernetes version.
+func getKubeletConfiguration(kc map[string]string, customKc *datamodel.CustomKubeletConfig,
+	k8sVersion string) *kubeletconfig.KubeletConfiguration {
 	// translate simple values.
 	kubeletConfig := getAKSKubeletConfiguration(kc)
 
 	// Authentication.
-	kubeletConfig.Authentication = datamodel.KubeletAuthentication{}
 	if ca := kc["--client-ca-file"]; ca != "" {
-		kubeletConfig.Authentication.X509 = datamodel.KubeletX509Authentication{
-			ClientCAFile: ca,
-		}
+		kubeletConfig.Authentication.X509.ClientCAFile = ca
 	}
 	if aw := kc["--authentication-token-webhook"]; aw != "" {
-		kubeletConfig.Authentication.Webhook = datamodel.KubeletWebhookAuthentication{
-			Enabled: strToBool(aw),
-		}
+		kubeletConfig.Authentication.Webhook.Enabled = strToBoolPtr(aw)
 	}
 	if aa := kc["--anonymous-auth"]; aa != "" {
-		kubeletConfig.Authentication.Anonymous = datamodel.KubeletAnonymousAuthentication{
-			Enabled: strToBool(aa),
-		}
+		kubeletConfig.Authentication.Anonymous.Enabled = strToBoolPtr(aa)
 	}
 
 	// EvictionHard.
@@ -705,7 +697,16 @@ func GetKubeletConfigFileContent(kc map[string]string, customKc *datamodel.Custo
 	// Settings from customKubeletConfig, only take if it's set.
 	setCustomKubeletConfig(customKc, kubeletConfig)
 
-	configStringByte, _ := json.MarshalIndent(kubeletConfig, "", "    ")
+	kubeletconfig.SetDefaults(kubeletConfig, k8sVersion)
+	return kubeletConfig
+}
+
+// GetKubeletConfigFileContent converts kubelet flags we set to a file, and return the json content.
+func GetKubeletConfigFileContent(kc map[string]string, customKc *datamodel.CustomKubeletConfig, k8sVersion string) string {
+	if kc == nil {
+		return ""
+	}
+	configStringByte, _ := kubeletconfig.Marshal(getKubeletConfiguration(kc, customKc, k8sVersion))
 	return string(configStringByte)
 }
 
@@ -751,6 +752,11 @@ func strToInt64Ptr(str string) *int64 {
 	return &i
 }
 
+func strToDuration(str string) kubeletconfig.Duration {
+	d, _ := time.ParseDuration(str)
+	return kubeletconfig.Duration{Duration: d}
+}
+
 func strKeyValToMap(str string, pairDelim string) map[string]string {
 	m := make(map[string]string)
 	pairs := strings.Split(str, ",")
@@ -765,23 +771,6 @@ func strKeyValToMap(str string, pairDelim string) map[string]string {
 	return m
 }
 
-// isValidEvictionSignal reports whether the given key is an eviction signal recognized by kubelet.
-// See https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/#eviction-signals.
-func isValidEvictionSignal(signal string) bool {
-	switch signal {
-	case "memory.available",
-		"nodefs.available",
-		"nodefs.inodesFree",
-		"imagefs.available",
-		"imagefs.inodesFree",
-		"pid.available",
-		"allocatableMemory.available":
-		return true
-	default:
-		return false
-	}
-}
-
 // filterEvictionSignals drops any keys not recognized by the kubelet so we never pass it an invalid value.
 func filterEvictionSignals(signals map[string]string) map[string]string {
 	if len(signals) == 0 {
@@ -791,7 +780,7 @@ func filterEvictionSignals(signals map[string]string) map[string]string {
 	// Copy only the entries whose key is a kubelet-recognized eviction signal.
 	validSignals := make(map[string]string, len(signals))
 	for signal, threshold := range signals {
-		if isValidEvictionSignal(signal) {
+		if kubeletconfig.IsEvictionSignal(signal) {
 			validSignals[signal] = threshold
 		}
 	}
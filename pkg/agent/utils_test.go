@@ -6,6 +6,7 @@ package agent
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,6 +17,8 @@ import (
 	"testing"
 
 	"github.com/Azure/agentbaker/pkg/agent/datamodel"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletconfig"
+	"github.com/Azure/agentbaker/pkg/agent/kubeletpolicy"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
@@ -69,7 +72,7 @@ func TestGetKubeletConfigFileFromFlags(t *testing.T) {
 		PodMaxPids:            to.Int32Ptr(12345),
 		SeccompDefault:        to.BoolPtr(true),
 	}
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 	diff := cmp.Diff(expectedKubeletJSON, configFileStr)
 	if diff != "" {
 		t.Errorf("Generated config file is different than expected: %s", diff)
@@ -85,9 +88,9 @@ func TestGetKubeletConfigFileContent_MergesFlagsWithoutOverwritingContent(t *tes
 		ImageGcHighThreshold: to.Int32Ptr(90),
 	}
 
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 
-	var merged datamodel.AKSKubeletConfiguration
+	var merged kubeletconfig.KubeletConfiguration
 	err := json.Unmarshal([]byte(configFileStr), &merged)
 	if err != nil {
 		t.Fatalf("failed to parse generated kubelet config json: %v", err)
@@ -120,9 +123,9 @@ func TestGetKubeletConfigFileContent_PrecedenceRules(t *testing.T) {
 		// maxPods, eventRecordQPS, clusterDNS, evictionHard NOT set — must come from flags
 	}
 
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 
-	var merged datamodel.AKSKubeletConfiguration
+	var merged kubeletconfig.KubeletConfiguration
 	err := json.Unmarshal([]byte(configFileStr), &merged)
 	if err != nil {
 		t.Fatalf("failed to parse generated kubelet config json: %v", err)
@@ -247,11 +250,12 @@ var expectedKubeletJSON = `{
         "webhook": {
             "enabled": true
         },
-        "anonymous": {}
+        "anonymous": {
+            "enabled": false
+        }
     },
     "authorization": {
-        "mode": "Webhook",
-        "webhook": {}
+        "mode": "Webhook"
     },
     "eventRecordQPS": 0,
     "clusterDomain": "cluster.local",
@@ -327,11 +331,12 @@ var expectedKubeletJSONWithNodeStatusReportFrequency = `{
         "webhook": {
             "enabled": true
         },
-        "anonymous": {}
+        "anonymous": {
+            "enabled": false
+        }
     },
     "authorization": {
-        "mode": "Webhook",
-        "webhook": {}
+        "mode": "Webhook"
     },
     "eventRecordQPS": 0,
     "clusterDomain": "cluster.local",
@@ -406,11 +411,12 @@ var expectedKubeletJSONWithContainerMaxLogSizeDefaultFromFlags = `{
         "webhook": {
             "enabled": true
         },
-        "anonymous": {}
+        "anonymous": {
+            "enabled": false
+        }
     },
     "authorization": {
-        "mode": "Webhook",
-        "webhook": {}
+        "mode": "Webhook"
     },
     "eventRecordQPS": 0,
     "clusterDomain": "cluster.local",
@@ -532,7 +538,7 @@ func TestGetKubeletConfigFileFlagsWithNodeStatusReportFrequency(t *testing.T) {
 		FailSwapOn:            to.BoolPtr(false),
 		PodMaxPids:            to.Int32Ptr(12345),
 	}
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 	diff := cmp.Diff(expectedKubeletJSONWithNodeStatusReportFrequency, configFileStr)
 	if diff != "" {
 		t.Errorf("Generated config file is different than expected: %s", diff)
@@ -553,7 +559,7 @@ func TestGetKubeletConfigFileFromFlagsWithContainerLogMaxSize(t *testing.T) {
 		ContainerLogMaxFiles:  to.Int32Ptr(99),
 		PodMaxPids:            to.Int32Ptr(12345),
 	}
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 	diff := cmp.Diff(expectedKubeletJSONWithContainerMaxLogSizeDefaultFromFlags, configFileStr)
 	if diff != "" {
 		t.Errorf("Generated config file is different than expected: %s", diff)
@@ -576,7 +582,7 @@ func TestGetKubeletConfigFileCustomKCShouldOverrideValuesPassedInKc(t *testing.T
 		PodMaxPids:            to.Int32Ptr(12345),
 		SeccompDefault:        to.BoolPtr(true),
 	}
-	configFileStr := GetKubeletConfigFileContent(kc, customKc)
+	configFileStr := GetKubeletConfigFileContent(kc, customKc, "")
 	diff := cmp.Diff(expectedKubeletJSON, configFileStr)
 	if diff != "" {
 		t.Errorf("Generated config file is different than expected: %s", diff)
@@ -587,7 +593,7 @@ func TestGetKubeletConfigFileNodeMemoryHardeningFields(t *testing.T) {
 	// Verifies AgentBaker renders the new Node Memory Hardening kubelet args
 	// (soft eviction + cgroup tiering) into the generated kubelet config file.
 	// Uses JSON unmarshaling rather than a brittle text snapshot so that future
-	// non-related additions to AKSKubeletConfiguration do not break this test.
+	// non-related additions to KubeletConfiguration do not break this test.
 	kc := getExampleKcWithNodeStatusReportFrequency()
 	kc["--eviction-soft"] = "memory.available<500Mi,nodefs.available<15%,imagefs.available<20%"
 	kc["--eviction-soft-grace-period"] = "memory.available=30s,nodefs.available=2m,imagefs.available=2m"
@@ -596,7 +602,7 @@ func TestGetKubeletConfigFileNodeMemoryHardeningFields(t *testing.T) {
 	kc["--kube-reserved-cgroup"] = "/kubereserved.slice"
 	kc["--system-reserved-cgroup"] = "/system.slice"
 
-	configFileStr := GetKubeletConfigFileContent(kc, nil)
+	configFileStr := GetKubeletConfigFileContent(kc, nil, "")
 
 	var got struct {
 		EvictionSoft              map[string]string `json:"evictionSoft"`
@@ -645,6 +651,20 @@ func TestGetKubeletConfigFileNodeMemoryHardeningFields(t *testing.T) {
 	}
 }
 
+func TestGetKubeletConfigFileContentClearsFieldsRemovedByKubernetesVersion(t *testing.T) {
+	kc := map[string]string{
+		"--max-pods":                          "110",
+		"--streaming-connection-idle-timeout": "4h",
+	}
+
+	if configFileStr := GetKubeletConfigFileContent(kc, nil, "1.33.0"); !strings.Contains(configFileStr, `"streamingConnectionIdleTimeout": "4h0m0s"`) {
+		t.Errorf("expected streamingConnectionIdleTimeout in the kubelet config of k8s 1.33, got:\n%s", configFileStr)
+	}
+	if configFileStr := GetKubeletConfigFileContent(kc, nil, "1.34.0"); strings.Contains(configFileStr, "streamingConnectionIdleTimeout") {
+		t.Errorf("expected streamingConnectionIdleTimeout to be cleared from the kubelet config of k8s 1.34, got:\n%s", configFileStr)
+	}
+}
+
 func TestSetNodeHardeningCgroupFlags(t *testing.T) {
 	// AgentBaker, not the RP, must own the cgroup slice names: it overwrites
 	// --kube-reserved-cgroup/--system-reserved-cgroup based solely on whether
@@ -724,7 +744,7 @@ func TestGetKubeletConfigFileNodeMemoryHardeningFieldsOmittedByDefault(t *testin
 	// VHD support window — non-hardened pools must see no change to these fields.
 	kc := getExampleKcWithNodeStatusReportFrequency()
 
-	configFileStr := GetKubeletConfigFileContent(kc, nil)
+	configFileStr := GetKubeletConfigFileContent(kc, nil, "")
 
 	for _, field := range []string{
 		`"evictionSoft"`,
@@ -748,7 +768,7 @@ func TestGetKubeletConfigFileFiltersUnknownEvictionSignals(t *testing.T) {
 	kc["--eviction-soft"] = "memory.available<500Mi,not-a-signal<1Gi"
 	kc["--eviction-soft-grace-period"] = "memory.available=30s,not-a-signal=1m"
 
-	configFileStr := GetKubeletConfigFileContent(kc, nil)
+	configFileStr := GetKubeletConfigFileContent(kc, nil, "")
 
 	var got struct {
 		EvictionHard            map[string]string `json:"evictionHard"`
@@ -1473,12 +1493,14 @@ func TestValidateAndSetNodeBootstrappingConfiguration_StreamingConnectionIdleTim
 				},
 			}
 
+			var err error
 			if tc.isWindows {
-				validateAndSetWindowsNodeBootstrappingConfiguration(config)
+				err = validateAndSetWindowsNodeBootstrappingConfiguration(config)
 			} else {
-				if err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config); err != nil {
-					t.Fatalf("unexpected validation error: %v", err)
-				}
+				err = ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+			}
+			if err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
 			}
 
 			_, exists := config.KubeletConfig["--streaming-connection-idle-timeout"]
@@ -1533,7 +1555,9 @@ func TestValidateAndSetNodeBootstrappingConfiguration_StreamingConnectionIdleTim
 			},
 		}
 
-		validateAndSetWindowsNodeBootstrappingConfiguration(config)
+		if err := validateAndSetWindowsNodeBootstrappingConfiguration(config); err != nil {
+			t.Fatalf("unexpected validation error: %v", err)
+		}
 
 		_, exists := config.KubeletConfig["--streaming-connection-idle-timeout"]
 		if exists {
@@ -1614,6 +1638,74 @@ func TestValidateAndSetNodeBootstrappingConfiguration_StreamingConnectionIdleTim
 	})
 }
 
+func TestValidateAndSetLinuxNodeBootstrappingConfiguration_KubeletFlagPolicy(t *testing.T) {
+	newConfig := func(version string, flags map[string]string) *datamodel.NodeBootstrappingConfiguration {
+		return &datamodel.NodeBootstrappingConfiguration{
+			ContainerService: &datamodel.ContainerService{
+				Properties: &datamodel.Properties{
+					OrchestratorProfile: &datamodel.OrchestratorProfile{
+						OrchestratorVersion: version,
+					},
+				},
+			},
+			KubeletConfig: flags,
+		}
+	}
+
+	t.Run("rejects flags removed by the kubernetes version", func(t *testing.T) {
+		config := newConfig("1.27.1", map[string]string{"--container-runtime": "remote"})
+		err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+		var invalid *kubeletpolicy.InvalidFlagsError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected an InvalidFlagsError, got %v", err)
+		}
+		if len(invalid.Violations) != 1 || invalid.Violations[0].Flag != "--container-runtime" {
+			t.Fatalf("expected --container-runtime to be rejected, got %v", invalid.Violations)
+		}
+	})
+
+	t.Run("accepts the same flags on older kubernetes versions", func(t *testing.T) {
+		config := newConfig("1.26.3", map[string]string{"--container-runtime": "remote"})
+		if err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config); err != nil {
+			t.Fatalf("unexpected validation error: %v", err)
+		}
+		if config.KubeletConfig["--container-runtime"] != "remote" {
+			t.Fatalf("expected --container-runtime to be kept for k8s 1.26")
+		}
+	})
+
+	t.Run("drops removed flags which are still sent by the RP", func(t *testing.T) {
+		config := newConfig("1.30.0", map[string]string{"--azure-container-registry-config": "/etc/kubernetes/azure.json"})
+		if err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config); err != nil {
+			t.Fatalf("unexpected validation error: %v", err)
+		}
+		if _, ok := config.KubeletConfig["--azure-container-registry-config"]; ok {
+			t.Fatalf("expected --azure-container-registry-config to be removed for k8s 1.30")
+		}
+	})
+
+	t.Run("rejects a kubelet config file the kubelet does not start with", func(t *testing.T) {
+		config := newConfig("1.33.0", map[string]string{"--image-gc-high-threshold": "85", "--image-gc-low-threshold": "80"})
+		config.AgentPoolProfile = &datamodel.AgentPoolProfile{
+			CustomKubeletConfig: &datamodel.CustomKubeletConfig{ImageGcLowThreshold: to.Int32Ptr(90)},
+		}
+		err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+		if err == nil || !strings.Contains(err.Error(), "imageGCLowThresholdPercent 90 must be less than imageGCHighThresholdPercent 85") {
+			t.Fatalf("expected the image GC thresholds to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("config file only flags can be translated to the kubelet config file", func(t *testing.T) {
+		for flag, fp := range kubeletpolicy.Embedded().Flags {
+			for _, rule := range fp.Rules {
+				if rule.State == kubeletpolicy.StateConfigFile && !TranslatedKubeletConfigFlags[flag] {
+					t.Fatalf("%s must be set through the kubelet config file from %s, but is not in TranslatedKubeletConfigFlags", flag, rule.Since)
+				}
+			}
+		}
+	})
+}
+
 func TestValidateAndSetLinuxNodeBootstrappingConfiguration_TransparentHugePageValues(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -1671,3 +1763,182 @@ func TestValidateAndSetLinuxNodeBootstrappingConfiguration_TransparentHugePageVa
 		})
 	}
 }
+
+func TestValidateAndSetLinuxNodeBootstrappingConfiguration_SysctlPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      *datamodel.CustomLinuxOSConfig
+		expectedErr string
+	}{
+		{
+			name: "accepts supported values",
+			config: &datamodel.CustomLinuxOSConfig{
+				Sysctls: &datamodel.SysctlConfig{
+					NetCoreSomaxconn:        to.Int32Ptr(65535),
+					NetIpv4TcpTwReuse:       to.BoolPtr(true),
+					NetIpv4IpLocalPortRange: "32768 65535",
+				},
+				UlimitConfig: &datamodel.UlimitConfig{MaxLockedMemory: "75000", NoFile: "1048"},
+			},
+		},
+		{
+			name: "rejects out of range sysctls",
+			config: &datamodel.CustomLinuxOSConfig{
+				Sysctls: &datamodel.SysctlConfig{VMSwappiness: to.Int32Ptr(101)},
+			},
+			expectedErr: "invalid vm.swappiness: 101 must be between 0 and 100",
+		},
+		{
+			name: "rejects invalid port ranges",
+			config: &datamodel.CustomLinuxOSConfig{
+				Sysctls: &datamodel.SysctlConfig{NetIpv4IpLocalPortRange: "32768 65535\nkernel.panic=1"},
+			},
+			expectedErr: "invalid net.ipv4.ip_local_port_range: error converting port range end value to int",
+		},
+		{
+			name: "rejects invalid ulimits",
+			config: &datamodel.CustomLinuxOSConfig{
+				UlimitConfig: &datamodel.UlimitConfig{NoFile: "unlimited"},
+			},
+			expectedErr: `invalid LimitNOFILE: "unlimited" is not an integer`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &datamodel.NodeBootstrappingConfiguration{
+				AgentPoolProfile: &datamodel.AgentPoolProfile{CustomLinuxOSConfig: tc.config},
+			}
+
+			err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected validation error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected validation error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAndSetLinuxNodeBootstrappingConfiguration_EnabledFeatures(t *testing.T) {
+	testCases := []struct {
+		name        string
+		features    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "accepts no features",
+		},
+		{
+			name:     "accepts registered features",
+			features: map[string]string{"ENABLE_PROVISIONING_HOTFIX": "true"},
+		},
+		{
+			name:        "rejects misspelled features",
+			features:    map[string]string{"ENABLE_PROVISIONING_HOTFX": "true"},
+			expectedErr: `unknown feature "ENABLE_PROVISIONING_HOTFX", did you mean ENABLE_PROVISIONING_HOTFIX?`,
+		},
+		{
+			name:        "rejects values of the wrong type",
+			features:    map[string]string{"ENABLE_PROVISIONING_HOTFIX": "1"},
+			expectedErr: `feature ENABLE_PROVISIONING_HOTFIX: value "1" must be true or false`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &datamodel.NodeBootstrappingConfiguration{
+				AgentPoolProfile: &datamodel.AgentPoolProfile{},
+				EnabledFeatures:  tc.features,
+			}
+
+			err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected validation error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected validation error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAndSetLinuxNodeBootstrappingConfiguration_LocalDNSProfile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		profile     *datamodel.LocalDNSProfile
+		expectedErr string
+	}{
+		{
+			name: "ignores overrides when localdns is disabled",
+			profile: &datamodel.LocalDNSProfile{
+				VnetDNSOverrides: map[string]*datamodel.LocalDNSOverrides{".": {ForwardPolicy: "Fastest"}},
+			},
+		},
+		{
+			name: "accepts supported overrides",
+			profile: &datamodel.LocalDNSProfile{
+				EnableLocalDNS: true,
+				VnetDNSOverrides: map[string]*datamodel.LocalDNSOverrides{
+					".":             {ForwardDestination: "VnetDNS", ForwardPolicy: "Sequential", ServeStale: "Verify"},
+					"cluster.local": {ForwardDestination: "ClusterCoreDNS", MaxConcurrent: to.Int32Ptr(1000)},
+				},
+				KubeDNSOverrides: map[string]*datamodel.LocalDNSOverrides{".": {ForwardDestination: "ClusterCoreDNS"}},
+				CriticalFQDNs:    []string{"mcr.microsoft.com"},
+			},
+		},
+		{
+			name: "rejects unknown forward policies",
+			profile: &datamodel.LocalDNSProfile{
+				EnableLocalDNS:   true,
+				KubeDNSOverrides: map[string]*datamodel.LocalDNSOverrides{".": {ForwardPolicy: "Fastest"}},
+			},
+			expectedErr: `KubeDNS override ".": forwardPolicy "Fastest" is invalid`,
+		},
+		{
+			name: "rejects overlapping domains",
+			profile: &datamodel.LocalDNSProfile{
+				EnableLocalDNS: true,
+				VnetDNSOverrides: map[string]*datamodel.LocalDNSOverrides{
+					"example.com":  {},
+					"example.com.": {},
+				},
+			},
+			expectedErr: `VnetDNS override "example.com.": overlaps with VnetDNS override "example.com"`,
+		},
+		{
+			name: "rejects invalid critical FQDNs",
+			profile: &datamodel.LocalDNSProfile{
+				EnableLocalDNS: true,
+				CriticalFQDNs:  []string{"mcr.microsoft.com,packages.aks.azure.com"},
+			},
+			expectedErr: `criticalFQDNs: domain "mcr.microsoft.com,packages.aks.azure.com" has invalid label`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &datamodel.NodeBootstrappingConfiguration{
+				AgentPoolProfile: &datamodel.AgentPoolProfile{LocalDNSProfile: tc.profile},
+			}
+
+			err := ValidateAndSetLinuxNodeBootstrappingConfigurationWithError(config)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected validation error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected validation error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
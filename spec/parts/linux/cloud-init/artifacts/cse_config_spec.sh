@@ -2301,6 +2301,59 @@ OVERRIDE_EOF
         End
     End
 
+    Describe 'configureMSIKubeconfig'
+        AfterEach 'cleanup_msi_kubeconfig'
+        cleanup_msi_kubeconfig() {
+            rm -f /var/lib/kubelet/kubeconfig /var/lib/kubelet/bootstrap-kubeconfig \
+                /etc/systemd/system/kubelet.service.d/10-tlsbootstrap.conf
+        }
+        tee() { echo "tee $1"; cat; }
+        BOOTSTRAPPING_AUTH_METHOD="azure_msi"
+        BOOTSTRAPPING_KUBECONFIG_CONTENT="$(echo "apiVersion: v1" | base64)"
+
+        It 'writes a bootstrap-kubeconfig when the node generates a CSR'
+            CLUSTER_JOIN_METHOD="generate_csr"
+            When call configureMSIKubeconfig
+            The output should include "using azure_msi to generate a bootstrap-kubeconfig"
+            The output should include "KUBELET_TLS_BOOTSTRAP_FLAGS=--kubeconfig /var/lib/kubelet/kubeconfig --bootstrap-kubeconfig /var/lib/kubelet/bootstrap-kubeconfig"
+            The contents of file "/var/lib/kubelet/bootstrap-kubeconfig" should equal "apiVersion: v1"
+            The status should be success
+        End
+
+        It 'writes the kubeconfig when the node keeps using the managed identity'
+            CLUSTER_JOIN_METHOD="use_bootstrapping_auth"
+            When call configureMSIKubeconfig
+            The output should include "using azure_msi to generate a kubeconfig"
+            The output should include 'KUBELET_TLS_BOOTSTRAP_FLAGS=--kubeconfig /var/lib/kubelet/kubeconfig"'
+            The contents of file "/var/lib/kubelet/kubeconfig" should equal "apiVersion: v1"
+            The status should be success
+        End
+
+        It 'fails without kubeconfig content'
+            BOOTSTRAPPING_KUBECONFIG_CONTENT=""
+            When run configureMSIKubeconfig
+            The output should include "BOOTSTRAPPING_KUBECONFIG_CONTENT must be set"
+            The status should equal 34
+        End
+    End
+
+    Describe 'configureContainerdRegistryMirrors'
+        AfterEach 'cleanup_registry_mirrors'
+        cleanup_registry_mirrors() {
+            rm -rf /etc/containerd/certs.d/docker.io /etc/containerd/certs.d/_default
+        }
+
+        It 'writes the hosts.toml file of each registry host'
+            docker_hosts="$(printf '[host."https://mirror.example.com"]\n  capabilities = ["pull", "resolve"]\n' | base64 -w 0)"
+            default_hosts="$(printf '[host."https://default.example.com"]\n  skip_verify = true\n' | base64 -w 0)"
+            CONTAINERD_REGISTRY_HOSTS_CONTENT="$(printf '_default %s\ndocker.io %s\n' "${default_hosts}" "${docker_hosts}" | base64 -w 0)"
+            When call configureContainerdRegistryMirrors
+            The status should be success
+            The contents of file "/etc/containerd/certs.d/docker.io/hosts.toml" should include 'host."https://mirror.example.com"'
+            The contents of file "/etc/containerd/certs.d/_default/hosts.toml" should include "skip_verify = true"
+        End
+    End
+
     Describe 'ensureKubelet credential provider installation gate'
         logs_to_events() {
             echo "logs_to_events $1 $2"